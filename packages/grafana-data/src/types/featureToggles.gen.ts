@@ -214,4 +214,6 @@ export interface FeatureToggles {
   groupAttributeSync?: boolean;
   improvedExternalSessionHandling?: boolean;
   useSessionStorageForRedirection?: boolean;
+  lokiBackendQuerySplitting?: boolean;
+  graphiteBackendMode?: boolean;
 }
@@ -29,6 +29,7 @@ var (
 	ScopeProvisionersDatasources   = ac.Scope("provisioners", "datasources")
 	ScopeProvisionersNotifications = ac.Scope("provisioners", "notifications")
 	ScopeProvisionersAlertRules    = ac.Scope("provisioners", "alerting")
+	ScopeProvisionersGit           = ac.Scope("provisioners", "git")
 )
 
 // declareFixedRoles declares to the AccessControl service fixed roles and their
@@ -7,6 +7,8 @@ import (
 
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
 )
 
 // swagger:route POST /admin/provisioning/dashboards/reload admin_provisioning adminProvisioningReloadDashboards
@@ -85,3 +87,79 @@ func (hs *HTTPServer) AdminProvisioningReloadAlerting(c *contextmodel.ReqContext
 	}
 	return response.Success("Alerting config reloaded")
 }
+
+// swagger:route POST /admin/provisioning/git/reload admin_provisioning adminProvisioningReloadGit
+//
+// Reload git provisioning repositories.
+//
+// Reads the git repositories config again, fetches the repositories and provisions the dashboards, datasources and alerting files of the repositories that point to a new commit. It won’t return until the new provisioned entities are already stored in the database. The status of the files is returned by the status endpoint.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `provisioning:reload` and scope `provisioners:git`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminProvisioningReloadGit(c *contextmodel.ReqContext) response.Response {
+	err := hs.ProvisioningService.ProvisionGit(c.Req.Context())
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return response.Error(http.StatusInternalServerError, "Failed to reload git repositories", err)
+	}
+	return response.Success("Git repositories reloaded")
+}
+
+// swagger:route GET /admin/provisioning/git/status admin_provisioning adminProvisioningGetGitStatus
+//
+// Get the status of git provisioning repositories.
+//
+// Returns the last sync of every git repository: the provisioned commit, the sync error if the repository could not be fetched or the commit could not be verified, and the status of every provisioning file of the commit.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `provisioning:reload` and scope `provisioners:git`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminProvisioningGitStatusResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+func (hs *HTTPServer) AdminProvisioningGetGitStatus(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.ProvisioningService.GetGitRepositoriesStatus())
+}
+
+// swagger:response adminProvisioningGitStatusResponse
+type AdminProvisioningGitStatusResponse struct {
+	// in:body
+	Body []git.RepositoryStatus `json:"body"`
+}
+
+// swagger:route GET /admin/provisioning/plan admin_provisioning adminProvisioningGetPlan
+//
+// Get the provisioning plan.
+//
+// Reads the provisioning config files of datasources, plugins, dashboards and alerting, and the current checkouts of the git repositories, and returns the resources that provisioning them would create, update or delete. Nothing is written to the database. Changes that overwrite or delete resources that were not provisioned from files are marked as provenance conflicts.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `provisioning:reload` and scope `provisioners:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminProvisioningPlanResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminProvisioningGetPlan(c *contextmodel.ReqContext) response.Response {
+	result, err := hs.ProvisioningService.Plan(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to plan provisioning", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:response adminProvisioningPlanResponse
+type AdminProvisioningPlanResponse struct {
+	// in:body
+	Body plan.Plan `json:"body"`
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web/webtest"
 )
@@ -165,3 +166,51 @@ func TestAPI_AdminProvisioningReload_AccessControl(t *testing.T) {
 		})
 	}
 }
+
+func TestAPI_AdminProvisioningPlan(t *testing.T) {
+	tests := []struct {
+		desc         string
+		expectedCode int
+		permissions  []accesscontrol.Permission
+	}{
+		{
+			desc:         "should work with broader scope",
+			expectedCode: http.StatusOK,
+			permissions:  []accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersAll}},
+		},
+		{
+			desc:         "should fail with specific scope",
+			expectedCode: http.StatusForbidden,
+			permissions:  []accesscontrol.Permission{{Action: ActionProvisioningReload, Scope: ScopeProvisionersDashboards}},
+		},
+		{
+			desc:         "should fail with no permission",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			pService := provisioning.NewProvisioningServiceMock(context.Background())
+			pService.PlanFunc = func(ctx context.Context) (*plan.Plan, error) {
+				return &plan.Plan{Changes: []plan.Change{{Resource: plan.ResourceDatasource, Action: plan.ActionDelete, OrgID: 1, Name: "old"}}}, nil
+			}
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.ProvisioningService = pService
+			})
+
+			res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/admin/provisioning/plan"), userWithPermissions(1, tt.permissions)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+
+			if tt.expectedCode == http.StatusOK {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"changes":[{"resource":"datasource","action":"delete","orgId":1,"name":"old"}]}`, string(body))
+				assert.Len(t, pService.Calls.Plan, 1)
+			}
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
@@ -566,6 +566,9 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Post("/provisioning/plugins/reload", authorize(ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersPlugins)), routing.Wrap(hs.AdminProvisioningReloadPlugins))
 		adminRoute.Post("/provisioning/datasources/reload", authorize(ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersDatasources)), routing.Wrap(hs.AdminProvisioningReloadDatasources))
 		adminRoute.Post("/provisioning/alerting/reload", authorize(ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersAlertRules)), routing.Wrap(hs.AdminProvisioningReloadAlerting))
+		adminRoute.Post("/provisioning/git/reload", authorize(ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersGit)), routing.Wrap(hs.AdminProvisioningReloadGit))
+		adminRoute.Get("/provisioning/git/status", authorize(ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersGit)), routing.Wrap(hs.AdminProvisioningGetGitStatus))
+		adminRoute.Get("/provisioning/plan", authorize(ac.EvalPermission(ActionProvisioningReload, ScopeProvisionersAll)), routing.Wrap(hs.AdminProvisioningGetPlan))
 	}, reqSignedIn)
 
 	// Administering users
@@ -291,6 +291,16 @@ func (proxy *DataSourceProxy) validateRequest() error {
 		}
 	}
 
+	// the other Graphite requests go through the resource API of the backend plugin
+	if proxy.ds.Type == datasources.DS_GRAPHITE && proxy.features.IsEnabled(proxy.ctx.Req.Context(), featuremgmt.FlagGraphiteBackendMode) {
+		if proxy.proxyPath != "render" {
+			return errors.New("only /render is allowed on proxied Graphite datasource")
+		}
+		if proxy.ctx.Req.Method != http.MethodGet && proxy.ctx.Req.Method != http.MethodPost {
+			return errors.New("only gets and posts are allowed on proxied Graphite datasource")
+		}
+	}
+
 	// found route if there are any
 	for _, route := range proxy.pluginRoutes {
 		// method match
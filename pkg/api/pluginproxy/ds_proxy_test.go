@@ -1071,6 +1071,32 @@ func Test_PathCheck(t *testing.T) {
 	require.Equal(t, routes[1], proxy.matchedRoute)
 }
 
+func TestDataSourceProxy_GraphiteBackendMode(t *testing.T) {
+	ds := &datasources.DataSource{Type: datasources.DS_GRAPHITE, URL: "http://graphite:8080"}
+	validate := func(method string, path string, features featuremgmt.FeatureToggles) error {
+		req, err := http.NewRequest(method, "http://localhost/"+path, nil)
+		require.NoError(t, err)
+		ctx := &contextmodel.ReqContext{
+			Context:      &web.Context{Req: req},
+			SignedInUser: &user.SignedInUser{OrgRole: org.RoleViewer},
+		}
+		proxy, err := setupDSProxyTest(t, ctx, ds, nil, path, func(proxy *DataSourceProxy) {
+			proxy.features = features
+		})
+		require.NoError(t, err)
+		return proxy.validateRequest()
+	}
+
+	enabled := featuremgmt.WithFeatures(featuremgmt.FlagGraphiteBackendMode)
+	require.NoError(t, validate(http.MethodPost, "render", enabled))
+	require.NoError(t, validate(http.MethodGet, "render", enabled))
+	require.Error(t, validate(http.MethodDelete, "render", enabled))
+	require.Error(t, validate(http.MethodGet, "metrics/find", enabled))
+	require.Error(t, validate(http.MethodPost, "tags/delSeries", enabled))
+
+	require.NoError(t, validate(http.MethodGet, "metrics/find", featuremgmt.WithFeatures()))
+}
+
 func setupDSProxyTest(t *testing.T, ctx *contextmodel.ReqContext, ds *datasources.DataSource, routes []*plugins.Route, path string, opts ...func(proxy *DataSourceProxy)) (*DataSourceProxy, error) {
 	t.Helper()
 
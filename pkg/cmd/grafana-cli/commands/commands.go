@@ -167,6 +167,24 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:  "provisioning",
+		Usage: "Runs provisioning commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "plan",
+				Usage:  "Shows the resources that provisioning would create, update or delete, without changing the database. Safe to execute multiple times.",
+				Action: runRunnerCommand(provisioningPlanCommand),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the plan as JSON",
+						Value: false,
+					},
+				},
+			},
+		},
+	},
 	{
 		Name:  "user-manager",
 		Usage: "Runs different helpful user commands",
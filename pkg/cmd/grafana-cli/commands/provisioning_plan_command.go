@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+)
+
+func provisioningPlanCommand(c utils.CommandLine, runner server.Runner) error {
+	result, err := runner.Provisioning.Plan(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to plan provisioning: %w", err)
+	}
+
+	if c.Bool("json") {
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.Info(string(b), "\n")
+		return nil
+	}
+
+	logger.Info(formatPlan(result))
+	return nil
+}
+
+func formatPlan(p *plan.Plan) string {
+	if len(p.Changes) == 0 {
+		return "No changes, the database matches the provisioning files.\n"
+	}
+
+	var sb strings.Builder
+	for _, c := range p.Changes {
+		var action string
+		switch c.Action {
+		case plan.ActionCreate:
+			action = color.GreenString("+ %s", c.Action)
+		case plan.ActionDelete, plan.ActionUnprovision:
+			action = color.RedString("- %s", c.Action)
+		default:
+			action = color.YellowString("~ %s", c.Action)
+		}
+		name := c.Name
+		if c.UID != "" {
+			name = fmt.Sprintf("%s (uid: %s)", name, c.UID)
+		}
+		fmt.Fprintf(&sb, "%s %s %s [org %d]", action, c.Resource, name, c.OrgID)
+		if len(c.Fields) > 0 {
+			fmt.Fprintf(&sb, " fields: %s", strings.Join(c.Fields, ", "))
+		}
+		if c.ProvenanceConflict {
+			provenance := c.Provenance
+			if provenance == "" {
+				provenance = "none"
+			}
+			sb.WriteString(color.RedString(" provenance conflict: %s", provenance))
+		}
+		if c.Source != "" {
+			fmt.Fprintf(&sb, "\n    from %s", c.Source)
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "\nPlan: %d to create, %d to update, %d to delete, %d to unprovision, %d provenance conflicts.\n",
+		p.Count(plan.ActionCreate), p.Count(plan.ActionUpdate), p.Count(plan.ActionDelete), p.Count(plan.ActionUnprovision), p.Conflicts())
+	return sb.String()
+}
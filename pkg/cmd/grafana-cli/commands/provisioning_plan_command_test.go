@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+)
+
+func TestFormatPlan(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	t.Run("empty plan", func(t *testing.T) {
+		assert.Equal(t, "No changes, the database matches the provisioning files.\n", formatPlan(&plan.Plan{}))
+	})
+
+	t.Run("plan with changes", func(t *testing.T) {
+		p := &plan.Plan{Changes: []plan.Change{
+			{Resource: plan.ResourceDatasource, Action: plan.ActionCreate, OrgID: 1, Name: "Prometheus", Source: "/etc/grafana/provisioning/datasources"},
+			{Resource: plan.ResourceAlertRule, Action: plan.ActionUpdate, OrgID: 1, Name: "High CPU", UID: "cpu", Fields: []string{"Data", "For"}, ProvenanceConflict: true},
+			{Resource: plan.ResourceDashboard, Action: plan.ActionDelete, OrgID: 2, Name: "Old"},
+		}}
+
+		expected := `+ create datasource Prometheus [org 1]
+    from /etc/grafana/provisioning/datasources
+~ update alert_rule High CPU (uid: cpu) [org 1] fields: Data, For provenance conflict: none
+- delete dashboard Old [org 2]
+
+Plan: 1 to create, 1 to update, 1 to delete, 0 to unprovision, 1 provenance conflicts.
+`
+		assert.Equal(t, expected, formatPlan(p))
+	})
+}
@@ -0,0 +1,354 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// AnomalyBandLabel is the label that tells the lower and upper bound series of the band apart.
+	AnomalyBandLabel = "anomaly_band"
+
+	defaultAnomalySensitivity = 3.0
+
+	// madScale makes the median absolute deviation comparable to the standard deviation for normally distributed data.
+	madScale = 1.4826
+
+	// minAnomalyBaseline is the number of points that are required to compute a band.
+	minAnomalyBaseline = 2
+)
+
+// +enum
+type AnomalyMethod string
+
+const (
+	// Mean and standard deviation of the series
+	AnomalyMethodZScore AnomalyMethod = "zscore"
+	// Median and median absolute deviation of the series, robust to outliers
+	AnomalyMethodMAD AnomalyMethod = "mad"
+	// Median and median absolute deviation of the points in the same hour of the week
+	AnomalyMethodSeasonal AnomalyMethod = "seasonal"
+)
+
+var supportedAnomalyMethods = []string{
+	string(AnomalyMethodZScore),
+	string(AnomalyMethodMAD),
+	string(AnomalyMethodSeasonal),
+}
+
+// AnomalyCommand is an expression that computes an expected band for each input series from its own history
+// and checks whether the last point of the series is outside of the band.
+// For each input series it returns a number that is 1 when the last point is anomalous, 0 when it is not and
+// no value when the series has no points to check, followed by the lower and upper bound of the band as series.
+type AnomalyCommand struct {
+	ReferenceVar string
+	RefID        string
+	Method       AnomalyMethod
+	Sensitivity  float64
+	// Window limits the baseline of each point to the points in the preceding window. Zero means the rest of the series.
+	Window time.Duration
+}
+
+// NewAnomalyCommand creates a new AnomalyCommand.
+func NewAnomalyCommand(refID, referenceVar string, method AnomalyMethod, sensitivity *float64, rawWindow string) (*AnomalyCommand, error) {
+	switch method {
+	case AnomalyMethodZScore, AnomalyMethodMAD, AnomalyMethodSeasonal:
+	default:
+		return nil, fmt.Errorf("expected anomaly method to be one of [%s], got %s", strings.Join(supportedAnomalyMethods, ", "), method)
+	}
+
+	s := defaultAnomalySensitivity
+	if sensitivity != nil {
+		s = *sensitivity
+	}
+	if s <= 0 || math.IsNaN(s) || math.IsInf(s, 0) {
+		return nil, fmt.Errorf("anomaly sensitivity must be a positive number, got %v", s)
+	}
+
+	var window time.Duration
+	if rawWindow != "" {
+		var err error
+		window, err = gtime.ParseDuration(rawWindow)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to parse anomaly "window" duration field %q: %w`, rawWindow, err)
+		}
+		if window <= 0 {
+			return nil, fmt.Errorf("anomaly window must be positive, got %s", rawWindow)
+		}
+	}
+
+	return &AnomalyCommand{
+		ReferenceVar: referenceVar,
+		RefID:        refID,
+		Method:       method,
+		Sensitivity:  s,
+		Window:       window,
+	}, nil
+}
+
+// UnmarshalAnomalyCommand creates an AnomalyCommand from Grafana's frontend query.
+func UnmarshalAnomalyCommand(rn *rawNode) (*AnomalyCommand, error) {
+	q := AnomalyQuery{}
+	if err := json.Unmarshal(rn.QueryRaw, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse the anomaly command: %w", err)
+	}
+	referenceVar, err := getReferenceVar(q.Expression, rn.RefID)
+	if err != nil {
+		return nil, err
+	}
+	return NewAnomalyCommand(rn.RefID, referenceVar, q.Method, q.Sensitivity, q.Window)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (ac *AnomalyCommand) NeedsVars() []string {
+	return []string{ac.ReferenceVar}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (ac *AnomalyCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.Vars, tracer tracing.Tracer) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteAnomaly")
+	defer span.End()
+
+	refVarResult := vars[ac.ReferenceVar]
+	newRes := mathexp.Results{Values: make(mathexp.Values, 0, len(refVarResult.Values))}
+	var bands mathexp.Values
+	for _, val := range refVarResult.Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			number, lower, upper := ac.detect(v)
+			newRes.Values = append(newRes.Values, number)
+			bands = append(bands, lower, upper)
+		case mathexp.NoData:
+			newRes.Values = append(newRes.Values, mathexp.NewNoData())
+		default:
+			return newRes, fmt.Errorf("can only detect anomalies in type series, got type %v", val.Type())
+		}
+	}
+	// the numbers come first so the result reads the same as a reduce expression
+	newRes.Values = append(newRes.Values, bands...)
+	return newRes, nil
+}
+
+func (ac *AnomalyCommand) Type() string {
+	return TypeAnomaly.String()
+}
+
+// anomalyBandMeta is the custom metadata of the lower and upper bound series of the band.
+type anomalyBandMeta struct {
+	Band string `json:"anomalyBand"`
+}
+
+// IsAnomalyBand returns true if the frame is the lower or upper bound of the band returned by an anomaly expression.
+// Such frames are there for visualization and are not part of the result of an alert rule condition.
+func IsAnomalyBand(frame *data.Frame) bool {
+	if frame.Meta == nil {
+		return false
+	}
+	_, ok := frame.Meta.Custom.(anomalyBandMeta)
+	return ok
+}
+
+func isAnomalyBandValue(v mathexp.Value) bool {
+	return IsAnomalyBand(v.AsDataFrame())
+}
+
+// withoutAnomalyBands returns the variables without the bands of anomaly expressions in the needed variables,
+// so that expressions that use the result of an anomaly expression only get the numbers.
+func withoutAnomalyBands(vars mathexp.Vars, needed []string) mathexp.Vars {
+	var filtered mathexp.Vars
+	for _, ref := range needed {
+		res, ok := vars[ref]
+		if !ok || !slices.ContainsFunc(res.Values, isAnomalyBandValue) {
+			continue
+		}
+		if filtered == nil {
+			filtered = maps.Clone(vars)
+		}
+		res.Values = slices.DeleteFunc(slices.Clone(res.Values), isAnomalyBandValue)
+		filtered[ref] = res
+	}
+	if filtered == nil {
+		return vars
+	}
+	return filtered
+}
+
+type anomalyPoint struct {
+	time  time.Time
+	value float64
+}
+
+// detect computes the band of the series and checks its last point.
+func (ac *AnomalyCommand) detect(s mathexp.Series) (mathexp.Number, mathexp.Series, mathexp.Series) {
+	labels := s.GetLabels()
+	number := mathexp.NewNumber(ac.RefID, labels)
+	lower := mathexp.NewSeries(ac.RefID, bandLabels(labels, "lower"), s.Len())
+	lower.SetMeta(anomalyBandMeta{Band: "lower"})
+	upper := mathexp.NewSeries(ac.RefID, bandLabels(labels, "upper"), s.Len())
+	upper.SetMeta(anomalyBandMeta{Band: "upper"})
+
+	points := make([]anomalyPoint, 0, s.Len())
+	for i := 0; i < s.Len(); i++ {
+		t, v := s.GetPoint(i)
+		if v != nil && !math.IsNaN(*v) {
+			points = append(points, anomalyPoint{time: t, value: *v})
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].time.Before(points[j].time) })
+
+	// the anomaly number is set from the last point with a value, and left empty when that point has no band
+	var last *time.Time
+	baseline := ac.baselineFunc(points)
+	for i := 0; i < s.Len(); i++ {
+		t, v := s.GetPoint(i)
+		hasValue := v != nil && !math.IsNaN(*v) && (last == nil || !t.Before(*last))
+		if hasValue {
+			last = &t
+			number.SetValue(nil)
+		}
+		center, spread, ok := baseline(t)
+		if !ok {
+			lower.SetPoint(i, t, nil)
+			upper.SetPoint(i, t, nil)
+			continue
+		}
+		lo, hi := center-ac.Sensitivity*spread, center+ac.Sensitivity*spread
+		lower.SetPoint(i, t, &lo)
+		upper.SetPoint(i, t, &hi)
+		if hasValue {
+			if *v < lo || *v > hi {
+				number.SetValue(util.Pointer(1.0))
+			} else {
+				number.SetValue(util.Pointer(0.0))
+			}
+		}
+	}
+	return number, lower, upper
+}
+
+// baselineFunc returns a function that computes the center and the spread of the expected values at a time.
+// The points must be sorted by time.
+func (ac *AnomalyCommand) baselineFunc(points []anomalyPoint) func(t time.Time) (float64, float64, bool) {
+	stats := func(values []float64) (float64, float64, bool) {
+		if len(values) < minAnomalyBaseline {
+			return 0, 0, false
+		}
+		if ac.Method == AnomalyMethodZScore {
+			m, sd := meanStdDev(values)
+			return m, sd, true
+		}
+		m, mad := medianMAD(values)
+		return m, mad * madScale, true
+	}
+
+	// the values in the window before t
+	window := func(points []anomalyPoint, t time.Time) []float64 {
+		from := sort.Search(len(points), func(i int) bool { return !points[i].time.Before(t.Add(-ac.Window)) })
+		to := sort.Search(len(points), func(i int) bool { return !points[i].time.Before(t) })
+		values := make([]float64, 0, to-from)
+		for _, p := range points[from:to] {
+			values = append(values, p.value)
+		}
+		return values
+	}
+
+	if ac.Method != AnomalyMethodSeasonal {
+		if ac.Window > 0 {
+			return func(t time.Time) (float64, float64, bool) {
+				return stats(window(points, t))
+			}
+		}
+		// all the other points, so that a point does not widen the band it is compared to
+		return func(t time.Time) (float64, float64, bool) {
+			return stats(except(points, t))
+		}
+	}
+
+	buckets := make(map[int][]anomalyPoint)
+	for _, p := range points {
+		b := hourOfWeek(p.time)
+		buckets[b] = append(buckets[b], p)
+	}
+	return func(t time.Time) (float64, float64, bool) {
+		bucket := buckets[hourOfWeek(t)]
+		if ac.Window > 0 {
+			return stats(window(bucket, t))
+		}
+		// the same hour in other weeks, without the point itself
+		return stats(except(bucket, t))
+	}
+}
+
+// except returns the values of the points that are not at time t.
+func except(points []anomalyPoint, t time.Time) []float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		if !p.time.Equal(t) {
+			values = append(values, p.value)
+		}
+	}
+	return values
+}
+
+func bandLabels(labels data.Labels, band string) data.Labels {
+	l := make(data.Labels, len(labels)+1)
+	for k, v := range labels {
+		l[k] = v
+	}
+	l[AnomalyBandLabel] = band
+	return l
+}
+
+// hourOfWeek returns the hour of the week of t in UTC, from 0 (Sunday 00:00) to 167.
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// meanStdDev returns the mean and the population standard deviation of the values.
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// medianMAD returns the median and the median absolute deviation of the values. The values are reordered.
+func medianMAD(values []float64) (float64, float64) {
+	m := median(values)
+	for i, v := range values {
+		values[i] = math.Abs(v - m)
+	}
+	return m, median(values)
+}
+
+func median(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
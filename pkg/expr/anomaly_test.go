@@ -0,0 +1,189 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestNewAnomalyCommand(t *testing.T) {
+	cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodMAD, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, defaultAnomalySensitivity, cmd.Sensitivity)
+	require.Equal(t, time.Duration(0), cmd.Window)
+	require.Equal(t, []string{"A"}, cmd.NeedsVars())
+
+	cmd, err = NewAnomalyCommand("B", "A", AnomalyMethodZScore, util.Pointer(2.5), "1d")
+	require.NoError(t, err)
+	require.Equal(t, 2.5, cmd.Sensitivity)
+	require.Equal(t, 24*time.Hour, cmd.Window)
+
+	_, err = NewAnomalyCommand("B", "A", "prophet", nil, "")
+	require.ErrorContains(t, err, "expected anomaly method to be one of")
+
+	_, err = NewAnomalyCommand("B", "A", AnomalyMethodZScore, util.Pointer(0.0), "")
+	require.ErrorContains(t, err, "sensitivity must be a positive number")
+
+	_, err = NewAnomalyCommand("B", "A", AnomalyMethodZScore, nil, "often")
+	require.Error(t, err)
+}
+
+func TestAnomalyCommandExecute(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := func(labels data.Labels, step time.Duration, values ...float64) mathexp.Series {
+		s := mathexp.NewSeries("A", labels, len(values))
+		for i, v := range values {
+			s.SetPoint(i, t0.Add(time.Duration(i)*step), util.Pointer(v))
+		}
+		return s
+	}
+	execute := func(t *testing.T, cmd *AnomalyCommand, values ...mathexp.Value) mathexp.Results {
+		t.Helper()
+		res, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{"A": mathexp.Results{Values: values}}, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("should return a number for each series followed by the band", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodZScore, util.Pointer(1.0), "")
+		require.NoError(t, err)
+
+		res := execute(t, cmd,
+			series(data.Labels{"host": "a"}, time.Minute, 1, 3, 1, 3, 2),
+			series(data.Labels{"host": "b"}, time.Minute, 1, 3, 1, 3, 10),
+		)
+		require.Len(t, res.Values, 6)
+
+		a := res.Values[0].(mathexp.Number)
+		require.Equal(t, data.Labels{"host": "a"}, a.GetLabels())
+		require.Equal(t, 0.0, *a.GetFloat64Value())
+		b := res.Values[1].(mathexp.Number)
+		require.Equal(t, data.Labels{"host": "b"}, b.GetLabels())
+		require.Equal(t, 1.0, *b.GetFloat64Value())
+
+		// each point is compared to the other points of the series, mean 2.25 and standard deviation 0.8292 for the
+		// first point of host a, mean 2 and standard deviation 1 for the last one
+		lower := res.Values[2].(mathexp.Series)
+		upper := res.Values[3].(mathexp.Series)
+		require.Equal(t, data.Labels{"host": "a", AnomalyBandLabel: "lower"}, lower.GetLabels())
+		require.Equal(t, data.Labels{"host": "a", AnomalyBandLabel: "upper"}, upper.GetLabels())
+		require.Equal(t, 5, lower.Len())
+		require.InDelta(t, 1.4208, *lower.GetValue(0), 0.0001)
+		require.InDelta(t, 3.0, *upper.GetValue(4), 0.0001)
+		require.True(t, IsAnomalyBand(lower.AsDataFrame()))
+		require.False(t, IsAnomalyBand(a.AsDataFrame()))
+		// only the anomaly expression marks bands, not the label
+		require.False(t, IsAnomalyBand(series(data.Labels{AnomalyBandLabel: "lower"}, time.Minute, 1, 2).AsDataFrame()))
+	})
+
+	t.Run("should ignore outliers with mad", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodMAD, nil, "")
+		require.NoError(t, err)
+
+		res := execute(t, cmd, series(nil, time.Minute, 10, 11, 9, 10, 1000, 10, 12))
+		require.Equal(t, 0.0, *res.Values[0].(mathexp.Number).GetFloat64Value())
+
+		// median 10.5 and median absolute deviation 1 of the other points for the first point
+		require.InDelta(t, 10.5-3*madScale, *res.Values[1].(mathexp.Series).GetValue(0), 0.0001)
+		require.InDelta(t, 10.5+3*madScale, *res.Values[2].(mathexp.Series).GetValue(0), 0.0001)
+	})
+
+	t.Run("should detect an outlier in a short series with the default sensitivity", func(t *testing.T) {
+		for _, method := range []AnomalyMethod{AnomalyMethodZScore, AnomalyMethodMAD} {
+			cmd, err := NewAnomalyCommand("B", "A", method, nil, "")
+			require.NoError(t, err)
+
+			res := execute(t, cmd, series(nil, time.Minute, 10, 10, 10, 10, 10, 100))
+			require.Equal(t, 1.0, *res.Values[0].(mathexp.Number).GetFloat64Value(), method)
+
+			res = execute(t, cmd, series(nil, time.Minute, 10, 12, 9, 11, 10, 11))
+			require.Equal(t, 0.0, *res.Values[0].(mathexp.Number).GetFloat64Value(), method)
+		}
+	})
+
+	t.Run("should only use the points in the preceding window", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodMAD, nil, "3m")
+		require.NoError(t, err)
+
+		res := execute(t, cmd, series(nil, time.Minute, 100, 100, 100, 1, 3, 2, 1, 2))
+		require.Equal(t, 0.0, *res.Values[0].(mathexp.Number).GetFloat64Value())
+
+		lower := res.Values[1].(mathexp.Series)
+		// not enough points before the first two points
+		require.Nil(t, lower.GetValue(0))
+		require.Nil(t, lower.GetValue(1))
+		require.NotNil(t, lower.GetValue(2))
+	})
+
+	t.Run("should compare the same hour of the week with seasonal", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodSeasonal, nil, "")
+		require.NoError(t, err)
+
+		// every 12 hours for 4 weeks, high at midnight and low at noon
+		values := make([]float64, 56)
+		for i := range values {
+			values[i] = 100
+			if i%2 == 1 {
+				values[i] = 10 + float64(i%3)
+			}
+		}
+		values[len(values)-1] = 100
+		res := execute(t, cmd, series(nil, 12*time.Hour, values...))
+		require.Equal(t, 1.0, *res.Values[0].(mathexp.Number).GetFloat64Value())
+
+		upper := res.Values[2].(mathexp.Series)
+		require.Less(t, *upper.GetValue(len(values) - 1), 20.0)
+		require.Greater(t, *upper.GetValue(len(values) - 2), 99.0)
+	})
+
+	t.Run("should return no value when the series has no points", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodZScore, nil, "")
+		require.NoError(t, err)
+
+		s := series(data.Labels{"host": "a"}, time.Minute, 1, 2)
+		s.SetPoint(1, t0.Add(time.Minute), nil)
+		res := execute(t, cmd, s, mathexp.NewNoData())
+		require.Len(t, res.Values, 4)
+		require.Nil(t, res.Values[0].(mathexp.Number).GetFloat64Value())
+		require.IsType(t, mathexp.NoData{}, res.Values[1])
+	})
+
+	t.Run("should not pass the band to downstream expressions", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodZScore, nil, "")
+		require.NoError(t, err)
+		vars := mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{series(data.Labels{"host": "a"}, time.Minute, 1, 3, 1, 3, 2)}},
+		}
+		vars["B"], err = cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.Len(t, vars["B"].Values, 3)
+
+		mathCmd, err := NewMathCommand("C", "$B + 1")
+		require.NoError(t, err)
+		node := &CMDNode{baseNode: baseNode{refID: "C"}, CMDType: TypeMath, Command: mathCmd}
+		res, err := node.Execute(context.Background(), time.Now(), vars, &Service{tracer: tracing.InitializeTracerForTest()})
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		require.Equal(t, 1.0, *res.Values[0].(mathexp.Number).GetFloat64Value())
+
+		// the result of the anomaly expression keeps the band
+		require.Len(t, vars["B"].Values, 3)
+	})
+
+	t.Run("should fail for numbers", func(t *testing.T) {
+		cmd, err := NewAnomalyCommand("B", "A", AnomalyMethodZScore, nil, "")
+		require.NoError(t, err)
+
+		_, err = cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{mathexp.NewNumber("A", nil)}},
+		}, tracing.InitializeTracerForTest())
+		require.ErrorContains(t, err, "can only detect anomalies in type series")
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"math"
 	"sort"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 )
 
@@ -15,8 +17,11 @@ func (cr reducer) ValidReduceFunc() bool {
 		return true
 	case "diff", "diff_abs", "percent_diff", "percent_diff_abs", "count_non_null":
 		return true
+	case "first", "range", "stddev", "variance":
+		return true
 	}
-	return false
+	_, ok := mathexp.ParsePercentile(mathexp.ReducerID(cr))
+	return ok
 }
 
 //nolint:gocyclo
@@ -144,6 +149,37 @@ func (cr reducer) Reduce(series mathexp.Series) mathexp.Number {
 		if value > 0 {
 			allNull = false
 		}
+	case "first":
+		for i := 0; i < ff.Len(); i++ {
+			f := ff.GetValue(i)
+			if !nilOrNaN(f) {
+				value = *f
+				allNull = false
+				break
+			}
+		}
+	case "range":
+		if values := nonNullValues(ff); values.Len() > 0 {
+			allNull = false
+			value = *mathexp.Range(values)
+		}
+	case "stddev":
+		if values := nonNullValues(ff); values.Len() > 0 {
+			allNull = false
+			value = *mathexp.StdDev(values)
+		}
+	case "variance":
+		if values := nonNullValues(ff); values.Len() > 0 {
+			allNull = false
+			value = *mathexp.Variance(values)
+		}
+	default:
+		if p, ok := mathexp.ParsePercentile(mathexp.ReducerID(cr)); ok {
+			if values := nonNullValues(ff); values.Len() > 0 {
+				allNull = false
+				value = *mathexp.Percentile(p)(values)
+			}
+		}
 	}
 
 	if allNull {
@@ -182,6 +218,20 @@ func calculateDiff(ff mathexp.Float64Field, allNull bool, value float64, fn func
 	return allNull, value
 }
 
+// nonNullValues returns a copy of the field without null and NaN values.
+func nonNullValues(ff mathexp.Float64Field) *mathexp.Float64Field {
+	values := make([]*float64, 0, ff.Len())
+	for i := 0; i < ff.Len(); i++ {
+		f := ff.GetValue(i)
+		if nilOrNaN(f) {
+			continue
+		}
+		values = append(values, f)
+	}
+	field := mathexp.Float64Field(*data.NewField("", nil, values))
+	return &field
+}
+
 func nilOrNaN(f *float64) bool {
 	return f == nil || math.IsNaN(*f)
 }
@@ -115,6 +115,54 @@ func TestReducer(t *testing.T) {
 			inputSeries:    newSeries(nil, nil),
 			expectedNumber: newNumber(nil),
 		},
+		{
+			name:           "first with null and NaN values",
+			reducer:        reducer("first"),
+			inputSeries:    newSeries(nil, util.Pointer(math.NaN()), util.Pointer(3.0), util.Pointer(4.0)),
+			expectedNumber: newNumber(util.Pointer(3.0)),
+		},
+		{
+			name:           "range",
+			reducer:        reducer("range"),
+			inputSeries:    newSeries(util.Pointer(3.0), nil, util.Pointer(-1.0), util.Pointer(4.0)),
+			expectedNumber: newNumber(util.Pointer(5.0)),
+		},
+		{
+			name:           "variance",
+			reducer:        reducer("variance"),
+			inputSeries:    newSeries(util.Pointer(2.0), util.Pointer(4.0), nil, util.Pointer(4.0), util.Pointer(4.0), util.Pointer(5.0), util.Pointer(5.0), util.Pointer(7.0), util.Pointer(9.0)),
+			expectedNumber: newNumber(util.Pointer(4.0)),
+		},
+		{
+			name:           "stddev",
+			reducer:        reducer("stddev"),
+			inputSeries:    newSeries(util.Pointer(2.0), util.Pointer(4.0), util.Pointer(4.0), util.Pointer(4.0), util.Pointer(5.0), util.Pointer(5.0), util.Pointer(7.0), util.Pointer(9.0), util.Pointer(math.NaN())),
+			expectedNumber: newNumber(util.Pointer(2.0)),
+		},
+		{
+			name:           "stddev with no values",
+			reducer:        reducer("stddev"),
+			inputSeries:    newSeries(nil, nil),
+			expectedNumber: newNumber(nil),
+		},
+		{
+			name:           "p50",
+			reducer:        reducer("p50"),
+			inputSeries:    newSeries(util.Pointer(4.0), nil, util.Pointer(1.0), util.Pointer(3.0), util.Pointer(2.0)),
+			expectedNumber: newNumber(util.Pointer(2.5)),
+		},
+		{
+			name:           "p90",
+			reducer:        reducer("p90"),
+			inputSeries:    newSeries(util.Pointer(10.0), util.Pointer(0.0), nil, util.Pointer(20.0)),
+			expectedNumber: newNumber(util.Pointer(18.0)),
+		},
+		{
+			name:           "p99 with no values",
+			reducer:        reducer("p99"),
+			inputSeries:    newSeries(nil, util.Pointer(math.NaN())),
+			expectedNumber: newNumber(nil),
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,6 +174,15 @@ func TestReducer(t *testing.T) {
 	}
 }
 
+func TestValidReduceFunc(t *testing.T) {
+	for _, r := range []reducer{"p1", "p99.9", "p100", "first", "range", "stddev", "variance"} {
+		require.Truef(t, r.ValidReduceFunc(), "expected %s to be valid", r)
+	}
+	for _, r := range []reducer{"p", "p0", "p101", "pNaN", "px", "stdev"} {
+		require.Falsef(t, r.ValidReduceFunc(), "expected %s to be invalid", r)
+	}
+}
+
 func TestDiffReducer(t *testing.T) {
 	var tests = []struct {
 		name           string
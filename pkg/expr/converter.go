@@ -229,8 +229,10 @@ func extractNumberSet(frame *data.Frame) ([]mathexp.Number, error) {
 				labels = make(data.Labels)
 			}
 			key := stringFieldNames[i] // TODO check for duplicate string column names
-			val, _ := frame.ConcreteAt(stringFieldIdxs[i], rowIdx)
-			labels[key] = val.(string) // TODO check assertion / return error
+			// null strings are left out of the labels
+			if val, ok := frame.ConcreteAt(stringFieldIdxs[i], rowIdx); ok {
+				labels[key] = val.(string)
+			}
 		}
 
 		n := mathexp.NewNumber(frame.Fields[numericField].Name, labels)
@@ -93,3 +93,21 @@ func makeUnexpectedNodeTypeError(refID, nodeType string) error {
 
 	return UnexpectedNodeTypeError.Build(data)
 }
+
+var sqlLimitErrStr = "SQL expression [{{ .Public.refId }}] exceeded a limit: {{ .Public.error }}"
+
+var SQLLimitError = errutil.BadRequest("sse.sqlLimitExceeded").MustTemplate(
+	sqlLimitErrStr,
+	errutil.WithPublic(sqlLimitErrStr))
+
+func makeSQLLimitError(refID string, err error) error {
+	data := errutil.TemplateData{
+		Public: map[string]any{
+			"refId": refID,
+			"error": err.Error(),
+		},
+		Error: err,
+	}
+
+	return SQLLimitError.Build(data)
+}
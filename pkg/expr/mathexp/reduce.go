@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
@@ -22,11 +24,48 @@ const (
 	ReducerCount  ReducerID = "count"
 	ReducerLast   ReducerID = "last"
 	ReducerMedian ReducerID = "median"
+	// 50th percentile
+	ReducerP50 ReducerID = "p50"
+	// 90th percentile
+	ReducerP90 ReducerID = "p90"
+	// 95th percentile
+	ReducerP95 ReducerID = "p95"
+	// 99th percentile
+	ReducerP99 ReducerID = "p99"
+	// Population standard deviation
+	ReducerStdDev ReducerID = "stddev"
+	// Population variance
+	ReducerVariance ReducerID = "variance"
+	// Difference between the max and min values
+	ReducerRange ReducerID = "range"
+	ReducerFirst ReducerID = "first"
+	// Number of values that are neither null nor NaN
+	ReducerCountNonNull ReducerID = "count_non_null"
+	// Difference between the last and first values
+	ReducerDiff ReducerID = "diff"
 )
 
 // GetSupportedReduceFuncs returns collection of supported function names
 func GetSupportedReduceFuncs() []ReducerID {
-	return []ReducerID{ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian}
+	return []ReducerID{
+		ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian,
+		ReducerP50, ReducerP90, ReducerP95, ReducerP99, ReducerStdDev, ReducerVariance, ReducerRange,
+		ReducerFirst, ReducerCountNonNull, ReducerDiff,
+	}
+}
+
+// ParsePercentile returns the percentile requested by a reducer of the form "pNN", e.g. "p99" or "p99.9".
+// The percentile must be greater than 0 and at most 100.
+func ParsePercentile(rFunc ReducerID) (float64, bool) {
+	s, ok := strings.CutPrefix(string(rFunc), "p")
+	if !ok || s == "" {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(p) || p <= 0 || p > 100 {
+		return 0, false
+	}
+	return p, true
 }
 
 func Sum(fv *Float64Field) *float64 {
@@ -126,6 +165,97 @@ func Median(fv *Float64Field) *float64 {
 	}
 }
 
+func First(fv *Float64Field) *float64 {
+	var f float64
+	if fv.Len() == 0 {
+		f = math.NaN()
+		return &f
+	}
+	return fv.GetValue(0)
+}
+
+func CountNonNull(fv *Float64Field) *float64 {
+	var f float64
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			continue
+		}
+		f++
+	}
+	return &f
+}
+
+func Diff(fv *Float64Field) *float64 {
+	first, last := First(fv), Last(fv)
+	if first == nil || last == nil {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *last - *first
+	return &f
+}
+
+func Range(fv *Float64Field) *float64 {
+	f := *Max(fv) - *Min(fv)
+	return &f
+}
+
+func Variance(fv *Float64Field) *float64 {
+	values, ok := floatValues(fv)
+	if !ok || len(values) == 0 {
+		nan := math.NaN()
+		return &nan
+	}
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var f float64
+	for _, v := range values {
+		f += (v - mean) * (v - mean)
+	}
+	f /= float64(len(values))
+	return &f
+}
+
+func StdDev(fv *Float64Field) *float64 {
+	f := math.Sqrt(*Variance(fv))
+	return &f
+}
+
+// Percentile returns a ReducerFunc that calculates the p-th percentile (0 < p <= 100)
+// of the values by linear interpolation between the closest ranks.
+func Percentile(p float64) ReducerFunc {
+	return func(fv *Float64Field) *float64 {
+		values, ok := floatValues(fv)
+		if !ok || len(values) == 0 {
+			nan := math.NaN()
+			return &nan
+		}
+		sort.Float64s(values)
+		rank := p / 100 * float64(len(values)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		f := values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+		return &f
+	}
+}
+
+// floatValues returns the values of the field. It returns false if any of the values is null or NaN.
+func floatValues(fv *Float64Field) ([]float64, bool) {
+	values := make([]float64, 0, fv.Len())
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return nil, false
+		}
+		values = append(values, *v)
+	}
+	return values, true
+}
+
 func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 	switch rFunc {
 	case ReducerSum:
@@ -142,7 +272,22 @@ func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 		return Last, nil
 	case ReducerMedian:
 		return Median, nil
+	case ReducerStdDev:
+		return StdDev, nil
+	case ReducerVariance:
+		return Variance, nil
+	case ReducerRange:
+		return Range, nil
+	case ReducerFirst:
+		return First, nil
+	case ReducerCountNonNull:
+		return CountNonNull, nil
+	case ReducerDiff:
+		return Diff, nil
 	default:
+		if p, ok := ParsePercentile(rFunc); ok {
+			return Percentile(p), nil
+		}
 		return nil, fmt.Errorf("reduction %v not implemented", rFunc)
 	}
 }
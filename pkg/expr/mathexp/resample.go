@@ -28,6 +28,8 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 	if newSeriesLength <= 0 {
 		return s, fmt.Errorf("the series cannot be sampled further; the time range is shorter than the interval")
 	}
+	// the reducer is resolved only when a bucket needs downsampling, so that upsampling works with any downsampler
+	var reduceFunc ReducerFunc
 	resampled := NewSeries(refID, s.GetLabels(), newSeriesLength+1)
 	bookmark := 0
 	var lastSeen *float64
@@ -72,24 +74,15 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 		} else if len(vals) == 1 {
 			value = vals[0]
 		} else { // downsampling
+			if reduceFunc == nil {
+				var err error
+				if reduceFunc, err = GetReduceFunc(downsampler); err != nil {
+					return s, fmt.Errorf("downsampling %v not implemented", downsampler)
+				}
+			}
 			fVec := data.NewField("", s.GetLabels(), vals)
 			ff := Float64Field(*fVec)
-			var tmp *float64
-			switch downsampler {
-			case ReducerSum:
-				tmp = Sum(&ff)
-			case ReducerMean:
-				tmp = Avg(&ff)
-			case ReducerMin:
-				tmp = Min(&ff)
-			case ReducerMax:
-				tmp = Max(&ff)
-			case ReducerLast:
-				tmp = Last(&ff)
-			default:
-				return s, fmt.Errorf("downsampling %v not implemented", downsampler)
-			}
-			value = tmp
+			value = reduceFunc(&ff)
 		}
 		resampled.SetPoint(idx, t, value)
 		t = t.Add(interval)
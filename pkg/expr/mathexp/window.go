@@ -0,0 +1,212 @@
+package mathexp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
+)
+
+// delta returns the difference between each value of the series and the previous non-null value.
+func delta(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "delta", varSet, func(s Series) Series {
+		return perPreviousPoint(e, s, func(_ float64, prev, cur float64) *float64 {
+			d := cur - prev
+			return &d
+		})
+	})
+}
+
+// increase returns the increase of a counter between each value of the series and the previous non-null value.
+// A decrease of the value is treated as a counter reset, in which case the increase is the value itself.
+func increase(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "increase", varSet, func(s Series) Series {
+		return perPreviousPoint(e, s, func(_ float64, prev, cur float64) *float64 {
+			d := counterIncrease(prev, cur)
+			return &d
+		})
+	})
+}
+
+// rate returns the per-second rate of increase of a counter between each value of the series and the previous non-null value.
+// Counter resets are handled the same way as in increase.
+func rate(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "rate", varSet, func(s Series) Series {
+		return perPreviousPoint(e, s, func(seconds float64, prev, cur float64) *float64 {
+			if seconds <= 0 {
+				return nil
+			}
+			r := counterIncrease(prev, cur) / seconds
+			return &r
+		})
+	})
+}
+
+// cumulativeSum returns the running total of the non-null values of the series.
+// Null values are kept as null and do not change the total.
+func cumulativeSum(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "cumulative_sum", varSet, func(s Series) Series {
+		newSeries := sortedSeriesCopy(e, s)
+		total := float64(0)
+		for i := 0; i < newSeries.Len(); i++ {
+			t, f := newSeries.GetPoint(i)
+			if f == nil {
+				continue
+			}
+			total += *f
+			nF := total
+			newSeries.SetPoint(i, t, &nF)
+		}
+		return newSeries
+	})
+}
+
+// movingAvg returns the average of the non-null values in the window of the last N points, including the current one.
+func movingAvg(e *State, varSet Results, windowSize Results) (Results, error) {
+	return perWindow(e, "moving_avg", varSet, windowSize, func(values []*float64) *float64 {
+		sum, count := sumNonNull(values)
+		if count == 0 {
+			return nil
+		}
+		avg := sum / float64(count)
+		return &avg
+	})
+}
+
+// movingSum returns the sum of the non-null values in the window of the last N points, including the current one.
+func movingSum(e *State, varSet Results, windowSize Results) (Results, error) {
+	return perWindow(e, "moving_sum", varSet, windowSize, func(values []*float64) *float64 {
+		sum, count := sumNonNull(values)
+		if count == 0 {
+			return nil
+		}
+		return &sum
+	})
+}
+
+// checkWindowSize makes sure that the window size argument is a positive integer constant.
+func checkWindowSize(_ *parse.Tree, f *parse.FuncNode) error {
+	n, ok := f.Args[1].(*parse.ScalarNode)
+	if !ok {
+		return fmt.Errorf("parse: window size of %s must be a number, got %v", f.Name, f.Args[1])
+	}
+	if n.Float64 < 1 || n.Float64 != math.Trunc(n.Float64) {
+		return fmt.Errorf("parse: window size of %s must be a positive integer, got %v", f.Name, n.Text)
+	}
+	return nil
+}
+
+// perSeries passes each Series of the result to seriesF. NoData is passed through,
+// all other types produce an error because windowed functions are only defined for time series.
+func perSeries(e *State, name string, varSet Results, seriesF func(s Series) Series) (Results, error) {
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		switch v := res.(type) {
+		case Series:
+			newRes.Values = append(newRes.Values, seriesF(v))
+		case NoData:
+			newRes.Values = append(newRes.Values, NewNoData())
+		default:
+			return newRes, fmt.Errorf("function %s expects time series as input, got %v", name, res.Type())
+		}
+	}
+	return newRes, nil
+}
+
+// perPreviousPoint calls pointF with each non-null value of the series, the previous non-null value and
+// the number of seconds between them. The first value of the series and null values produce null.
+func perPreviousPoint(e *State, s Series, pointF func(seconds float64, prev, cur float64) *float64) Series {
+	newSeries := sortedSeriesCopy(e, s)
+	var prev *float64
+	prevIdx := -1
+	for i := 0; i < newSeries.Len(); i++ {
+		t, f := newSeries.GetPoint(i)
+		if f == nil {
+			continue
+		}
+		cur := *f
+		var nF *float64
+		if prev != nil {
+			nF = pointF(t.Sub(newSeries.GetTime(prevIdx)).Seconds(), *prev, cur)
+		}
+		newSeries.SetPoint(i, t, nF)
+		prev = &cur
+		prevIdx = i
+	}
+	return newSeries
+}
+
+// perWindow calls windowF for each point of the series with the values of the last N points, including the current one.
+// If there are fewer than N points before the current one, the window contains all of them.
+func perWindow(e *State, name string, varSet Results, windowSize Results, windowF func(values []*float64) *float64) (Results, error) {
+	size, err := windowSizeFromResults(name, windowSize)
+	if err != nil {
+		return Results{}, err
+	}
+	return perSeries(e, name, varSet, func(s Series) Series {
+		newSeries := sortedSeriesCopy(e, s)
+		values := make([]*float64, newSeries.Len())
+		for i := 0; i < newSeries.Len(); i++ {
+			values[i] = newSeries.GetValue(i)
+		}
+		for i := range values {
+			start := i - size + 1
+			if start < 0 {
+				start = 0
+			}
+			newSeries.SetPoint(i, newSeries.GetTime(i), windowF(values[start:i+1]))
+		}
+		return newSeries
+	})
+}
+
+func windowSizeFromResults(name string, windowSize Results) (int, error) {
+	if len(windowSize.Values) != 1 {
+		return 0, fmt.Errorf("function %s expects a single window size", name)
+	}
+	scalar, ok := windowSize.Values[0].(Scalar)
+	if !ok {
+		return 0, fmt.Errorf("function %s expects window size to be a number, got %v", name, windowSize.Values[0].Type())
+	}
+	f := scalar.GetFloat64Value()
+	if f == nil || *f < 1 || *f != math.Trunc(*f) {
+		return 0, fmt.Errorf("function %s expects window size to be a positive integer", name)
+	}
+	return int(*f), nil
+}
+
+// sortedSeriesCopy returns a copy of the series that is sorted by time from oldest to newest.
+// The copy keeps the labels of the original series so that the result can be joined with other series.
+func sortedSeriesCopy(e *State, s Series) Series {
+	newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+	for i := 0; i < s.Len(); i++ {
+		t, f := s.GetPoint(i)
+		if f != nil {
+			v := *f
+			f = &v
+		}
+		newSeries.SetPoint(i, t, f)
+	}
+	newSeries.SortByTime(false)
+	return newSeries
+}
+
+func counterIncrease(prev, cur float64) float64 {
+	if cur < prev { // counter reset
+		return cur
+	}
+	return cur - prev
+}
+
+func sumNonNull(values []*float64) (float64, int) {
+	sum := float64(0)
+	count := 0
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		sum += *v
+		count++
+	}
+	return sum, count
+}
@@ -0,0 +1,236 @@
+package mathexp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestWindowFuncs(t *testing.T) {
+	counter := Vars{
+		"A": resultValuesNoErr(
+			makeSeries("", data.Labels{"host": "a"},
+				tp{time.Unix(10, 0), float64Pointer(2)},
+				tp{time.Unix(0, 0), float64Pointer(0)},
+				tp{time.Unix(20, 0), nil},
+				tp{time.Unix(30, 0), float64Pointer(8)},
+				tp{time.Unix(40, 0), float64Pointer(3)},
+			),
+		),
+	}
+
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name:      "delta on series",
+			expr:      "delta($A)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(10, 0), float64Pointer(2)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(6)},
+					tp{time.Unix(40, 0), float64Pointer(-5)},
+				),
+			),
+		},
+		{
+			name:      "increase on series handles counter reset",
+			expr:      "increase($A)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(10, 0), float64Pointer(2)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(6)},
+					tp{time.Unix(40, 0), float64Pointer(3)},
+				),
+			),
+		},
+		{
+			name:      "rate on series is per second",
+			expr:      "rate($A)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(10, 0), float64Pointer(0.2)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(0.3)},
+					tp{time.Unix(40, 0), float64Pointer(0.3)},
+				),
+			),
+		},
+		{
+			name:      "cumulative_sum on series skips nulls",
+			expr:      "cumulative_sum($A)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), float64Pointer(0)},
+					tp{time.Unix(10, 0), float64Pointer(2)},
+					tp{time.Unix(20, 0), nil},
+					tp{time.Unix(30, 0), float64Pointer(10)},
+					tp{time.Unix(40, 0), float64Pointer(13)},
+				),
+			),
+		},
+		{
+			name:      "moving_sum on series",
+			expr:      "moving_sum($A, 2)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), float64Pointer(0)},
+					tp{time.Unix(10, 0), float64Pointer(2)},
+					tp{time.Unix(20, 0), float64Pointer(2)},
+					tp{time.Unix(30, 0), float64Pointer(8)},
+					tp{time.Unix(40, 0), float64Pointer(11)},
+				),
+			),
+		},
+		{
+			name:      "moving_avg on series ignores nulls in the window",
+			expr:      "moving_avg($A, 3)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", data.Labels{"host": "a"},
+					tp{time.Unix(0, 0), float64Pointer(0)},
+					tp{time.Unix(10, 0), float64Pointer(1)},
+					tp{time.Unix(20, 0), float64Pointer(1)},
+					tp{time.Unix(30, 0), float64Pointer(5)},
+					tp{time.Unix(40, 0), float64Pointer(5.5)},
+				),
+			),
+		},
+		{
+			name: "moving_avg of only nulls is null",
+			expr: "moving_avg($A, 1)",
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), nil},
+						tp{time.Unix(10, 0), float64Pointer(math.Inf(1))},
+					),
+				),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(10, 0), float64Pointer(math.Inf(1))},
+				),
+			),
+		},
+		{
+			name:      "window functions pass no data through",
+			expr:      "rate($A)",
+			vars:      Vars{"A": resultValuesNoErr(NewNoData())},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(NewNoData()),
+		},
+		{
+			name:      "window functions fail on numbers",
+			expr:      "delta($A)",
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, float64Pointer(1)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name:     "window functions do not accept scalars",
+			expr:     "rate(1)",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "window size must be a positive integer",
+			expr:     "moving_avg($A, 1.5)",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "window size must be greater than zero",
+			expr:     "moving_sum($A, 0)",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "window size is required",
+			expr:     "moving_sum($A)",
+			newErrIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars, tracing.InitializeTracerForTest())
+				tt.execErrIs(t, err)
+				if tt.results.Values != nil {
+					require.Equal(t, tt.results, res)
+				}
+			}
+		})
+	}
+}
+
+func TestWindowFuncsUnion(t *testing.T) {
+	vars := Vars{
+		"A": resultValuesNoErr(
+			makeSeries("", data.Labels{"host": "a"},
+				tp{time.Unix(0, 0), float64Pointer(1)},
+				tp{time.Unix(10, 0), float64Pointer(3)},
+			),
+			makeSeries("", data.Labels{"host": "b"},
+				tp{time.Unix(0, 0), float64Pointer(10)},
+				tp{time.Unix(10, 0), float64Pointer(30)},
+			),
+		),
+	}
+
+	e, err := New("$A - moving_avg($A, 2)")
+	require.NoError(t, err)
+	res, err := e.Execute("", vars, tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+	require.Len(t, res.Values, 2)
+
+	expected := map[string][]float64{
+		"a": {0, 1},
+		"b": {0, 10},
+	}
+	for _, v := range res.Values {
+		s := v.(Series)
+		host := s.GetLabels()["host"]
+		require.Contains(t, expected, host)
+		require.Equal(t, 2, s.Len())
+		for i, f := range expected[host] {
+			require.NotNil(t, s.GetValue(i))
+			require.InDelta(t, f, *s.GetValue(i), 1e-9)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // label that is used when all mathexp.Series have 0 labels to make them identifiable by labels. The value of this label is extracted from value field names
@@ -103,10 +104,11 @@ func (gn *CMDNode) NeedsVars() []string {
 // other nodes they must have already been executed and their results must
 // already by in vars.
 func (gn *CMDNode) Execute(ctx context.Context, now time.Time, vars mathexp.Vars, s *Service) (mathexp.Results, error) {
-	return gn.Command.Execute(ctx, now, vars, s.tracer)
+	// the band of an anomaly expression is only returned for visualization
+	return gn.Command.Execute(ctx, now, withoutAnomalyBands(vars, gn.NeedsVars()), s.tracer)
 }
 
-func buildCMDNode(rn *rawNode, toggles featuremgmt.FeatureToggles) (*CMDNode, error) {
+func buildCMDNode(rn *rawNode, toggles featuremgmt.FeatureToggles, cfg *setting.Cfg) (*CMDNode, error) {
 	commandType, err := GetExpressionCommandType(rn.Query)
 	if err != nil {
 		return nil, fmt.Errorf("invalid command type in expression '%v': %w", rn.RefID, err)
@@ -142,6 +144,7 @@ func buildCMDNode(rn *rawNode, toggles featuremgmt.FeatureToggles) (*CMDNode, er
 			return nil, err
 		}
 		node.Command = q.Command
+		setSQLLimits(node, cfg)
 		return node, err
 	}
 
@@ -158,16 +161,26 @@ func buildCMDNode(rn *rawNode, toggles featuremgmt.FeatureToggles) (*CMDNode, er
 		node.Command, err = UnmarshalThresholdCommand(rn, toggles)
 	case TypeSQL:
 		node.Command, err = UnmarshalSQLCommand(rn)
+	case TypeAnomaly:
+		node.Command, err = UnmarshalAnomalyCommand(rn)
 	default:
 		return nil, fmt.Errorf("expression command type '%v' in expression '%v' not implemented", commandType, rn.RefID)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse expression '%v': %w", rn.RefID, err)
 	}
+	setSQLLimits(node, cfg)
 
 	return node, nil
 }
 
+// setSQLLimits applies the configured limits to SQL expressions.
+func setSQLLimits(node *CMDNode, cfg *setting.Cfg) {
+	if cmd, ok := node.Command.(*SQLCommand); ok && cfg != nil {
+		cmd.limits = sqlLimits(cfg)
+	}
+}
+
 const (
 	defaultIntervalMS = int64(64)
 	defaultMaxDP      = int64(5000)
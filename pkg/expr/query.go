@@ -27,8 +27,11 @@ const (
 	// Threshold
 	QueryTypeThreshold QueryType = "threshold"
 
-	// SQL query via DuckDB
+	// SQL query over the results of other queries
 	QueryTypeSQL QueryType = "sql"
+
+	// Statistical anomaly detection
+	QueryTypeAnomaly QueryType = "anomaly"
 )
 
 type MathQuery struct {
@@ -70,6 +73,20 @@ type ThresholdQuery struct {
 	Conditions []ThresholdConditionJSON `json:"conditions"`
 }
 
+type AnomalyQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// The method used to compute the band
+	Method AnomalyMethod `json:"method"`
+
+	// The number of standard deviations, or scaled median absolute deviations, between the center and the bounds of the band. Defaults to 3
+	Sensitivity *float64 `json:"sensitivity,omitempty" jsonschema:"example=3"`
+
+	// Only use the points in the preceding window to compute the band, the other points of the series are used when not set
+	Window string `json:"window,omitempty" jsonschema:"example=1d,example=4w"`
+}
+
 type ClassicQuery struct {
 	Conditions []classic.ConditionJSON `json:"conditions"`
 }
@@ -77,8 +94,23 @@ type ClassicQuery struct {
 // SQLQuery requires the sqlExpression feature flag
 type SQLExpression struct {
 	Expression string `json:"expression" jsonschema:"minLength=1,example=SELECT * FROM A LIMIT 1"`
+
+	// The format of the result, defaults to table
+	Format SQLFormat `json:"format,omitempty"`
 }
 
+// The format of the result of a SQL expression
+// +enum
+type SQLFormat string
+
+const (
+	// A single table
+	SQLFormatTable SQLFormat = "table"
+
+	// A number for each row, the string columns become the labels
+	SQLFormatNumbers SQLFormat = "numbers"
+)
+
 //-------------------------------
 // Non-query commands
 //-------------------------------
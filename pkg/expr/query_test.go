@@ -11,6 +11,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/expr/classic"
 	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 func TestQueryTypeDefinitions(t *testing.T) {
@@ -26,6 +27,7 @@ func TestQueryTypeDefinitions(t *testing.T) {
 				reflect.TypeOf(mathexp.UpsamplerPad), // pick an example value (not the root)
 				reflect.TypeOf(ReduceModeDrop),       // pick an example value (not the root)
 				reflect.TypeOf(ThresholdIsAbove),
+				reflect.TypeOf(AnomalyMethodZScore),
 				reflect.TypeOf(classic.ConditionOperatorAnd),
 			},
 		})
@@ -161,6 +163,28 @@ func TestQueryTypeDefinitions(t *testing.T) {
 				},
 			},
 		},
+		schemabuilder.QueryTypeInfo{
+			Discriminators: data.NewDiscriminators("type", QueryTypeAnomaly),
+			GoType:         reflect.TypeOf(&AnomalyQuery{}),
+			Examples: []data.QueryExample{
+				{
+					Name: "Robust band over the last day",
+					SaveModel: data.AsUnstructured(AnomalyQuery{
+						Expression: "$A",
+						Method:     AnomalyMethodMAD,
+						Window:     "1d",
+					}),
+				},
+				{
+					Name: "Compare with the same hour in previous weeks",
+					SaveModel: data.AsUnstructured(AnomalyQuery{
+						Expression:  "$A",
+						Method:      AnomalyMethodSeasonal,
+						Sensitivity: util.Pointer(4.0),
+					}),
+				},
+			},
+		},
 	)
 
 	require.NoError(t, err)
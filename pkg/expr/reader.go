@@ -128,7 +128,7 @@ func (h *ExpressionQueryReader) ReadQuery(
 		err = iter.ReadVal(q)
 		if err == nil {
 			eq.Properties = q
-			eq.Command, err = NewSQLCommand(common.RefID, q.Expression)
+			eq.Command, err = NewSQLCommand(common.RefID, q.Expression, q.Format)
 		}
 
 	case QueryTypeThreshold:
@@ -171,6 +171,17 @@ func (h *ExpressionQueryReader) ReadQuery(
 			}
 		}
 
+	case QueryTypeAnomaly:
+		q := &AnomalyQuery{}
+		err = iter.ReadVal(q)
+		if err == nil {
+			referenceVar, err = getReferenceVar(q.Expression, common.RefID)
+		}
+		if err == nil {
+			eq.Properties = q
+			eq.Command, err = NewAnomalyCommand(common.RefID, referenceVar, q.Method, q.Sensitivity, q.Window)
+		}
+
 	default:
 		err = fmt.Errorf("unknown query type (%s)", common.QueryType)
 	}
@@ -0,0 +1,406 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// accumulator computes the result of an aggregate over a set of non-NULL values.
+type accumulator interface {
+	add(v any) error
+	// result must not change the state of the accumulator, so that it can be used for running aggregates.
+	result() any
+}
+
+type aggregateFunc struct {
+	// args is the number of arguments, the second argument must be a constant.
+	minArgs, maxArgs int
+	returns          func(arg Type) Type
+	// newAccumulator creates an accumulator. param is the value of the constant second argument.
+	newAccumulator func(param any) (accumulator, error)
+}
+
+var aggregateFuncs map[string]aggregateFunc
+
+func init() {
+	float := func(Type) Type { return TypeFloat }
+	same := func(t Type) Type { return t }
+	aggregateFuncs = map[string]aggregateFunc{
+		"count": {minArgs: 1, maxArgs: 1, returns: func(Type) Type { return TypeInt }, newAccumulator: func(any) (accumulator, error) {
+			return &countAcc{}, nil
+		}},
+		"sum": {minArgs: 1, maxArgs: 1, returns: func(t Type) Type {
+			if t == TypeInt {
+				return TypeInt
+			}
+			return TypeFloat
+		}, newAccumulator: func(any) (accumulator, error) { return &sumAcc{}, nil }},
+		"avg":           {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return s.mean() })},
+		"mean":          {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return s.mean() })},
+		"stddev":        {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return sqrt(s.variance(1)) })},
+		"stddev_samp":   {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return sqrt(s.variance(1)) })},
+		"stddev_pop":    {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return sqrt(s.variance(0)) })},
+		"variance":      {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return s.variance(1) })},
+		"var_samp":      {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return s.variance(1) })},
+		"var_pop":       {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newStatsAcc(func(s *statsAcc) any { return s.variance(0) })},
+		"min":           {minArgs: 1, maxArgs: 1, returns: same, newAccumulator: func(any) (accumulator, error) { return &extremeAcc{dir: -1}, nil }},
+		"max":           {minArgs: 1, maxArgs: 1, returns: same, newAccumulator: func(any) (accumulator, error) { return &extremeAcc{dir: 1}, nil }},
+		"first":         {minArgs: 1, maxArgs: 1, returns: same, newAccumulator: func(any) (accumulator, error) { return &firstAcc{}, nil }},
+		"any_value":     {minArgs: 1, maxArgs: 1, returns: same, newAccumulator: func(any) (accumulator, error) { return &firstAcc{}, nil }},
+		"last":          {minArgs: 1, maxArgs: 1, returns: same, newAccumulator: func(any) (accumulator, error) { return &lastAcc{}, nil }},
+		"median":        {minArgs: 1, maxArgs: 1, returns: float, newAccumulator: newQuantileAcc},
+		"quantile_cont": {minArgs: 2, maxArgs: 2, returns: float, newAccumulator: newQuantileAcc},
+		"quantile":      {minArgs: 2, maxArgs: 2, returns: float, newAccumulator: newQuantileAcc},
+		"string_agg": {minArgs: 1, maxArgs: 2, returns: func(Type) Type { return TypeString }, newAccumulator: func(sep any) (accumulator, error) {
+			if sep == nil {
+				sep = ","
+			}
+			return &stringAggAcc{sep: formatValue(sep)}, nil
+		}},
+		"bool_and": {minArgs: 1, maxArgs: 1, returns: func(Type) Type { return TypeBool }, newAccumulator: func(any) (accumulator, error) {
+			return &boolAcc{and: true}, nil
+		}},
+		"bool_or": {minArgs: 1, maxArgs: 1, returns: func(Type) Type { return TypeBool }, newAccumulator: func(any) (accumulator, error) {
+			return &boolAcc{}, nil
+		}},
+	}
+	aggregateFuncs["group_concat"] = aggregateFuncs["string_agg"]
+}
+
+func isAggregate(name string) bool {
+	_, ok := aggregateFuncs[name]
+	return ok
+}
+
+// aggregate is an aggregate function call of a query.
+type aggregate struct {
+	fn       aggregateFunc
+	name     string
+	arg      *compiled
+	param    any
+	star     bool
+	distinct bool
+	filter   *compiled
+	typ      Type
+}
+
+func (s *scope) compileAggregate(f *FuncCall) (*aggregate, error) {
+	s.inAggregate = true
+	defer func() { s.inAggregate = false }()
+	return s.aggregateCall(f)
+}
+
+// aggregateCall compiles the arguments of an aggregate, which is either computed over the rows of
+// a group or over the frame of a window function.
+func (s *scope) aggregateCall(f *FuncCall) (*aggregate, error) {
+	fn := aggregateFuncs[f.Name]
+	agg := &aggregate{fn: fn, name: f.Name, star: f.Star, distinct: f.Distinct}
+	if f.Star {
+		if f.Name != "count" {
+			return nil, fmt.Errorf("%s(*) is not supported", f.Name)
+		}
+		agg.typ = TypeInt
+	} else {
+		if len(f.Args) < fn.minArgs || len(f.Args) > fn.maxArgs {
+			return nil, fmt.Errorf("wrong number of arguments to aggregate %s", f.Name)
+		}
+	}
+
+	if len(f.Args) > 0 {
+		arg, err := s.compile(f.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		agg.arg = arg
+		agg.typ = fn.returns(arg.typ)
+	}
+	if len(f.Args) > 1 {
+		// the parameter must be a constant, e.g. the quantile or the separator
+		param, err := newScope(s.ex, &relation{}, s.ctes).compile(f.Args[1])
+		if err != nil {
+			return nil, fmt.Errorf("the second argument of %s must be a constant", f.Name)
+		}
+		v, err := param.fn(&row{})
+		if err != nil {
+			return nil, fmt.Errorf("the second argument of %s must be a constant", f.Name)
+		}
+		agg.param = v
+	}
+	if f.Filter != nil {
+		filter, err := s.compile(f.Filter)
+		if err != nil {
+			return nil, err
+		}
+		agg.filter = filter
+	}
+	// validate the parameter
+	if _, err := fn.newAccumulator(agg.param); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return agg, nil
+}
+
+// compute returns the aggregate over all rows.
+func (a *aggregate) compute(ex *executor, rows [][]any) (any, error) {
+	acc, err := a.fn.newAccumulator(a.param)
+	if err != nil {
+		return nil, err
+	}
+	var seen map[string]bool
+	if a.distinct {
+		seen = map[string]bool{}
+	}
+	r := &row{}
+	for _, vals := range rows {
+		if err := ex.tick(); err != nil {
+			return nil, err
+		}
+		r.vals = vals
+		if err := a.add(acc, r, seen); err != nil {
+			return nil, err
+		}
+	}
+	return acc.result(), nil
+}
+
+// add adds the value of the row to the accumulator, unless it is filtered out.
+func (a *aggregate) add(acc accumulator, r *row, seen map[string]bool) error {
+	if a.filter != nil {
+		ok, err := evalBool(a.filter, r)
+		if err != nil || !ok {
+			return err
+		}
+	}
+	if a.star {
+		return acc.add(true)
+	}
+	v, err := a.arg.fn(r)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if seen != nil {
+		key := string(appendKey(nil, v))
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+	}
+	if err := acc.add(v); err != nil {
+		return fmt.Errorf("%s: %w", a.name, err)
+	}
+	return nil
+}
+
+type countAcc struct{ n int64 }
+
+func (c *countAcc) add(any) error { c.n++; return nil }
+func (c *countAcc) result() any   { return c.n }
+
+type sumAcc struct {
+	i       int64
+	f       float64
+	isFloat bool
+	any     bool
+}
+
+func (s *sumAcc) add(v any) error {
+	s.any = true
+	if i, ok := v.(int64); ok && !s.isFloat {
+		s.i += i
+		return nil
+	}
+	f, err := floatArg(v)
+	if err != nil {
+		return err
+	}
+	if !s.isFloat {
+		s.isFloat = true
+		s.f = float64(s.i)
+	}
+	s.f += f
+	return nil
+}
+
+func (s *sumAcc) result() any {
+	switch {
+	case !s.any:
+		return nil
+	case s.isFloat:
+		return s.f
+	}
+	return s.i
+}
+
+// statsAcc computes the mean and variance with Welford's algorithm.
+type statsAcc struct {
+	n      int64
+	avg    float64
+	m2     float64
+	finish func(s *statsAcc) any
+}
+
+func newStatsAcc(finish func(s *statsAcc) any) func(any) (accumulator, error) {
+	return func(any) (accumulator, error) {
+		return &statsAcc{finish: finish}, nil
+	}
+}
+
+func (s *statsAcc) add(v any) error {
+	f, err := floatArg(v)
+	if err != nil {
+		return err
+	}
+	s.n++
+	d := f - s.avg
+	s.avg += d / float64(s.n)
+	s.m2 += d * (f - s.avg)
+	return nil
+}
+
+func (s *statsAcc) result() any { return s.finish(s) }
+
+func (s *statsAcc) mean() any {
+	if s.n == 0 {
+		return nil
+	}
+	return s.avg
+}
+
+// variance returns the variance, with ddof 1 for the sample and 0 for the population variance.
+func (s *statsAcc) variance(ddof int64) any {
+	if s.n-ddof <= 0 {
+		return nil
+	}
+	return s.m2 / float64(s.n-ddof)
+}
+
+func sqrt(v any) any {
+	if v == nil {
+		return nil
+	}
+	return math.Sqrt(v.(float64))
+}
+
+type extremeAcc struct {
+	dir int
+	v   any
+}
+
+func (e *extremeAcc) add(v any) error {
+	if e.v == nil {
+		e.v = v
+		return nil
+	}
+	c, err := compareValues(v, e.v)
+	if err != nil {
+		return err
+	}
+	if c*e.dir > 0 {
+		e.v = v
+	}
+	return nil
+}
+
+func (e *extremeAcc) result() any { return e.v }
+
+type firstAcc struct{ v any }
+
+func (f *firstAcc) add(v any) error {
+	if f.v == nil {
+		f.v = v
+	}
+	return nil
+}
+
+func (f *firstAcc) result() any { return f.v }
+
+type lastAcc struct{ v any }
+
+func (l *lastAcc) add(v any) error { l.v = v; return nil }
+func (l *lastAcc) result() any     { return l.v }
+
+// quantileAcc computes a quantile with linear interpolation between the closest ranks.
+type quantileAcc struct {
+	q      float64
+	values []float64
+}
+
+func newQuantileAcc(param any) (accumulator, error) {
+	q := 0.5
+	if param != nil {
+		f, err := floatArg(param)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("the quantile must be between 0 and 1")
+		}
+		q = f
+	}
+	return &quantileAcc{q: q}, nil
+}
+
+func (a *quantileAcc) add(v any) error {
+	f, err := floatArg(v)
+	if err != nil {
+		return err
+	}
+	a.values = append(a.values, f)
+	return nil
+}
+
+func (a *quantileAcc) result() any {
+	if len(a.values) == 0 {
+		return nil
+	}
+	values := make([]float64, len(a.values))
+	copy(values, a.values)
+	sort.Float64s(values)
+	rank := a.q * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
+
+type stringAggAcc struct {
+	sep    string
+	values []string
+}
+
+func (s *stringAggAcc) add(v any) error {
+	s.values = append(s.values, formatValue(v))
+	return nil
+}
+
+func (s *stringAggAcc) result() any {
+	if len(s.values) == 0 {
+		return nil
+	}
+	return strings.Join(s.values, s.sep)
+}
+
+type boolAcc struct {
+	and bool
+	v   any
+}
+
+func (b *boolAcc) add(v any) error {
+	bv, err := convert(v, TypeBool)
+	if err != nil {
+		return err
+	}
+	if b.v == nil {
+		b.v = bv
+		return nil
+	}
+	if b.and {
+		b.v = b.v.(bool) && bv.(bool)
+	} else {
+		b.v = b.v.(bool) || bv.(bool)
+	}
+	return nil
+}
+
+func (b *boolAcc) result() any { return b.v }
@@ -0,0 +1,133 @@
+package sql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregates(t *testing.T) {
+	// the values of A are 1, 3, 10, NULL and 20
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		{expr: "count(*)", expected: int64(5)},
+		{expr: "count(value)", expected: int64(4)},
+		{expr: "count(1)", expected: int64(5)},
+		{expr: "count(DISTINCT host)", expected: int64(2)},
+		{expr: "sum(value)", expected: 34.0},
+		{expr: "sum(DISTINCT value > 2)", expected: 1.0},
+		{expr: "avg(value)", expected: 8.5},
+		{expr: "mean(value)", expected: 8.5},
+		{expr: "variance(value)", expected: 221.0 / 3},
+		{expr: "var_samp(value)", expected: 221.0 / 3},
+		{expr: "var_pop(value)", expected: 55.25},
+		{expr: "stddev(value)", expected: math.Sqrt(221.0 / 3)},
+		{expr: "stddev_samp(value)", expected: math.Sqrt(221.0 / 3)},
+		{expr: "stddev_pop(value)", expected: math.Sqrt(55.25)},
+		{expr: "min(value)", expected: 1.0},
+		{expr: "max(value)", expected: 20.0},
+		{expr: "min(host)", expected: "a"},
+		{expr: "max(time)", expected: time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)},
+		{expr: "first(value)", expected: 1.0},
+		{expr: "any_value(host)", expected: "a"},
+		{expr: "last(value)", expected: 20.0},
+		{expr: "median(value)", expected: 6.5},
+		{expr: "quantile_cont(value, 0.25)", expected: 2.5},
+		{expr: "quantile(value, 0)", expected: 1.0},
+		{expr: "quantile(value, 1)", expected: 20.0},
+		{expr: "quantile(value, 0.5 + 0.25)", expected: 12.5},
+		{expr: "string_agg(host)", expected: "a,a,b,b,b"},
+		{expr: "string_agg(DISTINCT host, '|')", expected: "a|b"},
+		{expr: "group_concat(value, ' ')", expected: "1 3 10 20"},
+		{expr: "bool_and(value > 2)", expected: false},
+		{expr: "bool_or(value > 2)", expected: true},
+		{expr: "bool_and(value > 0)", expected: true},
+		{expr: "count(*) FILTER (WHERE host = 'a')", expected: int64(2)},
+		{expr: "sum(value) FILTER (WHERE value > 2)", expected: 33.0},
+		{expr: "max(value) FILTER (WHERE value IS NULL)", expected: nil},
+		{expr: "sum(value) + count(*)", expected: 39.0},
+		{expr: "round(avg(value))", expected: 9.0},
+		{expr: "sum(value * 2)", expected: 68.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			res := execute(t, "SELECT "+tt.expr+" FROM A")
+			require.Len(t, res.Rows, 1)
+			if f, ok := tt.expected.(float64); ok {
+				require.InDelta(t, f, res.Rows[0][0], 1e-9)
+				return
+			}
+			require.Equal(t, tt.expected, res.Rows[0][0])
+		})
+	}
+}
+
+func TestAggregatesOfIntegers(t *testing.T) {
+	testQueries(t, []queryTest{
+		{name: "sum of integers is an integer", query: "SELECT sum(weight) FROM B", rows: [][]any{{int64(6)}}},
+		{name: "sum of mixed numbers is a float", query: "SELECT sum(x) FROM (SELECT 1 AS x UNION ALL SELECT 1.5)", rows: [][]any{{2.5}}},
+		{name: "avg of integers is a float", query: "SELECT avg(weight) FROM B", rows: [][]any{{2.0}}},
+		{name: "min and max keep the type", query: "SELECT min(weight), max(weight) FROM B", rows: [][]any{{int64(1), int64(3)}}},
+		{name: "distinct sum", query: "SELECT sum(DISTINCT weight % 2) FROM B", rows: [][]any{{int64(1)}}},
+	})
+}
+
+func TestAggregatesWithoutValues(t *testing.T) {
+	for _, expr := range []string{
+		"sum(value)", "avg(value)", "variance(value)", "stddev_pop(value)", "min(value)", "max(value)",
+		"first(value)", "last(value)", "median(value)", "string_agg(value)", "bool_and(value > 1)",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			res := execute(t, "SELECT count(*), "+expr+" FROM A WHERE value IS NULL")
+			require.Equal(t, [][]any{{int64(1), nil}}, res.Rows)
+		})
+	}
+
+	// the sample variance needs two values
+	res := execute(t, "SELECT var_pop(value), var_samp(value), stddev(value) FROM A WHERE value = 1")
+	require.Equal(t, [][]any{{0.0, nil, nil}}, res.Rows)
+}
+
+func TestAggregateTypes(t *testing.T) {
+	tests := []struct {
+		expr string
+		typ  Type
+	}{
+		{expr: "count(host)", typ: TypeInt},
+		{expr: "sum(weight)", typ: TypeInt},
+		{expr: "sum(weight * 1.5)", typ: TypeFloat},
+		{expr: "avg(weight)", typ: TypeFloat},
+		{expr: "min(host)", typ: TypeString},
+		{expr: "max(weight)", typ: TypeInt},
+		{expr: "median(weight)", typ: TypeFloat},
+		{expr: "string_agg(weight)", typ: TypeString},
+		{expr: "bool_or(weight > 1)", typ: TypeBool},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			res := execute(t, "SELECT "+tt.expr+" FROM B")
+			require.Equal(t, tt.typ, res.Columns[0].Type)
+		})
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	testQueryErrors(t, []errorTest{
+		{name: "star", query: "SELECT sum(*) FROM A", err: "sum(*) is not supported"},
+		{name: "no arguments", query: "SELECT count() FROM A", err: "wrong number of arguments to aggregate count"},
+		{name: "too many arguments", query: "SELECT sum(value, 1) FROM A", err: "wrong number of arguments to aggregate sum"},
+		{name: "missing quantile", query: "SELECT quantile(value) FROM A", err: "wrong number of arguments to aggregate quantile"},
+		{name: "quantile out of range", query: "SELECT quantile(value, 2) FROM A", err: "quantile: the quantile must be between 0 and 1"},
+		{name: "quantile is not a number", query: "SELECT quantile(value, 'x') FROM A", err: "quantile: the quantile must be between 0 and 1"},
+		{name: "non-constant quantile", query: "SELECT quantile(value, value / 100) FROM A", err: "the second argument of quantile must be a constant"},
+		{name: "sum of strings", query: "SELECT sum(host) FROM A", err: "sum: could not convert a to DOUBLE"},
+		{name: "avg of strings", query: "SELECT avg(host) FROM A", err: "could not convert a to DOUBLE"},
+		{name: "bool_and of strings", query: "SELECT bool_and(host) FROM A", err: "bool_and: could not convert a to BOOLEAN"},
+		{name: "unknown column in filter", query: "SELECT count(*) FILTER (WHERE foo) FROM A", err: `column "foo" not found`},
+		{name: "aggregate in filter", query: "SELECT count(*) FILTER (WHERE sum(value) > 1) FROM A", err: "aggregate function calls can not be nested"},
+	})
+}
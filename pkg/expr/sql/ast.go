@@ -0,0 +1,284 @@
+package sql
+
+// Query is a full SELECT statement, optionally with common table expressions
+// and set operations.
+type Query struct {
+	With    []*CTE
+	Body    SetExpr
+	OrderBy []*OrderItem
+	Limit   Expr
+	Offset  Expr
+}
+
+// CTE is a common table expression defined in a WITH clause.
+type CTE struct {
+	Name  string
+	Query *Query
+}
+
+// SetExpr is either a *Select, a *SetOp or a parenthesized *Query.
+type SetExpr interface {
+	setExpr()
+}
+
+// SetOp combines the rows of two queries with UNION, INTERSECT or EXCEPT.
+type SetOp struct {
+	Op    string
+	All   bool
+	Left  SetExpr
+	Right SetExpr
+}
+
+// Select is a single SELECT ... FROM ... block.
+type Select struct {
+	Distinct bool
+	Items    []*SelectItem
+	From     TableExpr
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+}
+
+func (*Select) setExpr() {}
+func (*SetOp) setExpr()  {}
+func (*Query) setExpr()  {}
+
+// SelectItem is an expression in the select list. Star items are
+// represented with a nil Expr and an optional Table qualifier.
+type SelectItem struct {
+	Expr  Expr
+	Alias string
+	Star  bool
+	Table string
+}
+
+// OrderItem is an expression in an ORDER BY clause.
+type OrderItem struct {
+	Expr       Expr
+	Desc       bool
+	NullsFirst *bool
+}
+
+// TableExpr is either a *TableName, a *DerivedTable or a *Join.
+type TableExpr interface {
+	tableExpr()
+}
+
+// TableName references an input table or a common table expression.
+type TableName struct {
+	Name  string
+	Alias string
+}
+
+// DerivedTable is a subquery used in the FROM clause.
+type DerivedTable struct {
+	Query *Query
+	Alias string
+}
+
+// Join combines two table expressions.
+type Join struct {
+	Kind  string // INNER, LEFT, RIGHT, FULL or CROSS
+	Left  TableExpr
+	Right TableExpr
+	On    Expr
+	Using []string
+}
+
+func (*TableName) tableExpr()    {}
+func (*DerivedTable) tableExpr() {}
+func (*Join) tableExpr()         {}
+
+// Expr is a scalar expression.
+type Expr interface {
+	expr()
+}
+
+// Literal is a constant value: nil, bool, int64, float64 or string.
+type Literal struct {
+	Value any
+}
+
+// ColumnRef references a column, optionally qualified with a table name or alias.
+type ColumnRef struct {
+	Table string
+	Name  string
+}
+
+// BinaryExpr is an expression with an infix operator, e.g. a + b or a AND b.
+type BinaryExpr struct {
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// UnaryExpr is an expression with a prefix operator: -, + or NOT.
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+// FuncCall is a call to a scalar, aggregate or window function.
+type FuncCall struct {
+	Name     string
+	Args     []Expr
+	Star     bool
+	Distinct bool
+	Filter   Expr
+	Over     *WindowSpec
+}
+
+// WindowSpec is the OVER clause of a window function.
+type WindowSpec struct {
+	PartitionBy []Expr
+	OrderBy     []*OrderItem
+	Frame       *WindowFrame
+}
+
+// WindowFrame is the ROWS or RANGE frame of a window function.
+type WindowFrame struct {
+	Rows  bool
+	Start FrameBound
+	End   FrameBound
+}
+
+// FrameBound is one of the bounds of a window frame.
+type FrameBound struct {
+	// Kind is one of UNBOUNDED PRECEDING, PRECEDING, CURRENT ROW, FOLLOWING or UNBOUNDED FOLLOWING.
+	Kind   string
+	Offset Expr
+}
+
+const (
+	boundUnboundedPreceding = "UNBOUNDED PRECEDING"
+	boundPreceding          = "PRECEDING"
+	boundCurrentRow         = "CURRENT ROW"
+	boundFollowing          = "FOLLOWING"
+	boundUnboundedFollowing = "UNBOUNDED FOLLOWING"
+)
+
+// CaseExpr is a CASE [operand] WHEN ... THEN ... [ELSE ...] END expression.
+type CaseExpr struct {
+	Operand Expr
+	Whens   []*When
+	Else    Expr
+}
+
+// When is a single WHEN ... THEN ... branch of a CASE expression.
+type When struct {
+	Cond   Expr
+	Result Expr
+}
+
+// CastExpr converts an expression to a type, e.g. CAST(a AS INTEGER) or a::INTEGER.
+type CastExpr struct {
+	Expr Expr
+	Type Type
+}
+
+// InExpr is an expr [NOT] IN (list) or expr [NOT] IN (subquery) expression.
+type InExpr struct {
+	Expr     Expr
+	List     []Expr
+	Subquery *Query
+	Not      bool
+}
+
+// BetweenExpr is an expr [NOT] BETWEEN low AND high expression.
+type BetweenExpr struct {
+	Expr Expr
+	Low  Expr
+	High Expr
+	Not  bool
+}
+
+// IsNullExpr is an expr IS [NOT] NULL expression.
+type IsNullExpr struct {
+	Expr Expr
+	Not  bool
+}
+
+// LikeExpr is an expr [NOT] LIKE|ILIKE pattern expression.
+type LikeExpr struct {
+	Expr            Expr
+	Pattern         Expr
+	Not             bool
+	CaseInsensitive bool
+}
+
+// SubqueryExpr is a scalar subquery that returns a single value.
+type SubqueryExpr struct {
+	Query *Query
+}
+
+// ExistsExpr is an [NOT] EXISTS (subquery) expression.
+type ExistsExpr struct {
+	Query *Query
+	Not   bool
+}
+
+func (*Literal) expr()      {}
+func (*ColumnRef) expr()    {}
+func (*BinaryExpr) expr()   {}
+func (*UnaryExpr) expr()    {}
+func (*FuncCall) expr()     {}
+func (*CaseExpr) expr()     {}
+func (*CastExpr) expr()     {}
+func (*InExpr) expr()       {}
+func (*BetweenExpr) expr()  {}
+func (*IsNullExpr) expr()   {}
+func (*LikeExpr) expr()     {}
+func (*SubqueryExpr) expr() {}
+func (*ExistsExpr) expr()   {}
+
+// walkExpr calls fn for every expression in the tree rooted at e, in depth-first order.
+// If fn returns false the children of that expression are skipped.
+// Subqueries are not entered.
+func walkExpr(e Expr, fn func(Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	switch e := e.(type) {
+	case *BinaryExpr:
+		walkExpr(e.Left, fn)
+		walkExpr(e.Right, fn)
+	case *UnaryExpr:
+		walkExpr(e.Expr, fn)
+	case *FuncCall:
+		for _, a := range e.Args {
+			walkExpr(a, fn)
+		}
+		walkExpr(e.Filter, fn)
+		if e.Over != nil {
+			for _, p := range e.Over.PartitionBy {
+				walkExpr(p, fn)
+			}
+			for _, o := range e.Over.OrderBy {
+				walkExpr(o.Expr, fn)
+			}
+		}
+	case *CaseExpr:
+		walkExpr(e.Operand, fn)
+		for _, w := range e.Whens {
+			walkExpr(w.Cond, fn)
+			walkExpr(w.Result, fn)
+		}
+		walkExpr(e.Else, fn)
+	case *CastExpr:
+		walkExpr(e.Expr, fn)
+	case *InExpr:
+		walkExpr(e.Expr, fn)
+		for _, l := range e.List {
+			walkExpr(l, fn)
+		}
+	case *BetweenExpr:
+		walkExpr(e.Expr, fn)
+		walkExpr(e.Low, fn)
+		walkExpr(e.High, fn)
+	case *IsNullExpr:
+		walkExpr(e.Expr, fn)
+	case *LikeExpr:
+		walkExpr(e.Expr, fn)
+		walkExpr(e.Pattern, fn)
+	}
+}
@@ -0,0 +1,317 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// QueryFrames runs the query over the frames and returns the result as a single frame.
+// The frames are the tables of the query, frames with the same RefID are combined into one table.
+// The labels of the fields become string columns, so that series from different frames can be told apart.
+func QueryFrames(ctx context.Context, refID string, q *Query, frames []*data.Frame, limits Limits) (*data.Frame, error) {
+	tables, err := framesToTables(frames)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Execute(ctx, q, tables, limits)
+	if err != nil {
+		return nil, err
+	}
+	return tableToFrame(refID, res)
+}
+
+// framesToTables converts the frames into one table per RefID.
+func framesToTables(frames []*data.Frame) ([]*Table, error) {
+	var tables []*Table
+	byName := map[string]*Table{}
+	for _, f := range frames {
+		t, ok := byName[f.RefID]
+		if !ok {
+			t = &Table{Name: f.RefID}
+			byName[f.RefID] = t
+			tables = append(tables, t)
+		}
+		if err := appendFrame(t, f); err != nil {
+			return nil, fmt.Errorf("table %s: %w", f.RefID, err)
+		}
+	}
+	return tables, nil
+}
+
+// appendFrame adds the rows of the frame to the table, adding the columns that the table does not have yet.
+func appendFrame(t *Table, f *data.Frame) error {
+	index := make(map[string]int, len(t.Columns))
+	for i, c := range t.Columns {
+		index[c.Name] = i
+	}
+	column := func(name string, typ Type, source *data.Field) (int, error) {
+		if i, ok := index[name]; ok {
+			if common := commonType(t.Columns[i].Type, typ); common != t.Columns[i].Type {
+				t.Columns[i].Type = common
+				for _, r := range t.Rows {
+					v, err := convert(r[i], common)
+					if err != nil {
+						return 0, fmt.Errorf("column %s: %w", name, err)
+					}
+					r[i] = v
+				}
+			}
+			if src, ok := t.Columns[i].Source.(*data.Field); ok && source != nil && !src.Labels.Equals(source.Labels) {
+				// the labels are only kept if all frames agree, otherwise they are in the label columns
+				src.Labels = nil
+			}
+			return i, nil
+		}
+		index[name] = len(t.Columns)
+		var src any
+		if source != nil {
+			// the source is an empty copy of the field, used as the template of the result field
+			field := data.NewFieldFromFieldType(source.Type(), 0)
+			field.Name, field.Config = name, source.Config
+			if source.Labels != nil {
+				field.Labels = source.Labels.Copy()
+			}
+			src = field
+		}
+		t.Columns = append(t.Columns, Column{Name: name, Type: typ, Source: src})
+		for i := range t.Rows {
+			t.Rows[i] = append(t.Rows[i], nil)
+		}
+		return index[name], nil
+	}
+
+	type fieldColumn struct {
+		field *data.Field
+		col   int
+	}
+	fields := make([]fieldColumn, 0, len(f.Fields))
+	names := map[string]int{}
+	for _, field := range f.Fields {
+		name := field.Name
+		if name == "" {
+			name = "value"
+		}
+		// fields with the same name are numbered, so that all of them can be referenced
+		names[name]++
+		if n := names[name]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		typ := fieldType(field.Type())
+		if typ == TypeUnknown {
+			typ = TypeString
+		}
+		col, err := column(name, typ, field)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, fieldColumn{field: field, col: col})
+	}
+
+	type labelColumn struct {
+		value string
+		col   int
+	}
+	var labels []labelColumn
+	seen := map[string]bool{}
+	for _, field := range f.Fields {
+		keys := make([]string, 0, len(field.Labels))
+		for key := range field.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if seen[key] || names[key] > 0 {
+				continue
+			}
+			seen[key] = true
+			col, err := column(key, TypeString, nil)
+			if err != nil {
+				return err
+			}
+			labels = append(labels, labelColumn{value: field.Labels[key], col: col})
+		}
+	}
+
+	rows, err := f.RowLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < rows; i++ {
+		row := make([]any, len(t.Columns))
+		for _, fc := range fields {
+			v, ok := fc.field.ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			v, err := convert(fromFieldValue(v), t.Columns[fc.col].Type)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", fc.field.Name, err)
+			}
+			row[fc.col] = v
+		}
+		for _, l := range labels {
+			row[l.col] = l.value
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return nil
+}
+
+// fieldType returns the column type for values of a field type, or TypeUnknown for unsupported types.
+func fieldType(ft data.FieldType) Type {
+	switch ft.NonNullableType() {
+	case data.FieldTypeInt8, data.FieldTypeInt16, data.FieldTypeInt32, data.FieldTypeInt64,
+		data.FieldTypeUint8, data.FieldTypeUint16, data.FieldTypeUint32, data.FieldTypeUint64:
+		return TypeInt
+	case data.FieldTypeFloat32, data.FieldTypeFloat64:
+		return TypeFloat
+	case data.FieldTypeBool:
+		return TypeBool
+	case data.FieldTypeTime:
+		return TypeTime
+	case data.FieldTypeString, data.FieldTypeJSON:
+		return TypeString
+	}
+	return TypeUnknown
+}
+
+// fromFieldValue converts a non-nil field value into a value of the engine.
+func fromFieldValue(v any) any {
+	switch v := v.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case float64, string, bool, time.Time:
+		return v
+	case json.RawMessage:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// tableToFrame converts the result of a query into a frame. Columns that reference a field of the input
+// keep the type, labels and config of the field, other columns use the nullable type of their values.
+func tableToFrame(refID string, t *Table) (*data.Frame, error) {
+	frame := data.NewFrame("")
+	frame.RefID = refID
+	for i, c := range t.Columns {
+		hasNull := false
+		for _, r := range t.Rows {
+			if r[i] == nil {
+				hasNull = true
+				break
+			}
+		}
+
+		var field *data.Field
+		if src, ok := c.Source.(*data.Field); ok && fieldType(src.Type()) == c.Type {
+			ft := src.Type()
+			if hasNull {
+				ft = ft.NullableType()
+			}
+			field = data.NewFieldFromFieldType(ft, len(t.Rows))
+			field.Config = src.Config
+			if src.Labels != nil {
+				field.Labels = src.Labels.Copy()
+			}
+		} else {
+			field = data.NewFieldFromFieldType(resultFieldType(c.Type), len(t.Rows))
+		}
+		field.Name = c.Name
+
+		ft := field.Type().NonNullableType()
+		for j, r := range t.Rows {
+			if r[i] == nil {
+				continue
+			}
+			v, err := toFieldValue(r[i], ft)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", c.Name, err)
+			}
+			field.SetConcrete(j, v)
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame, nil
+}
+
+// resultFieldType returns the field type of computed columns.
+func resultFieldType(t Type) data.FieldType {
+	switch t {
+	case TypeInt:
+		return data.FieldTypeNullableInt64
+	case TypeString:
+		return data.FieldTypeNullableString
+	case TypeBool:
+		return data.FieldTypeNullableBool
+	case TypeTime:
+		return data.FieldTypeNullableTime
+	}
+	return data.FieldTypeNullableFloat64
+}
+
+// toFieldValue converts a value of the engine into a value of the non-nullable field type.
+func toFieldValue(v any, ft data.FieldType) (any, error) {
+	var err error
+	switch ft {
+	case data.FieldTypeInt8, data.FieldTypeInt16, data.FieldTypeInt32, data.FieldTypeInt64,
+		data.FieldTypeUint8, data.FieldTypeUint16, data.FieldTypeUint32, data.FieldTypeUint64:
+		if v, err = convert(v, TypeInt); err != nil {
+			return nil, err
+		}
+		i := v.(int64)
+		switch ft {
+		case data.FieldTypeInt8:
+			return int8(i), nil
+		case data.FieldTypeInt16:
+			return int16(i), nil
+		case data.FieldTypeInt32:
+			return int32(i), nil
+		case data.FieldTypeUint8:
+			return uint8(i), nil
+		case data.FieldTypeUint16:
+			return uint16(i), nil
+		case data.FieldTypeUint32:
+			return uint32(i), nil
+		case data.FieldTypeUint64:
+			return uint64(i), nil
+		}
+		return i, nil
+	case data.FieldTypeFloat32:
+		if v, err = convert(v, TypeFloat); err != nil {
+			return nil, err
+		}
+		return float32(v.(float64)), nil
+	case data.FieldTypeFloat64:
+		return convert(v, TypeFloat)
+	case data.FieldTypeBool:
+		return convert(v, TypeBool)
+	case data.FieldTypeTime:
+		return convert(v, TypeTime)
+	case data.FieldTypeJSON:
+		return json.RawMessage(formatValue(v)), nil
+	case data.FieldTypeString:
+		return formatValue(v), nil
+	}
+	return nil, fmt.Errorf("unsupported field type %s", ft)
+}
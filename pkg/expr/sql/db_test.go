@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFrames(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := func(host string, values ...float64) *data.Frame {
+		times := make([]time.Time, len(values))
+		for i := range values {
+			times[i] = t0.Add(time.Duration(i) * time.Minute)
+		}
+		f := data.NewFrame("",
+			data.NewField("time", nil, times),
+			data.NewField("value", data.Labels{"host": host, "env": "prod"}, values),
+		)
+		f.RefID = "A"
+		return f
+	}
+	table := data.NewFrame("",
+		data.NewField("host", nil, []string{"a", "b"}),
+		data.NewField("cores", nil, []int32{4, 8}),
+		data.NewField("", nil, []*float64{nil, nil}),
+	)
+	table.RefID = "B"
+	frames := []*data.Frame{series("a", 1, 2), series("b", 3), table}
+
+	t.Run("labels become columns", func(t *testing.T) {
+		q, err := Parse("SELECT host, env, count(*) AS n FROM A GROUP BY host, env ORDER BY host")
+		require.NoError(t, err)
+		frame, err := QueryFrames(context.Background(), "C", q, frames, Limits{})
+		require.NoError(t, err)
+		require.Equal(t, "C", frame.RefID)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "b", *frame.Fields[0].At(1).(*string))
+		require.Equal(t, "prod", *frame.Fields[1].At(1).(*string))
+		require.Equal(t, int64(1), *frame.Fields[2].At(1).(*int64))
+	})
+
+	t.Run("selected fields keep their type and labels", func(t *testing.T) {
+		q, err := Parse("SELECT B.cores, A.value, B.value FROM A JOIN B USING (host) WHERE A.value > 1 ORDER BY A.value")
+		require.NoError(t, err)
+		frame, err := QueryFrames(context.Background(), "C", q, frames[1:], Limits{})
+		require.NoError(t, err)
+		require.Equal(t, 1, frame.Rows())
+
+		require.Equal(t, data.FieldTypeInt32, frame.Fields[0].Type())
+		require.Equal(t, int32(8), frame.Fields[0].At(0))
+
+		// only one series, so its labels are kept
+		require.Equal(t, data.FieldTypeFloat64, frame.Fields[1].Type())
+		require.Equal(t, data.Labels{"host": "b", "env": "prod"}, frame.Fields[1].Labels)
+		require.Equal(t, 3.0, frame.Fields[1].At(0))
+
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[2].Type())
+		require.Nil(t, frame.Fields[2].At(0))
+	})
+
+	t.Run("labels that differ between frames are dropped", func(t *testing.T) {
+		q, err := Parse("SELECT value FROM A")
+		require.NoError(t, err)
+		frame, err := QueryFrames(context.Background(), "C", q, frames, Limits{})
+		require.NoError(t, err)
+		require.Equal(t, 3, frame.Rows())
+		require.Nil(t, frame.Fields[0].Labels)
+	})
+
+	t.Run("computed columns are nullable", func(t *testing.T) {
+		q, err := Parse("SELECT cores * 2 AS twice, time FROM B CROSS JOIN (SELECT min(time) AS time FROM A) m")
+		require.NoError(t, err)
+		frame, err := QueryFrames(context.Background(), "C", q, frames, Limits{})
+		require.NoError(t, err)
+		require.Equal(t, data.FieldTypeNullableInt64, frame.Fields[0].Type())
+		require.Equal(t, int64(16), *frame.Fields[0].At(1).(*int64))
+		require.Equal(t, data.FieldTypeNullableTime, frame.Fields[1].Type())
+		require.Equal(t, t0, *frame.Fields[1].At(0).(*time.Time))
+	})
+}
@@ -0,0 +1,872 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrRowLimitExceeded is returned when an input, intermediate or output table has more rows than allowed.
+	ErrRowLimitExceeded = errors.New("row limit exceeded")
+	// ErrMemoryLimitExceeded is returned when the rows of a query use more memory than allowed.
+	ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+)
+
+// Limits restricts the resources used by a query.
+type Limits struct {
+	// MaxRows is the maximum number of rows of any input, intermediate or output table. Zero means no limit.
+	MaxRows int64
+	// MaxBytes is the maximum estimated memory used by all rows materialized by the query. Zero means no limit.
+	MaxBytes int64
+}
+
+// Column is a column of a Table.
+type Column struct {
+	Name string
+	Type Type
+	// Source is opaque metadata of the column, for example the field it was read from.
+	// It is kept on the result columns that select the column without changing it.
+	Source any
+}
+
+// Table is a named set of rows that is used as the input and output of queries.
+// Values are nil, bool, int64, float64, string or time.Time.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+}
+
+// Execute runs the query against the tables and returns the result.
+// Table names are matched case-insensitively, unless there are several tables that only differ by case.
+func Execute(ctx context.Context, q *Query, tables []*Table, limits Limits) (*Table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ex := &executor{
+		ctx:    ctx,
+		tables: map[string][]*Table{},
+		limits: limits,
+	}
+	for _, t := range tables {
+		key := strings.ToLower(t.Name)
+		ex.tables[key] = append(ex.tables[key], t)
+		if err := ex.checkRows(len(t.Rows)); err != nil {
+			return nil, fmt.Errorf("table %s: %w", t.Name, err)
+		}
+		for _, r := range t.Rows {
+			if err := ex.account(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rel, err := ex.query(q, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := &Table{Rows: rel.rows}
+	for _, c := range rel.cols {
+		if c.hidden {
+			continue
+		}
+		out.Columns = append(out.Columns, Column{Name: c.name, Type: c.typ, Source: c.source})
+	}
+	return out, nil
+}
+
+type executor struct {
+	ctx    context.Context
+	tables map[string][]*Table
+	limits Limits
+	bytes  int64
+	steps  int
+}
+
+// relation is an intermediate result of the query.
+type relation struct {
+	cols []relColumn
+	rows [][]any
+}
+
+type relColumn struct {
+	// table is the name or alias of the table the column belongs to.
+	table  string
+	name   string
+	typ    Type
+	source any
+	// hidden columns can be referenced but are not part of *, e.g. the right hand columns of JOIN ... USING.
+	hidden bool
+}
+
+// resolve returns the index of the column.
+func (r *relation) resolve(table, name string) (int, error) {
+	var matches []int
+	for i, c := range r.cols {
+		if table != "" && !strings.EqualFold(c.table, table) {
+			continue
+		}
+		if table == "" && c.hidden {
+			continue
+		}
+		if strings.EqualFold(c.name, name) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) > 1 {
+		var exact []int
+		for _, i := range matches {
+			if r.cols[i].name == name && (table == "" || r.cols[i].table == table) {
+				exact = append(exact, i)
+			}
+		}
+		matches = exact
+	}
+	qualified := name
+	if table != "" {
+		qualified = table + "." + name
+	}
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("column %q not found", qualified)
+	case 1:
+		return matches[0], nil
+	}
+	return 0, fmt.Errorf("column reference %q is ambiguous", qualified)
+}
+
+// checkRows returns an error if a table with n rows exceeds the row limit.
+func (ex *executor) checkRows(n int) error {
+	if ex.limits.MaxRows > 0 && int64(n) > ex.limits.MaxRows {
+		return fmt.Errorf("%w: more than %d rows", ErrRowLimitExceeded, ex.limits.MaxRows)
+	}
+	return nil
+}
+
+// account adds the estimated size of the row to the memory used by the query.
+func (ex *executor) account(row []any) error {
+	ex.bytes += 24
+	for _, v := range row {
+		ex.bytes += valueSize(v)
+	}
+	if ex.limits.MaxBytes > 0 && ex.bytes > ex.limits.MaxBytes {
+		return fmt.Errorf("%w: more than %d bytes", ErrMemoryLimitExceeded, ex.limits.MaxBytes)
+	}
+	return nil
+}
+
+// add appends the row to the relation, enforcing the limits.
+func (ex *executor) add(rel *relation, row []any) error {
+	if err := ex.checkRows(len(rel.rows) + 1); err != nil {
+		return err
+	}
+	if err := ex.account(row); err != nil {
+		return err
+	}
+	rel.rows = append(rel.rows, row)
+	return nil
+}
+
+// tick checks periodically whether the query has been canceled.
+func (ex *executor) tick() error {
+	ex.steps++
+	if ex.steps%1024 == 0 {
+		return ex.ctx.Err()
+	}
+	return nil
+}
+
+func (ex *executor) query(q *Query, ctes map[string]*relation) (*relation, error) {
+	if len(q.With) > 0 {
+		scope := make(map[string]*relation, len(ctes)+len(q.With))
+		for k, v := range ctes {
+			scope[k] = v
+		}
+		for _, cte := range q.With {
+			rel, err := ex.query(cte.Query, scope)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", cte.Name, err)
+			}
+			scope[strings.ToLower(cte.Name)] = rel
+		}
+		ctes = scope
+	}
+
+	var rel *relation
+	var err error
+	if sel, ok := q.Body.(*Select); ok {
+		// ORDER BY of a single SELECT may reference columns and aggregates that are not selected
+		rel, err = ex.selectRows(sel, q.OrderBy, ctes)
+	} else {
+		rel, err = ex.setExpr(q.Body, ctes)
+		if err == nil && len(q.OrderBy) > 0 {
+			err = ex.orderRelation(rel, q.OrderBy, ctes)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ex.limitRelation(rel, q, ctes)
+}
+
+func (ex *executor) setExpr(s SetExpr, ctes map[string]*relation) (*relation, error) {
+	switch s := s.(type) {
+	case *Select:
+		return ex.selectRows(s, nil, ctes)
+	case *Query:
+		return ex.query(s, ctes)
+	case *SetOp:
+		left, err := ex.setExpr(s.Left, ctes)
+		if err != nil {
+			return nil, err
+		}
+		right, err := ex.setExpr(s.Right, ctes)
+		if err != nil {
+			return nil, err
+		}
+		return ex.setOp(s, left, right)
+	}
+	return nil, fmt.Errorf("unsupported query %T", s)
+}
+
+func (ex *executor) setOp(s *SetOp, left, right *relation) (*relation, error) {
+	lcols, rcols := visibleColumns(left), visibleColumns(right)
+	if len(lcols) != len(rcols) {
+		return nil, fmt.Errorf("%s: queries have a different number of columns (%d and %d)", s.Op, len(lcols), len(rcols))
+	}
+	out := &relation{}
+	for i, c := range lcols {
+		rc := right.cols[rcols[i]]
+		col := relColumn{name: left.cols[c].name, typ: commonType(left.cols[c].typ, rc.typ)}
+		if col.typ == left.cols[c].typ && col.typ == rc.typ && left.cols[c].source == rc.source {
+			col.source = rc.source
+		}
+		out.cols = append(out.cols, col)
+	}
+
+	project := func(rel *relation, idx []int, row []any) ([]any, error) {
+		res := make([]any, len(idx))
+		for i, c := range idx {
+			v, err := convert(row[c], out.cols[i].typ)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = v
+		}
+		return res, nil
+	}
+
+	rightKeys := map[string]int{}
+	var rightRows [][]any
+	for _, row := range right.rows {
+		r, err := project(right, rcols, row)
+		if err != nil {
+			return nil, err
+		}
+		rightRows = append(rightRows, r)
+		rightKeys[string(rowKey(r))]++
+	}
+
+	seen := map[string]bool{}
+	emit := func(row []any) error {
+		if !s.All {
+			key := string(rowKey(row))
+			if seen[key] {
+				return nil
+			}
+			seen[key] = true
+		}
+		return ex.add(out, row)
+	}
+
+	for _, row := range left.rows {
+		r, err := project(left, lcols, row)
+		if err != nil {
+			return nil, err
+		}
+		key := string(rowKey(r))
+		switch s.Op {
+		case "INTERSECT":
+			if rightKeys[key] == 0 {
+				continue
+			}
+			if s.All {
+				rightKeys[key]--
+			}
+		case "EXCEPT":
+			if rightKeys[key] > 0 {
+				if s.All {
+					rightKeys[key]--
+				}
+				continue
+			}
+		}
+		if err := emit(r); err != nil {
+			return nil, err
+		}
+	}
+	if s.Op == "UNION" {
+		for _, r := range rightRows {
+			if err := emit(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+func visibleColumns(rel *relation) []int {
+	idx := make([]int, 0, len(rel.cols))
+	for i, c := range rel.cols {
+		if !c.hidden {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+func rowKey(row []any) []byte {
+	var key []byte
+	for _, v := range row {
+		key = appendKey(key, v)
+		key = append(key, 0)
+	}
+	return key
+}
+
+func (ex *executor) limitRelation(rel *relation, q *Query, ctes map[string]*relation) (*relation, error) {
+	offset, err := ex.constInt(q.Offset, "OFFSET", ctes)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := ex.constInt(q.Limit, "LIMIT", ctes)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		if offset >= int64(len(rel.rows)) {
+			rel.rows = nil
+		} else {
+			rel.rows = rel.rows[offset:]
+		}
+	}
+	if q.Limit != nil && limit < int64(len(rel.rows)) {
+		rel.rows = rel.rows[:limit]
+	}
+	return rel, nil
+}
+
+// constInt evaluates an expression that does not reference any column, e.g. the LIMIT.
+func (ex *executor) constInt(e Expr, clause string, ctes map[string]*relation) (int64, error) {
+	if e == nil {
+		return 0, nil
+	}
+	c, err := newScope(ex, &relation{}, ctes).compile(e)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", clause, err)
+	}
+	v, err := c.fn(&row{})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", clause, err)
+	}
+	v, err = convert(v, TypeInt)
+	if err != nil || v == nil || v.(int64) < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", clause)
+	}
+	return v.(int64), nil
+}
+
+// row is the context in which a compiled expression is evaluated.
+type row struct {
+	// vals are the values of the columns of the relation.
+	vals []any
+	// aggs are the results of the aggregates of the group the row represents.
+	aggs []any
+	// wins are the results of the window functions for the row.
+	wins []any
+}
+
+func (ex *executor) selectRows(sel *Select, orderBy []*OrderItem, ctes map[string]*relation) (*relation, error) {
+	src := &relation{}
+	if sel.From != nil {
+		var err error
+		src, err = ex.from(sel.From, ctes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sel.Where != nil {
+		where, err := newScope(ex, src, ctes).compile(sel.Where)
+		if err != nil {
+			return nil, fmt.Errorf("WHERE: %w", err)
+		}
+		filtered := make([][]any, 0, len(src.rows))
+		r := &row{}
+		for _, vals := range src.rows {
+			if err := ex.tick(); err != nil {
+				return nil, err
+			}
+			r.vals = vals
+			ok, err := evalBool(where, r)
+			if err != nil {
+				return nil, fmt.Errorf("WHERE: %w", err)
+			}
+			if ok {
+				filtered = append(filtered, vals)
+			}
+		}
+		src = &relation{cols: src.cols, rows: filtered}
+	}
+	if sel.From == nil {
+		// SELECT without FROM returns a single row
+		src.rows = [][]any{{}}
+	}
+
+	// compile everything that is evaluated after grouping, so that all aggregates and windows are known
+	sc := newScope(ex, src, ctes)
+	sc.allowAggregates = true
+	sc.allowWindows = true
+
+	out := &relation{}
+	var items []*compiled
+	for _, item := range sel.Items {
+		if item.Star {
+			found := false
+			for i, c := range src.cols {
+				if c.hidden || (item.Table != "" && !strings.EqualFold(c.table, item.Table)) {
+					continue
+				}
+				found = true
+				idx := i
+				items = append(items, &compiled{
+					fn:     func(r *row) (any, error) { return r.vals[idx], nil },
+					typ:    c.typ,
+					source: c.source,
+				})
+				out.cols = append(out.cols, relColumn{name: c.name, typ: c.typ, source: c.source})
+			}
+			if !found && item.Table != "" {
+				return nil, fmt.Errorf("table %q not found", item.Table)
+			}
+			continue
+		}
+		c, err := sc.compile(item.Expr)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+		name := item.Alias
+		if name == "" {
+			name = formatExpr(item.Expr)
+		}
+		out.cols = append(out.cols, relColumn{name: name, typ: c.typ, source: c.source})
+	}
+
+	var having *compiled
+	if sel.Having != nil {
+		hs := newScope(ex, src, ctes)
+		hs.allowAggregates = true
+		hs.aggs = sc.aggs
+		var err error
+		having, err = hs.compile(sel.Having)
+		if err != nil {
+			return nil, fmt.Errorf("HAVING: %w", err)
+		}
+		sc.aggs = hs.aggs
+	}
+
+	// ORDER BY items are output columns (by alias or position) or expressions over the input
+	type orderKey struct {
+		item   *OrderItem
+		output int
+		expr   *compiled
+	}
+	var orderKeys []orderKey
+	for _, o := range orderBy {
+		key := orderKey{item: o, output: -1}
+		if idx, ok := outputColumn(o.Expr, out); ok {
+			key.output = idx
+		} else {
+			c, err := sc.compile(o.Expr)
+			if err != nil {
+				return nil, fmt.Errorf("ORDER BY: %w", err)
+			}
+			key.expr = c
+		}
+		orderKeys = append(orderKeys, key)
+	}
+
+	units, err := ex.group(sel, src, sc)
+	if err != nil {
+		return nil, err
+	}
+	if having != nil {
+		filtered := units[:0]
+		for _, u := range units {
+			ok, err := evalBool(having, u)
+			if err != nil {
+				return nil, fmt.Errorf("HAVING: %w", err)
+			}
+			if ok {
+				filtered = append(filtered, u)
+			}
+		}
+		units = filtered
+	}
+	if len(sc.wins) > 0 {
+		if err := ex.computeWindows(sc.wins, units); err != nil {
+			return nil, err
+		}
+	}
+
+	type outRow struct {
+		vals []any
+		keys []any
+	}
+	rows := make([]outRow, 0, len(units))
+	var seen map[string]bool
+	if sel.Distinct {
+		seen = map[string]bool{}
+	}
+	for _, u := range units {
+		if err := ex.tick(); err != nil {
+			return nil, err
+		}
+		vals := make([]any, len(items))
+		for i, item := range items {
+			v, err := item.fn(u)
+			if err != nil {
+				return nil, err
+			}
+			if vals[i], err = convert(v, out.cols[i].typ); err != nil {
+				return nil, err
+			}
+		}
+		if seen != nil {
+			key := string(rowKey(vals))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		var keys []any
+		if len(orderKeys) > 0 {
+			keys = make([]any, len(orderKeys))
+			for i, k := range orderKeys {
+				if k.expr == nil {
+					keys[i] = vals[k.output]
+					continue
+				}
+				v, err := k.expr.fn(u)
+				if err != nil {
+					return nil, fmt.Errorf("ORDER BY: %w", err)
+				}
+				keys[i] = v
+			}
+		}
+		if err := ex.checkRows(len(rows) + 1); err != nil {
+			return nil, err
+		}
+		if err := ex.account(vals); err != nil {
+			return nil, err
+		}
+		rows = append(rows, outRow{vals: vals, keys: keys})
+	}
+
+	if len(orderKeys) > 0 {
+		items := make([]*OrderItem, len(orderKeys))
+		for i, k := range orderKeys {
+			items[i] = k.item
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return compareKeys(rows[i].keys, rows[j].keys, items) < 0
+		})
+	}
+	out.rows = make([][]any, len(rows))
+	for i, r := range rows {
+		out.rows[i] = r.vals
+	}
+	return out, nil
+}
+
+// outputColumn returns the index of the output column an ORDER BY item references by name or position.
+func outputColumn(e Expr, out *relation) (int, bool) {
+	switch e := e.(type) {
+	case *Literal:
+		if i, ok := e.Value.(int64); ok && i >= 1 && int(i) <= len(out.cols) {
+			return int(i) - 1, true
+		}
+	case *ColumnRef:
+		if e.Table != "" {
+			return 0, false
+		}
+		match := -1
+		for i, c := range out.cols {
+			if strings.EqualFold(c.name, e.Name) {
+				if match >= 0 {
+					return 0, false
+				}
+				match = i
+			}
+		}
+		return match, match >= 0
+	}
+	return 0, false
+}
+
+// orderRelation sorts the rows of a relation by expressions over its columns.
+func (ex *executor) orderRelation(rel *relation, orderBy []*OrderItem, ctes map[string]*relation) error {
+	exprs := make([]*compiled, len(orderBy))
+	outputs := make([]int, len(orderBy))
+	for i, o := range orderBy {
+		outputs[i] = -1
+		if idx, ok := outputColumn(o.Expr, rel); ok {
+			outputs[i] = idx
+			continue
+		}
+		c, err := newScope(ex, rel, ctes).compile(o.Expr)
+		if err != nil {
+			return fmt.Errorf("ORDER BY: %w", err)
+		}
+		exprs[i] = c
+	}
+	keys := make([][]any, len(rel.rows))
+	r := &row{}
+	for i, vals := range rel.rows {
+		keys[i] = make([]any, len(orderBy))
+		r.vals = vals
+		for j := range orderBy {
+			if outputs[j] >= 0 {
+				keys[i][j] = vals[outputs[j]]
+				continue
+			}
+			v, err := exprs[j].fn(r)
+			if err != nil {
+				return fmt.Errorf("ORDER BY: %w", err)
+			}
+			keys[i][j] = v
+		}
+	}
+	idx := make([]int, len(rel.rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return compareKeys(keys[idx[i]], keys[idx[j]], orderBy) < 0
+	})
+	sorted := make([][]any, len(idx))
+	for i, k := range idx {
+		sorted[i] = rel.rows[k]
+	}
+	rel.rows = sorted
+	return nil
+}
+
+// compareKeys compares two sets of sort keys. NULLs are sorted last, unless NULLS FIRST is specified.
+func compareKeys(a, b []any, items []*OrderItem) int {
+	for i, item := range items {
+		x, y := a[i], b[i]
+		nullsFirst := item.NullsFirst != nil && *item.NullsFirst
+		var c int
+		switch {
+		case x == nil && y == nil:
+			c = 0
+		case x == nil:
+			c = 1
+			if nullsFirst {
+				c = -1
+			}
+		case y == nil:
+			c = -1
+			if nullsFirst {
+				c = 1
+			}
+		default:
+			c = sortCompare(x, y)
+			if item.Desc {
+				c = -c
+			}
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// group turns the rows of the relation into the rows that are projected. When the query has
+// aggregates or a GROUP BY clause each returned row represents a group, otherwise the rows are returned as-is.
+func (ex *executor) group(sel *Select, src *relation, sc *scope) ([]*row, error) {
+	if len(sel.GroupBy) == 0 && len(sc.aggs) == 0 {
+		units := make([]*row, len(src.rows))
+		for i, vals := range src.rows {
+			units[i] = &row{vals: vals}
+		}
+		return units, nil
+	}
+
+	gs := newScope(ex, src, sc.ctes)
+	groupBy := make([]*compiled, len(sel.GroupBy))
+	for i, g := range sel.GroupBy {
+		// GROUP BY may reference a select item by position or alias
+		e := g
+		if idx, ok := groupByItem(g, sel, src); ok {
+			e = sel.Items[idx].Expr
+		}
+		c, err := gs.compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("GROUP BY: %w", err)
+		}
+		groupBy[i] = c
+	}
+
+	type group struct {
+		first []any
+		rows  [][]any
+	}
+	var groups []*group
+	index := map[string]*group{}
+	r := &row{}
+	for _, vals := range src.rows {
+		if err := ex.tick(); err != nil {
+			return nil, err
+		}
+		r.vals = vals
+		var key []byte
+		for _, g := range groupBy {
+			v, err := g.fn(r)
+			if err != nil {
+				return nil, fmt.Errorf("GROUP BY: %w", err)
+			}
+			key = appendKey(key, v)
+			key = append(key, 0)
+		}
+		grp, ok := index[string(key)]
+		if !ok {
+			grp = &group{first: vals}
+			index[string(key)] = grp
+			groups = append(groups, grp)
+			if err := ex.checkRows(len(groups)); err != nil {
+				return nil, err
+			}
+		}
+		grp.rows = append(grp.rows, vals)
+	}
+	if len(groups) == 0 && len(sel.GroupBy) == 0 {
+		// aggregates without GROUP BY always return one row
+		groups = append(groups, &group{first: make([]any, len(src.cols))})
+	}
+
+	units := make([]*row, len(groups))
+	for i, grp := range groups {
+		aggs := make([]any, len(sc.aggs))
+		for j, agg := range sc.aggs {
+			v, err := agg.compute(ex, grp.rows)
+			if err != nil {
+				return nil, err
+			}
+			aggs[j] = v
+		}
+		units[i] = &row{vals: grp.first, aggs: aggs}
+	}
+	return units, nil
+}
+
+// groupByItem returns the select item a GROUP BY expression references by position or alias.
+// A name that is also a column of the input references the column.
+func groupByItem(e Expr, sel *Select, src *relation) (int, bool) {
+	switch e := e.(type) {
+	case *Literal:
+		if i, ok := e.Value.(int64); ok && i >= 1 && int(i) <= len(sel.Items) && !sel.Items[i-1].Star {
+			return int(i) - 1, true
+		}
+	case *ColumnRef:
+		if e.Table != "" {
+			return 0, false
+		}
+		if _, err := src.resolve("", e.Name); err == nil {
+			return 0, false
+		}
+		for i, item := range sel.Items {
+			if !item.Star && strings.EqualFold(item.Alias, e.Name) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (ex *executor) from(t TableExpr, ctes map[string]*relation) (*relation, error) {
+	switch t := t.(type) {
+	case *TableName:
+		alias := t.Alias
+		if alias == "" {
+			alias = t.Name
+		}
+		if rel, ok := ctes[strings.ToLower(t.Name)]; ok {
+			return withTable(rel, alias), nil
+		}
+		table, err := ex.table(t.Name)
+		if err != nil {
+			return nil, err
+		}
+		rel := &relation{rows: table.Rows}
+		for _, c := range table.Columns {
+			rel.cols = append(rel.cols, relColumn{table: alias, name: c.Name, typ: c.Type, source: c.Source})
+		}
+		return rel, nil
+	case *DerivedTable:
+		rel, err := ex.query(t.Query, ctes)
+		if err != nil {
+			return nil, err
+		}
+		return withTable(rel, t.Alias), nil
+	case *Join:
+		return ex.join(t, ctes)
+	}
+	return nil, fmt.Errorf("unsupported table expression %T", t)
+}
+
+func (ex *executor) table(name string) (*Table, error) {
+	candidates := ex.tables[strings.ToLower(name)]
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("table %q not found", name)
+	case 1:
+		return candidates[0], nil
+	}
+	for _, t := range candidates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("table reference %q is ambiguous", name)
+}
+
+// withTable returns a copy of the relation whose columns belong to the table.
+func withTable(rel *relation, table string) *relation {
+	out := &relation{rows: rel.rows, cols: make([]relColumn, 0, len(rel.cols))}
+	for _, c := range rel.cols {
+		if c.hidden {
+			continue
+		}
+		c.table = table
+		out.cols = append(out.cols, c)
+	}
+	if len(out.cols) != len(rel.cols) {
+		idx := visibleColumns(rel)
+		out.rows = make([][]any, len(rel.rows))
+		for i, r := range rel.rows {
+			vals := make([]any, len(idx))
+			for j, k := range idx {
+				vals[j] = r[k]
+			}
+			out.rows[i] = vals
+		}
+	}
+	return out
+}
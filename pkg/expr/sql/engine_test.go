@@ -0,0 +1,373 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testTables() []*Table {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []*Table{
+		{
+			Name: "A",
+			Columns: []Column{
+				{Name: "time", Type: TypeTime},
+				{Name: "host", Type: TypeString},
+				{Name: "value", Type: TypeFloat},
+			},
+			Rows: [][]any{
+				{t0, "a", 1.0},
+				{t0.Add(time.Minute), "a", 3.0},
+				{t0, "b", 10.0},
+				{t0.Add(time.Minute), "b", nil},
+				{t0.Add(2 * time.Minute), "b", 20.0},
+			},
+		},
+		{
+			Name: "B",
+			Columns: []Column{
+				{Name: "host", Type: TypeString},
+				{Name: "dc", Type: TypeString},
+				{Name: "weight", Type: TypeInt},
+			},
+			Rows: [][]any{
+				{"a", "eu", int64(1)},
+				{"b", "us", int64(2)},
+				{"c", "us", int64(3)},
+			},
+		},
+	}
+}
+
+func execute(t *testing.T, query string) *Table {
+	t.Helper()
+	q, err := Parse(query)
+	require.NoError(t, err)
+	res, err := Execute(context.Background(), q, testTables(), Limits{})
+	require.NoError(t, err)
+	return res
+}
+
+func columnNames(t *Table) []string {
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		rows    [][]any
+	}{
+		{
+			name:    "select without from",
+			query:   "SELECT 1 + 2 AS x, 'a' || 'b' AS s, 7 / 2 AS d, NULL IS NULL AS n",
+			columns: []string{"x", "s", "d", "n"},
+			rows:    [][]any{{int64(3), "ab", 3.5, true}},
+		},
+		{
+			name:    "where and order by",
+			query:   "SELECT host, value FROM A WHERE value > 2 ORDER BY value DESC",
+			columns: []string{"host", "value"},
+			rows:    [][]any{{"b", 20.0}, {"b", 10.0}, {"a", 3.0}},
+		},
+		{
+			name:    "nulls sort last",
+			query:   "SELECT value FROM A WHERE host = 'b' ORDER BY value",
+			columns: []string{"value"},
+			rows:    [][]any{{10.0}, {20.0}, {nil}},
+		},
+		{
+			name:    "group by with aggregates",
+			query:   "SELECT host, count(*) AS n, count(value) AS c, sum(value) AS s, avg(value) AS a, max(time) AS t FROM A GROUP BY host ORDER BY host",
+			columns: []string{"host", "n", "c", "s", "a", "t"},
+			rows: [][]any{
+				{"a", int64(2), int64(2), 4.0, 2.0, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)},
+				{"b", int64(3), int64(2), 30.0, 15.0, time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:    "aggregates without rows",
+			query:   "SELECT count(*), sum(value) FROM A WHERE host = 'x'",
+			columns: []string{"count(*)", "sum(value)"},
+			rows:    [][]any{{int64(0), nil}},
+		},
+		{
+			name:    "having and filter",
+			query:   "SELECT host, count(*) FILTER (WHERE value > 5) AS big FROM A GROUP BY 1 HAVING sum(value) > 10",
+			columns: []string{"host", "big"},
+			rows:    [][]any{{"b", int64(2)}},
+		},
+		{
+			name:    "quantiles",
+			query:   "SELECT median(value) AS m, quantile_cont(value, 0.25) AS q FROM A",
+			columns: []string{"m", "q"},
+			rows:    [][]any{{6.5, 2.5}},
+		},
+		{
+			name:    "join",
+			query:   "SELECT A.host, B.dc, A.value * B.weight AS w FROM A JOIN B ON A.host = B.host WHERE A.value IS NOT NULL ORDER BY w",
+			columns: []string{"host", "dc", "w"},
+			rows:    [][]any{{"a", "eu", 1.0}, {"a", "eu", 3.0}, {"b", "us", 20.0}, {"b", "us", 40.0}},
+		},
+		{
+			name:    "left join using",
+			query:   "SELECT * FROM B LEFT JOIN (SELECT host, count(*) AS n FROM A GROUP BY host) c USING (host) ORDER BY host",
+			columns: []string{"host", "dc", "weight", "n"},
+			rows:    [][]any{{"a", "eu", int64(1), int64(2)}, {"b", "us", int64(2), int64(3)}, {"c", "us", int64(3), nil}},
+		},
+		{
+			name:    "cte and subqueries",
+			query:   "WITH hosts AS (SELECT DISTINCT host FROM A) SELECT host FROM B WHERE host NOT IN (SELECT host FROM hosts) AND EXISTS (SELECT 1 FROM hosts)",
+			columns: []string{"host"},
+			rows:    [][]any{{"c"}},
+		},
+		{
+			name:    "union",
+			query:   "SELECT host FROM A UNION SELECT host FROM B ORDER BY 1 DESC LIMIT 2 OFFSET 1",
+			columns: []string{"host"},
+			rows:    [][]any{{"b"}, {"a"}},
+		},
+		{
+			name:    "case and cast",
+			query:   "SELECT CASE WHEN weight > 1 THEN 'many' ELSE 'one' END AS k, CAST(weight AS DOUBLE) AS f, weight::VARCHAR AS s FROM B WHERE dc = 'us' ORDER BY weight",
+			columns: []string{"k", "f", "s"},
+			rows:    [][]any{{"many", 2.0, "2"}, {"many", 3.0, "3"}},
+		},
+		{
+			name:    "string functions",
+			query:   "SELECT upper(host) AS u, host LIKE 'b%' AS l, coalesce(NULL, dc) AS c, string_agg(dc, '-') OVER () AS all_dcs FROM B WHERE host < 'c'",
+			columns: []string{"u", "l", "c", "all_dcs"},
+			rows:    [][]any{{"A", false, "eu", "eu-us"}, {"B", true, "us", "eu-us"}},
+		},
+		{
+			name:    "window functions",
+			query:   "SELECT host, value, row_number() OVER (PARTITION BY host ORDER BY time) AS rn, sum(value) OVER (PARTITION BY host ORDER BY time) AS running, lag(value) OVER (PARTITION BY host ORDER BY time) AS prev FROM A ORDER BY host, time",
+			columns: []string{"host", "value", "rn", "running", "prev"},
+			rows: [][]any{
+				{"a", 1.0, int64(1), 1.0, nil},
+				{"a", 3.0, int64(2), 4.0, 1.0},
+				{"b", 10.0, int64(1), 10.0, nil},
+				{"b", nil, int64(2), 10.0, 10.0},
+				{"b", 20.0, int64(3), 30.0, nil},
+			},
+		},
+		{
+			name:    "rank and rows frame",
+			query:   "SELECT dc, rank() OVER (ORDER BY dc) AS r, dense_rank() OVER (ORDER BY dc) AS d, sum(weight) OVER (ORDER BY weight ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) AS s FROM B ORDER BY weight",
+			columns: []string{"dc", "r", "d", "s"},
+			rows:    [][]any{{"eu", int64(1), int64(1), int64(1)}, {"us", int64(2), int64(2), int64(3)}, {"us", int64(2), int64(2), int64(5)}},
+		},
+		{
+			name:    "windows over groups",
+			query:   "SELECT host, sum(value) AS s, sum(sum(value)) OVER () AS total FROM A GROUP BY host ORDER BY host",
+			columns: []string{"host", "s", "total"},
+			rows:    [][]any{{"a", 4.0, 34.0}, {"b", 30.0, 34.0}},
+		},
+		{
+			name:    "time functions",
+			query:   "SELECT epoch_ms(time) AS ms, date_trunc('hour', time) AS h FROM A WHERE host = 'a' AND time > '2024-01-01T00:00:00Z'",
+			columns: []string{"ms", "h"},
+			rows:    [][]any{{int64(1704067260000), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := execute(t, tt.query)
+			require.Equal(t, tt.columns, columnNames(res))
+			require.Equal(t, tt.rows, res.Rows)
+		})
+	}
+}
+
+func TestExecuteErrors(t *testing.T) {
+	testQueryErrors(t, []errorTest{
+		{name: "unknown table", query: "SELECT * FROM C", err: `table "C" not found`},
+		{name: "unknown column", query: "SELECT foo FROM A", err: `column "foo" not found`},
+		{name: "ambiguous column", query: "SELECT host FROM A JOIN B ON A.host = B.host", err: `"host" is ambiguous`},
+		{name: "aggregate in where", query: "SELECT host FROM A WHERE sum(value) > 1", err: "aggregate function sum is not allowed here"},
+		{name: "nested aggregate", query: "SELECT sum(max(value)) FROM A", err: "aggregate function calls can not be nested"},
+		{name: "unknown function", query: "SELECT foo(value) FROM A", err: "unknown function foo"},
+		{name: "window without over", query: "SELECT row_number() FROM A", err: "window function row_number requires an OVER clause"},
+		{name: "union column count", query: "SELECT host FROM A UNION SELECT host, dc FROM B", err: "UNION"},
+	})
+}
+
+// queryTest is a query and the rows it returns, nil if it returns no rows.
+type queryTest struct {
+	name  string
+	query string
+	rows  [][]any
+}
+
+func testQueries(t *testing.T, tests []queryTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := execute(t, tt.query)
+			if tt.rows == nil {
+				require.Empty(t, res.Rows)
+				return
+			}
+			require.Equal(t, tt.rows, res.Rows)
+		})
+	}
+}
+
+type errorTest struct {
+	name  string
+	query string
+	err   string
+}
+
+func testQueryErrors(t *testing.T, tests []errorTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			require.NoError(t, err)
+			_, err = Execute(context.Background(), q, testTables(), Limits{})
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestExecuteLimits(t *testing.T) {
+	q, err := Parse("SELECT * FROM A a1 CROSS JOIN A a2")
+	require.NoError(t, err)
+
+	_, err = Execute(context.Background(), q, testTables(), Limits{MaxRows: 10})
+	require.True(t, errors.Is(err, ErrRowLimitExceeded))
+
+	_, err = Execute(context.Background(), q, testTables(), Limits{MaxBytes: 100})
+	require.True(t, errors.Is(err, ErrMemoryLimitExceeded))
+
+	res, err := Execute(context.Background(), q, testTables(), Limits{MaxRows: 25})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 25)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Execute(ctx, q, testTables(), Limits{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExecuteClauses(t *testing.T) {
+	testQueries(t, []queryTest{
+		{name: "select distinct", query: "SELECT DISTINCT dc FROM B ORDER BY dc", rows: [][]any{{"eu"}, {"us"}}},
+		{name: "distinct treats NULLs as equal", query: "SELECT DISTINCT value IS NULL FROM A ORDER BY 1", rows: [][]any{{false}, {true}}},
+		{name: "distinct compares numbers by value", query: "SELECT DISTINCT x FROM (SELECT 1 AS x UNION ALL SELECT 1.0) ORDER BY 1", rows: [][]any{{1.0}}},
+		{name: "star", query: "SELECT * FROM B WHERE weight = 1", rows: [][]any{{"a", "eu", int64(1)}}},
+		{name: "table star", query: "SELECT b.*, 1 FROM B b WHERE weight = 1", rows: [][]any{{"a", "eu", int64(1), int64(1)}}},
+		{name: "where with NULL excludes the row", query: "SELECT host FROM A WHERE value <> 1 ORDER BY value", rows: [][]any{{"a"}, {"b"}, {"b"}}},
+		{name: "where false", query: "SELECT host FROM B WHERE FALSE"},
+
+		{name: "group by ordinal", query: "SELECT dc, count(*) FROM B GROUP BY 1 ORDER BY 1", rows: [][]any{{"eu", int64(1)}, {"us", int64(2)}}},
+		{name: "group by alias", query: "SELECT upper(dc) AS d, sum(weight) FROM B GROUP BY d ORDER BY d", rows: [][]any{{"EU", int64(1)}, {"US", int64(5)}}},
+		{name: "group by expression", query: "SELECT count(*) FROM B GROUP BY weight % 2 ORDER BY 1", rows: [][]any{{int64(1)}, {int64(2)}}},
+		{
+			name:  "group by prefers input columns over aliases",
+			query: "SELECT dc AS host, count(*) FROM B GROUP BY host ORDER BY 1",
+			rows:  [][]any{{"eu", int64(1)}, {"us", int64(1)}, {"us", int64(1)}},
+		},
+		{name: "group by NULL", query: "SELECT nullif(host, 'a') AS h, count(*) FROM A GROUP BY 1 ORDER BY 1", rows: [][]any{{"b", int64(3)}, {nil, int64(2)}}},
+		{name: "group by multiple columns", query: "SELECT host, value > 2 AS big, count(*) FROM A WHERE value IS NOT NULL GROUP BY 1, 2 ORDER BY 1, 2", rows: [][]any{
+			{"a", false, int64(1)}, {"a", true, int64(1)}, {"b", true, int64(2)},
+		}},
+		{name: "group by without aggregates", query: "SELECT host FROM A GROUP BY host ORDER BY host", rows: [][]any{{"a"}, {"b"}}},
+		{name: "group by without rows", query: "SELECT host, count(*) FROM A WHERE FALSE GROUP BY host"},
+		{name: "ungrouped column takes a value of the group", query: "SELECT dc, host, count(*) FROM B WHERE dc = 'eu' GROUP BY dc", rows: [][]any{{"eu", "a", int64(1)}}},
+		{name: "having", query: "SELECT dc FROM B GROUP BY dc HAVING count(*) > 1", rows: [][]any{{"us"}}},
+		{name: "having without group by", query: "SELECT count(*) FROM A HAVING count(*) > 10"},
+		{name: "having with alias of aggregate", query: "SELECT dc, sum(weight) AS s FROM B GROUP BY dc HAVING max(weight) < 2", rows: [][]any{{"eu", int64(1)}}},
+
+		{name: "order by nulls first", query: "SELECT value FROM A WHERE host = 'b' ORDER BY value NULLS FIRST", rows: [][]any{{nil}, {10.0}, {20.0}}},
+		{name: "order by desc", query: "SELECT value FROM A WHERE host = 'b' ORDER BY value DESC", rows: [][]any{{20.0}, {10.0}, {nil}}},
+		{name: "order by desc nulls first", query: "SELECT value FROM A WHERE host = 'b' ORDER BY value DESC NULLS FIRST", rows: [][]any{{nil}, {20.0}, {10.0}}},
+		{name: "order by column not selected", query: "SELECT host FROM B ORDER BY weight DESC", rows: [][]any{{"c"}, {"b"}, {"a"}}},
+		{name: "order by alias", query: "SELECT -weight AS w FROM B ORDER BY w", rows: [][]any{{int64(-3)}, {int64(-2)}, {int64(-1)}}},
+		{name: "order by expression", query: "SELECT host FROM B ORDER BY weight % 2, host", rows: [][]any{{"b"}, {"a"}, {"c"}}},
+		{name: "order by multiple keys", query: "SELECT host, value FROM A ORDER BY host DESC, value", rows: [][]any{
+			{"b", 10.0}, {"b", 20.0}, {"b", nil}, {"a", 1.0}, {"a", 3.0},
+		}},
+		{name: "order by aggregate", query: "SELECT host FROM A GROUP BY host ORDER BY count(*) DESC", rows: [][]any{{"b"}, {"a"}}},
+
+		{name: "limit", query: "SELECT host FROM B ORDER BY host LIMIT 2", rows: [][]any{{"a"}, {"b"}}},
+		{name: "limit zero", query: "SELECT host FROM B LIMIT 0"},
+		{name: "limit larger than the result", query: "SELECT host FROM B ORDER BY host LIMIT 10", rows: [][]any{{"a"}, {"b"}, {"c"}}},
+		{name: "limit expression", query: "SELECT host FROM B ORDER BY host LIMIT 1 + 1 OFFSET 2", rows: [][]any{{"c"}}},
+		{name: "offset beyond the result", query: "SELECT host FROM B OFFSET 5"},
+
+		{name: "union removes duplicates", query: "SELECT dc FROM B UNION SELECT 'eu' ORDER BY 1", rows: [][]any{{"eu"}, {"us"}}},
+		{name: "union all", query: "SELECT dc FROM B UNION ALL SELECT 'x' ORDER BY 1", rows: [][]any{{"eu"}, {"us"}, {"us"}, {"x"}}},
+		{name: "union converts to a common type", query: "SELECT weight FROM B UNION SELECT 1.5 ORDER BY 1", rows: [][]any{{1.0}, {1.5}, {2.0}, {3.0}}},
+		{name: "intersect", query: "SELECT host FROM A INTERSECT SELECT host FROM B ORDER BY 1", rows: [][]any{{"a"}, {"b"}}},
+		{name: "intersect all", query: "SELECT host FROM A INTERSECT ALL SELECT host FROM A WHERE value > 2 ORDER BY 1", rows: [][]any{{"a"}, {"b"}, {"b"}}},
+		{name: "except", query: "SELECT host FROM B EXCEPT SELECT host FROM A", rows: [][]any{{"c"}}},
+		{name: "except all", query: "SELECT host FROM A EXCEPT ALL SELECT host FROM B ORDER BY 1", rows: [][]any{{"a"}, {"b"}, {"b"}}},
+
+		{name: "cte referencing a cte", query: "WITH x AS (SELECT weight FROM B), y AS (SELECT sum(weight) AS s FROM x) SELECT s FROM y", rows: [][]any{{int64(6)}}},
+		{name: "cte shadows a table", query: "WITH A AS (SELECT 1 AS one) SELECT * FROM A", rows: [][]any{{int64(1)}}},
+		{name: "cte used twice", query: "WITH x AS (SELECT host FROM B) SELECT count(*) FROM x a JOIN x b ON a.host = b.host", rows: [][]any{{int64(3)}}},
+		{name: "derived table", query: "SELECT max(n) FROM (SELECT host, count(*) AS n FROM A GROUP BY host) c", rows: [][]any{{int64(3)}}},
+	})
+}
+
+func TestExecuteClauseErrors(t *testing.T) {
+	testQueryErrors(t, []errorTest{
+		{name: "negative limit", query: "SELECT * FROM B LIMIT -1", err: "LIMIT must be a non-negative integer"},
+		{name: "limit is not a number", query: "SELECT * FROM B LIMIT 'x'", err: "LIMIT must be a non-negative integer"},
+		{name: "limit references a column", query: "SELECT * FROM B LIMIT weight", err: `LIMIT: column "weight" not found`},
+		{name: "negative offset", query: "SELECT * FROM B OFFSET -1", err: "OFFSET must be a non-negative integer"},
+		{name: "intersect column count", query: "SELECT host FROM A INTERSECT SELECT host, dc FROM B", err: "INTERSECT: queries have a different number of columns (1 and 2)"},
+		{name: "except column count", query: "SELECT host, dc FROM B EXCEPT SELECT host FROM A", err: "EXCEPT: queries have a different number of columns (2 and 1)"},
+		{name: "self join without alias", query: "SELECT B.host FROM B JOIN B ON TRUE", err: `column reference "B.host" is ambiguous`},
+		{name: "unknown table star", query: "SELECT C.* FROM B", err: `table "C" not found`},
+		{name: "unknown column in where", query: "SELECT host FROM B WHERE foo = 1", err: `WHERE: column "foo" not found`},
+		{name: "invalid where", query: "SELECT host FROM B WHERE host", err: "WHERE: could not convert a to BOOLEAN"},
+		{name: "unknown column in group by", query: "SELECT count(*) FROM B GROUP BY foo", err: `GROUP BY: column "foo" not found`},
+		{name: "aggregate in group by", query: "SELECT count(*) FROM B GROUP BY count(*)", err: "GROUP BY: aggregate function count is not allowed here"},
+		{name: "unknown column in having", query: "SELECT dc FROM B GROUP BY dc HAVING foo > 1", err: `HAVING: column "foo" not found`},
+		{name: "unknown column in order by", query: "SELECT host FROM B ORDER BY foo", err: `ORDER BY: column "foo" not found`},
+		{name: "cte error", query: "WITH x AS (SELECT foo FROM B) SELECT * FROM x", err: `x: column "foo" not found`},
+		{name: "cte is not visible in an earlier cte", query: "WITH x AS (SELECT * FROM y), y AS (SELECT 1) SELECT * FROM x", err: `table "y" not found`},
+		{name: "scalar subquery with many rows", query: "SELECT (SELECT host FROM B)", err: "subquery used as an expression returned more than one row"},
+		{name: "scalar subquery with many columns", query: "SELECT (SELECT host, dc FROM B)", err: "subquery must return a single column, got 2"},
+		{name: "in subquery with many columns", query: "SELECT 1 IN (SELECT host, dc FROM B)", err: "IN subquery must return a single column, got 2"},
+		{name: "correlated subquery", query: "SELECT (SELECT count(*) FROM A WHERE A.host = B.host) FROM B", err: `column "B.host" not found`},
+		{name: "window in where", query: "SELECT host FROM B WHERE row_number() OVER () = 1", err: "window function row_number is not allowed here"},
+	})
+}
+
+func TestTableNames(t *testing.T) {
+	tables := []*Table{
+		{Name: "ab", Columns: []Column{{Name: "x", Type: TypeInt}}, Rows: [][]any{{int64(1)}}},
+		{Name: "Ab", Columns: []Column{{Name: "x", Type: TypeInt}}, Rows: [][]any{{int64(2)}}},
+		{Name: "cd", Columns: []Column{{Name: "x", Type: TypeInt}}, Rows: [][]any{{int64(3)}}},
+	}
+	run := func(query string) (*Table, error) {
+		q, err := Parse(query)
+		require.NoError(t, err)
+		return Execute(context.Background(), q, tables, Limits{})
+	}
+
+	// table names are case-insensitive unless several tables only differ in case
+	res, err := run("SELECT x FROM CD")
+	require.NoError(t, err)
+	require.Equal(t, [][]any{{int64(3)}}, res.Rows)
+
+	res, err = run("SELECT x FROM Ab")
+	require.NoError(t, err)
+	require.Equal(t, [][]any{{int64(2)}}, res.Rows)
+
+	_, err = run("SELECT x FROM AB")
+	require.ErrorContains(t, err, `table reference "AB" is ambiguous`)
+}
@@ -0,0 +1,667 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// compiled is an expression that is ready to be evaluated against rows of a relation.
+type compiled struct {
+	fn  func(r *row) (any, error)
+	typ Type
+	// source is set when the expression is a plain column reference.
+	source any
+}
+
+// scope resolves the column references, aggregates and window functions of expressions.
+type scope struct {
+	ex   *executor
+	rel  *relation
+	ctes map[string]*relation
+
+	allowAggregates bool
+	allowWindows    bool
+	// inAggregate is set while compiling the arguments of an aggregate.
+	inAggregate bool
+
+	aggs []*aggregate
+	wins []*window
+}
+
+func newScope(ex *executor, rel *relation, ctes map[string]*relation) *scope {
+	return &scope{ex: ex, rel: rel, ctes: ctes}
+}
+
+// evalBool evaluates a condition, where NULL is false.
+func evalBool(c *compiled, r *row) (bool, error) {
+	v, err := c.fn(r)
+	if err != nil || v == nil {
+		return false, err
+	}
+	b, err := convert(v, TypeBool)
+	if err != nil {
+		return false, err
+	}
+	return b.(bool), nil
+}
+
+func constant(v any) *compiled {
+	return &compiled{fn: func(*row) (any, error) { return v, nil }, typ: typeOfValue(v)}
+}
+
+func (s *scope) compile(e Expr) (*compiled, error) {
+	switch e := e.(type) {
+	case *Literal:
+		return constant(e.Value), nil
+	case *ColumnRef:
+		idx, err := s.rel.resolve(e.Table, e.Name)
+		if err != nil {
+			return nil, err
+		}
+		col := s.rel.cols[idx]
+		return &compiled{fn: func(r *row) (any, error) { return r.vals[idx], nil }, typ: col.typ, source: col.source}, nil
+	case *UnaryExpr:
+		return s.compileUnary(e)
+	case *BinaryExpr:
+		return s.compileBinary(e)
+	case *FuncCall:
+		return s.compileFunc(e)
+	case *CaseExpr:
+		return s.compileCase(e)
+	case *CastExpr:
+		c, err := s.compile(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		typ := e.Type
+		return &compiled{fn: func(r *row) (any, error) {
+			v, err := c.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			return convert(v, typ)
+		}, typ: typ}, nil
+	case *IsNullExpr:
+		c, err := s.compile(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		not := e.Not
+		return &compiled{fn: func(r *row) (any, error) {
+			v, err := c.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			return (v == nil) != not, nil
+		}, typ: TypeBool}, nil
+	case *BetweenExpr:
+		and := &BinaryExpr{
+			Op:    "AND",
+			Left:  &BinaryExpr{Op: ">=", Left: e.Expr, Right: e.Low},
+			Right: &BinaryExpr{Op: "<=", Left: e.Expr, Right: e.High},
+		}
+		if e.Not {
+			return s.compile(&UnaryExpr{Op: "NOT", Expr: and})
+		}
+		return s.compile(and)
+	case *InExpr:
+		return s.compileIn(e)
+	case *LikeExpr:
+		return s.compileLike(e)
+	case *SubqueryExpr:
+		rel, err := s.ex.query(e.Query, s.ctes)
+		if err != nil {
+			return nil, err
+		}
+		cols := visibleColumns(rel)
+		if len(cols) != 1 {
+			return nil, fmt.Errorf("subquery must return a single column, got %d", len(cols))
+		}
+		if len(rel.rows) > 1 {
+			return nil, fmt.Errorf("subquery used as an expression returned more than one row")
+		}
+		var v any
+		if len(rel.rows) == 1 {
+			v = rel.rows[0][cols[0]]
+		}
+		c := constant(v)
+		c.typ = rel.cols[cols[0]].typ
+		return c, nil
+	case *ExistsExpr:
+		rel, err := s.ex.query(e.Query, s.ctes)
+		if err != nil {
+			return nil, err
+		}
+		return constant((len(rel.rows) > 0) != e.Not), nil
+	}
+	return nil, fmt.Errorf("unsupported expression %T", e)
+}
+
+func (s *scope) compileUnary(e *UnaryExpr) (*compiled, error) {
+	c, err := s.compile(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Op {
+	case "NOT":
+		return &compiled{fn: func(r *row) (any, error) {
+			v, err := c.fn(r)
+			if err != nil || v == nil {
+				return nil, err
+			}
+			b, err := convert(v, TypeBool)
+			if err != nil {
+				return nil, err
+			}
+			return !b.(bool), nil
+		}, typ: TypeBool}, nil
+	case "-":
+		typ := c.typ
+		if !typ.numeric() {
+			typ = TypeFloat
+		}
+		return &compiled{fn: func(r *row) (any, error) {
+			v, err := c.fn(r)
+			if err != nil || v == nil {
+				return nil, err
+			}
+			switch v := v.(type) {
+			case int64:
+				return -v, nil
+			case float64:
+				return -v, nil
+			}
+			f, err := convert(v, TypeFloat)
+			if err != nil {
+				return nil, err
+			}
+			return -f.(float64), nil
+		}, typ: typ}, nil
+	}
+	return c, nil
+}
+
+func (s *scope) compileBinary(e *BinaryExpr) (*compiled, error) {
+	left, err := s.compile(e.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := s.compile(e.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case "AND", "OR":
+		and := e.Op == "AND"
+		return &compiled{fn: func(r *row) (any, error) {
+			lv, err := left.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			lb, err := convert(lv, TypeBool)
+			if err != nil {
+				return nil, err
+			}
+			// short circuit: FALSE AND x is FALSE, TRUE OR x is TRUE
+			if lb != nil && lb.(bool) != and {
+				return lb, nil
+			}
+			rv, err := right.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			rb, err := convert(rv, TypeBool)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case rb != nil && rb.(bool) != and:
+				return rb, nil
+			case lb == nil || rb == nil:
+				return nil, nil
+			}
+			return and, nil
+		}, typ: TypeBool}, nil
+	case "=", "<>", "<", "<=", ">", ">=", "IS":
+		op := e.Op
+		return &compiled{fn: func(r *row) (any, error) {
+			lv, err := left.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			rv, err := right.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			if lv == nil || rv == nil {
+				if op == "IS" {
+					return false, nil
+				}
+				return nil, nil
+			}
+			c, err := compareValues(lv, rv)
+			if err != nil {
+				return nil, err
+			}
+			switch op {
+			case "=", "IS":
+				return c == 0, nil
+			case "<>":
+				return c != 0, nil
+			case "<":
+				return c < 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">":
+				return c > 0, nil
+			}
+			return c >= 0, nil
+		}, typ: TypeBool}, nil
+	case "||":
+		return &compiled{fn: func(r *row) (any, error) {
+			lv, err := left.fn(r)
+			if err != nil || lv == nil {
+				return nil, err
+			}
+			rv, err := right.fn(r)
+			if err != nil || rv == nil {
+				return nil, err
+			}
+			return formatValue(lv) + formatValue(rv), nil
+		}, typ: TypeString}, nil
+	}
+	return arithmetic(e.Op, left, right)
+}
+
+func arithmetic(op string, left, right *compiled) (*compiled, error) {
+	typ := TypeFloat
+	if left.typ == TypeInt && right.typ == TypeInt && op != "/" {
+		typ = TypeInt
+	}
+	if left.typ == TypeTime || right.typ == TypeTime {
+		return nil, fmt.Errorf("operator %s is not supported for timestamps, use epoch_ms() and to_timestamp()", op)
+	}
+	return &compiled{fn: func(r *row) (any, error) {
+		lv, err := left.fn(r)
+		if err != nil || lv == nil {
+			return nil, err
+		}
+		rv, err := right.fn(r)
+		if err != nil || rv == nil {
+			return nil, err
+		}
+		if a, ok := lv.(int64); ok && typ == TypeInt {
+			if b, ok := rv.(int64); ok {
+				switch op {
+				case "+":
+					return a + b, nil
+				case "-":
+					return a - b, nil
+				case "*":
+					return a * b, nil
+				case "%":
+					if b == 0 {
+						return nil, nil
+					}
+					return a % b, nil
+				}
+			}
+		}
+		a, err := convert(lv, TypeFloat)
+		if err != nil {
+			return nil, err
+		}
+		b, err := convert(rv, TypeFloat)
+		if err != nil {
+			return nil, err
+		}
+		x, y := a.(float64), b.(float64)
+		switch op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/":
+			if y == 0 {
+				return nil, nil
+			}
+			return x / y, nil
+		case "%":
+			if y == 0 {
+				return nil, nil
+			}
+			return math.Mod(x, y), nil
+		}
+		return nil, fmt.Errorf("unsupported operator %s", op)
+	}, typ: typ}, nil
+}
+
+func (s *scope) compileCase(e *CaseExpr) (*compiled, error) {
+	var operand *compiled
+	var err error
+	if e.Operand != nil {
+		if operand, err = s.compile(e.Operand); err != nil {
+			return nil, err
+		}
+	}
+	conds := make([]*compiled, len(e.Whens))
+	results := make([]*compiled, len(e.Whens))
+	typ := TypeUnknown
+	for i, w := range e.Whens {
+		if conds[i], err = s.compile(w.Cond); err != nil {
+			return nil, err
+		}
+		if results[i], err = s.compile(w.Result); err != nil {
+			return nil, err
+		}
+		typ = commonType(typ, results[i].typ)
+	}
+	var elseExpr *compiled
+	if e.Else != nil {
+		if elseExpr, err = s.compile(e.Else); err != nil {
+			return nil, err
+		}
+		typ = commonType(typ, elseExpr.typ)
+	}
+	return &compiled{fn: func(r *row) (any, error) {
+		var op any
+		if operand != nil {
+			v, err := operand.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			op = v
+		}
+		for i, cond := range conds {
+			var match bool
+			if operand != nil {
+				v, err := cond.fn(r)
+				if err != nil {
+					return nil, err
+				}
+				if op != nil && v != nil {
+					c, err := compareValues(op, v)
+					if err != nil {
+						return nil, err
+					}
+					match = c == 0
+				}
+			} else {
+				ok, err := evalBool(cond, r)
+				if err != nil {
+					return nil, err
+				}
+				match = ok
+			}
+			if match {
+				v, err := results[i].fn(r)
+				if err != nil {
+					return nil, err
+				}
+				return convert(v, typ)
+			}
+		}
+		if elseExpr == nil {
+			return nil, nil
+		}
+		v, err := elseExpr.fn(r)
+		if err != nil {
+			return nil, err
+		}
+		return convert(v, typ)
+	}, typ: typ}, nil
+}
+
+func (s *scope) compileIn(e *InExpr) (*compiled, error) {
+	c, err := s.compile(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	not := e.Not
+
+	var list []*compiled
+	if e.Subquery != nil {
+		rel, err := s.ex.query(e.Subquery, s.ctes)
+		if err != nil {
+			return nil, err
+		}
+		cols := visibleColumns(rel)
+		if len(cols) != 1 {
+			return nil, fmt.Errorf("IN subquery must return a single column, got %d", len(cols))
+		}
+		for _, r := range rel.rows {
+			list = append(list, constant(r[cols[0]]))
+		}
+	} else {
+		for _, item := range e.List {
+			ic, err := s.compile(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, ic)
+		}
+	}
+
+	return &compiled{fn: func(r *row) (any, error) {
+		v, err := c.fn(r)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		sawNull := false
+		for _, item := range list {
+			iv, err := item.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			if iv == nil {
+				sawNull = true
+				continue
+			}
+			cmp, err := compareValues(v, iv)
+			if err != nil {
+				return nil, err
+			}
+			if cmp == 0 {
+				return !not, nil
+			}
+		}
+		if sawNull {
+			return nil, nil
+		}
+		return not, nil
+	}, typ: TypeBool}, nil
+}
+
+func (s *scope) compileLike(e *LikeExpr) (*compiled, error) {
+	c, err := s.compile(e.Expr)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := s.compile(e.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	not, ci := e.Not, e.CaseInsensitive
+	cache := map[string]*regexp.Regexp{}
+	return &compiled{fn: func(r *row) (any, error) {
+		v, err := c.fn(r)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		p, err := pattern.fn(r)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		ps := formatValue(p)
+		re, ok := cache[ps]
+		if !ok {
+			re, err = likeToRegexp(ps, ci)
+			if err != nil {
+				return nil, err
+			}
+			cache[ps] = re
+		}
+		return re.MatchString(formatValue(v)) != not, nil
+	}, typ: TypeBool}, nil
+}
+
+// likeToRegexp converts a LIKE pattern, where % matches any sequence and _ matches a single character.
+func likeToRegexp(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^(?s)")
+	if caseInsensitive {
+		sb.WriteString("(?i)")
+	}
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			sb.WriteString(".*")
+		case r == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func (s *scope) compileFunc(f *FuncCall) (*compiled, error) {
+	if f.Over != nil {
+		// windows are computed after the aggregates, so they can not be arguments of an aggregate
+		if !s.allowWindows || s.inAggregate {
+			return nil, fmt.Errorf("window function %s is not allowed here", f.Name)
+		}
+		return s.compileWindow(f)
+	}
+	if isAggregate(f.Name) {
+		if !s.allowAggregates {
+			return nil, fmt.Errorf("aggregate function %s is not allowed here", f.Name)
+		}
+		if s.inAggregate {
+			return nil, fmt.Errorf("aggregate function calls can not be nested")
+		}
+		agg, err := s.compileAggregate(f)
+		if err != nil {
+			return nil, err
+		}
+		idx := len(s.aggs)
+		s.aggs = append(s.aggs, agg)
+		return &compiled{fn: func(r *row) (any, error) { return r.aggs[idx], nil }, typ: agg.typ}, nil
+	}
+	if isWindowOnly(f.Name) {
+		return nil, fmt.Errorf("window function %s requires an OVER clause", f.Name)
+	}
+	if f.Star || f.Distinct || f.Filter != nil {
+		return nil, fmt.Errorf("%s is not an aggregate function", f.Name)
+	}
+
+	fn, ok := scalarFuncs[f.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", f.Name)
+	}
+	if len(f.Args) < fn.minArgs || (fn.maxArgs >= 0 && len(f.Args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments to function %s", f.Name)
+	}
+	args := make([]*compiled, len(f.Args))
+	types := make([]Type, len(f.Args))
+	for i, a := range f.Args {
+		c, err := s.compile(a)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = c
+		types[i] = c.typ
+	}
+	typ := fn.returns(types)
+	name := f.Name
+	vals := make([]any, len(args))
+	return &compiled{fn: func(r *row) (any, error) {
+		// the arguments are evaluated into a shared slice, the functions must not keep it
+		for i, a := range args {
+			v, err := a.fn(r)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil && !fn.nullable {
+				return nil, nil
+			}
+			vals[i] = v
+		}
+		v, err := fn.call(vals)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return v, nil
+	}, typ: typ}, nil
+}
+
+// formatExpr returns the SQL text of an expression, used as the name of unnamed result columns.
+func formatExpr(e Expr) string {
+	switch e := e.(type) {
+	case *Literal:
+		switch v := e.Value.(type) {
+		case string:
+			return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		return formatValue(e.Value)
+	case *ColumnRef:
+		return e.Name
+	case *UnaryExpr:
+		if e.Op == "NOT" {
+			return "NOT " + formatExpr(e.Expr)
+		}
+		return e.Op + formatExpr(e.Expr)
+	case *BinaryExpr:
+		return "(" + formatExpr(e.Left) + " " + e.Op + " " + formatExpr(e.Right) + ")"
+	case *FuncCall:
+		var sb strings.Builder
+		sb.WriteString(e.Name)
+		sb.WriteString("(")
+		if e.Distinct {
+			sb.WriteString("DISTINCT ")
+		}
+		if e.Star {
+			sb.WriteString("*")
+		}
+		for i, a := range e.Args {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(formatExpr(a))
+		}
+		sb.WriteString(")")
+		if e.Over != nil {
+			sb.WriteString(" OVER (...)")
+		}
+		return sb.String()
+	case *CastExpr:
+		return "CAST(" + formatExpr(e.Expr) + " AS " + e.Type.String() + ")"
+	case *CaseExpr:
+		return "CASE ... END"
+	case *IsNullExpr:
+		if e.Not {
+			return "(" + formatExpr(e.Expr) + " IS NOT NULL)"
+		}
+		return "(" + formatExpr(e.Expr) + " IS NULL)"
+	case *InExpr, *BetweenExpr, *LikeExpr, *ExistsExpr:
+		return "?column?"
+	case *SubqueryExpr:
+		return "(SELECT ...)"
+	}
+	return "?column?"
+}
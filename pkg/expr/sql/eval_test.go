@@ -0,0 +1,304 @@
+package sql
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// evalExpr evaluates a single expression against the test tables, with the columns of B in scope.
+func evalExpr(query string) (any, error) {
+	q, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Execute(context.Background(), q, testTables(), Limits{})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) != 1 || len(res.Rows[0]) != 1 {
+		return nil, nil
+	}
+	return res.Rows[0][0], nil
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		// literals
+		{expr: "1", expected: int64(1)},
+		{expr: "-1", expected: int64(-1)},
+		{expr: "1.5", expected: 1.5},
+		{expr: "1e3", expected: 1000.0},
+		{expr: "'it''s'", expected: "it's"},
+		{expr: "TRUE", expected: true},
+		{expr: "NULL", expected: nil},
+
+		// arithmetic
+		{expr: "1 + 2 * 3", expected: int64(7)},
+		{expr: "(1 + 2) * 3", expected: int64(9)},
+		{expr: "10 - 2 - 3", expected: int64(5)},
+		{expr: "7 / 2", expected: 3.5},
+		{expr: "6 / 3", expected: 2.0},
+		{expr: "7 % 3", expected: int64(1)},
+		{expr: "7.5 % 2", expected: 1.5},
+		{expr: "1 + 0.5", expected: 1.5},
+		{expr: "-(2 + 3)", expected: int64(-5)},
+		{expr: "- -2", expected: int64(2)},
+		{expr: "+2", expected: int64(2)},
+		{expr: "'2' + 1", expected: 3.0},
+		{expr: "1 / 0", expected: nil},
+		{expr: "1.0 / 0", expected: nil},
+		{expr: "1 % 0", expected: nil},
+		{expr: "1 + NULL", expected: nil},
+		{expr: "NULL * 2", expected: nil},
+		{expr: "-NULL", expected: nil},
+
+		// comparisons
+		{expr: "1 = 1", expected: true},
+		{expr: "1 = 1.0", expected: true},
+		{expr: "1 <> 2", expected: true},
+		{expr: "1 != 1", expected: false},
+		{expr: "1 < 2", expected: true},
+		{expr: "2 <= 2", expected: true},
+		{expr: "3 > 2.5", expected: true},
+		{expr: "2 >= 3", expected: false},
+		{expr: "'a' < 'b'", expected: true},
+		{expr: "'10' = 10", expected: true},
+		{expr: "TRUE > FALSE", expected: true},
+		{expr: "1 = NULL", expected: nil},
+		{expr: "NULL = NULL", expected: nil},
+		{expr: "NULL <> 1", expected: nil},
+		{expr: "'nan'::DOUBLE > 1", expected: true},
+
+		// three-valued logic
+		{expr: "TRUE AND NULL", expected: nil},
+		{expr: "FALSE AND NULL", expected: false},
+		{expr: "NULL AND FALSE", expected: false},
+		{expr: "TRUE OR NULL", expected: true},
+		{expr: "NULL OR TRUE", expected: true},
+		{expr: "FALSE OR NULL", expected: nil},
+		{expr: "NOT NULL", expected: nil},
+		{expr: "NOT TRUE", expected: false},
+		{expr: "NOT 1 = 2", expected: true},
+		{expr: "TRUE OR FALSE AND FALSE", expected: true},
+		{expr: "1 AND 0", expected: false},
+
+		// IS
+		{expr: "NULL IS NULL", expected: true},
+		{expr: "1 IS NULL", expected: false},
+		{expr: "1 IS NOT NULL", expected: true},
+		{expr: "NULL IS TRUE", expected: false},
+		{expr: "NULL IS NOT TRUE", expected: true},
+		{expr: "FALSE IS FALSE", expected: true},
+		{expr: "1 = 1 IS TRUE", expected: true},
+
+		// IN
+		{expr: "2 IN (1, 2, 3)", expected: true},
+		{expr: "4 IN (1, 2, 3)", expected: false},
+		{expr: "4 NOT IN (1, 2, 3)", expected: true},
+		{expr: "2 IN (1.0, 2.0)", expected: true},
+		{expr: "'a' IN ('a', NULL)", expected: true},
+		{expr: "'b' IN ('a', NULL)", expected: nil},
+		{expr: "'b' NOT IN ('a', NULL)", expected: nil},
+		{expr: "NULL IN (1)", expected: nil},
+		{expr: "'us' IN (SELECT dc FROM B)", expected: true},
+		{expr: "'ap' NOT IN (SELECT dc FROM B)", expected: true},
+
+		// BETWEEN
+		{expr: "2 BETWEEN 1 AND 3", expected: true},
+		{expr: "3 BETWEEN 1 AND 3", expected: true},
+		{expr: "4 BETWEEN 1 AND 3", expected: false},
+		{expr: "4 NOT BETWEEN 1 AND 3", expected: true},
+		{expr: "'b' BETWEEN 'a' AND 'c'", expected: true},
+		{expr: "2 BETWEEN NULL AND 3", expected: nil},
+		{expr: "5 BETWEEN NULL AND 3", expected: false},
+		{expr: "1 + 1 BETWEEN 1 AND 1 + 1", expected: true},
+
+		// LIKE
+		{expr: "'abc' LIKE 'a%'", expected: true},
+		{expr: "'abc' LIKE 'a_c'", expected: true},
+		{expr: "'abc' LIKE 'a_'", expected: false},
+		{expr: "'abc' LIKE 'ABC'", expected: false},
+		{expr: "'abc' ILIKE 'ABC'", expected: true},
+		{expr: "'abc' NOT LIKE '%b%'", expected: false},
+		{expr: "'a.c' LIKE 'a.c'", expected: true},
+		{expr: "'abc' LIKE 'a.c'", expected: false},
+		{expr: "'50%' LIKE '50\\%'", expected: true},
+		{expr: "'500' LIKE '50\\%'", expected: false},
+		{expr: "'a\nb' LIKE 'a%b'", expected: true},
+		{expr: "NULL LIKE 'a'", expected: nil},
+		{expr: "'a' LIKE NULL", expected: nil},
+		{expr: "12 LIKE '1%'", expected: true},
+
+		// concatenation
+		{expr: "'a' || 'b' || 'c'", expected: "abc"},
+		{expr: "'a' || 1", expected: "a1"},
+		{expr: "'a' || NULL", expected: nil},
+		{expr: "'x' || 1 + 1", expected: "x2"},
+
+		// CASE
+		{expr: "CASE WHEN 1 > 2 THEN 'a' WHEN 2 > 1 THEN 'b' ELSE 'c' END", expected: "b"},
+		{expr: "CASE WHEN 1 > 2 THEN 'a' END", expected: nil},
+		{expr: "CASE WHEN NULL THEN 'a' ELSE 'b' END", expected: "b"},
+		{expr: "CASE 2 WHEN 1 THEN 'one' WHEN 2 THEN 'two' END", expected: "two"},
+		{expr: "CASE NULL WHEN NULL THEN 'null' ELSE 'other' END", expected: "other"},
+		{expr: "CASE WHEN TRUE THEN 1 ELSE 2.5 END", expected: 1.0},
+		{expr: "CASE WHEN FALSE THEN 1 ELSE 'x' END", expected: "x"},
+
+		// CAST
+		{expr: "CAST('12' AS INTEGER)", expected: int64(12)},
+		{expr: "CAST('1.6' AS BIGINT)", expected: int64(2)},
+		{expr: "CAST(2.5 AS INT)", expected: int64(3)},
+		{expr: "CAST(1 AS DOUBLE)", expected: 1.0},
+		{expr: "CAST(1.5 AS VARCHAR)", expected: "1.5"},
+		{expr: "CAST('yes' AS BOOLEAN)", expected: true},
+		{expr: "CAST(0 AS BOOLEAN)", expected: false},
+		{expr: "CAST(TRUE AS INTEGER)", expected: int64(1)},
+		{expr: "CAST(NULL AS INTEGER)", expected: nil},
+		{expr: "CAST('2024-01-01' AS TIMESTAMP)", expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "CAST(0 AS TIMESTAMP)", expected: time.Unix(0, 0).UTC()},
+		{expr: "CAST('1.5' AS DECIMAL(10, 2))", expected: 1.5},
+		{expr: "CAST(1 AS DOUBLE PRECISION)", expected: 1.0},
+		{expr: "'3'::INTEGER + 1", expected: int64(4)},
+		{expr: "1::VARCHAR::INTEGER", expected: int64(1)},
+
+		// scalar subqueries and EXISTS
+		{expr: "(SELECT max(weight) FROM B)", expected: int64(3)},
+		{expr: "(SELECT weight FROM B WHERE host = 'x')", expected: nil},
+		{expr: "(SELECT count(*) FROM B) + 1", expected: int64(4)},
+		{expr: "EXISTS (SELECT 1 FROM B WHERE dc = 'us')", expected: true},
+		{expr: "EXISTS (SELECT 1 FROM B WHERE dc = 'ap')", expected: false},
+		{expr: "NOT EXISTS (SELECT 1 FROM B WHERE dc = 'ap')", expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			v, err := evalExpr("SELECT " + tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{expr: "'a' > 1", err: `could not compare "a" with 1`},
+		{expr: "TRUE = 'x'", err: "could not compare"},
+		{expr: "'a' + 1", err: "could not convert a to DOUBLE"},
+		{expr: "CAST('x' AS INTEGER)", err: "could not convert x to BIGINT"},
+		{expr: "CAST('x' AS BOOLEAN)", err: "could not convert x to BOOLEAN"},
+		{expr: "CAST('x' AS TIMESTAMP)", err: "could not convert x to TIMESTAMP"},
+		{expr: "CAST(1e300 AS INTEGER)", err: "out of range"},
+		{expr: "NOT 'x'", err: "could not convert x to BOOLEAN"},
+		{expr: "'x' AND TRUE", err: "could not convert x to BOOLEAN"},
+		{expr: "CASE WHEN 'x' THEN 1 END", err: "could not convert x to BOOLEAN"},
+		{expr: "(SELECT host FROM B)", err: "subquery used as an expression returned more than one row"},
+		{expr: "(SELECT host, dc FROM B)", err: "subquery must return a single column, got 2"},
+		{expr: "1 IN (SELECT host, dc FROM B)", err: "IN subquery must return a single column, got 2"},
+		{expr: "now() + 1", err: "operator + is not supported for timestamps"},
+		{expr: "sum(1) OVER () + count(*) OVER (PARTITION BY row_number() OVER ())", err: "window function row_number is not allowed here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evalExpr("SELECT " + tt.expr)
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestExpressionTypes(t *testing.T) {
+	tests := []struct {
+		expr string
+		typ  Type
+	}{
+		{expr: "1 + 1", typ: TypeInt},
+		{expr: "1 / 1", typ: TypeFloat},
+		{expr: "1 + 1.5", typ: TypeFloat},
+		{expr: "-weight", typ: TypeInt},
+		{expr: "-'1'", typ: TypeFloat},
+		{expr: "host || dc", typ: TypeString},
+		{expr: "weight > 1", typ: TypeBool},
+		{expr: "NULL", typ: TypeUnknown},
+		{expr: "CAST(weight AS TEXT)", typ: TypeString},
+		{expr: "CASE WHEN TRUE THEN weight ELSE NULL END", typ: TypeInt},
+		{expr: "CASE WHEN TRUE THEN weight ELSE 1.5 END", typ: TypeFloat},
+		{expr: "(SELECT max(time) FROM A)", typ: TypeTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Parse("SELECT " + tt.expr + " FROM B WHERE weight = 1")
+			require.NoError(t, err)
+			res, err := Execute(context.Background(), q, testTables(), Limits{})
+			require.NoError(t, err)
+			require.Len(t, res.Columns, 1)
+			require.Equal(t, tt.typ, res.Columns[0].Type)
+		})
+	}
+}
+
+func TestLikeToRegexp(t *testing.T) {
+	re, err := likeToRegexp("a%b_c\\_", false)
+	require.NoError(t, err)
+	require.Equal(t, `^(?s)a.*b.c_$`, re.String())
+
+	re, err = likeToRegexp("(x)%", true)
+	require.NoError(t, err)
+	require.True(t, re.MatchString("(X)yz"))
+	require.False(t, re.MatchString("x"))
+}
+
+func TestFormatExpr(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: "a", expected: "a"},
+		{expr: "t.a", expected: "a"},
+		{expr: "1", expected: "1"},
+		{expr: "1.5", expected: "1.5"},
+		{expr: "'it''s'", expected: "'it''s'"},
+		{expr: "NULL", expected: "NULL"},
+		{expr: "a + 1", expected: "(a + 1)"},
+		{expr: "NOT a", expected: "NOT a"},
+		{expr: "-a", expected: "-a"},
+		{expr: "count(*)", expected: "count(*)"},
+		{expr: "count(DISTINCT a)", expected: "count(DISTINCT a)"},
+		{expr: "round(a, 2)", expected: "round(a, 2)"},
+		{expr: "sum(a) OVER ()", expected: "sum(a) OVER (...)"},
+		{expr: "CAST(a AS INTEGER)", expected: "CAST(a AS BIGINT)"},
+		{expr: "a IS NULL", expected: "(a IS NULL)"},
+		{expr: "a IS NOT NULL", expected: "(a IS NOT NULL)"},
+		{expr: "a IN (1)", expected: "?column?"},
+		{expr: "CASE WHEN a THEN 1 END", expected: "CASE ... END"},
+		{expr: "(SELECT 1)", expected: "(SELECT ...)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Parse("SELECT " + tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, formatExpr(q.Body.(*Select).Items[0].Expr))
+		})
+	}
+}
+
+func TestNaNAndInfinity(t *testing.T) {
+	v, err := evalExpr("SELECT 'inf'::DOUBLE")
+	require.NoError(t, err)
+	require.True(t, math.IsInf(v.(float64), 1))
+
+	v, err = evalExpr("SELECT 'nan'::DOUBLE")
+	require.NoError(t, err)
+	require.True(t, math.IsNaN(v.(float64)))
+
+	v, err = evalExpr("SELECT 'nan'::DOUBLE = 'nan'::DOUBLE")
+	require.NoError(t, err)
+	require.Equal(t, true, v)
+}
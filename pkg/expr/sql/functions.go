@@ -0,0 +1,495 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type scalarFunc struct {
+	minArgs int
+	// maxArgs is -1 for variadic functions.
+	maxArgs int
+	// nullable functions are called with NULL arguments, all other functions return NULL if any argument is NULL.
+	nullable bool
+	returns  func(args []Type) Type
+	call     func(args []any) (any, error)
+}
+
+func returns(t Type) func([]Type) Type {
+	return func([]Type) Type { return t }
+}
+
+// returnsArg returns the type of the first argument.
+func returnsArg(args []Type) Type {
+	if len(args) == 0 {
+		return TypeUnknown
+	}
+	return args[0]
+}
+
+// returnsCommon returns the common type of all arguments.
+func returnsCommon(args []Type) Type {
+	t := TypeUnknown
+	for _, a := range args {
+		t = commonType(t, a)
+	}
+	return t
+}
+
+// returnsNumeric returns the type of the first argument if it is an integer and float otherwise.
+func returnsNumeric(args []Type) Type {
+	if len(args) > 0 && args[0] == TypeInt {
+		return TypeInt
+	}
+	return TypeFloat
+}
+
+func mathFunc(f func(float64) float64) scalarFunc {
+	return scalarFunc{minArgs: 1, maxArgs: 1, returns: returns(TypeFloat), call: func(args []any) (any, error) {
+		x, err := floatArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return f(x), nil
+	}}
+}
+
+func floatArg(v any) (float64, error) {
+	f, err := convert(v, TypeFloat)
+	if err != nil {
+		return 0, err
+	}
+	return f.(float64), nil
+}
+
+func intArg(v any) (int64, error) {
+	i, err := convert(v, TypeInt)
+	if err != nil {
+		return 0, err
+	}
+	return i.(int64), nil
+}
+
+func timeArg(v any) (time.Time, error) {
+	t, err := convert(v, TypeTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.(time.Time), nil
+}
+
+func stringFunc(f func(string) string) scalarFunc {
+	return scalarFunc{minArgs: 1, maxArgs: 1, returns: returns(TypeString), call: func(args []any) (any, error) {
+		return f(formatValue(args[0])), nil
+	}}
+}
+
+var scalarFuncs map[string]scalarFunc
+
+func init() {
+	scalarFuncs = map[string]scalarFunc{
+		"abs": {minArgs: 1, maxArgs: 1, returns: returnsNumeric, call: func(args []any) (any, error) {
+			if i, ok := args[0].(int64); ok {
+				if i < 0 {
+					return -i, nil
+				}
+				return i, nil
+			}
+			x, err := floatArg(args[0])
+			return math.Abs(x), err
+		}},
+		"ceil":    mathFunc(math.Ceil),
+		"ceiling": mathFunc(math.Ceil),
+		"floor":   mathFunc(math.Floor),
+		"trunc":   mathFunc(math.Trunc),
+		"sqrt":    mathFunc(math.Sqrt),
+		"cbrt":    mathFunc(math.Cbrt),
+		"exp":     mathFunc(math.Exp),
+		"ln":      mathFunc(math.Log),
+		"log":     mathFunc(math.Log10),
+		"log10":   mathFunc(math.Log10),
+		"log2":    mathFunc(math.Log2),
+		"sin":     mathFunc(math.Sin),
+		"cos":     mathFunc(math.Cos),
+		"tan":     mathFunc(math.Tan),
+		"sign": {minArgs: 1, maxArgs: 1, returns: returns(TypeInt), call: func(args []any) (any, error) {
+			x, err := floatArg(args[0])
+			switch {
+			case err != nil || math.IsNaN(x):
+				return nil, err
+			case x > 0:
+				return int64(1), nil
+			case x < 0:
+				return int64(-1), nil
+			}
+			return int64(0), nil
+		}},
+		"round": {minArgs: 1, maxArgs: 2, returns: returns(TypeFloat), call: func(args []any) (any, error) {
+			x, err := floatArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			var digits int64
+			if len(args) > 1 {
+				if digits, err = intArg(args[1]); err != nil {
+					return nil, err
+				}
+			}
+			p := math.Pow(10, float64(digits))
+			return math.Round(x*p) / p, nil
+		}},
+		"pow":   {minArgs: 2, maxArgs: 2, returns: returns(TypeFloat), call: power},
+		"power": {minArgs: 2, maxArgs: 2, returns: returns(TypeFloat), call: power},
+		"mod": {minArgs: 2, maxArgs: 2, returns: returnsCommon, call: func(args []any) (any, error) {
+			if a, ok := args[0].(int64); ok {
+				if b, ok := args[1].(int64); ok {
+					if b == 0 {
+						return nil, nil
+					}
+					return a % b, nil
+				}
+			}
+			a, err := floatArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			b, err := floatArg(args[1])
+			if err != nil || b == 0 {
+				return nil, err
+			}
+			return math.Mod(a, b), nil
+		}},
+		"pi": {minArgs: 0, maxArgs: 0, returns: returns(TypeFloat), call: func([]any) (any, error) {
+			return math.Pi, nil
+		}},
+		"isnan": {minArgs: 1, maxArgs: 1, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			x, err := floatArg(args[0])
+			return math.IsNaN(x), err
+		}},
+		"isinf": {minArgs: 1, maxArgs: 1, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			x, err := floatArg(args[0])
+			return math.IsInf(x, 0), err
+		}},
+		"isfinite": {minArgs: 1, maxArgs: 1, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			x, err := floatArg(args[0])
+			return !math.IsInf(x, 0) && !math.IsNaN(x), err
+		}},
+		"greatest": {minArgs: 1, maxArgs: -1, nullable: true, returns: returnsCommon, call: func(args []any) (any, error) {
+			return extreme(args, 1)
+		}},
+		"least": {minArgs: 1, maxArgs: -1, nullable: true, returns: returnsCommon, call: func(args []any) (any, error) {
+			return extreme(args, -1)
+		}},
+		"coalesce": {minArgs: 1, maxArgs: -1, nullable: true, returns: returnsCommon, call: coalesce},
+		"ifnull":   {minArgs: 2, maxArgs: 2, nullable: true, returns: returnsCommon, call: coalesce},
+		"nullif": {minArgs: 2, maxArgs: 2, nullable: true, returns: returnsArg, call: func(args []any) (any, error) {
+			if args[0] == nil || args[1] == nil {
+				return args[0], nil
+			}
+			c, err := compareValues(args[0], args[1])
+			if err != nil || c == 0 {
+				return nil, err
+			}
+			return args[0], nil
+		}},
+		"if": {minArgs: 3, maxArgs: 3, nullable: true, returns: func(args []Type) Type {
+			return commonType(args[1], args[2])
+		}, call: func(args []any) (any, error) {
+			if args[0] != nil {
+				b, err := convert(args[0], TypeBool)
+				if err != nil {
+					return nil, err
+				}
+				if b.(bool) {
+					return args[1], nil
+				}
+			}
+			return args[2], nil
+		}},
+
+		"lower": stringFunc(strings.ToLower),
+		"upper": stringFunc(strings.ToUpper),
+		"trim":  stringFunc(strings.TrimSpace),
+		"ltrim": stringFunc(func(s string) string { return strings.TrimLeft(s, " \t\n\r") }),
+		"rtrim": stringFunc(func(s string) string { return strings.TrimRight(s, " \t\n\r") }),
+		"length": {minArgs: 1, maxArgs: 1, returns: returns(TypeInt), call: func(args []any) (any, error) {
+			return int64(utf8.RuneCountInString(formatValue(args[0]))), nil
+		}},
+		"concat": {minArgs: 1, maxArgs: -1, nullable: true, returns: returns(TypeString), call: func(args []any) (any, error) {
+			var sb strings.Builder
+			for _, a := range args {
+				if a != nil {
+					sb.WriteString(formatValue(a))
+				}
+			}
+			return sb.String(), nil
+		}},
+		"concat_ws": {minArgs: 2, maxArgs: -1, nullable: true, returns: returns(TypeString), call: func(args []any) (any, error) {
+			if args[0] == nil {
+				return nil, nil
+			}
+			parts := make([]string, 0, len(args)-1)
+			for _, a := range args[1:] {
+				if a != nil {
+					parts = append(parts, formatValue(a))
+				}
+			}
+			return strings.Join(parts, formatValue(args[0])), nil
+		}},
+		"substr":    {minArgs: 2, maxArgs: 3, returns: returns(TypeString), call: substring},
+		"substring": {minArgs: 2, maxArgs: 3, returns: returns(TypeString), call: substring},
+		"left": {minArgs: 2, maxArgs: 2, returns: returns(TypeString), call: func(args []any) (any, error) {
+			n, err := intArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			r := []rune(formatValue(args[0]))
+			n = clamp(n, int64(len(r)))
+			return string(r[:n]), nil
+		}},
+		"right": {minArgs: 2, maxArgs: 2, returns: returns(TypeString), call: func(args []any) (any, error) {
+			n, err := intArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			r := []rune(formatValue(args[0]))
+			n = clamp(n, int64(len(r)))
+			return string(r[int64(len(r))-n:]), nil
+		}},
+		"replace": {minArgs: 3, maxArgs: 3, returns: returns(TypeString), call: func(args []any) (any, error) {
+			return strings.ReplaceAll(formatValue(args[0]), formatValue(args[1]), formatValue(args[2])), nil
+		}},
+		"contains": {minArgs: 2, maxArgs: 2, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			return strings.Contains(formatValue(args[0]), formatValue(args[1])), nil
+		}},
+		"starts_with": {minArgs: 2, maxArgs: 2, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			return strings.HasPrefix(formatValue(args[0]), formatValue(args[1])), nil
+		}},
+		"ends_with": {minArgs: 2, maxArgs: 2, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			return strings.HasSuffix(formatValue(args[0]), formatValue(args[1])), nil
+		}},
+		"regexp_matches": {minArgs: 2, maxArgs: 2, returns: returns(TypeBool), call: func(args []any) (any, error) {
+			re, err := compileRegexp(formatValue(args[1]))
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(formatValue(args[0])), nil
+		}},
+		"regexp_replace": {minArgs: 3, maxArgs: 3, returns: returns(TypeString), call: func(args []any) (any, error) {
+			re, err := compileRegexp(formatValue(args[1]))
+			if err != nil {
+				return nil, err
+			}
+			return re.ReplaceAllString(formatValue(args[0]), formatValue(args[2])), nil
+		}},
+
+		"now": {minArgs: 0, maxArgs: 0, returns: returns(TypeTime), call: func([]any) (any, error) {
+			return timeNow().UTC(), nil
+		}},
+		"epoch": {minArgs: 1, maxArgs: 1, returns: returns(TypeFloat), call: func(args []any) (any, error) {
+			t, err := timeArg(args[0])
+			return float64(t.UnixNano()) / float64(time.Second), err
+		}},
+		"epoch_ms": {minArgs: 1, maxArgs: 1, returns: func(args []Type) Type {
+			// epoch_ms(timestamp) returns milliseconds, epoch_ms(milliseconds) returns a timestamp
+			if len(args) == 1 && args[0].numeric() {
+				return TypeTime
+			}
+			return TypeInt
+		}, call: func(args []any) (any, error) {
+			if _, ok := toFloat(args[0]); ok {
+				return convert(args[0], TypeTime)
+			}
+			t, err := timeArg(args[0])
+			return t.UnixMilli(), err
+		}},
+		"to_timestamp": {minArgs: 1, maxArgs: 1, returns: returns(TypeTime), call: func(args []any) (any, error) {
+			sec, err := floatArg(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return time.Unix(0, int64(sec*float64(time.Second))).UTC(), nil
+		}},
+		"date_trunc": {minArgs: 2, maxArgs: 2, returns: returns(TypeTime), call: func(args []any) (any, error) {
+			t, err := timeArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return truncateTime(formatValue(args[0]), t)
+		}},
+		"date_part": {minArgs: 2, maxArgs: 2, returns: returns(TypeInt), call: func(args []any) (any, error) {
+			t, err := timeArg(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return timePart(formatValue(args[0]), t)
+		}},
+	}
+}
+
+// timeNow is replaced in tests.
+var timeNow = time.Now
+
+func power(args []any) (any, error) {
+	x, err := floatArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := floatArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return math.Pow(x, y), nil
+}
+
+func coalesce(args []any) (any, error) {
+	for _, a := range args {
+		if a != nil {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+// extreme returns the largest (dir 1) or smallest (dir -1) non-NULL argument.
+func extreme(args []any, dir int) (any, error) {
+	var res any
+	for _, a := range args {
+		if a == nil {
+			continue
+		}
+		if res == nil {
+			res = a
+			continue
+		}
+		c, err := compareValues(a, res)
+		if err != nil {
+			return nil, err
+		}
+		if c*dir > 0 {
+			res = a
+		}
+	}
+	return res, nil
+}
+
+// substring returns the characters starting at the 1-based position start.
+func substring(args []any) (any, error) {
+	r := []rune(formatValue(args[0]))
+	start, err := intArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end := int64(len(r))
+	if len(args) > 2 {
+		n, err := intArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("negative substring length")
+		}
+		end = start - 1 + n
+	}
+	begin := clamp(start-1, int64(len(r)))
+	end = clamp(end, int64(len(r)))
+	if end <= begin {
+		return "", nil
+	}
+	return string(r[begin:end]), nil
+}
+
+func clamp(n, max int64) int64 {
+	switch {
+	case n < 0:
+		return 0
+	case n > max:
+		return max
+	}
+	return n
+}
+
+var regexpCache = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: map[string]*regexp.Regexp{}}
+
+// compileRegexp compiles the pattern. Patterns are usually constant, so the most recent ones are cached.
+func compileRegexp(pattern string) (*regexp.Regexp, error) {
+	regexpCache.Lock()
+	defer regexpCache.Unlock()
+	if re, ok := regexpCache.m[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexpCache.m) >= 100 {
+		clear(regexpCache.m)
+	}
+	regexpCache.m[pattern] = re
+	return re, nil
+}
+
+func truncateTime(part string, t time.Time) (any, error) {
+	switch strings.ToLower(part) {
+	case "millisecond", "milliseconds":
+		return t.Truncate(time.Millisecond), nil
+	case "second", "seconds":
+		return t.Truncate(time.Second), nil
+	case "minute", "minutes":
+		return t.Truncate(time.Minute), nil
+	case "hour", "hours":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()), nil
+	case "day", "days":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	case "week", "weeks":
+		// weeks start on Monday
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7)), nil
+	case "month", "months":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+	case "quarter", "quarters":
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, t.Location()), nil
+	case "year", "years":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return nil, fmt.Errorf("unsupported date part %q", part)
+}
+
+func timePart(part string, t time.Time) (any, error) {
+	switch strings.ToLower(part) {
+	case "millisecond", "milliseconds":
+		return int64(t.Second()*1000 + t.Nanosecond()/int(time.Millisecond)), nil
+	case "second", "seconds":
+		return int64(t.Second()), nil
+	case "minute", "minutes":
+		return int64(t.Minute()), nil
+	case "hour", "hours":
+		return int64(t.Hour()), nil
+	case "day", "days":
+		return int64(t.Day()), nil
+	case "dow", "dayofweek":
+		return int64(t.Weekday()), nil
+	case "doy", "dayofyear":
+		return int64(t.YearDay()), nil
+	case "week", "weeks":
+		_, w := t.ISOWeek()
+		return int64(w), nil
+	case "month", "months":
+		return int64(t.Month()), nil
+	case "quarter", "quarters":
+		return int64((t.Month()-1)/3 + 1), nil
+	case "year", "years":
+		return int64(t.Year()), nil
+	case "epoch":
+		return t.Unix(), nil
+	}
+	return nil, fmt.Errorf("unsupported date part %q", part)
+}
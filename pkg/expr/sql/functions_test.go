@@ -0,0 +1,224 @@
+package sql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScalarFunctions(t *testing.T) {
+	ts := "CAST('2024-05-15 13:45:30.250' AS TIMESTAMP)"
+	tests := []struct {
+		expr     string
+		expected any
+	}{
+		// math
+		{expr: "abs(-2)", expected: int64(2)},
+		{expr: "abs(2)", expected: int64(2)},
+		{expr: "abs(-2.5)", expected: 2.5},
+		{expr: "abs(NULL)", expected: nil},
+		{expr: "ceil(1.2)", expected: 2.0},
+		{expr: "ceiling(-1.2)", expected: -1.0},
+		{expr: "floor(1.8)", expected: 1.0},
+		{expr: "trunc(-1.8)", expected: -1.0},
+		{expr: "sqrt(16)", expected: 4.0},
+		{expr: "cbrt(27)", expected: 3.0},
+		{expr: "exp(0)", expected: 1.0},
+		{expr: "ln(1)", expected: 0.0},
+		{expr: "log(100)", expected: 2.0},
+		{expr: "log10(1000)", expected: 3.0},
+		{expr: "log2(8)", expected: 3.0},
+		{expr: "sin(0)", expected: 0.0},
+		{expr: "cos(0)", expected: 1.0},
+		{expr: "tan(0)", expected: 0.0},
+		{expr: "sign(-3)", expected: int64(-1)},
+		{expr: "sign(0.0)", expected: int64(0)},
+		{expr: "sign(2.5)", expected: int64(1)},
+		{expr: "sign('nan'::DOUBLE)", expected: nil},
+		{expr: "round(2.5)", expected: 3.0},
+		{expr: "round(-2.5)", expected: -3.0},
+		{expr: "round(1.2345, 2)", expected: 1.23},
+		{expr: "round(1234, -2)", expected: 1200.0},
+		{expr: "pow(2, 10)", expected: 1024.0},
+		{expr: "power(9, 0.5)", expected: 3.0},
+		{expr: "mod(7, 3)", expected: int64(1)},
+		{expr: "mod(-7, 3)", expected: int64(-1)},
+		{expr: "mod(7.5, 2)", expected: 1.5},
+		{expr: "mod(7, 0)", expected: nil},
+		{expr: "mod(7.5, 0)", expected: nil},
+		{expr: "pi()", expected: math.Pi},
+		{expr: "isnan('nan'::DOUBLE)", expected: true},
+		{expr: "isnan(1)", expected: false},
+		{expr: "isinf('-inf'::DOUBLE)", expected: true},
+		{expr: "isfinite(1.5)", expected: true},
+		{expr: "isfinite('inf'::DOUBLE)", expected: false},
+		{expr: "greatest(1, 3, 2)", expected: int64(3)},
+		{expr: "greatest(1, NULL, 2.5)", expected: 2.5},
+		{expr: "least('b', 'a', 'c')", expected: "a"},
+		{expr: "least(NULL, NULL)", expected: nil},
+
+		// NULL handling
+		{expr: "coalesce(NULL, NULL, 3)", expected: int64(3)},
+		{expr: "coalesce(NULL, 1, 2.5)", expected: 1.0},
+		{expr: "coalesce(NULL)", expected: nil},
+		{expr: "ifnull(NULL, 'x')", expected: "x"},
+		{expr: "ifnull('y', 'x')", expected: "y"},
+		{expr: "nullif(1, 1)", expected: nil},
+		{expr: "nullif(1, 2)", expected: int64(1)},
+		{expr: "nullif(NULL, 1)", expected: nil},
+		{expr: "nullif(1, NULL)", expected: int64(1)},
+		{expr: "if(1 < 2, 'yes', 'no')", expected: "yes"},
+		{expr: "if(NULL, 'yes', 'no')", expected: "no"},
+		{expr: "if(TRUE, 1, 2.5)", expected: 1.0},
+
+		// strings
+		{expr: "lower('AbC')", expected: "abc"},
+		{expr: "upper('AbC')", expected: "ABC"},
+		{expr: "trim('  a  ')", expected: "a"},
+		{expr: "ltrim('  a  ')", expected: "a  "},
+		{expr: "rtrim('  a  ')", expected: "  a"},
+		{expr: "length('héllo')", expected: int64(5)},
+		{expr: "length(123)", expected: int64(3)},
+		{expr: "concat('a', NULL, 1, TRUE)", expected: "a1true"},
+		{expr: "concat(NULL)", expected: ""},
+		{expr: "concat_ws('-', 'a', NULL, 'b')", expected: "a-b"},
+		{expr: "concat_ws(NULL, 'a', 'b')", expected: nil},
+		{expr: "substr('hello', 2)", expected: "ello"},
+		{expr: "substring('hello', 2, 3)", expected: "ell"},
+		{expr: "substring('hello', 0, 2)", expected: "h"},
+		{expr: "substring('hello', 4, 10)", expected: "lo"},
+		{expr: "substring('hello', 10)", expected: ""},
+		{expr: "substring('héllo', 2, 1)", expected: "é"},
+		{expr: "left('hello', 2)", expected: "he"},
+		{expr: "left('hello', 10)", expected: "hello"},
+		{expr: "left('hello', -1)", expected: ""},
+		{expr: "right('hello', 2)", expected: "lo"},
+		{expr: "right('hello', 10)", expected: "hello"},
+		{expr: "replace('a-b-c', '-', '+')", expected: "a+b+c"},
+		{expr: "contains('hello', 'ell')", expected: true},
+		{expr: "starts_with('hello', 'he')", expected: true},
+		{expr: "ends_with('hello', 'he')", expected: false},
+		{expr: "regexp_matches('abc123', '[0-9]+$')", expected: true},
+		{expr: "regexp_matches('abc', '^b')", expected: false},
+		{expr: "regexp_replace('a1b22', '[0-9]+', '#')", expected: "a#b#"},
+		{expr: "regexp_replace('host-1', '(\\w+)-(\\d)', '${2}_${1}')", expected: "1_host"},
+		{expr: "upper(NULL)", expected: nil},
+		{expr: "replace('a', NULL, 'b')", expected: nil},
+
+		// time
+		{expr: "epoch(" + ts + ")", expected: 1715780730.25},
+		{expr: "epoch_ms(" + ts + ")", expected: int64(1715780730250)},
+		{expr: "epoch_ms(1715780730250)", expected: time.Date(2024, 5, 15, 13, 45, 30, 250000000, time.UTC)},
+		{expr: "to_timestamp(1715780730.5)", expected: time.Date(2024, 5, 15, 13, 45, 30, 500000000, time.UTC)},
+		{expr: "epoch('2024-01-01')", expected: 1704067200.0},
+		{expr: "date_trunc('millisecond', " + ts + ")", expected: time.Date(2024, 5, 15, 13, 45, 30, 250000000, time.UTC)},
+		{expr: "date_trunc('second', " + ts + ")", expected: time.Date(2024, 5, 15, 13, 45, 30, 0, time.UTC)},
+		{expr: "date_trunc('minute', " + ts + ")", expected: time.Date(2024, 5, 15, 13, 45, 0, 0, time.UTC)},
+		{expr: "date_trunc('HOUR', " + ts + ")", expected: time.Date(2024, 5, 15, 13, 0, 0, 0, time.UTC)},
+		{expr: "date_trunc('day', " + ts + ")", expected: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{expr: "date_trunc('week', " + ts + ")", expected: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{expr: "date_trunc('week', '2024-05-19')", expected: time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{expr: "date_trunc('month', " + ts + ")", expected: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "date_trunc('quarter', " + ts + ")", expected: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "date_trunc('year', " + ts + ")", expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "date_part('millisecond', " + ts + ")", expected: int64(30250)},
+		{expr: "date_part('second', " + ts + ")", expected: int64(30)},
+		{expr: "date_part('minute', " + ts + ")", expected: int64(45)},
+		{expr: "date_part('hour', " + ts + ")", expected: int64(13)},
+		{expr: "date_part('day', " + ts + ")", expected: int64(15)},
+		{expr: "date_part('dow', " + ts + ")", expected: int64(3)},
+		{expr: "date_part('doy', " + ts + ")", expected: int64(136)},
+		{expr: "date_part('week', " + ts + ")", expected: int64(20)},
+		{expr: "date_part('month', " + ts + ")", expected: int64(5)},
+		{expr: "date_part('quarter', " + ts + ")", expected: int64(2)},
+		{expr: "date_part('year', " + ts + ")", expected: int64(2024)},
+		{expr: "date_part('epoch', " + ts + ")", expected: int64(1715780730)},
+		{expr: "date_part('year', NULL)", expected: nil},
+
+		// function names are case-insensitive
+		{expr: "ABS(-1)", expected: int64(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			v, err := evalExpr("SELECT " + tt.expr)
+			require.NoError(t, err)
+			if f, ok := tt.expected.(float64); ok {
+				require.InDelta(t, f, v, 1e-6)
+				return
+			}
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestNow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("", 3600))
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+
+	v, err := evalExpr("SELECT now()")
+	require.NoError(t, err)
+	require.Equal(t, now.UTC(), v)
+}
+
+func TestScalarFunctionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{expr: "foo(1)", err: "unknown function foo"},
+		{expr: "abs()", err: "wrong number of arguments to function abs"},
+		{expr: "abs(1, 2)", err: "wrong number of arguments to function abs"},
+		{expr: "pi(1)", err: "wrong number of arguments to function pi"},
+		{expr: "concat_ws('-')", err: "wrong number of arguments to function concat_ws"},
+		{expr: "upper(*)", err: "upper is not an aggregate function"},
+		{expr: "upper(DISTINCT 'a')", err: "upper is not an aggregate function"},
+		{expr: "upper('a') FILTER (WHERE TRUE)", err: "upper is not an aggregate function"},
+		{expr: "sqrt('x')", err: "sqrt: could not convert x to DOUBLE"},
+		{expr: "round(1.5, 'x')", err: "round: could not convert x to BIGINT"},
+		{expr: "substring('abc', 1, -1)", err: "substring: negative substring length"},
+		{expr: "left('abc', 'x')", err: "left: could not convert x to BIGINT"},
+		{expr: "regexp_matches('a', '(')", err: "regexp_matches: error parsing regexp"},
+		{expr: "greatest(1, 'x')", err: `greatest: could not compare "x" with 1`},
+		{expr: "nullif('x', 1)", err: `nullif: could not compare "x" with 1`},
+		{expr: "if('x', 1, 2)", err: "if: could not convert x to BOOLEAN"},
+		{expr: "epoch('x')", err: "epoch: could not convert x to TIMESTAMP"},
+		{expr: "date_trunc('decade', now())", err: `date_trunc: unsupported date part "decade"`},
+		{expr: "date_part('decade', now())", err: `date_part: unsupported date part "decade"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := evalExpr("SELECT " + tt.expr)
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestScalarFunctionTypes(t *testing.T) {
+	tests := []struct {
+		expr string
+		typ  Type
+	}{
+		{expr: "abs(weight)", typ: TypeInt},
+		{expr: "abs(1.5)", typ: TypeFloat},
+		{expr: "round(weight)", typ: TypeFloat},
+		{expr: "mod(weight, 2)", typ: TypeInt},
+		{expr: "mod(weight, 2.0)", typ: TypeFloat},
+		{expr: "coalesce(NULL, weight)", typ: TypeInt},
+		{expr: "coalesce(weight, host)", typ: TypeString},
+		{expr: "nullif(weight, 1)", typ: TypeInt},
+		{expr: "length(host)", typ: TypeInt},
+		{expr: "epoch_ms(weight)", typ: TypeTime},
+		{expr: "epoch_ms(now())", typ: TypeInt},
+		{expr: "date_trunc('day', now())", typ: TypeTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			res := execute(t, "SELECT "+tt.expr+" FROM B")
+			require.Equal(t, tt.typ, res.Columns[0].Type)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (ex *executor) join(j *Join, ctes map[string]*relation) (*relation, error) {
+	left, err := ex.from(j.Left, ctes)
+	if err != nil {
+		return nil, err
+	}
+	right, err := ex.from(j.Right, ctes)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &relation{cols: make([]relColumn, 0, len(left.cols)+len(right.cols))}
+	out.cols = append(out.cols, left.cols...)
+	out.cols = append(out.cols, right.cols...)
+	on := j.On
+	if len(j.Using) > 0 {
+		on, err = usingCondition(j.Using, left, right, out)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cond *compiled
+	if on != nil {
+		cond, err = newScope(ex, out, ctes).compile(on)
+		if err != nil {
+			return nil, fmt.Errorf("JOIN: %w", err)
+		}
+	}
+
+	// candidates returns the right rows that may match a left row
+	allRight := make([]int, len(right.rows))
+	for i := range allRight {
+		allRight[i] = i
+	}
+	candidates := func(lrow []any) ([]int, error) { return allRight, nil }
+	if on != nil {
+		if lkeys, rkeys := equiJoinKeys(ex, on, left, right, ctes); len(lkeys) > 0 {
+			index := map[string][]int{}
+			r := &row{}
+			for i, vals := range right.rows {
+				r.vals = vals
+				key, ok, err := joinKey(rkeys, r)
+				if err != nil {
+					return nil, fmt.Errorf("JOIN: %w", err)
+				}
+				if ok {
+					index[string(key)] = append(index[string(key)], i)
+				}
+			}
+			lr := &row{}
+			candidates = func(lrow []any) ([]int, error) {
+				lr.vals = lrow
+				key, ok, err := joinKey(lkeys, lr)
+				if err != nil || !ok {
+					return nil, err
+				}
+				return index[string(key)], nil
+			}
+		}
+	}
+
+	combine := func(l, r []any) []any {
+		vals := make([]any, 0, len(out.cols))
+		if l == nil {
+			l = make([]any, len(left.cols))
+		}
+		if r == nil {
+			r = make([]any, len(right.cols))
+		}
+		vals = append(vals, l...)
+		return append(vals, r...)
+	}
+
+	matchedRight := make([]bool, len(right.rows))
+	r := &row{}
+	for _, lrow := range left.rows {
+		idx, err := candidates(lrow)
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, i := range idx {
+			if err := ex.tick(); err != nil {
+				return nil, err
+			}
+			vals := combine(lrow, right.rows[i])
+			if cond != nil {
+				r.vals = vals
+				ok, err := evalBool(cond, r)
+				if err != nil {
+					return nil, fmt.Errorf("JOIN: %w", err)
+				}
+				if !ok {
+					continue
+				}
+			}
+			matched = true
+			matchedRight[i] = true
+			if err := ex.add(out, vals); err != nil {
+				return nil, err
+			}
+		}
+		if !matched && (j.Kind == "LEFT" || j.Kind == "FULL") {
+			if err := ex.add(out, combine(lrow, nil)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if j.Kind == "RIGHT" || j.Kind == "FULL" {
+		for i, rrow := range right.rows {
+			if matchedRight[i] {
+				continue
+			}
+			if err := ex.add(out, combine(nil, rrow)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// usingCondition returns the join condition for JOIN ... USING (columns) and hides the right hand
+// columns, so that they are only returned once.
+func usingCondition(using []string, left, right, out *relation) (Expr, error) {
+	var on Expr
+	for _, name := range using {
+		li, err := left.resolve("", name)
+		if err != nil {
+			return nil, fmt.Errorf("JOIN USING: %w", err)
+		}
+		ri, err := right.resolve("", name)
+		if err != nil {
+			return nil, fmt.Errorf("JOIN USING: %w", err)
+		}
+		out.cols[len(left.cols)+ri].hidden = true
+		var eq Expr = &BinaryExpr{
+			Op:    "=",
+			Left:  &ColumnRef{Table: left.cols[li].table, Name: left.cols[li].name},
+			Right: &ColumnRef{Table: right.cols[ri].table, Name: right.cols[ri].name},
+		}
+		if strings.EqualFold(left.cols[li].table, right.cols[ri].table) {
+			return nil, fmt.Errorf("JOIN USING: both tables are named %q, use an alias", left.cols[li].table)
+		}
+		if on == nil {
+			on = eq
+		} else {
+			on = &BinaryExpr{Op: "AND", Left: on, Right: eq}
+		}
+	}
+	return on, nil
+}
+
+// equiJoinKeys finds the equality conditions of the join condition that compare an expression over
+// the left table with an expression over the right table, so that the join can use a hash table.
+// The full join condition is still evaluated for the rows with equal keys.
+func equiJoinKeys(ex *executor, on Expr, left, right *relation, ctes map[string]*relation) (lkeys, rkeys []*compiled) {
+	for _, c := range conjuncts(on) {
+		b, ok := c.(*BinaryExpr)
+		if !ok || b.Op != "=" {
+			continue
+		}
+		for _, sides := range [][2]Expr{{b.Left, b.Right}, {b.Right, b.Left}} {
+			l, r := sides[0], sides[1]
+			if !onlyIn(l, left, right) || !onlyIn(r, right, left) {
+				continue
+			}
+			lc, lerr := newScope(ex, left, ctes).compile(l)
+			rc, rerr := newScope(ex, right, ctes).compile(r)
+			if lerr != nil || rerr != nil || !hashable(lc.typ, rc.typ) {
+				continue
+			}
+			lkeys = append(lkeys, lc)
+			rkeys = append(rkeys, rc)
+			break
+		}
+	}
+	return lkeys, rkeys
+}
+
+// onlyIn reports whether the expression references columns of rel and none of other.
+func onlyIn(e Expr, rel, other *relation) bool {
+	found, only := false, true
+	walkExpr(e, func(e Expr) bool {
+		switch e := e.(type) {
+		case *ColumnRef:
+			if _, err := rel.resolve(e.Table, e.Name); err != nil {
+				only = false
+			}
+			if _, err := other.resolve(e.Table, e.Name); err == nil {
+				only = false
+			}
+			found = true
+		case *SubqueryExpr, *ExistsExpr:
+			only = false
+		}
+		return only
+	})
+	return found && only
+}
+
+// hashable reports whether values of the types compare equal exactly when their hash keys are equal.
+func hashable(a, b Type) bool {
+	if a.numeric() && b.numeric() {
+		return true
+	}
+	return a == b && a != TypeUnknown
+}
+
+func conjuncts(e Expr) []Expr {
+	if b, ok := e.(*BinaryExpr); ok && b.Op == "AND" {
+		return append(conjuncts(b.Left), conjuncts(b.Right)...)
+	}
+	return []Expr{e}
+}
+
+// joinKey returns the hash key of the join columns. ok is false if any of the values is NULL,
+// because NULL never equals another value.
+func joinKey(keys []*compiled, r *row) ([]byte, bool, error) {
+	var key []byte
+	for _, k := range keys {
+		v, err := k.fn(r)
+		if err != nil {
+			return nil, false, err
+		}
+		if v == nil {
+			return nil, false, nil
+		}
+		key = appendKey(key, v)
+		key = append(key, 0)
+	}
+	return key, true, nil
+}
@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"testing"
+)
+
+func TestJoins(t *testing.T) {
+	// h has the hosts a and b of A, B has the hosts a, b and c
+	const h = "(SELECT DISTINCT host FROM A) h"
+	testQueries(t, []queryTest{
+		{
+			name:  "inner join",
+			query: "SELECT B.host, h.host FROM B JOIN " + h + " ON B.host = h.host ORDER BY 1",
+			rows:  [][]any{{"a", "a"}, {"b", "b"}},
+		},
+		{
+			name:  "inner join keyword",
+			query: "SELECT B.host FROM B INNER JOIN " + h + " ON B.host = h.host ORDER BY 1",
+			rows:  [][]any{{"a"}, {"b"}},
+		},
+		{
+			name:  "left join",
+			query: "SELECT B.host, h.host FROM B LEFT JOIN " + h + " ON B.host = h.host ORDER BY 1",
+			rows:  [][]any{{"a", "a"}, {"b", "b"}, {"c", nil}},
+		},
+		{
+			name:  "left outer join",
+			query: "SELECT B.host, h.host FROM B LEFT OUTER JOIN " + h + " ON B.host = h.host WHERE h.host IS NULL",
+			rows:  [][]any{{"c", nil}},
+		},
+		{
+			name:  "right join",
+			query: "SELECT h.host, B.host FROM " + h + " RIGHT JOIN B ON B.host = h.host ORDER BY 2",
+			rows:  [][]any{{"a", "a"}, {"b", "b"}, {nil, "c"}},
+		},
+		{
+			name:  "full join",
+			query: "SELECT x.host, h.host FROM (SELECT host FROM B WHERE host <> 'a') x FULL JOIN " + h + " ON x.host = h.host ORDER BY 1, 2",
+			rows:  [][]any{{"b", "b"}, {"c", nil}, {nil, "a"}},
+		},
+		{
+			name:  "full outer join without matches",
+			query: "SELECT x.n, y.n FROM (SELECT 1 AS n) x FULL OUTER JOIN (SELECT 2 AS n) y ON x.n = y.n ORDER BY 1, 2",
+			rows:  [][]any{{int64(1), nil}, {nil, int64(2)}},
+		},
+		{
+			name:  "cross join",
+			query: "SELECT count(*) FROM A CROSS JOIN B",
+			rows:  [][]any{{int64(15)}},
+		},
+		{
+			name:  "comma join",
+			query: "SELECT count(*) FROM A, B WHERE A.host = B.host",
+			rows:  [][]any{{int64(5)}},
+		},
+		{
+			name:  "join using",
+			query: "SELECT host, dc, value FROM A JOIN B USING (host) WHERE value > 5 ORDER BY value",
+			rows:  [][]any{{"b", "us", 10.0}, {"b", "us", 20.0}},
+		},
+		{
+			name:  "join using multiple columns",
+			query: "SELECT count(*) FROM B JOIN (SELECT host, dc FROM B WHERE weight > 1) x USING (host, dc)",
+			rows:  [][]any{{int64(2)}},
+		},
+		{
+			name:  "join with NULL keys",
+			query: "SELECT count(*) FROM A a1 JOIN A a2 ON a1.value = a2.value",
+			rows:  [][]any{{int64(4)}},
+		},
+		{
+			name:  "left join with NULL keys",
+			query: "SELECT a1.value, a2.value FROM A a1 LEFT JOIN A a2 ON a1.value = a2.value WHERE a1.host = 'b' ORDER BY 1",
+			rows:  [][]any{{10.0, 10.0}, {20.0, 20.0}, {nil, nil}},
+		},
+		{
+			name:  "non-equi join",
+			query: "SELECT b1.host, b2.host FROM B b1 JOIN B b2 ON b1.weight < b2.weight ORDER BY 1, 2",
+			rows:  [][]any{{"a", "b"}, {"a", "c"}, {"b", "c"}},
+		},
+		{
+			name:  "equi join with an extra condition",
+			query: "SELECT A.host, A.value FROM A JOIN B ON A.host = B.host AND A.value > B.weight ORDER BY 2",
+			rows:  [][]any{{"a", 3.0}, {"b", 10.0}, {"b", 20.0}},
+		},
+		{
+			name:  "left join condition on the right table",
+			query: "SELECT B.host, A.value FROM B LEFT JOIN A ON B.host = A.host AND A.value > 5 ORDER BY 1, 2",
+			rows:  [][]any{{"a", nil}, {"b", 10.0}, {"b", 20.0}, {"c", nil}},
+		},
+		{
+			name:  "join integers with floats",
+			query: "SELECT B.host FROM B JOIN A ON B.weight = A.value ORDER BY 1",
+			rows:  [][]any{{"a"}, {"c"}},
+		},
+		{
+			name:  "join on expressions",
+			query: "SELECT count(*) FROM A JOIN B ON upper(A.host) = upper(B.host)",
+			rows:  [][]any{{int64(5)}},
+		},
+		{
+			name:  "join strings with numbers",
+			query: "SELECT x.s FROM (SELECT '2' AS s) x JOIN B ON x.s = B.weight",
+			rows:  [][]any{{"2"}},
+		},
+		{
+			name:  "three tables",
+			query: "SELECT B.host, h.host, c.n FROM B JOIN " + h + " ON B.host = h.host LEFT JOIN (SELECT 'b' AS host, 1 AS n) c ON c.host = h.host ORDER BY 1",
+			rows:  [][]any{{"a", "a", nil}, {"b", "b", int64(1)}},
+		},
+		{
+			name:  "join with an empty table",
+			query: "SELECT B.host, x.host FROM B LEFT JOIN (SELECT host FROM A WHERE FALSE) x ON B.host = x.host ORDER BY 1",
+			rows:  [][]any{{"a", nil}, {"b", nil}, {"c", nil}},
+		},
+	})
+}
+
+func TestJoinErrors(t *testing.T) {
+	testQueryErrors(t, []errorTest{
+		{name: "unknown column in condition", query: "SELECT * FROM A JOIN B ON A.foo = B.host", err: `JOIN: column "A.foo" not found`},
+		{name: "unknown using column", query: "SELECT * FROM A JOIN B USING (dc)", err: `JOIN USING: column "dc" not found`},
+		{name: "using with the same table twice", query: "SELECT * FROM B JOIN B USING (host)", err: `JOIN USING: both tables are named "B", use an alias`},
+		{name: "condition is not a boolean", query: "SELECT * FROM A JOIN B ON A.host", err: "JOIN: could not convert a to BOOLEAN"},
+		{name: "aggregate in condition", query: "SELECT * FROM A JOIN B ON count(*) > 1", err: "aggregate function count is not allowed here"},
+		{name: "unknown table", query: "SELECT * FROM A JOIN C ON TRUE", err: `table "C" not found`},
+	})
+}
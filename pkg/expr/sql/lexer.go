@@ -0,0 +1,206 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokKeyword
+	tokString
+	tokNumber
+	tokOperator
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	// val is the raw value of the token. Keywords are upper-cased, quoted identifiers and strings are unquoted.
+	val string
+	// text is the original text of a keyword, which is used when a non-reserved keyword is an identifier.
+	text string
+	pos  int
+}
+
+// ident returns the value of the token when it is used as an identifier.
+func (t token) ident() string {
+	if t.kind == tokKeyword {
+		return t.text
+	}
+	return t.val
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return fmt.Sprintf("'%s'", t.val)
+	case tokQuotedIdent:
+		return fmt.Sprintf("%q", t.val)
+	}
+	return fmt.Sprintf("%q", t.val)
+}
+
+var keywords = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true, "CASE": true, "CAST": true,
+	"CROSS": true, "CURRENT": true, "DESC": true, "DISTINCT": true, "ELSE": true, "END": true, "EXCEPT": true,
+	"EXISTS": true, "FALSE": true, "FILTER": true, "FIRST": true, "FOLLOWING": true, "FROM": true, "FULL": true,
+	"GROUP": true, "HAVING": true, "ILIKE": true, "IN": true, "INNER": true, "INTERSECT": true, "IS": true,
+	"JOIN": true, "LAST": true, "LEFT": true, "LIKE": true, "LIMIT": true, "NOT": true, "NULL": true, "NULLS": true,
+	"OFFSET": true, "ON": true, "OR": true, "ORDER": true, "OUTER": true, "OVER": true, "PARTITION": true,
+	"PRECEDING": true, "RANGE": true, "RIGHT": true, "ROW": true, "ROWS": true, "SELECT": true, "THEN": true,
+	"TRUE": true, "UNBOUNDED": true, "UNION": true, "USING": true, "WHEN": true, "WHERE": true, "WITH": true,
+}
+
+// nonReserved are keywords that may still be used as identifiers, e.g. as a column or function name.
+var nonReserved = map[string]bool{
+	"CURRENT": true, "FILTER": true, "FIRST": true, "FOLLOWING": true, "LAST": true, "LEFT": true, "NULLS": true,
+	"PRECEDING": true, "RANGE": true, "RIGHT": true, "ROW": true, "ROWS": true, "UNBOUNDED": true,
+}
+
+// lex splits the SQL statement into tokens. Comments are dropped.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(input[i:], "--"):
+			end := strings.IndexByte(input[i:], '\n')
+			if end < 0 {
+				i = len(input)
+			} else {
+				i += end + 1
+			}
+		case c == '/' && strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at position %d", i)
+			}
+			i += end + 4
+		case c == '\'':
+			s, n, err := lexQuoted(input[i:], '\'')
+			if err != nil {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokString, val: s, pos: i})
+			i += n
+		case c == '"' || c == '`':
+			s, n, err := lexQuoted(input[i:], c)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated quoted identifier at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokQuotedIdent, val: s, pos: i})
+			i += n
+		case isDigit(c) || (c == '.' && i+1 < len(input) && isDigit(input[i+1])):
+			n := lexNumber(input[i:])
+			tokens = append(tokens, token{kind: tokNumber, val: input[i : i+n], pos: i})
+			i += n
+		case isIdentStart(input[i:]):
+			start := i
+			for i < len(input) {
+				r, size := utf8.DecodeRuneInString(input[i:])
+				if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			word := input[start:i]
+			if upper := strings.ToUpper(word); keywords[upper] {
+				tokens = append(tokens, token{kind: tokKeyword, val: upper, text: word, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, val: word, pos: start})
+			}
+		default:
+			if op := lexOperator(input[i:]); op != "" {
+				tokens = append(tokens, token{kind: tokOperator, val: op, pos: i})
+				i += len(op)
+				continue
+			}
+			if strings.IndexByte("(),.;[]", c) >= 0 {
+				tokens = append(tokens, token{kind: tokPunct, val: string(c), pos: i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(input)})
+	return tokens, nil
+}
+
+// lexQuoted reads a string quoted by q, where a doubled quote is an escaped quote.
+// It returns the unquoted value and the number of bytes consumed.
+func lexQuoted(input string, q byte) (string, int, error) {
+	var sb strings.Builder
+	i := 1
+	for i < len(input) {
+		if input[i] == q {
+			if i+1 < len(input) && input[i+1] == q {
+				sb.WriteByte(q)
+				i += 2
+				continue
+			}
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteByte(input[i])
+		i++
+	}
+	return "", 0, fmt.Errorf("unterminated")
+}
+
+func lexNumber(input string) int {
+	i := 0
+	for i < len(input) && isDigit(input[i]) {
+		i++
+	}
+	if i < len(input) && input[i] == '.' {
+		i++
+		for i < len(input) && isDigit(input[i]) {
+			i++
+		}
+	}
+	if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+		j := i + 1
+		if j < len(input) && (input[j] == '+' || input[j] == '-') {
+			j++
+		}
+		if j < len(input) && isDigit(input[j]) {
+			i = j
+			for i < len(input) && isDigit(input[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+var operators = []string{"<>", "!=", "<=", ">=", "||", "::", "=", "<", ">", "+", "-", "*", "/", "%"}
+
+func lexOperator(input string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(input, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(input string) bool {
+	r, _ := utf8.DecodeRuneInString(input)
+	return r == '_' || unicode.IsLetter(r)
+}
@@ -0,0 +1,129 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []token
+	}{
+		{
+			name:  "keywords are upper-cased, identifiers keep their case",
+			input: "select Value from A",
+			expected: []token{
+				{kind: tokKeyword, val: "SELECT", text: "select", pos: 0},
+				{kind: tokIdent, val: "Value", pos: 7},
+				{kind: tokKeyword, val: "FROM", text: "from", pos: 13},
+				{kind: tokIdent, val: "A", pos: 18},
+			},
+		},
+		{
+			name:  "strings and quoted identifiers with escaped quotes",
+			input: `'it''s' "a ""b""" ` + "`c`",
+			expected: []token{
+				{kind: tokString, val: "it's", pos: 0},
+				{kind: tokQuotedIdent, val: `a "b"`, pos: 8},
+				{kind: tokQuotedIdent, val: "c", pos: 18},
+			},
+		},
+		{
+			name:  "numbers",
+			input: "1 2.5 .5 1e3 1.5E-2 3e",
+			expected: []token{
+				{kind: tokNumber, val: "1", pos: 0},
+				{kind: tokNumber, val: "2.5", pos: 2},
+				{kind: tokNumber, val: ".5", pos: 6},
+				{kind: tokNumber, val: "1e3", pos: 9},
+				{kind: tokNumber, val: "1.5E-2", pos: 13},
+				{kind: tokNumber, val: "3", pos: 20},
+				{kind: tokIdent, val: "e", pos: 21},
+			},
+		},
+		{
+			name:  "operators prefer the longest match",
+			input: "<><=>=!=||::=<>+-*/%",
+			expected: []token{
+				{kind: tokOperator, val: "<>", pos: 0},
+				{kind: tokOperator, val: "<=", pos: 2},
+				{kind: tokOperator, val: ">=", pos: 4},
+				{kind: tokOperator, val: "!=", pos: 6},
+				{kind: tokOperator, val: "||", pos: 8},
+				{kind: tokOperator, val: "::", pos: 10},
+				{kind: tokOperator, val: "=", pos: 12},
+				{kind: tokOperator, val: "<>", pos: 13},
+				{kind: tokOperator, val: "+", pos: 15},
+				{kind: tokOperator, val: "-", pos: 16},
+				{kind: tokOperator, val: "*", pos: 17},
+				{kind: tokOperator, val: "/", pos: 18},
+				{kind: tokOperator, val: "%", pos: 19},
+			},
+		},
+		{
+			name:  "punctuation",
+			input: "(a.b, c);",
+			expected: []token{
+				{kind: tokPunct, val: "(", pos: 0},
+				{kind: tokIdent, val: "a", pos: 1},
+				{kind: tokPunct, val: ".", pos: 2},
+				{kind: tokIdent, val: "b", pos: 3},
+				{kind: tokPunct, val: ",", pos: 4},
+				{kind: tokIdent, val: "c", pos: 6},
+				{kind: tokPunct, val: ")", pos: 7},
+				{kind: tokPunct, val: ";", pos: 8},
+			},
+		},
+		{
+			name:  "comments are dropped",
+			input: "a -- comment\n/* block\ncomment */ b -- trailing",
+			expected: []token{
+				{kind: tokIdent, val: "a", pos: 0},
+				{kind: tokIdent, val: "b", pos: 33},
+			},
+		},
+		{
+			name:  "unicode identifiers",
+			input: "température _x1 a$b",
+			expected: []token{
+				{kind: tokIdent, val: "température", pos: 0},
+				{kind: tokIdent, val: "_x1", pos: 13},
+				{kind: tokIdent, val: "a$b", pos: 17},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := lex(tt.input)
+			require.NoError(t, err)
+			expected := append(tt.expected, token{kind: tokEOF, pos: len(tt.input)})
+			require.Equal(t, expected, tokens)
+		})
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{input: "SELECT 'abc", err: "unterminated string at position 7"},
+		{input: `SELECT "abc`, err: "unterminated quoted identifier at position 7"},
+		{input: "SELECT `abc", err: "unterminated quoted identifier at position 7"},
+		{input: "SELECT 1 /* comment", err: "unterminated comment at position 9"},
+		{input: "SELECT a ? b", err: `unexpected character '?' at position 9`},
+		{input: "SELECT a ! b", err: `unexpected character '!' at position 9`},
+		{input: "SELECT a | b", err: `unexpected character '|' at position 9`},
+		{input: "SELECT a : b", err: `unexpected character ':' at position 9`},
+		{input: "SELECT $1", err: `unexpected character '$' at position 7`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := lex(tt.input)
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
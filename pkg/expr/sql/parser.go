@@ -1,90 +1,1137 @@
 package sql
 
 import (
-	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
-
-	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/jeremywohl/flatten"
-	"github.com/scottlepp/go-duck/duck"
-)
-
-const (
-	TABLE_NAME    = "table_name"
-	ERROR         = ".error"
-	ERROR_MESSAGE = ".error_message"
 )
 
-var logger = log.New("sql_expr")
-
 // TablesList returns a list of tables for the sql statement
 func TablesList(rawSQL string) ([]string, error) {
-	duckDB := duck.NewInMemoryDB()
-	rawSQL = strings.Replace(rawSQL, "'", "''", -1)
-	cmd := fmt.Sprintf("SELECT json_serialize_sql('%s')", rawSQL)
-	ret, err := duckDB.RunCommands([]string{cmd})
+	q, err := Parse(rawSQL)
 	if err != nil {
-		logger.Error("error serializing sql", "error", err.Error(), "sql", rawSQL, "cmd", cmd)
-		return nil, fmt.Errorf("error serializing sql: %s", err.Error())
+		return nil, err
+	}
+	return Tables(q), nil
+}
+
+// Tables returns the sorted list of tables referenced by the query
+func Tables(q *Query) []string {
+	tables := []string{}
+	collectTables(q, map[string]bool{}, func(name string) {
+		if !existsInList(name, tables) {
+			tables = append(tables, name)
+		}
+	})
+	sort.Strings(tables)
+	return tables
+}
+
+// collectTables calls add for every table referenced by the query that is not a common table expression.
+func collectTables(q *Query, ctes map[string]bool, add func(string)) {
+	scope := make(map[string]bool, len(ctes)+len(q.With))
+	for k := range ctes {
+		scope[k] = true
+	}
+	for _, cte := range q.With {
+		// a common table expression can only reference the ones defined before it
+		collectTables(cte.Query, scope, add)
+		scope[strings.ToLower(cte.Name)] = true
+	}
+
+	var visitExpr func(e Expr)
+	visitExpr = func(e Expr) {
+		walkExpr(e, func(e Expr) bool {
+			switch e := e.(type) {
+			case *SubqueryExpr:
+				collectTables(e.Query, scope, add)
+			case *ExistsExpr:
+				collectTables(e.Query, scope, add)
+			case *InExpr:
+				if e.Subquery != nil {
+					collectTables(e.Subquery, scope, add)
+				}
+			}
+			return true
+		})
+	}
+
+	var visitTable func(t TableExpr)
+	visitTable = func(t TableExpr) {
+		switch t := t.(type) {
+		case *TableName:
+			if !scope[strings.ToLower(t.Name)] {
+				add(t.Name)
+			}
+		case *DerivedTable:
+			collectTables(t.Query, scope, add)
+		case *Join:
+			visitTable(t.Left)
+			visitTable(t.Right)
+			visitExpr(t.On)
+		}
+	}
+
+	var visitSet func(s SetExpr)
+	visitSet = func(s SetExpr) {
+		switch s := s.(type) {
+		case *Query:
+			collectTables(s, scope, add)
+		case *SetOp:
+			visitSet(s.Left)
+			visitSet(s.Right)
+		case *Select:
+			if s.From != nil {
+				visitTable(s.From)
+			}
+			for _, item := range s.Items {
+				visitExpr(item.Expr)
+			}
+			visitExpr(s.Where)
+			for _, g := range s.GroupBy {
+				visitExpr(g)
+			}
+			visitExpr(s.Having)
+		}
+	}
+
+	visitSet(q.Body)
+	for _, o := range q.OrderBy {
+		visitExpr(o.Expr)
+	}
+}
+
+func existsInList(table string, list []string) bool {
+	for _, t := range list {
+		if t == table {
+			return true
+		}
 	}
+	return false
+}
 
-	ast := []map[string]any{}
-	err = json.Unmarshal([]byte(ret), &ast)
+// Parse parses a single SELECT statement.
+func Parse(rawSQL string) (*Query, error) {
+	tokens, err := lex(rawSQL)
 	if err != nil {
-		logger.Error("error converting json sql to ast", "error", err.Error(), "ret", ret)
-		return nil, fmt.Errorf("error converting json to ast: %s", err.Error())
+		return nil, fmt.Errorf("error in sql: %w", err)
 	}
+	p := &parser{tokens: tokens}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, fmt.Errorf("error in sql: %w", err)
+	}
+	for p.acceptPunct(";") {
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("error in sql: unexpected %s at position %d", p.peek(), p.peek().pos)
+	}
+	return q, nil
+}
 
-	return tablesFromAST(ast)
+type parser struct {
+	tokens []token
+	pos    int
 }
 
-// tablesFromAST returns a list of tables from the ast
-func tablesFromAST(ast []map[string]any) ([]string, error) {
-	flat, err := flatten.Flatten(ast[0], "", flatten.DotStyle)
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(kw ...string) bool {
+	t := p.peek()
+	if t.kind != tokKeyword {
+		return false
+	}
+	for _, k := range kw {
+		if t.val == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.unexpected(kw)
+	}
+	return nil
+}
+
+func (p *parser) isPunct(s string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.val == s
+}
+
+func (p *parser) acceptPunct(s string) bool {
+	if p.isPunct(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(s string) error {
+	if !p.acceptPunct(s) {
+		return p.unexpected(s)
+	}
+	return nil
+}
+
+func (p *parser) isOperator(op string) bool {
+	t := p.peek()
+	return t.kind == tokOperator && t.val == op
+}
+
+func (p *parser) acceptOperator(op string) bool {
+	if p.isOperator(op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected(expected string) error {
+	t := p.peek()
+	return fmt.Errorf("expected %s but got %s at position %d", expected, t, t.pos)
+}
+
+// isIdent reports whether the next token can be used as an identifier.
+func (p *parser) isIdent() bool {
+	t := p.peek()
+	return t.kind == tokIdent || t.kind == tokQuotedIdent || (t.kind == tokKeyword && nonReserved[t.val])
+}
+
+func (p *parser) parseIdent() (string, error) {
+	if !p.isIdent() {
+		return "", p.unexpected("identifier")
+	}
+	return p.next().ident(), nil
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{}
+	if p.acceptKeyword("WITH") {
+		for {
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AS"); err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			cq, err := p.parseQuery()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			q.With = append(q.With, &CTE{Name: name, Query: cq})
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+	}
+
+	body, err := p.parseSetExpr()
 	if err != nil {
-		logger.Error("error flattening ast", "error", err.Error(), "ast", ast)
-		return nil, fmt.Errorf("error flattening ast: %s", err.Error())
+		return nil, err
 	}
+	q.Body = body
 
-	tables := []string{}
-	for k, v := range flat {
-		if strings.HasSuffix(k, ERROR) {
-			v, ok := v.(bool)
-			if ok && v {
-				logger.Error("error in sql", "error", k)
-				return nil, astError(k, flat)
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		q.OrderBy, err = p.parseOrderItems()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		q.Limit, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("OFFSET") {
+		q.Offset, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+func (p *parser) parseSetExpr() (SetExpr, error) {
+	left, err := p.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("UNION", "INTERSECT", "EXCEPT") {
+		op := p.next().val
+		all := p.acceptKeyword("ALL")
+		if !all {
+			p.acceptKeyword("DISTINCT")
+		}
+		right, err := p.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = &SetOp{Op: op, All: all, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseSetOperand() (SetExpr, error) {
+	if p.acceptPunct("(") {
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return q, nil
+	}
+	return p.parseSelect()
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	s := &Select{}
+	if p.acceptKeyword("DISTINCT") {
+		s.Distinct = true
+	} else {
+		p.acceptKeyword("ALL")
+	}
+
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		s.Items = append(s.Items, item)
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+
+	var err error
+	if p.acceptKeyword("FROM") {
+		s.From, err = p.parseFrom()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WHERE") {
+		s.Where, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		s.GroupBy, err = p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("HAVING") {
+		s.Having, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseSelectItem() (*SelectItem, error) {
+	if p.acceptOperator("*") {
+		return &SelectItem{Star: true}, nil
+	}
+	// table.*
+	if p.isIdent() && p.peekAt(1).kind == tokPunct && p.peekAt(1).val == "." &&
+		p.peekAt(2).kind == tokOperator && p.peekAt(2).val == "*" {
+		table := p.next().ident()
+		p.pos += 2
+		return &SelectItem{Star: true, Table: table}, nil
+	}
+
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	item := &SelectItem{Expr: e}
+	alias, err := p.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+	item.Alias = alias
+	return item, nil
+}
+
+// parseAlias parses an optional [AS] alias.
+func (p *parser) parseAlias() (string, error) {
+	if p.acceptKeyword("AS") {
+		if p.peek().kind == tokString {
+			return p.next().val, nil
+		}
+		return p.parseIdent()
+	}
+	t := p.peek()
+	if t.kind == tokIdent || t.kind == tokQuotedIdent {
+		return p.next().val, nil
+	}
+	return "", nil
+}
+
+func (p *parser) parseFrom() (TableExpr, error) {
+	left, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.acceptPunct(",") {
+			right, err := p.parseTableRef()
+			if err != nil {
+				return nil, err
 			}
+			left = &Join{Kind: "CROSS", Left: left, Right: right}
+			continue
+		}
+		kind, ok := p.parseJoinKind()
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
 		}
-		if strings.Contains(k, TABLE_NAME) {
-			table, ok := v.(string)
-			if ok && !existsInList(table, tables) {
-				tables = append(tables, v.(string))
+		join := &Join{Kind: kind, Left: left, Right: right}
+		if kind != "CROSS" {
+			switch {
+			case p.acceptKeyword("ON"):
+				join.On, err = p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+			case p.acceptKeyword("USING"):
+				if err := p.expectPunct("("); err != nil {
+					return nil, err
+				}
+				for {
+					name, err := p.parseIdent()
+					if err != nil {
+						return nil, err
+					}
+					join.Using = append(join.Using, name)
+					if !p.acceptPunct(",") {
+						break
+					}
+				}
+				if err := p.expectPunct(")"); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, p.unexpected("ON or USING")
 			}
 		}
+		left = join
 	}
-	sort.Strings(tables)
+}
+
+// parseJoinKind parses the join keywords and reports whether there was a join.
+func (p *parser) parseJoinKind() (string, bool) {
+	start := p.pos
+	kind := "INNER"
+	switch {
+	case p.acceptKeyword("JOIN"):
+		return kind, true
+	case p.acceptKeyword("INNER"):
+	case p.acceptKeyword("CROSS"):
+		kind = "CROSS"
+	case p.acceptKeyword("LEFT"):
+		kind = "LEFT"
+		p.acceptKeyword("OUTER")
+	case p.acceptKeyword("RIGHT"):
+		kind = "RIGHT"
+		p.acceptKeyword("OUTER")
+	case p.acceptKeyword("FULL"):
+		kind = "FULL"
+		p.acceptKeyword("OUTER")
+	default:
+		return "", false
+	}
+	if !p.acceptKeyword("JOIN") {
+		p.pos = start
+		return "", false
+	}
+	return kind, true
+}
+
+func (p *parser) parseTableRef() (TableExpr, error) {
+	if p.acceptPunct("(") {
+		var t TableExpr
+		if p.isKeyword("SELECT", "WITH") || p.isPunct("(") && p.startsQuery() {
+			q, err := p.parseQuery()
+			if err != nil {
+				return nil, err
+			}
+			t = &DerivedTable{Query: q}
+		} else {
+			from, err := p.parseFrom()
+			if err != nil {
+				return nil, err
+			}
+			t = from
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		alias, err := p.parseAlias()
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case *DerivedTable:
+			t.Alias = alias
+		default:
+			if alias != "" {
+				return nil, fmt.Errorf("an alias is not allowed for a parenthesized join")
+			}
+		}
+		return t, nil
+	}
+
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	alias, err := p.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+	return &TableName{Name: name, Alias: alias}, nil
+}
 
-	logger.Debug("tables found in sql", "tables", tables)
+// startsQuery reports whether the tokens after any opening parentheses start a query.
+func (p *parser) startsQuery() bool {
+	i := 0
+	for p.peekAt(i).kind == tokPunct && p.peekAt(i).val == "(" {
+		i++
+	}
+	t := p.peekAt(i)
+	return t.kind == tokKeyword && (t.val == "SELECT" || t.val == "WITH")
+}
 
-	return tables, nil
+func (p *parser) parseOrderItems() ([]*OrderItem, error) {
+	var items []*OrderItem
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := &OrderItem{Expr: e}
+		if p.acceptKeyword("DESC") {
+			item.Desc = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
+		if p.acceptKeyword("NULLS") {
+			first := true
+			switch {
+			case p.acceptKeyword("FIRST"):
+			case p.acceptKeyword("LAST"):
+				first = false
+			default:
+				return nil, p.unexpected("FIRST or LAST")
+			}
+			item.NullsFirst = &first
+		}
+		items = append(items, item)
+		if !p.acceptPunct(",") {
+			return items, nil
+		}
+	}
 }
 
-func astError(k string, flat map[string]any) error {
-	key := strings.Replace(k, ERROR, "", 1)
-	message, ok := flat[key+ERROR_MESSAGE]
-	if !ok {
-		message = "unknown error in sql"
+func (p *parser) parseExprList() ([]Expr, error) {
+	var list []Expr
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.acceptPunct(",") {
+			return list, nil
+		}
 	}
-	return fmt.Errorf("error in sql: %s", message)
 }
 
-func existsInList(table string, list []string) bool {
-	for _, t := range list {
-		if t == table {
-			return true
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
 		}
+		left = &BinaryExpr{Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.acceptKeyword("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: "NOT", Expr: e}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokOperator && isComparison(t.val):
+			p.next()
+			right, err := p.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			op := t.val
+			if op == "!=" {
+				op = "<>"
+			}
+			left = &BinaryExpr{Op: op, Left: left, Right: right}
+		case p.isKeyword("IS"):
+			p.next()
+			not := p.acceptKeyword("NOT")
+			switch {
+			case p.acceptKeyword("NULL"):
+				left = &IsNullExpr{Expr: left, Not: not}
+			case p.isKeyword("TRUE", "FALSE"):
+				// a IS [NOT] TRUE is the same as a = TRUE, except that NULL is never returned
+				v := p.next().val == "TRUE"
+				var e Expr = &BinaryExpr{Op: "IS", Left: left, Right: &Literal{Value: v}}
+				if not {
+					e = &UnaryExpr{Op: "NOT", Expr: e}
+				}
+				left = e
+			default:
+				return nil, p.unexpected("NULL")
+			}
+		case p.isKeyword("NOT") && p.peekAt(1).kind == tokKeyword &&
+			(p.peekAt(1).val == "IN" || p.peekAt(1).val == "BETWEEN" || p.peekAt(1).val == "LIKE" || p.peekAt(1).val == "ILIKE"):
+			p.next()
+			left, err = p.parsePredicate(left, true)
+			if err != nil {
+				return nil, err
+			}
+		case p.isKeyword("IN", "BETWEEN", "LIKE", "ILIKE"):
+			left, err = p.parsePredicate(left, false)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+		return true
 	}
 	return false
 }
+
+func (p *parser) parsePredicate(left Expr, not bool) (Expr, error) {
+	switch kw := p.next().val; kw {
+	case "IN":
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		in := &InExpr{Expr: left, Not: not}
+		if p.isKeyword("SELECT", "WITH") {
+			q, err := p.parseQuery()
+			if err != nil {
+				return nil, err
+			}
+			in.Subquery = q
+		} else {
+			list, err := p.parseExprList()
+			if err != nil {
+				return nil, err
+			}
+			in.List = list
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	case "BETWEEN":
+		low, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		return &BetweenExpr{Expr: left, Low: low, High: high, Not: not}, nil
+	default: // LIKE, ILIKE
+		pattern, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		return &LikeExpr{Expr: left, Pattern: pattern, Not: not, CaseInsensitive: kw == "ILIKE"}, nil
+	}
+}
+
+func (p *parser) parseConcat() (Expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOperator("||") {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: "||", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+") || p.isOperator("-") {
+		op := p.next().val
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*") || p.isOperator("/") || p.isOperator("%") {
+		op := p.next().val
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.isOperator("-") || p.isOperator("+") {
+		op := p.next().val
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if lit, ok := e.(*Literal); ok && op == "-" {
+			switch v := lit.Value.(type) {
+			case int64:
+				return &Literal{Value: -v}, nil
+			case float64:
+				return &Literal{Value: -v}, nil
+			}
+		}
+		return &UnaryExpr{Op: op, Expr: e}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (Expr, error) {
+	e, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOperator("::") {
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		e = &CastExpr{Expr: e, Type: typ}
+	}
+	return e, nil
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokNumber:
+		p.next()
+		return parseNumber(t.val)
+	case tokString:
+		p.next()
+		return &Literal{Value: t.val}, nil
+	case tokKeyword:
+		switch t.val {
+		case "NULL":
+			p.next()
+			return &Literal{Value: nil}, nil
+		case "TRUE", "FALSE":
+			p.next()
+			return &Literal{Value: t.val == "TRUE"}, nil
+		case "CASE":
+			return p.parseCase()
+		case "CAST":
+			p.next()
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AS"); err != nil {
+				return nil, err
+			}
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			return &CastExpr{Expr: e, Type: typ}, nil
+		case "EXISTS":
+			p.next()
+			if err := p.expectPunct("("); err != nil {
+				return nil, err
+			}
+			q, err := p.parseQuery()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			return &ExistsExpr{Query: q}, nil
+		}
+	case tokPunct:
+		if t.val == "(" {
+			p.next()
+			if p.isKeyword("SELECT", "WITH") {
+				q, err := p.parseQuery()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(")"); err != nil {
+					return nil, err
+				}
+				return &SubqueryExpr{Query: q}, nil
+			}
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+
+	if !p.isIdent() {
+		return nil, p.unexpected("expression")
+	}
+	name := p.next().ident()
+	if p.isPunct("(") && t.kind != tokQuotedIdent {
+		return p.parseFuncCall(name)
+	}
+	if p.acceptPunct(".") {
+		col, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		return &ColumnRef{Table: name, Name: col}, nil
+	}
+	return &ColumnRef{Name: name}, nil
+}
+
+func parseNumber(s string) (Expr, error) {
+	if !strings.ContainsAny(s, ".eE") {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return &Literal{Value: i}, nil
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	return &Literal{Value: f}, nil
+}
+
+func (p *parser) parseCase() (Expr, error) {
+	p.next()
+	c := &CaseExpr{}
+	var err error
+	if !p.isKeyword("WHEN") {
+		c.Operand, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for p.acceptKeyword("WHEN") {
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+		res, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Whens = append(c.Whens, &When{Cond: cond, Result: res})
+	}
+	if len(c.Whens) == 0 {
+		return nil, p.unexpected("WHEN")
+	}
+	if p.acceptKeyword("ELSE") {
+		c.Else, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("END"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *parser) parseFuncCall(name string) (Expr, error) {
+	p.next() // (
+	f := &FuncCall{Name: strings.ToLower(name)}
+	if p.acceptOperator("*") {
+		f.Star = true
+	} else if !p.isPunct(")") {
+		if p.acceptKeyword("DISTINCT") {
+			f.Distinct = true
+		}
+		args, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		f.Args = args
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("FILTER") {
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("WHERE"); err != nil {
+			return nil, err
+		}
+		filter, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		f.Filter = filter
+	}
+	if p.acceptKeyword("OVER") {
+		spec, err := p.parseWindowSpec()
+		if err != nil {
+			return nil, err
+		}
+		f.Over = spec
+	}
+	return f, nil
+}
+
+func (p *parser) parseWindowSpec() (*WindowSpec, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	spec := &WindowSpec{}
+	var err error
+	if p.acceptKeyword("PARTITION") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		spec.PartitionBy, err = p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		spec.OrderBy, err = p.parseOrderItems()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword("ROWS", "RANGE") {
+		frame := &WindowFrame{Rows: p.next().val == "ROWS"}
+		if p.acceptKeyword("BETWEEN") {
+			frame.Start, err = p.parseFrameBound()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectKeyword("AND"); err != nil {
+				return nil, err
+			}
+			frame.End, err = p.parseFrameBound()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			frame.Start, err = p.parseFrameBound()
+			if err != nil {
+				return nil, err
+			}
+			frame.End = FrameBound{Kind: boundCurrentRow}
+		}
+		spec.Frame = frame
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func (p *parser) parseFrameBound() (FrameBound, error) {
+	switch {
+	case p.acceptKeyword("UNBOUNDED"):
+		switch {
+		case p.acceptKeyword("PRECEDING"):
+			return FrameBound{Kind: boundUnboundedPreceding}, nil
+		case p.acceptKeyword("FOLLOWING"):
+			return FrameBound{Kind: boundUnboundedFollowing}, nil
+		}
+		return FrameBound{}, p.unexpected("PRECEDING or FOLLOWING")
+	case p.acceptKeyword("CURRENT"):
+		if err := p.expectKeyword("ROW"); err != nil {
+			return FrameBound{}, err
+		}
+		return FrameBound{Kind: boundCurrentRow}, nil
+	}
+	offset, err := p.parseAdditive()
+	if err != nil {
+		return FrameBound{}, err
+	}
+	switch {
+	case p.acceptKeyword("PRECEDING"):
+		return FrameBound{Kind: boundPreceding, Offset: offset}, nil
+	case p.acceptKeyword("FOLLOWING"):
+		return FrameBound{Kind: boundFollowing, Offset: offset}, nil
+	}
+	return FrameBound{}, p.unexpected("PRECEDING or FOLLOWING")
+}
+
+// parseType parses a type name such as INTEGER, DOUBLE PRECISION or VARCHAR(255).
+func (p *parser) parseType() (Type, error) {
+	t := p.peek()
+	if t.kind != tokIdent && t.kind != tokQuotedIdent {
+		return TypeUnknown, p.unexpected("type")
+	}
+	p.next()
+	name := strings.ToUpper(t.val)
+	if name == "DOUBLE" && p.peek().kind == tokIdent && strings.EqualFold(p.peek().val, "PRECISION") {
+		p.next()
+	}
+	// ignore length and precision, e.g. VARCHAR(10) or DECIMAL(10, 2)
+	if p.acceptPunct("(") {
+		for !p.acceptPunct(")") {
+			if p.peek().kind == tokEOF {
+				return TypeUnknown, p.unexpected(")")
+			}
+			p.next()
+		}
+	}
+	typ, ok := typeNames[name]
+	if !ok {
+		return TypeUnknown, fmt.Errorf("unsupported type %s", t.val)
+	}
+	return typ, nil
+}
@@ -4,10 +4,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParse(t *testing.T) {
-	t.Skip()
 	sql := "select * from foo"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -16,7 +16,6 @@ func TestParse(t *testing.T) {
 }
 
 func TestParseWithComma(t *testing.T) {
-	t.Skip()
 	sql := "select * from foo,bar"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -26,7 +25,6 @@ func TestParseWithComma(t *testing.T) {
 }
 
 func TestParseWithCommas(t *testing.T) {
-	t.Skip()
 	sql := "select * from foo,bar,baz"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -37,7 +35,6 @@ func TestParseWithCommas(t *testing.T) {
 }
 
 func TestArray(t *testing.T) {
-	t.Skip()
 	sql := "SELECT array_value(1, 2, 3)"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -46,7 +43,7 @@ func TestArray(t *testing.T) {
 }
 
 func TestArray2(t *testing.T) {
-	t.Skip()
+	t.Skip("array syntax is not supported")
 	sql := "SELECT array_value(1, 2, 3)[2]"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -55,7 +52,7 @@ func TestArray2(t *testing.T) {
 }
 
 func TestXxx(t *testing.T) {
-	t.Skip()
+	t.Skip("array syntax is not supported")
 	sql := "SELECT [3, 2, 1]::INT[3];"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -64,7 +61,6 @@ func TestXxx(t *testing.T) {
 }
 
 func TestParseSubquery(t *testing.T) {
-	t.Skip()
 	sql := "select * from (select * from people limit 1)"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -74,7 +70,6 @@ func TestParseSubquery(t *testing.T) {
 }
 
 func TestJoin(t *testing.T) {
-	t.Skip()
 	sql := `select * from A
 	JOIN B ON A.name = B.name
 	LIMIT 10`
@@ -87,7 +82,6 @@ func TestJoin(t *testing.T) {
 }
 
 func TestRightJoin(t *testing.T) {
-	t.Skip()
 	sql := `select * from A
 	RIGHT JOIN B ON A.name = B.name
 	LIMIT 10`
@@ -100,7 +94,6 @@ func TestRightJoin(t *testing.T) {
 }
 
 func TestAliasWithJoin(t *testing.T) {
-	t.Skip()
 	sql := `select * from A as X
 	RIGHT JOIN B ON A.name = X.name
 	LIMIT 10`
@@ -113,7 +106,6 @@ func TestAliasWithJoin(t *testing.T) {
 }
 
 func TestAlias(t *testing.T) {
-	t.Skip()
 	sql := `select * from A as X LIMIT 10`
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -123,14 +115,12 @@ func TestAlias(t *testing.T) {
 }
 
 func TestError(t *testing.T) {
-	t.Skip()
 	sql := `select * from zzz aaa zzz`
 	_, err := TablesList((sql))
 	assert.NotNil(t, err)
 }
 
 func TestParens(t *testing.T) {
-	t.Skip()
 	sql := `SELECT  t1.Col1,
 	t2.Col1,
 	t3.Col1
@@ -149,7 +139,6 @@ func TestParens(t *testing.T) {
 }
 
 func TestWith(t *testing.T) {
-	t.Skip()
 	sql := `WITH
 
 	current_month AS (
@@ -191,14 +180,13 @@ func TestWith(t *testing.T) {
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
-	assert.Equal(t, 5, len(tables))
+	assert.Equal(t, 3, len(tables))
 	assert.Equal(t, "A", tables[0])
 	assert.Equal(t, "B", tables[1])
 	assert.Equal(t, "BEE", tables[2])
 }
 
 func TestWithQuote(t *testing.T) {
-	t.Skip()
 	sql := "select *,'junk' from foo"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
@@ -207,10 +195,251 @@ func TestWithQuote(t *testing.T) {
 }
 
 func TestWithQuote2(t *testing.T) {
-	t.Skip()
 	sql := "SELECT json_serialize_sql('SELECT 1')"
 	tables, err := TablesList((sql))
 	assert.Nil(t, err)
 
 	assert.Equal(t, 0, len(tables))
 }
+
+func TestParseExpr(t *testing.T) {
+	col := func(name string) *ColumnRef { return &ColumnRef{Name: name} }
+	lit := func(v any) *Literal { return &Literal{Value: v} }
+	bin := func(op string, l, r Expr) *BinaryExpr { return &BinaryExpr{Op: op, Left: l, Right: r} }
+
+	tests := []struct {
+		expr     string
+		expected Expr
+	}{
+		{expr: "a + b * c", expected: bin("+", col("a"), bin("*", col("b"), col("c")))},
+		{expr: "a - b - c", expected: bin("-", bin("-", col("a"), col("b")), col("c"))},
+		{expr: "a / b % c", expected: bin("%", bin("/", col("a"), col("b")), col("c"))},
+		{expr: "a || b + c", expected: bin("||", col("a"), bin("+", col("b"), col("c")))},
+		{expr: "a = b || c", expected: bin("=", col("a"), bin("||", col("b"), col("c")))},
+		{expr: "a != b", expected: bin("<>", col("a"), col("b"))},
+		{expr: "a OR b AND c", expected: bin("OR", col("a"), bin("AND", col("b"), col("c")))},
+		{expr: "NOT a = b", expected: &UnaryExpr{Op: "NOT", Expr: bin("=", col("a"), col("b"))}},
+		{expr: "NOT NOT a", expected: &UnaryExpr{Op: "NOT", Expr: &UnaryExpr{Op: "NOT", Expr: col("a")}}},
+		{expr: "-a", expected: &UnaryExpr{Op: "-", Expr: col("a")}},
+		{expr: "-1", expected: lit(int64(-1))},
+		{expr: "-1.5", expected: lit(-1.5)},
+		{expr: "+1", expected: &UnaryExpr{Op: "+", Expr: lit(int64(1))}},
+		{expr: "9223372036854775808", expected: lit(9223372036854775808.0)},
+		{expr: "t.a", expected: &ColumnRef{Table: "t", Name: "a"}},
+		{expr: `"Quoted Name"`, expected: col("Quoted Name")},
+		{expr: `"t"."a"`, expected: &ColumnRef{Table: "t", Name: "a"}},
+		{expr: "first", expected: col("first")},
+		{expr: "a IS NULL", expected: &IsNullExpr{Expr: col("a")}},
+		{expr: "a IS NOT NULL", expected: &IsNullExpr{Expr: col("a"), Not: true}},
+		{expr: "a IS TRUE", expected: bin("IS", col("a"), lit(true))},
+		{expr: "a IS NOT FALSE", expected: &UnaryExpr{Op: "NOT", Expr: bin("IS", col("a"), lit(false))}},
+		{expr: "a IN (1, 2)", expected: &InExpr{Expr: col("a"), List: []Expr{lit(int64(1)), lit(int64(2))}}},
+		{expr: "a NOT IN (1)", expected: &InExpr{Expr: col("a"), List: []Expr{lit(int64(1))}, Not: true}},
+		{expr: "a BETWEEN 1 AND 2 AND b", expected: bin("AND", &BetweenExpr{Expr: col("a"), Low: lit(int64(1)), High: lit(int64(2))}, col("b"))},
+		{expr: "a NOT BETWEEN b AND c", expected: &BetweenExpr{Expr: col("a"), Low: col("b"), High: col("c"), Not: true}},
+		{expr: "a LIKE 'x%'", expected: &LikeExpr{Expr: col("a"), Pattern: lit("x%")}},
+		{expr: "a NOT ILIKE 'x'", expected: &LikeExpr{Expr: col("a"), Pattern: lit("x"), Not: true, CaseInsensitive: true}},
+		{expr: "CAST(a AS VARCHAR(10))", expected: &CastExpr{Expr: col("a"), Type: TypeString}},
+		{expr: "a::int::text", expected: &CastExpr{Expr: &CastExpr{Expr: col("a"), Type: TypeInt}, Type: TypeString}},
+		{expr: "CASE a WHEN 1 THEN 'x' END", expected: &CaseExpr{Operand: col("a"), Whens: []*When{{Cond: lit(int64(1)), Result: lit("x")}}}},
+		{expr: "CASE WHEN a THEN 1 ELSE 2 END", expected: &CaseExpr{Whens: []*When{{Cond: col("a"), Result: lit(int64(1))}}, Else: lit(int64(2))}},
+		{expr: "COUNT(*)", expected: &FuncCall{Name: "count", Star: true}},
+		{expr: "count(DISTINCT a)", expected: &FuncCall{Name: "count", Args: []Expr{col("a")}, Distinct: true}},
+		{expr: "now()", expected: &FuncCall{Name: "now"}},
+		{expr: "sum(a) FILTER (WHERE b)", expected: &FuncCall{Name: "sum", Args: []Expr{col("a")}, Filter: col("b")}},
+		{expr: "left(a, 1)", expected: &FuncCall{Name: "left", Args: []Expr{col("a"), lit(int64(1))}}},
+		{
+			expr: "sum(a) OVER (PARTITION BY b ORDER BY c DESC NULLS FIRST ROWS BETWEEN 2 PRECEDING AND UNBOUNDED FOLLOWING)",
+			expected: &FuncCall{Name: "sum", Args: []Expr{col("a")}, Over: &WindowSpec{
+				PartitionBy: []Expr{col("b")},
+				OrderBy:     []*OrderItem{{Expr: col("c"), Desc: true, NullsFirst: func() *bool { b := true; return &b }()}},
+				Frame: &WindowFrame{
+					Rows:  true,
+					Start: FrameBound{Kind: boundPreceding, Offset: lit(int64(2))},
+					End:   FrameBound{Kind: boundUnboundedFollowing},
+				},
+			}},
+		},
+		{
+			expr: "row_number() OVER (RANGE UNBOUNDED PRECEDING)",
+			expected: &FuncCall{Name: "row_number", Over: &WindowSpec{
+				Frame: &WindowFrame{Start: FrameBound{Kind: boundUnboundedPreceding}, End: FrameBound{Kind: boundCurrentRow}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Parse("SELECT " + tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, q.Body.(*Select).Items[0].Expr)
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	t.Run("clauses", func(t *testing.T) {
+		q, err := Parse("SELECT DISTINCT a AS x, b y, t.*, * FROM t WHERE a > 1 GROUP BY a, 2 HAVING count(*) > 1 ORDER BY x DESC, 2 LIMIT 10 OFFSET 5;;")
+		require.NoError(t, err)
+		sel := q.Body.(*Select)
+		require.True(t, sel.Distinct)
+		require.Equal(t, []*SelectItem{
+			{Expr: &ColumnRef{Name: "a"}, Alias: "x"},
+			{Expr: &ColumnRef{Name: "b"}, Alias: "y"},
+			{Star: true, Table: "t"},
+			{Star: true},
+		}, sel.Items)
+		require.Equal(t, &TableName{Name: "t"}, sel.From)
+		require.NotNil(t, sel.Where)
+		require.Len(t, sel.GroupBy, 2)
+		require.NotNil(t, sel.Having)
+		require.Equal(t, []*OrderItem{
+			{Expr: &ColumnRef{Name: "x"}, Desc: true},
+			{Expr: &Literal{Value: int64(2)}},
+		}, q.OrderBy)
+		require.Equal(t, &Literal{Value: int64(10)}, q.Limit)
+		require.Equal(t, &Literal{Value: int64(5)}, q.Offset)
+	})
+
+	t.Run("string alias", func(t *testing.T) {
+		q, err := Parse("SELECT 1 AS 'one'")
+		require.NoError(t, err)
+		require.Equal(t, "one", q.Body.(*Select).Items[0].Alias)
+	})
+
+	t.Run("joins", func(t *testing.T) {
+		q, err := Parse("SELECT * FROM a JOIN b ON a.x = b.x LEFT OUTER JOIN c USING (x, y) CROSS JOIN d, e RIGHT JOIN f ON TRUE FULL JOIN g ON TRUE INNER JOIN h ON TRUE")
+		require.NoError(t, err)
+		var kinds []string
+		from := q.Body.(*Select).From
+		for {
+			j, ok := from.(*Join)
+			if !ok {
+				break
+			}
+			kinds = append([]string{j.Kind}, kinds...)
+			if j.Kind == "LEFT" {
+				require.Equal(t, []string{"x", "y"}, j.Using)
+			}
+			from = j.Left
+		}
+		require.Equal(t, []string{"INNER", "LEFT", "CROSS", "CROSS", "RIGHT", "FULL", "INNER"}, kinds)
+	})
+
+	t.Run("table alias without AS", func(t *testing.T) {
+		q, err := Parse("SELECT * FROM t x")
+		require.NoError(t, err)
+		require.Equal(t, &TableName{Name: "t", Alias: "x"}, q.Body.(*Select).From)
+	})
+
+	t.Run("left is a function and a join", func(t *testing.T) {
+		q, err := Parse("SELECT left(a, 1) FROM t LEFT JOIN u ON TRUE")
+		require.NoError(t, err)
+		require.Equal(t, "LEFT", q.Body.(*Select).From.(*Join).Kind)
+	})
+
+	t.Run("derived table", func(t *testing.T) {
+		q, err := Parse("SELECT * FROM (SELECT 1) AS d")
+		require.NoError(t, err)
+		d := q.Body.(*Select).From.(*DerivedTable)
+		require.Equal(t, "d", d.Alias)
+	})
+
+	t.Run("set operations are left associative", func(t *testing.T) {
+		q, err := Parse("SELECT 1 UNION ALL SELECT 2 EXCEPT SELECT 3 INTERSECT DISTINCT (SELECT 4 ORDER BY 1) ORDER BY 1")
+		require.NoError(t, err)
+		op := q.Body.(*SetOp)
+		require.Equal(t, "INTERSECT", op.Op)
+		require.False(t, op.All)
+		require.IsType(t, &Query{}, op.Right)
+		op = op.Left.(*SetOp)
+		require.Equal(t, "EXCEPT", op.Op)
+		op = op.Left.(*SetOp)
+		require.Equal(t, "UNION", op.Op)
+		require.True(t, op.All)
+		require.Len(t, q.OrderBy, 1)
+	})
+
+	t.Run("common table expressions", func(t *testing.T) {
+		q, err := Parse("WITH a AS (SELECT 1), b AS (WITH c AS (SELECT 2) SELECT * FROM c) SELECT * FROM a, b")
+		require.NoError(t, err)
+		require.Len(t, q.With, 2)
+		require.Equal(t, "a", q.With[0].Name)
+		require.Equal(t, "b", q.With[1].Name)
+		require.Len(t, q.With[1].Query.With, 1)
+	})
+
+	t.Run("subqueries", func(t *testing.T) {
+		q, err := Parse("SELECT (SELECT 1), EXISTS (SELECT 1), 1 IN (SELECT 1), 1 IN (WITH x AS (SELECT 1) SELECT * FROM x)")
+		require.NoError(t, err)
+		items := q.Body.(*Select).Items
+		require.IsType(t, &SubqueryExpr{}, items[0].Expr)
+		require.IsType(t, &ExistsExpr{}, items[1].Expr)
+		require.NotNil(t, items[2].Expr.(*InExpr).Subquery)
+		require.NotNil(t, items[3].Expr.(*InExpr).Subquery)
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		sql string
+		err string
+	}{
+		{sql: "", err: "expected SELECT but got end of input at position 0"},
+		{sql: "UPDATE t SET a = 1", err: `expected SELECT but got "UPDATE" at position 0`},
+		{sql: "SELECT", err: "expected expression but got end of input at position 6"},
+		{sql: "SELECT 1,", err: "expected expression but got end of input at position 9"},
+		{sql: "SELECT 1 FROM", err: "expected identifier"},
+		{sql: "SELECT 1; SELECT 2", err: `unexpected "SELECT" at position 10`},
+		{sql: "SELECT a b c", err: `unexpected "c" at position 11`},
+		{sql: "SELECT (1", err: "expected ) but got end of input"},
+		{sql: "SELECT 1 +", err: "expected expression"},
+		{sql: "SELECT a IS 1", err: "expected NULL"},
+		{sql: "SELECT a NOT", err: `unexpected "NOT"`},
+		{sql: "SELECT a IN 1", err: "expected ( but got"},
+		{sql: "SELECT a BETWEEN 1", err: "expected AND but got end of input"},
+		{sql: "SELECT CASE a END", err: "expected WHEN but got"},
+		{sql: "SELECT CASE WHEN a THEN 1", err: "expected END but got end of input"},
+		{sql: "SELECT CASE WHEN a 1 END", err: "expected THEN"},
+		{sql: "SELECT CAST(a INTEGER)", err: "expected AS"},
+		{sql: "SELECT CAST(a AS 1)", err: "expected type"},
+		{sql: "SELECT CAST(a AS BLOB)", err: "unsupported type BLOB"},
+		{sql: "SELECT a::VARCHAR(10", err: "expected ) but got end of input"},
+		{sql: "SELECT count(a", err: "expected ) but got end of input"},
+		{sql: "SELECT sum(a) FILTER (a > 1)", err: "expected WHERE"},
+		{sql: "SELECT sum(a) OVER b", err: "expected ( but got"},
+		{sql: "SELECT sum(a) OVER (ROWS BETWEEN 1 AND 2)", err: "expected PRECEDING or FOLLOWING"},
+		{sql: "SELECT sum(a) OVER (ROWS UNBOUNDED CURRENT ROW)", err: "expected PRECEDING or FOLLOWING"},
+		{sql: "SELECT sum(a) OVER (ROWS CURRENT)", err: "expected ROW"},
+		{sql: "SELECT a FROM t ORDER BY a NULLS", err: "expected FIRST or LAST"},
+		{sql: "SELECT a FROM t GROUP a", err: "expected BY"},
+		{sql: "SELECT a FROM t JOIN u", err: "expected ON or USING"},
+		{sql: "SELECT a FROM t JOIN u USING x", err: "expected ( but got"},
+		{sql: "SELECT a FROM (t JOIN u ON TRUE) AS x", err: "an alias is not allowed for a parenthesized join"},
+		{sql: "SELECT a FROM (SELECT 1", err: "expected ) but got end of input"},
+		{sql: "WITH a (SELECT 1) SELECT 1", err: "expected AS"},
+		{sql: "WITH a AS SELECT 1", err: "expected ( but got"},
+		{sql: "SELECT 1 UNION", err: "expected SELECT"},
+		{sql: "SELECT EXISTS 1", err: "expected ( but got"},
+		{sql: "SELECT 'abc", err: "unterminated string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			_, err := Parse(tt.sql)
+			require.ErrorContains(t, err, "error in sql: ")
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestTablesIgnoresCTEs(t *testing.T) {
+	tables, err := TablesList(`WITH a AS (SELECT * FROM x), b AS (SELECT * FROM a JOIN y ON TRUE)
+		SELECT (SELECT max(v) FROM z), * FROM b WHERE EXISTS (SELECT 1 FROM w) AND v IN (SELECT v FROM a)
+		UNION SELECT * FROM (SELECT * FROM x) d ORDER BY (SELECT 1 FROM o)`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"o", "w", "x", "y", "z"}, tables)
+
+	// a common table expression can not reference itself or the ones defined after it
+	tables, err = TablesList("WITH a AS (SELECT * FROM b), b AS (SELECT * FROM a) SELECT * FROM b")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, tables)
+}
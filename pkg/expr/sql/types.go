@@ -0,0 +1,373 @@
+package sql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the type of a column or expression. Values of each type are
+// represented with the Go types nil, bool, int64, float64, string and time.Time.
+type Type int
+
+const (
+	// TypeUnknown is the type of NULL literals.
+	TypeUnknown Type = iota
+	TypeBool
+	TypeInt
+	TypeFloat
+	TypeString
+	TypeTime
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeBool:
+		return "BOOLEAN"
+	case TypeInt:
+		return "BIGINT"
+	case TypeFloat:
+		return "DOUBLE"
+	case TypeString:
+		return "VARCHAR"
+	case TypeTime:
+		return "TIMESTAMP"
+	}
+	return "NULL"
+}
+
+func (t Type) numeric() bool {
+	return t == TypeInt || t == TypeFloat
+}
+
+var typeNames = map[string]Type{
+	"BOOL": TypeBool, "BOOLEAN": TypeBool,
+	"TINYINT": TypeInt, "SMALLINT": TypeInt, "INT": TypeInt, "INTEGER": TypeInt, "BIGINT": TypeInt,
+	"INT2": TypeInt, "INT4": TypeInt, "INT8": TypeInt, "HUGEINT": TypeInt, "UBIGINT": TypeInt,
+	"FLOAT": TypeFloat, "FLOAT4": TypeFloat, "FLOAT8": TypeFloat, "REAL": TypeFloat, "DOUBLE": TypeFloat,
+	"DECIMAL": TypeFloat, "NUMERIC": TypeFloat,
+	"VARCHAR": TypeString, "TEXT": TypeString, "STRING": TypeString, "CHAR": TypeString,
+	"TIMESTAMP": TypeTime, "DATETIME": TypeTime, "TIMESTAMPTZ": TypeTime, "DATE": TypeTime,
+}
+
+// commonType returns the type that values of both types can be converted to.
+func commonType(a, b Type) Type {
+	switch {
+	case a == b:
+		return a
+	case a == TypeUnknown:
+		return b
+	case b == TypeUnknown:
+		return a
+	case a.numeric() && b.numeric():
+		return TypeFloat
+	}
+	return TypeString
+}
+
+func typeOfValue(v any) Type {
+	switch v.(type) {
+	case bool:
+		return TypeBool
+	case int64:
+		return TypeInt
+	case float64:
+		return TypeFloat
+	case string:
+		return TypeString
+	case time.Time:
+		return TypeTime
+	}
+	return TypeUnknown
+}
+
+// convert converts the value to the given type, following the rules of CAST.
+func convert(v any, t Type) (any, error) {
+	if v == nil || t == TypeUnknown {
+		return v, nil
+	}
+	switch t {
+	case TypeBool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case float64:
+			return v != 0, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "t", "1", "yes", "y":
+				return true, nil
+			case "false", "f", "0", "no", "n":
+				return false, nil
+			}
+		}
+	case TypeInt:
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case int64:
+			return v, nil
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) || v >= math.MaxInt64 || v < math.MinInt64 {
+				return nil, fmt.Errorf("value %v is out of range for %s", v, t)
+			}
+			return int64(math.Round(v)), nil
+		case string:
+			s := strings.TrimSpace(v)
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return i, nil
+			}
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return convert(f, t)
+			}
+		case time.Time:
+			return v.UnixMilli(), nil
+		}
+	case TypeFloat:
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return float64(1), nil
+			}
+			return float64(0), nil
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		case time.Time:
+			return float64(v.UnixMilli()), nil
+		}
+	case TypeString:
+		return formatValue(v), nil
+	case TypeTime:
+		switch v := v.(type) {
+		case time.Time:
+			return v, nil
+		case int64:
+			return time.UnixMilli(v).UTC(), nil
+		case float64:
+			return time.UnixMilli(int64(v)).UTC(), nil
+		case string:
+			if ts, err := parseTime(v); err == nil {
+				return ts, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("could not convert %s to %s", formatValue(v), t)
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// toFloat returns the value as a float64. ok is false if the value is not numeric.
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// compareValues compares two non-nil values and returns -1, 0 or 1.
+// Numbers are compared with numbers, strings are compared with strings and so on.
+// Values of different kinds are converted to the type of the other operand where possible.
+func compareValues(a, b any) (int, error) {
+	switch av := a.(type) {
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return compareOrdered(av, bv), nil
+		}
+	case string:
+		switch bv := b.(type) {
+		case string:
+			return strings.Compare(av, bv), nil
+		case time.Time:
+			at, err := parseTime(av)
+			if err != nil {
+				return 0, err
+			}
+			return at.Compare(bv), nil
+		}
+	case time.Time:
+		switch bv := b.(type) {
+		case time.Time:
+			return av.Compare(bv), nil
+		case string:
+			bt, err := parseTime(bv)
+			if err != nil {
+				return 0, err
+			}
+			return av.Compare(bt), nil
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, nil
+			case !av:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	if aok && bok {
+		// NaN sorts after all other values, like in Postgres and DuckDB
+		switch {
+		case math.IsNaN(af) && math.IsNaN(bf):
+			return 0, nil
+		case math.IsNaN(af):
+			return 1, nil
+		case math.IsNaN(bf):
+			return -1, nil
+		}
+		return compareOrdered(af, bf), nil
+	}
+	if s, ok := a.(string); ok && bok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not compare %q with %s", s, formatValue(b))
+		}
+		return compareOrdered(f, bf), nil
+	}
+	if s, ok := b.(string); ok && aok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not compare %s with %q", formatValue(a), s)
+		}
+		return compareOrdered(af, f), nil
+	}
+	return 0, fmt.Errorf("could not compare %s with %s", typeOfValue(a), typeOfValue(b))
+}
+
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// sortCompare compares two values for sorting, where NULL is larger than any other value
+// and values that can not be compared are ordered by type.
+func sortCompare(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	c, err := compareValues(a, b)
+	if err != nil {
+		return compareOrdered(int64(typeOfValue(a)), int64(typeOfValue(b)))
+	}
+	return c
+}
+
+// appendKey appends an encoding of the value to the key, so that equal values
+// (including equal numbers of a different type) produce the same key.
+func appendKey(key []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(key, 'N')
+	case bool:
+		if v {
+			return append(key, 'T')
+		}
+		return append(key, 'F')
+	case int64:
+		key = append(key, 'i')
+		return strconv.AppendInt(key, v, 10)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			key = append(key, 'i')
+			return strconv.AppendInt(key, int64(v), 10)
+		}
+		if math.IsNaN(v) {
+			return append(key, "fNaN"...)
+		}
+		key = append(key, 'f')
+		return strconv.AppendFloat(key, v, 'g', -1, 64)
+	case string:
+		key = append(key, 's')
+		key = strconv.AppendInt(key, int64(len(v)), 10)
+		key = append(key, ':')
+		return append(key, v...)
+	case time.Time:
+		key = append(key, 't')
+		return strconv.AppendInt(key, v.UnixNano(), 10)
+	}
+	return append(key, fmt.Sprintf("?%v", v)...)
+}
+
+// valueSize is an estimate of the memory used by a value.
+func valueSize(v any) int64 {
+	const overhead = 16
+	switch v := v.(type) {
+	case string:
+		return overhead + int64(len(v))
+	case time.Time:
+		return overhead + 24
+	}
+	return overhead
+}
@@ -0,0 +1,164 @@
+package sql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    any
+		typ      Type
+		expected any
+		err      string
+	}{
+		{name: "nil stays nil", value: nil, typ: TypeInt, expected: nil},
+		{name: "unknown type keeps the value", value: "x", typ: TypeUnknown, expected: "x"},
+
+		{name: "int to bool", value: int64(2), typ: TypeBool, expected: true},
+		{name: "zero float to bool", value: 0.0, typ: TypeBool, expected: false},
+		{name: "string t to bool", value: " T ", typ: TypeBool, expected: true},
+		{name: "string no to bool", value: "no", typ: TypeBool, expected: false},
+		{name: "invalid string to bool", value: "maybe", typ: TypeBool, err: "could not convert maybe to BOOLEAN"},
+		{name: "time to bool", value: ts, typ: TypeBool, err: "could not convert"},
+
+		{name: "bool to int", value: false, typ: TypeInt, expected: int64(0)},
+		{name: "float to int rounds", value: -2.5, typ: TypeInt, expected: int64(-3)},
+		{name: "NaN to int", value: math.NaN(), typ: TypeInt, err: "out of range"},
+		{name: "Inf to int", value: math.Inf(1), typ: TypeInt, err: "out of range"},
+		{name: "string to int", value: " 42 ", typ: TypeInt, expected: int64(42)},
+		{name: "float string to int", value: "4.4", typ: TypeInt, expected: int64(4)},
+		{name: "invalid string to int", value: "4x", typ: TypeInt, err: "could not convert 4x to BIGINT"},
+		{name: "time to int is milliseconds", value: ts, typ: TypeInt, expected: ts.UnixMilli()},
+
+		{name: "bool to float", value: true, typ: TypeFloat, expected: 1.0},
+		{name: "int to float", value: int64(3), typ: TypeFloat, expected: 3.0},
+		{name: "string to float", value: "1e2", typ: TypeFloat, expected: 100.0},
+		{name: "invalid string to float", value: "x", typ: TypeFloat, err: "could not convert x to DOUBLE"},
+		{name: "time to float is milliseconds", value: ts, typ: TypeFloat, expected: float64(ts.UnixMilli())},
+
+		{name: "bool to string", value: true, typ: TypeString, expected: "true"},
+		{name: "int to string", value: int64(-1), typ: TypeString, expected: "-1"},
+		{name: "float to string", value: 0.1, typ: TypeString, expected: "0.1"},
+		{name: "large float to string", value: 1e21, typ: TypeString, expected: "1000000000000000000000"},
+		{name: "time to string", value: ts, typ: TypeString, expected: "2024-01-02T03:04:05Z"},
+
+		{name: "int to time is milliseconds", value: int64(1500), typ: TypeTime, expected: time.UnixMilli(1500).UTC()},
+		{name: "float to time is milliseconds", value: 1500.9, typ: TypeTime, expected: time.UnixMilli(1500).UTC()},
+		{name: "RFC3339 string to time", value: "2024-01-02T03:04:05Z", typ: TypeTime, expected: ts},
+		{name: "RFC3339 string with offset to time", value: "2024-01-02T04:04:05+01:00", typ: TypeTime, expected: ts.In(time.FixedZone("", 3600))},
+		{name: "SQL string to time", value: "2024-01-02 03:04:05", typ: TypeTime, expected: ts},
+		{name: "date string to time", value: "2024-01-02", typ: TypeTime, expected: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid string to time", value: "yesterday", typ: TypeTime, err: "could not convert yesterday to TIMESTAMP"},
+		{name: "bool to time", value: true, typ: TypeTime, err: "could not convert true to TIMESTAMP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := convert(tt.value, tt.typ)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			if expected, ok := tt.expected.(time.Time); ok {
+				require.True(t, expected.Equal(v.(time.Time)), "expected %s, got %s", expected, v)
+				return
+			}
+			require.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		a, b     any
+		expected int
+		err      string
+	}{
+		{name: "ints", a: int64(1), b: int64(2), expected: -1},
+		{name: "int and float", a: int64(2), b: 1.5, expected: 1},
+		{name: "equal int and float", a: int64(2), b: 2.0, expected: 0},
+		{name: "strings", a: "b", b: "a", expected: 1},
+		{name: "strings are case sensitive", a: "B", b: "a", expected: -1},
+		{name: "times", a: ts, b: ts.Add(time.Second), expected: -1},
+		{name: "time and string", a: ts, b: "2024-01-01", expected: 0},
+		{name: "string and time", a: "2023-12-31", b: ts, expected: -1},
+		{name: "invalid time string", a: ts, b: "x", err: `invalid timestamp "x"`},
+		{name: "bools", a: true, b: false, expected: 1},
+		{name: "equal bools", a: false, b: false, expected: 0},
+		{name: "bool and int", a: true, b: int64(1), expected: 0},
+		{name: "NaN is the largest number", a: math.NaN(), b: math.Inf(1), expected: 1},
+		{name: "number and NaN", a: int64(1), b: math.NaN(), expected: -1},
+		{name: "NaN equals NaN", a: math.NaN(), b: math.NaN(), expected: 0},
+		{name: "numeric string and number", a: "10", b: int64(9), expected: 1},
+		{name: "number and numeric string", a: 1.5, b: " 1.5 ", expected: 0},
+		{name: "string and number", a: "x", b: int64(1), err: `could not compare "x" with 1`},
+		{name: "number and string", a: int64(1), b: "x", err: `could not compare 1 with "x"`},
+		{name: "time and number", a: ts, b: int64(1), err: "could not compare TIMESTAMP with BIGINT"},
+		{name: "bool and string", a: true, b: "true", err: `could not compare true with "true"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := compareValues(tt.a, tt.b)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, c)
+		})
+	}
+}
+
+func TestSortCompare(t *testing.T) {
+	require.Equal(t, 0, sortCompare(nil, nil))
+	require.Equal(t, 1, sortCompare(nil, int64(1)))
+	require.Equal(t, -1, sortCompare(int64(1), nil))
+	require.Equal(t, -1, sortCompare(int64(1), int64(2)))
+	// values that can not be compared are ordered by type
+	require.Equal(t, -1, sortCompare(int64(1), "x"))
+	require.Equal(t, 1, sortCompare("x", int64(1)))
+}
+
+func TestCommonType(t *testing.T) {
+	tests := []struct {
+		a, b     Type
+		expected Type
+	}{
+		{a: TypeInt, b: TypeInt, expected: TypeInt},
+		{a: TypeUnknown, b: TypeTime, expected: TypeTime},
+		{a: TypeBool, b: TypeUnknown, expected: TypeBool},
+		{a: TypeInt, b: TypeFloat, expected: TypeFloat},
+		{a: TypeInt, b: TypeString, expected: TypeString},
+		{a: TypeTime, b: TypeBool, expected: TypeString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a.String()+" "+tt.b.String(), func(t *testing.T) {
+			require.Equal(t, tt.expected, commonType(tt.a, tt.b))
+			require.Equal(t, tt.expected, commonType(tt.b, tt.a))
+		})
+	}
+}
+
+func TestAppendKey(t *testing.T) {
+	key := func(v any) string { return string(appendKey(nil, v)) }
+
+	// equal numbers of a different type have the same key
+	require.Equal(t, key(int64(2)), key(2.0))
+	require.NotEqual(t, key(int64(2)), key(2.5))
+	require.Equal(t, key(math.NaN()), key(math.NaN()))
+	// values of different types that format the same have different keys
+	require.NotEqual(t, key("1"), key(int64(1)))
+	require.NotEqual(t, key(true), key("T"))
+	require.NotEqual(t, key(nil), key("N"))
+	// strings are length-prefixed, so concatenated keys are unambiguous
+	require.NotEqual(t, key("a")+key("bc"), key("ab")+key("c"))
+	require.Equal(t, key(time.UnixMilli(5)), key(time.UnixMilli(5).UTC()))
+}
@@ -0,0 +1,430 @@
+package sql
+
+import (
+	"fmt"
+	"sort"
+)
+
+var windowOnlyFuncs = map[string]struct{ minArgs, maxArgs int }{
+	"row_number":   {0, 0},
+	"rank":         {0, 0},
+	"dense_rank":   {0, 0},
+	"percent_rank": {0, 0},
+	"cume_dist":    {0, 0},
+	"ntile":        {1, 1},
+	"lag":          {1, 3},
+	"lead":         {1, 3},
+	"first_value":  {1, 1},
+	"last_value":   {1, 1},
+	"nth_value":    {2, 2},
+}
+
+func isWindowOnly(name string) bool {
+	_, ok := windowOnlyFuncs[name]
+	return ok
+}
+
+// window is a window function call of a query.
+type window struct {
+	name      string
+	args      []*compiled
+	agg       *aggregate
+	partition []*compiled
+	order     []*compiled
+	orderBy   []*OrderItem
+	// n is the constant argument of ntile, nth_value and the offset of lag and lead.
+	n int64
+	// frame bounds, offsets are only supported for ROWS frames.
+	rows                   bool
+	start, end             string
+	startOffset, endOffset int64
+	typ                    Type
+}
+
+func (s *scope) compileWindow(f *FuncCall) (*compiled, error) {
+	// window functions can not be nested, but their arguments may contain aggregates in grouped queries
+	s.allowWindows = false
+	defer func() { s.allowWindows = true }()
+
+	w := &window{name: f.Name, orderBy: f.Over.OrderBy}
+	switch {
+	case isAggregate(f.Name):
+		if f.Distinct && len(f.Over.OrderBy) > 0 {
+			return nil, fmt.Errorf("DISTINCT is not supported for window functions with ORDER BY")
+		}
+		agg, err := s.aggregateCall(f)
+		if err != nil {
+			return nil, err
+		}
+		w.agg = agg
+		w.typ = agg.typ
+	case isWindowOnly(f.Name):
+		limits := windowOnlyFuncs[f.Name]
+		if len(f.Args) < limits.minArgs || len(f.Args) > limits.maxArgs || f.Star || f.Distinct {
+			return nil, fmt.Errorf("wrong number of arguments to window function %s", f.Name)
+		}
+		if f.Filter != nil {
+			return nil, fmt.Errorf("FILTER is not supported for window function %s", f.Name)
+		}
+		for _, a := range f.Args {
+			c, err := s.compile(a)
+			if err != nil {
+				return nil, err
+			}
+			w.args = append(w.args, c)
+		}
+		if err := w.setType(s, f); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a window function", f.Name)
+	}
+
+	for _, p := range f.Over.PartitionBy {
+		c, err := s.compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("PARTITION BY: %w", err)
+		}
+		w.partition = append(w.partition, c)
+	}
+	for _, o := range f.Over.OrderBy {
+		c, err := s.compile(o.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("ORDER BY: %w", err)
+		}
+		w.order = append(w.order, c)
+	}
+	if err := w.setFrame(s, f.Over.Frame); err != nil {
+		return nil, err
+	}
+
+	idx := len(s.wins)
+	s.wins = append(s.wins, w)
+	return &compiled{fn: func(r *row) (any, error) { return r.wins[idx], nil }, typ: w.typ}, nil
+}
+
+func (w *window) setType(s *scope, f *FuncCall) error {
+	switch f.Name {
+	case "row_number", "rank", "dense_rank", "ntile":
+		w.typ = TypeInt
+	case "percent_rank", "cume_dist":
+		w.typ = TypeFloat
+	case "lag", "lead":
+		w.typ = w.args[0].typ
+		if len(w.args) > 2 {
+			w.typ = commonType(w.typ, w.args[2].typ)
+		}
+	default:
+		w.typ = w.args[0].typ
+	}
+
+	w.n = 1
+	var err error
+	switch f.Name {
+	case "ntile", "nth_value":
+		w.n, err = s.ex.constInt(f.Args[len(f.Args)-1], f.Name, s.ctes)
+		if err == nil && w.n < 1 {
+			err = fmt.Errorf("the argument of %s must be positive", f.Name)
+		}
+	case "lag", "lead":
+		if len(f.Args) > 1 {
+			w.n, err = s.ex.constInt(f.Args[1], f.Name, s.ctes)
+		}
+	}
+	return err
+}
+
+// setFrame sets the bounds of the window frame. Without a frame clause the frame is the whole partition,
+// or all rows up to the last peer of the current row when the window is ordered.
+func (w *window) setFrame(s *scope, frame *WindowFrame) error {
+	if frame == nil {
+		w.start, w.end = boundUnboundedPreceding, boundUnboundedFollowing
+		if len(w.orderBy) > 0 {
+			w.end = boundCurrentRow
+		}
+		return nil
+	}
+	w.rows, w.start, w.end = frame.Rows, frame.Start.Kind, frame.End.Kind
+	if w.start == boundUnboundedFollowing || w.end == boundUnboundedPreceding {
+		return fmt.Errorf("invalid window frame")
+	}
+	for _, b := range []struct {
+		bound  FrameBound
+		offset *int64
+	}{{frame.Start, &w.startOffset}, {frame.End, &w.endOffset}} {
+		if b.bound.Offset == nil {
+			continue
+		}
+		if !w.rows {
+			return fmt.Errorf("RANGE frames with an offset are not supported, use ROWS")
+		}
+		n, err := s.ex.constInt(b.bound.Offset, "window frame", s.ctes)
+		if err != nil {
+			return err
+		}
+		*b.offset = n
+	}
+	return nil
+}
+
+// computeWindows computes the window functions for all rows.
+func (ex *executor) computeWindows(wins []*window, units []*row) error {
+	for _, u := range units {
+		u.wins = make([]any, len(wins))
+	}
+	for wi, w := range wins {
+		partitions, err := ex.partition(w, units)
+		if err != nil {
+			return err
+		}
+		for _, p := range partitions {
+			if err := w.compute(ex, wi, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// windowRow is a row of a partition with its sort keys.
+type windowRow struct {
+	*row
+	keys []any
+}
+
+// partition splits the rows into the partitions of the window, each sorted by the window order.
+func (ex *executor) partition(w *window, units []*row) ([][]windowRow, error) {
+	var partitions [][]windowRow
+	index := map[string]int{}
+	for _, u := range units {
+		if err := ex.tick(); err != nil {
+			return nil, err
+		}
+		var key []byte
+		for _, p := range w.partition {
+			v, err := p.fn(u)
+			if err != nil {
+				return nil, fmt.Errorf("PARTITION BY: %w", err)
+			}
+			key = appendKey(key, v)
+			key = append(key, 0)
+		}
+		keys := make([]any, len(w.order))
+		for i, o := range w.order {
+			v, err := o.fn(u)
+			if err != nil {
+				return nil, fmt.Errorf("ORDER BY: %w", err)
+			}
+			keys[i] = v
+		}
+		i, ok := index[string(key)]
+		if !ok {
+			i = len(partitions)
+			index[string(key)] = i
+			partitions = append(partitions, nil)
+		}
+		partitions[i] = append(partitions[i], windowRow{row: u, keys: keys})
+	}
+	if len(w.order) > 0 {
+		for _, p := range partitions {
+			sort.SliceStable(p, func(i, j int) bool {
+				return compareKeys(p[i].keys, p[j].keys, w.orderBy) < 0
+			})
+		}
+	}
+	return partitions, nil
+}
+
+func (w *window) compute(ex *executor, wi int, p []windowRow) error {
+	n := len(p)
+	// peers are the rows with equal sort keys, without ORDER BY all rows of the partition are peers
+	firstPeer := make([]int, n)
+	lastPeer := make([]int, n)
+	for i := range p {
+		if i > 0 && compareKeys(p[i-1].keys, p[i].keys, w.orderBy) == 0 {
+			firstPeer[i] = firstPeer[i-1]
+		} else {
+			firstPeer[i] = i
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		if i < n-1 && firstPeer[i+1] == firstPeer[i] {
+			lastPeer[i] = lastPeer[i+1]
+		} else {
+			lastPeer[i] = i
+		}
+	}
+
+	switch w.name {
+	case "row_number":
+		for i := range p {
+			p[i].wins[wi] = int64(i + 1)
+		}
+		return nil
+	case "rank":
+		for i := range p {
+			p[i].wins[wi] = int64(firstPeer[i] + 1)
+		}
+		return nil
+	case "dense_rank":
+		var rank int64
+		for i := range p {
+			if firstPeer[i] == i {
+				rank++
+			}
+			p[i].wins[wi] = rank
+		}
+		return nil
+	case "percent_rank":
+		for i := range p {
+			v := 0.0
+			if n > 1 {
+				v = float64(firstPeer[i]) / float64(n-1)
+			}
+			p[i].wins[wi] = v
+		}
+		return nil
+	case "cume_dist":
+		for i := range p {
+			p[i].wins[wi] = float64(lastPeer[i]+1) / float64(n)
+		}
+		return nil
+	case "ntile":
+		// the first n % buckets buckets have one more row
+		buckets := int(min(w.n, int64(n)))
+		if buckets == 0 {
+			return nil
+		}
+		size, rest := n/buckets, n%buckets
+		i := 0
+		for b := 1; b <= buckets; b++ {
+			end := i + size
+			if b <= rest {
+				end++
+			}
+			for ; i < end; i++ {
+				p[i].wins[wi] = int64(b)
+			}
+		}
+		return nil
+	case "lag", "lead":
+		offset := int(w.n)
+		if w.name == "lag" {
+			offset = -offset
+		}
+		for i := range p {
+			var v any
+			var err error
+			if j := i + offset; j >= 0 && j < n {
+				v, err = w.args[0].fn(p[j].row)
+			} else if len(w.args) > 2 {
+				v, err = w.args[2].fn(p[i].row)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", w.name, err)
+			}
+			if p[i].wins[wi], err = convert(v, w.typ); err != nil {
+				return fmt.Errorf("%s: %w", w.name, err)
+			}
+		}
+		return nil
+	}
+
+	frame := func(i int) (int, int) {
+		start, end := 0, n-1
+		switch w.start {
+		case boundPreceding:
+			start = i - int(w.startOffset)
+		case boundCurrentRow:
+			start = i
+			if !w.rows {
+				start = firstPeer[i]
+			}
+		case boundFollowing:
+			start = i + int(w.startOffset)
+		}
+		switch w.end {
+		case boundPreceding:
+			end = i - int(w.endOffset)
+		case boundCurrentRow:
+			end = i
+			if !w.rows {
+				end = lastPeer[i]
+			}
+		case boundFollowing:
+			end = i + int(w.endOffset)
+		}
+		return max(start, 0), min(end, n-1)
+	}
+
+	if w.agg == nil {
+		// first_value, last_value and nth_value
+		for i := range p {
+			start, end := frame(i)
+			j := -1
+			switch w.name {
+			case "first_value":
+				j = start
+			case "last_value":
+				j = end
+			case "nth_value":
+				j = start + int(w.n) - 1
+			}
+			if j < start || j > end {
+				continue
+			}
+			v, err := w.args[0].fn(p[j].row)
+			if err != nil {
+				return fmt.Errorf("%s: %w", w.name, err)
+			}
+			p[i].wins[wi] = v
+		}
+		return nil
+	}
+
+	var seen map[string]bool
+	if w.agg.distinct {
+		seen = map[string]bool{}
+	}
+	if w.start == boundUnboundedPreceding {
+		// the frames only grow, so the rows are added to a single accumulator
+		acc, err := w.agg.fn.newAccumulator(w.agg.param)
+		if err != nil {
+			return err
+		}
+		added := 0
+		for i := range p {
+			_, end := frame(i)
+			for ; added <= end; added++ {
+				if err := ex.tick(); err != nil {
+					return err
+				}
+				if err := w.agg.add(acc, p[added].row, seen); err != nil {
+					return err
+				}
+			}
+			p[i].wins[wi] = acc.result()
+		}
+		return nil
+	}
+	for i := range p {
+		start, end := frame(i)
+		acc, err := w.agg.fn.newAccumulator(w.agg.param)
+		if err != nil {
+			return err
+		}
+		if seen != nil {
+			clear(seen)
+		}
+		for j := start; j <= end; j++ {
+			if err := ex.tick(); err != nil {
+				return err
+			}
+			if err := w.agg.add(acc, p[j].row, seen); err != nil {
+				return err
+			}
+		}
+		p[i].wins[wi] = acc.result()
+	}
+	return nil
+}
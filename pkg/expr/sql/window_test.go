@@ -0,0 +1,126 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowFunctions(t *testing.T) {
+	// the rows of B are (a, eu, 1), (b, us, 2) and (c, us, 3), the results are ordered by weight
+	tests := []struct {
+		expr     string
+		expected []any
+	}{
+		{expr: "row_number() OVER (ORDER BY weight)", expected: []any{int64(1), int64(2), int64(3)}},
+		{expr: "row_number() OVER (ORDER BY weight DESC)", expected: []any{int64(3), int64(2), int64(1)}},
+		{expr: "row_number() OVER (PARTITION BY dc ORDER BY weight)", expected: []any{int64(1), int64(1), int64(2)}},
+		{expr: "rank() OVER (ORDER BY dc)", expected: []any{int64(1), int64(2), int64(2)}},
+		{expr: "rank() OVER (ORDER BY dc DESC)", expected: []any{int64(3), int64(1), int64(1)}},
+		{expr: "rank() OVER (PARTITION BY dc ORDER BY weight DESC)", expected: []any{int64(1), int64(2), int64(1)}},
+		{expr: "rank() OVER ()", expected: []any{int64(1), int64(1), int64(1)}},
+		{expr: "dense_rank() OVER (ORDER BY dc DESC)", expected: []any{int64(2), int64(1), int64(1)}},
+		{expr: "percent_rank() OVER (ORDER BY dc)", expected: []any{0.0, 0.5, 0.5}},
+		{expr: "percent_rank() OVER (PARTITION BY host)", expected: []any{0.0, 0.0, 0.0}},
+		{expr: "cume_dist() OVER (ORDER BY dc)", expected: []any{1.0 / 3, 1.0, 1.0}},
+		{expr: "ntile(2) OVER (ORDER BY weight)", expected: []any{int64(1), int64(1), int64(2)}},
+		{expr: "ntile(5) OVER (ORDER BY weight)", expected: []any{int64(1), int64(2), int64(3)}},
+		{expr: "ntile(1) OVER (ORDER BY weight)", expected: []any{int64(1), int64(1), int64(1)}},
+		{expr: "lag(weight) OVER (ORDER BY weight)", expected: []any{nil, int64(1), int64(2)}},
+		{expr: "lag(weight, 2) OVER (ORDER BY weight)", expected: []any{nil, nil, int64(1)}},
+		{expr: "lag(weight, 1, 0) OVER (ORDER BY weight)", expected: []any{int64(0), int64(1), int64(2)}},
+		{expr: "lag(weight, 1, 0.5) OVER (ORDER BY weight)", expected: []any{0.5, 1.0, 2.0}},
+		{expr: "lag(weight, 0) OVER (ORDER BY weight)", expected: []any{int64(1), int64(2), int64(3)}},
+		{expr: "lead(weight) OVER (ORDER BY weight)", expected: []any{int64(2), int64(3), nil}},
+		{expr: "lead(host, 1, 'none') OVER (PARTITION BY dc ORDER BY weight)", expected: []any{"none", "c", "none"}},
+		{expr: "first_value(host) OVER (ORDER BY weight)", expected: []any{"a", "a", "a"}},
+		{expr: "first_value(host) OVER (ORDER BY weight DESC)", expected: []any{"c", "c", "c"}},
+		{expr: "last_value(host) OVER (ORDER BY weight)", expected: []any{"a", "b", "c"}},
+		{expr: "last_value(host) OVER (ORDER BY dc)", expected: []any{"a", "c", "c"}},
+		{expr: "last_value(host) OVER (ORDER BY weight ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)", expected: []any{"c", "c", "c"}},
+		{expr: "nth_value(host, 2) OVER (ORDER BY weight)", expected: []any{nil, "b", "b"}},
+		{expr: "nth_value(host, 4) OVER ()", expected: []any{nil, nil, nil}},
+
+		{expr: "sum(weight) OVER ()", expected: []any{int64(6), int64(6), int64(6)}},
+		{expr: "sum(weight) OVER (PARTITION BY dc)", expected: []any{int64(1), int64(5), int64(5)}},
+		{expr: "sum(weight) OVER (ORDER BY dc)", expected: []any{int64(1), int64(6), int64(6)}},
+		{expr: "sum(weight) OVER (ORDER BY dc ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)", expected: []any{int64(1), int64(3), int64(6)}},
+		{expr: "sum(weight) OVER (ORDER BY weight ROWS BETWEEN CURRENT ROW AND 1 FOLLOWING)", expected: []any{int64(3), int64(5), int64(3)}},
+		{expr: "sum(weight) OVER (ORDER BY weight ROWS BETWEEN 1 FOLLOWING AND UNBOUNDED FOLLOWING)", expected: []any{int64(5), int64(3), nil}},
+		{expr: "sum(weight) OVER (ORDER BY weight ROWS BETWEEN 2 PRECEDING AND 1 PRECEDING)", expected: []any{nil, int64(1), int64(3)}},
+		{expr: "sum(weight) OVER (ORDER BY dc RANGE BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING)", expected: []any{int64(6), int64(5), int64(5)}},
+		{expr: "avg(weight) OVER (ORDER BY weight ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING)", expected: []any{1.5, 2.0, 2.5}},
+		{expr: "count(*) OVER (ORDER BY dc)", expected: []any{int64(1), int64(3), int64(3)}},
+		{expr: "count(DISTINCT dc) OVER ()", expected: []any{int64(2), int64(2), int64(2)}},
+		{expr: "count(DISTINCT host) OVER (PARTITION BY dc)", expected: []any{int64(1), int64(2), int64(2)}},
+		{expr: "count(*) FILTER (WHERE dc = 'us') OVER ()", expected: []any{int64(2), int64(2), int64(2)}},
+		{expr: "max(host) OVER (PARTITION BY dc)", expected: []any{"a", "c", "c"}},
+		{expr: "string_agg(host, '') OVER (ORDER BY weight DESC)", expected: []any{"cba", "cb", "c"}},
+		{expr: "weight - lag(weight, 1, 0) OVER (ORDER BY weight)", expected: []any{int64(1), int64(1), int64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			res := execute(t, "SELECT "+tt.expr+" FROM B ORDER BY weight")
+			values := make([]any, len(res.Rows))
+			for i, r := range res.Rows {
+				values[i] = r[0]
+			}
+			require.Equal(t, tt.expected, values)
+		})
+	}
+}
+
+func TestWindowQueries(t *testing.T) {
+	testQueries(t, []queryTest{
+		{
+			name:  "window over an empty result",
+			query: "SELECT row_number() OVER () FROM B WHERE FALSE",
+		},
+		{
+			name:  "window in order by",
+			query: "SELECT host FROM B ORDER BY row_number() OVER (ORDER BY weight DESC)",
+			rows:  [][]any{{"c"}, {"b"}, {"a"}},
+		},
+		{
+			name:  "window over a filtered query",
+			query: "SELECT host, count(*) OVER () FROM B WHERE dc = 'us' ORDER BY host",
+			rows:  [][]any{{"b", int64(2)}, {"c", int64(2)}},
+		},
+		{
+			name:  "window over groups partitioned by an aggregate",
+			query: "SELECT host, rank() OVER (ORDER BY count(*) DESC) FROM A GROUP BY host ORDER BY host",
+			rows:  [][]any{{"a", int64(2)}, {"b", int64(1)}},
+		},
+		{
+			name:  "window with NULLs",
+			query: "SELECT value, sum(value) OVER (ORDER BY value) FROM A WHERE host = 'b' ORDER BY value",
+			rows:  [][]any{{10.0, 10.0}, {20.0, 30.0}, {nil, 30.0}},
+		},
+	})
+}
+
+func TestWindowErrors(t *testing.T) {
+	testQueryErrors(t, []errorTest{
+		{name: "arguments to rank", query: "SELECT rank(weight) OVER () FROM B", err: "wrong number of arguments to window function rank"},
+		{name: "missing ntile argument", query: "SELECT ntile() OVER () FROM B", err: "wrong number of arguments to window function ntile"},
+		{name: "too many lag arguments", query: "SELECT lag(weight, 1, 0, 0) OVER () FROM B", err: "wrong number of arguments to window function lag"},
+		{name: "star", query: "SELECT row_number(*) OVER () FROM B", err: "wrong number of arguments to window function row_number"},
+		{name: "distinct", query: "SELECT lag(DISTINCT weight) OVER () FROM B", err: "wrong number of arguments to window function lag"},
+		{name: "zero ntile", query: "SELECT ntile(0) OVER () FROM B", err: "the argument of ntile must be positive"},
+		{name: "zero nth_value", query: "SELECT nth_value(host, 0) OVER () FROM B", err: "the argument of nth_value must be positive"},
+		{name: "non-constant ntile", query: "SELECT ntile(weight) OVER () FROM B", err: `ntile: column "weight" not found`},
+		{name: "negative lag offset", query: "SELECT lag(weight, -1) OVER () FROM B", err: "lag must be a non-negative integer"},
+		{name: "filter", query: "SELECT lag(weight) FILTER (WHERE TRUE) OVER () FROM B", err: "FILTER is not supported for window function lag"},
+		{name: "distinct with order", query: "SELECT sum(DISTINCT weight) OVER (ORDER BY weight) FROM B", err: "DISTINCT is not supported for window functions with ORDER BY"},
+		{name: "scalar function", query: "SELECT upper(host) OVER () FROM B", err: "upper is not a window function"},
+		{name: "range offset", query: "SELECT sum(weight) OVER (ORDER BY weight RANGE BETWEEN 1 PRECEDING AND CURRENT ROW) FROM B", err: "RANGE frames with an offset are not supported, use ROWS"},
+		{name: "frame starts after the end", query: "SELECT sum(weight) OVER (ROWS BETWEEN UNBOUNDED FOLLOWING AND CURRENT ROW) FROM B", err: "invalid window frame"},
+		{name: "frame ends before the start", query: "SELECT sum(weight) OVER (ROWS BETWEEN CURRENT ROW AND UNBOUNDED PRECEDING) FROM B", err: "invalid window frame"},
+		{name: "non-constant frame offset", query: "SELECT sum(weight) OVER (ROWS BETWEEN weight PRECEDING AND CURRENT ROW) FROM B", err: `window frame: column "weight" not found`},
+		{name: "unknown partition column", query: "SELECT row_number() OVER (PARTITION BY foo) FROM B", err: `PARTITION BY: column "foo" not found`},
+		{name: "unknown order column", query: "SELECT row_number() OVER (ORDER BY foo) FROM B", err: `ORDER BY: column "foo" not found`},
+		{name: "nested windows", query: "SELECT sum(row_number() OVER ()) OVER () FROM B", err: "window function row_number is not allowed here"},
+		{name: "window in group by", query: "SELECT count(*) FROM B GROUP BY row_number() OVER ()", err: "window function row_number is not allowed here"},
+		{name: "window in aggregate", query: "SELECT sum(row_number() OVER ()) FROM B", err: "window function row_number is not allowed here"},
+	})
+}
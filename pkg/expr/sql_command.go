@@ -7,34 +7,51 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
-	"github.com/scottlepp/go-duck/duck"
 
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 	"github.com/grafana/grafana/pkg/expr/sql"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	defaultSQLRowLimit      = 100000
+	defaultSQLMemoryLimitMB = 100
 )
 
 // SQLCommand is an expression to run SQL over results
 type SQLCommand struct {
 	query       string
+	parsed      *sql.Query
 	varsToQuery []string
 	refID       string
+	format      SQLFormat
+	limits      sql.Limits
 }
 
 // NewSQLCommand creates a new SQLCommand.
-func NewSQLCommand(refID, rawSQL string) (*SQLCommand, error) {
+func NewSQLCommand(refID, rawSQL string, format SQLFormat) (*SQLCommand, error) {
 	if rawSQL == "" {
 		return nil, errutil.BadRequest("sql-missing-query",
 			errutil.WithPublicMessage("missing SQL query"))
 	}
-	tables, err := sql.TablesList(rawSQL)
+	switch format {
+	case "":
+		format = SQLFormatTable
+	case SQLFormatTable, SQLFormatNumbers:
+	default:
+		return nil, errutil.BadRequest("sql-invalid-format",
+			errutil.WithPublicMessage(fmt.Sprintf("SQL format '%s' is not supported. Supported only: [%s,%s]", format, SQLFormatTable, SQLFormatNumbers)))
+	}
+	parsed, err := sql.Parse(rawSQL)
 	if err != nil {
 		logger.Warn("invalid sql query", "sql", rawSQL, "error", err)
 		return nil, errutil.BadRequest("sql-invalid-sql",
-			errutil.WithPublicMessage("error reading SQL command"),
+			errutil.WithPublicMessage(fmt.Sprintf("error reading SQL command: %s", err)),
 		)
 	}
+	tables := sql.Tables(parsed)
 	if len(tables) == 0 {
 		logger.Warn("no tables found in SQL query", "sql", rawSQL)
 	}
@@ -43,8 +60,11 @@ func NewSQLCommand(refID, rawSQL string) (*SQLCommand, error) {
 	}
 	return &SQLCommand{
 		query:       rawSQL,
+		parsed:      parsed,
 		varsToQuery: tables,
 		refID:       refID,
+		format:      format,
+		limits:      sqlLimits(nil),
 	}, nil
 }
 
@@ -66,7 +86,27 @@ func UnmarshalSQLCommand(rn *rawNode) (*SQLCommand, error) {
 		return nil, fmt.Errorf("expected sql expression to be type string, but got type %T", expressionRaw)
 	}
 
-	return NewSQLCommand(rn.RefID, expression)
+	var format SQLFormat
+	if formatRaw, ok := rn.Query["format"]; ok {
+		formatStr, ok := formatRaw.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected sql format to be type string, but got type %T", formatRaw)
+		}
+		format = SQLFormat(formatStr)
+	}
+
+	return NewSQLCommand(rn.RefID, expression, format)
+}
+
+// sqlLimits returns the row and memory limits of SQL expressions from the configuration.
+func sqlLimits(cfg *setting.Cfg) sql.Limits {
+	if cfg == nil {
+		return sql.Limits{MaxRows: defaultSQLRowLimit, MaxBytes: defaultSQLMemoryLimitMB * 1024 * 1024}
+	}
+	return sql.Limits{
+		MaxRows:  cfg.SQLExpressionRowLimit,
+		MaxBytes: cfg.SQLExpressionMemoryLimitMB * 1024 * 1024,
+	}
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -78,7 +118,7 @@ func (gr *SQLCommand) NeedsVars() []string {
 // Execute runs the command and returns the results or an error if the command
 // failed to execute.
 func (gr *SQLCommand) Execute(ctx context.Context, now time.Time, vars mathexp.Vars, tracer tracing.Tracer) (mathexp.Results, error) {
-	_, span := tracer.Start(ctx, "SSE.ExecuteSQL")
+	ctx, span := tracer.Start(ctx, "SSE.ExecuteSQL")
 	defer span.End()
 
 	allFrames := []*data.Frame{}
@@ -94,24 +134,33 @@ func (gr *SQLCommand) Execute(ctx context.Context, now time.Time, vars mathexp.V
 
 	rsp := mathexp.Results{}
 
-	duckDB := duck.NewInMemoryDB()
-	var frame = &data.Frame{}
-
 	logger.Debug("Executing query", "query", gr.query, "frames", len(allFrames))
-	err := duckDB.QueryFramesInto(gr.refID, gr.query, allFrames, frame)
+	frame, err := sql.QueryFrames(ctx, gr.refID, gr.parsed, allFrames, gr.limits)
 	if err != nil {
 		logger.Error("Failed to query frames", "error", err.Error())
+		if errors.Is(err, sql.ErrRowLimitExceeded) || errors.Is(err, sql.ErrMemoryLimitExceeded) {
+			err = makeSQLLimitError(gr.refID, err)
+		}
 		rsp.Error = err
 		return rsp, nil
 	}
 	logger.Debug("Done Executing query", "query", gr.query, "rows", frame.Rows())
 
-	frame.RefID = gr.refID
-
 	if frame.Rows() == 0 {
 		rsp.Values = mathexp.Values{
 			mathexp.NoData{Frame: frame},
 		}
+		return rsp, nil
+	}
+
+	if gr.format == SQLFormatNumbers {
+		numbers, err := tableToNumbers(frame)
+		if err != nil {
+			rsp.Error = fmt.Errorf("failed to convert the result of SQL expression %s to numbers: %w", gr.refID, err)
+			return rsp, nil
+		}
+		rsp.Values = numbers
+		return rsp, nil
 	}
 
 	rsp.Values = mathexp.Values{
@@ -124,3 +173,52 @@ func (gr *SQLCommand) Execute(ctx context.Context, now time.Time, vars mathexp.V
 func (gr *SQLCommand) Type() string {
 	return TypeSQL.String()
 }
+
+// tableToNumbers converts a table with a single numeric or boolean column into a number for each row,
+// with the string columns as the labels. This works the same way as numeric tables returned by data sources,
+// so that SQL expressions can be the condition of alert rules.
+func tableToNumbers(frame *data.Frame) (mathexp.Values, error) {
+	frame = boolsToNumbers(frame)
+	if !isNumberTable(frame) {
+		return nil, errors.New("the result must have exactly one numeric or boolean column, all other columns must be strings")
+	}
+	numberSet, err := extractNumberSet(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(numberSet))
+	numbers := make(mathexp.Values, 0, len(numberSet))
+	for _, n := range numberSet {
+		key := n.GetLabels().String()
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate row with labels {%s}, the string columns must identify each row", key)
+		}
+		seen[key] = true
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// boolsToNumbers returns the frame with its boolean columns converted to 1 for true and 0 for false.
+func boolsToNumbers(frame *data.Frame) *data.Frame {
+	fields := make([]*data.Field, len(frame.Fields))
+	for i, field := range frame.Fields {
+		if field.Type() != data.FieldTypeBool && field.Type() != data.FieldTypeNullableBool {
+			fields[i] = field
+			continue
+		}
+		values := make([]*float64, field.Len())
+		for rowIdx := range values {
+			if v, ok := field.ConcreteAt(rowIdx); ok {
+				f := 0.0
+				if v.(bool) {
+					f = 1
+				}
+				values[rowIdx] = &f
+			}
+		}
+		fields[i] = data.NewField(field.Name, field.Labels, values).SetConfig(field.Config)
+	}
+	return data.NewFrame(frame.Name, fields...)
+}
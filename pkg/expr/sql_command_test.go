@@ -1,13 +1,22 @@
 package expr
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/expr/sql"
+	"github.com/grafana/grafana/pkg/infra/tracing"
 )
 
 func TestNewCommand(t *testing.T) {
-	t.Skip()
-	cmd, err := NewSQLCommand("a", "select a from foo, bar")
+	cmd, err := NewSQLCommand("a", "select a from foo, bar", SQLFormatTable)
 	if err != nil && strings.Contains(err.Error(), "feature is not enabled") {
 		return
 	}
@@ -25,3 +34,146 @@ func TestNewCommand(t *testing.T) {
 		return
 	}
 }
+
+func TestNewCommandInvalidSQL(t *testing.T) {
+	_, err := NewSQLCommand("a", "select from where", SQLFormatTable)
+	require.Error(t, err)
+}
+
+func TestNewCommandInvalidFormat(t *testing.T) {
+	_, err := NewSQLCommand("a", "select a from foo", "wide")
+	require.Error(t, err)
+}
+
+func TestSQLCommandExecute(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := func(host string, values ...float64) mathexp.Value {
+		s := mathexp.NewSeries("A", data.Labels{"host": host}, len(values))
+		for i, v := range values {
+			v := v
+			s.SetPoint(i, t0.Add(time.Duration(i)*time.Minute), &v)
+		}
+		return s
+	}
+	vars := mathexp.Vars{
+		"A": mathexp.Results{Values: mathexp.Values{series("a", 1, 3), series("b", 10, 20, 30)}},
+	}
+
+	t.Run("should aggregate the series by label", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT host, avg(A) AS avg, count(*) AS points FROM A GROUP BY host ORDER BY host", SQLFormatTable)
+		require.NoError(t, err)
+		require.Equal(t, []string{"A"}, cmd.NeedsVars())
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Values, 1)
+
+		frame := res.Values[0].(mathexp.TableData).Frame
+		require.Equal(t, "B", frame.RefID)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "host", frame.Fields[0].Name)
+		require.Equal(t, "a", frame.Fields[0].At(0))
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		require.Equal(t, 2.0, *frame.Fields[1].At(0).(*float64))
+		require.Equal(t, 20.0, *frame.Fields[1].At(1).(*float64))
+		require.Equal(t, int64(3), *frame.Fields[2].At(1).(*int64))
+	})
+
+	t.Run("should keep the type of selected fields", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT Time, A FROM A WHERE host = 'b' ORDER BY Time", SQLFormatTable)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+
+		frame := res.Values[0].(mathexp.TableData).Frame
+		require.Equal(t, data.FieldTypeTime, frame.Fields[0].Type())
+		require.Equal(t, data.FieldTypeNullableFloat64, frame.Fields[1].Type())
+		require.Equal(t, 3, frame.Rows())
+	})
+
+	t.Run("should return no data for an empty result", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT * FROM A WHERE host = 'c'", SQLFormatTable)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Values, 1)
+		require.IsType(t, mathexp.NoData{}, res.Values[0])
+	})
+
+	t.Run("should return a number for each row in numbers format", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT host, max(A) AS max FROM A GROUP BY host", SQLFormatNumbers)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Values, 2)
+
+		for i, expected := range []struct {
+			host  string
+			value float64
+		}{{"a", 3}, {"b", 30}} {
+			n, ok := res.Values[i].(mathexp.Number)
+			require.True(t, ok)
+			require.Equal(t, data.Labels{"host": expected.host}, n.GetLabels())
+			require.Equal(t, expected.value, *n.GetFloat64Value())
+		}
+	})
+
+	t.Run("should use booleans as numbers in numbers format", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT host, max(A) > 10 AS firing FROM A GROUP BY host ORDER BY host", SQLFormatNumbers)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Equal(t, 0.0, *res.Values[0].(mathexp.Number).GetFloat64Value())
+		require.Equal(t, 1.0, *res.Values[1].(mathexp.Number).GetFloat64Value())
+	})
+
+	t.Run("should leave NULL strings out of the labels in numbers format", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT nullif(host, 'a') AS host, max(A) AS max FROM A GROUP BY 1 ORDER BY max", SQLFormatNumbers)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, res.Values, 2)
+		require.Empty(t, res.Values[0].GetLabels())
+		require.Equal(t, data.Labels{"host": "b"}, res.Values[1].GetLabels())
+	})
+
+	t.Run("should fail in numbers format when rows have the same labels", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT host, A FROM A", SQLFormatNumbers)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.ErrorContains(t, res.Error, "duplicate row with labels")
+	})
+
+	t.Run("should fail in numbers format when there are other columns", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT Time, host, A FROM A", SQLFormatNumbers)
+		require.NoError(t, err)
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.ErrorContains(t, res.Error, "all other columns must be strings")
+	})
+
+	t.Run("should fail when a limit is exceeded", func(t *testing.T) {
+		cmd, err := NewSQLCommand("B", "SELECT * FROM A", SQLFormatTable)
+		require.NoError(t, err)
+		cmd.limits = sql.Limits{MaxRows: 4}
+
+		res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest())
+		require.NoError(t, err)
+		require.Error(t, res.Error)
+		require.True(t, errors.Is(res.Error, sql.ErrRowLimitExceeded))
+	})
+}
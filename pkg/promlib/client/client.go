@@ -35,11 +35,17 @@ func NewClient(d doer, method, baseUrl string) *Client {
 
 func (c *Client) QueryRange(ctx context.Context, q *models.Query) (*http.Response, error) {
 	tr := q.TimeRange()
+	return c.QueryRangeBetween(ctx, q, tr.Start, tr.End)
+}
+
+// QueryRangeBetween runs the range query between start and end instead of the time range of the query.
+// start and end are sent as is, they must already be aligned to the step of the query.
+func (c *Client) QueryRangeBetween(ctx context.Context, q *models.Query, start, end time.Time) (*http.Response, error) {
 	qv := map[string]string{
 		"query": q.Expr,
-		"start": formatTime(tr.Start),
-		"end":   formatTime(tr.End),
-		"step":  strconv.FormatFloat(tr.Step.Seconds(), 'f', -1, 64),
+		"start": formatTime(start),
+		"end":   formatTime(end),
+		"step":  strconv.FormatFloat(q.Step.Seconds(), 'f', -1, 64),
 	}
 
 	req, err := c.createQueryRequest(ctx, "api/v1/query_range", qv)
@@ -137,5 +137,26 @@ func TestClient(t *testing.T) {
 			require.Equal(t, []byte{}, body)
 			require.Equal(t, "http://localhost:9090/api/v1/query_range?end=1234&query=rate%28ALERTS%7Bjob%3D%22test%22+%5B%24__rate_interval%5D%7D%29&start=0&step=1", doer.Req.URL.String())
 		})
+
+		t.Run("sends the given range instead of the range of the query", func(t *testing.T) {
+			client := NewClient(doer, http.MethodGet, "http://localhost:9090")
+			req := &models.Query{
+				Expr:       "up",
+				Start:      time.Unix(0, 0),
+				End:        time.Unix(1234, 0),
+				RangeQuery: true,
+				Step:       10 * time.Second,
+			}
+			res, err := client.QueryRangeBetween(context.Background(), req, time.Unix(600, 0), time.Unix(1190, 0))
+			defer func() {
+				if res != nil && res.Body != nil {
+					if err := res.Body.Close(); err != nil {
+						fmt.Println("Error", "err", err)
+					}
+				}
+			}()
+			require.NoError(t, err)
+			require.Equal(t, "http://localhost:9090/api/v1/query_range?end=1190&query=up&start=600&step=10", doer.Req.URL.String())
+		})
 	})
 }
@@ -0,0 +1,125 @@
+package querydata
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// rangeChunkCacheSize is the number of chunks kept for a data source.
+	rangeChunkCacheSize = 1000
+	// rangeChunkCacheMaxBytes is the estimated size of the chunks kept for a data source.
+	rangeChunkCacheMaxBytes = 64 << 20
+	// rangeChunkCacheTTL limits how long a chunk is reused, so that the changes made to old samples,
+	// such as backfills or deletions, are eventually visible.
+	rangeChunkCacheTTL = time.Hour
+)
+
+// rangeChunkCache keeps the frames of the complete chunks of split range queries in memory.
+// The least recently used chunks are evicted when the cache has too many chunks or their
+// estimated size is too large. The frames must not be modified after they are added.
+type rangeChunkCache struct {
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	bytes   int64
+}
+
+type rangeChunkCacheEntry struct {
+	key     string
+	frames  data.Frames
+	size    int64
+	expires time.Time
+}
+
+func newRangeChunkCache(maxEntries int, maxBytes int64, ttl time.Duration) *rangeChunkCache {
+	return &rangeChunkCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+func (c *rangeChunkCache) get(key string) (data.Frames, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*rangeChunkCacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.frames, true
+}
+
+func (c *rangeChunkCache) set(key string, frames data.Frames) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &rangeChunkCacheEntry{key: key, frames: frames, size: framesSize(frames), expires: c.now().Add(c.ttl)}
+	// A chunk larger than the whole cache would evict all the other chunks.
+	if entry.size > c.maxBytes {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += entry.size
+	for c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *rangeChunkCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*rangeChunkCacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// framesSize estimates the memory used by the values and labels of frames.
+func framesSize(frames data.Frames) int64 {
+	var size int64
+	for _, frame := range frames {
+		for _, field := range frame.Fields {
+			for name, value := range field.Labels {
+				size += int64(len(name) + len(value))
+			}
+			switch field.Type() {
+			case data.FieldTypeString:
+				for i := 0; i < field.Len(); i++ {
+					s, _ := field.At(i).(string)
+					size += int64(16 + len(s))
+				}
+			case data.FieldTypeNullableString:
+				for i := 0; i < field.Len(); i++ {
+					size += 8
+					if s, ok := field.At(i).(*string); ok && s != nil {
+						size += int64(16 + len(*s))
+					}
+				}
+			case data.FieldTypeTime, data.FieldTypeNullableTime:
+				size += int64(field.Len()) * 24
+			default:
+				size += int64(field.Len()) * 8
+			}
+		}
+	}
+	return size
+}
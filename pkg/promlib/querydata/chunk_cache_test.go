@@ -0,0 +1,85 @@
+package querydata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func chunkFrames(rows int) data.Frames {
+	value := data.NewField("value", data.Labels{"job": "api"}, make([]float64, rows))
+	return data.Frames{data.NewFrame("", data.NewField("time", nil, make([]time.Time, rows)), value)}
+}
+
+func TestFramesSize(t *testing.T) {
+	// 10 times, 10 values and the label.
+	require.Equal(t, int64(10*24+10*8+6), framesSize(chunkFrames(10)))
+
+	text := "error"
+	frames := data.Frames{data.NewFrame("",
+		data.NewField("line", nil, []string{"ok"}),
+		data.NewField("error", nil, []*string{&text, nil}),
+	)}
+	require.Equal(t, int64(16+2+8+16+5+8), framesSize(frames))
+}
+
+func TestRangeChunkCache(t *testing.T) {
+	t.Run("evicts the least recently used chunks when there are too many", func(t *testing.T) {
+		c := newRangeChunkCache(2, 1<<20, time.Hour)
+		c.set("a", chunkFrames(1))
+		c.set("b", chunkFrames(1))
+		_, ok := c.get("a")
+		require.True(t, ok)
+		c.set("c", chunkFrames(1))
+
+		_, ok = c.get("b")
+		require.False(t, ok)
+		_, ok = c.get("a")
+		require.True(t, ok)
+		_, ok = c.get("c")
+		require.True(t, ok)
+	})
+
+	t.Run("evicts the least recently used chunks when they are too large", func(t *testing.T) {
+		size := framesSize(chunkFrames(10))
+		c := newRangeChunkCache(100, 2*size, time.Hour)
+		c.set("a", chunkFrames(10))
+		c.set("b", chunkFrames(10))
+		c.set("c", chunkFrames(10))
+		require.Equal(t, 2*size, c.bytes)
+
+		_, ok := c.get("a")
+		require.False(t, ok)
+		_, ok = c.get("b")
+		require.True(t, ok)
+
+		// Replacing a chunk accounts for its new size.
+		c.set("b", chunkFrames(1))
+		require.Equal(t, size+framesSize(chunkFrames(1)), c.bytes)
+	})
+
+	t.Run("does not keep chunks larger than the cache", func(t *testing.T) {
+		c := newRangeChunkCache(100, framesSize(chunkFrames(10)), time.Hour)
+		c.set("a", chunkFrames(1))
+		c.set("b", chunkFrames(11))
+
+		_, ok := c.get("b")
+		require.False(t, ok)
+		_, ok = c.get("a")
+		require.True(t, ok)
+	})
+
+	t.Run("expires the chunks", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		c := newRangeChunkCache(100, 1<<20, time.Minute)
+		c.now = func() time.Time { return now }
+		c.set("a", chunkFrames(1))
+
+		now = now.Add(2 * time.Minute)
+		_, ok := c.get("a")
+		require.False(t, ok)
+		require.Zero(t, c.bytes)
+	})
+}
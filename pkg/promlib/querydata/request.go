@@ -45,6 +45,8 @@ type QueryData struct {
 	URL                string
 	TimeInterval       string
 	exemplarSampler    func() exemplar.Sampler
+	rangeSplitting     rangeSplitting
+	rangeChunks        *rangeChunkCache
 }
 
 func New(
@@ -67,6 +69,11 @@ func New(
 		httpMethod = http.MethodPost
 	}
 
+	splitting, err := readRangeSplitting(jsonData)
+	if err != nil {
+		return nil, err
+	}
+
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
 
 	// standard deviation sampler is the default for backwards compatibility
@@ -81,6 +88,8 @@ func New(
 		ID:                 settings.ID,
 		URL:                settings.URL,
 		exemplarSampler:    exemplarSampler,
+		rangeSplitting:     splitting,
+		rangeChunks:        newRangeChunkCache(rangeChunkCacheSize, rangeChunkCacheMaxBytes, rangeChunkCacheTTL),
 	}, nil
 }
 
@@ -93,6 +102,7 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 	}
 
 	var (
+		scope                             = rangeChunkScope(req)
 		cfg                               = backend.GrafanaConfigFromContext(ctx)
 		hasPromQLScopeFeatureFlag         = cfg.FeatureToggles().IsEnabled("promQLScope")
 		hasPrometheusDataplaneFeatureFlag = cfg.FeatureToggles().IsEnabled("prometheusDataplane")
@@ -112,7 +122,7 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 
 		_ = concurrency.ForEachJob(ctx, len(req.Queries), concurrentQueryCount, func(ctx context.Context, idx int) error {
 			query := req.Queries[idx]
-			r := s.handleQuery(ctx, query, fromAlert, hasPromQLScopeFeatureFlag, hasPrometheusDataplaneFeatureFlag, true)
+			r := s.handleQuery(ctx, query, fromAlert, hasPromQLScopeFeatureFlag, hasPrometheusDataplaneFeatureFlag, true, scope)
 			if r != nil {
 				m.Lock()
 				result.Responses[query.RefID] = *r
@@ -122,7 +132,7 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 		})
 	} else {
 		for _, q := range req.Queries {
-			r := s.handleQuery(ctx, q, fromAlert, hasPromQLScopeFeatureFlag, hasPrometheusDataplaneFeatureFlag, false)
+			r := s.handleQuery(ctx, q, fromAlert, hasPromQLScopeFeatureFlag, hasPrometheusDataplaneFeatureFlag, false, scope)
 			if r != nil {
 				result.Responses[q.RefID] = *r
 			}
@@ -133,7 +143,7 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 }
 
 func (s *QueryData) handleQuery(ctx context.Context, bq backend.DataQuery, fromAlert,
-	hasPromQLScopeFeatureFlag, hasPrometheusDataplaneFeatureFlag, hasPrometheusRunQueriesInParallel bool) *backend.DataResponse {
+	hasPromQLScopeFeatureFlag, hasPrometheusDataplaneFeatureFlag, hasPrometheusRunQueriesInParallel bool, scope []string) *backend.DataResponse {
 	traceCtx, span := s.tracer.Start(ctx, "datasource.prometheus")
 	defer span.End()
 	query, err := models.Parse(span, bq, s.TimeInterval, s.intervalCalculator, fromAlert, hasPromQLScopeFeatureFlag)
@@ -143,7 +153,7 @@ func (s *QueryData) handleQuery(ctx context.Context, bq backend.DataQuery, fromA
 		}
 	}
 
-	r := s.fetch(traceCtx, s.client, query, hasPrometheusDataplaneFeatureFlag, hasPrometheusRunQueriesInParallel)
+	r := s.fetch(traceCtx, s.client, query, hasPrometheusDataplaneFeatureFlag, hasPrometheusRunQueriesInParallel, scope)
 	if r == nil {
 		s.log.FromContext(ctx).Debug("Received nil response from runQuery", "query", query.Expr)
 	}
@@ -151,7 +161,7 @@ func (s *QueryData) handleQuery(ctx context.Context, bq backend.DataQuery, fromA
 }
 
 func (s *QueryData) fetch(traceCtx context.Context, client *client.Client, q *models.Query,
-	enablePrometheusDataplane, hasPrometheusRunQueriesInParallel bool) *backend.DataResponse {
+	enablePrometheusDataplane, hasPrometheusRunQueriesInParallel bool, scope []string) *backend.DataResponse {
 	logger := s.log.FromContext(traceCtx)
 	logger.Debug("Sending query", "start", q.Start, "end", q.End, "step", q.Step, "query", q.Expr)
 
@@ -186,13 +196,13 @@ func (s *QueryData) fetch(traceCtx context.Context, client *client.Client, q *mo
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				res := s.rangeQuery(traceCtx, client, q, enablePrometheusDataplane)
+				res := s.rangeQuery(traceCtx, client, q, enablePrometheusDataplane, scope)
 				m.Lock()
 				addDataResponse(&res, dr)
 				m.Unlock()
 			}()
 		} else {
-			res := s.rangeQuery(traceCtx, client, q, enablePrometheusDataplane)
+			res := s.rangeQuery(traceCtx, client, q, enablePrometheusDataplane, scope)
 			addDataResponse(&res, dr)
 		}
 	}
@@ -227,7 +237,11 @@ func (s *QueryData) fetch(traceCtx context.Context, client *client.Client, q *mo
 	return dr
 }
 
-func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.Query, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.Query, enablePrometheusDataplaneFlag bool, scope []string) backend.DataResponse {
+	if chunks := s.rangeSplitting.chunks(q, time.Now()); chunks != nil {
+		return s.splitRangeQuery(ctx, c, q, chunks, scope)
+	}
+
 	res, err := c.QueryRange(ctx, q)
 	if err != nil {
 		return backend.DataResponse{
@@ -19,19 +19,29 @@ import (
 )
 
 func (s *QueryData) parseResponse(ctx context.Context, q *models.Query, res *http.Response) backend.DataResponse {
+	return s.processResponse(ctx, q, s.readResponse(ctx, res))
+}
+
+// readResponse converts a response to frames, without the metadata of the query.
+func (s *QueryData) readResponse(ctx context.Context, res *http.Response) backend.DataResponse {
 	defer func() {
 		if err := res.Body.Close(); err != nil {
 			s.log.FromContext(ctx).Error("Failed to close response body", "err", err)
 		}
 	}()
 
-	ctx, endSpan := utils.StartTrace(ctx, s.tracer, "datasource.prometheus.parseResponse")
+	_, endSpan := utils.StartTrace(ctx, s.tracer, "datasource.prometheus.parseResponse")
 	defer endSpan()
 
 	iter := jsoniter.Parse(jsoniter.ConfigDefault, res.Body, 1024)
 	r := converter.ReadPrometheusStyleResult(iter, converter.Options{Dataplane: true})
 	r.Status = backend.Status(res.StatusCode)
+	return r
+}
 
+// processResponse adds the metadata of the query to the frames read by readResponse and samples
+// the exemplars.
+func (s *QueryData) processResponse(ctx context.Context, q *models.Query, r backend.DataResponse) backend.DataResponse {
 	// Add frame to attach metadata
 	if len(r.Frames) == 0 && !q.ExemplarQuery {
 		r.Frames = append(r.Frames, data.NewFrame(""))
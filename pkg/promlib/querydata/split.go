@@ -0,0 +1,334 @@
+package querydata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/utils/maputil"
+
+	"github.com/grafana/grafana/pkg/promlib/client"
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+const (
+	defaultRangeQuerySplitInterval = 24 * time.Hour
+	defaultRangeQueryOverlapWindow = 10 * time.Minute
+	// maxRangeQueryChunks limits the number of requests sent for a range query, longer ranges
+	// use larger chunks.
+	maxRangeQueryChunks = 60
+	// rangeQueryChunkConcurrency is the number of chunks of a range query fetched in parallel.
+	rangeQueryChunkConcurrency = 4
+)
+
+// identityHeaders are the headers forwarded to Prometheus that identify the user, the chunks
+// fetched with them are only reused for the same identity.
+var identityHeaders = []string{
+	backend.OAuthIdentityTokenHeaderName,
+	backend.OAuthIdentityIDTokenHeaderName,
+	backend.CookiesHeaderName,
+}
+
+// rangeSplitting configures the splitting of range queries into chunks. The complete chunks are
+// cached, so that refreshing a long range only fetches the newest chunks again.
+type rangeSplitting struct {
+	// interval is the minimum size of the chunks, 0 disables the splitting.
+	interval time.Duration
+	// overlap is the window before now in which the chunks are not cached, Prometheus may
+	// still ingest samples for it.
+	overlap time.Duration
+}
+
+type rangeChunk struct {
+	start time.Time
+	end   time.Time
+	// complete is true for the chunks that cover their whole interval and end before the overlap
+	// window, they are cached.
+	complete bool
+}
+
+func readRangeSplitting(jsonData map[string]any) (rangeSplitting, error) {
+	enabled, err := maputil.GetBoolOptional(jsonData, "rangeQuerySplitting")
+	if err != nil || !enabled {
+		return rangeSplitting{}, err
+	}
+
+	splitting := rangeSplitting{
+		interval: defaultRangeQuerySplitInterval,
+		overlap:  defaultRangeQueryOverlapWindow,
+	}
+	interval, err := maputil.GetStringOptional(jsonData, "rangeQuerySplitInterval")
+	if err != nil {
+		return rangeSplitting{}, err
+	}
+	if interval != "" {
+		if splitting.interval, err = gtime.ParseIntervalStringToTimeDuration(interval); err != nil {
+			return rangeSplitting{}, fmt.Errorf("invalid range query split interval %q: %w", interval, err)
+		}
+		if splitting.interval <= 0 {
+			return rangeSplitting{}, fmt.Errorf("range query split interval must be positive, got %q", interval)
+		}
+	}
+	overlap, err := maputil.GetStringOptional(jsonData, "incrementalQueryOverlapWindow")
+	if err != nil {
+		return rangeSplitting{}, err
+	}
+	if overlap != "" {
+		if splitting.overlap, err = gtime.ParseIntervalStringToTimeDuration(overlap); err != nil {
+			return rangeSplitting{}, fmt.Errorf("invalid incremental query overlap window %q: %w", overlap, err)
+		}
+	}
+	return splitting, nil
+}
+
+// chunks splits the time range of a query into chunks of a multiple of its step. The chunk
+// boundaries are aligned to the chunk size and to the step, so that the chunks of a relative
+// time range are the same between refreshes; the first chunk may start before the time range.
+// It returns nil when the query is not split.
+func (sp rangeSplitting) chunks(q *models.Query, now time.Time) []rangeChunk {
+	stepMs := q.Step.Milliseconds()
+	if sp.interval <= 0 || stepMs <= 0 || !splittableExpr(q.Expr) {
+		return nil
+	}
+	// The boundaries are computed in milliseconds, the precision of the Prometheus timestamps.
+	tr := q.TimeRange()
+	startMs := tr.Start.Round(time.Millisecond).UnixMilli()
+	endMs := tr.End.Round(time.Millisecond).UnixMilli()
+
+	sizeMs := ceilDiv(sp.interval.Milliseconds(), stepMs) * stepMs
+	// The chunk size only depends on the duration of the time range, not on its alignment, so
+	// that it doesn't change between refreshes of a relative time range.
+	if n := ceilDiv(q.End.Sub(q.Start).Milliseconds(), sizeMs); n > maxRangeQueryChunks {
+		sizeMs *= ceilDiv(n, maxRangeQueryChunks)
+	}
+	if endMs-startMs < sizeMs {
+		return nil
+	}
+
+	offsetMs := q.UtcOffsetSec * 1000
+	completeBeforeMs := now.Add(-sp.overlap).UnixMilli()
+	var chunks []rangeChunk
+	for chunkStart := floorDiv(startMs+offsetMs, sizeMs)*sizeMs - offsetMs; chunkStart <= endMs; chunkStart += sizeMs {
+		chunkEnd := chunkStart + sizeMs - stepMs
+		chunks = append(chunks, rangeChunk{
+			start:    time.UnixMilli(chunkStart).UTC(),
+			end:      time.UnixMilli(min(chunkEnd, endMs)).UTC(),
+			complete: chunkEnd <= endMs && chunkEnd < completeBeforeMs,
+		})
+	}
+	return chunks
+}
+
+// splittableExpr returns false for the expressions using the @ modifier: start() and end() are
+// evaluated for each chunk instead of the whole time range.
+func splittableExpr(expr string) bool {
+	return !strings.Contains(expr, "@")
+}
+
+// rangeChunkScope returns the identity of the user the chunks of a request are fetched for.
+func rangeChunkScope(req *backend.QueryDataRequest) []string {
+	var scope []string
+	for _, name := range identityHeaders {
+		if value := req.GetHTTPHeader(name); value != "" {
+			scope = append(scope, name+"="+value)
+		}
+	}
+	return scope
+}
+
+func (s *QueryData) rangeChunkKey(q *models.Query, chunk rangeChunk, scope []string) (string, error) {
+	b, err := json.Marshal(struct {
+		DataSourceID int64    `json:"dataSourceId"`
+		URL          string   `json:"url"`
+		Expr         string   `json:"expr"`
+		StepMs       int64    `json:"stepMs"`
+		Start        int64    `json:"start"`
+		End          int64    `json:"end"`
+		Scope        []string `json:"scope,omitempty"`
+	}{s.ID, s.URL, q.Expr, q.Step.Milliseconds(), chunk.start.UnixMilli(), chunk.end.UnixMilli(), scope})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// splitRangeQuery runs a range query chunk by chunk, reusing the cached complete chunks, and
+// stitches the frames of the chunks together. The exemplars are sampled once for the whole
+// time range, after the chunks are stitched.
+func (s *QueryData) splitRangeQuery(ctx context.Context, c *client.Client, q *models.Query, chunks []rangeChunk, scope []string) backend.DataResponse {
+	logger := s.log.FromContext(ctx)
+	responses := make([]backend.DataResponse, len(chunks))
+	keys := make([]string, len(chunks))
+	var missing []int
+	for i, chunk := range chunks {
+		if chunk.complete {
+			key, err := s.rangeChunkKey(q, chunk, scope)
+			if err != nil {
+				return backend.DataResponse{Error: err}
+			}
+			keys[i] = key
+			if frames, ok := s.rangeChunks.get(key); ok {
+				responses[i] = backend.DataResponse{Frames: frames, Status: backend.StatusOK}
+				continue
+			}
+		}
+		missing = append(missing, i)
+	}
+	logger.Debug("Running split range query", "query", q.Expr, "chunks", len(chunks), "cached", len(chunks)-len(missing))
+
+	err := concurrency.ForEachJob(ctx, len(missing), rangeQueryChunkConcurrency, func(ctx context.Context, idx int) error {
+		i := missing[idx]
+		chunk := chunks[i]
+		r := s.fetchRangeChunk(ctx, c, q, chunk)
+		if chunk.complete && r.Error == nil && r.Status == backend.StatusOK {
+			s.rangeChunks.set(keys[i], r.Frames)
+		}
+		responses[i] = r
+		return nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return backend.DataResponse{Error: err, Status: backend.StatusBadGateway}
+	}
+
+	for _, r := range responses {
+		if r.Error != nil {
+			return r
+		}
+	}
+	r := backend.DataResponse{
+		Frames: stitchFrames(responses, q.TimeRange().Start.Round(time.Millisecond)),
+		Status: responses[len(responses)-1].Status,
+	}
+	return s.processResponse(ctx, q, r)
+}
+
+func (s *QueryData) fetchRangeChunk(ctx context.Context, c *client.Client, q *models.Query, chunk rangeChunk) backend.DataResponse {
+	res, err := c.QueryRangeBetween(ctx, q, chunk.start, chunk.end)
+	if err != nil {
+		return backend.DataResponse{
+			Error:  err,
+			Status: backend.StatusBadGateway,
+		}
+	}
+	return s.readResponse(ctx, res)
+}
+
+// stitchFrames concatenates the frames of the same series of the chunk responses, in the order
+// of the chunks, and drops the rows before start. The frames are copied, the frames of the
+// responses are never modified as they may be cached.
+func stitchFrames(responses []backend.DataResponse, start time.Time) data.Frames {
+	var frames data.Frames
+	series := map[string]*data.Frame{}
+	for _, r := range responses {
+		for _, frame := range r.Frames {
+			key := frameSeriesKey(frame)
+			stitched, ok := series[key]
+			if !ok {
+				stitched = emptyFrameLike(frame)
+				series[key] = stitched
+				frames = append(frames, stitched)
+			} else {
+				mergeNotices(stitched, frame)
+			}
+			appendRowsFrom(stitched, frame, start)
+		}
+	}
+	// The series of the first chunk may only have rows before start.
+	return slices.DeleteFunc(frames, func(frame *data.Frame) bool {
+		return len(frame.Fields) > 0 && frame.Rows() == 0
+	})
+}
+
+// frameSeriesKey identifies the series of a frame, the frames of a series have the same fields.
+func frameSeriesKey(frame *data.Frame) string {
+	var b strings.Builder
+	b.WriteString(frame.Name)
+	if frame.Meta != nil {
+		b.WriteString("\x00" + string(frame.Meta.Type))
+	}
+	for _, f := range frame.Fields {
+		b.WriteString("\x00" + f.Name + "\x00" + f.Type().String() + "\x00" + f.Labels.String())
+	}
+	return b.String()
+}
+
+func emptyFrameLike(frame *data.Frame) *data.Frame {
+	stitched := data.NewFrame(frame.Name)
+	stitched.RefID = frame.RefID
+	if frame.Meta != nil {
+		meta := *frame.Meta
+		meta.Notices = slices.Clone(frame.Meta.Notices)
+		stitched.Meta = &meta
+	}
+	for _, f := range frame.Fields {
+		field := data.NewFieldFromFieldType(f.Type(), 0)
+		field.Name = f.Name
+		if f.Labels != nil {
+			field.Labels = f.Labels.Copy()
+		}
+		if f.Config != nil {
+			config := *f.Config
+			field.Config = &config
+		}
+		stitched.Fields = append(stitched.Fields, field)
+	}
+	return stitched
+}
+
+// mergeNotices adds the notices of src missing from dst, such as the warnings of Prometheus.
+func mergeNotices(dst, src *data.Frame) {
+	if src.Meta == nil || dst.Meta == nil {
+		return
+	}
+	for _, notice := range src.Meta.Notices {
+		if !slices.Contains(dst.Meta.Notices, notice) {
+			dst.Meta.Notices = append(dst.Meta.Notices, notice)
+		}
+	}
+}
+
+// appendRowsFrom appends the rows of src from start to dst, using the first time field of src.
+func appendRowsFrom(dst, src *data.Frame, start time.Time) {
+	timeIdx := -1
+	for i, f := range src.Fields {
+		if f.Type() == data.FieldTypeTime {
+			timeIdx = i
+			break
+		}
+	}
+	for row := 0; row < src.Rows(); row++ {
+		if timeIdx >= 0 {
+			if t, ok := src.Fields[timeIdx].ConcreteAt(row); ok && t.(time.Time).Before(start) {
+				continue
+			}
+		}
+		for i, f := range src.Fields {
+			dst.Fields[i].Append(f.CopyAt(row))
+		}
+	}
+}
+
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
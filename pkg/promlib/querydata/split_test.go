@@ -0,0 +1,194 @@
+package querydata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+func TestRangeSplittingChunks(t *testing.T) {
+	splitting := rangeSplitting{interval: 24 * time.Hour, overlap: 10 * time.Minute}
+	end := time.Date(2024, 5, 4, 12, 0, 30, 0, time.UTC)
+	query := func(start, end time.Time) *models.Query {
+		return &models.Query{Expr: "up", Step: time.Minute, Start: start, End: end, RangeQuery: true}
+	}
+
+	t.Run("splits the range in aligned chunks", func(t *testing.T) {
+		chunks := splitting.chunks(query(end.Add(-72*time.Hour), end), end)
+		require.Equal(t, []rangeChunk{
+			{start: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC), complete: true},
+			{start: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 5, 2, 23, 59, 0, 0, time.UTC), complete: true},
+			{start: time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 5, 3, 23, 59, 0, 0, time.UTC), complete: true},
+			{start: time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC), complete: false},
+		}, chunks)
+
+		// A refresh keeps the complete chunks.
+		refreshed := splitting.chunks(query(end.Add(-72*time.Hour+time.Minute), end.Add(time.Minute)), end.Add(time.Minute))
+		require.Equal(t, chunks[:3], refreshed[:3])
+	})
+
+	t.Run("doesn't cache the chunks in the overlap window", func(t *testing.T) {
+		chunks := splitting.chunks(query(end.Add(-48*time.Hour), end.Add(-12*time.Hour-30*time.Second)), end.Add(-12*time.Hour+5*time.Minute))
+		require.Len(t, chunks, 3)
+		require.True(t, chunks[0].complete)
+		require.False(t, chunks[1].complete, "the chunk ends in the overlap window")
+		require.False(t, chunks[2].complete)
+	})
+
+	t.Run("uses larger chunks for long ranges", func(t *testing.T) {
+		hourly := rangeSplitting{interval: time.Hour, overlap: 10 * time.Minute}
+		chunks := hourly.chunks(query(end.Add(-90*24*time.Hour), end), end)
+		require.LessOrEqual(t, len(chunks), maxRangeQueryChunks+1)
+		require.Equal(t, 36*time.Hour-time.Minute, chunks[1].end.Sub(chunks[1].start))
+	})
+
+	t.Run("doesn't split short ranges and @ modifiers", func(t *testing.T) {
+		require.Nil(t, splitting.chunks(query(end.Add(-6*time.Hour), end), end))
+
+		q := query(end.Add(-72*time.Hour), end)
+		q.Expr = "rate(up[5m] @ end())"
+		require.Nil(t, splitting.chunks(q, end))
+		require.Nil(t, rangeSplitting{}.chunks(query(end.Add(-72*time.Hour), end), end))
+	})
+}
+
+func TestStitchFrames(t *testing.T) {
+	series := func(job string, from, to int64, notice string) *data.Frame {
+		var times []time.Time
+		var values []float64
+		for ts := from; ts <= to; ts += 60 {
+			times = append(times, time.Unix(ts, 0).UTC())
+			values = append(values, float64(ts))
+		}
+		frame := data.NewFrame("",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, data.Labels{"job": job}, values),
+		)
+		frame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti}
+		if notice != "" {
+			frame.Meta.Notices = []data.Notice{{Severity: data.NoticeSeverityWarning, Text: notice}}
+		}
+		return frame
+	}
+
+	first := series("a", 0, 540, "warning")
+	responses := []backend.DataResponse{
+		{Frames: data.Frames{first, series("b", 0, 120, "")}},
+		{Frames: data.Frames{series("a", 600, 1140, "warning")}},
+		{Frames: data.Frames{series("a", 1200, 1260, "other warning")}},
+	}
+	frames := stitchFrames(responses, time.Unix(300, 0))
+
+	require.Len(t, frames, 1, "series b has no rows in the range")
+	require.Equal(t, 17, frames[0].Rows())
+	require.Equal(t, time.Unix(300, 0).UTC(), frames[0].Fields[0].At(0))
+	require.Equal(t, time.Unix(1260, 0).UTC(), frames[0].Fields[0].At(16))
+	require.Equal(t, "job=a", frames[0].Fields[1].Labels.String())
+	require.Len(t, frames[0].Meta.Notices, 2)
+
+	// The frames of the chunks are not modified.
+	require.Equal(t, 10, first.Rows())
+	require.Len(t, first.Meta.Notices, 1)
+}
+
+// fakePrometheus answers range queries with one series, whose values are the timestamps.
+type fakePrometheus struct {
+	mu     sync.Mutex
+	ranges [][2]time.Time
+}
+
+func (p *fakePrometheus) RoundTrip(req *http.Request) (*http.Response, error) {
+	params := req.URL.Query()
+	start, _ := strconv.ParseFloat(params.Get("start"), 64)
+	end, _ := strconv.ParseFloat(params.Get("end"), 64)
+	step, _ := strconv.ParseFloat(params.Get("step"), 64)
+
+	p.mu.Lock()
+	p.ranges = append(p.ranges, [2]time.Time{time.Unix(int64(start), 0).UTC(), time.Unix(int64(end), 0).UTC()})
+	p.mu.Unlock()
+
+	var values []string
+	for ts := start; ts <= end; ts += step {
+		v := strconv.FormatFloat(ts, 'f', -1, 64)
+		values = append(values, fmt.Sprintf(`[%s,"%s"]`, v, v))
+	}
+	body := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","job":"prometheus"},"values":[` +
+		strings.Join(values, ",") + `]}]}}`
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
+}
+
+func (p *fakePrometheus) requests() [][2]time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ranges := p.ranges
+	p.ranges = nil
+	return ranges
+}
+
+func TestSplitRangeQuery(t *testing.T) {
+	prom := &fakePrometheus{}
+	settings := backend.DataSourceInstanceSettings{
+		ID:       1,
+		URL:      "http://localhost:9090",
+		JSONData: json.RawMessage(`{"httpMethod":"GET","timeInterval":"15s","rangeQuerySplitting":true,"rangeQuerySplitInterval":"1d"}`),
+	}
+	qd, err := New(&http.Client{Transport: prom}, settings, log.New())
+	require.NoError(t, err)
+
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID:         "A",
+			MaxDataPoints: 1000,
+			TimeRange: backend.TimeRange{
+				From: time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC),
+				To:   time.Date(2024, 5, 4, 10, 30, 0, 0, time.UTC),
+			},
+			JSON: []byte(`{"refId":"A","expr":"up","range":true,"interval":"1h","legendFormat":"{{job}}"}`),
+		}},
+	}
+	run := func() *data.Frame {
+		res, err := qd.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Len(t, res.Responses["A"].Frames, 1)
+		return res.Responses["A"].Frames[0]
+	}
+
+	frame := run()
+	require.Len(t, prom.requests(), 4)
+	require.Equal(t, 73, frame.Rows())
+	for i := 0; i < frame.Rows(); i++ {
+		ts := time.Date(2024, 5, 1, 10+i, 0, 0, 0, time.UTC)
+		require.Equal(t, ts, frame.Fields[0].At(i))
+		require.Equal(t, float64(ts.Unix()), frame.Fields[1].At(i))
+	}
+	require.Equal(t, "prometheus", frame.Fields[1].Config.DisplayNameFromDS)
+
+	// The complete chunks are reused, only the last one is fetched again.
+	refreshed := run()
+	require.Equal(t, [][2]time.Time{{
+		time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 4, 10, 0, 0, 0, time.UTC),
+	}}, prom.requests())
+	require.Equal(t, frame, refreshed)
+
+	// The chunks fetched for other users are not reused.
+	req.SetHTTPHeader(backend.OAuthIdentityTokenHeaderName, "Bearer token")
+	run()
+	require.Len(t, prom.requests(), 4)
+}
@@ -0,0 +1,136 @@
+package caching
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/middleware"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func (s *OSSCachingService) registerAPIEndpoints() {
+	authorize := ac.Middleware(s.accessControl)
+	uidScope := datasources.ScopeProvider.GetResourceScopeUID(ac.Parameter(":uid"))
+	read := authorize(ac.EvalPermission(datasources.ActionRead, uidScope))
+	write := authorize(ac.EvalPermission(datasources.ActionWrite, uidScope))
+
+	s.routeRegister.Group("/api/datasources/:uid/cache", func(cacheRoute routing.RouteRegister) {
+		cacheRoute.Get("/", read, routing.Wrap(s.getConfigHandler))
+		cacheRoute.Post("/", write, routing.Wrap(s.updateConfigHandler))
+		cacheRoute.Post("/enable", write, routing.Wrap(s.enableHandler))
+		cacheRoute.Post("/disable", write, routing.Wrap(s.disableHandler))
+		cacheRoute.Post("/clean", write, routing.Wrap(s.cleanHandler))
+	}, middleware.ReqSignedIn)
+}
+
+// UpdateDataSourceCacheConfigCommand is the body of the update cache configuration request.
+type UpdateDataSourceCacheConfigCommand struct {
+	Enabled        bool  `json:"enabled"`
+	UseDefaultTTL  bool  `json:"useDefaultTTL"`
+	TTLQueriesMs   int64 `json:"ttlQueriesMs"`
+	TTLResourcesMs int64 `json:"ttlResourcesMs"`
+}
+
+type DataSourceCacheConfigResponse struct {
+	Message        string    `json:"message"`
+	DataSourceID   int64     `json:"dataSourceID"`
+	DataSourceUID  string    `json:"dataSourceUID"`
+	Enabled        bool      `json:"enabled"`
+	UseDefaultTTL  bool      `json:"useDefaultTTL"`
+	TTLQueriesMs   int64     `json:"ttlQueriesMs"`
+	TTLResourcesMs int64     `json:"ttlResourcesMs"`
+	DefaultTTLMs   int64     `json:"defaultTTLMs"`
+	Created        time.Time `json:"created"`
+	Updated        time.Time `json:"updated"`
+}
+
+func (s *OSSCachingService) getConfigHandler(c *contextmodel.ReqContext) response.Response {
+	return s.handleConfig(c, "Data source cache settings loaded", nil)
+}
+
+func (s *OSSCachingService) updateConfigHandler(c *contextmodel.ReqContext) response.Response {
+	cmd := UpdateDataSourceCacheConfigCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	if cmd.TTLQueriesMs < 0 || cmd.TTLResourcesMs < 0 {
+		return response.Error(http.StatusBadRequest, "TTLs must not be negative", nil)
+	}
+	return s.handleConfig(c, "Data source cache settings updated", func(config *DataSourceCacheConfig) {
+		config.Enabled = cmd.Enabled
+		config.UseDefaultTTL = cmd.UseDefaultTTL
+		config.TTLQueriesMs = cmd.TTLQueriesMs
+		config.TTLResourcesMs = cmd.TTLResourcesMs
+	})
+}
+
+func (s *OSSCachingService) enableHandler(c *contextmodel.ReqContext) response.Response {
+	return s.handleConfig(c, "Data source cache enabled", func(config *DataSourceCacheConfig) {
+		config.Enabled = true
+	})
+}
+
+func (s *OSSCachingService) disableHandler(c *contextmodel.ReqContext) response.Response {
+	return s.handleConfig(c, "Data source cache disabled", func(config *DataSourceCacheConfig) {
+		config.Enabled = false
+	})
+}
+
+// cleanHandler cleans the cache of a data source. The entries are not deleted, they can't be
+// found anymore and expire with their TTL.
+func (s *OSSCachingService) cleanHandler(c *contextmodel.ReqContext) response.Response {
+	return s.handleConfig(c, "Data source cache cleaned", func(config *DataSourceCacheConfig) {
+		config.Generation = time.Now().UnixNano()
+	})
+}
+
+// handleConfig returns the caching configuration of the data source of the request, after
+// applying update when not nil.
+func (s *OSSCachingService) handleConfig(c *contextmodel.ReqContext, message string, update func(*DataSourceCacheConfig)) response.Response {
+	ctx := c.Req.Context()
+	ds, err := s.dataSourceCache.GetDatasourceByUID(ctx, web.Params(c.Req)[":uid"], c.SignedInUser, c.SkipDSCache)
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			return response.Error(http.StatusNotFound, "Data source not found", nil)
+		}
+		if errors.Is(err, datasources.ErrDataSourceAccessDenied) {
+			return response.Error(http.StatusForbidden, "Access denied to data source", err)
+		}
+		return response.Error(http.StatusInternalServerError, "Failed to get data source", err)
+	}
+
+	config, err := s.configs.get(ctx, ds.OrgID, ds.UID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get data source cache settings", err)
+	}
+	if update != nil {
+		now := time.Now()
+		if config.Created.IsZero() {
+			config.Created = now
+		}
+		config.Updated = now
+		update(&config)
+		if err := s.configs.set(ctx, ds.OrgID, ds.UID, config); err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to update data source cache settings", err)
+		}
+	}
+
+	return response.JSON(http.StatusOK, DataSourceCacheConfigResponse{
+		Message:        message,
+		DataSourceID:   ds.ID,
+		DataSourceUID:  ds.UID,
+		Enabled:        config.Enabled,
+		UseDefaultTTL:  config.UseDefaultTTL,
+		TTLQueriesMs:   config.TTLQueriesMs,
+		TTLResourcesMs: config.TTLResourcesMs,
+		DefaultTTLMs:   s.cfg.QueryCaching.TTL.Milliseconds(),
+		Created:        config.Created,
+		Updated:        config.Updated,
+	})
+}
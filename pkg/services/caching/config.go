@@ -0,0 +1,101 @@
+package caching
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+)
+
+const configNamespace = "datasource-caching"
+
+// configRefreshInterval is how long the configuration of a data source is kept in memory
+// before it is read again, changes made by other instances are applied after at most
+// this interval.
+const configRefreshInterval = 30 * time.Second
+
+// DataSourceCacheConfig is the caching configuration of a data source.
+type DataSourceCacheConfig struct {
+	Enabled bool `json:"enabled"`
+	// UseDefaultTTL uses the [caching] ttl setting for both queries and resources.
+	UseDefaultTTL  bool  `json:"useDefaultTTL"`
+	TTLQueriesMs   int64 `json:"ttlQueriesMs"`
+	TTLResourcesMs int64 `json:"ttlResourcesMs"`
+	// Generation is part of the cache keys of the data source, it's changed to clean
+	// the cache: the previous entries can't be found anymore and expire.
+	Generation int64     `json:"generation"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+type cachedConfig struct {
+	config   DataSourceCacheConfig
+	loadedAt time.Time
+}
+
+// configStore keeps the caching configuration of the data sources in the kvstore.
+type configStore struct {
+	kv  kvstore.KVStore
+	now func() time.Time
+
+	mu      sync.Mutex
+	configs map[string]cachedConfig
+}
+
+func newConfigStore(kv kvstore.KVStore) *configStore {
+	return &configStore{
+		kv:      kv,
+		now:     time.Now,
+		configs: map[string]cachedConfig{},
+	}
+}
+
+func configKey(orgID int64, dataSourceUID string) string {
+	return fmt.Sprintf("%d/%s", orgID, dataSourceUID)
+}
+
+// get returns the configuration of a data source, caching is disabled for data sources
+// that were never configured.
+func (s *configStore) get(ctx context.Context, orgID int64, dataSourceUID string) (DataSourceCacheConfig, error) {
+	key := configKey(orgID, dataSourceUID)
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.configs[key]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.loadedAt) < configRefreshInterval {
+		return cached.config, nil
+	}
+
+	config := DataSourceCacheConfig{UseDefaultTTL: true}
+	value, found, err := s.kv.Get(ctx, orgID, configNamespace, dataSourceUID)
+	if err != nil {
+		return DataSourceCacheConfig{}, err
+	}
+	if found {
+		if err := json.Unmarshal([]byte(value), &config); err != nil {
+			return DataSourceCacheConfig{}, fmt.Errorf("invalid caching configuration of data source %s: %w", dataSourceUID, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.configs[key] = cachedConfig{config: config, loadedAt: now}
+	s.mu.Unlock()
+	return config, nil
+}
+
+func (s *configStore) set(ctx context.Context, orgID int64, dataSourceUID string, config DataSourceCacheConfig) error {
+	value, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := s.kv.Set(ctx, orgID, configNamespace, dataSourceUID, string(value)); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.configs[configKey(orgID, dataSourceUID)] = cachedConfig{config: config, loadedAt: s.now()}
+	s.mu.Unlock()
+	return nil
+}
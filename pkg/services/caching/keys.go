@@ -0,0 +1,122 @@
+package caching
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// volatileQueryProperties are properties of the query models that don't change the results.
+var volatileQueryProperties = []string{"queryCachingTTL", "requestId"}
+
+// identityHeaders are the headers forwarded to data sources that identify the user, the
+// responses of data sources forwarding them can't be shared between users.
+var identityHeaders = []string{
+	backend.OAuthIdentityTokenHeaderName,
+	backend.OAuthIdentityIDTokenHeaderName,
+	backend.CookiesHeaderName,
+}
+
+type headerGetter interface {
+	GetHTTPHeader(key string) string
+}
+
+type keyQuery struct {
+	RefID         string          `json:"refId"`
+	QueryType     string          `json:"queryType"`
+	MaxDataPoints int64           `json:"maxDataPoints"`
+	IntervalMs    int64           `json:"intervalMs"`
+	From          int64           `json:"from"`
+	To            int64           `json:"to"`
+	Model         json.RawMessage `json:"model"`
+}
+
+type keyScope struct {
+	OrgID             int64     `json:"orgId"`
+	DataSourceUID     string    `json:"dataSourceUid"`
+	DataSourceUpdated time.Time `json:"dataSourceUpdated"`
+	Generation        int64     `json:"generation"`
+	Identity          []string  `json:"identity,omitempty"`
+}
+
+// queryCacheKey returns the cache key of a query request. The time ranges are aligned to
+// the interval of the queries, or to the TTL for queries without interval, so that
+// requests made at slightly different times for the same relative range share the key.
+func queryCacheKey(req *backend.QueryDataRequest, scope keyScope, ttl time.Duration) (string, error) {
+	queries := make([]keyQuery, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		model, err := normalizeQueryModel(q.JSON)
+		if err != nil {
+			return "", err
+		}
+		alignment := q.Interval
+		if alignment <= 0 {
+			alignment = ttl
+		}
+		queries = append(queries, keyQuery{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			MaxDataPoints: q.MaxDataPoints,
+			IntervalMs:    q.Interval.Milliseconds(),
+			From:          q.TimeRange.From.Truncate(alignment).UnixMilli(),
+			To:            q.TimeRange.To.Truncate(alignment).UnixMilli(),
+			Model:         model,
+		})
+	}
+	return hashKey("query", struct {
+		Scope   keyScope   `json:"scope"`
+		Queries []keyQuery `json:"queries"`
+	}{scope, queries})
+}
+
+// resourceCacheKey returns the cache key of a resource request.
+func resourceCacheKey(req *backend.CallResourceRequest, scope keyScope) (string, error) {
+	return hashKey("resource", struct {
+		Scope  keyScope `json:"scope"`
+		Method string   `json:"method"`
+		URL    string   `json:"url"`
+		Body   []byte   `json:"body"`
+	}{scope, req.Method, req.URL, req.Body})
+}
+
+func hashKey(kind string, v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return "caching:" + kind + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeQueryModel removes the volatile properties of a query model, the properties
+// are sorted by encoding/json.
+func normalizeQueryModel(model json.RawMessage) (json.RawMessage, error) {
+	if len(model) == 0 {
+		return model, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(model))
+	decoder.UseNumber()
+	var properties map[string]any
+	if err := decoder.Decode(&properties); err != nil {
+		return nil, err
+	}
+	for _, name := range volatileQueryProperties {
+		delete(properties, name)
+	}
+	return json.Marshal(properties)
+}
+
+// requestIdentity returns the identity headers forwarded with a request.
+func requestIdentity(req headerGetter) []string {
+	var identity []string
+	for _, name := range identityHeaders {
+		if value := req.GetHTTPHeader(name); value != "" {
+			identity = append(identity, name+"="+value)
+		}
+	}
+	return identity
+}
@@ -0,0 +1,33 @@
+package caching
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+type cachingMetrics struct {
+	queryRequests    *prometheus.CounterVec
+	resourceRequests *prometheus.CounterVec
+}
+
+func newCachingMetrics(reg prometheus.Registerer) *cachingMetrics {
+	m := &cachingMetrics{
+		queryRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "caching",
+			Name:      "query_requests_total",
+			Help:      "Total number of query requests by cache status",
+		}, []string{"datasource_type", "cache"}),
+		resourceRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.ExporterName,
+			Subsystem: "caching",
+			Name:      "resource_requests_total",
+			Help:      "Total number of resource requests by cache status",
+		}, []string{"plugin_id", "cache"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.queryRequests, m.resourceRequests)
+	}
+	return m
+}
@@ -0,0 +1,283 @@
+package caching
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func ProvideCachingService(cfg *setting.Cfg, cache remotecache.CacheStorage, kv kvstore.KVStore,
+	dataSourceCache datasources.CacheService, routeRegister routing.RouteRegister,
+	accessControl accesscontrol.AccessControl, reg prometheus.Registerer) *OSSCachingService {
+	s := &OSSCachingService{
+		cfg:             cfg,
+		cache:           cache,
+		configs:         newConfigStore(kv),
+		dataSourceCache: dataSourceCache,
+		routeRegister:   routeRegister,
+		accessControl:   accessControl,
+		metrics:         newCachingMetrics(reg),
+		log:             log.New("caching"),
+	}
+	if cfg.QueryCaching.Enabled {
+		s.registerAPIEndpoints()
+	}
+	return s
+}
+
+// OSSCachingService caches the query and resource responses of the data sources for which
+// caching is enabled in the remote cache.
+type OSSCachingService struct {
+	cfg             *setting.Cfg
+	cache           remotecache.CacheStorage
+	configs         *configStore
+	dataSourceCache datasources.CacheService
+	routeRegister   routing.RouteRegister
+	accessControl   accesscontrol.AccessControl
+	metrics         *cachingMetrics
+	log             log.Logger
+}
+
+// enabled returns whether query caching is enabled, it is not for a service that wasn't provided.
+func (s *OSSCachingService) enabled() bool {
+	return s.cfg != nil && s.cfg.QueryCaching.Enabled
+}
+
+// cacheMode is how a request uses the cache, depending on its headers.
+type cacheMode int
+
+const (
+	cacheModeDefault cacheMode = iota
+	// cacheModeRefresh doesn't read the cache but stores the response, for Cache-Control: no-cache.
+	cacheModeRefresh
+	// cacheModeSkip doesn't use the cache, for Cache-Control: no-store and X-Cache-Skip.
+	cacheModeSkip
+)
+
+func requestCacheMode(reqCtx *contextmodel.ReqContext) cacheMode {
+	if reqCtx.SkipQueryCache {
+		return cacheModeSkip
+	}
+	mode := cacheModeDefault
+	if reqCtx.Req != nil {
+		for _, directive := range strings.Split(reqCtx.Req.Header.Get("Cache-Control"), ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-store":
+				return cacheModeSkip
+			case "no-cache":
+				mode = cacheModeRefresh
+			}
+		}
+	}
+	return mode
+}
+
+func (s *OSSCachingService) HandleQueryRequest(ctx context.Context, req *backend.QueryDataRequest) (bool, CachedQueryDataResponse) {
+	reqCtx := contexthandler.FromContext(ctx)
+	ds := req.PluginContext.DataSourceInstanceSettings
+	if !s.enabled() || reqCtx == nil || ds == nil {
+		return false, CachedQueryDataResponse{}
+	}
+	setStatus := func(status string) {
+		reqCtx.Resp.Header().Set(XCacheHeader, status)
+		s.metrics.queryRequests.WithLabelValues(ds.Type, status).Inc()
+	}
+
+	config, err := s.configs.get(ctx, req.PluginContext.OrgID, ds.UID)
+	if err != nil {
+		s.log.FromContext(ctx).Error("Failed to get the caching configuration", "datasource", ds.UID, "error", err)
+		setStatus(StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+	if !config.Enabled {
+		return false, CachedQueryDataResponse{}
+	}
+	mode := requestCacheMode(reqCtx)
+	if mode == cacheModeSkip {
+		setStatus(StatusBypass)
+		return false, CachedQueryDataResponse{}
+	}
+
+	ttl := s.ttl(config, config.TTLQueriesMs)
+	key, err := queryCacheKey(req, s.keyScope(reqCtx, req, req.PluginContext, config), ttl)
+	if err != nil {
+		s.log.FromContext(ctx).Error("Failed to compute the cache key of a query", "datasource", ds.UID, "error", err)
+		setStatus(StatusError)
+		return false, CachedQueryDataResponse{}
+	}
+
+	if mode == cacheModeDefault {
+		value, err := s.cache.Get(ctx, key)
+		switch {
+		case err == nil:
+			resp := &backend.QueryDataResponse{}
+			if err := json.Unmarshal(value, resp); err == nil {
+				setStatus(StatusHit)
+				return true, CachedQueryDataResponse{Response: resp}
+			}
+			s.log.FromContext(ctx).Warn("Failed to decode a cached query response", "datasource", ds.UID, "error", err)
+		case !errors.Is(err, remotecache.ErrCacheItemNotFound):
+			s.log.FromContext(ctx).Error("Failed to read the query cache", "datasource", ds.UID, "error", err)
+			setStatus(StatusError)
+			return false, CachedQueryDataResponse{}
+		}
+		setStatus(StatusMiss)
+	} else {
+		setStatus(StatusBypass)
+	}
+
+	return false, CachedQueryDataResponse{
+		UpdateCacheFn: func(ctx context.Context, resp *backend.QueryDataResponse) {
+			if resp == nil || !cacheableQueryResponse(resp) {
+				return
+			}
+			s.store(ctx, key, resp, ttl)
+		},
+	}
+}
+
+func (s *OSSCachingService) HandleResourceRequest(ctx context.Context, req *backend.CallResourceRequest) (bool, CachedResourceDataResponse) {
+	reqCtx := contexthandler.FromContext(ctx)
+	ds := req.PluginContext.DataSourceInstanceSettings
+	if !s.enabled() || reqCtx == nil || ds == nil || req.Method != http.MethodGet {
+		return false, CachedResourceDataResponse{}
+	}
+	setStatus := func(status string) {
+		reqCtx.Resp.Header().Set(XCacheHeader, status)
+		s.metrics.resourceRequests.WithLabelValues(req.PluginContext.PluginID, status).Inc()
+	}
+
+	config, err := s.configs.get(ctx, req.PluginContext.OrgID, ds.UID)
+	if err != nil {
+		s.log.FromContext(ctx).Error("Failed to get the caching configuration", "datasource", ds.UID, "error", err)
+		setStatus(StatusError)
+		return false, CachedResourceDataResponse{}
+	}
+	if !config.Enabled {
+		return false, CachedResourceDataResponse{}
+	}
+	mode := requestCacheMode(reqCtx)
+	if mode == cacheModeSkip {
+		setStatus(StatusBypass)
+		return false, CachedResourceDataResponse{}
+	}
+
+	key, err := resourceCacheKey(req, s.keyScope(reqCtx, req, req.PluginContext, config))
+	if err != nil {
+		s.log.FromContext(ctx).Error("Failed to compute the cache key of a resource request", "datasource", ds.UID, "error", err)
+		setStatus(StatusError)
+		return false, CachedResourceDataResponse{}
+	}
+
+	if mode == cacheModeDefault {
+		value, err := s.cache.Get(ctx, key)
+		switch {
+		case err == nil:
+			resp := &backend.CallResourceResponse{}
+			if err := json.Unmarshal(value, resp); err == nil {
+				setStatus(StatusHit)
+				return true, CachedResourceDataResponse{Response: resp}
+			}
+			s.log.FromContext(ctx).Warn("Failed to decode a cached resource response", "datasource", ds.UID, "error", err)
+		case !errors.Is(err, remotecache.ErrCacheItemNotFound):
+			s.log.FromContext(ctx).Error("Failed to read the resource cache", "datasource", ds.UID, "error", err)
+			setStatus(StatusError)
+			return false, CachedResourceDataResponse{}
+		}
+		setStatus(StatusMiss)
+	} else {
+		setStatus(StatusBypass)
+	}
+
+	ttl := s.ttl(config, config.TTLResourcesMs)
+	responses := 0
+	return false, CachedResourceDataResponse{
+		UpdateCacheFn: func(ctx context.Context, resp *backend.CallResourceResponse) {
+			responses++
+			// Streamed responses can't be served from the cache, the first response
+			// stored is removed when the next one is sent.
+			if responses > 1 {
+				if responses == 2 {
+					if err := s.cache.Delete(ctx, key); err != nil {
+						s.log.FromContext(ctx).Warn("Failed to delete a cached resource response", "error", err)
+					}
+				}
+				return
+			}
+			if resp == nil || resp.Status < http.StatusOK || resp.Status >= http.StatusMultipleChoices {
+				return
+			}
+			s.store(ctx, key, resp, ttl)
+		},
+	}
+}
+
+// ttl returns the TTL of the entries of a data source, capped by [caching] max_ttl.
+func (s *OSSCachingService) ttl(config DataSourceCacheConfig, ttlMs int64) time.Duration {
+	ttl := s.cfg.QueryCaching.TTL
+	if !config.UseDefaultTTL && ttlMs > 0 {
+		ttl = time.Duration(ttlMs) * time.Millisecond
+	}
+	if maxTTL := s.cfg.QueryCaching.MaxTTL; maxTTL > 0 && ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+func (s *OSSCachingService) keyScope(reqCtx *contextmodel.ReqContext, req headerGetter, pluginCtx backend.PluginContext, config DataSourceCacheConfig) keyScope {
+	identity := requestIdentity(req)
+	// The user header is added to the requests after the cache is checked.
+	if s.cfg.SendUserHeader && reqCtx.SignedInUser != nil {
+		identity = append(identity, "user="+reqCtx.SignedInUser.GetLogin())
+	}
+	return keyScope{
+		OrgID:             pluginCtx.OrgID,
+		DataSourceUID:     pluginCtx.DataSourceInstanceSettings.UID,
+		DataSourceUpdated: pluginCtx.DataSourceInstanceSettings.Updated,
+		Generation:        config.Generation,
+		Identity:          identity,
+	}
+}
+
+func (s *OSSCachingService) store(ctx context.Context, key string, v any, ttl time.Duration) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		s.log.FromContext(ctx).Warn("Failed to encode a response for the cache", "error", err)
+		return
+	}
+	if maxMB := s.cfg.QueryCaching.MaxValueMB; maxMB > 0 && len(value) > maxMB*1024*1024 {
+		s.log.FromContext(ctx).Debug("Response is too large to be cached", "size", len(value))
+		return
+	}
+	if err := s.cache.Set(ctx, key, value, ttl); err != nil {
+		s.log.FromContext(ctx).Error("Failed to write to the cache", "error", err)
+	}
+}
+
+// cacheableQueryResponse returns false when a query of the response failed.
+func cacheableQueryResponse(resp *backend.QueryDataResponse) bool {
+	for _, r := range resp.Responses {
+		if r.Error != nil || r.Status >= backend.StatusBadRequest {
+			return false
+		}
+	}
+	return true
+}
+
+var _ CachingService = &OSSCachingService{}
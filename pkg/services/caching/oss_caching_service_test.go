@@ -0,0 +1,245 @@
+package caching
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const testDataSourceUID = "ds-uid"
+
+func newTestCachingService(t *testing.T) (*OSSCachingService, remotecache.FakeCacheStorage) {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.QueryCaching = setting.QueryCachingSettings{Enabled: true, TTL: time.Minute, MaxValueMB: 1}
+	cache := remotecache.NewFakeCacheStorage()
+	s := ProvideCachingService(cfg, cache, kvstore.NewFakeKVStore(), nil, routing.NewRouteRegister(), nil, nil)
+	return s, cache
+}
+
+func enableCaching(t *testing.T, s *OSSCachingService, config DataSourceCacheConfig) {
+	t.Helper()
+	config.Enabled = true
+	require.NoError(t, s.configs.set(context.Background(), 1, testDataSourceUID, config))
+}
+
+func testRequestContext(headers map[string]string) (context.Context, *contextmodel.ReqContext) {
+	req := httptest.NewRequest(http.MethodPost, "/api/ds/query", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	reqCtx := &contextmodel.ReqContext{
+		Context: &web.Context{
+			Req:  req,
+			Resp: web.NewResponseWriter(req.Method, httptest.NewRecorder()),
+		},
+		SignedInUser: &user.SignedInUser{OrgID: 1, Login: "viewer"},
+	}
+	return ctxkey.Set(context.Background(), reqCtx), reqCtx
+}
+
+func testQueryRequest(to time.Time) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			OrgID:    1,
+			PluginID: "elasticsearch",
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+				UID:  testDataSourceUID,
+				Type: "elasticsearch",
+			},
+		},
+		Queries: []backend.DataQuery{{
+			RefID:     "A",
+			Interval:  15 * time.Second,
+			TimeRange: backend.TimeRange{From: to.Add(-6 * time.Hour), To: to},
+			JSON:      []byte(`{"refId":"A","query":"status:500","queryCachingTTL":60000}`),
+		}},
+	}
+}
+
+func testQueryResponse() *backend.QueryDataResponse {
+	return &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("",
+			data.NewField("time", nil, []time.Time{time.Unix(1000, 0).UTC()}),
+			data.NewField("value", nil, []float64{42}),
+		)}},
+	}}
+}
+
+func TestHandleQueryRequest(t *testing.T) {
+	to := time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)
+
+	t.Run("does nothing for data sources without caching", func(t *testing.T) {
+		s, _ := newTestCachingService(t)
+		ctx, reqCtx := testRequestContext(nil)
+		hit, cr := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Empty(t, reqCtx.Resp.Header().Get(XCacheHeader))
+	})
+
+	t.Run("does nothing for a service that wasn't provided", func(t *testing.T) {
+		s := &OSSCachingService{}
+		ctx, _ := testRequestContext(nil)
+		hit, cr := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+	})
+
+	t.Run("caches responses with time ranges aligned to the interval", func(t *testing.T) {
+		s, cache := newTestCachingService(t)
+		enableCaching(t, s, DataSourceCacheConfig{UseDefaultTTL: true})
+
+		ctx, reqCtx := testRequestContext(nil)
+		hit, cr := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, reqCtx.Resp.Header().Get(XCacheHeader))
+		require.NotNil(t, cr.UpdateCacheFn)
+		cr.UpdateCacheFn(ctx, testQueryResponse())
+		require.Len(t, cache.Storage, 1)
+
+		ctx, reqCtx = testRequestContext(nil)
+		hit, cr = s.HandleQueryRequest(ctx, testQueryRequest(to.Add(5*time.Second)))
+		require.True(t, hit)
+		require.Equal(t, StatusHit, reqCtx.Resp.Header().Get(XCacheHeader))
+		require.Equal(t, 42.0, cr.Response.Responses["A"].Frames[0].Fields[1].At(0))
+
+		// The next interval has its own entry.
+		ctx, _ = testRequestContext(nil)
+		hit, _ = s.HandleQueryRequest(ctx, testQueryRequest(to.Add(15*time.Second)))
+		require.False(t, hit)
+	})
+
+	t.Run("respects the cache headers of the request", func(t *testing.T) {
+		s, cache := newTestCachingService(t)
+		enableCaching(t, s, DataSourceCacheConfig{UseDefaultTTL: true})
+
+		ctx, reqCtx := testRequestContext(map[string]string{"Cache-Control": "no-store"})
+		hit, cr := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Equal(t, StatusBypass, reqCtx.Resp.Header().Get(XCacheHeader))
+
+		ctx, reqCtx = testRequestContext(nil)
+		reqCtx.SkipQueryCache = true
+		_, cr = s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.Nil(t, cr.UpdateCacheFn)
+
+		// no-cache refreshes the cached response.
+		ctx, reqCtx = testRequestContext(map[string]string{"Cache-Control": "no-cache"})
+		hit, cr = s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.False(t, hit)
+		require.Equal(t, StatusBypass, reqCtx.Resp.Header().Get(XCacheHeader))
+		cr.UpdateCacheFn(ctx, testQueryResponse())
+		require.Len(t, cache.Storage, 1)
+	})
+
+	t.Run("does not cache failed queries", func(t *testing.T) {
+		s, cache := newTestCachingService(t)
+		enableCaching(t, s, DataSourceCacheConfig{UseDefaultTTL: true})
+
+		ctx, _ := testRequestContext(nil)
+		_, cr := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		cr.UpdateCacheFn(ctx, &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": {Error: errors.New("timeout"), Status: backend.StatusTimeout},
+		}})
+		require.Empty(t, cache.Storage)
+	})
+
+	t.Run("does not share responses of data sources forwarding the identity", func(t *testing.T) {
+		s, _ := newTestCachingService(t)
+		enableCaching(t, s, DataSourceCacheConfig{UseDefaultTTL: true})
+
+		req := testQueryRequest(to)
+		req.Headers = map[string]string{backend.OAuthIdentityTokenHeaderName: "Bearer user1"}
+		ctx, _ := testRequestContext(nil)
+		_, cr := s.HandleQueryRequest(ctx, req)
+		cr.UpdateCacheFn(ctx, testQueryResponse())
+
+		req = testQueryRequest(to)
+		req.Headers = map[string]string{backend.OAuthIdentityTokenHeaderName: "Bearer user2"}
+		ctx, _ = testRequestContext(nil)
+		hit, _ := s.HandleQueryRequest(ctx, req)
+		require.False(t, hit)
+	})
+
+	t.Run("cleaning the cache of a data source changes its keys", func(t *testing.T) {
+		s, _ := newTestCachingService(t)
+		enableCaching(t, s, DataSourceCacheConfig{UseDefaultTTL: true})
+
+		ctx, _ := testRequestContext(nil)
+		_, cr := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		cr.UpdateCacheFn(ctx, testQueryResponse())
+
+		enableCaching(t, s, DataSourceCacheConfig{UseDefaultTTL: true, Generation: 1})
+		ctx, _ = testRequestContext(nil)
+		hit, _ := s.HandleQueryRequest(ctx, testQueryRequest(to))
+		require.False(t, hit)
+	})
+}
+
+func TestHandleResourceRequest(t *testing.T) {
+	newRequest := func(method string) *backend.CallResourceRequest {
+		return &backend.CallResourceRequest{
+			PluginContext: testQueryRequest(time.Now()).PluginContext,
+			Method:        method,
+			Path:          "_mapping",
+			URL:           "_mapping?index=logs",
+		}
+	}
+	response := &backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(`{"logs":{}}`)}
+
+	s, cache := newTestCachingService(t)
+	enableCaching(t, s, DataSourceCacheConfig{TTLResourcesMs: 300000})
+
+	ctx, _ := testRequestContext(nil)
+	hit, cr := s.HandleResourceRequest(ctx, newRequest(http.MethodPost))
+	require.False(t, hit)
+	require.Nil(t, cr.UpdateCacheFn, "only GET requests are cached")
+
+	ctx, reqCtx := testRequestContext(nil)
+	hit, cr = s.HandleResourceRequest(ctx, newRequest(http.MethodGet))
+	require.False(t, hit)
+	require.Equal(t, StatusMiss, reqCtx.Resp.Header().Get(XCacheHeader))
+	cr.UpdateCacheFn(ctx, response)
+
+	ctx, _ = testRequestContext(nil)
+	hit, cr = s.HandleResourceRequest(ctx, newRequest(http.MethodGet))
+	require.True(t, hit)
+	require.Equal(t, response.Body, cr.Response.Body)
+
+	// Streamed responses are not cached.
+	cache.Storage = map[string][]byte{}
+	s.cache = cache
+	ctx, _ = testRequestContext(nil)
+	_, cr = s.HandleResourceRequest(ctx, newRequest(http.MethodGet))
+	cr.UpdateCacheFn(ctx, response)
+	cr.UpdateCacheFn(ctx, response)
+	require.Empty(t, cache.Storage)
+}
+
+func TestTTL(t *testing.T) {
+	s, _ := newTestCachingService(t)
+	require.Equal(t, time.Minute, s.ttl(DataSourceCacheConfig{UseDefaultTTL: true, TTLQueriesMs: 5000}, 5000))
+	require.Equal(t, 5*time.Second, s.ttl(DataSourceCacheConfig{TTLQueriesMs: 5000}, 5000))
+	require.Equal(t, time.Minute, s.ttl(DataSourceCacheConfig{}, 0))
+
+	s.cfg.QueryCaching.MaxTTL = 30 * time.Second
+	require.Equal(t, 30*time.Second, s.ttl(DataSourceCacheConfig{}, 0))
+}
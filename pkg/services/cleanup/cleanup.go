@@ -21,7 +21,11 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboardsnapshots"
 	dashver "github.com/grafana/grafana/pkg/services/dashboardversion"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/notificationhistory"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
+	"github.com/grafana/grafana/pkg/services/recordedseries"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
 	"github.com/grafana/grafana/pkg/setting"
@@ -101,6 +105,10 @@ func (srv *CleanUpService) clean(ctx context.Context) {
 		{"delete expired snapshots", srv.deleteExpiredSnapshots},
 		{"delete expired dashboard versions", srv.deleteExpiredDashboardVersions},
 		{"delete expired images", srv.deleteExpiredImages},
+		{"delete expired recorded samples", srv.deleteExpiredRecordedSamples},
+		{"delete expired alert state history", srv.deleteExpiredAlertStateHistory},
+		{"delete expired notification history", srv.deleteExpiredNotificationHistory},
+		{"delete expired notification dead letters", srv.deleteExpiredDeadLetters},
 		{"cleanup old annotations", srv.cleanUpOldAnnotations},
 		{"expire old user invites", srv.expireOldUserInvites},
 		{"delete stale short URLs", srv.deleteStaleShortURLs},
@@ -228,6 +236,62 @@ func (srv *CleanUpService) deleteExpiredImages(ctx context.Context) {
 	}
 }
 
+func (srv *CleanUpService) deleteExpiredRecordedSamples(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	settings := srv.Cfg.UnifiedAlerting.RecordingRules
+	if !srv.Cfg.UnifiedAlerting.IsEnabled() || !settings.Enabled || settings.Backend != setting.RecordingRuleBackendLocal || settings.LocalRetention <= 0 {
+		return
+	}
+	olderThan := time.Now().Add(-settings.LocalRetention)
+	if rowsAffected, err := recordedseries.NewStore(srv.store).DeleteBefore(ctx, olderThan); err != nil {
+		logger.Error("Failed to delete expired recorded samples", "error", err.Error())
+	} else {
+		logger.Debug("Deleted expired recorded samples", "rows affected", rowsAffected)
+	}
+}
+
+func (srv *CleanUpService) deleteExpiredAlertStateHistory(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	settings := srv.Cfg.UnifiedAlerting.StateHistory
+	if !srv.Cfg.UnifiedAlerting.IsEnabled() || !historian.UsesBackend(settings, historian.BackendTypeSQL) || settings.SQLRetention <= 0 {
+		return
+	}
+	olderThan := time.Now().Add(-settings.SQLRetention)
+	if rowsAffected, err := historian.NewSQLStore(srv.store).DeleteBefore(ctx, olderThan); err != nil {
+		logger.Error("Failed to delete expired alert state history", "error", err.Error())
+	} else {
+		logger.Debug("Deleted expired alert state history", "rows affected", rowsAffected)
+	}
+}
+
+func (srv *CleanUpService) deleteExpiredNotificationHistory(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	settings := srv.Cfg.UnifiedAlerting.NotificationHistory
+	if !srv.Cfg.UnifiedAlerting.IsEnabled() || !settings.Enabled || settings.Retention <= 0 {
+		return
+	}
+	olderThan := time.Now().Add(-settings.Retention)
+	if rowsAffected, err := notificationhistory.NewStore(srv.store).DeleteBefore(ctx, olderThan); err != nil {
+		logger.Error("Failed to delete expired notification history", "error", err.Error())
+	} else {
+		logger.Debug("Deleted expired notification history", "rows affected", rowsAffected)
+	}
+}
+
+func (srv *CleanUpService) deleteExpiredDeadLetters(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	settings := srv.Cfg.UnifiedAlerting.ResendQueue
+	if !srv.Cfg.UnifiedAlerting.IsEnabled() || !settings.Enabled || settings.DeadLetterRetention <= 0 {
+		return
+	}
+	olderThan := time.Now().Add(-settings.DeadLetterRetention)
+	if rowsAffected, err := resendqueue.NewStore(srv.store).DeleteDeadLettersBefore(ctx, olderThan); err != nil {
+		logger.Error("Failed to delete expired notification dead letters", "error", err.Error())
+	} else {
+		logger.Debug("Deleted expired notification dead letters", "rows affected", rowsAffected)
+	}
+}
+
 func (srv *CleanUpService) expireOldUserInvites(ctx context.Context) {
 	logger := srv.log.FromContext(ctx)
 	maxInviteLifetime := srv.Cfg.UserInviteMaxLifetime
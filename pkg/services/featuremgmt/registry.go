@@ -1473,6 +1473,18 @@ var (
 			Stage:       FeatureStagePublicPreview,
 			Owner:       identityAccessTeam,
 		},
+		{
+			Name:        "lokiBackendQuerySplitting",
+			Description: "Split long Loki range queries by time and by stream shard in the backend, and run the chunks in parallel",
+			Stage:       FeatureStageExperimental,
+			Owner:       grafanaObservabilityLogsSquad,
+		},
+		{
+			Name:        "graphiteBackendMode",
+			Description: "Route Graphite metric find, tag, function and event requests through the backend, and restrict the data proxy to render requests",
+			Stage:       FeatureStageExperimental,
+			Owner:       grafanaPartnerPluginsSquad,
+		},
 	}
 )
 
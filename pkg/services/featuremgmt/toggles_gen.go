@@ -790,4 +790,12 @@ const (
 	// FlagUseSessionStorageForRedirection
 	// Use session storage for handling the redirection after login
 	FlagUseSessionStorageForRedirection = "useSessionStorageForRedirection"
+
+	// FlagLokiBackendQuerySplitting
+	// Split long Loki range queries by time and by stream shard in the backend, and run the chunks in parallel
+	FlagLokiBackendQuerySplitting = "lokiBackendQuerySplitting"
+
+	// FlagGraphiteBackendMode
+	// Route Graphite metric find, tag, function and event requests through the backend, and restrict the data proxy to render requests
+	FlagGraphiteBackendMode = "graphiteBackendMode"
 )
@@ -0,0 +1,56 @@
+package live
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/alertmanager/api/v2/models"
+
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+)
+
+// alertmanagerGetter returns the Grafana Alertmanager of an organization.
+type alertmanagerGetter interface {
+	AlertmanagerFor(orgID int64) (notifier.Alertmanager, error)
+}
+
+// ProvideAlertSender returns the sender of the alerts of the Live pipeline. The sender fails while
+// unified alerting is disabled.
+func ProvideAlertSender(alertNG *ngalert.AlertNG) pipeline.AlertSender {
+	if alertNG == nil || alertNG.IsDisabled() || alertNG.MultiOrgAlertmanager == nil {
+		return &alertmanagerSender{}
+	}
+	return &alertmanagerSender{alertmanagers: alertNG.MultiOrgAlertmanager}
+}
+
+// alertmanagerSender sends the alerts of the Live pipeline to the Grafana Alertmanager
+// of an organization.
+type alertmanagerSender struct {
+	alertmanagers alertmanagerGetter
+}
+
+func (s *alertmanagerSender) SendAlerts(ctx context.Context, orgID int64, alerts []pipeline.Alert) error {
+	if s.alertmanagers == nil {
+		return errors.New("unified alerting is disabled")
+	}
+	am, err := s.alertmanagers.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	postable := apimodels.PostableAlerts{PostableAlerts: make([]models.PostableAlert, 0, len(alerts))}
+	for _, alert := range alerts {
+		postable.PostableAlerts = append(postable.PostableAlerts, models.PostableAlert{
+			Annotations: models.LabelSet(alert.Annotations),
+			StartsAt:    strfmt.DateTime(alert.StartsAt),
+			EndsAt:      strfmt.DateTime(alert.EndsAt),
+			Alert: models.Alert{
+				Labels: models.LabelSet(alert.Labels),
+			},
+		})
+	}
+	return am.PutAlerts(ctx, postable)
+}
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/centrifugal/centrifuge"
+)
+
+// Tags of a publication that carry its position in the history to the Grafana servers, so that subscribers of
+// every server see the same offsets.
+const (
+	offsetTag = "gf_history_offset"
+	epochTag  = "gf_history_epoch"
+)
+
+// Broker wraps the broker of the Centrifuge node and keeps the history of the channels with history in the storage.
+// The other channels are delegated to the wrapped broker as is.
+type Broker struct {
+	centrifuge.Broker
+	storage  Storage
+	channels *Channels
+}
+
+func NewBroker(broker centrifuge.Broker, storage Storage, channels *Channels) *Broker {
+	return &Broker{Broker: broker, storage: storage, channels: channels}
+}
+
+func (b *Broker) RegisterBrokerEventHandler(h centrifuge.BrokerEventHandler) error {
+	return b.Broker.RegisterBrokerEventHandler(&eventHandler{BrokerEventHandler: h})
+}
+
+// Publish saves the publication in the history, then publishes it with its position using the wrapped broker.
+func (b *Broker) Publish(ch string, data []byte, opts centrifuge.PublishOptions) (centrifuge.StreamPosition, bool, error) {
+	if _, ok := b.channels.Get(ch); !ok {
+		return b.Broker.Publish(ch, data, opts)
+	}
+	sp, err := b.storage.Append(context.Background(), ch, data)
+	if err != nil {
+		return centrifuge.StreamPosition{}, false, err
+	}
+	tags := make(map[string]string, len(opts.Tags)+2)
+	for k, v := range opts.Tags {
+		tags[k] = v
+	}
+	tags[offsetTag] = strconv.FormatUint(sp.Offset, 10)
+	tags[epochTag] = sp.Epoch
+	_, suppressed, err := b.Broker.Publish(ch, data, centrifuge.PublishOptions{
+		ClientInfo: opts.ClientInfo,
+		Tags:       tags,
+	})
+	return sp, suppressed, err
+}
+
+func (b *Broker) History(ch string, opts centrifuge.HistoryOptions) ([]*centrifuge.Publication, centrifuge.StreamPosition, error) {
+	if _, ok := b.channels.Get(ch); !ok {
+		return b.Broker.History(ch, opts)
+	}
+	pubs, sp, err := b.storage.History(context.Background(), ch, Filter{
+		Since:   opts.Filter.Since,
+		Limit:   opts.Filter.Limit,
+		Reverse: opts.Filter.Reverse,
+	})
+	if err != nil {
+		return nil, sp, err
+	}
+	result := make([]*centrifuge.Publication, 0, len(pubs))
+	for _, pub := range pubs {
+		result = append(result, &centrifuge.Publication{Offset: pub.Offset, Data: pub.Data})
+	}
+	return result, sp, nil
+}
+
+func (b *Broker) RemoveHistory(ch string) error {
+	if _, ok := b.channels.Get(ch); !ok {
+		return b.Broker.RemoveHistory(ch)
+	}
+	return b.storage.Remove(context.Background(), ch)
+}
+
+// eventHandler restores the position of the publications of the channels with history before they are
+// delivered to the subscribers.
+type eventHandler struct {
+	centrifuge.BrokerEventHandler
+}
+
+func (h *eventHandler) HandlePublication(ch string, pub *centrifuge.Publication, sp centrifuge.StreamPosition, useDelta bool, prevPub *centrifuge.Publication) error {
+	if offset, ok := pub.Tags[offsetTag]; ok {
+		if parsed, err := strconv.ParseUint(offset, 10, 64); err == nil {
+			pub.Offset = parsed
+			sp = centrifuge.StreamPosition{Offset: parsed, Epoch: pub.Tags[epochTag]}
+		}
+		delete(pub.Tags, offsetTag)
+		delete(pub.Tags, epochTag)
+		if len(pub.Tags) == 0 {
+			pub.Tags = nil
+		}
+	}
+	return h.BrokerEventHandler.HandlePublication(ch, pub, sp, useDelta, prevPub)
+}
@@ -0,0 +1,66 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Retention is the history kept for a channel.
+type Retention struct {
+	// Size is the maximum number of publications.
+	Size int
+	// TTL is the time window of the publications.
+	TTL time.Duration
+}
+
+type channelRule struct {
+	pattern   glob.Glob
+	retention Retention
+}
+
+// Channels matches the channels with history.
+type Channels struct {
+	rules []channelRule
+}
+
+// NewChannels creates Channels from the [live] history_channels setting.
+func NewChannels(channels []setting.LiveHistoryChannel) (*Channels, error) {
+	rules := make([]channelRule, 0, len(channels))
+	for _, ch := range channels {
+		pattern, err := glob.Compile(ch.Pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid history channel pattern %q: %w", ch.Pattern, err)
+		}
+		rules = append(rules, channelRule{
+			pattern:   pattern,
+			retention: Retention{Size: ch.Size, TTL: ch.TTL},
+		})
+	}
+	return &Channels{rules: rules}, nil
+}
+
+// Get returns the retention of a channel with the org prefix, e.g. "1/stream/factory/line1".
+// The first matching pattern is used.
+func (c *Channels) Get(channel string) (Retention, bool) {
+	_, ch, err := orgchannel.StripOrgID(channel)
+	if err != nil {
+		return Retention{}, false
+	}
+	// Data source and plugin streams are published to the local subscribers only, without the broker.
+	if strings.HasPrefix(ch, live.ScopeDatasource+"/") || strings.HasPrefix(ch, live.ScopePlugin+"/") {
+		return Retention{}, false
+	}
+	for _, rule := range c.rules {
+		if rule.pattern.Match(ch) {
+			return rule.retention, true
+		}
+	}
+	return Retention{}, false
+}
@@ -0,0 +1,36 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestChannels(t *testing.T) {
+	channels, err := NewChannels([]setting.LiveHistoryChannel{
+		{Pattern: "stream/factory/*", Size: 1000, TTL: time.Hour},
+		{Pattern: "stream/**", Size: 10, TTL: time.Minute},
+		{Pattern: "plugin/testdata/*", Size: 10, TTL: time.Minute},
+	})
+	require.NoError(t, err)
+
+	retention, ok := channels.Get("1/stream/factory/line1")
+	require.True(t, ok)
+	require.Equal(t, Retention{Size: 1000, TTL: time.Hour}, retention)
+
+	retention, ok = channels.Get("2/stream/factory/line1/temperature")
+	require.True(t, ok)
+	require.Equal(t, Retention{Size: 10, TTL: time.Minute}, retention)
+
+	_, ok = channels.Get("1/grafana/dashboard/uid/abc")
+	require.False(t, ok)
+
+	_, ok = channels.Get("stream/factory/line1")
+	require.False(t, ok, "channel without org prefix")
+
+	_, ok = channels.Get("1/plugin/testdata/random-2s-stream")
+	require.False(t, ok, "plugin streams are not published with the broker")
+}
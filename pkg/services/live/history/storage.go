@@ -0,0 +1,91 @@
+// Package history keeps a persistent history of Grafana Live channels, so that subscribers can recover the
+// publications they missed with Centrifuge recovery, e.g. after reconnecting or reloading a dashboard.
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var logger = log.New("live.history")
+
+// Publication is a publication kept in the history of a channel.
+type Publication struct {
+	Offset uint64
+	Data   []byte
+	Time   time.Time
+}
+
+// Filter selects the publications of a channel.
+type Filter struct {
+	// Since selects the publications after the position, or before it when Reverse is set. When the epoch of the
+	// position doesn't match the stream no publications are returned. Nil selects all publications.
+	Since *centrifuge.StreamPosition
+	// Limit is the maximum number of publications. Zero only returns the position of the stream, a negative
+	// value means no limit.
+	Limit int
+	// Reverse returns the most recent publications first.
+	Reverse bool
+}
+
+// Storage keeps the publications of the channels with history. The publications of a channel are kept according
+// to the retention of the channel, and the offset of every publication is one more than the previous one.
+type Storage interface {
+	// Append saves a publication and returns its position in the stream of the channel.
+	Append(ctx context.Context, channel string, data []byte) (centrifuge.StreamPosition, error)
+	// History returns the publications of a channel and the position of the last publication.
+	History(ctx context.Context, channel string, filter Filter) ([]Publication, centrifuge.StreamPosition, error)
+	// PositionAt returns the position of the stream right before the first publication at or after t.
+	// It returns false when the channel has no history.
+	PositionAt(ctx context.Context, channel string, t time.Time) (centrifuge.StreamPosition, bool, error)
+	// Remove removes the history of a channel.
+	Remove(ctx context.Context, channel string) error
+	// Cleanup removes the expired publications and the history of channels that are not configured anymore.
+	Cleanup(ctx context.Context) error
+}
+
+// NewStorage creates the storage configured in the [live] section.
+func NewStorage(cfg *setting.Cfg, store db.DB, channels *Channels) (Storage, error) {
+	switch cfg.LiveHistoryStorage {
+	case "sql":
+		return NewSQLStorage(store, channels), nil
+	case "file":
+		return NewFileStorage(cfg.LiveHistoryPath, channels)
+	default:
+		return nil, fmt.Errorf("unsupported live history storage type: %s", cfg.LiveHistoryStorage)
+	}
+}
+
+// filterPublications applies a filter to publications sorted by offset.
+func filterPublications(pubs []Publication, filter Filter) []Publication {
+	if filter.Limit == 0 {
+		return nil
+	}
+	result := make([]Publication, 0, len(pubs))
+	if filter.Reverse {
+		for i := len(pubs) - 1; i >= 0; i-- {
+			if filter.Since != nil && pubs[i].Offset >= filter.Since.Offset {
+				continue
+			}
+			result = append(result, pubs[i])
+		}
+	} else {
+		for _, pub := range pubs {
+			if filter.Since != nil && pub.Offset <= filter.Since.Offset {
+				continue
+			}
+			result = append(result, pub)
+		}
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result
+}
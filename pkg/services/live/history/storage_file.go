@@ -0,0 +1,314 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const historyFileExtension = ".jsonl"
+
+// FileStorage keeps the history in JSON lines files, one per channel. The history of the channels is also kept in
+// memory. Not usable in HA setup.
+type FileStorage struct {
+	dir      string
+	channels *Channels
+	now      func() time.Time
+
+	mu      sync.Mutex
+	streams map[string]*fileStream
+}
+
+type fileStream struct {
+	path  string
+	epoch string
+	top   uint64
+	pubs  []Publication
+	// lines is the number of publications written to the file, including the ones removed from pubs since the
+	// file was last compacted.
+	lines int
+}
+
+// fileHeader is the first line of a history file.
+type fileHeader struct {
+	Epoch string `json:"epoch"`
+	Top   uint64 `json:"top"`
+}
+
+type filePublication struct {
+	Offset uint64 `json:"offset"`
+	Time   int64  `json:"time"`
+	Data   []byte `json:"data"`
+}
+
+func NewFileStorage(dir string, channels *Channels) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create live history directory: %w", err)
+	}
+	return &FileStorage{
+		dir:      dir,
+		channels: channels,
+		now:      time.Now,
+		streams:  map[string]*fileStream{},
+	}, nil
+}
+
+func (s *FileStorage) Append(_ context.Context, channel string, data []byte) (centrifuge.StreamPosition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream, err := s.stream(channel)
+	if err != nil {
+		return centrifuge.StreamPosition{}, err
+	}
+	now := s.now()
+	stream.top++
+	pub := Publication{Offset: stream.top, Data: data, Time: now}
+	stream.pubs = append(stream.pubs, pub)
+	s.trim(channel, stream, now)
+
+	// Compact the file once most of its publications were removed, otherwise append the publication.
+	if stream.lines+1 > 2*len(stream.pubs) {
+		err = stream.rewrite()
+	} else {
+		err = stream.appendPublication(pub)
+	}
+	if err != nil {
+		return centrifuge.StreamPosition{}, fmt.Errorf("failed to append to the history of channel %s: %w", channel, err)
+	}
+	return centrifuge.StreamPosition{Offset: stream.top, Epoch: stream.epoch}, nil
+}
+
+func (s *FileStorage) History(_ context.Context, channel string, filter Filter) ([]Publication, centrifuge.StreamPosition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream, err := s.stream(channel)
+	if err != nil {
+		return nil, centrifuge.StreamPosition{}, err
+	}
+	s.trim(channel, stream, s.now())
+	sp := centrifuge.StreamPosition{Offset: stream.top, Epoch: stream.epoch}
+	if filter.Since != nil && filter.Since.Epoch != stream.epoch {
+		return nil, sp, nil
+	}
+	return filterPublications(stream.pubs, filter), sp, nil
+}
+
+func (s *FileStorage) PositionAt(_ context.Context, channel string, t time.Time) (centrifuge.StreamPosition, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.streams[channel]; !ok {
+		if _, err := os.Stat(s.path(channel)); errors.Is(err, fs.ErrNotExist) {
+			return centrifuge.StreamPosition{}, false, nil
+		}
+	}
+	stream, err := s.stream(channel)
+	if err != nil {
+		return centrifuge.StreamPosition{}, false, err
+	}
+	s.trim(channel, stream, s.now())
+	sp := centrifuge.StreamPosition{Offset: stream.top, Epoch: stream.epoch}
+	for _, pub := range stream.pubs {
+		if !pub.Time.Before(t) {
+			sp.Offset = pub.Offset - 1
+			break
+		}
+	}
+	return sp, true, nil
+}
+
+func (s *FileStorage) Remove(_ context.Context, channel string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.streams, channel)
+	if err := os.Remove(s.path(channel)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove the history of channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+func (s *FileStorage) Cleanup(ctx context.Context) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list the live channel history: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), historyFileExtension) {
+			continue
+		}
+		channel, err := url.PathUnescape(strings.TrimSuffix(entry.Name(), historyFileExtension))
+		if err != nil {
+			continue
+		}
+		if _, ok := s.channels.Get(channel); !ok {
+			if err := s.Remove(ctx, channel); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.compact(channel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStorage) compact(channel string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream, err := s.stream(channel)
+	if err != nil {
+		return err
+	}
+	s.trim(channel, stream, s.now())
+	if stream.lines == len(stream.pubs) {
+		return nil
+	}
+	if err := stream.rewrite(); err != nil {
+		return fmt.Errorf("failed to clean up the history of channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+func (s *FileStorage) path(channel string) string {
+	return filepath.Join(s.dir, url.PathEscape(channel)+historyFileExtension)
+}
+
+// trim removes the publications beyond the retention of the channel from memory.
+func (s *FileStorage) trim(channel string, stream *fileStream, now time.Time) {
+	retention, ok := s.channels.Get(channel)
+	if !ok {
+		return
+	}
+	i := 0
+	for i < len(stream.pubs) && (len(stream.pubs)-i > retention.Size || now.Sub(stream.pubs[i].Time) > retention.TTL) {
+		i++
+	}
+	stream.pubs = stream.pubs[i:]
+}
+
+// stream returns the stream of a channel, loading it from its file if needed. The caller must hold the lock.
+func (s *FileStorage) stream(channel string) (*fileStream, error) {
+	if stream, ok := s.streams[channel]; ok {
+		return stream, nil
+	}
+	stream := &fileStream{path: s.path(channel)}
+	err := stream.load()
+	if errors.Is(err, fs.ErrNotExist) {
+		stream.epoch = util.GenerateShortUID()
+		err = stream.rewrite()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the history of channel %s: %w", channel, err)
+	}
+	s.streams[channel] = stream
+	return stream, nil
+}
+
+func (st *fileStream) load() error {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path is built from the history directory and the escaped channel.
+	f, err := os.Open(st.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Warn("Failed to close history file", "path", st.path, "error", err)
+		}
+	}()
+
+	r := bufio.NewReader(f)
+	line, err := r.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	var header fileHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return fmt.Errorf("invalid history file header: %w", err)
+	}
+	st.epoch = header.Epoch
+	st.top = header.Top
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var pub filePublication
+			if err := json.Unmarshal(line, &pub); err != nil {
+				// A partially written line when Grafana was stopped, the following publications are still valid.
+				logger.Warn("Skipping invalid history file line", "path", st.path, "error", err)
+			} else {
+				st.pubs = append(st.pubs, Publication{Offset: pub.Offset, Data: pub.Data, Time: time.UnixMilli(pub.Time)})
+				if pub.Offset > st.top {
+					st.top = pub.Offset
+				}
+			}
+			st.lines++
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// rewrite replaces the file with the header and the publications of the stream.
+func (st *fileStream) rewrite() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(fileHeader{Epoch: st.epoch, Top: st.top}); err != nil {
+		return err
+	}
+	for _, pub := range st.pubs {
+		if err := enc.Encode(filePublication{Offset: pub.Offset, Time: pub.Time.UnixMilli(), Data: pub.Data}); err != nil {
+			return err
+		}
+	}
+	tmp := st.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, st.path); err != nil {
+		return err
+	}
+	st.lines = len(st.pubs)
+	return nil
+}
+
+func (st *fileStream) appendPublication(pub Publication) error {
+	line, err := json.Marshal(filePublication{Offset: pub.Offset, Time: pub.Time.UnixMilli(), Data: pub.Data})
+	if err != nil {
+		return err
+	}
+	// nolint:gosec
+	// We can ignore the gosec G304 warning since the path is built from the history directory and the escaped channel.
+	f, err := os.OpenFile(st.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	st.lines++
+	return nil
+}
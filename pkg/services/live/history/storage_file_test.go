@@ -0,0 +1,113 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestFileStorage(t *testing.T) {
+	const channel = "1/stream/factory/line1"
+	ctx := context.Background()
+	dir := t.TempDir()
+	channels, err := NewChannels([]setting.LiveHistoryChannel{{Pattern: "stream/factory/*", Size: 3, TTL: time.Hour}})
+	require.NoError(t, err)
+
+	now := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	s, err := NewFileStorage(dir, channels)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+
+	_, sp, err := s.History(ctx, channel, Filter{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), sp.Offset)
+	require.NotEmpty(t, sp.Epoch)
+	epoch := sp.Epoch
+
+	for i := 0; i < 5; i++ {
+		sp, err = s.Append(ctx, channel, []byte{byte('a' + i)})
+		require.NoError(t, err)
+		require.Equal(t, centrifuge.StreamPosition{Offset: uint64(i + 1), Epoch: epoch}, sp)
+		now = now.Add(time.Minute)
+	}
+
+	t.Run("history keeps the last publications", func(t *testing.T) {
+		pubs, sp, err := s.History(ctx, channel, Filter{Limit: -1})
+		require.NoError(t, err)
+		require.Equal(t, centrifuge.StreamPosition{Offset: 5, Epoch: epoch}, sp)
+		require.Equal(t, []uint64{3, 4, 5}, offsets(pubs))
+
+		pubs, _, err = s.History(ctx, channel, Filter{Since: &centrifuge.StreamPosition{Offset: 3, Epoch: epoch}, Limit: -1})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{4, 5}, offsets(pubs))
+
+		pubs, _, err = s.History(ctx, channel, Filter{Limit: 2, Reverse: true})
+		require.NoError(t, err)
+		require.Equal(t, []uint64{5, 4}, offsets(pubs))
+
+		pubs, _, err = s.History(ctx, channel, Filter{Since: &centrifuge.StreamPosition{Offset: 3, Epoch: "other"}, Limit: -1})
+		require.NoError(t, err)
+		require.Empty(t, pubs)
+
+		pubs, _, err = s.History(ctx, channel, Filter{})
+		require.NoError(t, err)
+		require.Empty(t, pubs)
+	})
+
+	t.Run("position at a time", func(t *testing.T) {
+		sp, ok, err := s.PositionAt(ctx, channel, time.Date(2024, 5, 2, 10, 3, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, centrifuge.StreamPosition{Offset: 3, Epoch: epoch}, sp)
+
+		_, ok, err = s.PositionAt(ctx, "1/stream/factory/line2", now)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("history is loaded from the file", func(t *testing.T) {
+		reloaded, err := NewFileStorage(dir, channels)
+		require.NoError(t, err)
+		reloaded.now = s.now
+
+		pubs, sp, err := reloaded.History(ctx, channel, Filter{Limit: -1})
+		require.NoError(t, err)
+		require.Equal(t, centrifuge.StreamPosition{Offset: 5, Epoch: epoch}, sp)
+		require.Equal(t, []uint64{3, 4, 5}, offsets(pubs))
+		require.Equal(t, []byte("e"), pubs[2].Data)
+
+		sp, err = reloaded.Append(ctx, channel, []byte("f"))
+		require.NoError(t, err)
+		require.Equal(t, centrifuge.StreamPosition{Offset: 6, Epoch: epoch}, sp)
+	})
+
+	t.Run("expired publications and channels without history are removed", func(t *testing.T) {
+		_, err := s.Append(ctx, "1/stream/other/line1", []byte("x"))
+		require.NoError(t, err)
+
+		now = now.Add(2 * time.Hour)
+		require.NoError(t, s.Cleanup(ctx))
+
+		pubs, sp, err := s.History(ctx, channel, Filter{Limit: -1})
+		require.NoError(t, err)
+		require.Empty(t, pubs)
+		require.Equal(t, epoch, sp.Epoch)
+
+		_, ok, err := s.PositionAt(ctx, "1/stream/other/line1", now)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+func offsets(pubs []Publication) []uint64 {
+	result := make([]uint64, 0, len(pubs))
+	for _, pub := range pubs {
+		result = append(result, pub.Offset)
+	}
+	return result
+}
@@ -0,0 +1,241 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// errStreamRemoved is returned when the history of a channel is removed while it is written.
+var errStreamRemoved = errors.New("the history of the channel was removed")
+
+type liveChannelStream struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	Channel string `xorm:"channel"`
+	Epoch   string `xorm:"epoch"`
+	// TopOffset is the offset of the last publication of the channel.
+	TopOffset int64 `xorm:"top_offset"`
+}
+
+func (liveChannelStream) TableName() string {
+	return "live_channel_stream"
+}
+
+type liveChannelPublication struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	Channel string `xorm:"channel"`
+	Offset  int64  `xorm:"pub_offset"`
+	Data    []byte `xorm:"data"`
+	Created int64  `xorm:"created"`
+}
+
+func (liveChannelPublication) TableName() string {
+	return "live_channel_publication"
+}
+
+// SQLStorage keeps the history in the Grafana database. It can be shared by all Grafana servers in an HA setup.
+type SQLStorage struct {
+	db       db.DB
+	channels *Channels
+	now      func() time.Time
+}
+
+func NewSQLStorage(db db.DB, channels *Channels) *SQLStorage {
+	return &SQLStorage{db: db, channels: channels, now: time.Now}
+}
+
+// Append only inserts the publication, the publications that are out of the retention of the channel are removed
+// by Cleanup and are skipped by History until then.
+func (s *SQLStorage) Append(ctx context.Context, channel string, data []byte) (centrifuge.StreamPosition, error) {
+	var sp centrifuge.StreamPosition
+	stream, err := s.getOrCreateStream(ctx, channel)
+	if err != nil {
+		return sp, fmt.Errorf("failed to append to the history of channel %s: %w", channel, err)
+	}
+	err = s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		// Increment in the database, so that concurrent appends from different servers get different offsets.
+		if _, err := sess.Exec("UPDATE live_channel_stream SET top_offset = top_offset + 1 WHERE id = ?", stream.ID); err != nil {
+			return err
+		}
+		ok, err := sess.ID(stream.ID).Get(stream)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errStreamRemoved
+		}
+		if _, err := sess.Insert(&liveChannelPublication{
+			Channel: channel,
+			Offset:  stream.TopOffset,
+			Data:    data,
+			Created: s.now().UnixMilli(),
+		}); err != nil {
+			return err
+		}
+		sp = centrifuge.StreamPosition{Offset: uint64(stream.TopOffset), Epoch: stream.Epoch}
+		return nil
+	})
+	if err != nil {
+		return sp, fmt.Errorf("failed to append to the history of channel %s: %w", channel, err)
+	}
+	return sp, nil
+}
+
+func (s *SQLStorage) History(ctx context.Context, channel string, filter Filter) ([]Publication, centrifuge.StreamPosition, error) {
+	var pubs []Publication
+	var sp centrifuge.StreamPosition
+	// The stream is created on the first read, so that subscribers get a stable epoch before the first publication.
+	stream, err := s.getOrCreateStream(ctx, channel)
+	if err != nil {
+		return nil, sp, fmt.Errorf("failed to read the history of channel %s: %w", channel, err)
+	}
+	sp = centrifuge.StreamPosition{Offset: uint64(stream.TopOffset), Epoch: stream.Epoch}
+	if filter.Limit == 0 || (filter.Since != nil && filter.Since.Epoch != stream.Epoch) {
+		return nil, sp, nil
+	}
+
+	err = s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Where("channel = ?", channel)
+		if retention, ok := s.channels.Get(channel); ok {
+			q = q.And("pub_offset > ? AND created >= ?", stream.TopOffset-int64(retention.Size), s.now().Add(-retention.TTL).UnixMilli())
+		}
+		if filter.Since != nil {
+			if filter.Reverse {
+				q = q.And("pub_offset < ?", int64(filter.Since.Offset))
+			} else {
+				q = q.And("pub_offset > ?", int64(filter.Since.Offset))
+			}
+		}
+		if filter.Reverse {
+			q = q.Desc("pub_offset")
+		} else {
+			q = q.Asc("pub_offset")
+		}
+		if filter.Limit > 0 {
+			q = q.Limit(filter.Limit)
+		}
+		var rows []liveChannelPublication
+		if err := q.Find(&rows); err != nil {
+			return err
+		}
+		pubs = make([]Publication, 0, len(rows))
+		for _, row := range rows {
+			pubs = append(pubs, Publication{Offset: uint64(row.Offset), Data: row.Data, Time: time.UnixMilli(row.Created)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sp, fmt.Errorf("failed to read the history of channel %s: %w", channel, err)
+	}
+	return pubs, sp, nil
+}
+
+func (s *SQLStorage) PositionAt(ctx context.Context, channel string, t time.Time) (centrifuge.StreamPosition, bool, error) {
+	var sp centrifuge.StreamPosition
+	var found bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var stream liveChannelStream
+		ok, err := sess.Where("channel = ?", channel).Get(&stream)
+		if err != nil || !ok {
+			return err
+		}
+		found = true
+		sp = centrifuge.StreamPosition{Offset: uint64(stream.TopOffset), Epoch: stream.Epoch}
+
+		q := sess.Where("channel = ? AND created >= ?", channel, t.UnixMilli())
+		if retention, ok := s.channels.Get(channel); ok {
+			q = q.And("pub_offset > ?", stream.TopOffset-int64(retention.Size))
+		}
+		var first liveChannelPublication
+		ok, err = q.Asc("pub_offset").Limit(1).Get(&first)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sp.Offset = uint64(first.Offset - 1)
+		}
+		return nil
+	})
+	if err != nil {
+		return sp, false, fmt.Errorf("failed to read the history of channel %s: %w", channel, err)
+	}
+	return sp, found, nil
+}
+
+func (s *SQLStorage) Remove(ctx context.Context, channel string) error {
+	err := s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Exec("DELETE FROM live_channel_publication WHERE channel = ?", channel); err != nil {
+			return err
+		}
+		_, err := sess.Exec("DELETE FROM live_channel_stream WHERE channel = ?", channel)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove the history of channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+func (s *SQLStorage) Cleanup(ctx context.Context) error {
+	var streams []liveChannelStream
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Find(&streams)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list the live channel history: %w", err)
+	}
+
+	now := s.now()
+	for _, stream := range streams {
+		retention, ok := s.channels.Get(stream.Channel)
+		if !ok {
+			if err := s.Remove(ctx, stream.Channel); err != nil {
+				return err
+			}
+			continue
+		}
+		err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("DELETE FROM live_channel_publication WHERE channel = ? AND (pub_offset <= ? OR created < ?)",
+				stream.Channel, stream.TopOffset-int64(retention.Size), now.Add(-retention.TTL).UnixMilli())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to clean up the history of channel %s: %w", stream.Channel, err)
+		}
+	}
+	return nil
+}
+
+// getOrCreateStream returns the stream of the channel and creates it if it doesn't exist. When another server
+// creates the stream at the same time, the insert fails on the unique channel and the stream of the other server
+// is returned.
+func (s *SQLStorage) getOrCreateStream(ctx context.Context, channel string) (*liveChannelStream, error) {
+	stream := &liveChannelStream{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		ok, err := sess.Where("channel = ?", channel).Get(stream)
+		if err != nil || ok {
+			return err
+		}
+		stream = &liveChannelStream{Channel: channel, Epoch: util.GenerateShortUID()}
+		_, err = sess.Insert(stream)
+		if err == nil || !s.db.GetDialect().IsUniqueConstraintViolation(err) {
+			return err
+		}
+		stream = &liveChannelStream{}
+		ok, err = sess.Where("channel = ?", channel).Get(stream)
+		if err == nil && !ok {
+			return errStreamRemoved
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
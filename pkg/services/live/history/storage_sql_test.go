@@ -0,0 +1,94 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/centrifugal/centrifuge"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationSQLStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	const channel = "1/stream/factory/line1"
+	ctx := context.Background()
+	channels, err := NewChannels([]setting.LiveHistoryChannel{{Pattern: "stream/factory/*", Size: 3, TTL: time.Hour}})
+	require.NoError(t, err)
+
+	now := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	s := NewSQLStorage(db.InitTestDB(t), channels)
+	s.now = func() time.Time { return now }
+
+	_, sp, err := s.History(ctx, channel, Filter{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), sp.Offset)
+	require.NotEmpty(t, sp.Epoch)
+	epoch := sp.Epoch
+
+	for i := 0; i < 5; i++ {
+		sp, err = s.Append(ctx, channel, []byte{byte('a' + i)})
+		require.NoError(t, err)
+		require.Equal(t, centrifuge.StreamPosition{Offset: uint64(i + 1), Epoch: epoch}, sp)
+		now = now.Add(time.Minute)
+	}
+
+	pubs, sp, err := s.History(ctx, channel, Filter{Limit: -1})
+	require.NoError(t, err)
+	require.Equal(t, centrifuge.StreamPosition{Offset: 5, Epoch: epoch}, sp)
+	require.Equal(t, []uint64{3, 4, 5}, offsets(pubs))
+	require.Equal(t, []byte("e"), pubs[2].Data)
+	// the publications out of the retention are skipped, they are only removed by the cleanup
+	require.Equal(t, int64(5), countPublications(t, s, channel))
+
+	pubs, _, err = s.History(ctx, channel, Filter{Since: &centrifuge.StreamPosition{Offset: 3, Epoch: epoch}, Limit: -1})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{4, 5}, offsets(pubs))
+
+	pubs, _, err = s.History(ctx, channel, Filter{Limit: 2, Reverse: true})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5, 4}, offsets(pubs))
+
+	sp, ok, err := s.PositionAt(ctx, channel, time.Date(2024, 5, 2, 10, 3, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, centrifuge.StreamPosition{Offset: 3, Epoch: epoch}, sp)
+
+	_, err = s.Append(ctx, "1/stream/other/line1", []byte("x"))
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, s.Cleanup(ctx))
+
+	pubs, sp, err = s.History(ctx, channel, Filter{Limit: -1})
+	require.NoError(t, err)
+	require.Empty(t, pubs)
+	require.Equal(t, centrifuge.StreamPosition{Offset: 5, Epoch: epoch}, sp)
+	require.Zero(t, countPublications(t, s, channel))
+
+	_, ok, err = s.PositionAt(ctx, "1/stream/other/line1", now)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func countPublications(t *testing.T, s *SQLStorage, channel string) int64 {
+	t.Helper()
+	var count int64
+	err := s.db.WithDbSession(context.Background(), func(sess *db.Session) error {
+		var err error
+		count, err = sess.Where("channel = ?", channel).Count(&liveChannelPublication{})
+		return err
+	})
+	require.NoError(t, err)
+	return count
+}
@@ -0,0 +1,186 @@
+// Package ingest provides inputs of the Live pipeline that subscribe to message brokers, so that
+// data can be pushed to Live channels without the HTTP and WebSocket push endpoints.
+//
+// The inputs subscribe with the Eclipse Paho MQTT client and the NATS client: the messages of a
+// topic are processed by the channel rules of the Live channel built from the topic.
+package ingest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var logger = log.New("live.ingest")
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+	dialTimeout       = 10 * time.Second
+)
+
+// Processor processes the data of a channel, it's implemented by the Live pipeline.
+type Processor interface {
+	ProcessInput(ctx context.Context, orgID int64, channelID string, body []byte) (bool, error)
+}
+
+// Message is a message received from a broker.
+type Message struct {
+	// Topic is the MQTT topic or NATS subject of the message.
+	Topic string
+	Data  []byte
+}
+
+// subscriber connects to a broker and calls handle for every message until the connection fails or
+// the context is done. handle blocks while the buffer of the input is full, which pauses reading from
+// the connection.
+type subscriber interface {
+	subscribe(ctx context.Context, handle func(Message) error) error
+}
+
+// Input subscribes to a broker and processes the messages with the Live pipeline. It reconnects with an
+// exponential backoff when the connection fails.
+type Input struct {
+	name          string
+	subscriber    subscriber
+	processor     Processor
+	orgID         int64
+	channelPrefix string
+	// separator is the separator of the topic levels, replaced by "/" in the channel path.
+	separator string
+	buffer    chan Message
+
+	minReconnectDelay time.Duration
+	maxReconnectDelay time.Duration
+}
+
+func newInput(name string, sub subscriber, processor Processor, s setting.LiveIngestSettings, separator string) *Input {
+	bufferSize := s.BufferSize
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Input{
+		name:              name,
+		subscriber:        sub,
+		processor:         processor,
+		orgID:             s.OrgID,
+		channelPrefix:     s.ChannelPrefix,
+		separator:         separator,
+		buffer:            make(chan Message, bufferSize),
+		minReconnectDelay: minReconnectDelay,
+		maxReconnectDelay: maxReconnectDelay,
+	}
+}
+
+// Run subscribes to the broker and processes the messages until the context is done.
+func (in *Input) Run(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		in.process(ctx)
+	}()
+
+	delay := in.minReconnectDelay
+	for {
+		connected := time.Now()
+		err := in.subscriber.subscribe(ctx, func(msg Message) error {
+			select {
+			case in.buffer <- msg:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if ctx.Err() != nil {
+			<-done
+			return ctx.Err()
+		}
+		// Reset the backoff when the connection was up for a while.
+		if time.Since(connected) > in.maxReconnectDelay {
+			delay = in.minReconnectDelay
+		}
+		logger.Warn("Live input disconnected, reconnecting", "input", in.name, "error", err, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			<-done
+			return ctx.Err()
+		}
+		delay *= 2
+		if delay > in.maxReconnectDelay {
+			delay = in.maxReconnectDelay
+		}
+	}
+}
+
+func (in *Input) process(ctx context.Context) {
+	for {
+		select {
+		case msg := <-in.buffer:
+			channel := in.channel(msg.Topic)
+			if _, err := live.ParseChannel(channel); err != nil {
+				logger.Warn("Skipping message with invalid Live channel", "input", in.name, "topic", msg.Topic, "channel", channel, "error", err)
+				continue
+			}
+			ok, err := in.processor.ProcessInput(ctx, in.orgID, channel, msg.Data)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					logger.Error("Error processing message", "input", in.name, "channel", channel, "error", err)
+				}
+				continue
+			}
+			if !ok {
+				logger.Debug("No channel rule for message", "input", in.name, "channel", channel)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// channel returns the Live channel of a topic, e.g. "stream/mqtt/factory/line1" for the "factory/line1" MQTT topic.
+func (in *Input) channel(topic string) string {
+	path := strings.Trim(topic, in.separator)
+	if in.separator != "/" {
+		path = strings.ReplaceAll(path, in.separator, "/")
+	}
+	return in.channelPrefix + "/" + path
+}
+
+// parseBrokerURL returns the address of a broker and its TLS config, nil without TLS. schemes maps the
+// accepted URL schemes to whether they use TLS.
+func parseBrokerURL(rawURL string, schemes map[string]bool, defaultPort, defaultTLSPort string) (string, *tls.Config, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+	useTLS, ok := schemes[u.Scheme]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported broker URL scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", nil, fmt.Errorf("missing host in broker URL %q", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+		if useTLS {
+			port = defaultTLSPort
+		}
+	}
+	var tlsConfig *tls.Config
+	if useTLS {
+		tlsConfig = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	return net.JoinHostPort(u.Hostname(), port), tlsConfig, nil
+}
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type processedMessage struct {
+	orgID   int64
+	channel string
+	body    string
+}
+
+// fakeProcessor records the processed messages. When block is set, processing waits until it's closed.
+type fakeProcessor struct {
+	mu       sync.Mutex
+	messages []processedMessage
+	block    chan struct{}
+}
+
+func (p *fakeProcessor) ProcessInput(ctx context.Context, orgID int64, channelID string, body []byte) (bool, error) {
+	if p.block != nil {
+		select {
+		case <-p.block:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, processedMessage{orgID: orgID, channel: channelID, body: string(body)})
+	return true, nil
+}
+
+func (p *fakeProcessor) processed() []processedMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]processedMessage(nil), p.messages...)
+}
+
+func (p *fakeProcessor) waitFor(t *testing.T, n int) []processedMessage {
+	t.Helper()
+	require.Eventually(t, func() bool { return len(p.processed()) >= n }, 5*time.Second, 10*time.Millisecond)
+	return p.processed()
+}
+
+// runInput runs an input with short reconnect delays until the end of the test.
+func runInput(t *testing.T, in *Input) {
+	t.Helper()
+	in.minReconnectDelay = 10 * time.Millisecond
+	in.maxReconnectDelay = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- in.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+}
+
+func TestInputChannel(t *testing.T) {
+	mqtt := newInput("mqtt", nil, nil, setting.LiveIngestSettings{ChannelPrefix: "stream/mqtt"}, "/")
+	assert.Equal(t, "stream/mqtt/factory/line1", mqtt.channel("factory/line1"))
+	assert.Equal(t, "stream/mqtt/factory/line1", mqtt.channel("/factory/line1/"))
+
+	nats := newInput("nats", nil, nil, setting.LiveIngestSettings{ChannelPrefix: "stream/nats"}, ".")
+	assert.Equal(t, "stream/nats/factory/line1", nats.channel("factory.line1"))
+}
+
+func TestParseBrokerURL(t *testing.T) {
+	schemes := map[string]bool{"tcp": false, "ssl": true}
+
+	addr, tlsConfig, err := parseBrokerURL("tcp://localhost", schemes, "1883", "8883")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:1883", addr)
+	assert.Nil(t, tlsConfig)
+
+	addr, tlsConfig, err = parseBrokerURL("ssl://broker.example.com:9883", schemes, "1883", "8883")
+	require.NoError(t, err)
+	assert.Equal(t, "broker.example.com:9883", addr)
+	require.NotNil(t, tlsConfig)
+	assert.Equal(t, "broker.example.com", tlsConfig.ServerName)
+
+	_, _, err = parseBrokerURL("http://localhost", schemes, "1883", "8883")
+	assert.Error(t, err)
+	_, _, err = parseBrokerURL("tcp://", schemes, "1883", "8883")
+	assert.Error(t, err)
+}
+
+func TestInputSkipsInvalidChannels(t *testing.T) {
+	processor := &fakeProcessor{}
+	sub := subscriberFunc(func(ctx context.Context, handle func(Message) error) error {
+		for _, topic := range []string{"bad topic", "good"} {
+			if err := handle(Message{Topic: topic, Data: []byte(topic)}); err != nil {
+				return err
+			}
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	runInput(t, newInput("test", sub, processor, setting.LiveIngestSettings{ChannelPrefix: "stream/test", OrgID: 2, BufferSize: 10}, "/"))
+
+	messages := processor.waitFor(t, 1)
+	assert.Equal(t, []processedMessage{{orgID: 2, channel: "stream/test/good", body: "good"}}, messages)
+}
+
+type subscriberFunc func(ctx context.Context, handle func(Message) error) error
+
+func (f subscriberFunc) subscribe(ctx context.Context, handle func(Message) error) error {
+	return f(ctx, handle)
+}
@@ -0,0 +1,123 @@
+package ingest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	mqttKeepAlive = 30 * time.Second
+	// mqttDisconnectQuiesce is how long the client waits for the pending work when it disconnects.
+	mqttDisconnectQuiesce = 250
+)
+
+// NewMQTTInput returns an input that subscribes to the topics of an MQTT broker. The messages are
+// received with QoS 1 and acknowledged once they are buffered.
+func NewMQTTInput(s setting.LiveIngestSettings, processor Processor) (*Input, error) {
+	if len(s.Topics) == 0 {
+		return nil, errors.New("no MQTT topic to subscribe to")
+	}
+	// MQTT 3.1.1 only allows a password together with a user name.
+	if s.Password != "" && s.Username == "" {
+		return nil, errors.New("MQTT password requires a user name")
+	}
+	addr, tlsConfig, err := parseBrokerURL(s.URL, map[string]bool{"tcp": false, "mqtt": false, "ssl": true, "tls": true, "mqtts": true}, "1883", "8883")
+	if err != nil {
+		return nil, err
+	}
+	sub := &mqttSubscriber{
+		addr:      addr,
+		tlsConfig: tlsConfig,
+		settings:  s,
+		keepAlive: mqttKeepAlive,
+	}
+	return newInput("mqtt", sub, processor, s, "/"), nil
+}
+
+type mqttSubscriber struct {
+	addr      string
+	tlsConfig *tls.Config
+	settings  setting.LiveIngestSettings
+	keepAlive time.Duration
+}
+
+func (s *mqttSubscriber) options() *mqtt.ClientOptions {
+	broker := "tcp://" + s.addr
+	if s.tlsConfig != nil {
+		broker = "ssl://" + s.addr
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(s.settings.ClientID).
+		SetUsername(s.settings.Username).
+		SetPassword(s.settings.Password).
+		// Clean session, so that the broker doesn't keep the messages while Grafana is disconnected.
+		SetCleanSession(true).
+		SetKeepAlive(s.keepAlive).
+		SetConnectTimeout(dialTimeout).
+		// The input reconnects with its own backoff and subscribes again.
+		SetAutoReconnect(false).
+		SetConnectRetry(false).
+		// The messages are handled one at a time, a message is acknowledged when its handler returns.
+		SetOrderMatters(true)
+	if s.tlsConfig != nil {
+		opts.SetTLSConfig(s.tlsConfig)
+	}
+	return opts
+}
+
+func (s *mqttSubscriber) subscribe(ctx context.Context, handle func(Message) error) error {
+	lost := make(chan error, 1)
+	opts := s.options().SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		lost <- err
+	})
+	client := mqtt.NewClient(opts)
+	// Also stops a connection attempt that is still running when the context is done.
+	defer client.Disconnect(mqttDisconnectQuiesce)
+	if err := waitMQTTToken(ctx, client.Connect()); err != nil {
+		return err
+	}
+
+	filters := make(map[string]byte, len(s.settings.Topics))
+	for _, topic := range s.settings.Topics {
+		filters[topic] = 1
+	}
+	token := client.SubscribeMultiple(filters, func(_ mqtt.Client, msg mqtt.Message) {
+		// Blocking while the buffer is full delays the acknowledgement, so the broker stops sending messages
+		// once its inflight window is full. The error is the one of the context, which ends the subscription.
+		_ = handle(Message{Topic: msg.Topic(), Data: msg.Payload()})
+	})
+	if err := waitMQTTToken(ctx, token); err != nil {
+		return err
+	}
+	for topic, code := range token.(*mqtt.SubscribeToken).Result() {
+		if code == 0x80 {
+			return fmt.Errorf("MQTT subscription to topic %s refused", topic)
+		}
+	}
+	logger.Info("Subscribed to MQTT topics", "addr", s.addr, "topics", s.settings.Topics)
+
+	select {
+	case err := <-lost:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitMQTTToken waits until the operation of the token completes or the context is done.
+func waitMQTTToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
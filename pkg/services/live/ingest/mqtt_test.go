@@ -0,0 +1,232 @@
+package ingest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// testMQTTBroker is an embedded MQTT broker that authenticates the clients with a user name and a password,
+// and records the subscriptions and the acknowledged publications.
+type testMQTTBroker struct {
+	mqttserver.HookBase
+
+	server *mqttserver.Server
+	addr   string
+
+	mu            sync.Mutex
+	username      string
+	password      string
+	subscriptions [][]string
+	acks          int
+	// connected receives the client identifiers once subscribed.
+	connected chan string
+}
+
+func newTestMQTTBroker(t *testing.T, username, password string) *testMQTTBroker {
+	t.Helper()
+	b := &testMQTTBroker{username: username, password: password, connected: make(chan string, 10)}
+	b.server = mqttserver.New(&mqttserver.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, b.server.AddHook(b, nil))
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	require.NoError(t, b.server.AddListener(tcp))
+	require.NoError(t, b.server.Serve())
+	t.Cleanup(func() { _ = b.server.Close() })
+	b.addr = tcp.Address()
+	return b
+}
+
+func (b *testMQTTBroker) ID() string {
+	return "test"
+}
+
+func (b *testMQTTBroker) Provides(hook byte) bool {
+	return bytes.Contains([]byte{
+		mqttserver.OnConnectAuthenticate,
+		mqttserver.OnACLCheck,
+		mqttserver.OnSubscribed,
+		mqttserver.OnQosComplete,
+	}, []byte{hook})
+}
+
+func (b *testMQTTBroker) OnConnectAuthenticate(cl *mqttserver.Client, pk packets.Packet) bool {
+	if cl.Net.Inline {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(pk.Connect.Username) == b.username && string(pk.Connect.Password) == b.password
+}
+
+func (b *testMQTTBroker) OnACLCheck(*mqttserver.Client, string, bool) bool {
+	return true
+}
+
+func (b *testMQTTBroker) OnSubscribed(cl *mqttserver.Client, pk packets.Packet, _ []byte) {
+	topics := make([]string, 0, len(pk.Filters))
+	for _, filter := range pk.Filters {
+		topics = append(topics, filter.Filter)
+	}
+	b.mu.Lock()
+	b.subscriptions = append(b.subscriptions, topics)
+	b.mu.Unlock()
+	b.connected <- cl.ID
+}
+
+func (b *testMQTTBroker) OnQosComplete(*mqttserver.Client, packets.Packet) {
+	b.mu.Lock()
+	b.acks++
+	b.mu.Unlock()
+}
+
+func (b *testMQTTBroker) setPassword(password string) {
+	b.mu.Lock()
+	b.password = password
+	b.mu.Unlock()
+}
+
+func (b *testMQTTBroker) waitConnected(t *testing.T) string {
+	t.Helper()
+	select {
+	case id := <-b.connected:
+		return id
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the MQTT subscription")
+		return ""
+	}
+}
+
+func (b *testMQTTBroker) publish(t *testing.T, topic, payload string) {
+	t.Helper()
+	require.NoError(t, b.server.Publish(topic, []byte(payload), false, 1))
+}
+
+func (b *testMQTTBroker) disconnect(t *testing.T, id string) {
+	t.Helper()
+	cl, ok := b.server.Clients.Get(id)
+	require.True(t, ok)
+	cl.Stop(errors.New("disconnected by the test"))
+}
+
+func (b *testMQTTBroker) acked() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acks
+}
+
+func newTestMQTTInput(t *testing.T, b *testMQTTBroker, processor Processor, bufferSize int) *Input {
+	t.Helper()
+	in, err := NewMQTTInput(setting.LiveIngestSettings{
+		URL:           "mqtt://" + b.addr,
+		Username:      "grafana",
+		Password:      "secret",
+		ClientID:      "grafana",
+		Topics:        []string{"factory/#", "office/+/temperature"},
+		ChannelPrefix: "stream/mqtt",
+		OrgID:         1,
+		BufferSize:    bufferSize,
+	}, processor)
+	require.NoError(t, err)
+	return in
+}
+
+func TestMQTTInput(t *testing.T) {
+	t.Run("processes the messages of the subscribed topics", func(t *testing.T) {
+		b := newTestMQTTBroker(t, "grafana", "secret")
+		processor := &fakeProcessor{}
+		runInput(t, newTestMQTTInput(t, b, processor, 10))
+
+		b.waitConnected(t)
+		b.publish(t, "factory/line1", `{"value":1}`)
+		b.publish(t, "office/kitchen/temperature", `{"value":21.5}`)
+
+		messages := processor.waitFor(t, 2)
+		assert.Equal(t, []processedMessage{
+			{orgID: 1, channel: "stream/mqtt/factory/line1", body: `{"value":1}`},
+			{orgID: 1, channel: "stream/mqtt/office/kitchen/temperature", body: `{"value":21.5}`},
+		}, messages)
+		require.Eventually(t, func() bool { return b.acked() == 2 }, 5*time.Second, 10*time.Millisecond)
+		b.mu.Lock()
+		assert.Len(t, b.subscriptions, 1)
+		assert.ElementsMatch(t, []string{"factory/#", "office/+/temperature"}, b.subscriptions[0])
+		b.mu.Unlock()
+	})
+
+	t.Run("reconnects and subscribes again when the connection is lost", func(t *testing.T) {
+		b := newTestMQTTBroker(t, "grafana", "secret")
+		processor := &fakeProcessor{}
+		runInput(t, newTestMQTTInput(t, b, processor, 10))
+
+		id := b.waitConnected(t)
+		b.publish(t, "factory/line1", "before")
+		processor.waitFor(t, 1)
+		b.disconnect(t, id)
+
+		b.waitConnected(t)
+		b.publish(t, "factory/line1", "after")
+		messages := processor.waitFor(t, 2)
+		assert.Equal(t, "after", messages[1].body)
+		b.mu.Lock()
+		assert.Len(t, b.subscriptions, 2)
+		b.mu.Unlock()
+	})
+
+	t.Run("retries when the connection is refused", func(t *testing.T) {
+		b := newTestMQTTBroker(t, "grafana", "other")
+		processor := &fakeProcessor{}
+		runInput(t, newTestMQTTInput(t, b, processor, 10))
+
+		select {
+		case <-b.connected:
+			t.Fatal("unexpected subscription with invalid credentials")
+		case <-time.After(100 * time.Millisecond):
+		}
+		b.setPassword("secret")
+		b.waitConnected(t)
+	})
+
+	t.Run("stops acknowledging while the buffer is full", func(t *testing.T) {
+		b := newTestMQTTBroker(t, "grafana", "secret")
+		processor := &fakeProcessor{block: make(chan struct{})}
+		runInput(t, newTestMQTTInput(t, b, processor, 1))
+
+		b.waitConnected(t)
+		for i := 0; i < 5; i++ {
+			b.publish(t, "factory/line1", "message")
+		}
+		// One message is being processed, one is in the buffer and the next one waits for space.
+		require.Eventually(t, func() bool { return b.acked() == 2 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 2, b.acked())
+
+		close(processor.block)
+		processor.waitFor(t, 5)
+		require.Eventually(t, func() bool { return b.acked() == 5 }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestNewMQTTInput(t *testing.T) {
+	t.Run("rejects a password without a user name", func(t *testing.T) {
+		_, err := NewMQTTInput(setting.LiveIngestSettings{
+			URL:      "mqtt://localhost",
+			Password: "secret",
+			Topics:   []string{"factory/#"},
+		}, &fakeProcessor{})
+		require.EqualError(t, err, "MQTT password requires a user name")
+	})
+}
@@ -0,0 +1,98 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const natsPingInterval = 30 * time.Second
+
+// NewNATSInput returns an input that subscribes to the subjects of a NATS server. Core NATS has no
+// acknowledgements: while the buffer is full the client keeps the messages up to its pending limits, then
+// it drops them and reports Grafana as a slow consumer.
+func NewNATSInput(s setting.LiveIngestSettings, processor Processor) (*Input, error) {
+	if len(s.Topics) == 0 {
+		return nil, errors.New("no NATS subject to subscribe to")
+	}
+	if _, _, err := parseBrokerURL(s.URL, map[string]bool{"nats": false, "tls": true}, "4222", "4222"); err != nil {
+		return nil, err
+	}
+	sub := &natsSubscriber{
+		url:          s.URL,
+		settings:     s,
+		pingInterval: natsPingInterval,
+	}
+	return newInput("nats", sub, processor, s, "."), nil
+}
+
+type natsSubscriber struct {
+	// url is the URL of the server, the tls scheme requires TLS and the server can also require it.
+	url          string
+	settings     setting.LiveIngestSettings
+	pingInterval time.Duration
+}
+
+func (s *natsSubscriber) options() []nats.Option {
+	opts := []nats.Option{
+		nats.Name(s.settings.ClientID),
+		nats.Timeout(dialTimeout),
+		nats.PingInterval(s.pingInterval),
+		nats.MaxPingsOutstanding(2),
+		// The input reconnects with its own backoff and subscribes again.
+		nats.NoReconnect(),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			if sub != nil {
+				logger.Warn("NATS subscription error", "subject", sub.Subject, "error", err)
+				return
+			}
+			logger.Warn("NATS error", "error", err)
+		}),
+	}
+	if s.settings.Username != "" || s.settings.Password != "" {
+		opts = append(opts, nats.UserInfo(s.settings.Username, s.settings.Password))
+	}
+	if s.settings.Token != "" {
+		opts = append(opts, nats.Token(s.settings.Token))
+	}
+	return opts
+}
+
+func (s *natsSubscriber) subscribe(ctx context.Context, handle func(Message) error) error {
+	closed := make(chan struct{})
+	opts := append(s.options(), nats.ClosedHandler(func(*nats.Conn) { close(closed) }))
+	nc, err := nats.Connect(s.url, opts...)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	for _, subject := range s.settings.Topics {
+		_, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+			// The error is the one of the context, which ends the subscription.
+			_ = handle(Message{Topic: msg.Subject, Data: msg.Data})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// The server answers the flush once it processed the subscriptions.
+	if err := nc.FlushTimeout(dialTimeout); err != nil {
+		return err
+	}
+	logger.Info("Subscribed to NATS subjects", "url", nc.ConnectedUrlRedacted(), "subjects", s.settings.Topics)
+
+	select {
+	case <-closed:
+		if err := nc.LastError(); err != nil {
+			return err
+		}
+		return nats.ErrConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
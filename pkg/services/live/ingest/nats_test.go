@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// testNATSServer is an embedded NATS server that authenticates the clients with a token.
+type testNATSServer struct {
+	server *server.Server
+	opts   *server.Options
+
+	mu sync.Mutex
+	// subscribed are the identifiers of the Grafana connections that subscribed to the subjects.
+	subscribed map[uint64]bool
+}
+
+func newTestNATSServer(t *testing.T, token string) *testNATSServer {
+	t.Helper()
+	// The port is fixed, so that the options can be reloaded with another token.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	opts := &server.Options{
+		Host:          "127.0.0.1",
+		Port:          port,
+		NoLog:         true,
+		NoSigs:        true,
+		Authorization: token,
+	}
+	srv, err := server.NewServer(opts)
+	require.NoError(t, err)
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second), "NATS server not ready")
+	return &testNATSServer{server: srv, opts: opts, subscribed: map[uint64]bool{}}
+}
+
+func (s *testNATSServer) setToken(t *testing.T, token string) {
+	t.Helper()
+	opts := s.opts.Clone()
+	opts.Authorization = token
+	require.NoError(t, s.server.ReloadOptions(opts))
+	s.opts = opts
+}
+
+// waitConnected waits for a new Grafana connection that subscribed to all the subjects and returns its identifier.
+func (s *testNATSServer) waitConnected(t *testing.T) uint64 {
+	t.Helper()
+	var cid uint64
+	var subs []string
+	require.Eventually(t, func() bool {
+		connz, err := s.server.Connz(&server.ConnzOptions{Subscriptions: true})
+		if err != nil {
+			return false
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, conn := range connz.Conns {
+			if conn.Name == "grafana" && conn.NumSubs == 2 && !s.subscribed[conn.Cid] {
+				subs = conn.Subs
+				s.subscribed[conn.Cid] = true
+				cid = conn.Cid
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond, "timeout waiting for the NATS subscriptions")
+	assert.ElementsMatch(t, []string{"factory.>", "office.*.temperature"}, subs)
+	return cid
+}
+
+func (s *testNATSServer) publish(t *testing.T, subject, payload string) {
+	t.Helper()
+	nc, err := nats.Connect(s.server.ClientURL(), nats.Token(s.opts.Authorization), nats.Name("publisher"))
+	require.NoError(t, err)
+	defer nc.Close()
+	require.NoError(t, nc.Publish(subject, []byte(payload)))
+	require.NoError(t, nc.Flush())
+}
+
+func newTestNATSInput(t *testing.T, s *testNATSServer, processor Processor, bufferSize int) *Input {
+	t.Helper()
+	in, err := NewNATSInput(setting.LiveIngestSettings{
+		URL:           s.server.ClientURL(),
+		Token:         "secret",
+		ClientID:      "grafana",
+		Topics:        []string{"factory.>", "office.*.temperature"},
+		ChannelPrefix: "stream/nats",
+		OrgID:         1,
+		BufferSize:    bufferSize,
+	}, processor)
+	require.NoError(t, err)
+	return in
+}
+
+func TestNATSInput(t *testing.T) {
+	t.Run("processes the messages of the subscribed subjects", func(t *testing.T) {
+		s := newTestNATSServer(t, "secret")
+		processor := &fakeProcessor{}
+		runInput(t, newTestNATSInput(t, s, processor, 10))
+
+		s.waitConnected(t)
+		s.publish(t, "factory.line1", `{"value":1}`)
+		s.publish(t, "office.kitchen.temperature", "")
+		s.publish(t, "factory.line2", "multi\r\nline")
+
+		messages := processor.waitFor(t, 3)
+		assert.Equal(t, []processedMessage{
+			{orgID: 1, channel: "stream/nats/factory/line1", body: `{"value":1}`},
+			{orgID: 1, channel: "stream/nats/office/kitchen/temperature", body: ""},
+			{orgID: 1, channel: "stream/nats/factory/line2", body: "multi\r\nline"},
+		}, messages)
+	})
+
+	t.Run("reconnects and subscribes again when the connection is lost", func(t *testing.T) {
+		s := newTestNATSServer(t, "secret")
+		processor := &fakeProcessor{}
+		runInput(t, newTestNATSInput(t, s, processor, 10))
+
+		cid := s.waitConnected(t)
+		s.publish(t, "factory.line1", "before")
+		processor.waitFor(t, 1)
+		require.NoError(t, s.server.DisconnectClientByID(cid))
+
+		s.waitConnected(t)
+		s.publish(t, "factory.line1", "after")
+		messages := processor.waitFor(t, 2)
+		assert.Equal(t, "after", messages[1].body)
+	})
+
+	t.Run("retries when the authentication fails", func(t *testing.T) {
+		s := newTestNATSServer(t, "other")
+		processor := &fakeProcessor{}
+		runInput(t, newTestNATSInput(t, s, processor, 10))
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Zero(t, s.server.NumClients())
+		s.setToken(t, "secret")
+		s.waitConnected(t)
+	})
+
+	t.Run("keeps the messages while the buffer is full", func(t *testing.T) {
+		s := newTestNATSServer(t, "secret")
+		processor := &fakeProcessor{block: make(chan struct{})}
+		runInput(t, newTestNATSInput(t, s, processor, 1))
+
+		s.waitConnected(t)
+		for i := 0; i < 5; i++ {
+			s.publish(t, "factory.line1", fmt.Sprint(i))
+		}
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, processor.processed())
+
+		close(processor.block)
+		messages := processor.waitFor(t, 5)
+		for i, msg := range messages {
+			assert.Equal(t, fmt.Sprint(i), msg.body)
+		}
+	})
+}
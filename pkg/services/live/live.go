@@ -17,6 +17,7 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/gobwas/glob"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/live"
 	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/sync/errgroup"
@@ -41,6 +42,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/live/database"
 	"github.com/grafana/grafana/pkg/services/live/features"
+	"github.com/grafana/grafana/pkg/services/live/history"
+	"github.com/grafana/grafana/pkg/services/live/ingest"
 	"github.com/grafana/grafana/pkg/services/live/livecontext"
 	"github.com/grafana/grafana/pkg/services/live/liveplugin"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
@@ -78,7 +81,7 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	dataSourceCache datasources.CacheService, sqlStore db.DB, secretsService secrets.Service,
 	usageStatsService usagestats.Service, queryDataService query.Service, toggles featuremgmt.FeatureToggles,
 	accessControl accesscontrol.AccessControl, dashboardService dashboards.DashboardService, annotationsRepo annotations.Repository,
-	orgService org.Service) (*GrafanaLive, error) {
+	orgService org.Service, alertSender pipeline.AlertSender) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -97,6 +100,9 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		},
 		usageStatsService: usageStatsService,
 		orgService:        orgService,
+		annotationsRepo:   annotationsRepo,
+		dashboardService:  dashboardService,
+		alertSender:       alertSender,
 	}
 
 	logger.Debug("GrafanaLive initialization", "ha", g.IsHA())
@@ -120,19 +126,37 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	}
 	g.node = node
 
+	var broker centrifuge.Broker
 	redisHealthy := false
 	if g.IsHA() {
 		// Configure HA with Redis. In this case Centrifuge nodes
 		// will be connected over Redis PUB/SUB. Presence will work
 		// globally since kept inside Redis.
-		err := setupRedisLiveEngine(g, node)
+		redisBroker, err := setupRedisLiveEngine(g, node)
 		if err != nil {
 			logger.Error("failed to setup redis live engine: %v", err)
 		} else {
+			broker = redisBroker
 			redisHealthy = true
 		}
 	}
 
+	if g.Cfg.LiveHistoryStorage != "" {
+		if broker == nil {
+			broker, err = centrifuge.NewMemoryBroker(node, centrifuge.MemoryBrokerConfig{})
+			if err != nil {
+				return nil, fmt.Errorf("error creating Live memory broker: %w", err)
+			}
+		}
+		broker, err = setupLiveHistory(g, broker)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if broker != nil {
+		node.SetBroker(broker)
+	}
+
 	channelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, nil)
 
 	var managedStreamRunner *managedstream.Runner
@@ -166,6 +190,14 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 	g.ManagedStreamRunner = managedStreamRunner
 
 	g.contextGetter = liveplugin.NewContextGetter(g.PluginContextProvider, g.DataSourceCache)
+	if g.Cfg.LivePipelineEnabled {
+		if err := setupLivePipeline(g); err != nil {
+			return nil, err
+		}
+	}
+	if err := setupLiveInputs(g); err != nil {
+		return nil, err
+	}
 	pipelinedChannelLocalPublisher := liveplugin.NewChannelLocalPublisher(node, g.Pipeline)
 	numLocalSubscribersGetter := liveplugin.NewNumLocalSubscribersGetter(node)
 	g.runStreamManager = runstream.NewManager(pipelinedChannelLocalPublisher, numLocalSubscribersGetter, g.contextGetter)
@@ -321,12 +353,28 @@ func ProvideService(plugCtxProvider *plugincontext.Provider, cfg *setting.Cfg, r
 		group.Get("/pipeline/push/*", g.pushPipelineWebsocketHandler)
 	}, middleware.ReqOrgAdmin, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
 
+	if g.Pipeline != nil {
+		// Pipeline rule API, the rules are saved in the data directory.
+		g.RouteRegister.Group("/api/live", func(group routing.RouteRegister) {
+			group.Get("/channel-rules", routing.Wrap(g.HandleChannelRulesListHTTP))
+			group.Post("/channel-rules", routing.Wrap(g.HandleChannelRulesPostHTTP))
+			group.Put("/channel-rules", routing.Wrap(g.HandleChannelRulesPutHTTP))
+			group.Delete("/channel-rules", routing.Wrap(g.HandleChannelRulesDeleteHTTP))
+			group.Get("/pipeline-entities", routing.Wrap(g.HandlePipelineEntitiesListHTTP))
+			group.Post("/pipeline-convert-test", routing.Wrap(g.HandlePipelineConvertTestHTTP))
+			group.Get("/write-configs", routing.Wrap(g.HandleWriteConfigsListHTTP))
+			group.Post("/write-configs", routing.Wrap(g.HandleWriteConfigsPostHTTP))
+			group.Put("/write-configs", routing.Wrap(g.HandleWriteConfigsPutHTTP))
+			group.Delete("/write-configs", routing.Wrap(g.HandleWriteConfigsDeleteHTTP))
+		}, middleware.ReqOrgAdmin, requestmeta.SetSLOGroup(requestmeta.SLOGroupNone))
+	}
+
 	g.registerUsageMetrics()
 
 	return g, nil
 }
 
-func setupRedisLiveEngine(g *GrafanaLive, node *centrifuge.Node) error {
+func setupRedisLiveEngine(g *GrafanaLive, node *centrifuge.Node) (centrifuge.Broker, error) {
 	redisAddress := g.Cfg.LiveHAEngineAddress
 	redisPassword := g.Cfg.LiveHAEnginePassword
 	redisShardConfigs := []centrifuge.RedisShardConfig{
@@ -337,7 +385,7 @@ func setupRedisLiveEngine(g *GrafanaLive, node *centrifuge.Node) error {
 	for _, redisConf := range redisShardConfigs {
 		redisShard, err := centrifuge.NewRedisShard(node, redisConf)
 		if err != nil {
-			return fmt.Errorf("error connecting to Live Redis: %v", err)
+			return nil, fmt.Errorf("error connecting to Live Redis: %v", err)
 		}
 
 		redisShards = append(redisShards, redisShard)
@@ -348,24 +396,92 @@ func setupRedisLiveEngine(g *GrafanaLive, node *centrifuge.Node) error {
 		Shards: redisShards,
 	})
 	if err != nil {
-		return fmt.Errorf("error creating Live Redis broker: %v", err)
+		return nil, fmt.Errorf("error creating Live Redis broker: %v", err)
 	}
 
-	node.SetBroker(broker)
-
 	presenceManager, err := centrifuge.NewRedisPresenceManager(node, centrifuge.RedisPresenceManagerConfig{
 		Prefix: "gf_live",
 		Shards: redisShards,
 	})
 	if err != nil {
-		return fmt.Errorf("error creating Live Redis presence manager: %v", err)
+		return nil, fmt.Errorf("error creating Live Redis presence manager: %v", err)
 	}
 
 	node.SetPresenceManager(presenceManager)
 
+	return broker, nil
+}
+
+// setupLivePipeline creates the pipeline that processes the data pushed to channels
+// according to the channel rules saved in the data directory.
+func setupLivePipeline(g *GrafanaLive) error {
+	g.pipelineStorage = &pipeline.FileStorage{
+		DataPath:       g.Cfg.DataPath,
+		SecretsService: g.SecretsService,
+	}
+	builder := &pipeline.StorageRuleBuilder{
+		Node:                 g.node,
+		ManagedStream:        g.ManagedStreamRunner,
+		FrameStorage:         pipeline.NewFrameStorage(),
+		Storage:              g.pipelineStorage,
+		ChannelHandlerGetter: g,
+		SecretsService:       g.SecretsService,
+		AnnotationsRepo:      g.annotationsRepo,
+		DashboardGetter:      g.dashboardService,
+		AlertSender:          g.alertSender,
+	}
+	pipe, err := pipeline.New(pipeline.NewCacheSegmentedTree(builder))
+	if err != nil {
+		return fmt.Errorf("error creating Live pipeline: %w", err)
+	}
+	g.Pipeline = pipe
+	return nil
+}
+
+// setupLiveInputs creates the inputs of the pipeline configured in the [live.mqtt]
+// and [live.nats] sections.
+func setupLiveInputs(g *GrafanaLive) error {
+	inputs := []struct {
+		name     string
+		settings setting.LiveIngestSettings
+		create   func(setting.LiveIngestSettings, ingest.Processor) (*ingest.Input, error)
+	}{
+		{name: "live.mqtt", settings: g.Cfg.LiveMQTT, create: ingest.NewMQTTInput},
+		{name: "live.nats", settings: g.Cfg.LiveNATS, create: ingest.NewNATSInput},
+	}
+	for _, input := range inputs {
+		if input.settings.URL == "" {
+			continue
+		}
+		if g.Pipeline == nil {
+			logger.Warn("Ignoring Live input since the pipeline is disabled, set [live] pipeline_enabled to enable it", "section", input.name)
+			continue
+		}
+		in, err := input.create(input.settings, g.Pipeline)
+		if err != nil {
+			return fmt.Errorf("error creating Live input [%s]: %w", input.name, err)
+		}
+		g.inputs = append(g.inputs, in)
+	}
 	return nil
 }
 
+// setupLiveHistory wraps the broker to keep the history of the channels configured
+// in [live] history_channels in the history storage.
+func setupLiveHistory(g *GrafanaLive, broker centrifuge.Broker) (centrifuge.Broker, error) {
+	channels, err := history.NewChannels(g.Cfg.LiveHistoryChannels)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := history.NewStorage(g.Cfg, g.SQLStore, channels)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Live history storage: %w", err)
+	}
+	g.historyChannels = channels
+	g.historyStorage = storage
+	return history.NewBroker(broker, storage, channels), nil
+}
+
 // GrafanaLive manages live real-time connections to Grafana (over WebSocket at this moment).
 // The main concept here is Channel. Connections can subscribe to many channels. Each channel
 // can have different permissions and properties but once a connection subscribed to a channel
@@ -384,6 +500,9 @@ type GrafanaLive struct {
 	pluginClient          plugins.Client
 	queryDataService      query.Service
 	orgService            org.Service
+	annotationsRepo       annotations.Repository
+	dashboardService      dashboards.DashboardService
+	alertSender           pipeline.AlertSender
 
 	node         *centrifuge.Node
 	surveyCaller *survey.Caller
@@ -403,11 +522,17 @@ type GrafanaLive struct {
 	ManagedStreamRunner *managedstream.Runner
 	Pipeline            *pipeline.Pipeline
 	pipelineStorage     pipeline.Storage
+	// Inputs of the pipeline subscribing to message brokers.
+	inputs []*ingest.Input
 
 	contextGetter    *liveplugin.ContextGetter
 	runStreamManager *runstream.Manager
 	storage          *database.Storage
 
+	// Persistent history of channels, nil when [live] history_storage is not set.
+	historyChannels *history.Channels
+	historyStorage  history.Storage
+
 	usageStatsService usagestats.Service
 	usageStats        usageStats
 }
@@ -456,6 +581,30 @@ func (g *GrafanaLive) Run(ctx context.Context) error {
 		}
 	})
 
+	if g.historyStorage != nil {
+		eGroup.Go(func() error {
+			cleanupTicker := time.NewTicker(historyCleanupInterval)
+			defer cleanupTicker.Stop()
+
+			for {
+				select {
+				case <-cleanupTicker.C:
+					if err := g.historyStorage.Cleanup(eCtx); err != nil {
+						logger.Error("Failed to clean up Live channel history", "error", err)
+					}
+				case <-eCtx.Done():
+					return eCtx.Err()
+				}
+			}
+		})
+	}
+
+	for _, in := range g.inputs {
+		eGroup.Go(func() error {
+			return in.Run(eCtx)
+		})
+	}
+
 	if g.runStreamManager != nil {
 		// Only run stream manager if GrafanaLive properly initialized.
 		eGroup.Go(func() error {
@@ -519,6 +668,8 @@ func checkAllowedOrigin(origin string, originURL *url.URL, appURL *url.URL, orig
 
 var clientConcurrency = 12
 
+var historyCleanupInterval = 10 * time.Minute
+
 func (g *GrafanaLive) IsHA() bool {
 	return g.Cfg != nil && g.Cfg.LiveHAEngine != ""
 }
@@ -692,18 +843,67 @@ func (g *GrafanaLive) handleOnSubscribe(ctx context.Context, client *centrifuge.
 		logger.Debug("Return custom subscribe error", "user", client.UserID(), "client", client.ID(), "channel", e.Channel, "code", code)
 		return centrifuge.SubscribeReply{}, &centrifuge.Error{Code: uint32(code), Message: text}
 	}
+	options := centrifuge.SubscribeOptions{
+		EmitPresence:   reply.Presence,
+		EmitJoinLeave:  reply.JoinLeave,
+		PushJoinLeave:  reply.JoinLeave,
+		EnableRecovery: reply.Recover,
+		Data:           reply.Data,
+	}
+	if g.historyChannels != nil {
+		if _, ok := g.historyChannels.Get(e.Channel); ok {
+			options.EnableRecovery = true
+			if since, ok := historySince(e.Data); ok {
+				sp, found, err := g.historyStorage.PositionAt(ctx, e.Channel, since)
+				if err != nil {
+					logger.Error("Error getting channel history position", "user", client.UserID(), "client", client.ID(), "channel", e.Channel, "error", err)
+					return centrifuge.SubscribeReply{}, centrifuge.ErrorInternal
+				}
+				if found {
+					// The data since the position is replayed, so only send the schema of the last frame.
+					options.RecoverSince = &sp
+					options.Data = frameSchema(options.Data)
+				}
+			}
+		}
+	}
 	logger.Debug("Client subscribed", "user", client.UserID(), "client", client.ID(), "channel", e.Channel)
 	return centrifuge.SubscribeReply{
-		Options: centrifuge.SubscribeOptions{
-			EmitPresence:   reply.Presence,
-			EmitJoinLeave:  reply.JoinLeave,
-			PushJoinLeave:  reply.JoinLeave,
-			EnableRecovery: reply.Recover,
-			Data:           reply.Data,
-		},
+		Options: options,
 	}, nil
 }
 
+// historySince returns the time to replay the history since, set in the subscribe
+// data as {"since": <epoch milliseconds>}.
+func historySince(subscribeData json.RawMessage) (time.Time, bool) {
+	if len(subscribeData) == 0 {
+		return time.Time{}, false
+	}
+	var req struct {
+		Since *int64 `json:"since"`
+	}
+	if err := json.Unmarshal(subscribeData, &req); err != nil || req.Since == nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(*req.Since), true
+}
+
+// frameSchema returns the schema of a JSON data frame, or the data as is when it's not a frame.
+func frameSchema(frameJSON json.RawMessage) json.RawMessage {
+	if len(frameJSON) == 0 {
+		return frameJSON
+	}
+	var frame data.Frame
+	if err := json.Unmarshal(frameJSON, &frame); err != nil || len(frame.Fields) == 0 {
+		return frameJSON
+	}
+	schema, err := data.FrameToJSON(&frame, data.IncludeSchemaOnly)
+	if err != nil {
+		return frameJSON
+	}
+	return schema
+}
+
 func (g *GrafanaLive) handleOnPublish(ctx context.Context, client *centrifuge.Client, e centrifuge.PublishEvent) (centrifuge.PublishReply, error) {
 	logger.Debug("Client wants to publish", "user", client.UserID(), "client", client.ID(), "channel", e.Channel)
 
@@ -50,11 +50,53 @@ type KeepFieldsFrameProcessorConfig struct {
 	FieldNames []string `json:"fieldNames"`
 }
 
+type MathFrameProcessorConfig struct {
+	// Expression uses the numeric fields as variables, e.g. "$temperature * 1.8 + 32" or "${room temperature} / 100".
+	Expression string            `json:"expression"`
+	FieldName  string            `json:"fieldName"`
+	Config     *data.FieldConfig `json:"config,omitempty" ts_type:"FieldConfig"`
+}
+
+type RenameFieldsFrameProcessorConfig struct {
+	// Names maps field names to their new names.
+	Names map[string]string `json:"names,omitempty"`
+	// Labels are set on all fields except the time fields, an empty value removes the label.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type ConvertUnitFrameProcessorConfig struct {
+	FieldName string `json:"fieldName"`
+	// From and To are Grafana unit IDs, e.g. "celsius" and "fahrenheit".
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type RateFrameProcessorConfig struct {
+	FieldName string `json:"fieldName"`
+	// RateFieldName is the name of the rate field, the field name with a "_rate" suffix by default.
+	RateFieldName string `json:"rateFieldName,omitempty"`
+}
+
+type DownsampleFrameProcessorConfig struct {
+	IntervalMilliseconds int64                 `json:"intervalMilliseconds"`
+	Aggregation          DownsampleAggregation `json:"aggregation,omitempty"`
+}
+
+type ThrottleFrameProcessorConfig struct {
+	IntervalMilliseconds int64 `json:"intervalMilliseconds"`
+}
+
 type FrameProcessorConfig struct {
-	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
-	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
-	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
-	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	Type                        string                            `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
+	DropFieldsProcessorConfig   *DropFieldsFrameProcessorConfig   `json:"dropFields,omitempty"`
+	KeepFieldsProcessorConfig   *KeepFieldsFrameProcessorConfig   `json:"keepFields,omitempty"`
+	MultipleProcessorConfig     *MultipleFrameProcessorConfig     `json:"multiple,omitempty"`
+	MathProcessorConfig         *MathFrameProcessorConfig         `json:"math,omitempty"`
+	RenameFieldsProcessorConfig *RenameFieldsFrameProcessorConfig `json:"renameFields,omitempty"`
+	ConvertUnitProcessorConfig  *ConvertUnitFrameProcessorConfig  `json:"convertUnit,omitempty"`
+	RateProcessorConfig         *RateFrameProcessorConfig         `json:"rate,omitempty"`
+	DownsampleProcessorConfig   *DownsampleFrameProcessorConfig   `json:"downsample,omitempty"`
+	ThrottleProcessorConfig     *ThrottleFrameProcessorConfig     `json:"throttle,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -109,6 +151,8 @@ type FrameOutputterConfig struct {
 	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
 	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
 	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	AnnotationOutputConfig  *AnnotationOutputConfig    `json:"annotation,omitempty"`
+	AlertOutputConfig       *AlertOutputConfig         `json:"alert,omitempty"`
 }
 
 type MultipleFrameConditionCheckerConfig struct {
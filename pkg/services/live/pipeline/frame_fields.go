@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// frameTimeField returns the index of the first time field of a frame, -1 if there is none.
+func frameTimeField(frame *data.Frame) int {
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			return i
+		}
+	}
+	return -1
+}
+
+func frameFieldIndex(frame *data.Frame, name string) int {
+	for i, field := range frame.Fields {
+		if field.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// timeAt returns the value of a time field, false for null values.
+func timeAt(field *data.Field, i int) (time.Time, bool) {
+	switch v := field.At(i).(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// setFrameField replaces the field with the same name, or appends the field to the frame.
+func setFrameField(frame *data.Frame, field *data.Field) {
+	if i := frameFieldIndex(frame, field.Name); i >= 0 {
+		frame.Fields[i] = field
+		return
+	}
+	frame.Fields = append(frame.Fields, field)
+}
+
+// lastFrameTime returns the last value of the time field of a frame, false for frames
+// without time field or rows.
+func lastFrameTime(frame *data.Frame) (time.Time, bool) {
+	timeIndex := frameTimeField(frame)
+	if timeIndex < 0 || frame.Rows() == 0 {
+		return time.Time{}, false
+	}
+	return timeAt(frame.Fields[timeIndex], frame.Rows()-1)
+}
+
+// lastFieldString returns the last value of a field formatted as a string, false when the
+// field is missing or the value is null.
+func lastFieldString(frame *data.Frame, name string) (string, bool) {
+	i := frameFieldIndex(frame, name)
+	if i < 0 || frame.Fields[i].Len() == 0 {
+		return "", false
+	}
+	field := frame.Fields[i]
+	value, ok := field.ConcreteAt(field.Len() - 1)
+	if !ok {
+		return "", false
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339), true
+	}
+	return fmt.Sprint(value), true
+}
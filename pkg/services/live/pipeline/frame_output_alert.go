@@ -0,0 +1,220 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Alert is an alert sent to the Alertmanager of an organization.
+type Alert struct {
+	Labels      map[string]string
+	Annotations map[string]string
+	StartsAt    time.Time
+	EndsAt      time.Time
+}
+
+// AlertSender sends alerts to the Alertmanager of an organization.
+type AlertSender interface {
+	SendAlerts(ctx context.Context, orgID int64, alerts []Alert) error
+}
+
+type AlertOutputConfig struct {
+	// Condition to fire the alert, the alert fires for every frame when not set.
+	Condition *FrameConditionCheckerConfig `json:"condition,omitempty"`
+	// AlertName is the alertname label of the alert.
+	AlertName string            `json:"alertName"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// LabelFields add a label for each field, named after the field.
+	LabelFields []string          `json:"labelFields,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// AnnotationFields add an annotation for each field, named after the field.
+	AnnotationFields []string `json:"annotationFields,omitempty"`
+	// TimeoutMilliseconds after which the Alertmanager resolves a firing alert that is not
+	// sent again, 5 minutes by default.
+	TimeoutMilliseconds int64 `json:"timeoutMilliseconds,omitempty"`
+}
+
+const defaultAlertTimeout = 5 * time.Minute
+
+// AlertFrameOutput can send alerts to the Grafana Alertmanager. Labels and annotations of
+// an alert are taken from the last row of the frame, so a channel can fire alerts with
+// different labels. A firing alert is sent again when half of its timeout has passed, and
+// is resolved with the first frame with the same labels for which the condition does not fire.
+type AlertFrameOutput struct {
+	sender       AlertSender
+	frameStorage FrameGetSetter
+	condition    FrameConditionChecker
+	config       AlertOutputConfig
+	timeout      time.Duration
+	now          func() time.Time
+}
+
+func NewAlertFrameOutput(sender AlertSender, frameStorage FrameGetSetter, condition FrameConditionChecker, config AlertOutputConfig) (*AlertFrameOutput, error) {
+	if config.AlertName == "" {
+		return nil, errors.New("alert output requires an alert name")
+	}
+	timeout := defaultAlertTimeout
+	if config.TimeoutMilliseconds > 0 {
+		timeout = time.Duration(config.TimeoutMilliseconds) * time.Millisecond
+	}
+	return &AlertFrameOutput{
+		sender:       sender,
+		frameStorage: frameStorage,
+		condition:    condition,
+		config:       config,
+		timeout:      timeout,
+		now:          time.Now,
+	}, nil
+}
+
+const FrameOutputTypeAlert = "alert"
+
+func (out *AlertFrameOutput) Type() string {
+	return FrameOutputTypeAlert
+}
+
+func (out *AlertFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.sender == nil {
+		return nil, errors.New("alertmanager is not available")
+	}
+	firing := true
+	if out.condition != nil {
+		var err error
+		firing, err = out.condition.CheckFrameCondition(ctx, frame)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	labels := out.labels(frame)
+	fingerprint := labelsFingerprint(labels)
+
+	// The firing alerts of the channel are kept with a key that can't be a channel.
+	key := "alert:" + vars.Channel
+	stateFrame, ok, err := out.frameStorage.Get(vars.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	var state []firingAlert
+	if ok {
+		state = readFiringAlerts(stateFrame)
+	}
+	index := -1
+	for i, a := range state {
+		if a.fingerprint == fingerprint {
+			index = i
+			break
+		}
+	}
+
+	now := out.now()
+	alert := Alert{Labels: labels, Annotations: out.annotations(frame), StartsAt: now}
+	switch {
+	case firing && index < 0:
+		alert.EndsAt = now.Add(out.timeout)
+		state = append(state, firingAlert{fingerprint: fingerprint, startsAt: now, sentAt: now})
+	case firing:
+		if now.Sub(state[index].sentAt) < out.timeout/2 {
+			return nil, nil
+		}
+		alert.StartsAt = state[index].startsAt
+		alert.EndsAt = now.Add(out.timeout)
+		state[index].sentAt = now
+	case index >= 0:
+		alert.StartsAt = state[index].startsAt
+		alert.EndsAt = now
+		state = append(state[:index], state[index+1:]...)
+	default:
+		return nil, nil
+	}
+
+	if err := out.sender.SendAlerts(ctx, vars.OrgID, []Alert{alert}); err != nil {
+		return nil, err
+	}
+	return nil, out.frameStorage.Set(vars.OrgID, key, firingAlertsFrame(state))
+}
+
+func (out *AlertFrameOutput) labels(frame *data.Frame) map[string]string {
+	labels := make(map[string]string, len(out.config.Labels)+len(out.config.LabelFields)+1)
+	for k, v := range out.config.Labels {
+		labels[k] = v
+	}
+	for _, name := range out.config.LabelFields {
+		if value, ok := lastFieldString(frame, name); ok {
+			labels[name] = value
+		}
+	}
+	labels["alertname"] = out.config.AlertName
+	return labels
+}
+
+func (out *AlertFrameOutput) annotations(frame *data.Frame) map[string]string {
+	annotations := make(map[string]string, len(out.config.Annotations)+len(out.config.AnnotationFields))
+	for k, v := range out.config.Annotations {
+		annotations[k] = v
+	}
+	for _, name := range out.config.AnnotationFields {
+		if value, ok := lastFieldString(frame, name); ok {
+			annotations[name] = value
+		}
+	}
+	return annotations
+}
+
+type firingAlert struct {
+	fingerprint string
+	startsAt    time.Time
+	sentAt      time.Time
+}
+
+// readFiringAlerts returns a copy of the firing alerts kept in a frame, stored frames are
+// never modified.
+func readFiringAlerts(frame *data.Frame) []firingAlert {
+	if len(frame.Fields) != 3 {
+		return nil
+	}
+	alerts := make([]firingAlert, 0, frame.Rows())
+	for i := 0; i < frame.Rows(); i++ {
+		fingerprint, _ := frame.Fields[0].At(i).(string)
+		startsAt, _ := frame.Fields[1].At(i).(time.Time)
+		sentAt, _ := frame.Fields[2].At(i).(time.Time)
+		alerts = append(alerts, firingAlert{fingerprint: fingerprint, startsAt: startsAt, sentAt: sentAt})
+	}
+	return alerts
+}
+
+func firingAlertsFrame(alerts []firingAlert) *data.Frame {
+	fingerprints := make([]string, len(alerts))
+	startsAt := make([]time.Time, len(alerts))
+	sentAt := make([]time.Time, len(alerts))
+	for i, a := range alerts {
+		fingerprints[i], startsAt[i], sentAt[i] = a.fingerprint, a.startsAt, a.sentAt
+	}
+	return data.NewFrame("",
+		data.NewField("fingerprint", nil, fingerprints),
+		data.NewField("startsAt", nil, startsAt),
+		data.NewField("sentAt", nil, sentAt),
+	)
+}
+
+// labelsFingerprint returns a string identifying a label set.
+func labelsFingerprint(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteByte(0)
+		sb.WriteString(labels[name])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
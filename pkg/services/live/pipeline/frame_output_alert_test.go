@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testAlertSender struct {
+	alerts []Alert
+}
+
+func (s *testAlertSender) SendAlerts(_ context.Context, orgID int64, alerts []Alert) error {
+	s.alerts = append(s.alerts, alerts...)
+	return nil
+}
+
+func TestAlertFrameOutput(t *testing.T) {
+	sender := &testAlertSender{}
+	condition := NewFrameNumberCompareCondition("failed", NumberCompareOpGt, 0)
+	out, err := NewAlertFrameOutput(sender, NewFrameStorage(), condition, AlertOutputConfig{
+		AlertName:           "DeployFailed",
+		Labels:              map[string]string{"team": "platform"},
+		LabelFields:         []string{"service"},
+		Annotations:         map[string]string{"summary": "deploy failed"},
+		TimeoutMilliseconds: 60000,
+	})
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	out.now = func() time.Time { return now }
+	vars := Vars{OrgID: 1, Channel: "stream/deploy/events"}
+
+	output := func(service string, failed float64) {
+		_, err := out.OutputFrame(context.Background(), vars, deployFrame(now, service, failed))
+		require.NoError(t, err)
+	}
+
+	output("api", 0)
+	require.Empty(t, sender.alerts, "the condition does not fire")
+
+	output("api", 1)
+	require.Equal(t, []Alert{{
+		Labels:      map[string]string{"alertname": "DeployFailed", "team": "platform", "service": "api"},
+		Annotations: map[string]string{"summary": "deploy failed"},
+		StartsAt:    now,
+		EndsAt:      now.Add(time.Minute),
+	}}, sender.alerts)
+
+	// The firing alert is sent again when half of the timeout has passed.
+	now = now.Add(10 * time.Second)
+	output("api", 1)
+	require.Len(t, sender.alerts, 1)
+	now = now.Add(20 * time.Second)
+	output("api", 1)
+	require.Len(t, sender.alerts, 2)
+	require.Equal(t, time.Unix(1000, 0), sender.alerts[1].StartsAt)
+	require.Equal(t, now.Add(time.Minute), sender.alerts[1].EndsAt)
+
+	// Alerts with other labels are independent.
+	output("web", 0)
+	require.Len(t, sender.alerts, 2)
+
+	output("api", 0)
+	require.Len(t, sender.alerts, 3)
+	require.Equal(t, time.Unix(1000, 0), sender.alerts[2].StartsAt)
+	require.Equal(t, now, sender.alerts[2].EndsAt)
+
+	output("api", 0)
+	require.Len(t, sender.alerts, 3, "the alert is already resolved")
+}
+
+func TestAlertFrameOutput_RequiresAlertName(t *testing.T) {
+	_, err := NewAlertFrameOutput(&testAlertSender{}, NewFrameStorage(), nil, AlertOutputConfig{})
+	require.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+type AnnotationOutputConfig struct {
+	// Condition to create an annotation, an annotation is created for every frame when not set.
+	Condition *FrameConditionCheckerConfig `json:"condition,omitempty"`
+	// DashboardUID and PanelID of the annotation, organization annotations are created when not set.
+	DashboardUID string `json:"dashboardUID,omitempty"`
+	PanelID      int64  `json:"panelId,omitempty"`
+	// Text of the annotation, the value of TextField is used when set.
+	Text      string   `json:"text,omitempty"`
+	TextField string   `json:"textField,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	// TagFields add a "name:value" tag for each field.
+	TagFields []string `json:"tagFields,omitempty"`
+	// Region creates one annotation for the time the condition fires instead of one for every frame.
+	Region bool `json:"region,omitempty"`
+}
+
+// DashboardGetter returns the dashboards of the annotations.
+type DashboardGetter interface {
+	GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error)
+}
+
+// AnnotationFrameOutput can create Grafana annotations from frames. The time of an
+// annotation is the last value of the time field of the frame, or the processing time
+// for frames without time field. Text and tags are taken from the last row.
+type AnnotationFrameOutput struct {
+	repo         annotations.Repository
+	dashboards   DashboardGetter
+	frameStorage FrameGetSetter
+	condition    FrameConditionChecker
+	config       AnnotationOutputConfig
+	now          func() time.Time
+
+	mu sync.Mutex
+	// dashboardIDs are the IDs of the dashboard by organization, annotations are still saved with the ID.
+	dashboardIDs map[int64]int64
+}
+
+func NewAnnotationFrameOutput(repo annotations.Repository, dashboardGetter DashboardGetter, frameStorage FrameGetSetter, condition FrameConditionChecker, config AnnotationOutputConfig) *AnnotationFrameOutput {
+	return &AnnotationFrameOutput{
+		repo:         repo,
+		dashboards:   dashboardGetter,
+		frameStorage: frameStorage,
+		condition:    condition,
+		config:       config,
+		now:          time.Now,
+		dashboardIDs: map[int64]int64{},
+	}
+}
+
+const FrameOutputTypeAnnotation = "annotation"
+
+func (out *AnnotationFrameOutput) Type() string {
+	return FrameOutputTypeAnnotation
+}
+
+func (out *AnnotationFrameOutput) OutputFrame(ctx context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.repo == nil {
+		return nil, errors.New("annotations are not available")
+	}
+	firing := true
+	if out.condition != nil {
+		var err error
+		firing, err = out.condition.CheckFrameCondition(ctx, frame)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t, ok := lastFrameTime(frame)
+	if !ok {
+		t = out.now()
+	}
+	item, err := out.item(ctx, vars, frame, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if !out.config.Region {
+		if !firing {
+			return nil, nil
+		}
+		return nil, out.repo.Save(ctx, item)
+	}
+
+	// The region being annotated is kept with a key that can't be a channel.
+	key := "annotation:" + vars.Channel
+	region, ok, err := out.frameStorage.Get(vars.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	active := ok && region.Rows() == 1
+	switch {
+	case firing && !active:
+		if err := out.repo.Save(ctx, item); err != nil {
+			return nil, err
+		}
+		return nil, out.setRegion(vars.OrgID, key, item)
+	case active:
+		item.ID, _ = region.Fields[0].At(0).(int64)
+		item.Epoch, _ = region.Fields[1].At(0).(int64)
+		text, _ := region.Fields[2].At(0).(string)
+		tags, _ := region.Fields[3].At(0).(string)
+		if firing {
+			// The region is only updated when its text or tags change, it is extended when it ends.
+			if text == item.Text && tags == encodeAnnotationTags(item.Tags) {
+				return nil, nil
+			}
+			if err := out.repo.Update(ctx, item); err != nil {
+				return nil, err
+			}
+			return nil, out.setRegion(vars.OrgID, key, item)
+		}
+		// The region ends with the first frame for which the condition does not fire, and keeps the
+		// text and tags of the last frame for which it did.
+		item.Text = text
+		item.Tags = nil
+		if err := json.Unmarshal([]byte(tags), &item.Tags); err != nil {
+			return nil, err
+		}
+		if err := out.repo.Update(ctx, item); err != nil {
+			return nil, err
+		}
+		return nil, out.frameStorage.Set(vars.OrgID, key, data.NewFrame(""))
+	}
+	return nil, nil
+}
+
+func (out *AnnotationFrameOutput) setRegion(orgID int64, key string, item *annotations.Item) error {
+	return out.frameStorage.Set(orgID, key, data.NewFrame("",
+		data.NewField("id", nil, []int64{item.ID}),
+		data.NewField("epoch", nil, []int64{item.Epoch}),
+		data.NewField("text", nil, []string{item.Text}),
+		data.NewField("tags", nil, []string{encodeAnnotationTags(item.Tags)}),
+	))
+}
+
+func encodeAnnotationTags(tags []string) string {
+	// Marshalling strings can't fail.
+	b, _ := json.Marshal(tags)
+	return string(b)
+}
+
+// dashboardID returns the ID of the dashboard of the annotations in an organization.
+func (out *AnnotationFrameOutput) dashboardID(ctx context.Context, orgID int64) (int64, error) {
+	if out.config.DashboardUID == "" {
+		return 0, nil
+	}
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if id, ok := out.dashboardIDs[orgID]; ok {
+		return id, nil
+	}
+	if out.dashboards == nil {
+		return 0, errors.New("dashboards are not available")
+	}
+	dashboard, err := out.dashboards.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: out.config.DashboardUID, OrgID: orgID})
+	if err != nil {
+		return 0, fmt.Errorf("error getting dashboard %s: %w", out.config.DashboardUID, err)
+	}
+	out.dashboardIDs[orgID] = dashboard.ID
+	return dashboard.ID, nil
+}
+
+func (out *AnnotationFrameOutput) item(ctx context.Context, vars Vars, frame *data.Frame, t time.Time) (*annotations.Item, error) {
+	dashboardID, err := out.dashboardID(ctx, vars.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	text := out.config.Text
+	if out.config.TextField != "" {
+		if value, ok := lastFieldString(frame, out.config.TextField); ok {
+			text = value
+		}
+	}
+	tags := make([]string, 0, len(out.config.Tags)+len(out.config.TagFields))
+	tags = append(tags, out.config.Tags...)
+	for _, name := range out.config.TagFields {
+		if value, ok := lastFieldString(frame, name); ok {
+			tags = append(tags, name+":"+value)
+		}
+	}
+	epoch := t.UnixMilli()
+	return &annotations.Item{
+		OrgID:       vars.OrgID,
+		DashboardID: dashboardID,
+		PanelID:     out.config.PanelID,
+		Text:        text,
+		Tags:        tags,
+		Epoch:       epoch,
+		EpochEnd:    epoch,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+type testAnnotationsRepo struct {
+	annotations.Repository
+	items   map[int64]annotations.Item
+	updates int
+}
+
+func newTestAnnotationsRepo() *testAnnotationsRepo {
+	return &testAnnotationsRepo{items: map[int64]annotations.Item{}}
+}
+
+func (r *testAnnotationsRepo) Save(_ context.Context, item *annotations.Item) error {
+	item.ID = int64(len(r.items) + 1)
+	r.items[item.ID] = *item
+	return nil
+}
+
+func (r *testAnnotationsRepo) Update(_ context.Context, item *annotations.Item) error {
+	r.items[item.ID] = *item
+	r.updates++
+	return nil
+}
+
+type testDashboardGetter struct {
+	queries int
+}
+
+func (g *testDashboardGetter) GetDashboard(_ context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+	g.queries++
+	if query.UID != "deploys" || query.OrgID != 1 {
+		return nil, dashboards.ErrDashboardNotFound
+	}
+	return &dashboards.Dashboard{ID: 2, UID: "deploys", OrgID: 1}, nil
+}
+
+func deployFrame(t time.Time, service string, failed float64) *data.Frame {
+	return data.NewFrame("deploy",
+		data.NewField("time", nil, []time.Time{t}),
+		data.NewField("service", nil, []string{service}),
+		data.NewField("failed", nil, []*float64{&failed}),
+	)
+}
+
+func TestAnnotationFrameOutput(t *testing.T) {
+	repo := newTestAnnotationsRepo()
+	dashboardGetter := &testDashboardGetter{}
+	out := NewAnnotationFrameOutput(repo, dashboardGetter, NewFrameStorage(), nil, AnnotationOutputConfig{
+		DashboardUID: "deploys",
+		TextField:    "service",
+		Tags:         []string{"deploy"},
+		TagFields:    []string{"service"},
+	})
+	vars := Vars{OrgID: 1, Channel: "stream/deploy/events"}
+	start := time.Unix(1000, 0)
+
+	for i, service := range []string{"api", "web"} {
+		_, err := out.OutputFrame(context.Background(), vars, deployFrame(start.Add(time.Duration(i)*time.Second), service, 0))
+		require.NoError(t, err)
+	}
+	require.Len(t, repo.items, 2)
+	require.Equal(t, annotations.Item{
+		ID:          2,
+		OrgID:       1,
+		DashboardID: 2,
+		Text:        "web",
+		Tags:        []string{"deploy", "service:web"},
+		Epoch:       1001000,
+		EpochEnd:    1001000,
+	}, repo.items[2])
+	require.Equal(t, 1, dashboardGetter.queries, "the dashboard ID is cached")
+}
+
+func TestAnnotationFrameOutput_DashboardNotFound(t *testing.T) {
+	repo := newTestAnnotationsRepo()
+	out := NewAnnotationFrameOutput(repo, &testDashboardGetter{}, NewFrameStorage(), nil, AnnotationOutputConfig{DashboardUID: "unknown"})
+	_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1}, deployFrame(time.Unix(1000, 0), "api", 0))
+	require.ErrorIs(t, err, dashboards.ErrDashboardNotFound)
+	require.Empty(t, repo.items)
+}
+
+func TestAnnotationFrameOutput_Region(t *testing.T) {
+	repo := newTestAnnotationsRepo()
+	condition := NewFrameNumberCompareCondition("failed", NumberCompareOpGt, 0)
+	out := NewAnnotationFrameOutput(repo, nil, NewFrameStorage(), condition, AnnotationOutputConfig{
+		TextField: "service",
+		Tags:      []string{"deploy"},
+		Region:    true,
+	})
+	vars := Vars{OrgID: 1, Channel: "stream/deploy/events"}
+	start := time.Unix(1000, 0)
+
+	output := func(offset time.Duration, service string, failed float64) {
+		_, err := out.OutputFrame(context.Background(), vars, deployFrame(start.Add(offset), service, failed))
+		require.NoError(t, err)
+	}
+
+	output(0, "api", 0)
+	require.Empty(t, repo.items, "the condition does not fire")
+
+	output(time.Second, "api", 1)
+	output(2*time.Second, "api", 1)
+	require.Len(t, repo.items, 1)
+	require.Zero(t, repo.updates, "the region is not updated while it does not change")
+	require.Equal(t, int64(1001000), repo.items[1].Epoch)
+
+	// The region is updated when its text changes.
+	output(3*time.Second, "web", 1)
+	require.Equal(t, 1, repo.updates)
+	require.Equal(t, "web", repo.items[1].Text)
+	require.Equal(t, int64(1003000), repo.items[1].EpochEnd)
+
+	// The region ends with the first frame for which the condition does not fire.
+	output(4*time.Second, "db", 0)
+	output(5*time.Second, "db", 0)
+	require.Len(t, repo.items, 1)
+	require.Equal(t, 2, repo.updates)
+	require.Equal(t, annotations.Item{
+		ID:       1,
+		OrgID:    1,
+		Text:     "web",
+		Tags:     []string{"deploy"},
+		Epoch:    1001000,
+		EpochEnd: 1004000,
+	}, repo.items[1])
+
+	output(6*time.Second, "api", 1)
+	require.Len(t, repo.items, 2)
+	require.Equal(t, "api", repo.items[2].Text)
+}
+
+func TestAnnotationFrameOutput_NoRepository(t *testing.T) {
+	out := NewAnnotationFrameOutput(nil, nil, NewFrameStorage(), nil, AnnotationOutputConfig{})
+	_, err := out.OutputFrame(context.Background(), Vars{OrgID: 1}, deployFrame(time.Unix(1000, 0), "api", 0))
+	require.Error(t, err)
+}
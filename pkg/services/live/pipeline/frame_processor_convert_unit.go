@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// unitScale converts a value of a unit to the base unit of its dimension: base = value*factor + offset.
+type unitScale struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+// convertibleUnits are the units supported by the convertUnit processor, by Grafana unit ID.
+var convertibleUnits = map[string]unitScale{
+	// Temperature, in kelvin.
+	"kelvin":     {dimension: "temperature", factor: 1},
+	"celsius":    {dimension: "temperature", factor: 1, offset: 273.15},
+	"fahrenheit": {dimension: "temperature", factor: 5.0 / 9.0, offset: 459.67 * 5.0 / 9.0},
+
+	// Time, in seconds.
+	"ns": {dimension: "time", factor: 1e-9},
+	"µs": {dimension: "time", factor: 1e-6},
+	"ms": {dimension: "time", factor: 1e-3},
+	"s":  {dimension: "time", factor: 1},
+	"m":  {dimension: "time", factor: 60},
+	"h":  {dimension: "time", factor: 3600},
+	"d":  {dimension: "time", factor: 86400},
+
+	// Length, in meters.
+	"lengthmm": {dimension: "length", factor: 1e-3},
+	"lengthm":  {dimension: "length", factor: 1},
+	"lengthkm": {dimension: "length", factor: 1e3},
+	"lengthft": {dimension: "length", factor: 0.3048},
+	"lengthmi": {dimension: "length", factor: 1609.344},
+
+	// Speed, in meters per second.
+	"velocityms":   {dimension: "speed", factor: 1},
+	"velocitykmh":  {dimension: "speed", factor: 1e3 / 3600},
+	"velocitymph":  {dimension: "speed", factor: 1609.344 / 3600},
+	"velocityknot": {dimension: "speed", factor: 1852.0 / 3600},
+
+	// Pressure, in pascals.
+	"pressurepa":   {dimension: "pressure", factor: 1},
+	"pressurehpa":  {dimension: "pressure", factor: 1e2},
+	"pressurekpa":  {dimension: "pressure", factor: 1e3},
+	"pressurembar": {dimension: "pressure", factor: 1e2},
+	"pressurebar":  {dimension: "pressure", factor: 1e5},
+	"pressurepsi":  {dimension: "pressure", factor: 6894.757293168},
+
+	// Data, in bytes.
+	"bits":   {dimension: "data", factor: 1.0 / 8},
+	"bytes":  {dimension: "data", factor: 1},
+	"kbytes": {dimension: "data", factor: 1 << 10},
+	"mbytes": {dimension: "data", factor: 1 << 20},
+	"gbytes": {dimension: "data", factor: 1 << 30},
+
+	// Ratio, as a fraction.
+	"percentunit": {dimension: "ratio", factor: 1},
+	"percent":     {dimension: "ratio", factor: 1e-2},
+}
+
+// ConvertUnitFrameProcessor can convert the values of a numeric field to another unit. The
+// converted values are float64 and the unit of the field config is set to the new unit.
+type ConvertUnitFrameProcessor struct {
+	config ConvertUnitFrameProcessorConfig
+	from   unitScale
+	to     unitScale
+}
+
+func NewConvertUnitFrameProcessor(config ConvertUnitFrameProcessorConfig) (*ConvertUnitFrameProcessor, error) {
+	from, ok := convertibleUnits[config.From]
+	if !ok {
+		return nil, fmt.Errorf("unsupported unit: %s", config.From)
+	}
+	to, ok := convertibleUnits[config.To]
+	if !ok {
+		return nil, fmt.Errorf("unsupported unit: %s", config.To)
+	}
+	if from.dimension != to.dimension {
+		return nil, fmt.Errorf("can't convert %s to %s", config.From, config.To)
+	}
+	return &ConvertUnitFrameProcessor{config: config, from: from, to: to}, nil
+}
+
+const FrameProcessorTypeConvertUnit = "convertUnit"
+
+func (p *ConvertUnitFrameProcessor) Type() string {
+	return FrameProcessorTypeConvertUnit
+}
+
+func (p *ConvertUnitFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	i := frameFieldIndex(frame, p.config.FieldName)
+	if i < 0 {
+		return frame, nil
+	}
+	field := frame.Fields[i]
+	if !field.Type().Numeric() {
+		return nil, fmt.Errorf("can't convert the unit of field %s: not numeric", field.Name)
+	}
+
+	values := make([]*float64, field.Len())
+	for row := range values {
+		value, err := field.NullableFloatAt(row)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			converted := p.convert(*value)
+			values[row] = &converted
+		}
+	}
+
+	converted := data.NewField(field.Name, field.Labels, values)
+	config := data.FieldConfig{}
+	if field.Config != nil {
+		config = *field.Config
+	}
+	config.Unit = p.config.To
+	converted.Config = &config
+	frame.Fields[i] = converted
+	return frame, nil
+}
+
+func (p *ConvertUnitFrameProcessor) convert(value float64) float64 {
+	base := value*p.from.factor + p.from.offset
+	return (base - p.to.offset) / p.to.factor
+}
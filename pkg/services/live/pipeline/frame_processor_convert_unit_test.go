@@ -0,0 +1,46 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertUnitFrameProcessor(t *testing.T) {
+	tests := []struct {
+		from, to string
+		value    float64
+		expected float64
+	}{
+		{from: "celsius", to: "fahrenheit", value: 100, expected: 212},
+		{from: "fahrenheit", to: "celsius", value: 32, expected: 0},
+		{from: "celsius", to: "kelvin", value: 0, expected: 273.15},
+		{from: "ms", to: "s", value: 1500, expected: 1.5},
+		{from: "velocitykmh", to: "velocityms", value: 36, expected: 10},
+		{from: "kbytes", to: "bytes", value: 2, expected: 2048},
+		{from: "percentunit", to: "percent", value: 0.25, expected: 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			processor, err := NewConvertUnitFrameProcessor(ConvertUnitFrameProcessorConfig{FieldName: "value", From: tt.from, To: tt.to})
+			require.NoError(t, err)
+			frame := data.NewFrame("test", data.NewField("value", nil, []*float64{&tt.value, nil}))
+			frame, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+			require.NoError(t, err)
+			value, ok := frame.Fields[0].At(0).(*float64)
+			require.True(t, ok)
+			require.InDelta(t, tt.expected, *value, 1e-9)
+			require.Nil(t, frame.Fields[0].At(1))
+			require.Equal(t, tt.to, frame.Fields[0].Config.Unit)
+		})
+	}
+}
+
+func TestConvertUnitFrameProcessor_InvalidConfig(t *testing.T) {
+	_, err := NewConvertUnitFrameProcessor(ConvertUnitFrameProcessorConfig{FieldName: "value", From: "celsius", To: "unknown"})
+	require.Error(t, err)
+	_, err = NewConvertUnitFrameProcessor(ConvertUnitFrameProcessorConfig{FieldName: "value", From: "celsius", To: "ms"})
+	require.Error(t, err)
+}
@@ -0,0 +1,242 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type DownsampleAggregation string
+
+const (
+	DownsampleAggregationMean  DownsampleAggregation = "mean"
+	DownsampleAggregationMin   DownsampleAggregation = "min"
+	DownsampleAggregationMax   DownsampleAggregation = "max"
+	DownsampleAggregationSum   DownsampleAggregation = "sum"
+	DownsampleAggregationFirst DownsampleAggregation = "first"
+	DownsampleAggregationLast  DownsampleAggregation = "last"
+)
+
+// DownsampleFrameProcessor can aggregate the rows of a channel to one row per interval. The rows
+// of the current interval are kept until a row of a following interval is received, then the
+// numeric fields are aggregated and the other fields keep their last value. Frames that don't
+// complete an interval are dropped.
+type DownsampleFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       DownsampleFrameProcessorConfig
+	interval     time.Duration
+}
+
+func NewDownsampleFrameProcessor(frameStorage FrameGetSetter, config DownsampleFrameProcessorConfig) (*DownsampleFrameProcessor, error) {
+	if config.IntervalMilliseconds <= 0 {
+		return nil, errors.New("downsample interval must be positive")
+	}
+	switch config.Aggregation {
+	case "":
+		config.Aggregation = DownsampleAggregationMean
+	case DownsampleAggregationMean, DownsampleAggregationMin, DownsampleAggregationMax,
+		DownsampleAggregationSum, DownsampleAggregationFirst, DownsampleAggregationLast:
+	default:
+		return nil, fmt.Errorf("unknown downsample aggregation: %s", config.Aggregation)
+	}
+	return &DownsampleFrameProcessor{
+		frameStorage: frameStorage,
+		config:       config,
+		interval:     time.Duration(config.IntervalMilliseconds) * time.Millisecond,
+	}, nil
+}
+
+const FrameProcessorTypeDownsample = "downsample"
+
+func (p *DownsampleFrameProcessor) Type() string {
+	return FrameProcessorTypeDownsample
+}
+
+func (p *DownsampleFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	timeIndex := frameTimeField(frame)
+	if timeIndex < 0 {
+		return nil, errors.New("downsample processor requires a time field")
+	}
+
+	// The rows of the current interval are kept with a key that can't be a channel.
+	key := "downsample:" + vars.Channel
+	pending, ok, err := p.frameStorage.Get(vars.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || !sameFrameSchema(pending, frame) {
+		pending = frame.EmptyCopy()
+	}
+	var pendingInterval time.Time
+	if pending.Rows() > 0 {
+		if t, ok := timeAt(pending.Fields[timeIndex], 0); ok {
+			pendingInterval = t.Truncate(p.interval)
+		}
+	}
+
+	var out *data.Frame
+	rows := frame.Rows()
+	for row := 0; row < rows; row++ {
+		t, ok := timeAt(frame.Fields[timeIndex], row)
+		if !ok {
+			continue
+		}
+		interval := t.Truncate(p.interval)
+		if pending.Rows() > 0 && interval.After(pendingInterval) {
+			if out == nil {
+				out = downsampledFrame(frame, timeIndex)
+			}
+			out.AppendRow(p.aggregate(pending, timeIndex, pendingInterval)...)
+			pending = frame.EmptyCopy()
+		}
+		if pending.Rows() == 0 {
+			pendingInterval = interval
+		}
+		pending.AppendRow(frame.RowCopy(row)...)
+	}
+
+	if err := p.frameStorage.Set(vars.OrgID, key, pending); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// aggregate returns the row of an interval.
+func (p *DownsampleFrameProcessor) aggregate(frame *data.Frame, timeIndex int, interval time.Time) []any {
+	row := make([]any, len(frame.Fields))
+	for i, field := range frame.Fields {
+		switch {
+		case i == timeIndex:
+			if field.Type() == data.FieldTypeNullableTime {
+				row[i] = &interval
+			} else {
+				row[i] = interval
+			}
+		case field.Type().Numeric():
+			row[i] = aggregateField(field, p.config.Aggregation)
+		default:
+			row[i] = field.CopyAt(field.Len() - 1)
+		}
+	}
+	return row
+}
+
+func aggregateField(field *data.Field, aggregation DownsampleAggregation) *float64 {
+	var result float64
+	count := 0
+	for i := 0; i < field.Len(); i++ {
+		value, err := field.NullableFloatAt(i)
+		if err != nil || value == nil {
+			continue
+		}
+		v := *value
+		switch {
+		case count == 0:
+			result = v
+		case aggregation == DownsampleAggregationMean || aggregation == DownsampleAggregationSum:
+			result += v
+		case aggregation == DownsampleAggregationMin:
+			result = math.Min(result, v)
+		case aggregation == DownsampleAggregationMax:
+			result = math.Max(result, v)
+		case aggregation == DownsampleAggregationLast:
+			result = v
+		}
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	if aggregation == DownsampleAggregationMean {
+		result /= float64(count)
+	}
+	return &result
+}
+
+// downsampledFrame returns an empty frame with the schema of the aggregated rows: the numeric
+// fields are float64.
+func downsampledFrame(frame *data.Frame, timeIndex int) *data.Frame {
+	out := data.NewFrame(frame.Name)
+	out.Meta = frame.Meta
+	for i, field := range frame.Fields {
+		var f *data.Field
+		if i != timeIndex && field.Type().Numeric() {
+			f = data.NewField(field.Name, field.Labels, []*float64{})
+		} else {
+			f = data.NewFieldFromFieldType(field.Type(), 0)
+			f.Name = field.Name
+			f.Labels = field.Labels
+		}
+		f.Config = field.Config
+		out.Fields = append(out.Fields, f)
+	}
+	return out
+}
+
+func sameFrameSchema(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
+
+// ThrottleFrameProcessor can drop the frames of a channel received less than an interval
+// after the last frame it let through. The time of a frame is the last value of its time
+// field, or the processing time for frames without time field.
+type ThrottleFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       ThrottleFrameProcessorConfig
+	interval     time.Duration
+	now          func() time.Time
+}
+
+func NewThrottleFrameProcessor(frameStorage FrameGetSetter, config ThrottleFrameProcessorConfig) (*ThrottleFrameProcessor, error) {
+	if config.IntervalMilliseconds <= 0 {
+		return nil, errors.New("throttle interval must be positive")
+	}
+	return &ThrottleFrameProcessor{
+		frameStorage: frameStorage,
+		config:       config,
+		interval:     time.Duration(config.IntervalMilliseconds) * time.Millisecond,
+		now:          time.Now,
+	}, nil
+}
+
+const FrameProcessorTypeThrottle = "throttle"
+
+func (p *ThrottleFrameProcessor) Type() string {
+	return FrameProcessorTypeThrottle
+}
+
+func (p *ThrottleFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	t, ok := lastFrameTime(frame)
+	if !ok {
+		t = p.now()
+	}
+
+	// The time of the last frame let through is kept with a key that can't be a channel.
+	key := "throttle:" + vars.Channel
+	last, ok, err := p.frameStorage.Get(vars.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	if ok && last.Rows() == 1 {
+		// Frames older than the last one are let through, e.g. when the clock of the source was reset.
+		if lastTime, ok := timeAt(last.Fields[0], 0); ok && !t.Before(lastTime) && t.Sub(lastTime) < p.interval {
+			return nil, nil
+		}
+	}
+	if err := p.frameStorage.Set(vars.OrgID, key, data.NewFrame("", data.NewField("time", nil, []time.Time{t}))); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
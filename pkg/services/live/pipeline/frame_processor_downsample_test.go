@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsampleFrameProcessor(t *testing.T) {
+	processor, err := NewDownsampleFrameProcessor(NewFrameStorage(), DownsampleFrameProcessorConfig{IntervalMilliseconds: 1000})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/downsample"}
+	start := time.Unix(1000, 0)
+
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start, start.Add(300 * time.Millisecond), start.Add(600 * time.Millisecond)}),
+		data.NewField("value", nil, []float64{1, 2, 6}),
+		data.NewField("state", nil, []string{"a", "b", "c"}),
+	)
+	out, err := processor.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Nil(t, out, "the interval is not complete")
+
+	frame = data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start.Add(1100 * time.Millisecond), start.Add(2100 * time.Millisecond)}),
+		data.NewField("value", nil, []float64{10, 20}),
+		data.NewField("state", nil, []string{"d", "e"}),
+	)
+	out, err = processor.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	require.Equal(t, 2, out.Rows())
+	require.Equal(t, start, out.Fields[0].At(0))
+	require.Equal(t, float64Ptr(3), out.Fields[1].At(0))
+	require.Equal(t, "c", out.Fields[2].At(0))
+	require.Equal(t, start.Add(time.Second), out.Fields[0].At(1))
+	require.Equal(t, float64Ptr(10), out.Fields[1].At(1))
+	require.Equal(t, "d", out.Fields[2].At(1))
+}
+
+func TestDownsampleFrameProcessor_Aggregations(t *testing.T) {
+	start := time.Unix(1000, 0)
+	values := []*float64{float64Ptr(4), nil, float64Ptr(1), float64Ptr(7)}
+	expected := map[DownsampleAggregation]*float64{
+		DownsampleAggregationMean:  float64Ptr(4),
+		DownsampleAggregationMin:   float64Ptr(1),
+		DownsampleAggregationMax:   float64Ptr(7),
+		DownsampleAggregationSum:   float64Ptr(12),
+		DownsampleAggregationFirst: float64Ptr(4),
+		DownsampleAggregationLast:  float64Ptr(7),
+	}
+	for aggregation, value := range expected {
+		t.Run(string(aggregation), func(t *testing.T) {
+			processor, err := NewDownsampleFrameProcessor(NewFrameStorage(), DownsampleFrameProcessorConfig{IntervalMilliseconds: 1000, Aggregation: aggregation})
+			require.NoError(t, err)
+			times := []time.Time{start, start.Add(100 * time.Millisecond), start.Add(200 * time.Millisecond), start.Add(300 * time.Millisecond), start.Add(time.Second)}
+			frame := data.NewFrame("test",
+				data.NewField("time", nil, times),
+				data.NewField("value", nil, append(values, float64Ptr(100))),
+			)
+			out, err := processor.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/downsample"}, frame)
+			require.NoError(t, err)
+			require.Equal(t, 1, out.Rows())
+			require.Equal(t, value, out.Fields[1].At(0))
+		})
+	}
+
+	_, err := NewDownsampleFrameProcessor(NewFrameStorage(), DownsampleFrameProcessorConfig{IntervalMilliseconds: 1000, Aggregation: "median"})
+	require.Error(t, err)
+	_, err = NewDownsampleFrameProcessor(NewFrameStorage(), DownsampleFrameProcessorConfig{})
+	require.Error(t, err)
+}
+
+func TestThrottleFrameProcessor(t *testing.T) {
+	processor, err := NewThrottleFrameProcessor(NewFrameStorage(), ThrottleFrameProcessorConfig{IntervalMilliseconds: 1000})
+	require.NoError(t, err)
+	vars := Vars{OrgID: 1, Channel: "stream/test/throttle"}
+	start := time.Unix(1000, 0)
+
+	for _, tc := range []struct {
+		offset  time.Duration
+		allowed bool
+	}{
+		{offset: 0, allowed: true},
+		{offset: 100 * time.Millisecond, allowed: false},
+		{offset: 999 * time.Millisecond, allowed: false},
+		{offset: time.Second, allowed: true},
+		{offset: 1500 * time.Millisecond, allowed: false},
+		{offset: 2500 * time.Millisecond, allowed: true},
+	} {
+		frame := data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{start.Add(tc.offset)}),
+			data.NewField("value", nil, []float64{1}),
+		)
+		out, err := processor.ProcessFrame(context.Background(), vars, frame)
+		require.NoError(t, err)
+		require.Equal(t, tc.allowed, out != nil, "frame at %s", tc.offset)
+	}
+}
+
+func TestThrottleFrameProcessor_ProcessingTime(t *testing.T) {
+	processor, err := NewThrottleFrameProcessor(NewFrameStorage(), ThrottleFrameProcessorConfig{IntervalMilliseconds: 1000})
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	processor.now = func() time.Time { return now }
+	vars := Vars{OrgID: 1, Channel: "stream/test/throttle"}
+
+	frame := func() *data.Frame {
+		return data.NewFrame("test", data.NewField("value", nil, []float64{1}))
+	}
+	out, err := processor.ProcessFrame(context.Background(), vars, frame())
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	out, err = processor.ProcessFrame(context.Background(), vars, frame())
+	require.NoError(t, err)
+	require.Nil(t, out)
+	now = now.Add(time.Second)
+	out, err = processor.ProcessFrame(context.Background(), vars, frame())
+	require.NoError(t, err)
+	require.NotNil(t, out)
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+// MathFrameProcessor adds a field computed from the other fields of a data.Frame
+// with a math expression, evaluated for every row.
+type MathFrameProcessor struct {
+	config MathFrameProcessorConfig
+	expr   *mathexp.Expr
+}
+
+var mathTracer = tracing.NewNoopTracerService()
+
+func NewMathFrameProcessor(config MathFrameProcessorConfig) (*MathFrameProcessor, error) {
+	if config.FieldName == "" {
+		return nil, errors.New("math processor requires a field name")
+	}
+	expr, err := mathexp.New(config.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid math expression: %w", err)
+	}
+	return &MathFrameProcessor{config: config, expr: expr}, nil
+}
+
+const FrameProcessorTypeMath = "math"
+
+func (p *MathFrameProcessor) Type() string {
+	return FrameProcessorTypeMath
+}
+
+func (p *MathFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	fields := make(map[string]*data.Field, len(p.expr.VarNames))
+	for _, name := range p.expr.VarNames {
+		i := frameFieldIndex(frame, name)
+		if i < 0 {
+			return nil, fmt.Errorf("field %s of the math expression not found", name)
+		}
+		if !frame.Fields[i].Type().Numeric() {
+			return nil, fmt.Errorf("field %s of the math expression is not numeric", name)
+		}
+		fields[name] = frame.Fields[i]
+	}
+
+	rows, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*float64, rows)
+	for row := 0; row < rows; row++ {
+		vars := make(mathexp.Vars, len(fields))
+		for name, field := range fields {
+			value, err := field.NullableFloatAt(row)
+			if err != nil {
+				return nil, err
+			}
+			vars[name] = mathexp.NewScalarResults(name, value)
+		}
+		results, err := p.expr.Execute("", vars, mathTracer)
+		if err != nil {
+			return nil, err
+		}
+		if len(results.Values) != 1 {
+			return nil, errors.New("math expression must return a single number")
+		}
+		scalar, ok := results.Values[0].(mathexp.Scalar)
+		if !ok {
+			return nil, errors.New("math expression must return a single number")
+		}
+		values[row] = scalar.GetFloat64Value()
+	}
+
+	field := data.NewField(p.config.FieldName, nil, values)
+	field.Config = p.config.Config
+	setFrameField(frame, field)
+	return frame, nil
+}
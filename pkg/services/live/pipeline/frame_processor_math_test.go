@@ -0,0 +1,47 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMathFrameProcessor(t *testing.T) {
+	processor, err := NewMathFrameProcessor(MathFrameProcessorConfig{
+		Expression: "${room temperature} * 1.8 + $offset",
+		FieldName:  "fahrenheit",
+	})
+	require.NoError(t, err)
+
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1000, 0), time.Unix(1001, 0)}),
+		data.NewField("room temperature", nil, []*float64{float64Ptr(100), nil}),
+		data.NewField("offset", nil, []int64{32, 32}),
+	)
+	frame, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 4)
+	require.Equal(t, "fahrenheit", frame.Fields[3].Name)
+	require.Equal(t, float64Ptr(212), frame.Fields[3].At(0))
+	require.Nil(t, frame.Fields[3].At(1))
+
+	// The field is replaced when it already exists.
+	frame, err = processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 4)
+}
+
+func TestMathFrameProcessor_Errors(t *testing.T) {
+	_, err := NewMathFrameProcessor(MathFrameProcessorConfig{Expression: "$a +", FieldName: "b"})
+	require.Error(t, err)
+	_, err = NewMathFrameProcessor(MathFrameProcessorConfig{Expression: "$a + 1"})
+	require.Error(t, err)
+
+	processor, err := NewMathFrameProcessor(MathFrameProcessorConfig{Expression: "$missing + 1", FieldName: "b"})
+	require.NoError(t, err)
+	_, err = processor.ProcessFrame(context.Background(), Vars{}, data.NewFrame("test", data.NewField("a", nil, []float64{1})))
+	require.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// RateFrameProcessor can add a field with the per-second rate of a counter field of a data.Frame.
+// The rate of the first row is computed from the last row of the previous frame of the channel,
+// a decreasing counter is considered as reset to zero.
+type RateFrameProcessor struct {
+	frameStorage FrameGetSetter
+	config       RateFrameProcessorConfig
+}
+
+func NewRateFrameProcessor(frameStorage FrameGetSetter, config RateFrameProcessorConfig) *RateFrameProcessor {
+	return &RateFrameProcessor{frameStorage: frameStorage, config: config}
+}
+
+const FrameProcessorTypeRate = "rate"
+
+func (p *RateFrameProcessor) Type() string {
+	return FrameProcessorTypeRate
+}
+
+func (p *RateFrameProcessor) rateFieldName() string {
+	if p.config.RateFieldName != "" {
+		return p.config.RateFieldName
+	}
+	return p.config.FieldName + "_rate"
+}
+
+func (p *RateFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	counterIndex := frameFieldIndex(frame, p.config.FieldName)
+	if counterIndex < 0 {
+		return frame, nil
+	}
+	counter := frame.Fields[counterIndex]
+	if !counter.Type().Numeric() {
+		return nil, fmt.Errorf("can't compute the rate of field %s: not numeric", counter.Name)
+	}
+	timeIndex := frameTimeField(frame)
+	if timeIndex < 0 {
+		return nil, errors.New("rate processor requires a time field")
+	}
+
+	// The last sample of the previous frame is kept with a key that can't be a channel.
+	key := "rate:" + vars.Channel + ":" + p.config.FieldName
+	previous, ok, err := p.frameStorage.Get(vars.OrgID, key)
+	if err != nil {
+		return nil, err
+	}
+	var lastTime time.Time
+	var lastValue float64
+	hasLast := false
+	if ok && previous.Rows() == 1 {
+		lastTime, hasLast = timeAt(previous.Fields[0], 0)
+		lastValue, _ = previous.Fields[1].At(0).(float64)
+	}
+
+	rates := make([]*float64, counter.Len())
+	for row := range rates {
+		t, ok := timeAt(frame.Fields[timeIndex], row)
+		if !ok {
+			continue
+		}
+		value, err := counter.NullableFloatAt(row)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		if hasLast && t.After(lastTime) {
+			delta := *value - lastValue
+			if delta < 0 {
+				delta = *value
+			}
+			rate := delta / t.Sub(lastTime).Seconds()
+			rates[row] = &rate
+		}
+		if !hasLast || t.After(lastTime) {
+			lastTime, lastValue, hasLast = t, *value, true
+		}
+	}
+
+	if hasLast {
+		err := p.frameStorage.Set(vars.OrgID, key, data.NewFrame("",
+			data.NewField("time", nil, []time.Time{lastTime}),
+			data.NewField("value", nil, []float64{lastValue}),
+		))
+		if err != nil {
+			return nil, err
+		}
+	}
+	setFrameField(frame, data.NewField(p.rateFieldName(), counter.Labels, rates))
+	return frame, nil
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestRateFrameProcessor(t *testing.T) {
+	processor := NewRateFrameProcessor(NewFrameStorage(), RateFrameProcessorConfig{FieldName: "requests"})
+	vars := Vars{OrgID: 1, Channel: "stream/test/rate"}
+	start := time.Unix(1000, 0)
+
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start, start.Add(2 * time.Second)}),
+		data.NewField("requests", nil, []float64{10, 30}),
+	)
+	frame, err := processor.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Len(t, frame.Fields, 3)
+	require.Equal(t, "requests_rate", frame.Fields[2].Name)
+	require.Nil(t, frame.Fields[2].At(0))
+	require.Equal(t, float64Ptr(10), frame.Fields[2].At(1))
+
+	// The rate of the first row uses the last row of the previous frame, the counter is reset.
+	frame = data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start.Add(4 * time.Second), start.Add(5 * time.Second)}),
+		data.NewField("requests", nil, []float64{50, 20}),
+	)
+	frame, err = processor.ProcessFrame(context.Background(), vars, frame)
+	require.NoError(t, err)
+	require.Equal(t, float64Ptr(10), frame.Fields[2].At(0))
+	require.Equal(t, float64Ptr(20), frame.Fields[2].At(1))
+
+	// Other channels have their own previous frame.
+	frame = data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{start.Add(6 * time.Second)}),
+		data.NewField("requests", nil, []float64{100}),
+	)
+	frame, err = processor.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/other"}, frame)
+	require.NoError(t, err)
+	require.Nil(t, frame.Fields[2].At(0))
+}
+
+func TestRateFrameProcessor_RequiresTimeField(t *testing.T) {
+	processor := NewRateFrameProcessor(NewFrameStorage(), RateFrameProcessorConfig{FieldName: "requests", RateFieldName: "rps"})
+	frame := data.NewFrame("test", data.NewField("requests", nil, []float64{10}))
+	_, err := processor.ProcessFrame(context.Background(), Vars{OrgID: 1, Channel: "stream/test/rate"}, frame)
+	require.Error(t, err)
+}
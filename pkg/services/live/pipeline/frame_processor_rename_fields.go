@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// RenameFieldsFrameProcessor can rename fields of a data.Frame and set the labels
+// of its value fields.
+type RenameFieldsFrameProcessor struct {
+	config RenameFieldsFrameProcessorConfig
+}
+
+func NewRenameFieldsFrameProcessor(config RenameFieldsFrameProcessorConfig) *RenameFieldsFrameProcessor {
+	return &RenameFieldsFrameProcessor{config: config}
+}
+
+const FrameProcessorTypeRenameFields = "renameFields"
+
+func (p *RenameFieldsFrameProcessor) Type() string {
+	return FrameProcessorTypeRenameFields
+}
+
+func (p *RenameFieldsFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	for _, field := range frame.Fields {
+		if name, ok := p.config.Names[field.Name]; ok {
+			field.Name = name
+		}
+		if len(p.config.Labels) == 0 || field.Type().Time() {
+			continue
+		}
+		labels := make(data.Labels, len(field.Labels)+len(p.config.Labels))
+		for k, v := range field.Labels {
+			labels[k] = v
+		}
+		for k, v := range p.config.Labels {
+			// An empty value removes the label.
+			if v == "" {
+				delete(labels, k)
+			} else {
+				labels[k] = v
+			}
+		}
+		field.Labels = labels
+		if len(labels) == 0 {
+			field.Labels = nil
+		}
+	}
+	return frame, nil
+}
@@ -0,0 +1,27 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameFieldsFrameProcessor(t *testing.T) {
+	processor := NewRenameFieldsFrameProcessor(RenameFieldsFrameProcessorConfig{
+		Names:  map[string]string{"temp": "temperature"},
+		Labels: map[string]string{"site": "factory", "host": ""},
+	})
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1000, 0)}),
+		data.NewField("temp", data.Labels{"host": "a", "room": "1"}, []float64{21}),
+	)
+	frame, err := processor.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Equal(t, "time", frame.Fields[0].Name)
+	require.Nil(t, frame.Fields[0].Labels)
+	require.Equal(t, "temperature", frame.Fields[1].Name)
+	require.Equal(t, data.Labels{"site": "factory", "room": "1"}, frame.Fields[1].Labels)
+}
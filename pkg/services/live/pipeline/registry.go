@@ -48,6 +48,16 @@ var FrameOutputsRegistry = []EntityInfo{
 		Type:        FrameOutputTypeLoki,
 		Description: "output frame as JSON to Loki",
 	},
+	{
+		Type:        FrameOutputTypeAnnotation,
+		Description: "create Grafana annotations when a condition fires",
+		Example:     AnnotationOutputConfig{},
+	},
+	{
+		Type:        FrameOutputTypeAlert,
+		Description: "send alerts to the Grafana Alertmanager when a condition fires",
+		Example:     AlertOutputConfig{},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -79,6 +89,53 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeMath,
+		Description: "add a field computed from the other fields with a math expression",
+		Example: MathFrameProcessorConfig{
+			Expression: "$temperature * 1.8 + 32",
+			FieldName:  "temperature_fahrenheit",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeRenameFields,
+		Description: "rename fields and set their labels",
+		Example: RenameFieldsFrameProcessorConfig{
+			Names:  map[string]string{"temp": "temperature"},
+			Labels: map[string]string{"site": "factory"},
+		},
+	},
+	{
+		Type:        FrameProcessorTypeConvertUnit,
+		Description: "convert the values of a field to another unit",
+		Example: ConvertUnitFrameProcessorConfig{
+			FieldName: "temperature",
+			From:      "celsius",
+			To:        "fahrenheit",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeRate,
+		Description: "add the per-second rate of a counter field, using the previous frame",
+		Example: RateFrameProcessorConfig{
+			FieldName: "requests",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeDownsample,
+		Description: "aggregate the rows to one row per interval",
+		Example: DownsampleFrameProcessorConfig{
+			IntervalMilliseconds: 1000,
+			Aggregation:          DownsampleAggregationMean,
+		},
+	},
+	{
+		Type:        FrameProcessorTypeThrottle,
+		Description: "drop frames received less than an interval after the last one",
+		Example: ThrottleFrameProcessorConfig{
+			IntervalMilliseconds: 1000,
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
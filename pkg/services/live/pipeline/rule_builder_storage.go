@@ -6,6 +6,7 @@ import (
 
 	"github.com/centrifugal/centrifuge"
 
+	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/secrets"
 )
@@ -17,6 +18,9 @@ type StorageRuleBuilder struct {
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service
+	AnnotationsRepo      annotations.Repository
+	DashboardGetter      DashboardGetter
+	AlertSender          AlertSender
 }
 
 func (f *StorageRuleBuilder) extractSubscriber(config *SubscriberConfig) (Subscriber, error) {
@@ -104,6 +108,36 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			processors = append(processors, proc)
 		}
 		return NewMultipleFrameProcessor(processors...), nil
+	case FrameProcessorTypeMath:
+		if config.MathProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewMathFrameProcessor(*config.MathProcessorConfig)
+	case FrameProcessorTypeRenameFields:
+		if config.RenameFieldsProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewRenameFieldsFrameProcessor(*config.RenameFieldsProcessorConfig), nil
+	case FrameProcessorTypeConvertUnit:
+		if config.ConvertUnitProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewConvertUnitFrameProcessor(*config.ConvertUnitProcessorConfig)
+	case FrameProcessorTypeRate:
+		if config.RateProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewRateFrameProcessor(f.FrameStorage, *config.RateProcessorConfig), nil
+	case FrameProcessorTypeDownsample:
+		if config.DownsampleProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewDownsampleFrameProcessor(f.FrameStorage, *config.DownsampleProcessorConfig)
+	case FrameProcessorTypeThrottle:
+		if config.ThrottleProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewThrottleFrameProcessor(f.FrameStorage, *config.ThrottleProcessorConfig)
 	default:
 		return nil, fmt.Errorf("unknown processor type: %s", config.Type)
 	}
@@ -249,6 +283,24 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, missingConfiguration
 		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeAnnotation:
+		if config.AnnotationOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		condition, err := f.extractFrameConditionChecker(config.AnnotationOutputConfig.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return NewAnnotationFrameOutput(f.AnnotationsRepo, f.DashboardGetter, f.FrameStorage, condition, *config.AnnotationOutputConfig), nil
+	case FrameOutputTypeAlert:
+		if config.AlertOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		condition, err := f.extractFrameConditionChecker(config.AlertOutputConfig.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return NewAlertFrameOutput(f.AlertSender, f.FrameStorage, condition, *config.AlertOutputConfig)
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -2,24 +2,37 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/infra/log"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/notificationhistory"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
 )
 
 type NotificationSrv struct {
-	logger            log.Logger
-	receiverService   ReceiverService
-	muteTimingService MuteTimingService // defined in api_provisioning.go
+	logger              log.Logger
+	receiverService     ReceiverService
+	muteTimingService   MuteTimingService // defined in api_provisioning.go
+	notificationHistory NotificationHistoryStore
+}
+
+// NotificationHistoryStore is the store of the attempts to deliver notifications to contact points.
+type NotificationHistoryStore interface {
+	Find(ctx context.Context, query notificationhistory.Query) ([]notificationhistory.Entry, error)
 }
 
 type ReceiverService interface {
 	GetReceiver(ctx context.Context, q models.GetReceiverQuery, u identity.Requester) (*models.Receiver, error)
 	ListReceivers(ctx context.Context, q models.ListReceiversQuery, user identity.Requester) ([]*models.Receiver, error)
+	ListDeadLetters(ctx context.Context, q models.ListDeadLettersQuery, user identity.Requester) ([]resendqueue.Item, error)
+	ResendDeadLetter(ctx context.Context, orgID, id int64, user identity.Requester) error
 }
 
 func (srv *NotificationSrv) RouteGetTimeInterval(c *contextmodel.ReqContext, name string) response.Response {
@@ -78,3 +91,90 @@ func (srv *NotificationSrv) RouteGetReceivers(c *contextmodel.ReqContext) respon
 
 	return response.JSON(http.StatusOK, gettables)
 }
+
+func (srv *NotificationSrv) RouteGetNotificationHistory(c *contextmodel.ReqContext) response.Response {
+	if srv.notificationHistory == nil {
+		return ErrResp(http.StatusNotFound, errors.New("notification history is not enabled"), "")
+	}
+	q := notificationhistory.Query{
+		OrgID:    c.SignedInUser.GetOrgID(),
+		Receiver: c.Query("receiver"),
+		RuleUID:  c.Query("ruleUID"),
+		Limit:    c.QueryInt("limit"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		q.From = time.Unix(from, 0)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		q.To = time.Unix(to, 0)
+	}
+
+	entries, err := srv.notificationHistory.Find(c.Req.Context(), q)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get notification history")
+	}
+
+	result := make([]apimodels.NotificationHistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		alerts := make([]apimodels.NotificationHistoryAlert, 0, len(e.Alerts))
+		for _, a := range e.Alerts {
+			alerts = append(alerts, apimodels.NotificationHistoryAlert{
+				RuleUID:     a.RuleUID,
+				Fingerprint: a.Fingerprint,
+			})
+		}
+		result = append(result, apimodels.NotificationHistoryEntry{
+			Receiver:         e.Receiver,
+			Integration:      e.Integration,
+			IntegrationUID:   e.IntegrationUID,
+			IntegrationIndex: e.IntegrationIndex,
+			GroupKey:         e.GroupKey,
+			Title:            e.Title,
+			Status:           e.Status,
+			Result:           e.Result,
+			Error:            e.Error,
+			Retry:            e.Retry,
+			Duration:         e.Duration,
+			Time:             time.UnixMilli(e.Epoch).UTC(),
+			Alerts:           alerts,
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv *NotificationSrv) RouteGetDeadLetters(c *contextmodel.ReqContext) response.Response {
+	q := models.ListDeadLettersQuery{
+		OrgID:    c.SignedInUser.GetOrgID(),
+		Receiver: c.Query("receiver"),
+		Limit:    c.QueryInt("limit"),
+	}
+	items, err := srv.receiverService.ListDeadLetters(c.Req.Context(), q, c.SignedInUser)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get dead letters", err)
+	}
+
+	result := make([]apimodels.DeadLetter, 0, len(items))
+	for _, item := range items {
+		result = append(result, apimodels.DeadLetter{
+			ID:               item.ID,
+			Receiver:         item.Receiver,
+			Integration:      item.Integration,
+			IntegrationUID:   item.IntegrationUID,
+			IntegrationIndex: item.IntegrationIndex,
+			GroupKey:         item.GroupKey,
+			Attempts:         item.Attempts,
+			LastError:        item.LastError,
+			Created:          time.UnixMilli(item.Created).UTC(),
+			Updated:          time.UnixMilli(item.Updated).UTC(),
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv *NotificationSrv) RoutePostResendDeadLetter(c *contextmodel.ReqContext, id int64) response.Response {
+	err := srv.receiverService.ResendDeadLetter(c.Req.Context(), c.SignedInUser.GetOrgID(), id, c.SignedInUser)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to resend dead letter", err)
+	}
+	return response.Empty(http.StatusAccepted)
+}
@@ -23,6 +23,14 @@ import (
 
 const disableProvenanceHeaderName = "X-Disable-Provenance"
 
+// Types of the HCL resources, they match the resources of the Terraform provider.
+const (
+	hclRuleGroupResourceType          = "grafana_rule_group"
+	hclContactPointResourceType       = "grafana_contact_point"
+	hclNotificationPolicyResourceType = "grafana_notification_policy"
+	hclMuteTimingResourceType         = "grafana_mute_timing"
+)
+
 type ProvisioningSrv struct {
 	log                 log.Logger
 	policies            NotificationPolicyService
@@ -31,6 +39,7 @@ type ProvisioningSrv struct {
 	muteTimings         MuteTimingService
 	alertRules          AlertRuleService
 	folderSvc           folder.Service
+	xactManager         provisioning.TransactionManager
 
 	// XXX: Used to flag recording rules, remove when FT is removed
 	featureManager featuremgmt.FeatureToggles
@@ -588,7 +597,7 @@ func exportHcl(download bool, body definitions.AlertingFileExport) response.Resp
 			gr := group
 			hash := getHash([]string{gr.Name, gr.FolderUID})
 			resources = append(resources, hcl.Resource{
-				Type: "grafana_rule_group",
+				Type: hclRuleGroupResourceType,
 				Name: fmt.Sprintf("rule_group_%016x", hash),
 				Body: &gr,
 			})
@@ -600,7 +609,7 @@ func exportHcl(download bool, body definitions.AlertingFileExport) response.Resp
 			}
 			hash := getHash([]string{upd.Name})
 			resources = append(resources, hcl.Resource{
-				Type: "grafana_contact_point",
+				Type: hclContactPointResourceType,
 				Name: fmt.Sprintf("contact_point_%016x", hash),
 				Body: &upd,
 			})
@@ -609,7 +618,7 @@ func exportHcl(download bool, body definitions.AlertingFileExport) response.Resp
 		for idx, cp := range body.Policies {
 			policy := cp.RouteExport
 			resources = append(resources, hcl.Resource{
-				Type: "grafana_notification_policy",
+				Type: hclNotificationPolicyResourceType,
 				Name: fmt.Sprintf("notification_policy_%d", idx+1),
 				Body: policy,
 			})
@@ -622,7 +631,7 @@ func exportHcl(download bool, body definitions.AlertingFileExport) response.Resp
 			}
 			hash := getHash([]string{mthcl.Name})
 			resources = append(resources, hcl.Resource{
-				Type: "grafana_mute_timing",
+				Type: hclMuteTimingResourceType,
 				Name: fmt.Sprintf("mute_timing_%016x", hash),
 				Body: mthcl,
 			})
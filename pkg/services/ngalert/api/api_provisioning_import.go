@@ -0,0 +1,510 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/hcl"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	alerting_models "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	importKindRuleGroup          = "rule_group"
+	importKindAlertRule          = "alert_rule"
+	importKindContactPoint       = "contact_point"
+	importKindNotificationPolicy = "notification_policy"
+	importKindMuteTiming         = "mute_timing"
+)
+
+// RoutePostProvisioningImport imports the resources of a provisioning file. Resources are matched with the existing ones
+// by name, and rules of a rule group by UID or, if the file does not contain UIDs, by title. Resources that do not exist
+// in the file are not deleted, except for rules of the imported rule groups and integrations of the imported contact points.
+func (srv *ProvisioningSrv) RoutePostProvisioningImport(c *contextmodel.ReqContext) response.Response {
+	provenance, err := importProvenance(c)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	dryRun := c.QueryBoolWithDefault("dryRun", false)
+
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to read body")
+	}
+	file, err := decodeImport(extractImportFormat(c), body, c.SignedInUser.GetOrgID())
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to decode body")
+	}
+
+	plan, err := srv.planImport(c.Req.Context(), c.SignedInUser, file, provenance)
+	if err != nil {
+		return importErrorResponse(err)
+	}
+	if !dryRun {
+		// the changes are applied all or nothing
+		err := srv.xactManager.InTransaction(c.Req.Context(), func(ctx context.Context) error {
+			for _, apply := range plan.apply {
+				if err := apply(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return importErrorResponse(err)
+		}
+	}
+
+	return response.JSON(http.StatusOK, definitions.ImportResult{
+		DryRun:     dryRun,
+		Provenance: definitions.Provenance(provenance),
+		Changes:    plan.changes,
+	})
+}
+
+func importErrorResponse(err error) response.Response {
+	if errors.Is(err, errInvalidImport) ||
+		errors.Is(err, provisioning.ErrValidation) ||
+		errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) ||
+		errors.Is(err, alerting_models.ErrAlertRuleUniqueConstraintViolation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if errors.Is(err, store.ErrOptimisticLock) {
+		return ErrResp(http.StatusConflict, err, "")
+	}
+	return response.ErrOrFallback(http.StatusInternalServerError, "failed to import", err)
+}
+
+func importProvenance(c *contextmodel.ReqContext) (alerting_models.Provenance, error) {
+	switch p := c.Query("provenance"); p {
+	case "":
+		return alerting_models.Provenance(determineProvenance(c)), nil
+	case string(alerting_models.ProvenanceAPI), string(alerting_models.ProvenanceFile):
+		return alerting_models.Provenance(p), nil
+	default:
+		return "", fmt.Errorf("unsupported provenance '%s', expected api or file", p)
+	}
+}
+
+func extractImportFormat(c *contextmodel.ReqContext) string {
+	queryFormat := c.Query("format")
+	if queryFormat == "yaml" || queryFormat == "json" || queryFormat == "hcl" {
+		return queryFormat
+	}
+
+	contentType := c.Req.Header.Get("Content-Type")
+	if strings.Contains(contentType, "hcl") {
+		return "hcl"
+	}
+	if strings.Contains(contentType, "yaml") {
+		return "yaml"
+	}
+	return "json"
+}
+
+func decodeImport(format string, body []byte, orgID int64) (definitions.AlertingFileExport, error) {
+	var file definitions.AlertingFileExport
+	switch format {
+	case "hcl":
+		return decodeHclImport(body, orgID)
+	case "yaml":
+		if err := yaml.Unmarshal(body, &file); err != nil {
+			return file, err
+		}
+	default:
+		if err := json.Unmarshal(body, &file); err != nil {
+			return file, err
+		}
+	}
+	return file, nil
+}
+
+// decodeHclImport decodes the resources written by exportHcl into the provisioning file format.
+func decodeHclImport(body []byte, orgID int64) (definitions.AlertingFileExport, error) {
+	file := definitions.AlertingFileExport{APIVersion: 1}
+	resources, err := hcl.Decode(body, "import.tf", func(resourceType string) (interface{}, error) {
+		switch resourceType {
+		case hclRuleGroupResourceType:
+			return &definitions.AlertRuleGroupExport{}, nil
+		case hclContactPointResourceType:
+			return &definitions.ContactPoint{}, nil
+		case hclNotificationPolicyResourceType:
+			return &definitions.RouteExport{}, nil
+		case hclMuteTimingResourceType:
+			return &definitions.MuteTimeIntervalExportHcl{}, nil
+		}
+		return nil, errors.New("unsupported resource type")
+	})
+	if err != nil {
+		return file, err
+	}
+
+	for _, resource := range resources {
+		switch r := resource.Body.(type) {
+		case *definitions.AlertRuleGroupExport:
+			file.Groups = append(file.Groups, *r)
+		case *definitions.ContactPoint:
+			cp, err := ContactPointExportFromContactPoint(orgID, *r)
+			if err != nil {
+				return file, fmt.Errorf("failed to convert contact point [%s] from HCL: %w", r.Name, err)
+			}
+			file.ContactPoints = append(file.ContactPoints, cp)
+		case *definitions.RouteExport:
+			file.Policies = append(file.Policies, definitions.NotificationPolicyExport{OrgID: orgID, RouteExport: r})
+		case *definitions.MuteTimeIntervalExportHcl:
+			mt, err := MuteTimeIntervalFromMuteTimeIntervalHclExport(*r)
+			if err != nil {
+				return file, fmt.Errorf("failed to convert mute timing [%s] from HCL: %w", r.Name, err)
+			}
+			file.MuteTimings = append(file.MuteTimings, definitions.MuteTimeIntervalExport{OrgID: orgID, MuteTimeInterval: mt})
+		}
+	}
+	return file, nil
+}
+
+// importPlan contains the changes of an import and the functions that apply them.
+type importPlan struct {
+	changes []definitions.ImportChange
+	apply   []func(ctx context.Context) error
+}
+
+func (p *importPlan) add(change definitions.ImportChange, apply func(ctx context.Context) error) {
+	p.changes = append(p.changes, change)
+	if change.Action != definitions.ImportActionUnchanged && apply != nil {
+		p.apply = append(p.apply, apply)
+	}
+}
+
+// planImport calculates the changes of the import. They are applied in the order of dependencies between resources:
+// mute timings and contact points first because notification policies and rules refer to them.
+func (srv *ProvisioningSrv) planImport(ctx context.Context, user identity.Requester, file definitions.AlertingFileExport, provenance alerting_models.Provenance) (*importPlan, error) {
+	plan := &importPlan{}
+	if err := srv.planMuteTimingsImport(ctx, user.GetOrgID(), file.MuteTimings, provenance, plan); err != nil {
+		return nil, err
+	}
+	if err := srv.planContactPointsImport(ctx, user, file.ContactPoints, provenance, plan); err != nil {
+		return nil, err
+	}
+	if err := srv.planPoliciesImport(ctx, user.GetOrgID(), file.Policies, provenance, plan); err != nil {
+		return nil, err
+	}
+	if err := srv.planRuleGroupsImport(ctx, user, file.Groups, provenance, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (srv *ProvisioningSrv) planMuteTimingsImport(ctx context.Context, orgID int64, muteTimings []definitions.MuteTimeIntervalExport, provenance alerting_models.Provenance, plan *importPlan) error {
+	for _, mt := range muteTimings {
+		desired := definitions.MuteTimeInterval{
+			MuteTimeInterval: mt.MuteTimeInterval,
+			Provenance:       definitions.Provenance(provenance),
+		}
+		change := definitions.ImportChange{Kind: importKindMuteTiming, Name: mt.Name}
+
+		existing, err := srv.muteTimings.GetMuteTiming(ctx, mt.Name, orgID)
+		if errors.Is(err, provisioning.ErrTimeIntervalNotFound) {
+			change.Action = definitions.ImportActionCreate
+			plan.add(change, func(ctx context.Context) error {
+				_, err := srv.muteTimings.CreateMuteTiming(ctx, desired, orgID)
+				return err
+			})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		equal, err := util.JSONEqual(existing.MuteTimeInterval, desired.MuteTimeInterval)
+		if err != nil {
+			return err
+		}
+		change.Action = definitions.ImportActionUnchanged
+		if !equal {
+			change.Action = definitions.ImportActionUpdate
+		}
+		desired.Version = existing.Version
+		plan.add(change, func(ctx context.Context) error {
+			_, err := srv.muteTimings.UpdateMuteTiming(ctx, desired, orgID)
+			return err
+		})
+	}
+	return nil
+}
+
+func (srv *ProvisioningSrv) planContactPointsImport(ctx context.Context, user identity.Requester, contactPoints []definitions.ContactPointExport, provenance alerting_models.Provenance, plan *importPlan) error {
+	if len(contactPoints) == 0 {
+		return nil
+	}
+	orgID := user.GetOrgID()
+	existing, err := srv.contactPointService.GetContactPoints(ctx, provisioning.ContactPointQuery{OrgID: orgID}, user)
+	if err != nil {
+		return err
+	}
+	existingByName := make(map[string][]definitions.EmbeddedContactPoint)
+	for _, cp := range existing {
+		existingByName[cp.Name] = append(existingByName[cp.Name], cp)
+	}
+
+	for _, cp := range contactPoints {
+		desired, err := EmbeddedContactPointsFromContactPointExport(cp)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errInvalidImport, err.Error())
+		}
+		change := definitions.ImportChange{Kind: importKindContactPoint, Name: cp.Name, Action: definitions.ImportActionUnchanged}
+		current := existingByName[cp.Name]
+		if len(current) == 0 {
+			change.Action = definitions.ImportActionCreate
+		}
+
+		// Integrations are matched by UID if the file contains it, otherwise by type in the order of the file.
+		matched := make([]bool, len(current))
+		match := func(integration definitions.EmbeddedContactPoint) int {
+			for i, c := range current {
+				if !matched[i] && integration.UID != "" && c.UID == integration.UID {
+					return i
+				}
+			}
+			for i, c := range current {
+				if !matched[i] && integration.UID == "" && c.Type == integration.Type {
+					return i
+				}
+			}
+			return -1
+		}
+
+		var ops []func(ctx context.Context) error
+		for _, integration := range desired {
+			idx := match(integration)
+			if idx < 0 {
+				if change.Action == definitions.ImportActionUnchanged {
+					change.Action = definitions.ImportActionUpdate
+				}
+				integration.UID = ""
+				ops = append(ops, func(ctx context.Context) error {
+					_, err := srv.contactPointService.CreateContactPoint(ctx, orgID, user, integration, provenance)
+					return err
+				})
+				continue
+			}
+			matched[idx] = true
+			integration.UID = current[idx].UID
+			equal, err := integrationEqual(current[idx], integration)
+			if err != nil {
+				return err
+			}
+			if equal {
+				continue
+			}
+			change.Action = definitions.ImportActionUpdate
+			ops = append(ops, func(ctx context.Context) error {
+				return srv.contactPointService.UpdateContactPoint(ctx, orgID, integration, provenance)
+			})
+		}
+		for i := range current {
+			if matched[i] {
+				continue
+			}
+			uid := current[i].UID
+			change.Action = definitions.ImportActionUpdate
+			ops = append(ops, func(ctx context.Context) error {
+				return srv.contactPointService.DeleteContactPoint(ctx, orgID, uid)
+			})
+		}
+
+		plan.add(change, func(ctx context.Context) error {
+			for _, op := range ops {
+				if err := op(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// integrationEqual compares the settings of an existing integration with the imported one. Secure settings of the
+// existing integration are redacted, therefore, they are equal only if the imported settings are redacted as well.
+func integrationEqual(existing, imported definitions.EmbeddedContactPoint) (bool, error) {
+	if existing.Type != imported.Type || existing.DisableResolveMessage != imported.DisableResolveMessage {
+		return false, nil
+	}
+	return util.JSONEqual(existing.Settings, imported.Settings)
+}
+
+func (srv *ProvisioningSrv) planPoliciesImport(ctx context.Context, orgID int64, policies []definitions.NotificationPolicyExport, provenance alerting_models.Provenance, plan *importPlan) error {
+	if len(policies) == 0 {
+		return nil
+	}
+	if len(policies) > 1 {
+		return fmt.Errorf("%w: only one notification policy tree can be imported, got %d", errInvalidImport, len(policies))
+	}
+	if policies[0].RouteExport == nil {
+		return fmt.Errorf("%w: notification policy tree is empty", errInvalidImport)
+	}
+	desired, err := RouteFromRouteExport(policies[0].RouteExport)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errInvalidImport, err.Error())
+	}
+
+	change := definitions.ImportChange{Kind: importKindNotificationPolicy, Action: definitions.ImportActionUpdate}
+	existing, err := srv.policies.GetPolicyTree(ctx, orgID)
+	if err != nil && !errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+		return err
+	}
+	if err == nil {
+		equal, err := util.JSONEqual(RouteExportFromRoute(&existing), RouteExportFromRoute(desired))
+		if err != nil {
+			return err
+		}
+		if equal {
+			change.Action = definitions.ImportActionUnchanged
+		}
+	}
+	plan.add(change, func(ctx context.Context) error {
+		return srv.policies.UpdatePolicyTree(ctx, orgID, *desired, provenance)
+	})
+	return nil
+}
+
+func (srv *ProvisioningSrv) planRuleGroupsImport(ctx context.Context, user identity.Requester, groups []definitions.AlertRuleGroupExport, provenance alerting_models.Provenance, plan *importPlan) error {
+	for _, g := range groups {
+		folderUID, err := srv.importFolderUID(ctx, user, g)
+		if err != nil {
+			return err
+		}
+		group, err := AlertRuleGroupFromAlertRuleGroupExport(g, user.GetOrgID(), folderUID)
+		if err != nil {
+			return fmt.Errorf("%w: rule group '%s': %s", errInvalidImport, g.Name, err.Error())
+		}
+
+		change := definitions.ImportChange{Kind: importKindRuleGroup, Name: group.Title, FolderUID: folderUID, Action: definitions.ImportActionUnchanged}
+		existing, err := srv.alertRules.GetRuleGroup(ctx, user, folderUID, group.Title)
+		if errors.Is(err, alerting_models.ErrAlertRuleGroupNotFound) {
+			change.Action = definitions.ImportActionCreate
+		} else if err != nil {
+			return err
+		} else if existing.Interval != group.Interval {
+			change.Action = definitions.ImportActionUpdate
+		}
+
+		byUID := make(map[string]*alerting_models.AlertRule, len(existing.Rules))
+		byTitle := make(map[string]*alerting_models.AlertRule, len(existing.Rules))
+		for i := range existing.Rules {
+			byUID[existing.Rules[i].UID] = &existing.Rules[i]
+			byTitle[existing.Rules[i].Title] = &existing.Rules[i]
+		}
+
+		ruleChanges := make([]definitions.ImportChange, 0, len(group.Rules))
+		matched := make(map[string]struct{}, len(group.Rules))
+		for i := range group.Rules {
+			rule := &group.Rules[i]
+			current := byTitle[rule.Title]
+			if rule.UID != "" {
+				current = byUID[rule.UID]
+			} else if current != nil {
+				// keep the UID, so that the state of the rule is not lost
+				rule.UID = current.UID
+			}
+
+			ruleChange := definitions.ImportChange{Kind: importKindAlertRule, Name: rule.Title, FolderUID: folderUID, Group: group.Title, Action: definitions.ImportActionCreate}
+			if current != nil {
+				matched[current.UID] = struct{}{}
+				equal, err := ruleEqual(*current, *rule)
+				if err != nil {
+					return err
+				}
+				ruleChange.Action = definitions.ImportActionUpdate
+				if equal {
+					ruleChange.Action = definitions.ImportActionUnchanged
+				}
+			}
+			ruleChanges = append(ruleChanges, ruleChange)
+		}
+		for _, r := range existing.Rules {
+			if _, ok := matched[r.UID]; !ok {
+				ruleChanges = append(ruleChanges, definitions.ImportChange{Kind: importKindAlertRule, Name: r.Title, FolderUID: folderUID, Group: group.Title, Action: definitions.ImportActionDelete})
+			}
+		}
+		for _, rc := range ruleChanges {
+			if rc.Action != definitions.ImportActionUnchanged && change.Action == definitions.ImportActionUnchanged {
+				change.Action = definitions.ImportActionUpdate
+			}
+		}
+
+		plan.add(change, func(ctx context.Context) error {
+			return srv.alertRules.ReplaceRuleGroup(ctx, user, group, provenance)
+		})
+		plan.changes = append(plan.changes, ruleChanges...)
+	}
+	return nil
+}
+
+// ruleEqual compares the rules in the provisioning file format, so that only the fields that can be imported are compared.
+func ruleEqual(existing, imported alerting_models.AlertRule) (bool, error) {
+	// the file does not distinguish between empty and missing labels and annotations
+	for _, r := range []*alerting_models.AlertRule{&existing, &imported} {
+		if len(r.Labels) == 0 {
+			r.Labels = nil
+		}
+		if len(r.Annotations) == 0 {
+			r.Annotations = nil
+		}
+	}
+	a, err := AlertRuleExportFromAlertRule(existing)
+	if err != nil {
+		return false, err
+	}
+	b, err := AlertRuleExportFromAlertRule(imported)
+	if err != nil {
+		return false, err
+	}
+	return util.JSONEqual(a, b)
+}
+
+// importFolderUID returns the UID of the folder of the rule group. HCL contains the UID of the folder,
+// while YAML and JSON contain its full path.
+func (srv *ProvisioningSrv) importFolderUID(ctx context.Context, user identity.Requester, g definitions.AlertRuleGroupExport) (string, error) {
+	if g.FolderUID != "" {
+		return g.FolderUID, nil
+	}
+	titles := folderimpl.SplitFullpath(g.Folder)
+	if len(titles) == 0 {
+		return "", fmt.Errorf("%w: folder of rule group '%s' is not set", errInvalidImport, g.Name)
+	}
+
+	var parentUID *string
+	for i := range titles {
+		f, err := srv.folderSvc.Get(ctx, &folder.GetFolderQuery{
+			OrgID:        user.GetOrgID(),
+			Title:        &titles[i],
+			ParentUID:    parentUID,
+			SignedInUser: user,
+		})
+		if errors.Is(err, dashboards.ErrFolderNotFound) {
+			return "", fmt.Errorf("%w: folder '%s' of rule group '%s' does not exist", errInvalidImport, g.Folder, g.Name)
+		}
+		if err != nil {
+			return "", err
+		}
+		parentUID = &f.UID
+	}
+	return *parentUID, nil
+}
@@ -3,7 +3,9 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -18,6 +20,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -573,6 +576,214 @@ func TestProvisioningApi(t *testing.T) {
 		})
 	})
 
+	t.Run("import", func(t *testing.T) {
+		importRequestCtx := func(body string, format string, dryRun bool) contextmodel.ReqContext {
+			rc := createTestRequestCtx()
+			rc.Req.Body = io.NopCloser(strings.NewReader(body))
+			rc.Context.Req.Form.Set("format", format)
+			rc.Context.Req.Form.Set("dryRun", fmt.Sprint(dryRun))
+			return rc
+		}
+
+		t.Run("exported rule group is unchanged", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			insertRule(t, sut, createTestAlertRule("rule1", 1))
+			insertRule(t, sut, createTestAlertRule("rule2", 1))
+			for _, format := range []string{"hcl", "yaml", "json"} {
+				rc := createTestRequestCtx()
+				rc.Context.Req.Form.Set("format", format)
+				export := sut.RouteGetAlertRuleGroupExport(&rc, "folder-uid", "my-cool-group")
+				require.Equal(t, 200, export.Status())
+
+				rc = importRequestCtx(string(export.Body()), format, true)
+				response := sut.RoutePostProvisioningImport(&rc)
+
+				require.Equal(t, 200, response.Status(), string(response.Body()))
+				result := deserializeImportResult(t, response.Body())
+				require.True(t, result.DryRun)
+				require.Len(t, result.Changes, 3)
+				for _, change := range result.Changes {
+					require.Equalf(t, definitions.ImportActionUnchanged, change.Action, "%s %s", format, change.Name)
+					require.Equal(t, "folder-uid", change.FolderUID)
+				}
+			}
+		})
+
+		t.Run("rule group is created only if not dry run", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			insertRule(t, sut, createTestAlertRule("rule1", 1))
+			rc := createTestRequestCtx()
+			rc.Context.Req.Form.Set("format", "json")
+			export := sut.RouteGetAlertRuleGroupExport(&rc, "folder-uid", "my-cool-group")
+			require.Equal(t, 200, export.Status())
+
+			var file definitions.AlertingFileExport
+			require.NoError(t, json.Unmarshal(export.Body(), &file))
+			file.Groups[0].Name = "imported-group"
+			file.Groups[0].Rules[0].UID = ""
+			file.Groups[0].Rules[0].Title = "imported-rule"
+			body, err := json.Marshal(file)
+			require.NoError(t, err)
+
+			rc = importRequestCtx(string(body), "json", true)
+			response := sut.RoutePostProvisioningImport(&rc)
+			require.Equal(t, 200, response.Status(), string(response.Body()))
+			require.Equal(t, []definitions.ImportChange{
+				{Kind: importKindRuleGroup, Name: "imported-group", FolderUID: "folder-uid", Action: definitions.ImportActionCreate},
+				{Kind: importKindAlertRule, Name: "imported-rule", FolderUID: "folder-uid", Group: "imported-group", Action: definitions.ImportActionCreate},
+			}, deserializeImportResult(t, response.Body()).Changes)
+
+			rc = createTestRequestCtx()
+			require.Equal(t, 404, sut.RouteGetAlertRuleGroup(&rc, "folder-uid", "imported-group").Status())
+
+			rc = importRequestCtx(string(body), "json", false)
+			response = sut.RoutePostProvisioningImport(&rc)
+			require.Equal(t, 200, response.Status(), string(response.Body()))
+			require.False(t, deserializeImportResult(t, response.Body()).DryRun)
+
+			rc = createTestRequestCtx()
+			response = sut.RouteGetAlertRuleGroup(&rc, "folder-uid", "imported-group")
+			require.Equal(t, 200, response.Status())
+			var group definitions.AlertRuleGroup
+			require.NoError(t, json.Unmarshal(response.Body(), &group))
+			require.Len(t, group.Rules, 1)
+			require.Equal(t, "imported-rule", group.Rules[0].Title)
+			require.Equal(t, definitions.Provenance(models.ProvenanceAPI), group.Rules[0].Provenance)
+		})
+
+		t.Run("rules missing in the file are deleted", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			insertRule(t, sut, createTestAlertRule("rule1", 1))
+			insertRule(t, sut, createTestAlertRule("rule2", 1))
+			rc := createTestRequestCtx()
+			rc.Context.Req.Form.Set("format", "yaml")
+			export := sut.RouteGetAlertRuleGroupExport(&rc, "folder-uid", "my-cool-group")
+			require.Equal(t, 200, export.Status())
+
+			var file definitions.AlertingFileExport
+			require.NoError(t, yaml.Unmarshal(export.Body(), &file))
+			file.Groups[0].Rules = file.Groups[0].Rules[:1]
+			body, err := yaml.Marshal(file)
+			require.NoError(t, err)
+
+			rc = importRequestCtx(string(body), "yaml", true)
+			response := sut.RoutePostProvisioningImport(&rc)
+			require.Equal(t, 200, response.Status(), string(response.Body()))
+			require.Equal(t, []definitions.ImportChange{
+				{Kind: importKindRuleGroup, Name: "my-cool-group", FolderUID: "folder-uid", Action: definitions.ImportActionUpdate},
+				{Kind: importKindAlertRule, Name: "rule1", FolderUID: "folder-uid", Group: "my-cool-group", Action: definitions.ImportActionUnchanged},
+				{Kind: importKindAlertRule, Name: "rule2", FolderUID: "folder-uid", Group: "my-cool-group", Action: definitions.ImportActionDelete},
+			}, deserializeImportResult(t, response.Body()).Changes)
+		})
+
+		t.Run("notification policy tree is replaced with the requested provenance", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			body := `{"apiVersion": 1, "policies": [{"orgId": 1, "receiver": "some-receiver"}]}`
+
+			rc := importRequestCtx(body, "json", true)
+			response := sut.RoutePostProvisioningImport(&rc)
+			require.Equal(t, 200, response.Status(), string(response.Body()))
+			require.Equal(t, []definitions.ImportChange{
+				{Kind: importKindNotificationPolicy, Action: definitions.ImportActionUnchanged},
+			}, deserializeImportResult(t, response.Body()).Changes)
+
+			body = `resource "grafana_notification_policy" "notification_policy_1" {
+  contact_point = "other-receiver"
+  group_by      = ["alertname"]
+}
+`
+			rc = importRequestCtx(body, "hcl", false)
+			rc.Context.Req.Form.Set("provenance", "file")
+			response = sut.RoutePostProvisioningImport(&rc)
+			require.Equal(t, 200, response.Status(), string(response.Body()))
+			result := deserializeImportResult(t, response.Body())
+			require.Equal(t, definitions.Provenance(models.ProvenanceFile), result.Provenance)
+			require.Equal(t, []definitions.ImportChange{
+				{Kind: importKindNotificationPolicy, Action: definitions.ImportActionUpdate},
+			}, result.Changes)
+
+			policies := sut.policies.(*fakeNotificationPolicyService)
+			require.Equal(t, "other-receiver", policies.tree.Receiver)
+			require.Equal(t, []string{"alertname"}, policies.tree.GroupByStr)
+			require.Equal(t, models.ProvenanceFile, policies.prov)
+		})
+
+		t.Run("changes are applied in one transaction", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			applied := false
+			sut.xactManager = transactionManagerFunc(func(ctx context.Context, work func(ctx context.Context) error) error {
+				if err := work(ctx); err != nil {
+					return err
+				}
+				applied = true
+				return errors.New("failed to commit")
+			})
+			body := `{"apiVersion": 1, "policies": [{"orgId": 1, "receiver": "other-receiver"}]}`
+
+			rc := importRequestCtx(body, "json", false)
+			response := sut.RoutePostProvisioningImport(&rc)
+
+			require.True(t, applied)
+			require.Equal(t, 500, response.Status())
+		})
+
+		t.Run("returns 400", func(t *testing.T) {
+			testCases := []struct {
+				name     string
+				body     string
+				format   string
+				mutate   func(rc *contextmodel.ReqContext)
+				contains string
+			}{
+				{
+					name:     "when provenance is not supported",
+					body:     `{"apiVersion": 1}`,
+					format:   "json",
+					mutate:   func(rc *contextmodel.ReqContext) { rc.Context.Req.Form.Set("provenance", "converted_prometheus") },
+					contains: "unsupported provenance",
+				},
+				{
+					name:     "when body is not valid",
+					body:     `{"apiVersion": `,
+					format:   "json",
+					contains: "failed to decode body",
+				},
+				{
+					name:     "when HCL resource is not supported",
+					body:     `resource "grafana_folder" "folder" {}`,
+					format:   "hcl",
+					contains: "unsupported resource type",
+				},
+				{
+					name:     "when folder of rule group does not exist",
+					body:     "apiVersion: 1\ngroups:\n  - name: group\n    folder: does not exist\n    interval: 1m\n    rules: []\n",
+					format:   "yaml",
+					contains: "folder 'does not exist' of rule group 'group' does not exist",
+				},
+				{
+					name:     "when there are multiple notification policy trees",
+					body:     `{"apiVersion": 1, "policies": [{"orgId": 1, "receiver": "a"}, {"orgId": 1, "receiver": "b"}]}`,
+					format:   "json",
+					contains: "only one notification policy tree can be imported",
+				},
+			}
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					sut := createProvisioningSrvSut(t)
+					rc := importRequestCtx(tc.body, tc.format, true)
+					if tc.mutate != nil {
+						tc.mutate(&rc)
+					}
+
+					response := sut.RoutePostProvisioningImport(&rc)
+
+					require.Equal(t, 400, response.Status())
+					require.Contains(t, string(response.Body()), tc.contains)
+				})
+			}
+		})
+	})
+
 	t.Run("exports", func(t *testing.T) {
 		t.Run("alert rule group", func(t *testing.T) {
 			t.Run("are present, GET returns 200", func(t *testing.T) {
@@ -1912,6 +2123,7 @@ func createProvisioningSrvSutFromEnv(t *testing.T, env *testEnvironment) Provisi
 		muteTimings:         provisioning.NewMuteTimingService(configStore, env.prov, env.xact, env.log, env.store),
 		alertRules:          provisioning.NewAlertRuleService(env.store, env.prov, env.folderService, env.quotas, env.xact, 60, 10, 100, env.log, &provisioning.NotificationSettingsValidatorProviderFake{}, env.rulesAuthz),
 		folderSvc:           env.folderService,
+		xactManager:         env.xact,
 		featureManager:      env.features,
 	}
 }
@@ -2152,6 +2364,15 @@ func insertRuleInOrg(t *testing.T, srv ProvisioningSrv, rule definitions.Provisi
 	require.Equal(t, 201, resp.Status())
 }
 
+func deserializeImportResult(t *testing.T, data []byte) definitions.ImportResult {
+	t.Helper()
+
+	var result definitions.ImportResult
+	err := json.Unmarshal(data, &result)
+	require.NoError(t, err)
+	return result
+}
+
 func deserializeRule(t *testing.T, data []byte) definitions.ProvisionedAlertRule {
 	t.Helper()
 
@@ -2352,3 +2573,9 @@ var testContactPointConfig = `
 	}
 }
 `
+
+type transactionManagerFunc func(ctx context.Context, work func(ctx context.Context) error) error
+
+func (f transactionManagerFunc) InTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	return f(ctx, work)
+}
@@ -66,6 +66,9 @@ var (
 // ignore fields that are not part of the rule definition
 var ignoreFieldsForValidate = [...]string{"RuleGroupIndex"}
 
+// ignore fields that are not part of the rule definition or are not stored in the history of rule versions
+var ruleVersionFieldsToIgnoreInDiff = [...]string{"ID", "Version", "Updated", "RuleGroupIndex"}
+
 // RouteDeleteAlertRules deletes all alert rules the user is authorized to access in the given namespace
 // or, if non-empty, a specific group of rules in the namespace.
 // Returns http.StatusForbidden if user does not have access to any of the rules that match the filter.
@@ -328,6 +331,157 @@ func (srv RulerSrv) RouteGetRuleByUID(c *contextmodel.ReqContext, ruleUID string
 	return response.JSON(http.StatusOK, result)
 }
 
+// RouteGetRuleVersionsByUID returns all versions of the alert rule with the given UID, starting from the most recent one
+func (srv RulerSrv) RouteGetRuleVersionsByUID(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	ctx := c.Req.Context()
+	orgID := c.SignedInUser.GetOrgID()
+
+	rule, err := srv.getAuthorizedRuleByUid(ctx, c, ruleUID)
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return response.Empty(http.StatusNotFound)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get rule by UID", err)
+	}
+
+	versions, err := srv.store.GetAlertRuleVersions(ctx, rule.GetKey())
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return response.Empty(http.StatusNotFound)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get rule versions", err)
+	}
+
+	provenance, err := srv.provenanceStore.GetProvenance(ctx, &rule, orgID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get rule provenance", err)
+	}
+	provenanceRecords := map[string]ngmodels.Provenance{rule.ResourceID(): provenance}
+
+	result := make(apimodels.GettableRuleVersions, 0, len(versions))
+	for _, version := range versions {
+		version.ID = rule.ID
+		result = append(result, toGettableExtendedRuleNode(*version, provenanceRecords))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteGetRuleVersionsDiff returns the difference between two versions of the alert rule with the given UID.
+// If the version to compare to is not specified, the current version of the rule is used.
+func (srv RulerSrv) RouteGetRuleVersionsDiff(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	ctx := c.Req.Context()
+
+	from := c.QueryInt64("from")
+	to := c.QueryInt64("to")
+	if from <= 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("query parameter 'from' must be a positive version number"), "")
+	}
+	if to < 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("query parameter 'to' must be a positive version number"), "")
+	}
+
+	rule, err := srv.getAuthorizedRuleByUid(ctx, c, ruleUID)
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return response.Empty(http.StatusNotFound)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get rule by UID", err)
+	}
+	if to == 0 {
+		to = rule.Version
+	}
+
+	fromRule, err := srv.getRuleVersion(ctx, rule, from)
+	if err != nil {
+		return ruleVersionErrorToResponse(err, from)
+	}
+	toRule, err := srv.getRuleVersion(ctx, rule, to)
+	if err != nil {
+		return ruleVersionErrorToResponse(err, to)
+	}
+
+	diff := fromRule.Diff(toRule, ruleVersionFieldsToIgnoreInDiff[:]...)
+	result := apimodels.RuleVersionDiff{
+		RuleUID: rule.UID,
+		From:    from,
+		To:      to,
+		Diff:    make([]apimodels.RuleVersionFieldDiff, 0, len(diff)),
+	}
+	for _, d := range diff {
+		fieldDiff := apimodels.RuleVersionFieldDiff{Path: d.Path}
+		if d.Left.IsValid() && d.Left.CanInterface() {
+			fieldDiff.Left = d.Left.Interface()
+		}
+		if d.Right.IsValid() && d.Right.CanInterface() {
+			fieldDiff.Right = d.Right.Interface()
+		}
+		result.Diff = append(result.Diff, fieldDiff)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteRestoreRuleVersion restores the definition of the alert rule with the given UID from the specified version.
+// The rule stays in its current folder and group, and the restored definition goes through the same validation,
+// authorization and provenance checks as any other update of the group. It is saved as a new version of the rule.
+func (srv RulerSrv) RouteRestoreRuleVersion(c *contextmodel.ReqContext, ruleUID string, version int64) response.Response {
+	ctx := c.Req.Context()
+
+	rule, err := srv.getAuthorizedRuleByUid(ctx, c, ruleUID)
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return response.Empty(http.StatusNotFound)
+		}
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get rule by UID", err)
+	}
+
+	target, err := srv.getRuleVersion(ctx, rule, version)
+	if err != nil {
+		return ruleVersionErrorToResponse(err, version)
+	}
+
+	groupKey := rule.GetGroupKey()
+	group, err := srv.getAuthorizedRuleGroup(ctx, c, groupKey)
+	if err != nil {
+		return errorToResponse(err)
+	}
+
+	submitted := make([]*ngmodels.AlertRuleWithOptionals, 0, len(group))
+	for _, r := range group {
+		if r.UID != rule.UID {
+			submitted = append(submitted, &ngmodels.AlertRuleWithOptionals{AlertRule: *r, HasPause: true})
+			continue
+		}
+		restored := ngmodels.CopyRule(target)
+		// the location of the rule and the group-wide settings are not part of the restored definition
+		restored.ID = r.ID
+		restored.Version = r.Version
+		restored.NamespaceUID = r.NamespaceUID
+		restored.RuleGroup = r.RuleGroup
+		restored.RuleGroupIndex = r.RuleGroupIndex
+		restored.IntervalSeconds = r.IntervalSeconds
+		submitted = append(submitted, &ngmodels.AlertRuleWithOptionals{AlertRule: *restored, HasPause: true})
+	}
+
+	srv.log.Info("Restoring alert rule version", append(rule.GetKey().LogContext(), "version", version, "currentVersion", rule.Version)...)
+	return srv.updateAlertRulesInGroup(c, groupKey, submitted, map[string]int64{rule.UID: version})
+}
+
+// getRuleVersion returns the requested version of the rule. The current version is returned as is because
+// not every change of the version is recorded in the history, e.g. moving folders bumps the version of all rules.
+func (srv RulerSrv) getRuleVersion(ctx context.Context, current ngmodels.AlertRule, version int64) (*ngmodels.AlertRule, error) {
+	if version == current.Version {
+		return &current, nil
+	}
+	return srv.store.GetAlertRuleVersion(ctx, current.GetKey(), version)
+}
+
+func ruleVersionErrorToResponse(err error, version int64) response.Response {
+	if errors.Is(err, ngmodels.ErrAlertRuleVersionNotFound) {
+		return ErrResp(http.StatusNotFound, err, "version %d", version)
+	}
+	return response.ErrOrFallback(http.StatusInternalServerError, "failed to get rule version", err)
+}
+
 func (srv RulerSrv) RoutePostNameRulesConfig(c *contextmodel.ReqContext, ruleGroupConfig apimodels.PostableRuleGroupConfig, namespaceUID string) response.Response {
 	namespace, err := srv.store.GetNamespaceByUID(c.Req.Context(), namespaceUID, c.SignedInUser.GetOrgID(), c.SignedInUser)
 	if err != nil {
@@ -349,7 +503,7 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *contextmodel.ReqContext, ruleGro
 		RuleGroup:    ruleGroupConfig.Name,
 	}
 
-	return srv.updateAlertRulesInGroup(c, groupKey, rules)
+	return srv.updateAlertRulesInGroup(c, groupKey, rules, nil)
 }
 
 func (srv RulerSrv) checkGroupLimits(group apimodels.PostableRuleGroupConfig) error {
@@ -365,10 +519,11 @@ func (srv RulerSrv) checkGroupLimits(group apimodels.PostableRuleGroupConfig) er
 }
 
 // updateAlertRulesInGroup calculates changes (rules to add,update,delete), verifies that the user is authorized to do the calculated changes and updates database.
-// All operations are performed in a single transaction
+// All operations are performed in a single transaction.
+// restoredFrom maps UIDs of rules restored from their history to the restored version, it can be nil.
 //
 //nolint:gocyclo
-func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey ngmodels.AlertRuleGroupKey, rules []*ngmodels.AlertRuleWithOptionals) response.Response {
+func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey ngmodels.AlertRuleGroupKey, rules []*ngmodels.AlertRuleWithOptionals, restoredFrom map[string]int64) response.Response {
 	var finalChanges *store.GroupDelta
 	var dbConfig *ngmodels.AlertConfiguration
 	err := srv.xactManager.InTransaction(c.Req.Context(), func(tranCtx context.Context) error {
@@ -439,8 +594,9 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 			for _, update := range finalChanges.Update {
 				logger.Debug("Updating rule", "rule_uid", update.New.UID, "diff", update.Diff.String())
 				updates = append(updates, ngmodels.UpdateRule{
-					Existing: update.Existing,
-					New:      *update.New,
+					Existing:     update.Existing,
+					New:          *update.New,
+					RestoredFrom: restoredFrom[update.New.UID],
 				})
 			}
 			err = srv.store.UpdateAlertRules(tranCtx, updates)
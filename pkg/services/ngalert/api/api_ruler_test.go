@@ -379,6 +379,202 @@ func TestRouteGetRuleByUID(t *testing.T) {
 	})
 }
 
+func TestRouteGetRuleVersionsByUID(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	groupKey := models.GenerateGroupKey(orgID)
+	groupKey.NamespaceUID = folder.UID
+	gen := models.RuleGen.With(models.RuleGen.WithGroupKey(groupKey), models.RuleGen.WithUniqueID())
+
+	rule := gen.With(gen.WithVersion(3)).GenerateRef()
+	ruleStore.PutRule(context.Background(), rule)
+	for v := int64(1); v <= rule.Version; v++ {
+		version := models.CopyRule(rule)
+		version.Version = v
+		version.Title = fmt.Sprintf("%s-%d", rule.Title, v)
+		ruleStore.History[rule.UID] = append(ruleStore.History[rule.UID], version)
+	}
+	perms := createPermissionsForRules([]*models.AlertRule{rule}, orgID)
+
+	t.Run("should return all versions starting from the latest", func(t *testing.T) {
+		req := createRequestContextWithPerms(orgID, perms, nil)
+		response := createService(ruleStore).RouteGetRuleVersionsByUID(req, rule.UID)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.GettableRuleVersions
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result, 3)
+		for i, version := range result {
+			expectedVersion := rule.Version - int64(i)
+			assert.Equal(t, expectedVersion, version.GrafanaManagedAlert.Version)
+			assert.Equal(t, fmt.Sprintf("%s-%d", rule.Title, expectedVersion), version.GrafanaManagedAlert.Title)
+			assert.Equal(t, rule.UID, version.GrafanaManagedAlert.UID)
+		}
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		req := createRequestContextWithPerms(orgID, perms, nil)
+		response := createService(ruleStore).RouteGetRuleVersionsByUID(req, "foobar")
+
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 403 if user cannot read the rule", func(t *testing.T) {
+		req := createRequestContextWithPerms(orgID, map[int64]map[string][]string{}, nil)
+		response := createService(ruleStore).RouteGetRuleVersionsByUID(req, rule.UID)
+
+		require.Equal(t, http.StatusForbidden, response.Status())
+	})
+}
+
+func TestRouteGetRuleVersionsDiff(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	groupKey := models.GenerateGroupKey(orgID)
+	groupKey.NamespaceUID = folder.UID
+	gen := models.RuleGen.With(models.RuleGen.WithGroupKey(groupKey), models.RuleGen.WithUniqueID())
+
+	rule := gen.With(gen.WithVersion(2)).GenerateRef()
+	ruleStore.PutRule(context.Background(), rule)
+	previous := models.CopyRule(rule)
+	previous.Version = 1
+	previous.Title = "previous-title"
+	ruleStore.History[rule.UID] = []*models.AlertRule{previous}
+	perms := createPermissionsForRules([]*models.AlertRule{rule}, orgID)
+
+	requestWithQuery := func(query url.Values) *contextmodel.ReqContext {
+		req := createRequestContextWithPerms(orgID, perms, nil)
+		req.Req.Form = query
+		return req
+	}
+
+	t.Run("should compare with the current version if 'to' is not specified", func(t *testing.T) {
+		req := requestWithQuery(url.Values{"from": []string{"1"}})
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(req, rule.UID)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.RuleVersionDiff
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		assert.Equal(t, rule.UID, result.RuleUID)
+		assert.EqualValues(t, 1, result.From)
+		assert.EqualValues(t, 2, result.To)
+		require.Len(t, result.Diff, 1)
+		assert.Equal(t, "Title", result.Diff[0].Path)
+		assert.Equal(t, previous.Title, result.Diff[0].Left)
+		assert.Equal(t, rule.Title, result.Diff[0].Right)
+	})
+
+	t.Run("should return empty diff for the same version", func(t *testing.T) {
+		req := requestWithQuery(url.Values{"from": []string{"1"}, "to": []string{"1"}})
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(req, rule.UID)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.RuleVersionDiff
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Empty(t, result.Diff)
+	})
+
+	t.Run("should return 400 if 'from' is not specified", func(t *testing.T) {
+		req := requestWithQuery(url.Values{})
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(req, rule.UID)
+
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("should return 404 if version does not exist", func(t *testing.T) {
+		req := requestWithQuery(url.Values{"from": []string{"10"}})
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(req, rule.UID)
+
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+}
+
+func TestRouteRestoreRuleVersion(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	groupKey := models.GenerateGroupKey(orgID)
+	groupKey.NamespaceUID = folder.UID
+	gen := models.RuleGen.With(models.RuleGen.WithGroupKey(groupKey), models.RuleGen.WithUniqueID(), models.RuleGen.WithUniqueGroupIndex())
+
+	setup := func() (*fakes.RuleStore, *models.AlertRule, *models.AlertRule, map[int64]map[string][]string) {
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+		rules := gen.With(gen.WithVersion(2)).GenerateManyRef(3)
+		ruleStore.PutRule(context.Background(), rules...)
+		rule := rules[0]
+		previous := models.CopyRule(rule)
+		previous.Version = 1
+		previous.Title = "previous-title"
+		ruleStore.History[rule.UID] = []*models.AlertRule{previous}
+
+		perms := createPermissionsForRules(rules, orgID)
+		scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID)
+		perms[orgID][ac.ActionAlertingRuleUpdate] = []string{scope}
+		return ruleStore, rule, previous, perms
+	}
+
+	t.Run("should update the rule with the definition of the version", func(t *testing.T) {
+		ruleStore, rule, previous, perms := setup()
+		svc := createService(ruleStore)
+		svc.conditionValidator = &recordingConditionValidator{}
+		req := createRequestContextWithPerms(orgID, perms, nil)
+
+		response := svc.RouteRestoreRuleVersion(req, rule.UID, previous.Version)
+
+		require.Equal(t, http.StatusAccepted, response.Status())
+		var result apimodels.UpdateRuleGroupResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Contains(t, result.Updated, rule.UID)
+		require.Empty(t, result.Created)
+		require.Empty(t, result.Deleted)
+
+		updates := ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.UpdateRule)
+			return c, ok
+		})
+		require.Len(t, updates, 1)
+		var restored *models.AlertRule
+		for _, upd := range updates[0].([]models.UpdateRule) {
+			if upd.New.UID == rule.UID {
+				restored = &upd.New
+				require.Equal(t, previous.Version, upd.RestoredFrom)
+			} else {
+				require.Zero(t, upd.RestoredFrom)
+			}
+		}
+		require.NotNil(t, restored)
+		require.Equal(t, previous.Title, restored.Title)
+		require.Equal(t, rule.RuleGroupIndex, restored.RuleGroupIndex)
+		require.Equal(t, rule.Version, restored.Version)
+	})
+
+	t.Run("should return 404 if version does not exist", func(t *testing.T) {
+		ruleStore, rule, _, perms := setup()
+		req := createRequestContextWithPerms(orgID, perms, nil)
+
+		response := createService(ruleStore).RouteRestoreRuleVersion(req, rule.UID, 10)
+
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 400 if rule is provisioned", func(t *testing.T) {
+		ruleStore, rule, previous, perms := setup()
+		provisioningStore := fakes.NewFakeProvisioningStore()
+		require.NoError(t, provisioningStore.SetProvenance(context.Background(), rule, orgID, models.ProvenanceAPI))
+		svc := createServiceWithProvenanceStore(ruleStore, provisioningStore)
+		svc.conditionValidator = &recordingConditionValidator{}
+		req := createRequestContextWithPerms(orgID, perms, nil)
+
+		response := svc.RouteRestoreRuleVersion(req, rule.UID, previous.Version)
+
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+}
+
 func TestRouteGetRulesConfig(t *testing.T) {
 	gen := models.RuleGen
 	t.Run("fine-grained access is enabled", func(t *testing.T) {
@@ -10,6 +10,8 @@ import (
 
 	"github.com/benbjohnson/clock"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/timeinterval"
 
 	"github.com/grafana/alerting/models"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -29,6 +31,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/setting"
@@ -51,6 +54,7 @@ type TestingApiSrv struct {
 	appUrl          *url.URL
 	tracer          tracing.Tracer
 	folderService   folderService
+	amConfigStore   AMConfigStore
 }
 
 // RouteTestGrafanaRuleConfig returns a list of potential alerts for a given rule configuration. This is intended to be
@@ -286,3 +290,148 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 	}
 	return response.JSON(http.StatusOK, body)
 }
+
+// BacktestRuleGroup evaluates all rules of a group over a time range and routes the alerts through an Alertmanager
+// configuration, either the one in the request or the current one of the organization.
+func (srv TestingApiSrv) BacktestRuleGroup(c *contextmodel.ReqContext, cmd apimodels.BacktestGroupConfig) response.Response {
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backgtesting API is not enabled")
+	}
+
+	if cmd.From.After(cmd.To) {
+		return ErrResp(http.StatusBadRequest, nil, "From cannot be greater than To")
+	}
+
+	folder, err := srv.folderService.GetNamespaceByUID(c.Req.Context(), cmd.NamespaceUID, c.OrgID, c.SignedInUser)
+	if err != nil {
+		return toNamespaceErrorResponse(dashboards.ErrFolderAccessDenied)
+	}
+
+	validated, err := ValidateRuleGroup(&cmd.Group, c.SignedInUser.GetOrgID(), folder.UID, RuleLimitsFromConfig(srv.cfg, srv.featureManager))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if len(validated) == 0 {
+		return ErrResp(http.StatusBadRequest, nil, "Rule group must have at least one rule")
+	}
+
+	rules := make([]*ngmodels.AlertRule, 0, len(validated))
+	for _, r := range validated {
+		rule := r.AlertRule
+		if rule.UID == "" {
+			// prefix backtesting- is to distinguish between executions of regular rule and backtesting in logs
+			rule.UID = "backtesting-" + util.GenerateShortUID()
+		}
+		if err := srv.authz.AuthorizeDatasourceAccessForRule(c.Req.Context(), c.SignedInUser, &rule); err != nil {
+			return errorToResponse(err)
+		}
+		rules = append(rules, &rule)
+	}
+
+	amConfig := cmd.AlertmanagerConfig
+	if amConfig == nil {
+		amConfig, err = srv.getLatestAlertmanagerConfig(c.Req.Context(), c.SignedInUser.GetOrgID())
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "Failed to get the Alertmanager configuration")
+		}
+	}
+	if srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingSimplifiedRouting) {
+		err = notifier.AddAutogenConfig(c.Req.Context(), srv.log, backtestNotificationSettings(rules), c.SignedInUser.GetOrgID(), amConfig, true)
+		if err != nil {
+			return ErrResp(http.StatusBadRequest, err, "Failed to generate routes for the notification settings of the rules")
+		}
+	}
+	policy, err := newBacktestNotificationPolicy(amConfig)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "Invalid Alertmanager configuration")
+	}
+
+	result, err := srv.backtesting.TestGroup(c.Req.Context(), c.SignedInUser, rules, folder.Fullpath, policy, cmd.From, cmd.To)
+	if err != nil {
+		if errors.Is(err, backtesting.ErrInvalidInputData) {
+			return ErrResp(http.StatusBadRequest, err, "Failed to evaluate")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "Failed to evaluate")
+	}
+
+	return response.JSON(http.StatusOK, toBacktestGroupResult(result))
+}
+
+func (srv TestingApiSrv) getLatestAlertmanagerConfig(ctx context.Context, orgID int64) (*apimodels.PostableApiAlertingConfig, error) {
+	stored, err := srv.amConfigStore.GetLatestAlertmanagerConfiguration(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := notifier.Load([]byte(stored.AlertmanagerConfiguration))
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.AlertmanagerConfig, nil
+}
+
+// backtestNotificationSettings provides the notification settings of the tested rules to the generator of
+// the routes for simplified routing.
+type backtestNotificationSettings []*ngmodels.AlertRule
+
+func (rules backtestNotificationSettings) ListNotificationSettings(_ context.Context, _ ngmodels.ListNotificationSettingsQuery) (map[ngmodels.AlertRuleKey][]ngmodels.NotificationSettings, error) {
+	result := make(map[ngmodels.AlertRuleKey][]ngmodels.NotificationSettings, len(rules))
+	for _, rule := range rules {
+		if len(rule.NotificationSettings) > 0 {
+			result[rule.GetKey()] = rule.NotificationSettings
+		}
+	}
+	return result, nil
+}
+
+func newBacktestNotificationPolicy(cfg *apimodels.PostableApiAlertingConfig) (*backtesting.NotificationPolicy, error) {
+	if cfg.Route == nil {
+		return nil, errors.New("the configuration has no notification policy")
+	}
+	intervals := make(map[string][]timeinterval.TimeInterval, len(cfg.MuteTimeIntervals)+len(cfg.TimeIntervals))
+	for _, ti := range cfg.MuteTimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+	for _, ti := range cfg.TimeIntervals {
+		intervals[ti.Name] = ti.TimeIntervals
+	}
+	return &backtesting.NotificationPolicy{
+		Route:         dispatch.NewRoute(cfg.Route.AsAMRoute(), nil),
+		TimeIntervals: intervals,
+	}, nil
+}
+
+func toBacktestGroupResult(result *backtesting.GroupResult) apimodels.BacktestGroupResult {
+	transitions := make([]apimodels.BacktestStateTransition, 0, len(result.Transitions))
+	for _, t := range result.Transitions {
+		transitions = append(transitions, apimodels.BacktestStateTransition{
+			Time:          t.Time,
+			RuleUID:       t.RuleUID,
+			RuleTitle:     t.RuleTitle,
+			Labels:        t.Labels,
+			PreviousState: t.PreviousState,
+			State:         t.State,
+		})
+	}
+	notifications := make([]apimodels.BacktestNotification, 0, len(result.Notifications))
+	for _, n := range result.Notifications {
+		alerts := make([]apimodels.BacktestNotificationAlert, 0, len(n.Alerts))
+		for _, a := range n.Alerts {
+			alerts = append(alerts, apimodels.BacktestNotificationAlert{
+				Labels:   a.Labels,
+				Status:   a.Status,
+				StartsAt: a.StartsAt,
+				EndsAt:   a.EndsAt,
+			})
+		}
+		notifications = append(notifications, apimodels.BacktestNotification{
+			Time:        n.Time,
+			Receiver:    n.Receiver,
+			GroupLabels: n.GroupLabels,
+			Alerts:      alerts,
+		})
+	}
+	return apimodels.BacktestGroupResult{
+		Transitions:   transitions,
+		Notifications: notifications,
+	}
+}
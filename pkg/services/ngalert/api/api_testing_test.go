@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/backtesting"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -406,3 +408,177 @@ func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mo
 		folderService:   ruleStore,
 	}
 }
+
+func TestBacktestRuleGroup(t *testing.T) {
+	rc := &contextmodel.ReqContext{
+		Context: &web.Context{
+			Req: &http.Request{},
+		},
+		SignedInUser: &user.SignedInUser{
+			OrgID: 1,
+		},
+	}
+	features := featuremgmt.WithFeatures(featuremgmt.FlagAlertingBacktesting)
+	groupWait := model.Duration(0)
+	amConfig := &definitions.PostableApiAlertingConfig{
+		Config: definitions.Config{
+			Route: &definitions.Route{Receiver: "default", GroupWait: &groupWait},
+		},
+	}
+
+	createSrv := func(t *testing.T, ac *acMock.Mock, evaluator *eval_mocks.ConditionEvaluatorMock, features featuremgmt.FeatureToggles, ruleStore *fakes2.RuleStore) *TestingApiSrv {
+		evalFactory := eval_mocks.NewEvaluatorFactory(evaluator)
+		srv := createTestingApiSrv(t, nil, ac, evalFactory, features, ruleStore)
+		srv.backtesting = backtesting.NewEngine(nil, evalFactory, srv.tracer, srv.cfg, features)
+		return srv
+	}
+
+	createCmd := func(srv *TestingApiSrv, folderUID string, rules ...definitions.PostableExtendedRuleNode) definitions.BacktestGroupConfig {
+		from := time.Now().Truncate(time.Second)
+		return definitions.BacktestGroupConfig{
+			From:         from,
+			To:           from.Add(3 * srv.cfg.BaseInterval),
+			NamespaceUID: folderUID,
+			Group: definitions.PostableRuleGroupConfig{
+				Name:     "test-group",
+				Interval: model.Duration(srv.cfg.BaseInterval),
+				Rules:    rules,
+			},
+			AlertmanagerConfig: amConfig,
+		}
+	}
+
+	t.Run("should return NotFound if the feature is disabled", func(t *testing.T) {
+		f := randFolder()
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.Folders[rc.OrgID] = []*folder.Folder{f}
+		srv := createSrv(t, nil, &eval_mocks.ConditionEvaluatorMock{}, featuremgmt.WithFeatures(), ruleStore)
+
+		response := srv.BacktestRuleGroup(rc, createCmd(srv, f.UID, validRule()))
+
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return BadRequest if From is after To", func(t *testing.T) {
+		f := randFolder()
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.Folders[rc.OrgID] = []*folder.Folder{f}
+		srv := createSrv(t, nil, &eval_mocks.ConditionEvaluatorMock{}, features, ruleStore)
+
+		cmd := createCmd(srv, f.UID, validRule())
+		cmd.From, cmd.To = cmd.To, cmd.From
+		response := srv.BacktestRuleGroup(rc, cmd)
+
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("should return Forbidden if user cannot access folder", func(t *testing.T) {
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.Hook = func(cmd any) error {
+			q, ok := cmd.(fakes2.GenericRecordedQuery)
+			if !ok {
+				return nil
+			}
+			if q.Name == "GetNamespaceByUID" {
+				return dashboards.ErrFolderAccessDenied
+			}
+			return nil
+		}
+		srv := createSrv(t, nil, &eval_mocks.ConditionEvaluatorMock{}, features, ruleStore)
+
+		response := srv.BacktestRuleGroup(rc, createCmd(srv, uuid.NewString(), validRule()))
+
+		require.Equal(t, http.StatusForbidden, response.Status())
+	})
+
+	t.Run("should return BadRequest if the group is invalid", func(t *testing.T) {
+		f := randFolder()
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.Folders[rc.OrgID] = []*folder.Folder{f}
+		srv := createSrv(t, nil, &eval_mocks.ConditionEvaluatorMock{}, features, ruleStore)
+
+		t.Run("no name", func(t *testing.T) {
+			cmd := createCmd(srv, f.UID, validRule())
+			cmd.Group.Name = ""
+			response := srv.BacktestRuleGroup(rc, cmd)
+
+			require.Equal(t, http.StatusBadRequest, response.Status())
+		})
+
+		t.Run("no rules", func(t *testing.T) {
+			response := srv.BacktestRuleGroup(rc, createCmd(srv, f.UID))
+
+			require.Equal(t, http.StatusBadRequest, response.Status())
+		})
+
+		t.Run("no notification policy", func(t *testing.T) {
+			cmd := createCmd(srv, f.UID, validRule())
+			cmd.AlertmanagerConfig = &definitions.PostableApiAlertingConfig{}
+			response := srv.BacktestRuleGroup(rc, cmd)
+
+			require.Equal(t, http.StatusBadRequest, response.Status())
+		})
+	})
+
+	t.Run("should return Forbidden if user cannot query a data source", func(t *testing.T) {
+		gen := models.RuleGen
+		data1 := gen.GenerateQuery()
+		data2 := gen.GenerateQuery()
+
+		ac := acMock.New().WithPermissions([]ac.Permission{
+			{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(data1.DatasourceUID)},
+		})
+
+		f := randFolder()
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.Folders[rc.OrgID] = []*folder.Folder{f}
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		srv := createSrv(t, ac, evaluator, features, ruleStore)
+
+		rule := validRule()
+		rule.GrafanaManagedAlert.Data = ApiAlertQueriesFromAlertQueries([]models.AlertQuery{data1, data2})
+		rule.GrafanaManagedAlert.Condition = data2.RefID
+		response := srv.BacktestRuleGroup(rc, createCmd(srv, f.UID, rule))
+
+		require.Equal(t, http.StatusForbidden, response.Status())
+		evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return 200 with the result of the backtesting", func(t *testing.T) {
+		gen := models.RuleGen
+		data1 := gen.GenerateQuery()
+
+		ac := acMock.New().WithPermissions([]ac.Permission{
+			{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(data1.DatasourceUID)},
+		})
+
+		f := randFolder()
+		ruleStore := fakes2.NewRuleStore(t)
+		ruleStore.Folders[rc.OrgID] = []*folder.Folder{f}
+
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{"test": "data"}, State: eval.Alerting}}, nil)
+		srv := createSrv(t, ac, evaluator, features, ruleStore)
+
+		rule := validRule()
+		forDuration := model.Duration(0)
+		rule.For = &forDuration
+		rule.GrafanaManagedAlert.Data = ApiAlertQueriesFromAlertQueries([]models.AlertQuery{data1})
+		rule.GrafanaManagedAlert.Condition = data1.RefID
+		cmd := createCmd(srv, f.UID, rule)
+		response := srv.BacktestRuleGroup(rc, cmd)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		evaluator.AssertNumberOfCalls(t, "Evaluate", 3)
+
+		var result definitions.BacktestGroupResult
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Transitions, 1)
+		require.Equal(t, rule.GrafanaManagedAlert.UID, result.Transitions[0].RuleUID)
+		require.Equal(t, "Normal", result.Transitions[0].PreviousState)
+		require.Equal(t, "Alerting", result.Transitions[0].State)
+		require.True(t, cmd.From.Equal(result.Transitions[0].Time))
+		require.NotEmpty(t, result.Notifications)
+		require.Equal(t, "default", result.Notifications[0].Receiver)
+	})
+}
@@ -3,12 +3,15 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	amConfig "github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/common/model"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/util"
@@ -169,6 +172,33 @@ func AlertRuleGroupExportFromAlertRuleGroupWithFolderFullpath(d models.AlertRule
 	}, nil
 }
 
+// AlertRuleGroupFromAlertRuleGroupExport creates a models.AlertRuleGroup from the definitions.AlertRuleGroupExport DTO.
+// The export refers to the folder by its full path, therefore, the UID of the folder is provided by the caller.
+func AlertRuleGroupFromAlertRuleGroupExport(d definitions.AlertRuleGroupExport, orgID int64, folderUID string) (models.AlertRuleGroup, error) {
+	interval := d.IntervalSeconds
+	if interval == 0 {
+		interval = int64(time.Duration(d.Interval).Seconds())
+	}
+	group := models.AlertRuleGroup{
+		Title:     d.Name,
+		FolderUID: folderUID,
+		Interval:  interval,
+		Rules:     make([]models.AlertRule, 0, len(d.Rules)),
+	}
+	for i := range d.Rules {
+		rule, err := AlertRuleFromAlertRuleExport(d.Rules[i])
+		if err != nil {
+			return models.AlertRuleGroup{}, fmt.Errorf("invalid rule '%s': %w", d.Rules[i].Title, err)
+		}
+		rule.OrgID = orgID
+		rule.NamespaceUID = folderUID
+		rule.RuleGroup = d.Name
+		rule.IntervalSeconds = interval
+		group.Rules = append(group.Rules, rule)
+	}
+	return group, nil
+}
+
 // AlertRuleExportFromAlertRule creates a definitions.AlertRuleExport DTO from models.AlertRule.
 func AlertRuleExportFromAlertRule(rule models.AlertRule) (definitions.AlertRuleExport, error) {
 	data := make([]definitions.AlertQueryExport, 0, len(rule.Data))
@@ -222,6 +252,65 @@ func AlertRuleExportFromAlertRule(rule models.AlertRule) (definitions.AlertRuleE
 	return result, nil
 }
 
+// AlertRuleFromAlertRuleExport creates a models.AlertRule from the definitions.AlertRuleExport DTO.
+// The states default to the ones used by file provisioning if they are not set.
+func AlertRuleFromAlertRuleExport(rule definitions.AlertRuleExport) (models.AlertRule, error) {
+	data := make([]models.AlertQuery, 0, len(rule.Data))
+	for i := range rule.Data {
+		query, err := AlertQueryFromAlertQueryExport(rule.Data[i])
+		if err != nil {
+			return models.AlertRule{}, err
+		}
+		data = append(data, query)
+	}
+
+	forDuration := time.Duration(rule.For)
+	if rule.ForString != nil {
+		d, err := model.ParseDuration(*rule.ForString)
+		if err != nil {
+			return models.AlertRule{}, fmt.Errorf("invalid duration '%s': %w", *rule.ForString, err)
+		}
+		forDuration = time.Duration(d)
+	}
+
+	ns, err := NotificationSettingsFromAlertRuleNotificationSettingsExport(rule.NotificationSettings)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+
+	result := models.AlertRule{
+		UID:                  rule.UID,
+		Title:                rule.Title,
+		Data:                 data,
+		For:                  forDuration,
+		DashboardUID:         rule.DashboardUID,
+		PanelID:              rule.PanelID,
+		IsPaused:             rule.IsPaused,
+		NotificationSettings: ns,
+		Record:               ModelRecordFromAlertRuleRecordExport(rule.Record),
+	}
+	if rule.Condition != nil {
+		result.Condition = *rule.Condition
+	}
+	if rule.Record == nil {
+		result.NoDataState = models.NoData
+		result.ExecErrState = models.AlertingErrState
+	}
+	if rule.NoDataState != nil {
+		result.NoDataState = models.NoDataState(*rule.NoDataState)
+	}
+	if rule.ExecErrState != nil {
+		result.ExecErrState = models.ExecutionErrorState(*rule.ExecErrState)
+	}
+	if rule.Annotations != nil {
+		result.Annotations = *rule.Annotations
+	}
+	if rule.Labels != nil {
+		result.Labels = *rule.Labels
+	}
+	return result, nil
+}
+
 func encodeQueryModel(m map[string]any) (string, error) {
 	var buf bytes.Buffer
 	enc := json.NewEncoder(&buf)
@@ -264,6 +353,36 @@ func AlertQueryExportFromAlertQuery(query models.AlertQuery) (definitions.AlertQ
 	}, nil
 }
 
+// AlertQueryFromAlertQueryExport creates a models.AlertQuery from the definitions.AlertQueryExport DTO.
+func AlertQueryFromAlertQueryExport(query definitions.AlertQueryExport) (models.AlertQuery, error) {
+	// HCL contains the model as a JSON string
+	mdl := query.Model
+	if mdl == nil && query.ModelString != "" {
+		if err := json.Unmarshal([]byte(query.ModelString), &mdl); err != nil {
+			return models.AlertQuery{}, fmt.Errorf("invalid model of query '%s': %w", query.RefID, err)
+		}
+	}
+	raw, err := json.Marshal(mdl)
+	if err != nil {
+		return models.AlertQuery{}, err
+	}
+	var queryType string
+	if query.QueryType != nil {
+		queryType = *query.QueryType
+	}
+
+	return models.AlertQuery{
+		RefID:     query.RefID,
+		QueryType: queryType,
+		RelativeTimeRange: models.RelativeTimeRange{
+			From: models.Duration(time.Duration(query.RelativeTimeRange.FromSeconds) * time.Second),
+			To:   models.Duration(time.Duration(query.RelativeTimeRange.ToSeconds) * time.Second),
+		},
+		DatasourceUID: query.DatasourceUID,
+		Model:         raw,
+	}, nil
+}
+
 // AlertingFileExportFromEmbeddedContactPoints creates a definitions.AlertingFileExport DTO from []definitions.EmbeddedContactPoint.
 func AlertingFileExportFromEmbeddedContactPoints(orgID int64, ecps []definitions.EmbeddedContactPoint) (definitions.AlertingFileExport, error) {
 	f := definitions.AlertingFileExport{APIVersion: 1}
@@ -309,6 +428,25 @@ func ReceiverExportFromEmbeddedContactPoint(contact definitions.EmbeddedContactP
 	}, nil
 }
 
+// EmbeddedContactPointsFromContactPointExport creates a definitions.EmbeddedContactPoint for every receiver of the definitions.ContactPointExport DTO.
+func EmbeddedContactPointsFromContactPointExport(cp definitions.ContactPointExport) ([]definitions.EmbeddedContactPoint, error) {
+	result := make([]definitions.EmbeddedContactPoint, 0, len(cp.Receivers))
+	for _, recv := range cp.Receivers {
+		settings, err := simplejson.NewJson(recv.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("invalid settings of %s integration of contact point '%s': %w", recv.Type, cp.Name, err)
+		}
+		result = append(result, definitions.EmbeddedContactPoint{
+			UID:                   recv.UID,
+			Name:                  cp.Name,
+			Type:                  recv.Type,
+			Settings:              settings,
+			DisableResolveMessage: recv.DisableResolveMessage,
+		})
+	}
+	return result, nil
+}
+
 // AlertingFileExportFromRoute creates a definitions.AlertingFileExport DTO from definitions.Route.
 func AlertingFileExportFromRoute(orgID int64, route definitions.Route) (definitions.AlertingFileExport, error) {
 	f := definitions.AlertingFileExport{
@@ -365,6 +503,74 @@ func RouteExportFromRoute(route *definitions.Route) *definitions.RouteExport {
 	return &export
 }
 
+// RouteFromRouteExport creates a definitions.Route from the definitions.RouteExport DTO.
+func RouteFromRouteExport(export *definitions.RouteExport) (*definitions.Route, error) {
+	parseIfNotNil := func(s *string) (*model.Duration, error) {
+		if s == nil {
+			return nil, nil
+		}
+		d, err := model.ParseDuration(*s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration '%s': %w", *s, err)
+		}
+		return &d, nil
+	}
+
+	route := definitions.Route{
+		Receiver:       export.Receiver,
+		Match:          export.Match,
+		MatchRE:        export.MatchRE,
+		Matchers:       export.Matchers,
+		ObjectMatchers: export.ObjectMatchers,
+	}
+	if export.GroupByStr != nil {
+		route.GroupByStr = *export.GroupByStr
+	}
+	if export.MuteTimeIntervals != nil {
+		route.MuteTimeIntervals = *export.MuteTimeIntervals
+	}
+	if export.Continue != nil {
+		route.Continue = *export.Continue
+	}
+	// HCL contains the object matchers as blocks
+	for _, m := range export.ObjectMatchersSlice {
+		matcher, err := matcherFromMatcherExport(m)
+		if err != nil {
+			return nil, err
+		}
+		route.ObjectMatchers = append(route.ObjectMatchers, matcher)
+	}
+
+	var err error
+	if route.GroupWait, err = parseIfNotNil(export.GroupWait); err != nil {
+		return nil, err
+	}
+	if route.GroupInterval, err = parseIfNotNil(export.GroupInterval); err != nil {
+		return nil, err
+	}
+	if route.RepeatInterval, err = parseIfNotNil(export.RepeatInterval); err != nil {
+		return nil, err
+	}
+
+	for _, r := range export.Routes {
+		child, err := RouteFromRouteExport(r)
+		if err != nil {
+			return nil, err
+		}
+		route.Routes = append(route.Routes, child)
+	}
+	return &route, nil
+}
+
+func matcherFromMatcherExport(m *definitions.MatcherExport) (*labels.Matcher, error) {
+	for _, t := range []labels.MatchType{labels.MatchEqual, labels.MatchNotEqual, labels.MatchRegexp, labels.MatchNotRegexp} {
+		if t.String() == m.Match {
+			return labels.NewMatcher(t, m.Label, m.Value)
+		}
+	}
+	return nil, fmt.Errorf("invalid match type '%s' of matcher for label '%s'", m.Match, m.Label)
+}
+
 // OmitDefault returns nil if the value is the default.
 func OmitDefault[T comparable](v *T) *T {
 	var def T
@@ -415,6 +621,18 @@ func MuteTimingIntervalToMuteTimeIntervalHclExport(m definitions.MuteTimeInterva
 	return result, err
 }
 
+// MuteTimeIntervalFromMuteTimeIntervalHclExport converts definitions.MuteTimeIntervalExportHcl to amConfig.MuteTimeInterval using JSON marshalling.
+func MuteTimeIntervalFromMuteTimeIntervalHclExport(m definitions.MuteTimeIntervalExportHcl) (amConfig.MuteTimeInterval, error) {
+	result := amConfig.MuteTimeInterval{}
+	j := jsoniter.ConfigCompatibleWithStandardLibrary
+	mdata, err := j.Marshal(m)
+	if err != nil {
+		return result, err
+	}
+	err = j.Unmarshal(mdata, &result)
+	return result, err
+}
+
 // AlertRuleEditorSettingsFromEditorSettings converts models.EditorSettings to definitions.AlertRuleEditorSettings
 func AlertRuleEditorSettingsFromModelEditorSettings(es models.EditorSettings) *definitions.AlertRuleEditorSettings {
 	return &definitions.AlertRuleEditorSettings{
@@ -470,6 +688,41 @@ func AlertRuleNotificationSettingsExportFromNotificationSettings(ns []models.Not
 	}
 }
 
+// NotificationSettingsFromAlertRuleNotificationSettingsExport converts definitions.AlertRuleNotificationSettingsExport to []models.NotificationSettings
+func NotificationSettingsFromAlertRuleNotificationSettingsExport(ns *definitions.AlertRuleNotificationSettingsExport) ([]models.NotificationSettings, error) {
+	if ns == nil {
+		return nil, nil
+	}
+
+	parseIfNotNil := func(s *string) (*model.Duration, error) {
+		if s == nil {
+			return nil, nil
+		}
+		d, err := model.ParseDuration(*s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration '%s': %w", *s, err)
+		}
+		return &d, nil
+	}
+
+	result := models.NotificationSettings{
+		Receiver:          ns.Receiver,
+		GroupBy:           ns.GroupBy,
+		MuteTimeIntervals: ns.MuteTimeIntervals,
+	}
+	var err error
+	if result.GroupWait, err = parseIfNotNil(ns.GroupWait); err != nil {
+		return nil, err
+	}
+	if result.GroupInterval, err = parseIfNotNil(ns.GroupInterval); err != nil {
+		return nil, err
+	}
+	if result.RepeatInterval, err = parseIfNotNil(ns.RepeatInterval); err != nil {
+		return nil, err
+	}
+	return []models.NotificationSettings{result}, nil
+}
+
 // NotificationSettingsFromAlertRuleNotificationSettings converts definitions.AlertRuleNotificationSettings to []models.NotificationSettings
 func NotificationSettingsFromAlertRuleNotificationSettings(ns *definitions.AlertRuleNotificationSettings) []models.NotificationSettings {
 	if ns == nil {
@@ -497,6 +750,16 @@ func AlertRuleRecordExportFromRecord(r *models.Record) *definitions.AlertRuleRec
 	}
 }
 
+func ModelRecordFromAlertRuleRecordExport(r *definitions.AlertRuleRecordExport) *models.Record {
+	if r == nil {
+		return nil
+	}
+	return &models.Record{
+		Metric: r.Metric,
+		From:   r.From,
+	}
+}
+
 func ModelRecordFromApiRecord(r *definitions.Record) *models.Record {
 	if r == nil {
 		return nil
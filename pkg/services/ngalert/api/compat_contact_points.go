@@ -36,6 +36,29 @@ func ContactPointFromContactPointExport(rawContactPoint definitions.ContactPoint
 	return contactPoint, errors.Join(errs...)
 }
 
+// ContactPointExportFromContactPoint converts the strongly typed definitions.ContactPoint back to the definitions.ContactPointExport
+// where settings of integrations are represented in JSON.
+func ContactPointExportFromContactPoint(orgID int64, cp definitions.ContactPoint) (definitions.ContactPointExport, error) {
+	recv, err := ContactPointToContactPointExport(cp)
+	if err != nil {
+		return definitions.ContactPointExport{}, err
+	}
+	result := definitions.ContactPointExport{
+		OrgID:     orgID,
+		Name:      cp.Name,
+		Receivers: make([]definitions.ReceiverExport, 0, len(recv.Integrations)),
+	}
+	for _, integration := range recv.Integrations {
+		result.Receivers = append(result.Receivers, definitions.ReceiverExport{
+			UID:                   integration.UID,
+			Type:                  integration.Type,
+			Settings:              definitions.RawMessage(integration.Settings),
+			DisableResolveMessage: integration.DisableResolveMessage,
+		})
+	}
+	return result, nil
+}
+
 // ContactPointToContactPointExport converts definitions.ContactPoint to notify.APIReceiver.
 // It uses special extension for json-iterator API that properly handles marshalling of some specific fields.
 //
@@ -15,6 +15,9 @@ var (
 
 	// errFolderAccess is used as a wrapper to propagate folder related errors and correctly map to the response status
 	errFolderAccess = errors.New("cannot get folder")
+
+	// errInvalidImport is used as a wrapper for errors in the content of an imported file
+	errInvalidImport = errors.New("invalid import")
 )
 
 func unexpectedDatasourceTypeError(actual string, expected string) error {
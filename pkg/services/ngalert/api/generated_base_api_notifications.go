@@ -19,12 +19,21 @@ import (
 )
 
 type NotificationsApi interface {
+	RouteGetDeadLetters(*contextmodel.ReqContext) response.Response
+	RouteGetNotificationHistory(*contextmodel.ReqContext) response.Response
 	RouteGetReceiver(*contextmodel.ReqContext) response.Response
 	RouteGetReceivers(*contextmodel.ReqContext) response.Response
 	RouteNotificationsGetTimeInterval(*contextmodel.ReqContext) response.Response
 	RouteNotificationsGetTimeIntervals(*contextmodel.ReqContext) response.Response
+	RoutePostResendDeadLetter(*contextmodel.ReqContext) response.Response
 }
 
+func (f *NotificationsApiHandler) RouteGetDeadLetters(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetDeadLetters(ctx)
+}
+func (f *NotificationsApiHandler) RouteGetNotificationHistory(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetNotificationHistory(ctx)
+}
 func (f *NotificationsApiHandler) RouteGetReceiver(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -41,9 +50,38 @@ func (f *NotificationsApiHandler) RouteNotificationsGetTimeInterval(ctx *context
 func (f *NotificationsApiHandler) RouteNotificationsGetTimeIntervals(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteNotificationsGetTimeIntervals(ctx)
 }
+func (f *NotificationsApiHandler) RoutePostResendDeadLetter(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	return f.handleRoutePostResendDeadLetter(ctx, iDParam)
+}
 
 func (api *API) RegisterNotificationsApiEndpoints(srv NotificationsApi, m *metrics.API) {
 	api.RouteRegister.Group("", func(group routing.RouteRegister) {
+		group.Get(
+			toMacaronPath("/api/v1/notifications/dead-letters"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/notifications/dead-letters"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/notifications/dead-letters",
+				api.Hooks.Wrap(srv.RouteGetDeadLetters),
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/notifications/history"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/v1/notifications/history"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/notifications/history",
+				api.Hooks.Wrap(srv.RouteGetNotificationHistory),
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/notifications/receivers/{Name}"),
 			requestmeta.SetOwner(requestmeta.TeamAlerting),
@@ -92,5 +130,17 @@ func (api *API) RegisterNotificationsApiEndpoints(srv NotificationsApi, m *metri
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/notifications/dead-letters/{ID}/resend"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodPost, "/api/v1/notifications/dead-letters/{ID}/resend"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/notifications/dead-letters/{ID}/resend",
+				api.Hooks.Wrap(srv.RoutePostResendDeadLetter),
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
@@ -3,7 +3,9 @@ package hcl
 import (
 	"fmt"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
@@ -28,3 +30,37 @@ func Encode(resources ...Resource) (data []byte, err error) {
 	}
 	return f.Bytes(), nil
 }
+
+// Decode parses the resource blocks of an HCL file. The body of every resource is decoded into the value
+// returned by newBody for the type of the resource. Blocks other than resources, e.g. provider or terraform
+// blocks, are ignored, so that files written for Terraform can be decoded as well.
+func Decode(data []byte, filename string, newBody func(resourceType string) (interface{}, error)) ([]Resource, error) {
+	file, diags := hclparse.NewParser().ParseHCL(data, filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "resource", LabelNames: []string{"type", "name"}}},
+	})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	resources := make([]Resource, 0, len(content.Blocks))
+	for _, block := range content.Blocks {
+		resource := Resource{
+			Type: block.Labels[0],
+			Name: block.Labels[1],
+		}
+		body, err := newBody(resource.Type)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s.%s: %w", resource.Type, resource.Name, err)
+		}
+		if diags := gohcl.DecodeBody(block.Body, nil, body); diags.HasErrors() {
+			return nil, diags
+		}
+		resource.Body = body
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
@@ -1,6 +1,7 @@
 package hcl
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -73,3 +74,86 @@ func TestEncode(t *testing.T) {
 }
 `, string(encoded))
 }
+
+func TestDecode(t *testing.T) {
+	type sub struct {
+		Name string `hcl:"name"`
+	}
+	type data struct {
+		Name     string             `hcl:"name"`
+		Number   float64            `hcl:"number,optional"`
+		BoolRef  *bool              `hcl:"bulRef"`
+		Labels   *map[string]string `hcl:"labels"`
+		Ignored  string
+		Blocks   []sub `hcl:"blocks,block"`
+		Optional *sub  `hcl:"sub,block"`
+	}
+	newBody := func(resourceType string) (interface{}, error) {
+		if resourceType != "grafana_test" {
+			return nil, fmt.Errorf("unsupported resource type")
+		}
+		return &data{}, nil
+	}
+
+	t.Run("should decode resources and ignore other blocks", func(t *testing.T) {
+		resources, err := Decode([]byte(`
+provider "grafana" {
+  url = "http://localhost:3000"
+}
+
+resource "grafana_test" "test-01" {
+  name   = "test"
+  bulRef = true
+  labels = {
+    team = "a"
+  }
+
+  blocks {
+    name = "el-0"
+  }
+  blocks {
+    name = "el-1"
+  }
+}
+`), "test.tf", newBody)
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		require.Equal(t, "grafana_test", resources[0].Type)
+		require.Equal(t, "test-01", resources[0].Name)
+		require.Equal(t, &data{
+			Name:    "test",
+			BoolRef: func(b bool) *bool { return &b }(true),
+			Labels:  &map[string]string{"team": "a"},
+			Blocks:  []sub{{Name: "el-0"}, {Name: "el-1"}},
+		}, resources[0].Body)
+	})
+
+	t.Run("should decode encoded resources", func(t *testing.T) {
+		expected := Resource{
+			Type: "grafana_test",
+			Name: "test-01",
+			Body: &data{
+				Name:     "test",
+				Number:   123,
+				Blocks:   []sub{{Name: "el-0"}},
+				Optional: &sub{Name: "sub-data"},
+			},
+		}
+		encoded, err := Encode(expected)
+		require.NoError(t, err)
+
+		resources, err := Decode(encoded, "test.tf", newBody)
+		require.NoError(t, err)
+		require.Equal(t, []Resource{expected}, resources)
+	})
+
+	t.Run("should fail on unsupported resource types", func(t *testing.T) {
+		_, err := Decode([]byte(`resource "grafana_folder" "test" {}`), "test.tf", newBody)
+		require.ErrorContains(t, err, "grafana_folder.test: unsupported resource type")
+	})
+
+	t.Run("should fail on missing required attributes", func(t *testing.T) {
+		_, err := Decode([]byte(`resource "grafana_test" "test" {}`), "test.tf", newBody)
+		require.ErrorContains(t, err, "Missing required argument")
+	})
+}
@@ -1,6 +1,10 @@
 package api
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 )
@@ -30,3 +34,19 @@ func (f *NotificationsApiHandler) handleRouteGetReceiver(ctx *contextmodel.ReqCo
 func (f *NotificationsApiHandler) handleRouteGetReceivers(ctx *contextmodel.ReqContext) response.Response {
 	return f.notificationSrv.RouteGetReceivers(ctx)
 }
+
+func (f *NotificationsApiHandler) handleRouteGetNotificationHistory(ctx *contextmodel.ReqContext) response.Response {
+	return f.notificationSrv.RouteGetNotificationHistory(ctx)
+}
+
+func (f *NotificationsApiHandler) handleRouteGetDeadLetters(ctx *contextmodel.ReqContext) response.Response {
+	return f.notificationSrv.RouteGetDeadLetters(ctx)
+}
+
+func (f *NotificationsApiHandler) handleRoutePostResendDeadLetter(ctx *contextmodel.ReqContext, idParam string) response.Response {
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, errors.New("dead letter ID must be an integer"), "")
+	}
+	return f.notificationSrv.RoutePostResendDeadLetter(ctx, id)
+}
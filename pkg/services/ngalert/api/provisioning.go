@@ -76,6 +76,10 @@ func (f *ProvisioningApiHandler) handleRoutePostMuteTiming(ctx *contextmodel.Req
 	return f.svc.RoutePostMuteTiming(ctx, mt)
 }
 
+func (f *ProvisioningApiHandler) handleRoutePostProvisioningImport(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RoutePostProvisioningImport(ctx)
+}
+
 func (f *ProvisioningApiHandler) handleRoutePutMuteTiming(ctx *contextmodel.ReqContext, mt apimodels.MuteTimeInterval, name string) response.Response {
 	return f.svc.RoutePutMuteTiming(ctx, mt, name)
 }
@@ -20,6 +20,44 @@ import (
 //       403: ForbiddenError
 //       404: description: Not found.
 
+// swagger:route Get /ruler/grafana/api/v1/rule/{RuleUID}/versions ruler RouteGetRuleVersionsByUID
+//
+// Get all versions of the rule by UID, starting from the most recent one
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableRuleVersions
+//       403: ForbiddenError
+//       404: description: Not found.
+
+// swagger:route Get /ruler/grafana/api/v1/rule/{RuleUID}/versions/diff ruler RouteGetRuleVersionsDiff
+//
+// Compare two versions of the rule
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleVersionDiff
+//       400: ValidationError
+//       403: ForbiddenError
+//       404: description: Not found.
+
+// swagger:route POST /ruler/grafana/api/v1/rule/{RuleUID}/versions/{Version}/restore ruler RouteRestoreRuleVersion
+//
+// Restore the rule to the specified version. The restored definition is saved as a new version of the rule.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       202: UpdateRuleGroupResponse
+//       400: ValidationError
+//       403: ForbiddenError
+//       404: description: Not found.
+
 // swagger:route Get /ruler/grafana/api/v1/rules ruler RouteGetGrafanaRulesConfig
 //
 // List rule groups
@@ -218,12 +256,57 @@ type PathGetRulesParams struct {
 	PanelID int64
 }
 
-// swagger:parameters RouteGetRuleByUID
+// swagger:parameters RouteGetRuleByUID RouteGetRuleVersionsByUID
 type PathGetRuleByUIDParams struct {
 	// in: path
 	RuleUID string
 }
 
+// swagger:parameters RouteGetRuleVersionsDiff
+type RuleVersionsDiffParams struct {
+	// in: path
+	RuleUID string
+	// Version of the rule to compare from
+	// in: query
+	// required: true
+	From int64 `json:"from"`
+	// Version of the rule to compare to. If not specified, the current version of the rule is used.
+	// in: query
+	// required: false
+	To int64 `json:"to"`
+}
+
+// swagger:parameters RouteRestoreRuleVersion
+type PathRestoreRuleVersionParams struct {
+	// in: path
+	RuleUID string
+	// in: path
+	Version int64
+}
+
+// swagger:model
+type GettableRuleVersions []GettableExtendedRuleNode
+
+// swagger:model
+type RuleVersionDiff struct {
+	RuleUID string `json:"ruleUID"`
+	From    int64  `json:"from"`
+	To      int64  `json:"to"`
+	// Diff contains one entry per changed field. It is empty if the versions are identical.
+	Diff []RuleVersionFieldDiff `json:"diff"`
+}
+
+// RuleVersionFieldDiff describes a difference in a single field of two versions of a rule.
+type RuleVersionFieldDiff struct {
+	// Path to the field that has changed. Array index and map key are designated by square brackets.
+	// For example, Data[0].Model or Labels[severity]
+	Path string `json:"path"`
+	// Value of the field in the version specified by the "from" parameter. Omitted if the field was added.
+	Left any `json:"left,omitempty"`
+	// Value of the field in the version specified by the "to" parameter. Omitted if the field was removed.
+	Right any `json:"right,omitempty"`
+}
+
 // swagger:model
 type RuleGroupConfigResponse struct {
 	GettableRuleGroupConfig
@@ -0,0 +1,67 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /v1/notifications/dead-letters notifications RouteGetDeadLetters
+//
+// Get the notifications that could not be delivered to contact points after the maximum number of attempts,
+// the most recent ones first. The resend queue must be enabled in the [unified_alerting.resend_queue] section of the configuration.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: DeadLetters
+//       403: PermissionDenied
+//       404: NotFound
+
+// swagger:route POST /v1/notifications/dead-letters/{ID}/resend notifications RoutePostResendDeadLetter
+//
+// Move a notification that could not be delivered back to the resend queue to resend it immediately.
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+//       403: PermissionDenied
+//       404: NotFound
+
+// swagger:parameters RouteGetDeadLetters
+type DeadLettersParams struct {
+	// Filter by the name of the contact point.
+	// in:query
+	// required: false
+	Receiver string `json:"receiver"`
+	// Limits the number of returned dead letters.
+	// in:query
+	// required: false
+	Limit int `json:"limit"`
+}
+
+// swagger:parameters RoutePostResendDeadLetter
+type DeadLetterIDParam struct {
+	// in:path
+	// required: true
+	ID int64 `json:"ID"`
+}
+
+// swagger:response DeadLetters
+type DeadLetters struct {
+	// in:body
+	Body []DeadLetter
+}
+
+// DeadLetter is a notification that an integration of a contact point could not deliver.
+// swagger:model
+type DeadLetter struct {
+	ID               int64  `json:"id"`
+	Receiver         string `json:"receiver"`
+	Integration      string `json:"integration"`
+	IntegrationUID   string `json:"integrationUid,omitempty"`
+	IntegrationIndex int    `json:"integrationIndex"`
+	GroupKey         string `json:"groupKey"`
+	// Attempts is the number of failed attempts to deliver the notification.
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
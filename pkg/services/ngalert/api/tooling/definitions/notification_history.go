@@ -0,0 +1,75 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /v1/notifications/history notifications RouteGetNotificationHistory
+//
+// Get the attempts to deliver notifications to contact points, the most recent ones first.
+// The notification history must be enabled in the [unified_alerting.notification_history] section of the configuration.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: NotificationHistory
+//       403: PermissionDenied
+//       404: NotFound
+
+// swagger:parameters RouteGetNotificationHistory
+type NotificationHistoryParams struct {
+	// Filter by the name of the contact point.
+	// in:query
+	// required: false
+	Receiver string `json:"receiver"`
+	// Filter by the UID of a notified alert rule.
+	// in:query
+	// required: false
+	RuleUID string `json:"ruleUID"`
+	// The timestamp in seconds of the start of the time range. Defaults to 6 hours before the end of the time range.
+	// in:query
+	// required: false
+	From int64 `json:"from"`
+	// The timestamp in seconds of the end of the time range. Defaults to now.
+	// in:query
+	// required: false
+	To int64 `json:"to"`
+	// Limits the number of returned entries.
+	// in:query
+	// required: false
+	Limit int `json:"limit"`
+}
+
+// swagger:response NotificationHistory
+type NotificationHistory struct {
+	// in:body
+	Body []NotificationHistoryEntry
+}
+
+// NotificationHistoryEntry is an attempt to deliver a notification with an integration of a contact point.
+// swagger:model
+type NotificationHistoryEntry struct {
+	Receiver         string `json:"receiver"`
+	Integration      string `json:"integration"`
+	IntegrationUID   string `json:"integrationUid,omitempty"`
+	IntegrationIndex int    `json:"integrationIndex"`
+	GroupKey         string `json:"groupKey"`
+	Title            string `json:"title"`
+	// Status of the notified alerts.
+	// enum: firing,resolved
+	Status string `json:"status"`
+	// enum: success,failure
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+	// Retry is the number of attempts made before this one to deliver the same notification.
+	Retry int `json:"retry"`
+	// Duration of the attempt in milliseconds.
+	Duration int64                      `json:"duration"`
+	Time     time.Time                  `json:"time"`
+	Alerts   []NotificationHistoryAlert `json:"alerts"`
+}
+
+// NotificationHistoryAlert is an alert notified by an entry of the notification history.
+type NotificationHistoryAlert struct {
+	RuleUID     string `json:"ruleUid,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+}
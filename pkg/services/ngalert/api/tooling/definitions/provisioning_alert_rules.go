@@ -248,7 +248,7 @@ type AlertRuleGroup struct {
 
 // AlertRuleGroupExport is the provisioned file export of AlertRuleGroupV1.
 type AlertRuleGroupExport struct {
-	OrgID           int64             `json:"orgId" yaml:"orgId" hcl:"org_id"`
+	OrgID           int64             `json:"orgId" yaml:"orgId" hcl:"org_id,optional"`
 	Name            string            `json:"name" yaml:"name" hcl:"name"`
 	Folder          string            `json:"folder" yaml:"folder"`
 	FolderUID       string            `json:"-" yaml:"-" hcl:"folder_uid"`
@@ -274,7 +274,7 @@ type AlertRuleExport struct {
 	ForString            *string                              `json:"-" yaml:"-" hcl:"for"`
 	Annotations          *map[string]string                   `json:"annotations,omitempty" yaml:"annotations,omitempty" hcl:"annotations"`
 	Labels               *map[string]string                   `json:"labels,omitempty" yaml:"labels,omitempty" hcl:"labels"`
-	IsPaused             bool                                 `json:"isPaused" yaml:"isPaused" hcl:"is_paused"`
+	IsPaused             bool                                 `json:"isPaused" yaml:"isPaused" hcl:"is_paused,optional"`
 	NotificationSettings *AlertRuleNotificationSettingsExport `json:"notification_settings,omitempty" yaml:"notification_settings,omitempty" hcl:"notification_settings,block"`
 	Record               *AlertRuleRecordExport               `json:"record,omitempty" yaml:"record,omitempty" hcl:"record,block"`
 }
@@ -299,11 +299,11 @@ type AlertRuleNotificationSettingsExport struct {
 	// Field name mismatches with Terraform provider schema are noted where applicable.
 
 	Receiver          string   `yaml:"receiver,omitempty" json:"receiver,omitempty" hcl:"contact_point"` // TF -> `contact_point`
-	GroupBy           []string `yaml:"group_by,omitempty" json:"group_by,omitempty" hcl:"group_by"`
+	GroupBy           []string `yaml:"group_by,omitempty" json:"group_by,omitempty" hcl:"group_by,optional"`
 	GroupWait         *string  `yaml:"group_wait,omitempty" json:"group_wait,omitempty" hcl:"group_wait,optional"`
 	GroupInterval     *string  `yaml:"group_interval,omitempty" json:"group_interval,omitempty" hcl:"group_interval,optional"`
 	RepeatInterval    *string  `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty" hcl:"repeat_interval,optional"`
-	MuteTimeIntervals []string `yaml:"mute_time_intervals,omitempty" json:"mute_time_intervals,omitempty" hcl:"mute_timings"` // TF -> `mute_timings`
+	MuteTimeIntervals []string `yaml:"mute_time_intervals,omitempty" json:"mute_time_intervals,omitempty" hcl:"mute_timings,optional"` // TF -> `mute_timings`
 }
 
 // Record is the provisioned export of models.Record.
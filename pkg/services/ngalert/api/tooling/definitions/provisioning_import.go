@@ -0,0 +1,71 @@
+package definitions
+
+// swagger:route POST /v1/provisioning/import provisioning stable RoutePostProvisioningImport
+//
+// Import alert rule groups, contact points, notification policies and mute timings from a file in provisioning
+// file format (YAML or JSON) or in the HCL format of the exports. Use dryRun to preview the changes before applying them.
+//
+//     Consumes:
+//     - application/json
+//     - application/yaml
+//     - application/terraform+hcl
+//     - text/yaml
+//     - text/hcl
+//
+//     Responses:
+//       200: ImportResult
+//       400: ValidationError
+//       403: PermissionDenied
+
+// swagger:parameters RoutePostProvisioningImport
+type ImportParams struct {
+	// Format of the body. Supported yaml, json or hcl. Content-Type header can also be used, but the query parameter will take precedence.
+	// in: query
+	// required: false
+	// default: json
+	// enum: yaml,json,hcl
+	Format string `json:"format"`
+
+	// Whether to only return the changes that the import would make without applying them.
+	// in: query
+	// required: false
+	// default: false
+	DryRun bool `json:"dryRun"`
+
+	// Provenance of the imported resources.
+	// in: query
+	// required: false
+	// default: api
+	// enum: api,file
+	Provenance string `json:"provenance"`
+}
+
+// ImportAction is the change the import makes to a resource.
+type ImportAction string
+
+const (
+	ImportActionCreate    ImportAction = "create"
+	ImportActionUpdate    ImportAction = "update"
+	ImportActionDelete    ImportAction = "delete"
+	ImportActionUnchanged ImportAction = "unchanged"
+)
+
+// ImportChange is the change of a single resource.
+type ImportChange struct {
+	// Kind of the resource: rule_group, alert_rule, contact_point, notification_policy or mute_timing.
+	Kind string `json:"kind"`
+	// Name of the resource. The notification policy tree does not have a name.
+	Name string `json:"name,omitempty"`
+	// Folder and rule group of alert rules and rule groups.
+	FolderUID string       `json:"folderUid,omitempty"`
+	Group     string       `json:"group,omitempty"`
+	Action    ImportAction `json:"action"`
+}
+
+// ImportResult lists the changes of an import.
+// swagger:model
+type ImportResult struct {
+	DryRun     bool           `json:"dryRun"`
+	Provenance Provenance     `json:"provenance"`
+	Changes    []ImportChange `json:"changes"`
+}
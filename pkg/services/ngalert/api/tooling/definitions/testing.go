@@ -68,6 +68,21 @@ import (
 //     Responses:
 //       200: BacktestResult
 
+// swagger:route Post /v1/rule/backtest/group testing BacktestRuleGroup
+//
+// Test a rule group and the notifications it would send
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: BacktestGroupResult
+//       400: ValidationError
+//       404: NotFound
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -234,3 +249,57 @@ type BacktestConfig struct {
 
 // swagger:model
 type BacktestResult data.Frame
+
+// swagger:parameters BacktestRuleGroup
+type BacktestRuleGroupRequest struct {
+	// in:body
+	Body BacktestGroupConfig
+}
+
+// swagger:model
+type BacktestGroupConfig struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	// example: okrd3I0Vz
+	NamespaceUID string `json:"folderUid"`
+	// required: true
+	Group PostableRuleGroupConfig `json:"group"`
+
+	// AlertmanagerConfig is the configuration to route the alerts through.
+	// If it is empty, the current configuration of the Grafana Alertmanager is used.
+	AlertmanagerConfig *PostableApiAlertingConfig `json:"alertmanager_config,omitempty"`
+}
+
+// swagger:model
+type BacktestGroupResult struct {
+	Transitions   []BacktestStateTransition `json:"transitions"`
+	Notifications []BacktestNotification    `json:"notifications"`
+}
+
+// swagger:model
+type BacktestStateTransition struct {
+	Time          time.Time         `json:"time"`
+	RuleUID       string            `json:"ruleUid"`
+	RuleTitle     string            `json:"ruleTitle"`
+	Labels        map[string]string `json:"labels"`
+	PreviousState string            `json:"previousState"`
+	State         string            `json:"state"`
+}
+
+// swagger:model
+type BacktestNotification struct {
+	Time        time.Time                   `json:"time"`
+	Receiver    string                      `json:"receiver"`
+	GroupLabels map[string]string           `json:"groupLabels"`
+	Alerts      []BacktestNotificationAlert `json:"alerts"`
+}
+
+// swagger:model
+type BacktestNotificationAlert struct {
+	Labels map[string]string `json:"labels"`
+	// enum: firing,resolved
+	Status   string    `json:"status"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+}
@@ -15,10 +15,12 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
@@ -40,26 +42,32 @@ type stateManager interface {
 }
 
 type Engine struct {
-	evalFactory        eval.EvaluatorFactory
-	createStateManager func() stateManager
+	evalFactory          eval.EvaluatorFactory
+	createStateManager   func() stateManager
+	appUrl               *url.URL
+	disableGrafanaFolder bool
 }
 
-func NewEngine(appUrl *url.URL, evalFactory eval.EvaluatorFactory, tracer tracing.Tracer) *Engine {
+func NewEngine(appUrl *url.URL, evalFactory eval.EvaluatorFactory, tracer tracing.Tracer, cfg *setting.UnifiedAlertingSettings, features featuremgmt.FeatureToggles) *Engine {
 	return &Engine{
 		evalFactory: evalFactory,
 		createStateManager: func() stateManager {
 			cfg := state.ManagerCfg{
-				Metrics:       nil,
-				ExternalURL:   appUrl,
-				InstanceStore: nil,
-				Images:        &NoopImageService{},
-				Clock:         clock.New(),
-				Historian:     nil,
-				Tracer:        tracer,
-				Log:           log.New("ngalert.state.manager"),
+				Metrics:                        nil,
+				ExternalURL:                    appUrl,
+				InstanceStore:                  nil,
+				Images:                         &NoopImageService{},
+				Clock:                          clock.New(),
+				Historian:                      nil,
+				ApplyNoDataAndErrorToAllStates: features.IsEnabledGlobally(featuremgmt.FlagAlertingNoDataErrorExecution),
+				ResolvedRetention:              cfg.ResolvedAlertRetention,
+				Tracer:                         tracer,
+				Log:                            log.New("ngalert.state.manager"),
 			}
 			return state.NewManager(cfg, state.NewNoopPersister())
 		},
+		appUrl:               appUrl,
+		disableGrafanaFolder: cfg.ReservedLabels.IsReservedLabelDisabled(models.FolderTitleLabel),
 	}
 }
 
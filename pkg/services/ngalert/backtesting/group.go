@@ -0,0 +1,127 @@
+package backtesting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+// GroupResult is the result of backtesting a rule group.
+type GroupResult struct {
+	// Transitions are the changes of state of all alert instances of the group, ordered by time.
+	Transitions []StateTransition
+	// Notifications are the notifications that the Alertmanager would send, ordered by time.
+	// It is empty if the group was tested without a notification policy.
+	Notifications []Notification
+}
+
+// StateTransition is a change of the state of an alert instance.
+type StateTransition struct {
+	Time          time.Time
+	RuleUID       string
+	RuleTitle     string
+	Labels        data.Labels
+	PreviousState string
+	State         string
+}
+
+// TestGroup evaluates all rules of a group in the interval [from, to) with the evaluation interval of the group.
+// The results are processed by the state manager the same way as the scheduler does it, including For,
+// NoDataState, ExecErrState and KeepLast. If policy is not nil, the alerts that the scheduler would send
+// to the Alertmanager are routed through it, and the result contains the notifications that would be sent
+// to the receivers.
+func (e *Engine) TestGroup(ctx context.Context, user identity.Requester, rules []*models.AlertRule, folderTitle string, policy *NotificationPolicy, from, to time.Time) (*GroupResult, error) {
+	logger := logger.FromContext(ctx)
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w: rule group must have at least one rule", ErrInvalidInputData)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: invalid interval of the backtesting [%d,%d]", ErrInvalidInputData, from.Unix(), to.Unix())
+	}
+	interval := rules[0].IntervalSeconds
+	for _, rule := range rules {
+		if rule.IntervalSeconds != interval {
+			return nil, fmt.Errorf("%w: all rules of a group must have the same evaluation interval", ErrInvalidInputData)
+		}
+	}
+	if to.Sub(from).Seconds() < float64(interval) {
+		return nil, fmt.Errorf("%w: interval of the backtesting [%d,%d] is less than evaluation interval [%ds]", ErrInvalidInputData, from.Unix(), to.Unix(), interval)
+	}
+	length := int(to.Sub(from).Seconds()) / int(interval)
+
+	rules = slices.Clone(rules)
+	slices.SortStableFunc(rules, func(a, b *models.AlertRule) int {
+		return a.RuleGroupIndex - b.RuleGroupIndex
+	})
+
+	logger.Info("Start testing alert rule group", "from", from, "to", to, "interval", interval, "evaluations", length, "rules", len(rules))
+	start := time.Now()
+
+	// states are cached by rule so one manager is enough for the whole group
+	stateManager := e.createStateManager()
+	result := &GroupResult{}
+	var sent []sentAlerts
+	for _, rule := range rules {
+		ruleCtx := models.WithRuleKey(ctx, rule.GetKey())
+		evaluator, err := backtestingEvaluatorFactory(ruleCtx, e.evalFactory, user, rule.GetEvalCondition().WithSource("backtesting"), &schedule.AlertingResultsFromRuleState{
+			Manager: stateManager,
+			Rule:    rule,
+		})
+		if err != nil {
+			return nil, errors.Join(ErrInvalidInputData, fmt.Errorf("rule %s: %w", rule.Title, err))
+		}
+
+		extraLabels := state.GetRuleExtraLabels(logger, rule, folderTitle, !e.disableGrafanaFolder)
+		err = evaluator.Eval(ruleCtx, from, time.Duration(interval)*time.Second, length, func(idx int, now time.Time, results eval.Results) error {
+			if idx >= length {
+				return nil
+			}
+			transitions := stateManager.ProcessEvalResults(ruleCtx, now, rule, results, extraLabels, func(_ context.Context, toSend state.StateTransitions) {
+				sent = append(sent, sentAlerts{time: now, alerts: toNotificationAlerts(toSend, e.appUrl)})
+			})
+			for _, t := range transitions {
+				if t.PreviousState == t.State.State && t.PreviousStateReason == t.StateReason {
+					continue
+				}
+				result.Transitions = append(result.Transitions, StateTransition{
+					Time:          now,
+					RuleUID:       rule.UID,
+					RuleTitle:     rule.Title,
+					Labels:        t.Labels,
+					PreviousState: state.FormatStateAndReason(t.PreviousState, t.PreviousStateReason),
+					State:         state.FormatStateAndReason(t.State.State, t.StateReason),
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Title, err)
+		}
+	}
+
+	// rules are evaluated one after another, the results are ordered as if they were evaluated at the same time
+	slices.SortStableFunc(result.Transitions, func(a, b StateTransition) int {
+		return a.Time.Compare(b.Time)
+	})
+	slices.SortStableFunc(sent, func(a, b sentAlerts) int {
+		return a.time.Compare(b.time)
+	})
+
+	if policy != nil {
+		result.Notifications = simulateNotifications(policy, sent, to)
+	}
+
+	logger.Info("Rule group testing finished successfully", "duration", time.Since(start), "transitions", len(result.Transitions), "notifications", len(result.Notifications))
+	return result, nil
+}
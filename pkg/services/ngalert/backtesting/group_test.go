@@ -0,0 +1,134 @@
+package backtesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestEngineTestGroup(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(10 * time.Minute)
+
+	// the condition of each rule is the list of evaluations that are alerting
+	firing := map[string]map[int]bool{
+		"A": {1: true, 2: true, 3: true, 4: true},
+		"B": {6: true},
+	}
+	backtestingEvaluatorFactory = func(ctx context.Context, evalFactory eval.EvaluatorFactory, user identity.Requester, condition models.Condition, r eval.AlertingResultsReader) (backtestingEvaluator, error) {
+		return &fakeBacktestingEvaluator{
+			evalCallback: func(now time.Time) (eval.Results, error) {
+				s := eval.Normal
+				if firing[condition.Condition][int(now.Sub(from)/time.Minute)] {
+					s = eval.Alerting
+				}
+				return eval.Results{
+					eval.ResultGen(eval.WithState(s), eval.WithEvaluatedAt(now), eval.WithLabels(data.Labels{"host": "a"}))(),
+				}, nil
+			},
+		}, nil
+	}
+	t.Cleanup(func() {
+		backtestingEvaluatorFactory = newBacktestingEvaluator
+	})
+
+	engine := NewEngine(nil, nil, tracing.InitializeTracerForTest(), &setting.UnifiedAlertingSettings{}, featuremgmt.WithFeatures())
+
+	gen := models.RuleGen
+	gen = gen.With(gen.WithInterval(time.Minute), gen.WithNoNotificationSettings(), gen.WithLabels(nil))
+	ruleA := gen.With(gen.WithTitle("A"), gen.WithFor(2*time.Minute), gen.WithGroupIndex(1)).GenerateRef()
+	ruleA.Condition = "A"
+	ruleB := gen.With(gen.WithTitle("B"), gen.WithFor(0), gen.WithGroupIndex(2)).GenerateRef()
+	ruleB.Condition = "B"
+
+	route := dispatch.NewRoute(&config.Route{
+		Receiver:       "team",
+		GroupBy:        []model.LabelName{model.AlertNameLabel},
+		GroupWait:      durationPtr(30 * time.Second),
+		GroupInterval:  durationPtr(5 * time.Minute),
+		RepeatInterval: durationPtr(4 * time.Hour),
+	}, nil)
+
+	t.Run("should return state transitions of all rules ordered by time", func(t *testing.T) {
+		result, err := engine.TestGroup(context.Background(), nil, []*models.AlertRule{ruleB, ruleA}, "folder", nil, from, to)
+		require.NoError(t, err)
+		require.Empty(t, result.Notifications)
+
+		type transition struct {
+			minute   int
+			rule     string
+			previous string
+			state    string
+		}
+		expected := []transition{
+			{1, "A", "Normal", "Pending"},
+			{3, "A", "Pending", "Alerting"},
+			{5, "A", "Alerting", "Normal"},
+			{6, "B", "Normal", "Alerting"},
+			{7, "B", "Alerting", "Normal"},
+		}
+		actual := make([]transition, 0, len(result.Transitions))
+		for _, tr := range result.Transitions {
+			require.Equal(t, "a", tr.Labels["host"])
+			actual = append(actual, transition{int(tr.Time.Sub(from) / time.Minute), tr.RuleTitle, tr.PreviousState, tr.State})
+		}
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("should return the notifications of the policy", func(t *testing.T) {
+		result, err := engine.TestGroup(context.Background(), nil, []*models.AlertRule{ruleA, ruleB}, "folder", &NotificationPolicy{Route: route}, from, to)
+		require.NoError(t, err)
+
+		type notification struct {
+			time   time.Time
+			rule   string
+			status string
+		}
+		var actual []notification
+		for _, n := range result.Notifications {
+			require.Equal(t, "team", n.Receiver)
+			require.Len(t, n.Alerts, 1)
+			require.Equal(t, n.GroupLabels[model.AlertNameLabel], n.Alerts[0].Labels[model.AlertNameLabel])
+			actual = append(actual, notification{n.Time, n.GroupLabels[model.AlertNameLabel], n.Alerts[0].Status})
+		}
+		require.Equal(t, []notification{
+			{from.Add(3*time.Minute + 30*time.Second), "A", NotificationStatusFiring},
+			{from.Add(6*time.Minute + 30*time.Second), "B", NotificationStatusFiring},
+			{from.Add(8*time.Minute + 30*time.Second), "A", NotificationStatusResolved},
+		}, actual)
+	})
+
+	t.Run("should fail if rules have different intervals", func(t *testing.T) {
+		ruleC := gen.With(gen.WithInterval(2 * time.Minute)).GenerateRef()
+		_, err := engine.TestGroup(context.Background(), nil, []*models.AlertRule{ruleA, ruleC}, "folder", nil, from, to)
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+
+	t.Run("should fail if the group has no rules", func(t *testing.T) {
+		_, err := engine.TestGroup(context.Background(), nil, nil, "folder", nil, from, to)
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+
+	t.Run("should fail if interval is less than evaluation interval", func(t *testing.T) {
+		_, err := engine.TestGroup(context.Background(), nil, []*models.AlertRule{ruleA}, "folder", nil, from, from.Add(30*time.Second))
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+}
+
+func durationPtr(d time.Duration) *model.Duration {
+	md := model.Duration(d)
+	return &md
+}
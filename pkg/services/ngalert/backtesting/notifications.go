@@ -0,0 +1,262 @@
+package backtesting
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+const (
+	NotificationStatusFiring   = "firing"
+	NotificationStatusResolved = "resolved"
+)
+
+// NotificationPolicy is the routing part of an Alertmanager configuration that the alerts are routed through.
+type NotificationPolicy struct {
+	Route *dispatch.Route
+	// TimeIntervals are the mute and active time intervals that can be referenced by the routes, by name.
+	TimeIntervals map[string][]timeinterval.TimeInterval
+}
+
+// Notification is a notification of a group of alerts that would be sent to a receiver.
+type Notification struct {
+	Time        time.Time
+	Receiver    string
+	GroupLabels data.Labels
+	Alerts      []NotificationAlert
+}
+
+// NotificationAlert is an alert in a notification.
+type NotificationAlert struct {
+	Labels   data.Labels
+	Status   string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// sentAlerts are the alerts that the scheduler sends to the Alertmanager after an evaluation.
+type sentAlerts struct {
+	time   time.Time
+	alerts []NotificationAlert
+}
+
+func toNotificationAlerts(transitions state.StateTransitions, appUrl *url.URL) []NotificationAlert {
+	result := make([]NotificationAlert, 0, len(transitions))
+	for _, t := range transitions {
+		alert := state.StateToPostableAlert(t, appUrl)
+		result = append(result, NotificationAlert{
+			Labels:   data.Labels(alert.Labels),
+			StartsAt: time.Time(alert.StartsAt),
+			EndsAt:   time.Time(alert.EndsAt),
+		})
+	}
+	return result
+}
+
+// aggregationGroup is a group of alerts of a route that are notified together, see dispatch.aggrGroup.
+type aggregationGroup struct {
+	key       string
+	route     *dispatch.Route
+	labels    model.LabelSet
+	alerts    map[model.Fingerprint]NotificationAlert
+	nextFlush time.Time
+	flushed   bool
+}
+
+// notificationLogEntry is the last notification of a group, see nflogpb.Entry.
+type notificationLogEntry struct {
+	time     time.Time
+	firing   map[model.Fingerprint]struct{}
+	resolved map[model.Fingerprint]struct{}
+}
+
+// notificationSimulator replays the alerts sent by the scheduler through the dispatcher and the notification pipeline
+// of the Alertmanager, with a simulated clock. Inhibition rules and silences are not taken into account.
+type notificationSimulator struct {
+	policy        *NotificationPolicy
+	groups        map[string]*aggregationGroup
+	log           map[string]notificationLogEntry
+	notifications []Notification
+}
+
+// simulateNotifications returns the notifications that the Alertmanager would send until the time to,
+// if it received the alerts at the time they were sent. The sent alerts must be ordered by time.
+func simulateNotifications(policy *NotificationPolicy, sent []sentAlerts, to time.Time) []Notification {
+	s := &notificationSimulator{
+		policy: policy,
+		groups: make(map[string]*aggregationGroup),
+		log:    make(map[string]notificationLogEntry),
+	}
+	for _, batch := range sent {
+		if batch.time.After(to) {
+			break
+		}
+		// alerts received at the same time as a flush is due are included in it
+		s.flushUntil(func(t time.Time) bool { return t.Before(batch.time) })
+		for _, alert := range batch.alerts {
+			s.insert(batch.time, alert)
+		}
+	}
+	s.flushUntil(func(t time.Time) bool { return !t.After(to) })
+	return s.notifications
+}
+
+func (s *notificationSimulator) insert(now time.Time, alert NotificationAlert) {
+	lset := make(model.LabelSet, len(alert.Labels))
+	for k, v := range alert.Labels {
+		lset[model.LabelName(k)] = model.LabelValue(v)
+	}
+	for _, route := range s.policy.Route.Match(lset) {
+		groupLabels := getGroupLabels(lset, route)
+		key := route.Key() + ":" + groupLabels.String()
+		group, ok := s.groups[key]
+		if !ok {
+			group = &aggregationGroup{
+				key:       key,
+				route:     route,
+				labels:    groupLabels,
+				alerts:    make(map[model.Fingerprint]NotificationAlert),
+				nextFlush: now.Add(route.RouteOpts.GroupWait),
+			}
+			s.groups[key] = group
+		}
+		group.alerts[lset.Fingerprint()] = alert
+		// the Alertmanager does not wait for alerts that started long ago
+		if !group.flushed && alert.StartsAt.Add(route.RouteOpts.GroupWait).Before(now) {
+			group.nextFlush = now
+		}
+	}
+}
+
+// flushUntil flushes the groups in the order of their flush times for as long as due returns true.
+func (s *notificationSimulator) flushUntil(due func(t time.Time) bool) {
+	for {
+		var next *aggregationGroup
+		for _, g := range s.groups {
+			if next == nil || g.nextFlush.Before(next.nextFlush) || (g.nextFlush.Equal(next.nextFlush) && g.key < next.key) {
+				next = g
+			}
+		}
+		if next == nil || !due(next.nextFlush) {
+			return
+		}
+		s.flush(next)
+	}
+}
+
+func (s *notificationSimulator) flush(g *aggregationGroup) {
+	now := g.nextFlush
+	g.flushed = true
+	g.nextFlush = now.Add(g.route.RouteOpts.GroupInterval)
+
+	firing := make(map[model.Fingerprint]struct{})
+	resolved := make(map[model.Fingerprint]struct{})
+	alerts := make([]NotificationAlert, 0, len(g.alerts))
+	for fp, alert := range g.alerts {
+		if !alert.EndsAt.IsZero() && !alert.EndsAt.After(now) {
+			alert.Status = NotificationStatusResolved
+			resolved[fp] = struct{}{}
+		} else {
+			alert.Status = NotificationStatusFiring
+			firing[fp] = struct{}{}
+		}
+		alerts = append(alerts, alert)
+	}
+
+	logKey := g.route.RouteOpts.Receiver + "/" + g.key
+	if !s.isMuted(g.route, now) && s.needsUpdate(logKey, firing, resolved, g.route.RouteOpts.RepeatInterval, now) {
+		slices.SortFunc(alerts, func(a, b NotificationAlert) int {
+			return strings.Compare(a.Labels.String(), b.Labels.String())
+		})
+		groupLabels := make(data.Labels, len(g.labels))
+		for k, v := range g.labels {
+			groupLabels[string(k)] = string(v)
+		}
+		s.notifications = append(s.notifications, Notification{
+			Time:        now,
+			Receiver:    g.route.RouteOpts.Receiver,
+			GroupLabels: groupLabels,
+			Alerts:      alerts,
+		})
+		s.log[logKey] = notificationLogEntry{time: now, firing: firing, resolved: resolved}
+	}
+
+	for fp := range resolved {
+		delete(g.alerts, fp)
+	}
+	if len(g.alerts) == 0 {
+		delete(s.groups, g.key)
+	}
+}
+
+func (s *notificationSimulator) isMuted(route *dispatch.Route, now time.Time) bool {
+	for _, name := range route.RouteOpts.MuteTimeIntervals {
+		if s.containsTime(name, now) {
+			return true
+		}
+	}
+	if len(route.RouteOpts.ActiveTimeIntervals) == 0 {
+		return false
+	}
+	for _, name := range route.RouteOpts.ActiveTimeIntervals {
+		if s.containsTime(name, now) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *notificationSimulator) containsTime(name string, now time.Time) bool {
+	for _, interval := range s.policy.TimeIntervals[name] {
+		if interval.ContainsTime(now.UTC()) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsUpdate works the same way as the deduplication stage of the notification pipeline, for receivers that send resolved alerts.
+func (s *notificationSimulator) needsUpdate(logKey string, firing, resolved map[model.Fingerprint]struct{}, repeat time.Duration, now time.Time) bool {
+	entry, ok := s.log[logKey]
+	if !ok {
+		return len(firing) > 0
+	}
+	if !isSubset(entry.firing, firing) {
+		return true
+	}
+	if len(firing) == 0 {
+		return len(entry.firing) > 0
+	}
+	if !isSubset(entry.resolved, resolved) {
+		return true
+	}
+	return entry.time.Before(now.Add(-repeat))
+}
+
+// isSubset returns true if all elements of subset are in set.
+func isSubset(set, subset map[model.Fingerprint]struct{}) bool {
+	for k := range subset {
+		if _, ok := set[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func getGroupLabels(lset model.LabelSet, route *dispatch.Route) model.LabelSet {
+	groupLabels := model.LabelSet{}
+	for ln, lv := range lset {
+		if _, ok := route.RouteOpts.GroupBy[ln]; ok || route.RouteOpts.GroupByAll {
+			groupLabels[ln] = lv
+		}
+	}
+	return groupLabels
+}
@@ -0,0 +1,110 @@
+package backtesting
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateNotifications(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newRoute := func(mutate func(r *config.Route)) *dispatch.Route {
+		r := &config.Route{
+			Receiver:       "team",
+			GroupBy:        []model.LabelName{model.AlertNameLabel},
+			GroupWait:      durationPtr(30 * time.Second),
+			GroupInterval:  durationPtr(5 * time.Minute),
+			RepeatInterval: durationPtr(time.Hour),
+		}
+		if mutate != nil {
+			mutate(r)
+		}
+		return dispatch.NewRoute(r, nil)
+	}
+	// firing returns the alerts sent every minute while the alert is firing, and the resolved alert after that
+	firing := func(labels data.Labels, start time.Time, duration time.Duration) []sentAlerts {
+		var result []sentAlerts
+		for now := start; now.Before(start.Add(duration)); now = now.Add(time.Minute) {
+			result = append(result, sentAlerts{
+				time:   now,
+				alerts: []NotificationAlert{{Labels: labels, StartsAt: start, EndsAt: now.Add(4 * time.Minute)}},
+			})
+		}
+		end := start.Add(duration)
+		return append(result, sentAlerts{
+			time:   end,
+			alerts: []NotificationAlert{{Labels: labels, StartsAt: start, EndsAt: end}},
+		})
+	}
+	times := func(notifications []Notification) []time.Duration {
+		result := make([]time.Duration, 0, len(notifications))
+		for _, n := range notifications {
+			result = append(result, n.Time.Sub(from))
+		}
+		return result
+	}
+
+	t.Run("should wait for group wait and repeat after repeat interval", func(t *testing.T) {
+		sent := firing(data.Labels{model.AlertNameLabel: "test"}, from, 2*time.Hour)
+		notifications := simulateNotifications(&NotificationPolicy{Route: newRoute(nil)}, sent, from.Add(3*time.Hour))
+		require.Equal(t, []time.Duration{
+			30 * time.Second,
+			time.Hour + 5*time.Minute + 30*time.Second,
+			2*time.Hour + 30*time.Second,
+		}, times(notifications))
+		require.Equal(t, NotificationStatusFiring, notifications[0].Alerts[0].Status)
+		require.Equal(t, NotificationStatusResolved, notifications[2].Alerts[0].Status)
+		require.Equal(t, data.Labels{model.AlertNameLabel: "test"}, notifications[0].GroupLabels)
+	})
+
+	t.Run("should group alerts by labels", func(t *testing.T) {
+		sent := append(
+			firing(data.Labels{model.AlertNameLabel: "test", "host": "a"}, from, 10*time.Minute),
+			firing(data.Labels{model.AlertNameLabel: "test", "host": "b"}, from.Add(time.Minute), 10*time.Minute)...,
+		)
+		slices.SortStableFunc(sent, func(a, b sentAlerts) int {
+			return a.time.Compare(b.time)
+		})
+		notifications := simulateNotifications(&NotificationPolicy{Route: newRoute(nil)}, sent, from.Add(time.Hour))
+		require.Equal(t, []time.Duration{30 * time.Second, 5*time.Minute + 30*time.Second, 10*time.Minute + 30*time.Second, 15*time.Minute + 30*time.Second}, times(notifications))
+		require.Len(t, notifications[0].Alerts, 1)
+		require.Len(t, notifications[1].Alerts, 2)
+		require.Equal(t, NotificationStatusResolved, notifications[2].Alerts[0].Status)
+		require.Equal(t, NotificationStatusFiring, notifications[2].Alerts[1].Status)
+	})
+
+	t.Run("should not notify when the route is muted", func(t *testing.T) {
+		route := newRoute(func(r *config.Route) {
+			r.MuteTimeIntervals = []string{"night"}
+		})
+		policy := &NotificationPolicy{
+			Route: route,
+			TimeIntervals: map[string][]timeinterval.TimeInterval{
+				"night": {{Times: []timeinterval.TimeRange{{StartMinute: 0, EndMinute: 60}}}},
+			},
+		}
+		sent := firing(data.Labels{model.AlertNameLabel: "test"}, from.Add(10*time.Minute), time.Hour)
+		notifications := simulateNotifications(policy, sent, from.Add(90*time.Minute))
+		// the flushes from 00:10:30 to 00:55:30 are muted
+		require.Equal(t, time.Hour+30*time.Second, times(notifications)[0])
+	})
+
+	t.Run("should not notify about alerts resolved before group wait", func(t *testing.T) {
+		sent := firing(data.Labels{model.AlertNameLabel: "test"}, from, 0)
+		notifications := simulateNotifications(&NotificationPolicy{Route: newRoute(nil)}, sent, from.Add(time.Hour))
+		require.Empty(t, notifications)
+	})
+
+	t.Run("should ignore alerts sent after the end of the range", func(t *testing.T) {
+		sent := firing(data.Labels{model.AlertNameLabel: "test"}, from, 10*time.Minute)
+		notifications := simulateNotifications(&NotificationPolicy{Route: newRoute(nil)}, sent, from.Add(20*time.Second))
+		require.Empty(t, notifications)
+	})
+}
@@ -704,6 +704,11 @@ func evaluateExecutionResult(execResults ExecutionResults, ts time.Time) Results
 			continue
 		}
 
+		// the band of an anomaly expression is returned for visualization next to the numbers that are alerted on
+		if expr.IsAnomalyBand(f) {
+			continue
+		}
+
 		if len(f.TypeIndices(data.FieldTypeTime, data.FieldTypeNullableTime)) > 0 {
 			appendErrRes(&invalidEvalResultFormatError{refID: f.RefID, reason: "looks like time series data, only reduced data can be alerted on."})
 			continue
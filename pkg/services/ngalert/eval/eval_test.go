@@ -212,6 +212,27 @@ func TestEvaluateExecutionResult(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "band of an anomaly expression is ignored",
+			execResults: ExecutionResults{
+				Condition: []*data.Frame{
+					data.NewFrame("",
+						data.NewField("", data.Labels{"a": "b"}, []*float64{util.Pointer(1.0)}),
+					),
+					data.NewFrame("",
+						data.NewField("Time", nil, []time.Time{{}}),
+						data.NewField("", data.Labels{"a": "b", expr.AnomalyBandLabel: "lower"}, []*float64{util.Pointer(2.0)}),
+					),
+				},
+			},
+			expectResultLength: 1,
+			expectResults: Results{
+				{
+					State:    Alerting,
+					Instance: data.Labels{"a": "b"},
+				},
+			},
+		},
 		{
 			desc: "non []*float64 field will produce Error state result",
 			execResults: ExecutionResults{
@@ -1004,6 +1025,53 @@ func TestEvaluate(t *testing.T) {
 	}
 }
 
+func TestEvaluateSQLExpression(t *testing.T) {
+	const query = "SELECT 'a' AS host, 5 AS value UNION ALL SELECT 'b', 15 ORDER BY host"
+
+	cases := []struct {
+		name      string
+		condition models.Condition
+		expected  map[string]State
+	}{{
+		name: "numbers are the condition",
+		condition: models.Condition{
+			Condition: "A",
+			Data: []models.AlertQuery{
+				models.CreateSQLExpression("A", "SELECT host, value > 10 AS firing FROM ("+query+")", string(expr.SQLFormatNumbers)),
+			},
+		},
+		expected: map[string]State{"a": Normal, "b": Alerting},
+	}, {
+		name: "numbers are the input of a threshold",
+		condition: models.Condition{
+			Condition: "B",
+			Data: []models.AlertQuery{
+				models.CreateSQLExpression("A", query, string(expr.SQLFormatNumbers)),
+				models.CreateHysteresisExpression(t, "B", "A", 10, 5),
+			},
+		},
+		expected: map[string]State{"a": Normal, "b": Alerting},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, &fakes.FakeCacheService{}, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, featuremgmt.WithFeatures(featuremgmt.FlagRecoveryThreshold), nil, tracing.InitializeTracerForTest()))
+			evaluator, err := factory.Create(NewContext(context.Background(), &user.SignedInUser{}), tc.condition)
+			require.NoError(t, err)
+
+			results, err := evaluator.Evaluate(context.Background(), time.Now())
+			require.NoError(t, err)
+
+			states := make(map[string]State, len(results))
+			for _, r := range results {
+				require.NoError(t, r.Error)
+				states[r.Instance["host"]] = r.State
+			}
+			require.Equal(t, tc.expected, states)
+		})
+	}
+}
+
 func TestEvaluateRaw(t *testing.T) {
 	t.Run("should timeout if request takes too long", func(t *testing.T) {
 		unexpectedResponse := &backend.QueryDataResponse{}
@@ -32,6 +32,7 @@ type NGAlert struct {
 	historianMetrics            *Historian
 	remoteAlertmanagerMetrics   *RemoteAlertmanager
 	remoteWriterMetrics         *RemoteWriter
+	notificationHistoryMetrics  *NotificationHistory
 }
 
 // NewNGAlert manages the metrics of all the alerting components.
@@ -45,6 +46,7 @@ func NewNGAlert(r prometheus.Registerer) *NGAlert {
 		historianMetrics:            NewHistorianMetrics(r, Subsystem),
 		remoteAlertmanagerMetrics:   NewRemoteAlertmanagerMetrics(r),
 		remoteWriterMetrics:         NewRemoteWriterMetrics(r),
+		notificationHistoryMetrics:  NewNotificationHistoryMetrics(r),
 	}
 }
 
@@ -75,3 +77,7 @@ func (ng *NGAlert) GetRemoteAlertmanagerMetrics() *RemoteAlertmanager {
 func (ng *NGAlert) GetRemoteWriterMetrics() *RemoteWriter {
 	return ng.remoteWriterMetrics
 }
+
+func (ng *NGAlert) GetNotificationHistoryMetrics() *NotificationHistory {
+	return ng.notificationHistoryMetrics
+}
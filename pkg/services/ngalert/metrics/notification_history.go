@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type NotificationHistory struct {
+	DroppedEntries prometheus.Counter
+}
+
+func NewNotificationHistoryMetrics(r prometheus.Registerer) *NotificationHistory {
+	return &NotificationHistory{
+		DroppedEntries: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "notification_history_dropped_entries_total",
+			Help:      "The total number of notification history entries dropped because the write queue was full.",
+		}),
+	}
+}
@@ -40,6 +40,13 @@ type ListReceiversQuery struct {
 	Offset int
 }
 
+// ListDeadLettersQuery represents a query for the notifications that could not be delivered to receivers.
+type ListDeadLettersQuery struct {
+	OrgID    int64
+	Receiver string
+	Limit    int
+}
+
 // ReceiverMetadata contains metadata about a receiver's usage in routes and rules.
 type ReceiverMetadata struct {
 	InUseByRules  []AlertRuleKey
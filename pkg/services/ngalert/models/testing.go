@@ -308,6 +308,12 @@ func (a *AlertRuleMutators) WithTitle(title string) AlertRuleMutator {
 	}
 }
 
+func (a *AlertRuleMutators) WithVersion(version int64) AlertRuleMutator {
+	return func(rule *AlertRule) {
+		rule.Version = version
+	}
+}
+
 func (a *AlertRuleMutators) WithFor(duration time.Duration) AlertRuleMutator {
 	return func(rule *AlertRule) {
 		rule.For = duration
@@ -733,6 +739,25 @@ func CreateReduceExpression(refID string, inputRefID string, reducer string) Ale
 	}
 }
 
+func CreateSQLExpression(refID string, sql string, format string) AlertQuery {
+	model, _ := json.Marshal(map[string]any{
+		"refId":      refID,
+		"type":       "sql",
+		"expression": sql,
+		"format":     format,
+		"datasource": map[string]string{
+			"uid":  expr.DatasourceUID,
+			"type": expr.DatasourceType,
+		},
+	})
+	return AlertQuery{
+		RefID:         refID,
+		QueryType:     expr.DatasourceType,
+		DatasourceUID: expr.DatasourceUID,
+		Model:         model,
+	}
+}
+
 func CreatePrometheusQuery(refID string, expr string, intervalMs int64, maxDataPoints int64, isInstant bool, datasourceUID string) AlertQuery {
 	return AlertQuery{
 		RefID:         refID,
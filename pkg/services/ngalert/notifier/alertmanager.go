@@ -7,12 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	alertingNotify "github.com/grafana/alerting/notify"
 	"github.com/grafana/alerting/receivers"
 	alertingTemplates "github.com/grafana/alerting/templates"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
 
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 
@@ -20,6 +22,7 @@ import (
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/notificationhistory"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/setting"
@@ -59,6 +62,14 @@ type alertmanager struct {
 	decryptFn alertingNotify.GetDecryptedValueFn
 	orgID     int64
 
+	// notificationHistory saves the attempts to deliver notifications. It is nil if the notification history is disabled.
+	notificationHistory *notificationhistory.Recorder
+	// resendQueue resends the notifications that failed. It is nil if the resend queue is disabled.
+	resendQueue *resendQueue
+
+	integrationsMtx sync.RWMutex
+	integrations    *receiverIntegrations
+
 	withAutogen bool
 }
 
@@ -91,7 +102,7 @@ func (m maintenanceOptions) MaintenanceFunc(state alertingNotify.State) (int64,
 
 func NewAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store AlertingStore, stateStore stateStore,
 	peer alertingNotify.ClusterPeer, decryptFn alertingNotify.GetDecryptedValueFn, ns notifications.Service,
-	m *metrics.Alertmanager, withAutogen bool,
+	m *metrics.Alertmanager, notificationHistory *notificationhistory.Recorder, resendQueue *resendQueue, withAutogen bool,
 ) (*alertmanager, error) {
 	nflog, err := stateStore.GetNotificationLog(ctx)
 	if err != nil {
@@ -150,6 +161,9 @@ func NewAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 		decryptFn:           decryptFn,
 		stateStore:          stateStore,
 		logger:              l,
+		notificationHistory: notificationHistory,
+		resendQueue:         resendQueue,
+		integrations:        newReceiverIntegrations(),
 
 		// TODO: Preferably, logic around autogen would be outside of the specific alertmanager implementation so that remote alertmanager will get it for free.
 		withAutogen: withAutogen,
@@ -342,6 +356,12 @@ func (am *alertmanager) applyConfig(cfg *apimodels.PostableUserConfig) (bool, er
 	}
 
 	am.logger.Info("Applying new configuration to Alertmanager", "configHash", fmt.Sprintf("%x", configHash))
+	// Integrations built after the configuration is applied, for example to test receivers, are not added.
+	integrations := newReceiverIntegrations()
+	defer integrations.seal()
+	buildReceiverIntegrations := func(r *alertingNotify.APIReceiver, tmpl *alertingTemplates.Template) ([]*alertingNotify.Integration, error) {
+		return am.buildReceiverIntegrations(r, tmpl, integrations)
+	}
 	err = am.Base.ApplyConfig(AlertingConfiguration{
 		rawAlertmanagerConfig:    rawConfig,
 		configHash:               configHash,
@@ -351,11 +371,14 @@ func (am *alertmanager) applyConfig(cfg *apimodels.PostableUserConfig) (bool, er
 		timeIntervals:            cfg.AlertmanagerConfig.TimeIntervals,
 		templates:                ToTemplateDefinitions(cfg),
 		receivers:                PostableApiAlertingConfigToApiReceivers(cfg.AlertmanagerConfig),
-		receiverIntegrationsFunc: am.buildReceiverIntegrations,
+		receiverIntegrationsFunc: buildReceiverIntegrations,
 	})
 	if err != nil {
 		return false, err
 	}
+	am.integrationsMtx.Lock()
+	am.integrations = integrations
+	am.integrationsMtx.Unlock()
 
 	am.updateConfigMetrics(cfg, len(rawConfig))
 	return true, nil
@@ -384,7 +407,8 @@ func (am *alertmanager) AppURL() string {
 }
 
 // buildReceiverIntegrations builds a list of integration notifiers off of a receiver config.
-func (am *alertmanager) buildReceiverIntegrations(receiver *alertingNotify.APIReceiver, tmpl *alertingTemplates.Template) ([]*alertingNotify.Integration, error) {
+// The integrations built while the configuration is applied are added to applied.
+func (am *alertmanager) buildReceiverIntegrations(receiver *alertingNotify.APIReceiver, tmpl *alertingTemplates.Template, applied *receiverIntegrations) ([]*alertingNotify.Integration, error) {
 	receiverCfg, err := alertingNotify.BuildReceiverConfiguration(context.Background(), receiver, am.decryptFn)
 	if err != nil {
 		return nil, err
@@ -408,9 +432,27 @@ func (am *alertmanager) buildReceiverIntegrations(receiver *alertingNotify.APIRe
 	if err != nil {
 		return nil, err
 	}
+	if am.notificationHistory != nil || am.resendQueue != nil {
+		integrations = am.wrapIntegrations(receiver, integrations, tmpl, applied)
+	}
 	return integrations, nil
 }
 
+// receiverIntegration returns the integration of a receiver of the applied configuration.
+func (am *alertmanager) receiverIntegration(receiver, uid, integration string, index int) (notify.Notifier, error) {
+	am.integrationsMtx.RLock()
+	integrations := am.integrations
+	am.integrationsMtx.RUnlock()
+	if integrations == nil {
+		return nil, errIntegrationNotFound
+	}
+	n, ok := integrations.get(receiver, uid, integration, index)
+	if !ok {
+		return nil, errIntegrationNotFound
+	}
+	return n, nil
+}
+
 // PutAlerts receives the alerts and then sends them through the corresponding route based on whenever the alert has a receiver embedded or not
 func (am *alertmanager) PutAlerts(_ context.Context, postableAlerts apimodels.PostableAlerts) error {
 	alerts := make(alertingNotify.PostableAlerts, 0, len(postableAlerts.PostableAlerts))
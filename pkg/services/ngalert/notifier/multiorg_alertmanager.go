@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/client_golang/prometheus"
 
 	alertingCluster "github.com/grafana/alerting/cluster"
@@ -21,6 +22,8 @@ import (
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/notificationhistory"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
 	"github.com/grafana/grafana/pkg/services/secrets"
@@ -100,6 +103,9 @@ type MultiOrgAlertmanager struct {
 
 	metrics *metrics.MultiOrgAlertmanager
 	ns      notifications.Service
+
+	notificationHistory *notificationhistory.Recorder
+	resendQueue         *resendQueue
 }
 
 type OrgAlertmanagerFactory func(ctx context.Context, orgID int64) (Alertmanager, error)
@@ -112,6 +118,20 @@ func WithAlertmanagerOverride(f func(OrgAlertmanagerFactory) OrgAlertmanagerFact
 	}
 }
 
+// WithNotificationHistory saves the attempts of the Alertmanagers to deliver notifications in the notification history.
+func WithNotificationHistory(r *notificationhistory.Recorder) Option {
+	return func(moa *MultiOrgAlertmanager) {
+		moa.notificationHistory = r
+	}
+}
+
+// WithResendQueue saves the notifications that the Alertmanagers failed to deliver in the resend queue.
+func WithResendQueue(store *resendqueue.Store) Option {
+	return func(moa *MultiOrgAlertmanager) {
+		moa.resendQueue = newResendQueue(store, moa.settings.UnifiedAlerting.ResendQueue, moa.logger.New("component", "resend-queue"))
+	}
+}
+
 func NewMultiOrgAlertmanager(
 	cfg *setting.Cfg,
 	configStore AlertingStore,
@@ -155,7 +175,7 @@ func NewMultiOrgAlertmanager(
 	moa.factory = func(ctx context.Context, orgID int64) (Alertmanager, error) {
 		m := metrics.NewAlertmanagerMetrics(moa.metrics.GetOrCreateOrgRegistry(orgID), l)
 		stateStore := NewFileStore(orgID, kvStore)
-		return NewAlertmanager(ctx, orgID, moa.settings, moa.configStore, stateStore, moa.peer, moa.decryptFn, moa.ns, m, featureManager.IsEnabled(ctx, featuremgmt.FlagAlertingSimplifiedRouting))
+		return NewAlertmanager(ctx, orgID, moa.settings, moa.configStore, stateStore, moa.peer, moa.decryptFn, moa.ns, m, moa.notificationHistory, moa.resendQueue, featureManager.IsEnabled(ctx, featuremgmt.FlagAlertingSimplifiedRouting))
 	}
 
 	for _, opt := range opts {
@@ -233,6 +253,10 @@ func (moa *MultiOrgAlertmanager) setupClustering(cfg *setting.Cfg) error {
 func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
 	moa.logger.Info("Starting MultiOrg Alertmanager")
 
+	if moa.resendQueue != nil {
+		go moa.resendQueue.run(ctx, moa.resendIntegration)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -246,6 +270,21 @@ func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
 	}
 }
 
+// resendIntegration returns the integration of the Alertmanager of the organization that the notification is resent with.
+func (moa *MultiOrgAlertmanager) resendIntegration(orgID int64, item *resendqueue.Item) (notify.Notifier, error) {
+	moa.alertmanagersMtx.RLock()
+	am, ok := moa.alertmanagers[orgID]
+	moa.alertmanagersMtx.RUnlock()
+	if !ok {
+		return nil, ErrAlertmanagerNotFound.Errorf("")
+	}
+	internal, ok := am.(*alertmanager)
+	if !ok {
+		return nil, errors.New("the Alertmanager of the organization does not deliver notifications")
+	}
+	return internal.receiverIntegration(item.Receiver, item.IntegrationUID, item.Integration, item.IntegrationIndex)
+}
+
 func (moa *MultiOrgAlertmanager) LoadAndSyncAlertmanagersForOrgs(ctx context.Context) error {
 	moa.logger.Debug("Synchronizing Alertmanagers for orgs")
 	// First, load all the organizations from the database.
@@ -401,6 +440,20 @@ func (moa *MultiOrgAlertmanager) StopAndWait() {
 	}
 }
 
+// ClusterMembers is implemented by the peers of the HA cluster that know the live members of the cluster.
+type ClusterMembers interface {
+	Members() []string
+	// Name returns the name of this peer as it appears in the members.
+	Name() string
+}
+
+// ClusterMembers returns the members of the HA cluster. It returns false if the peer of the cluster does not track its
+// members, which is the case when high availability is not enabled or uses memberlist instead of redis.
+func (moa *MultiOrgAlertmanager) ClusterMembers() (ClusterMembers, bool) {
+	members, ok := moa.peer.(ClusterMembers)
+	return members, ok
+}
+
 // AlertmanagerFor returns the Alertmanager instance for the organization provided.
 // When the organization does not have an active Alertmanager, it returns a ErrNoAlertmanagerForOrg.
 // When the Alertmanager of the organization is not ready, it returns a ErrAlertmanagerNotReady.
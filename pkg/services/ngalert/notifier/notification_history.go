@@ -0,0 +1,125 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	alertingModels "github.com/grafana/alerting/models"
+	"github.com/grafana/alerting/receivers"
+	alertingTemplates "github.com/grafana/alerting/templates"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/notificationhistory"
+)
+
+// flushAttemptsRetention is how long the number of attempts of a notification is kept when
+// the last attempt is not known, for example because the notification timed out.
+const flushAttemptsRetention = time.Hour
+
+// titleFromSettings returns the title template of an integration, most integrations call it title and email calls it subject.
+func titleFromSettings(settings json.RawMessage) string {
+	var s struct {
+		Title   string `json:"title"`
+		Subject string `json:"subject"`
+	}
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return ""
+	}
+	if s.Title != "" {
+		return s.Title
+	}
+	return s.Subject
+}
+
+// flushKey identifies a notification of an aggregation group. The attempts to deliver
+// a notification share the group key and the time of the flush of the group.
+type flushKey struct {
+	groupKey string
+	now      int64
+}
+
+// historyRecordingNotifier is a notify.Notifier that saves the attempts of the wrapped notifier in the notification history.
+type historyRecordingNotifier struct {
+	notifier  notify.Notifier
+	recorder  *notificationhistory.Recorder
+	orgID     int64
+	receiver  string
+	integType string
+	uid       string
+	index     int
+	title     string
+	tmpl      *alertingTemplates.Template
+
+	mtx      sync.Mutex
+	attempts map[flushKey]int
+}
+
+func (n *historyRecordingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.notifier.Notify(ctx, alerts...)
+	duration := time.Since(start)
+
+	groupKey, _ := notify.GroupKey(ctx)
+	entry := &notificationhistory.Entry{
+		OrgID:            n.orgID,
+		Receiver:         n.receiver,
+		Integration:      n.integType,
+		IntegrationUID:   n.uid,
+		IntegrationIndex: n.index,
+		GroupKey:         groupKey,
+		Title:            n.renderTitle(ctx, alerts),
+		Status:           string(types.Alerts(alerts...).Status()),
+		Result:           notificationhistory.ResultSuccess,
+		Retry:            n.retries(ctx, groupKey, err == nil || !retry),
+		Duration:         duration.Milliseconds(),
+		Epoch:            start.UnixMilli(),
+		Alerts:           make([]notificationhistory.Alert, 0, len(alerts)),
+	}
+	if err != nil {
+		entry.Result = notificationhistory.ResultFailure
+		entry.Error = err.Error()
+	}
+	for _, a := range alerts {
+		entry.Alerts = append(entry.Alerts, notificationhistory.Alert{
+			RuleUID:     string(a.Labels[alertingModels.RuleUIDLabel]),
+			Fingerprint: a.Fingerprint().String(),
+		})
+	}
+	n.recorder.Record(entry)
+
+	return retry, err
+}
+
+// retries returns the number of previous attempts to deliver the notification. If last is true,
+// no more attempts are made and the count is forgotten.
+func (n *historyRecordingNotifier) retries(ctx context.Context, groupKey string, last bool) int {
+	now, ok := notify.Now(ctx)
+	if !ok {
+		return 0
+	}
+	key := flushKey{groupKey: groupKey, now: now.UnixNano()}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	count := n.attempts[key]
+	if last {
+		delete(n.attempts, key)
+	} else {
+		n.attempts[key] = count + 1
+	}
+	for k := range n.attempts {
+		if time.Since(time.Unix(0, k.now)) > flushAttemptsRetention {
+			delete(n.attempts, k)
+		}
+	}
+	return count
+}
+
+func (n *historyRecordingNotifier) renderTitle(ctx context.Context, alerts []*types.Alert) string {
+	var tmplErr error
+	tmpl, _ := receivers.TmplText(ctx, n.tmpl, alerts, LoggerFactory("ngalert.notifier.history"), &tmplErr)
+	return tmpl(n.title)
+}
@@ -0,0 +1,139 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	alertingModels "github.com/grafana/alerting/models"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/notificationhistory"
+)
+
+type fakeHistoryNotifier struct {
+	errs []error
+}
+
+func (f *fakeHistoryNotifier) Notify(context.Context, ...*types.Alert) (bool, error) {
+	if len(f.errs) == 0 {
+		return false, nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err != nil, err
+}
+
+func TestTitleFromSettings(t *testing.T) {
+	require.Equal(t, "{{ .CommonLabels.alertname }}", titleFromSettings([]byte(`{"title": "{{ .CommonLabels.alertname }}"}`)))
+	require.Equal(t, "subject", titleFromSettings([]byte(`{"subject": "subject", "addresses": "a@b.c"}`)))
+	require.Empty(t, titleFromSettings([]byte(`{"url": "http://localhost"}`)))
+	require.Empty(t, titleFromSettings(nil))
+}
+
+func TestIntegrationNotificationHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := notificationhistory.NewStore(db.InitTestDB(t))
+	recorder := notificationhistory.NewRecorder(store, log.NewNopLogger(), metrics.NewNotificationHistoryMetrics(prometheus.NewRegistry()))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = recorder.Run(ctx)
+	}()
+	fake := &fakeHistoryNotifier{}
+	n := &historyRecordingNotifier{
+		notifier:  fake,
+		recorder:  recorder,
+		orgID:     1,
+		receiver:  "team-a",
+		integType: "webhook",
+		uid:       "integration-uid",
+		title:     `{{ .CommonLabels.alertname }} is {{ .Status }}`,
+		tmpl:      templateForTests(t),
+		attempts:  make(map[flushKey]int),
+	}
+
+	alert := func(rule, name string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:   model.LabelSet{alertingModels.RuleUIDLabel: model.LabelValue(rule), "alertname": model.LabelValue(name)},
+			StartsAt: time.Now().Add(-time.Minute),
+		}}
+	}
+	notifyAt := func(groupKey string, now time.Time, alerts ...*types.Alert) {
+		ctx := notify.WithGroupKey(context.Background(), groupKey)
+		ctx = notify.WithNow(ctx, now)
+		_, _ = n.Notify(ctx, alerts...)
+	}
+	find := func(t *testing.T, q notificationhistory.Query, expected int) []notificationhistory.Entry {
+		t.Helper()
+		q.OrgID = 1
+		var entries []notificationhistory.Entry
+		require.Eventually(t, func() bool {
+			var err error
+			entries, err = store.Find(context.Background(), q)
+			require.NoError(t, err)
+			return len(entries) == expected
+		}, 5*time.Second, 10*time.Millisecond)
+		return entries
+	}
+
+	flush := time.Now()
+	// the first notification fails once and is then delivered
+	fake.errs = []error{errors.New("connection refused"), nil}
+	notifyAt("group-1", flush, alert("rule-1", "HighLatency"), alert("rule-2", "HighLatency"))
+	notifyAt("group-1", flush, alert("rule-1", "HighLatency"), alert("rule-2", "HighLatency"))
+	notifyAt("group-2", flush.Add(time.Second), alert("rule-3", "DiskFull"))
+
+	t.Run("every attempt is saved with its result, title and alerts", func(t *testing.T) {
+		entries := find(t, notificationhistory.Query{}, 3)
+		byRetry := map[string]map[int]notificationhistory.Entry{}
+		for _, e := range entries {
+			if byRetry[e.GroupKey] == nil {
+				byRetry[e.GroupKey] = map[int]notificationhistory.Entry{}
+			}
+			byRetry[e.GroupKey][e.Retry] = e
+		}
+
+		failed := byRetry["group-1"][0]
+		require.Equal(t, notificationhistory.ResultFailure, failed.Result)
+		require.Equal(t, "connection refused", failed.Error)
+		delivered := byRetry["group-1"][1]
+		require.Equal(t, notificationhistory.ResultSuccess, delivered.Result)
+		require.Empty(t, delivered.Error)
+		require.Equal(t, "team-a", delivered.Receiver)
+		require.Equal(t, "webhook", delivered.Integration)
+		require.Equal(t, "integration-uid", delivered.IntegrationUID)
+		require.Equal(t, "HighLatency is firing", delivered.Title)
+		require.Equal(t, "firing", delivered.Status)
+		require.Len(t, delivered.Alerts, 2)
+		require.Equal(t, "rule-1", delivered.Alerts[0].RuleUID)
+		require.NotEmpty(t, delivered.Alerts[0].Fingerprint)
+
+		require.Equal(t, 0, byRetry["group-2"][0].Retry)
+		require.Empty(t, n.attempts)
+	})
+
+	t.Run("entries are filtered by receiver, rule and time range", func(t *testing.T) {
+		require.Len(t, find(t, notificationhistory.Query{RuleUID: "rule-3"}, 1), 1)
+		require.Len(t, find(t, notificationhistory.Query{RuleUID: "rule-2"}, 2), 2)
+		find(t, notificationhistory.Query{Receiver: "team-b"}, 0)
+		find(t, notificationhistory.Query{From: time.Now().Add(-2 * time.Hour), To: time.Now().Add(-time.Hour)}, 0)
+	})
+
+	t.Run("entries older than the retention are deleted", func(t *testing.T) {
+		deleted, err := store.DeleteBefore(context.Background(), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(3), deleted)
+		find(t, notificationhistory.Query{}, 0)
+	})
+}
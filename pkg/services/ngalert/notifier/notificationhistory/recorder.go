@@ -0,0 +1,72 @@
+package notificationhistory
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+)
+
+const (
+	// writeTimeout is the maximum time to save an entry.
+	writeTimeout = time.Minute
+	// queueSize is the number of entries that can wait to be saved. Entries recorded when the queue is full are dropped.
+	queueSize = 1000
+)
+
+// Recorder saves the entries of the notification history in the background, so that
+// the delivery of notifications is not delayed by the database.
+type Recorder struct {
+	store   *Store
+	log     log.Logger
+	metrics *metrics.NotificationHistory
+	entries chan *Entry
+}
+
+func NewRecorder(store *Store, logger log.Logger, metrics *metrics.NotificationHistory) *Recorder {
+	return &Recorder{
+		store:   store,
+		log:     logger,
+		metrics: metrics,
+		entries: make(chan *Entry, queueSize),
+	}
+}
+
+// Run saves the recorded entries until the context is cancelled, and then the entries that are still in the queue.
+func (r *Recorder) Run(ctx context.Context) error {
+	for {
+		select {
+		case entry := <-r.entries:
+			r.save(entry)
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-r.entries:
+					r.save(entry)
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// Record queues the entry to be saved in the background. If the queue is full, the entry is dropped.
+func (r *Recorder) Record(entry *Entry) {
+	select {
+	case r.entries <- entry:
+	default:
+		r.metrics.DroppedEntries.Inc()
+		r.log.Warn("Dropped notification history entry because the write queue is full", "receiver", entry.Receiver, "integration", entry.Integration)
+	}
+}
+
+func (r *Recorder) save(entry *Entry) {
+	// The entry is saved with a new context so that the shutdown of the Alertmanager does not interrupt the write.
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := r.store.Save(ctx, entry); err != nil {
+		r.log.Error("Failed to save notification history entry", "receiver", entry.Receiver, "integration", entry.Integration, "error", err)
+	}
+}
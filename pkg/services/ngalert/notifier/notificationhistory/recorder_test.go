@@ -0,0 +1,26 @@
+package notificationhistory
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("should drop entries when the queue is full", func(t *testing.T) {
+		m := metrics.NewNotificationHistoryMetrics(prometheus.NewRegistry())
+		r := NewRecorder(nil, log.NewNopLogger(), m)
+
+		for i := 0; i < queueSize+2; i++ {
+			r.Record(&Entry{Receiver: "test"})
+		}
+
+		require.Len(t, r.entries, queueSize)
+		require.Equal(t, 2.0, testutil.ToFloat64(m.DroppedEntries))
+	})
+}
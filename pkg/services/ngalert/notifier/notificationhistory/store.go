@@ -0,0 +1,182 @@
+package notificationhistory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+const (
+	// ResultSuccess is the result of an attempt that delivered the notification.
+	ResultSuccess = "success"
+	// ResultFailure is the result of an attempt that failed to deliver the notification.
+	ResultFailure = "failure"
+)
+
+const (
+	defaultQueryRange = 6 * time.Hour
+	defaultPageSize   = 1000
+	maximumPageSize   = 5000
+)
+
+// Entry is an attempt to deliver a notification with an integration of a contact point.
+type Entry struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	Receiver         string `xorm:"receiver"`
+	Integration      string `xorm:"integration"`
+	IntegrationUID   string `xorm:"integration_uid"`
+	IntegrationIndex int    `xorm:"integration_index"`
+	GroupKey         string `xorm:"group_key"`
+	Title            string `xorm:"title"`
+	// Status is the status of the notified alerts, either firing or resolved.
+	Status string `xorm:"status"`
+	Result string `xorm:"result"`
+	Error  string `xorm:"error"`
+	// Retry is the number of attempts made before this one to deliver the same notification.
+	Retry int `xorm:"retry"`
+	// Duration of the attempt in milliseconds.
+	Duration int64 `xorm:"duration"`
+	Epoch    int64 `xorm:"epoch"`
+
+	Alerts []Alert `xorm:"-"`
+}
+
+func (Entry) TableName() string {
+	return "notification_history"
+}
+
+// Alert is an alert notified by an entry.
+type Alert struct {
+	ID             int64  `xorm:"pk autoincr 'id'"`
+	OrgID          int64  `xorm:"org_id"`
+	NotificationID int64  `xorm:"notification_id"`
+	RuleUID        string `xorm:"rule_uid"`
+	Fingerprint    string `xorm:"fingerprint"`
+}
+
+func (Alert) TableName() string {
+	return "notification_history_alert"
+}
+
+// Query filters the notification history. Receiver and RuleUID are optional.
+type Query struct {
+	OrgID    int64
+	Receiver string
+	RuleUID  string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// Store saves the notification history in the Grafana database.
+type Store struct {
+	db db.DB
+}
+
+func NewStore(db db.DB) *Store {
+	return &Store{db: db}
+}
+
+// Save saves the entry and its alerts.
+func (s *Store) Save(ctx context.Context, entry *Entry) error {
+	err := s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(entry); err != nil {
+			return err
+		}
+		if len(entry.Alerts) == 0 {
+			return nil
+		}
+		alerts := make([]*Alert, 0, len(entry.Alerts))
+		for i := range entry.Alerts {
+			entry.Alerts[i].OrgID = entry.OrgID
+			entry.Alerts[i].NotificationID = entry.ID
+			alerts = append(alerts, &entry.Alerts[i])
+		}
+		_, err := sess.InsertMulti(alerts)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save notification history entry: %w", err)
+	}
+	return nil
+}
+
+// Find returns the entries that match the query with their alerts, the most recent ones first.
+func (s *Store) Find(ctx context.Context, query Query) ([]Entry, error) {
+	now := time.Now()
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-defaultQueryRange)
+	}
+	limit := query.Limit
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maximumPageSize {
+		limit = maximumPageSize
+	}
+
+	var entries []Entry
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Where("org_id = ? AND epoch >= ? AND epoch <= ?", query.OrgID, query.From.UnixMilli(), query.To.UnixMilli())
+		if query.Receiver != "" {
+			q = q.And("receiver = ?", query.Receiver)
+		}
+		if query.RuleUID != "" {
+			q = q.And("id IN (SELECT notification_id FROM notification_history_alert WHERE org_id = ? AND rule_uid = ?)", query.OrgID, query.RuleUID)
+		}
+		if err := q.Desc("epoch", "id").Limit(limit).Find(&entries); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, 0, len(entries))
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		var alerts []Alert
+		if err := sess.In("notification_id", ids).Asc("id").Find(&alerts); err != nil {
+			return err
+		}
+		byEntry := make(map[int64][]Alert, len(entries))
+		for _, a := range alerts {
+			byEntry[a.NotificationID] = append(byEntry[a.NotificationID], a)
+		}
+		for i := range entries {
+			entries[i].Alerts = byEntry[entries[i].ID]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification history: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteBefore deletes the entries older than t and their alerts. It returns the number of deleted entries.
+func (s *Store) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM notification_history_alert WHERE notification_id IN (SELECT id FROM notification_history WHERE epoch < ?)", t.UnixMilli())
+		if err != nil {
+			return err
+		}
+		res, err := sess.Exec("DELETE FROM notification_history WHERE epoch < ?", t.UnixMilli())
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notification history: %w", err)
+	}
+	return affected, nil
+}
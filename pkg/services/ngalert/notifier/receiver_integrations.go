@@ -0,0 +1,133 @@
+package notifier
+
+import (
+	"strings"
+	"sync"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/grafana/alerting/notify/nfstatus"
+	alertingTemplates "github.com/grafana/alerting/templates"
+	"github.com/prometheus/alertmanager/notify"
+)
+
+// receiverIntegration is an integration of a receiver of the applied configuration.
+type receiverIntegration struct {
+	uid         string
+	integration string
+	index       int
+	notifier    notify.Notifier
+}
+
+// receiverIntegrations are the integrations of the receivers of a configuration of the Alertmanager, they are used
+// to resend notifications. Integrations built after the configuration is applied, for example to test receivers,
+// are not added.
+type receiverIntegrations struct {
+	mtx        sync.RWMutex
+	sealed     bool
+	byReceiver map[string][]receiverIntegration
+}
+
+func newReceiverIntegrations() *receiverIntegrations {
+	return &receiverIntegrations{byReceiver: make(map[string][]receiverIntegration)}
+}
+
+// add adds the integration of the receiver. It returns false if the configuration is already applied.
+func (r *receiverIntegrations) add(receiver string, i receiverIntegration) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.sealed {
+		return false
+	}
+	r.byReceiver[receiver] = append(r.byReceiver[receiver], i)
+	return true
+}
+
+// seal marks the configuration as applied.
+func (r *receiverIntegrations) seal() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.sealed = true
+}
+
+// get returns the integration of the receiver with the UID or, if the integration does not have a UID, with the type and index.
+func (r *receiverIntegrations) get(receiver, uid, integration string, index int) (notify.Notifier, bool) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	for _, i := range r.byReceiver[receiver] {
+		if uid != "" && i.uid == uid || uid == "" && i.integration == integration && i.index == index {
+			return i.notifier, true
+		}
+	}
+	return nil, false
+}
+
+// wrapIntegrations adds the notification history and the resend queue to the integrations of the receiver.
+func (am *alertmanager) wrapIntegrations(
+	receiver *alertingNotify.APIReceiver,
+	integrations []*alertingNotify.Integration,
+	tmpl *alertingTemplates.Template,
+	applied *receiverIntegrations,
+) []*alertingNotify.Integration {
+	configs := integrationConfigs(receiver, integrations)
+	result := make([]*alertingNotify.Integration, 0, len(integrations))
+	for i, integration := range integrations {
+		var uid string
+		title := alertingTemplates.DefaultMessageTitleEmbed
+		if cfg := configs[i]; cfg != nil {
+			uid = cfg.UID
+			if t := titleFromSettings(cfg.Settings); t != "" {
+				title = t
+			}
+		}
+
+		var n notify.Notifier = integration
+		if am.notificationHistory != nil {
+			n = &historyRecordingNotifier{
+				notifier:  n,
+				recorder:  am.notificationHistory,
+				orgID:     am.orgID,
+				receiver:  receiver.Name,
+				integType: integration.Name(),
+				uid:       uid,
+				index:     integration.Index(),
+				title:     title,
+				tmpl:      tmpl,
+				attempts:  make(map[flushKey]int),
+			}
+		}
+		if am.resendQueue != nil && applied.add(receiver.Name, receiverIntegration{uid: uid, integration: integration.Name(), index: integration.Index(), notifier: n}) {
+			n = &resendingNotifier{
+				notifier:    n,
+				queue:       am.resendQueue,
+				orgID:       am.orgID,
+				receiver:    receiver.Name,
+				integration: integration.Name(),
+				uid:         uid,
+				index:       integration.Index(),
+			}
+		}
+		result = append(result, nfstatus.NewIntegration(n, integration, integration.Name(), integration.Index(), receiver.Name))
+	}
+	return result
+}
+
+// integrationConfigs returns the configuration of each integration, or nil if it is not found.
+func integrationConfigs(receiver *alertingNotify.APIReceiver, integrations []*alertingNotify.Integration) []*alertingNotify.GrafanaIntegrationConfig {
+	// The integrations are built per type in the order of their configurations,
+	// so the n-th integration of a type is built from the n-th configuration of that type.
+	configsByType := make(map[string][]*alertingNotify.GrafanaIntegrationConfig)
+	for _, cfg := range receiver.Integrations {
+		t := strings.ToLower(cfg.Type)
+		configsByType[t] = append(configsByType[t], cfg)
+	}
+	seen := make(map[string]int)
+	result := make([]*alertingNotify.GrafanaIntegrationConfig, len(integrations))
+	for i, integration := range integrations {
+		t := strings.ToLower(integration.Name())
+		if cfgs := configsByType[t]; seen[t] < len(cfgs) {
+			result[i] = cfgs[seen[t]]
+		}
+		seen[t]++
+	}
+	return result
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"golang.org/x/exp/maps"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier/legacy_storage"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning/validation"
 	"github.com/grafana/grafana/pkg/services/secrets"
 )
@@ -30,6 +32,8 @@ var (
 		errutil.WithPublic("Provided version '{{ .Public.Version }}' of receiver '{{ .Public.Name }}' does not match current version '{{ .Public.CurrentVersion }}'"),
 	)
 
+	ErrResendQueueDisabled = errutil.NotFound("alerting.notifications.dead-letters.disabled", errutil.WithPublicMessage("Resend queue is not enabled"))
+
 	ErrReceiverDependentResourcesProvenance = errutil.Conflict("alerting.notifications.receivers.usedProvisioned").MustTemplate(
 		"Receiver cannot be renamed because it is used by provisioned {{ if .Public.UsedByRules }}alert rules{{ end }}{{ if .Public.UsedByRoutes }}{{ if .Public.UsedByRules }} and {{ end }}notification policies{{ end }}",
 		errutil.WithPublic(`Receiver cannot be renamed because it is used by provisioned {{ if .Public.UsedByRules }}alert rules{{ end }}{{ if .Public.UsedByRoutes }}{{ if .Public.UsedByRules }} and {{ end }}notification policies{{ end }}. You must update those resources first using the original provision method.`),
@@ -47,6 +51,14 @@ type ReceiverService struct {
 	log                    log.Logger
 	provenanceValidator    validation.ProvenanceStatusTransitionValidator
 	resourcePermissions    ac.ReceiverPermissionsService
+	deadLetters            DeadLetterStore
+}
+
+// DeadLetterStore stores the notifications that could not be delivered after the maximum number of attempts.
+type DeadLetterStore interface {
+	ListDeadLetters(ctx context.Context, q resendqueue.DeadLetterQuery) ([]resendqueue.Item, error)
+	GetDeadLetter(ctx context.Context, orgID, id int64) (*resendqueue.Item, error)
+	Requeue(ctx context.Context, orgID, id int64, at time.Time) error
 }
 
 type alertRuleNotificationSettingsStore interface {
@@ -113,6 +125,11 @@ func NewReceiverService(
 	}
 }
 
+// SetDeadLetterStore enables the dead letters of the receivers.
+func (rs *ReceiverService) SetDeadLetterStore(store DeadLetterStore) {
+	rs.deadLetters = store
+}
+
 // GetReceiver returns a receiver by name.
 // The receiver's secure settings are decrypted if requested and the user has access to do so.
 func (rs *ReceiverService) GetReceiver(ctx context.Context, q models.GetReceiverQuery, user identity.Requester) (*models.Receiver, error) {
@@ -468,6 +485,68 @@ func (rs *ReceiverService) UpdateReceiver(ctx context.Context, r *models.Receive
 	return result, nil
 }
 
+// ListDeadLetters returns the notifications that could not be delivered to the receivers the user has access to,
+// the most recent ones first. The dead letters can be filtered by receiver name.
+func (rs *ReceiverService) ListDeadLetters(ctx context.Context, q models.ListDeadLettersQuery, user identity.Requester) ([]resendqueue.Item, error) {
+	if rs.deadLetters == nil {
+		return nil, ErrResendQueueDisabled.Errorf("")
+	}
+	var uids []string
+	if q.Receiver != "" {
+		uids = []string{legacy_storage.NameToUid(q.Receiver)}
+	}
+	revision, err := rs.cfgStore.Get(ctx, q.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	receivers, err := PostableApiReceiversToReceivers(revision.GetReceivers(uids), nil)
+	if err != nil {
+		return nil, err
+	}
+	receivers, err = rs.authz.FilterRead(ctx, user, receivers...)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(receivers))
+	for _, r := range receivers {
+		names = append(names, r.Name)
+	}
+	return rs.deadLetters.ListDeadLetters(ctx, resendqueue.DeadLetterQuery{
+		OrgID:     q.OrgID,
+		Receivers: names,
+		Limit:     q.Limit,
+	})
+}
+
+// ResendDeadLetter moves a notification that could not be delivered back to the resend queue, to resend it immediately.
+// The user must be allowed to update the receiver of the notification.
+func (rs *ReceiverService) ResendDeadLetter(ctx context.Context, orgID, id int64, user identity.Requester) error {
+	if rs.deadLetters == nil {
+		return ErrResendQueueDisabled.Errorf("")
+	}
+	item, err := rs.deadLetters.GetDeadLetter(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	revision, err := rs.cfgStore.Get(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	postable, err := revision.GetReceiver(legacy_storage.NameToUid(item.Receiver))
+	if err != nil {
+		return err
+	}
+	rcv, err := PostableApiReceiverToReceiver(postable, models.ProvenanceNone)
+	if err != nil {
+		return err
+	}
+	if err := rs.authz.AuthorizeUpdate(ctx, user, rcv); err != nil {
+		return err
+	}
+	return rs.deadLetters.Requeue(ctx, orgID, id, time.Now())
+}
+
 func (rs *ReceiverService) UsedByRules(ctx context.Context, orgID int64, name string) ([]models.AlertRuleKey, error) {
 	keys, err := rs.ruleNotificationsStore.ListNotificationSettings(ctx, models.ListNotificationSettingsQuery{OrgID: orgID, ReceiverName: name})
 	if err != nil {
@@ -0,0 +1,269 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// resendBatchSize is the maximum number of notifications resent every poll interval.
+	resendBatchSize = 100
+	// resendTimeout is the maximum time to resend a notification. The notification is claimed for this time,
+	// so that other instances of Grafana do not resend it at the same time.
+	resendTimeout = time.Minute
+)
+
+var errIntegrationNotFound = errors.New("integration does not exist in the configuration anymore")
+
+// resendIntegrationFunc returns the integration that a notification is resent with.
+type resendIntegrationFunc func(orgID int64, item *resendqueue.Item) (notify.Notifier, error)
+
+// resendQueue saves the notifications that integrations failed to deliver and resends them with an exponential backoff.
+type resendQueue struct {
+	store    *resendqueue.Store
+	settings setting.UnifiedAlertingResendQueueSettings
+	logger   log.Logger
+	clock    clock.Clock
+}
+
+func newResendQueue(store *resendqueue.Store, settings setting.UnifiedAlertingResendQueueSettings, logger log.Logger) *resendQueue {
+	return &resendQueue{
+		store:    store,
+		settings: settings,
+		logger:   logger,
+		clock:    clock.New(),
+	}
+}
+
+// enqueue saves a notification that failed to be delivered. If the failure is not retryable or the integration
+// does not allow more attempts, the notification is saved as a dead letter.
+func (q *resendQueue) enqueue(ctx context.Context, item *resendqueue.Item, alerts []*types.Alert, retry bool, cause error) error {
+	groupKey, _ := notify.GroupKey(ctx)
+	groupLabels, _ := notify.GroupLabels(ctx)
+	rawLabels, err := json.Marshal(groupLabels)
+	if err != nil {
+		return fmt.Errorf("failed to serialize group labels: %w", err)
+	}
+	rawAlerts, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("failed to serialize alerts: %w", err)
+	}
+
+	now := q.clock.Now()
+	item.GroupKey = groupKey
+	item.GroupLabels = string(rawLabels)
+	item.Alerts = string(rawAlerts)
+	item.Created = now.UnixMilli()
+	q.failed(item, now, retry, cause)
+
+	// The Alertmanager may be shutting down, the notification must be saved anyway.
+	writeCtx, cancel := context.WithTimeout(context.Background(), resendTimeout)
+	defer cancel()
+	return q.store.Enqueue(writeCtx, item)
+}
+
+// failed records a failed attempt and schedules the next one.
+func (q *resendQueue) failed(item *resendqueue.Item, now time.Time, retry bool, cause error) {
+	backoff := q.settings.Backoff(item.Integration)
+	item.Attempts++
+	item.LastError = cause.Error()
+	item.Updated = now.UnixMilli()
+	item.Status = resendqueue.StatusPending
+	if !retry || item.Attempts >= backoff.MaxAttempts {
+		item.Status = resendqueue.StatusDead
+		return
+	}
+	item.NextAttempt = now.Add(nextBackoff(backoff, item.Attempts)).UnixMilli()
+}
+
+// nextBackoff returns the time to wait after the given number of failed attempts.
+func nextBackoff(b setting.ResendBackoffSettings, attempts int) time.Duration {
+	d := b.InitialBackoff
+	for i := 1; i < attempts && d < b.MaxBackoff; i++ {
+		d *= 2
+	}
+	if b.MaxBackoff > 0 && d > b.MaxBackoff {
+		return b.MaxBackoff
+	}
+	return d
+}
+
+// run resends the due notifications every poll interval until the context is done.
+func (q *resendQueue) run(ctx context.Context, integrationFn resendIntegrationFunc) {
+	ticker := q.clock.Ticker(q.settings.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.resendDue(ctx, integrationFn)
+		}
+	}
+}
+
+func (q *resendQueue) resendDue(ctx context.Context, integrationFn resendIntegrationFunc) {
+	items, err := q.store.Due(ctx, q.clock.Now(), resendBatchSize)
+	if err != nil {
+		q.logger.Error("Failed to get notifications to resend", "error", err)
+		return
+	}
+	for i := range items {
+		if ctx.Err() != nil {
+			return
+		}
+		item := &items[i]
+		claimed, err := q.store.Claim(ctx, item, q.clock.Now().Add(resendTimeout))
+		if err != nil {
+			q.logger.Error("Failed to claim notification", "id", item.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		q.resend(ctx, item, integrationFn)
+	}
+}
+
+func (q *resendQueue) resend(ctx context.Context, item *resendqueue.Item, integrationFn resendIntegrationFunc) {
+	logger := q.logger.New("id", item.ID, "org", item.OrgID, "receiver", item.Receiver, "integration", item.Integration, "attempts", item.Attempts)
+	retry, err := q.notify(ctx, item, integrationFn)
+	if err == nil {
+		logger.Info("Resent notification")
+		if err := q.store.Delete(ctx, item.ID); err != nil {
+			logger.Error("Failed to delete resent notification", "error", err)
+		}
+		return
+	}
+
+	q.failed(item, q.clock.Now(), retry, err)
+	if item.Status == resendqueue.StatusDead {
+		logger.Warn("Failed to resend notification, giving up", "error", err)
+	} else {
+		logger.Debug("Failed to resend notification", "error", err, "next_attempt", time.UnixMilli(item.NextAttempt))
+	}
+	if err := q.store.Update(ctx, item); err != nil {
+		logger.Error("Failed to update notification", "error", err)
+	}
+}
+
+func (q *resendQueue) notify(ctx context.Context, item *resendqueue.Item, integrationFn resendIntegrationFunc) (bool, error) {
+	n, err := integrationFn(item.OrgID, item)
+	if err != nil {
+		return false, err
+	}
+	var groupLabels model.LabelSet
+	if err := json.Unmarshal([]byte(item.GroupLabels), &groupLabels); err != nil {
+		return false, fmt.Errorf("failed to read group labels: %w", err)
+	}
+	var alerts []*types.Alert
+	if err := json.Unmarshal([]byte(item.Alerts), &alerts); err != nil {
+		return false, fmt.Errorf("failed to read alerts: %w", err)
+	}
+	// The notification is resent as it was first sent, alerts that were firing then are still firing.
+	created := time.UnixMilli(item.Created)
+	for _, a := range alerts {
+		if !a.ResolvedAt(created) {
+			a.EndsAt = time.Time{}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resendTimeout)
+	defer cancel()
+	ctx = notify.WithGroupKey(ctx, item.GroupKey)
+	ctx = notify.WithGroupLabels(ctx, groupLabels)
+	ctx = notify.WithReceiverName(ctx, item.Receiver)
+	ctx = notify.WithNow(ctx, q.clock.Now())
+	return n.Notify(ctx, alerts...)
+}
+
+// resendingNotifier is a notify.Notifier that saves the notifications that the wrapped notifier failed to deliver
+// in the resend queue. The errors are returned to the Alertmanager, which retries the notification until the context
+// of the flush is done. The notification is saved only when the Alertmanager gives up.
+type resendingNotifier struct {
+	notifier    notify.Notifier
+	queue       *resendQueue
+	orgID       int64
+	receiver    string
+	integration string
+	uid         string
+	index       int
+
+	mtx sync.Mutex
+	// pending contains the failed notifications that the Alertmanager may still retry, by group key.
+	pending map[string]*pendingResend
+	// wg waits for the pending notifications that are being saved.
+	wg sync.WaitGroup
+}
+
+type pendingResend struct {
+	stop func() bool
+}
+
+func (n *resendingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	retry, err := n.notifier.Notify(ctx, alerts...)
+
+	groupKey, _ := notify.GroupKey(ctx)
+	n.mtx.Lock()
+	// the previous attempt is retried now, it is not saved when the context is done
+	if p, ok := n.pending[groupKey]; ok {
+		delete(n.pending, groupKey)
+		if p.stop() {
+			n.wg.Done()
+		}
+	}
+	if err == nil || !retry {
+		n.mtx.Unlock()
+		if err != nil {
+			// the Alertmanager does not retry failures that are not retryable
+			n.enqueue(ctx, alerts, retry, err)
+		}
+		return retry, err
+	}
+
+	p := &pendingResend{}
+	n.wg.Add(1)
+	p.stop = context.AfterFunc(ctx, func() {
+		defer n.wg.Done()
+		n.mtx.Lock()
+		if n.pending[groupKey] == p {
+			delete(n.pending, groupKey)
+		}
+		n.mtx.Unlock()
+		n.enqueue(ctx, alerts, retry, err)
+	})
+	if n.pending == nil {
+		n.pending = make(map[string]*pendingResend)
+	}
+	n.pending[groupKey] = p
+	n.mtx.Unlock()
+	return retry, err
+}
+
+func (n *resendingNotifier) enqueue(ctx context.Context, alerts []*types.Alert, retry bool, err error) {
+	item := &resendqueue.Item{
+		OrgID:            n.orgID,
+		Receiver:         n.receiver,
+		Integration:      n.integration,
+		IntegrationUID:   n.uid,
+		IntegrationIndex: n.index,
+	}
+	if qerr := n.queue.enqueue(ctx, item, alerts, retry, err); qerr != nil {
+		n.queue.logger.Error("Failed to save notification in the resend queue", "receiver", n.receiver, "integration", n.integration, "error", qerr)
+		return
+	}
+	n.queue.logger.Debug("Saved notification in the resend queue", "receiver", n.receiver, "integration", n.integration, "status", item.Status, "error", err)
+}
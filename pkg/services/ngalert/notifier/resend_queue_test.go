@@ -0,0 +1,240 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestNextBackoff(t *testing.T) {
+	b := setting.ResendBackoffSettings{MaxAttempts: 10, InitialBackoff: time.Minute, MaxBackoff: 10 * time.Minute}
+	require.Equal(t, time.Minute, nextBackoff(b, 1))
+	require.Equal(t, 2*time.Minute, nextBackoff(b, 2))
+	require.Equal(t, 8*time.Minute, nextBackoff(b, 4))
+	require.Equal(t, 10*time.Minute, nextBackoff(b, 5))
+	require.Equal(t, 10*time.Minute, nextBackoff(b, 100))
+}
+
+func TestResendQueueFailed(t *testing.T) {
+	q := newResendQueue(nil, setting.UnifiedAlertingResendQueueSettings{
+		DefaultBackoff: setting.ResendBackoffSettings{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour},
+		IntegrationBackoff: map[string]setting.ResendBackoffSettings{
+			"email": {MaxAttempts: 1, InitialBackoff: time.Minute, MaxBackoff: time.Hour},
+		},
+	}, log.NewNopLogger())
+	now := time.Now()
+
+	t.Run("retryable failures are scheduled with a backoff", func(t *testing.T) {
+		item := &resendqueue.Item{Integration: "webhook", Attempts: 1}
+		q.failed(item, now, true, errors.New("timeout"))
+		require.Equal(t, resendqueue.StatusPending, item.Status)
+		require.Equal(t, 2, item.Attempts)
+		require.Equal(t, "timeout", item.LastError)
+		require.Equal(t, now.Add(2*time.Minute).UnixMilli(), item.NextAttempt)
+	})
+
+	t.Run("failures are dead letters after the maximum number of attempts", func(t *testing.T) {
+		item := &resendqueue.Item{Integration: "webhook", Attempts: 2}
+		q.failed(item, now, true, errors.New("timeout"))
+		require.Equal(t, resendqueue.StatusDead, item.Status)
+	})
+
+	t.Run("integrations can have their own maximum number of attempts", func(t *testing.T) {
+		item := &resendqueue.Item{Integration: "email"}
+		q.failed(item, now, true, errors.New("timeout"))
+		require.Equal(t, resendqueue.StatusDead, item.Status)
+	})
+
+	t.Run("failures that are not retryable are dead letters", func(t *testing.T) {
+		item := &resendqueue.Item{Integration: "webhook"}
+		q.failed(item, now, false, errors.New("bad request"))
+		require.Equal(t, resendqueue.StatusDead, item.Status)
+	})
+}
+
+func TestReceiverIntegrations(t *testing.T) {
+	r := newReceiverIntegrations()
+	a, b := &fakeHistoryNotifier{}, &fakeHistoryNotifier{}
+	require.True(t, r.add("team-a", receiverIntegration{uid: "uid-a", integration: "webhook", notifier: a}))
+	require.True(t, r.add("team-a", receiverIntegration{integration: "email", index: 1, notifier: b}))
+	r.seal()
+	require.False(t, r.add("team-a", receiverIntegration{uid: "uid-c", integration: "webhook"}))
+
+	n, ok := r.get("team-a", "uid-a", "webhook", 5)
+	require.True(t, ok)
+	require.Same(t, a, n)
+	n, ok = r.get("team-a", "", "email", 1)
+	require.True(t, ok)
+	require.Same(t, b, n)
+	_, ok = r.get("team-a", "uid-c", "webhook", 0)
+	require.False(t, ok)
+	_, ok = r.get("team-b", "uid-a", "webhook", 0)
+	require.False(t, ok)
+}
+
+func TestIntegrationResendQueue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := resendqueue.NewStore(db.InitTestDB(t))
+	q := newResendQueue(store, setting.UnifiedAlertingResendQueueSettings{
+		DefaultBackoff: setting.ResendBackoffSettings{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}, log.NewNopLogger())
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+	q.clock = clk
+	fake := &fakeHistoryNotifier{}
+	n := &resendingNotifier{
+		notifier:    fake,
+		queue:       q,
+		orgID:       1,
+		receiver:    "team-a",
+		integration: "webhook",
+		uid:         "integration-uid",
+	}
+	var resent [][]*types.Alert
+	integrationFn := func(orgID int64, item *resendqueue.Item) (notify.Notifier, error) {
+		require.Equal(t, int64(1), orgID)
+		require.Equal(t, "integration-uid", item.IntegrationUID)
+		return notifierFunc(func(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+			groupKey, _ := notify.GroupKey(ctx)
+			require.Equal(t, "group-1", groupKey)
+			resent = append(resent, alerts)
+			return fake.Notify(ctx, alerts...)
+		}), nil
+	}
+	ctx := notify.WithGroupKey(context.Background(), "group-1")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{"alertname": "HighLatency"})
+	alert := &types.Alert{Alert: model.Alert{
+		Labels:   model.LabelSet{"alertname": "HighLatency"},
+		StartsAt: clk.Now().Add(-time.Minute),
+		EndsAt:   clk.Now().Add(time.Millisecond),
+	}}
+	// notifyUntilDone sends the notification once for every error of the fake notifier, and then stops the retries
+	// like the Alertmanager does when the context of the flush is done.
+	notifyUntilDone := func(t *testing.T) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(ctx)
+		for len(fake.errs) > 0 {
+			_, _ = n.Notify(ctx, alert)
+		}
+		cancel()
+		n.wg.Wait()
+	}
+	waitDue := func(t *testing.T) {
+		t.Helper()
+		clk.Add(time.Millisecond)
+	}
+	deadLetters := func(t *testing.T) []resendqueue.Item {
+		t.Helper()
+		items, err := store.ListDeadLetters(context.Background(), resendqueue.DeadLetterQuery{OrgID: 1})
+		require.NoError(t, err)
+		return items
+	}
+
+	t.Run("failed notifications are retried by the Alertmanager first", func(t *testing.T) {
+		fake.errs = []error{errors.New("connection refused")}
+		ctx, cancel := context.WithCancel(ctx)
+		retry, err := n.Notify(ctx, alert)
+		require.Error(t, err)
+		require.True(t, retry)
+		retry, err = n.Notify(ctx, alert)
+		require.NoError(t, err)
+		require.False(t, retry)
+		cancel()
+		n.wg.Wait()
+
+		due, err := store.Due(context.Background(), clk.Now().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, due)
+	})
+
+	t.Run("failed notifications are resent until they are delivered", func(t *testing.T) {
+		// the Alertmanager gives up after two retries
+		fake.errs = []error{errors.New("connection refused"), errors.New("connection refused"), errors.New("connection refused")}
+		notifyUntilDone(t)
+		fake.errs = []error{errors.New("connection refused")}
+
+		waitDue(t)
+		q.resendDue(context.Background(), integrationFn)
+		require.Len(t, resent, 1)
+		waitDue(t)
+		q.resendDue(context.Background(), integrationFn)
+		require.Len(t, resent, 2)
+		// the alert was firing when the notification was first sent
+		require.True(t, resent[1][0].EndsAt.IsZero())
+
+		due, err := store.Due(context.Background(), clk.Now().Add(time.Hour), 10)
+		require.NoError(t, err)
+		require.Empty(t, due)
+		require.Empty(t, deadLetters(t))
+	})
+
+	t.Run("notifications are dead letters after the maximum number of attempts and can be requeued", func(t *testing.T) {
+		resent = nil
+		fake.errs = []error{errors.New("connection refused")}
+		notifyUntilDone(t)
+		fake.errs = []error{errors.New("connection refused"), errors.New("connection refused")}
+		for i := 0; i < 2; i++ {
+			waitDue(t)
+			q.resendDue(context.Background(), integrationFn)
+		}
+		require.Len(t, resent, 2)
+
+		dead := deadLetters(t)
+		require.Len(t, dead, 1)
+		require.Equal(t, 3, dead[0].Attempts)
+		require.Equal(t, "connection refused", dead[0].LastError)
+		require.Equal(t, "team-a", dead[0].Receiver)
+
+		items, err := store.ListDeadLetters(context.Background(), resendqueue.DeadLetterQuery{OrgID: 1, Receivers: []string{"team-b"}})
+		require.NoError(t, err)
+		require.Empty(t, items)
+
+		require.NoError(t, store.Requeue(context.Background(), 1, dead[0].ID, clk.Now()))
+		require.ErrorIs(t, store.Requeue(context.Background(), 1, dead[0].ID, clk.Now()), resendqueue.ErrItemNotFound)
+		q.resendDue(context.Background(), integrationFn)
+		require.Len(t, resent, 3)
+		require.Empty(t, deadLetters(t))
+	})
+
+	t.Run("notifications are claimed once", func(t *testing.T) {
+		item := &resendqueue.Item{OrgID: 1, Receiver: "team-a", Status: resendqueue.StatusPending, NextAttempt: time.Now().UnixMilli()}
+		require.NoError(t, store.Enqueue(context.Background(), item))
+		stale := *item
+		claimed, err := store.Claim(context.Background(), item, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.True(t, claimed)
+		claimed, err = store.Claim(context.Background(), &stale, time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.False(t, claimed)
+	})
+
+	t.Run("expired dead letters are deleted", func(t *testing.T) {
+		item := &resendqueue.Item{OrgID: 1, Receiver: "team-a", Status: resendqueue.StatusDead, Updated: time.Now().Add(-time.Hour).UnixMilli()}
+		require.NoError(t, store.Enqueue(context.Background(), item))
+		deleted, err := store.DeleteDeadLettersBefore(context.Background(), time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+		_, err = store.GetDeadLetter(context.Background(), 1, item.ID)
+		require.ErrorIs(t, err, resendqueue.ErrItemNotFound)
+	})
+}
+
+type notifierFunc func(ctx context.Context, alerts ...*types.Alert) (bool, error)
+
+func (f notifierFunc) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	return f(ctx, alerts...)
+}
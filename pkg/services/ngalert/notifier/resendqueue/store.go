@@ -0,0 +1,213 @@
+package resendqueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+const (
+	// StatusPending is the status of notifications that are waiting to be resent.
+	StatusPending = "pending"
+	// StatusDead is the status of notifications that could not be delivered after the maximum number of attempts.
+	StatusDead = "dead"
+)
+
+var ErrItemNotFound = errutil.NotFound("alerting.notifications.dead-letters.notFound", errutil.WithPublicMessage("Dead letter not found"))
+
+// Item is a notification that an integration of a contact point failed to deliver.
+type Item struct {
+	ID               int64  `xorm:"pk autoincr 'id'"`
+	OrgID            int64  `xorm:"org_id"`
+	Receiver         string `xorm:"receiver"`
+	Integration      string `xorm:"integration"`
+	IntegrationUID   string `xorm:"integration_uid"`
+	IntegrationIndex int    `xorm:"integration_index"`
+	GroupKey         string `xorm:"group_key"`
+	// GroupLabels and Alerts are the JSON encoded labels of the aggregation group and alerts of the notification.
+	GroupLabels string `xorm:"group_labels"`
+	Alerts      string `xorm:"alerts"`
+	Status      string `xorm:"status"`
+	// Attempts is the number of failed attempts to deliver the notification.
+	Attempts  int    `xorm:"attempts"`
+	LastError string `xorm:"last_error"`
+	// NextAttempt, Created and Updated are timestamps in milliseconds.
+	NextAttempt int64 `xorm:"next_attempt"`
+	Created     int64 `xorm:"created"`
+	Updated     int64 `xorm:"updated"`
+}
+
+func (Item) TableName() string {
+	return "notification_resend_queue"
+}
+
+// DeadLetterQuery filters the dead letters of an organization.
+type DeadLetterQuery struct {
+	OrgID int64
+	// Receivers limits the dead letters to these receivers. If nil, the dead letters of all receivers are returned.
+	Receivers []string
+	Limit     int
+}
+
+// Store saves the resend queue in the Grafana database.
+type Store struct {
+	db db.DB
+}
+
+func NewStore(db db.DB) *Store {
+	return &Store{db: db}
+}
+
+// Enqueue saves a new item in the queue.
+func (s *Store) Enqueue(ctx context.Context, item *Item) error {
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(item)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+	return nil
+}
+
+// Due returns at most limit pending items that are due at the given time, the longest waiting first.
+func (s *Store) Due(ctx context.Context, now time.Time, limit int) ([]Item, error) {
+	var items []Item
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("status = ? AND next_attempt <= ?", StatusPending, now.UnixMilli()).Asc("next_attempt", "id").Limit(limit).Find(&items)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resend queue: %w", err)
+	}
+	return items, nil
+}
+
+// Claim postpones the next attempt of a pending item until the given time, so that other instances of Grafana
+// do not resend it at the same time. It returns false if the item was claimed, changed or deleted in the meantime.
+func (s *Store) Claim(ctx context.Context, item *Item, until time.Time) (bool, error) {
+	var claimed bool
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE notification_resend_queue SET next_attempt = ? WHERE id = ? AND status = ? AND next_attempt = ?",
+			until.UnixMilli(), item.ID, StatusPending, item.NextAttempt)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		claimed = affected == 1
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim notification: %w", err)
+	}
+	if claimed {
+		item.NextAttempt = until.UnixMilli()
+	}
+	return claimed, nil
+}
+
+// Update saves the status, attempts, error and next attempt of an item.
+func (s *Store) Update(ctx context.Context, item *Item) error {
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.ID(item.ID).Cols("status", "attempts", "last_error", "next_attempt", "updated").Update(item)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes an item from the queue.
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.ID(id).Delete(&Item{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification: %w", err)
+	}
+	return nil
+}
+
+// GetDeadLetter returns a dead letter of the organization.
+func (s *Store) GetDeadLetter(ctx context.Context, orgID, id int64) (*Item, error) {
+	item := &Item{}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		exists, err := sess.Where("id = ? AND org_id = ? AND status = ?", id, orgID, StatusDead).Get(item)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrItemNotFound.Errorf("")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ListDeadLetters returns the dead letters that match the query, the most recent ones first.
+func (s *Store) ListDeadLetters(ctx context.Context, q DeadLetterQuery) ([]Item, error) {
+	items := []Item{}
+	if q.Receivers != nil && len(q.Receivers) == 0 {
+		return items, nil
+	}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		query := sess.Where("org_id = ? AND status = ?", q.OrgID, StatusDead)
+		if q.Receivers != nil {
+			query = query.In("receiver", q.Receivers)
+		}
+		if q.Limit > 0 {
+			query = query.Limit(q.Limit)
+		}
+		return query.Desc("updated", "id").Find(&items)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	return items, nil
+}
+
+// Requeue moves a dead letter back to the queue to resend it at the given time with a new number of attempts.
+func (s *Store) Requeue(ctx context.Context, orgID, id int64, at time.Time) error {
+	return s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("UPDATE notification_resend_queue SET status = ?, attempts = 0, next_attempt = ?, updated = ? WHERE id = ? AND org_id = ? AND status = ?",
+			StatusPending, at.UnixMilli(), at.UnixMilli(), id, orgID, StatusDead)
+		if err != nil {
+			return fmt.Errorf("failed to requeue dead letter: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to requeue dead letter: %w", err)
+		}
+		if affected == 0 {
+			return ErrItemNotFound.Errorf("")
+		}
+		return nil
+	})
+}
+
+// DeleteDeadLettersBefore deletes the dead letters that were last updated before t. It returns the number of deleted dead letters.
+func (s *Store) DeleteDeadLettersBefore(ctx context.Context, t time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM notification_resend_queue WHERE status = ? AND updated < ?", StatusDead, t.UnixMilli())
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letters: %w", err)
+	}
+	return affected, nil
+}
@@ -18,6 +18,7 @@ import (
 var (
 	errRuleDeleted   = errors.New("rule deleted")
 	errRuleRestarted = errors.New("rule restarted")
+	errRuleNotOwned  = errors.New("rule is evaluated by another instance")
 )
 
 type ruleFactory interface {
@@ -100,6 +100,9 @@ type schedule struct {
 	tracer tracing.Tracer
 
 	recordingWriter RecordingWriter
+
+	// clusterMembership is set when the evaluation of rules is sharded across the instances of an HA cluster.
+	clusterMembership ClusterMembership
 }
 
 // SchedulerCfg is the scheduler configuration.
@@ -119,6 +122,8 @@ type SchedulerCfg struct {
 	Tracer               tracing.Tracer
 	Log                  log.Logger
 	RecordingWriter      RecordingWriter
+	// ClusterMembership shards the evaluation of rules across the members of the HA cluster. If it is nil, all rules are evaluated.
+	ClusterMembership ClusterMembership
 }
 
 // NewScheduler returns a new scheduler.
@@ -148,6 +153,7 @@ func NewScheduler(cfg SchedulerCfg, stateManager *state.Manager) *schedule {
 		alertsSender:          cfg.AlertSender,
 		tracer:                cfg.Tracer,
 		recordingWriter:       cfg.RecordingWriter,
+		clusterMembership:     cfg.ClusterMembership,
 	}
 
 	return &sch
@@ -217,6 +223,19 @@ func (sch *schedule) schedulePeriodic(ctx context.Context, t *ticker.T) error {
 	}
 }
 
+// syncFollowedRules loads the state of the rules evaluated by other instances of the cluster, so that the API of
+// this instance returns their current state and the state is ready if this instance takes over the rules.
+func (sch *schedule) syncFollowedRules(ctx context.Context, rules []*ngmodels.AlertRule) {
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := sch.stateManager.LoadStateForRule(ctx, rule); err != nil {
+			sch.log.FromContext(ctx).Warn("Failed to sync the state of the rule evaluated by another instance", append(rule.GetKey().LogContext(), "error", err)...)
+		}
+	}
+}
+
 type readyToRunItem struct {
 	ruleRoutine Rule
 	Evaluation
@@ -248,6 +267,12 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 
 	sch.updateRulesMetrics(alertRules)
 
+	ownership := newRuleOwnership(sch.clusterMembership)
+	// rules that are evaluated by other instances of the cluster. Their state is synced from the database.
+	followed := make([]*ngmodels.AlertRule, 0)
+	relinquishedRules := make([]Rule, 0)
+	ownedRules := 0
+
 	readyToRun := make([]readyToRunItem, 0)
 	updatedRules := make([]ngmodels.AlertRuleKeyWithVersion, 0, len(updated)) // this is needed for tests only
 	restartedRules := make([]Rule, 0)
@@ -270,7 +295,6 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		sch.stopAppliedFunc,
 	)
 	for _, item := range alertRules {
-		ruleRoutine, newRoutine := sch.registry.getOrCreate(ctx, item, ruleFactory)
 		key := item.GetKey()
 		logger := sch.log.FromContext(ctx).New(key.LogContext()...)
 
@@ -282,6 +306,26 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 
 		invalidInterval := item.IntervalSeconds%int64(sch.baseInterval.Seconds()) != 0
 
+		itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
+		offset := jitterOffsetInTicks(item, sch.baseInterval, sch.jitterEvaluations)
+		isReadyToRun := item.IntervalSeconds != 0 && !invalidInterval && (tickNum%itemFrequency)-offset == 0
+
+		if !ownership.owns(key) {
+			// The rule is evaluated by another instance of the cluster. Stop the routine if this instance evaluated
+			// the rule before, without touching its state, so that the new owner can continue from it.
+			if ruleRoutine, ok := sch.registry.del(key); ok {
+				logger.Debug("Rule is evaluated by another instance of the cluster, stopping the evaluation routine")
+				relinquishedRules = append(relinquishedRules, ruleRoutine)
+			}
+			if isReadyToRun && item.Type() == ngmodels.RuleTypeAlerting {
+				followed = append(followed, item)
+			}
+			delete(registeredDefinitions, key)
+			continue
+		}
+		ownedRules++
+
+		ruleRoutine, newRoutine := sch.registry.getOrCreate(ctx, item, ruleFactory)
 		if item.Type() != ruleRoutine.Type() {
 			// Restart rules that need it. For now we just replace them, we'll shut them down at the end of the tick.
 			logger.Debug("Rule restarted because type changed", "old", ruleRoutine.Type(), "new", item.Type())
@@ -291,7 +335,15 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		}
 
 		if newRoutine && !invalidInterval {
+			// When rules are sharded, the rule might have been evaluated by another instance, which saved its state.
+			// Load the state before the first evaluation so that pending alerts keep their start time.
+			loadState := sch.clusterMembership != nil && item.Type() == ngmodels.RuleTypeAlerting
 			dispatcherGroup.Go(func() error {
+				if loadState {
+					if err := sch.stateManager.LoadStateForRule(ctx, item); err != nil {
+						logger.Error("Failed to load the state of the rule from the database", "error", err)
+					}
+				}
 				return ruleRoutine.Run()
 			})
 		}
@@ -303,10 +355,6 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 			continue
 		}
 
-		itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
-		offset := jitterOffsetInTicks(item, sch.baseInterval, sch.jitterEvaluations)
-		isReadyToRun := item.IntervalSeconds != 0 && (tickNum%itemFrequency)-offset == 0
-
 		var folderTitle string
 		if !sch.disableGrafanaFolder {
 			title, ok := folderTitles[item.GetFolderKey()]
@@ -379,6 +427,19 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		oldRoutine.Stop(errRuleRestarted)
 	}
 
+	// Stop routines of rules that are now evaluated by other instances, and sync the state of the rules that those
+	// instances evaluated in the previous tick.
+	for _, routine := range relinquishedRules {
+		routine.Stop(errRuleNotOwned)
+	}
+	sch.metrics.OwnedAlertRules.Set(float64(ownedRules))
+	if len(followed) > 0 {
+		dispatcherGroup.Go(func() error {
+			sch.syncFollowedRules(ctx, followed)
+			return nil
+		})
+	}
+
 	// unregister and stop routines of the deleted alert rules
 	toDelete := make([]ngmodels.AlertRuleKey, 0, len(registeredDefinitions))
 	for key := range registeredDefinitions {
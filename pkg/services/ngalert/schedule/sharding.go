@@ -0,0 +1,84 @@
+package schedule
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ClusterMembership is the view of the HA cluster that the evaluation of alert rules is sharded across.
+type ClusterMembership interface {
+	// Members returns the names of the live members of the cluster.
+	Members() []string
+	// Name returns the name of this instance in the members of the cluster.
+	Name() string
+}
+
+// ruleOwnership assigns every alert rule to one member of the cluster using rendezvous hashing. When a member joins or
+// leaves the cluster, only the rules of that member move to other members.
+type ruleOwnership struct {
+	members []string
+	self    string
+}
+
+// newRuleOwnership returns the ownership of rules for the current members of the cluster. If membership is nil, the
+// cluster does not have other members or this instance is not a member yet, e.g. at startup or after a missed
+// heartbeat, this instance owns all rules.
+func newRuleOwnership(membership ClusterMembership) ruleOwnership {
+	if membership == nil {
+		return ruleOwnership{}
+	}
+	members := membership.Members()
+	if len(members) < 2 {
+		return ruleOwnership{}
+	}
+	self := membership.Name()
+	if !slices.Contains(members, self) {
+		return ruleOwnership{}
+	}
+	return ruleOwnership{members: members, self: self}
+}
+
+// sharded returns true if the rules are sharded across more than one member.
+func (o ruleOwnership) sharded() bool {
+	return len(o.members) > 1
+}
+
+// owns returns true if this instance evaluates the rule.
+func (o ruleOwnership) owns(key ngmodels.AlertRuleKey) bool {
+	if !o.sharded() {
+		return true
+	}
+	return o.owner(key) == o.self
+}
+
+// owner returns the member with the highest score for the rule.
+func (o ruleOwnership) owner(key ngmodels.AlertRuleKey) string {
+	var owner string
+	var maxScore uint64
+	for _, m := range o.members {
+		if score := rendezvousScore(m, key); owner == "" || score > maxScore {
+			owner, maxScore = m, score
+		}
+	}
+	return owner
+}
+
+func rendezvousScore(member string, key ngmodels.AlertRuleKey) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(member))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(strconv.FormatInt(key.OrgID, 10)))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key.UID))
+	// FNV does not spread the bits of the last bytes well enough to compare scores, so the hash is finalized as in MurmurHash3.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+type fakeClusterMembership struct {
+	members []string
+	name    string
+}
+
+func (f *fakeClusterMembership) Members() []string { return f.members }
+func (f *fakeClusterMembership) Name() string      { return f.name }
+
+func TestRuleOwnership(t *testing.T) {
+	keys := make([]models.AlertRuleKey, 0, 1000)
+	for i := 0; i < cap(keys); i++ {
+		keys = append(keys, models.AlertRuleKey{OrgID: int64(i%3 + 1), UID: fmt.Sprintf("rule-%d", i)})
+	}
+	members := []string{"peer-a", "peer-b", "peer-c"}
+	ownershipOf := func(members []string, name string) ruleOwnership {
+		return newRuleOwnership(&fakeClusterMembership{members: members, name: name})
+	}
+
+	t.Run("instance owns all rules if cluster does not have other members", func(t *testing.T) {
+		for _, o := range []ruleOwnership{
+			newRuleOwnership(nil),
+			ownershipOf(nil, "peer-a"),
+			ownershipOf([]string{"peer-a"}, "peer-a"),
+		} {
+			require.False(t, o.sharded())
+			for _, key := range keys {
+				require.True(t, o.owns(key))
+			}
+		}
+	})
+
+	t.Run("instance owns all rules if it is not a member of the cluster yet", func(t *testing.T) {
+		// Another member must not be taken for this instance, which would evaluate the rules of that member twice.
+		o := ownershipOf(members, "peer-d")
+		require.False(t, o.sharded())
+		for _, key := range keys {
+			require.True(t, o.owns(key))
+		}
+	})
+
+	t.Run("every rule is owned by exactly one member", func(t *testing.T) {
+		owned := make(map[string]int)
+		for _, key := range keys {
+			owners := 0
+			for i := range members {
+				if ownershipOf(members, members[i]).owns(key) {
+					owned[members[i]]++
+					owners++
+				}
+			}
+			require.Equal(t, 1, owners)
+		}
+		for _, m := range members {
+			require.Greater(t, owned[m], len(keys)/len(members)/2, "rules are not evenly distributed: %v", owned)
+		}
+	})
+
+	t.Run("only rules of the member that left are moved", func(t *testing.T) {
+		before := ownershipOf(members, "peer-a")
+		after := ownershipOf([]string{"peer-a", "peer-c"}, "peer-a")
+		for _, key := range keys {
+			if before.owner(key) != "peer-b" {
+				require.Equal(t, before.owner(key), after.owner(key))
+			}
+		}
+	})
+}
+
+func TestProcessTicksWithSharding(t *testing.T) {
+	ctx := context.Background()
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	ruleStore := newFakeRulesStore()
+	instanceStore := &state.FakeInstanceStore{}
+	sched := setupScheduler(t, ruleStore, instanceStore, nil, nil, nil)
+	membership := &fakeClusterMembership{members: []string{"peer-a", "peer-b"}, name: "peer-a"}
+	sched.clusterMembership = membership
+
+	gen := models.RuleGen
+	var ownedRule, otherRule *models.AlertRule
+	for ownedRule == nil || otherRule == nil {
+		rule := gen.With(gen.WithOrgID(1), gen.WithInterval(time.Second), gen.WithIsPaused(true)).GenerateRef()
+		if newRuleOwnership(membership).owns(rule.GetKey()) {
+			if ownedRule == nil {
+				ownedRule = rule
+				ruleStore.PutRule(ctx, rule)
+			}
+		} else if otherRule == nil {
+			otherRule = rule
+			ruleStore.PutRule(ctx, rule)
+		}
+	}
+	listQueries := func() []models.ListAlertInstancesQuery {
+		var result []models.ListAlertInstancesQuery
+		for _, op := range instanceStore.RecordedOps() {
+			if q, ok := op.(models.ListAlertInstancesQuery); ok {
+				result = append(result, q)
+			}
+		}
+		return result
+	}
+
+	tick := time.Time{}
+
+	t.Run("only owned rules are evaluated", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+
+		scheduled, stopped, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+		require.Len(t, scheduled, 1)
+		require.Equal(t, ownedRule.GetKey(), scheduled[0].rule.GetKey())
+		require.Empty(t, stopped)
+		require.True(t, sched.registry.exists(ownedRule.GetKey()))
+		require.False(t, sched.registry.exists(otherRule.GetKey()))
+
+		all, _ := sched.Rules()
+		require.Len(t, all, 2)
+	})
+
+	t.Run("state of owned rule is loaded before evaluation and state of other rule is synced", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return len(listQueries()) == 2
+		}, time.Second, 10*time.Millisecond)
+		require.ElementsMatch(t, []models.ListAlertInstancesQuery{
+			{RuleOrgID: 1, RuleUID: ownedRule.UID},
+			{RuleOrgID: 1, RuleUID: otherRule.UID},
+		}, listQueries())
+	})
+
+	t.Run("rules are taken over when a member leaves the cluster", func(t *testing.T) {
+		membership.members = []string{"peer-a"}
+		tick = tick.Add(time.Second)
+
+		scheduled, stopped, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+		require.Len(t, scheduled, 2)
+		require.Empty(t, stopped)
+		require.True(t, sched.registry.exists(otherRule.GetKey()))
+	})
+
+	t.Run("routine is stopped without deleting the rule when another member takes over", func(t *testing.T) {
+		membership.members = []string{"peer-a", "peer-b"}
+		routine, ok := sched.registry.rules[otherRule.GetKey()]
+		require.True(t, ok)
+		tick = tick.Add(time.Second)
+
+		scheduled, stopped, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+		require.Len(t, scheduled, 1)
+		require.Empty(t, stopped)
+		require.False(t, sched.registry.exists(otherRule.GetKey()))
+		require.ErrorIs(t, routine.(*alertRule).ctx.Err(), errRuleNotOwned)
+		all, _ := sched.Rules()
+		require.Len(t, all, 2)
+	})
+}
@@ -3,6 +3,8 @@ package historian
 import (
 	"fmt"
 	"strings"
+
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 // BackendType identifies different kinds of state history backends.
@@ -18,6 +20,7 @@ const (
 	BackendTypeLoki        BackendType = "loki"
 	BackendTypeMultiple    BackendType = "multiple"
 	BackendTypeNoop        BackendType = "noop"
+	BackendTypeSQL         BackendType = "sql"
 )
 
 func ParseBackendType(s string) (BackendType, error) {
@@ -28,6 +31,7 @@ func ParseBackendType(s string) (BackendType, error) {
 		BackendTypeLoki:        {},
 		BackendTypeMultiple:    {},
 		BackendTypeNoop:        {},
+		BackendTypeSQL:         {},
 	}
 	p := BackendType(norm)
 	if _, ok := types[p]; !ok {
@@ -35,3 +39,24 @@ func ParseBackendType(s string) (BackendType, error) {
 	}
 	return p, nil
 }
+
+// UsesBackend returns true if the state history is written to the given backend,
+// either as the only backend or as one of multiple backends.
+func UsesBackend(cfg setting.UnifiedAlertingStateHistorySettings, backend BackendType) bool {
+	if !cfg.Enabled {
+		return false
+	}
+	b, err := ParseBackendType(cfg.Backend)
+	if err != nil {
+		return false
+	}
+	if b != BackendTypeMultiple {
+		return b == backend
+	}
+	for _, name := range append([]string{cfg.MultiPrimary}, cfg.MultiSecondaries...) {
+		if b, err := ParseBackendType(name); err == nil && b == backend {
+			return true
+		}
+	}
+	return false
+}
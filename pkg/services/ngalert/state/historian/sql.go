@@ -0,0 +1,404 @@
+package historian
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+)
+
+const (
+	// sqlLabelMaxLength is the maximum length of label names and values in the label index.
+	// Longer names and values are replaced by their hash.
+	sqlLabelMaxLength = 190
+	// sqlMaxRowsPerStatement keeps the number of parameters of a statement below the limits of the databases.
+	sqlMaxRowsPerStatement = 100
+)
+
+// stateHistoryEntry is a state transition of an alert instance.
+type stateHistoryEntry struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	OrgID         int64  `xorm:"org_id"`
+	RuleID        int64  `xorm:"rule_id"`
+	RuleUID       string `xorm:"rule_uid"`
+	RuleTitle     string `xorm:"rule_title"`
+	RuleGroup     string `xorm:"rule_group"`
+	FolderUID     string `xorm:"folder_uid"`
+	DashboardUID  string `xorm:"dashboard_uid"`
+	PanelID       int64  `xorm:"panel_id"`
+	Condition     string `xorm:"rule_condition"`
+	Fingerprint   string `xorm:"fingerprint"`
+	Labels        string `xorm:"labels"`
+	PreviousState string `xorm:"previous_state"`
+	CurrentState  string `xorm:"current_state"`
+	Error         string `xorm:"error"`
+	Values        string `xorm:"state_values"`
+	Epoch         int64  `xorm:"epoch"`
+}
+
+func (stateHistoryEntry) TableName() string {
+	return "alert_state_history"
+}
+
+// stateHistoryLabel is a label of the alert instances with the fingerprint. The labels of an instance
+// are stored once, and the entries are filtered by label through their fingerprint.
+type stateHistoryLabel struct {
+	ID          int64  `xorm:"pk autoincr 'id'"`
+	OrgID       int64  `xorm:"org_id"`
+	Fingerprint string `xorm:"fingerprint"`
+	LabelKey    string `xorm:"label_key"`
+	LabelValue  string `xorm:"label_value"`
+}
+
+func (stateHistoryLabel) TableName() string {
+	return "alert_state_history_label"
+}
+
+// SQLStore saves state history in the Grafana database.
+type SQLStore struct {
+	db db.DB
+}
+
+func NewSQLStore(db db.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// save saves the entries and the labels of the alert instances that are not saved yet.
+func (s *SQLStore) save(ctx context.Context, entries []*stateHistoryEntry, labels map[string]data.Labels) error {
+	orgID := entries[0].OrgID
+	// the labels are saved with the entries, so that an entry is never saved without its labels
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		for batch := range slices.Chunk(entries, sqlMaxRowsPerStatement) {
+			if _, err := sess.InsertMulti(batch); err != nil {
+				return fmt.Errorf("failed to save state history entries: %w", err)
+			}
+		}
+		if err := s.saveLabels(sess, orgID, labels); err != nil {
+			return fmt.Errorf("failed to save state history labels: %w", err)
+		}
+		return nil
+	})
+}
+
+// saveLabels saves the labels of the fingerprints that are not saved yet.
+func (s *SQLStore) saveLabels(sess *db.Session, orgID int64, labels map[string]data.Labels) error {
+	fingerprints := make([]string, 0, len(labels))
+	for fingerprint := range labels {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+	saved := make(map[string]struct{}, len(fingerprints))
+	for batch := range slices.Chunk(fingerprints, sqlMaxRowsPerStatement) {
+		var existing []string
+		err := sess.Table(stateHistoryLabel{}).Distinct("fingerprint").Where("org_id = ?", orgID).In("fingerprint", batch).Find(&existing)
+		if err != nil {
+			return err
+		}
+		for _, fingerprint := range existing {
+			saved[fingerprint] = struct{}{}
+		}
+	}
+
+	var rows []any
+	for _, fingerprint := range fingerprints {
+		if _, ok := saved[fingerprint]; ok {
+			continue
+		}
+		for k, v := range labels[fingerprint] {
+			rows = append(rows, orgID, fingerprint, indexedLabel(k), indexedLabel(v))
+		}
+	}
+	// another instance of Grafana in HA may save the same labels at the same time
+	const columns = 4
+	for batch := range slices.Chunk(rows, sqlMaxRowsPerStatement*columns) {
+		upsertSQL, err := s.db.GetDialect().UpsertMultipleSQL(
+			stateHistoryLabel{}.TableName(),
+			[]string{"org_id", "fingerprint", "label_key"},
+			[]string{"org_id", "fingerprint", "label_key", "label_value"},
+			len(batch)/columns)
+		if err != nil {
+			return err
+		}
+		if _, err := sess.Exec(append([]any{upsertSQL}, batch...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find returns the entries that match the query, at most limit entries, the most recent ones first.
+// If folderUIDs is not empty, only the entries of rules in these folders are returned.
+func (s *SQLStore) find(ctx context.Context, query models.HistoryQuery, folderUIDs []string, limit int) ([]stateHistoryEntry, error) {
+	var entries []stateHistoryEntry
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Where("org_id = ? AND epoch >= ? AND epoch <= ?", query.OrgID, query.From.UnixMilli(), query.To.UnixMilli())
+		if query.RuleUID != "" {
+			q = q.And("rule_uid = ?", query.RuleUID)
+		}
+		if query.DashboardUID != "" {
+			q = q.And("dashboard_uid = ?", query.DashboardUID)
+		}
+		if query.PanelID != 0 {
+			q = q.And("panel_id = ?", query.PanelID)
+		}
+		if len(folderUIDs) > 0 {
+			q = q.In("folder_uid", folderUIDs)
+		}
+		keys := make([]string, 0, len(query.Labels))
+		for k := range query.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			q = q.And("fingerprint IN (SELECT fingerprint FROM alert_state_history_label WHERE org_id = ? AND label_key = ? AND label_value = ?)",
+				query.OrgID, indexedLabel(k), indexedLabel(query.Labels[k]))
+		}
+		return q.Desc("epoch", "id").Limit(limit).Find(&entries)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state history: %w", err)
+	}
+	return entries, nil
+}
+
+// DeleteBefore deletes the entries older than t, and the labels of alert instances that do not have entries anymore.
+// It returns the number of deleted entries.
+func (s *SQLStore) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	var affected int64
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE epoch < ?", t.UnixMilli())
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return nil
+		}
+		_, err = sess.Exec(`DELETE FROM alert_state_history_label WHERE NOT EXISTS (
+			SELECT 1 FROM alert_state_history h WHERE h.org_id = alert_state_history_label.org_id AND h.fingerprint = alert_state_history_label.fingerprint
+		)`)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete state history: %w", err)
+	}
+	return affected, nil
+}
+
+// indexedLabel returns the label name or value as it is saved in the label index. Names and values longer than
+// sqlLabelMaxLength are replaced by their hash, so they neither collide in the index nor match partially.
+func indexedLabel(s string) string {
+	if len(s) <= sqlLabelMaxLength {
+		return s
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// SQLBackend is a state.Historian that records state history to the Grafana database.
+type SQLBackend struct {
+	store     *SQLStore
+	ruleStore RuleStore
+	metrics   *metrics.Historian
+	log       log.Logger
+	ac        AccessControl
+}
+
+func NewSQLBackend(logger log.Logger, store *SQLStore, ruleStore RuleStore, metrics *metrics.Historian, ac AccessControl) *SQLBackend {
+	return &SQLBackend{
+		store:     store,
+		ruleStore: ruleStore,
+		metrics:   metrics,
+		log:       logger,
+		ac:        ac,
+	}
+}
+
+// Record writes a number of state transitions for a given rule to the database.
+func (h *SQLBackend) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
+	logger := h.log.FromContext(ctx)
+	entries, labels := buildStateHistoryEntries(rule, states, logger)
+
+	errCh := make(chan error, 1)
+	if len(entries) == 0 {
+		close(errCh)
+		return errCh
+	}
+
+	// This is a new background job, so let's create a brand new context for it.
+	// We want it to be isolated, i.e. we don't want grafana shutdowns to interrupt this work
+	// immediately but rather try to flush writes.
+	// This also prevents timeouts or other lingering objects (like transactions) from being
+	// incorrectly propagated here from other areas.
+	writeCtx := context.Background()
+	writeCtx, cancel := context.WithTimeout(writeCtx, StateHistoryWriteTimeout)
+	writeCtx = history_model.WithRuleData(writeCtx, rule)
+	writeCtx = trace.ContextWithSpan(writeCtx, trace.SpanFromContext(ctx))
+
+	go func(ctx context.Context) {
+		defer cancel()
+		defer close(errCh)
+		logger := h.log.FromContext(ctx)
+		logger.Debug("Saving state history batch", "samples", len(entries))
+		org := fmt.Sprint(rule.OrgID)
+		h.metrics.WritesTotal.WithLabelValues(org, BackendTypeSQL.String()).Inc()
+		h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(len(entries)))
+
+		if err := h.store.save(ctx, entries, labels); err != nil {
+			logger.Error("Failed to save alert state history batch", "error", err)
+			h.metrics.WritesFailed.WithLabelValues(org, BackendTypeSQL.String()).Inc()
+			h.metrics.TransitionsFailed.WithLabelValues(org).Add(float64(len(entries)))
+			errCh <- fmt.Errorf("failed to save alert state history batch: %w", err)
+			return
+		}
+		logger.Debug("Done saving alert state history batch", "samples", len(entries))
+	}(writeCtx)
+	return errCh
+}
+
+// Query retrieves state history entries from the database and formats them into a dataframe,
+// in the same format as the Loki backend.
+func (h *SQLBackend) Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
+	uids, err := getFolderUIDsForFilter(ctx, h.ac, h.ruleStore, query)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		query.From = now.Add(-defaultQueryRange)
+	}
+	limit := query.Limit
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > maximumPageSize {
+		limit = maximumPageSize
+	}
+
+	entries, err := h.store.find(ctx, query, uids, limit)
+	if err != nil {
+		return nil, err
+	}
+	// the entries are returned most recent first, the frame is ordered by time
+	slices.Reverse(entries)
+
+	times := make([]time.Time, 0, len(entries))
+	lines := make([]json.RawMessage, 0, len(entries))
+	labels := make([]json.RawMessage, 0, len(entries))
+	for _, e := range entries {
+		instanceLabels := map[string]string{}
+		if err := json.Unmarshal([]byte(e.Labels), &instanceLabels); err != nil {
+			return nil, fmt.Errorf("failed to read labels of state history entry: %w", err)
+		}
+		values, err := simplejson.NewJson([]byte(e.Values))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values of state history entry: %w", err)
+		}
+		line, err := json.Marshal(LokiEntry{
+			SchemaVersion:  1,
+			Previous:       e.PreviousState,
+			Current:        e.CurrentState,
+			Error:          e.Error,
+			Values:         values,
+			Condition:      e.Condition,
+			DashboardUID:   e.DashboardUID,
+			PanelID:        e.PanelID,
+			Fingerprint:    e.Fingerprint,
+			RuleTitle:      e.RuleTitle,
+			RuleID:         e.RuleID,
+			RuleUID:        e.RuleUID,
+			InstanceLabels: instanceLabels,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize state history entry: %w", err)
+		}
+		streamLabels, err := json.Marshal(map[string]string{
+			StateHistoryLabelKey: StateHistoryLabelValue,
+			OrgIDLabel:           fmt.Sprint(e.OrgID),
+			GroupLabel:           e.RuleGroup,
+			FolderUIDLabel:       e.FolderUID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize stream labels: %w", err)
+		}
+		times = append(times, time.UnixMilli(e.Epoch))
+		lines = append(lines, line)
+		labels = append(labels, streamLabels)
+	}
+
+	frame := data.NewFrame("states")
+	lbls := data.Labels(map[string]string{})
+	frame.Fields = append(frame.Fields, data.NewField(dfTime, lbls, times))
+	frame.Fields = append(frame.Fields, data.NewField(dfLine, lbls, lines))
+	frame.Fields = append(frame.Fields, data.NewField(dfLabels, lbls, labels))
+	return frame, nil
+}
+
+func buildStateHistoryEntries(rule history_model.RuleMeta, states []state.StateTransition, logger log.Logger) ([]*stateHistoryEntry, map[string]data.Labels) {
+	entries := make([]*stateHistoryEntry, 0, len(states))
+	labels := make(map[string]data.Labels)
+	for _, state := range states {
+		if !shouldRecord(state) {
+			continue
+		}
+
+		sanitizedLabels := removePrivateLabels(state.Labels)
+		lbls, err := json.Marshal(sanitizedLabels)
+		if err != nil {
+			logger.Error("Failed to serialize labels of state, skipping", "error", err)
+			continue
+		}
+		values, err := json.Marshal(valuesAsDataBlob(state.State))
+		if err != nil {
+			logger.Error("Failed to serialize values of state, skipping", "error", err)
+			continue
+		}
+		fingerprint := labelFingerprint(sanitizedLabels)
+		entry := &stateHistoryEntry{
+			OrgID:         rule.OrgID,
+			RuleID:        rule.ID,
+			RuleUID:       rule.UID,
+			RuleTitle:     rule.Title,
+			RuleGroup:     rule.Group,
+			FolderUID:     rule.NamespaceUID,
+			DashboardUID:  rule.DashboardUID,
+			PanelID:       rule.PanelID,
+			Condition:     rule.Condition,
+			Fingerprint:   fingerprint,
+			Labels:        string(lbls),
+			PreviousState: state.PreviousFormatted(),
+			CurrentState:  state.Formatted(),
+			Values:        string(values),
+			Epoch:         state.State.LastEvaluationTime.UnixMilli(),
+		}
+		if state.State.State == eval.Error {
+			entry.Error = state.Error.Error()
+		}
+		entries = append(entries, entry)
+		labels[fingerprint] = sanitizedLabels
+	}
+	return entries, labels
+}
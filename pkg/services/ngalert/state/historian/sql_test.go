@@ -0,0 +1,225 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/folder"
+	acfakes "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol/fakes"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestBuildStateHistoryEntries(t *testing.T) {
+	t.Run("skips non-transitory states", func(t *testing.T) {
+		entries, labels := buildStateHistoryEntries(createTestRule(), singleFromNormal(&state.State{State: eval.Normal}), log.NewNopLogger())
+
+		require.Empty(t, entries)
+		require.Empty(t, labels)
+	})
+
+	t.Run("maps evaluation errors", func(t *testing.T) {
+		entries, _ := buildStateHistoryEntries(createTestRule(), singleFromNormal(&state.State{State: eval.Error, Error: fmt.Errorf("oh no")}), log.NewNopLogger())
+
+		require.Len(t, entries, 1)
+		require.Contains(t, entries[0].Error, "oh no")
+	})
+
+	t.Run("removes private labels", func(t *testing.T) {
+		states := singleFromNormal(&state.State{
+			State:  eval.Alerting,
+			Labels: data.Labels{"a": "b", "__private__": "c"},
+		})
+
+		entries, labels := buildStateHistoryEntries(createTestRule(), states, log.NewNopLogger())
+
+		require.Len(t, entries, 1)
+		require.JSONEq(t, `{"a":"b"}`, entries[0].Labels)
+		require.Equal(t, map[string]data.Labels{entries[0].Fingerprint: {"a": "b"}}, labels)
+	})
+}
+
+func TestUsesBackend(t *testing.T) {
+	require.True(t, UsesBackend(setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "sql"}, BackendTypeSQL))
+	require.True(t, UsesBackend(setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "multiple", MultiPrimary: "annotations", MultiSecondaries: []string{"sql"}}, BackendTypeSQL))
+	require.False(t, UsesBackend(setting.UnifiedAlertingStateHistorySettings{Enabled: false, Backend: "sql"}, BackendTypeSQL))
+	require.False(t, UsesBackend(setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "loki"}, BackendTypeSQL))
+}
+
+func TestIntegrationSQLBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := NewSQLStore(db.InitTestDB(t))
+	rules := fakes.NewRuleStore(t)
+	ac := &acfakes.FakeRuleService{}
+	backend := NewSQLBackend(log.NewNopLogger(), store, rules, metrics.NewHistorianMetrics(prometheus.NewRegistry(), metrics.Subsystem), ac)
+	ctx := context.Background()
+	user := &identity.StaticRequester{OrgID: 1}
+
+	start := time.Now().Truncate(time.Second).Add(-time.Hour)
+	record := func(rule history_model.RuleMeta, at time.Time, labels data.Labels) {
+		t.Helper()
+		states := singleFromNormal(&state.State{
+			State:              eval.Alerting,
+			Labels:             labels,
+			LastEvaluationTime: at,
+			Values:             map[string]float64{"A": 1},
+		})
+		require.NoError(t, <-backend.Record(ctx, rule, states))
+	}
+
+	rule := createTestRule()
+	otherRule := createTestRule()
+	otherRule.UID = "other-rule-uid"
+	otherRule.NamespaceUID = "other-folder"
+	record(rule, start, data.Labels{"host": "a", "team": "x"})
+	record(rule, start.Add(time.Minute), data.Labels{"host": "b", "team": "x"})
+	record(otherRule, start.Add(2*time.Minute), data.Labels{"host": "a", "team": "y"})
+	// the labels of an instance are saved once
+	record(rule, start.Add(3*time.Minute), data.Labels{"host": "a", "team": "x"})
+
+	entries := func(t *testing.T, frame *data.Frame) []LokiEntry {
+		t.Helper()
+		require.Len(t, frame.Fields, 3)
+		result := make([]LokiEntry, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			var entry LokiEntry
+			require.NoError(t, json.Unmarshal(frame.Fields[1].At(i).(json.RawMessage), &entry))
+			result = append(result, entry)
+		}
+		return result
+	}
+
+	t.Run("should return all entries ordered by time", func(t *testing.T) {
+		ac.CanReadAllRulesFunc = func(context.Context, identity.Requester) (bool, error) { return true, nil }
+		frame, err := backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+
+		result := entries(t, frame)
+		require.Len(t, result, 4)
+		require.True(t, start.Equal(frame.Fields[0].At(0).(time.Time)))
+		require.Equal(t, "Normal", result[0].Previous)
+		require.Equal(t, "Alerting", result[0].Current)
+		require.Equal(t, rule.UID, result[0].RuleUID)
+		require.Equal(t, map[string]string{"host": "a", "team": "x"}, result[0].InstanceLabels)
+		require.Equal(t, 1.0, result[0].Values.Get("A").MustFloat64())
+		require.Equal(t, otherRule.UID, result[2].RuleUID)
+	})
+
+	t.Run("should filter by rule and labels", func(t *testing.T) {
+		ac.CanReadAllRulesFunc = func(context.Context, identity.Requester) (bool, error) { return true, nil }
+		frame, err := backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, Labels: map[string]string{"host": "a"}, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, entries(t, frame), 3)
+
+		frame, err = backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, RuleUID: rule.UID, Labels: map[string]string{"host": "a", "team": "x"}, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		result := entries(t, frame)
+		require.Len(t, result, 2)
+		for _, e := range result {
+			require.Equal(t, rule.UID, e.RuleUID)
+			require.Equal(t, "a", e.InstanceLabels["host"])
+		}
+	})
+
+	t.Run("should return the most recent entries up to the limit", func(t *testing.T) {
+		ac.CanReadAllRulesFunc = func(context.Context, identity.Requester) (bool, error) { return true, nil }
+		frame, err := backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, Limit: 2, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, entries(t, frame), 2)
+		require.True(t, start.Add(2*time.Minute).Equal(frame.Fields[0].At(0).(time.Time)))
+	})
+
+	t.Run("should return only entries of folders the user has access to", func(t *testing.T) {
+		ac.CanReadAllRulesFunc = func(context.Context, identity.Requester) (bool, error) { return false, nil }
+		ac.HasAccessInFolderFunc = func(_ context.Context, _ identity.Requester, n models.Namespaced) (bool, error) {
+			return n.GetNamespaceUID() == rule.NamespaceUID, nil
+		}
+		rules.Rules[1] = nil
+		rules.Folders[1] = []*folder.Folder{{UID: rule.NamespaceUID}, {UID: otherRule.NamespaceUID}}
+
+		frame, err := backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		result := entries(t, frame)
+		require.Len(t, result, 3)
+		for _, e := range result {
+			require.Equal(t, rule.UID, e.RuleUID)
+		}
+	})
+
+	t.Run("should delete entries and labels older than the given time", func(t *testing.T) {
+		ac.CanReadAllRulesFunc = func(context.Context, identity.Requester) (bool, error) { return true, nil }
+		deleted, err := store.DeleteBefore(ctx, start.Add(2*time.Minute+time.Second))
+		require.NoError(t, err)
+		require.Equal(t, int64(3), deleted)
+
+		frame, err := backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, entries(t, frame), 1)
+
+		// the labels of host b do not have entries anymore
+		frame, err = backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, Labels: map[string]string{"host": "b"}, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Empty(t, entries(t, frame))
+	})
+
+	t.Run("should match long label names and values exactly", func(t *testing.T) {
+		ac.CanReadAllRulesFunc = func(context.Context, identity.Requester) (bool, error) { return true, nil }
+		long := strings.Repeat("x", sqlLabelMaxLength)
+		record(rule, start.Add(10*time.Minute), data.Labels{long + "1": "a", "value": long + "1"})
+		record(rule, start.Add(11*time.Minute), data.Labels{long + "2": "a", "value": long + "1"})
+		record(rule, start.Add(12*time.Minute), data.Labels{long + "1": "a", "value": long + "2"})
+
+		frame, err := backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, Labels: map[string]string{long + "1": "a"}, Limit: 1, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		result := entries(t, frame)
+		require.Len(t, result, 1)
+		require.Equal(t, long+"2", result[0].InstanceLabels["value"])
+
+		frame, err = backend.Query(ctx, models.HistoryQuery{OrgID: 1, SignedInUser: user, Labels: map[string]string{"value": long + "1"}, Limit: 1, From: start, To: start.Add(time.Hour)})
+		require.NoError(t, err)
+		result = entries(t, frame)
+		require.Len(t, result, 1)
+		require.Equal(t, "a", result[0].InstanceLabels[long+"2"])
+	})
+
+	t.Run("should save more entries than the rows of a statement", func(t *testing.T) {
+		from := start.Add(20 * time.Minute)
+		n := 2*sqlMaxRowsPerStatement + 1
+		rows := make([]*stateHistoryEntry, 0, n)
+		labels := make(map[string]data.Labels, n)
+		for i := 0; i < n; i++ {
+			l := data.Labels{"instance": fmt.Sprint(i)}
+			fingerprint := l.Fingerprint().String()
+			rows = append(rows, &stateHistoryEntry{OrgID: 1, RuleUID: "many", Fingerprint: fingerprint, Labels: "{}", Epoch: from.UnixMilli()})
+			labels[fingerprint] = l
+		}
+		require.NoError(t, store.save(ctx, rows, labels))
+
+		saved, err := store.find(ctx, models.HistoryQuery{OrgID: 1, RuleUID: "many", From: from, To: from}, nil, 2*n)
+		require.NoError(t, err)
+		require.Len(t, saved, n)
+	})
+}
@@ -243,6 +243,27 @@ func TestWarmStateCache(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("states of a single rule can be loaded from the database", func(t *testing.T) {
+		st := state.NewManager(cfg, state.NewNoopPersister())
+		stale := &state.State{AlertRuleUID: rule.UID, OrgID: rule.OrgID, Labels: data.Labels{"stale": "true"}, State: eval.Alerting}
+		setCacheID(stale)
+		st.Put([]*state.State{stale})
+
+		require.NoError(t, st.LoadStateForRule(ctx, rule))
+
+		require.Nil(t, st.Get(stale.OrgID, stale.AlertRuleUID, stale.CacheID))
+		require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), len(expectedEntries))
+		for _, entry := range expectedEntries {
+			setCacheID(entry)
+			cacheEntry := st.Get(entry.OrgID, entry.AlertRuleUID, entry.CacheID)
+
+			if diff := cmp.Diff(entry, cacheEntry, cmpopts.IgnoreFields(state.State{}, "LatestResult")); diff != "" {
+				t.Errorf("Result mismatch (-want +got):\n%s", diff)
+				t.FailNow()
+			}
+		}
+	})
 }
 
 func TestDashboardAnnotations(t *testing.T) {
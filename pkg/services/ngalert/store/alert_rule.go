@@ -169,6 +169,53 @@ func (st DBstore) GetAlertRulesGroupByRuleUID(ctx context.Context, query *ngmode
 	return result, err
 }
 
+// GetAlertRuleVersions returns all versions of the alert rule identified by the key, ordered from the most recent to the oldest.
+// It returns ngmodels.ErrAlertRuleNotFound if the rule has no versions.
+func (st DBstore) GetAlertRuleVersions(ctx context.Context, key ngmodels.AlertRuleKey) (result []*ngmodels.AlertRule, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var versions []alertRuleVersion
+		err := sess.Table(alertRuleVersion{}).Where("rule_org_id = ? AND rule_uid = ?", key.OrgID, key.UID).Desc("version").Find(&versions)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return ngmodels.ErrAlertRuleNotFound
+		}
+		result = make([]*ngmodels.AlertRule, 0, len(versions))
+		for _, v := range versions {
+			converted, err := alertRuleVersionToModelsAlertRule(v, st.Logger)
+			if err != nil {
+				return fmt.Errorf("failed to convert version %d of alert rule %q: %w", v.Version, v.RuleUID, err)
+			}
+			result = append(result, &converted)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetAlertRuleVersion returns the specific version of the alert rule identified by the key.
+// It returns ngmodels.ErrAlertRuleVersionNotFound if the version does not exist.
+func (st DBstore) GetAlertRuleVersion(ctx context.Context, key ngmodels.AlertRuleKey, version int64) (result *ngmodels.AlertRule, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		v := alertRuleVersion{}
+		has, err := sess.Table(alertRuleVersion{}).Where("rule_org_id = ? AND rule_uid = ? AND version = ?", key.OrgID, key.UID, version).Get(&v)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ngmodels.ErrAlertRuleVersionNotFound
+		}
+		converted, err := alertRuleVersionToModelsAlertRule(v, st.Logger)
+		if err != nil {
+			return fmt.Errorf("failed to convert version %d of alert rule %q: %w", v.Version, v.RuleUID, err)
+		}
+		result = &converted
+		return nil
+	})
+	return result, err
+}
+
 // InsertAlertRules is a handler for creating/updating alert rules.
 // Returns the UID and ID of rules that were created in the same order as the input rules.
 func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRule) ([]ngmodels.AlertRuleKeyWithId, error) {
@@ -268,6 +315,7 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateR
 			v := alertRuleToAlertRuleVersion(converted)
 			v.Version++
 			v.ParentVersion = r.Existing.Version
+			v.RestoredFrom = r.RestoredFrom
 			ruleVersions = append(ruleVersions, v)
 		}
 		if len(ruleVersions) > 0 {
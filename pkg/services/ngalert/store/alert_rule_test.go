@@ -1409,6 +1409,89 @@ func TestIncreaseVersionForAllRulesInNamespaces(t *testing.T) {
 	})
 }
 
+func TestIntegrationGetAlertRuleVersions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	cfg := setting.NewCfg()
+	cfg.UnifiedAlerting = setting.UnifiedAlertingSettings{BaseInterval: time.Duration(rand.Int63n(100)+1) * time.Second}
+	sqlStore := db.InitTestReplDB(t)
+	store := &DBstore{
+		SQLStore:      sqlStore,
+		Cfg:           cfg.UnifiedAlerting,
+		FolderService: setupFolderService(t, sqlStore, cfg, featuremgmt.WithFeatures()),
+		Logger:        &logtest.Fake{},
+	}
+	orgID := int64(1)
+	gen := models.RuleGen
+	gen = gen.With(gen.WithIntervalMatching(store.Cfg.BaseInterval), gen.WithOrgID(orgID))
+
+	rule := gen.Generate()
+	ids, err := store.InsertAlertRules(context.Background(), []models.AlertRule{rule})
+	require.NoError(t, err)
+	rule.ID = ids[0].ID
+	rule.UID = ids[0].UID
+
+	current, err := store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+	require.NoError(t, err)
+	titles := []string{current.Title}
+	for i := 0; i < 2; i++ {
+		updated := models.CopyRule(current)
+		updated.Title = util.GenerateShortUID()
+		err = store.UpdateAlertRules(context.Background(), []models.UpdateRule{{Existing: current, New: *updated}})
+		require.NoError(t, err)
+		titles = append(titles, updated.Title)
+		current, err = store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.NoError(t, err)
+	}
+
+	t.Run("should return all versions starting from the latest", func(t *testing.T) {
+		versions, err := store.GetAlertRuleVersions(context.Background(), rule.GetKey())
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		for i, v := range versions {
+			assert.Equal(t, current.Version-int64(i), v.Version)
+			assert.Equal(t, titles[len(titles)-1-i], v.Title)
+			assert.Equal(t, rule.UID, v.UID)
+			assert.Equal(t, rule.Condition, v.Condition)
+		}
+	})
+
+	t.Run("should return specific version", func(t *testing.T) {
+		v, err := store.GetAlertRuleVersion(context.Background(), rule.GetKey(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, titles[0], v.Title)
+		assert.EqualValues(t, 1, v.Version)
+	})
+
+	t.Run("should return ErrAlertRuleVersionNotFound if version does not exist", func(t *testing.T) {
+		_, err := store.GetAlertRuleVersion(context.Background(), rule.GetKey(), current.Version+1)
+		require.ErrorIs(t, err, models.ErrAlertRuleVersionNotFound)
+	})
+
+	t.Run("should return ErrAlertRuleNotFound if rule does not have versions", func(t *testing.T) {
+		_, err := store.GetAlertRuleVersions(context.Background(), models.AlertRuleKey{OrgID: orgID, UID: util.GenerateShortUID()})
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	})
+
+	t.Run("should record the version the rule was restored from", func(t *testing.T) {
+		restored := models.CopyRule(current)
+		restored.Title = titles[0]
+		err := store.UpdateAlertRules(context.Background(), []models.UpdateRule{{Existing: current, New: *restored, RestoredFrom: 1}})
+		require.NoError(t, err)
+
+		var v alertRuleVersion
+		err = sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Table(alertRuleVersion{}).Where("rule_org_id = ? AND rule_uid = ?", orgID, rule.UID).Desc("version").Limit(1).Get(&v)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, current.Version+1, v.Version)
+		assert.Equal(t, current.Version, v.ParentVersion)
+		assert.EqualValues(t, 1, v.RestoredFrom)
+	})
+}
+
 // createAlertRule creates an alert rule in the database and returns it.
 // If a generator is not specified, uniqueness of primary key is not guaranteed.
 func createRule(t *testing.T, store *DBstore, generator *models.AlertRuleGenerator) *models.AlertRule {
@@ -194,3 +194,36 @@ func alertRuleToAlertRuleVersion(rule alertRule) alertRuleVersion {
 		Metadata:             rule.Metadata,
 	}
 }
+
+func alertRuleVersionToModelsAlertRule(v alertRuleVersion, l log.Logger) (models.AlertRule, error) {
+	result, err := alertRuleToModelsAlertRule(alertRule{
+		OrgID:                v.RuleOrgID,
+		Title:                v.Title,
+		Condition:            v.Condition,
+		Data:                 v.Data,
+		Updated:              v.Created,
+		IntervalSeconds:      v.IntervalSeconds,
+		Version:              v.Version,
+		UID:                  v.RuleUID,
+		NamespaceUID:         v.RuleNamespaceUID,
+		RuleGroup:            v.RuleGroup,
+		RuleGroupIndex:       v.RuleGroupIndex,
+		Record:               v.Record,
+		NoDataState:          v.NoDataState,
+		ExecErrState:         v.ExecErrState,
+		For:                  v.For,
+		Annotations:          v.Annotations,
+		Labels:               v.Labels,
+		IsPaused:             v.IsPaused,
+		NotificationSettings: v.NotificationSettings,
+		Metadata:             v.Metadata,
+	}, l)
+	if err != nil {
+		return models.AlertRule{}, err
+	}
+	// alert_rule_version table does not have dashboard_uid and panel_id columns, restore them from the annotations.
+	if err := result.SetDashboardAndPanelFromAnnotations(); err != nil {
+		l.Warn("Failed to restore dashboard and panel of the alert rule version", append(result.GetKey().LogContext(), "version", v.Version, "error", err)...)
+	}
+	return result, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/resendqueue"
 )
 
 type ReceiverServiceMethodCall struct {
@@ -35,6 +36,16 @@ func (f *FakeReceiverService) ListReceivers(ctx context.Context, q models.ListRe
 	return f.ListReceiversFn(ctx, q, u)
 }
 
+func (f *FakeReceiverService) ListDeadLetters(ctx context.Context, q models.ListDeadLettersQuery, u identity.Requester) ([]resendqueue.Item, error) {
+	f.MethodCalls = append(f.MethodCalls, ReceiverServiceMethodCall{Method: "ListDeadLetters", Args: []interface{}{ctx, q}})
+	return nil, nil
+}
+
+func (f *FakeReceiverService) ResendDeadLetter(ctx context.Context, orgID, id int64, u identity.Requester) error {
+	f.MethodCalls = append(f.MethodCalls, ReceiverServiceMethodCall{Method: "ResendDeadLetter", Args: []interface{}{ctx, orgID, id}})
+	return nil
+}
+
 func (f *FakeReceiverService) PopMethodCall() ReceiverServiceMethodCall {
 	if len(f.MethodCalls) == 0 {
 		return ReceiverServiceMethodCall{}
@@ -25,6 +25,8 @@ type RuleStore struct {
 	Hook        func(cmd any) error // use Hook if you need to intercept some query and return an error
 	RecordedOps []any
 	Folders     map[int64][]*folder.Folder
+	// History contains previous versions of rules by rule UID
+	History map[string][]*models.AlertRule
 }
 
 type GenericRecordedQuery struct {
@@ -40,6 +42,7 @@ func NewRuleStore(t *testing.T) *RuleStore {
 			return nil
 		},
 		Folders: map[int64][]*folder.Folder{},
+		History: map[string][]*models.AlertRule{},
 	}
 }
 
@@ -144,6 +147,51 @@ func (f *RuleStore) GetAlertRuleByUID(_ context.Context, q *models.GetAlertRuleB
 	return nil, models.ErrAlertRuleNotFound
 }
 
+func (f *RuleStore) GetAlertRuleVersions(_ context.Context, key models.AlertRuleKey) ([]*models.AlertRule, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	q := GenericRecordedQuery{
+		Name:   "GetAlertRuleVersions",
+		Params: []any{key},
+	}
+	f.RecordedOps = append(f.RecordedOps, q)
+	if err := f.Hook(q); err != nil {
+		return nil, err
+	}
+	var result []*models.AlertRule
+	for _, rule := range f.History[key.UID] {
+		if rule.OrgID == key.OrgID {
+			result = append(result, rule)
+		}
+	}
+	if len(result) == 0 {
+		return nil, models.ErrAlertRuleNotFound
+	}
+	slices.SortFunc(result, func(a, b *models.AlertRule) int {
+		return int(b.Version - a.Version)
+	})
+	return result, nil
+}
+
+func (f *RuleStore) GetAlertRuleVersion(_ context.Context, key models.AlertRuleKey, version int64) (*models.AlertRule, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	q := GenericRecordedQuery{
+		Name:   "GetAlertRuleVersion",
+		Params: []any{key, version},
+	}
+	f.RecordedOps = append(f.RecordedOps, q)
+	if err := f.Hook(q); err != nil {
+		return nil, err
+	}
+	for _, rule := range f.History[key.UID] {
+		if rule.OrgID == key.OrgID && rule.Version == version {
+			return rule, nil
+		}
+	}
+	return nil, models.ErrAlertRuleVersionNotFound
+}
+
 func (f *RuleStore) GetAlertRulesGroupByRuleUID(_ context.Context, q *models.GetAlertRulesGroupByRuleUIDQuery) ([]*models.AlertRule, error) {
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
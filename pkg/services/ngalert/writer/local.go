@@ -0,0 +1,66 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/recordedseries"
+)
+
+const localBackendType = "local"
+
+// LocalWriter writes recorded series to the local store, so they can be queried without an external time series database.
+type LocalWriter struct {
+	store   *recordedseries.Store
+	clock   clock.Clock
+	logger  log.Logger
+	metrics *metrics.RemoteWriter
+}
+
+func NewLocalWriter(store *recordedseries.Store, clock clock.Clock, l log.Logger, metrics *metrics.RemoteWriter) *LocalWriter {
+	return &LocalWriter{
+		store:   store,
+		clock:   clock,
+		logger:  l,
+		metrics: metrics,
+	}
+}
+
+// Write saves the given frames in the local store.
+func (w LocalWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+	l := w.logger.FromContext(ctx)
+	lvs := []string{fmt.Sprint(orgID), localBackendType}
+
+	points, err := PointsFromFrames(name, t, frames, extraLabels)
+	if err != nil {
+		return errors.Join(ErrBadFrame, err)
+	}
+
+	samples := make([]recordedseries.Sample, 0, len(points))
+	for _, p := range points {
+		samples = append(samples, recordedseries.Sample{Metric: p.Name, Labels: p.Labels, Time: p.Metric.T, Value: p.Metric.V})
+	}
+
+	l.Debug("Writing metric", "name", name)
+	writeStart := w.clock.Now()
+	writeErr := w.store.Save(ctx, orgID, samples)
+	w.metrics.WriteDuration.WithLabelValues(lvs...).Observe(w.clock.Now().Sub(writeStart).Seconds())
+
+	status := "200"
+	if writeErr != nil {
+		status = "500"
+	}
+	w.metrics.WritesTotal.WithLabelValues(append(lvs, status)...).Inc()
+
+	if writeErr != nil {
+		return errors.Join(ErrWriteFailure, writeErr)
+	}
+	return nil
+}
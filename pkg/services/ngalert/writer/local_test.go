@@ -0,0 +1,91 @@
+package writer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/recordedseries"
+	"github.com/grafana/grafana/pkg/tests/testsuite"
+)
+
+func TestMain(m *testing.M) {
+	testsuite.Run(m)
+}
+
+func TestIntegrationLocalWriter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := recordedseries.NewStore(db.InitTestDB(t))
+	writer := NewLocalWriter(store, clock.New(), log.NewNopLogger(), metrics.NewRemoteWriterMetrics(prometheus.NewRegistry()))
+	ctx := context.Background()
+
+	series := []map[string]string{{"foo": "1"}, {"foo": "2"}}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		frames := frameGenFromLabels(t, data.FrameTypeNumericMulti, series)
+		require.NoError(t, writer.Write(ctx, "test", from.Add(time.Duration(i)*time.Minute), frames, 1, map[string]string{"extra": "label"}))
+	}
+
+	t.Run("should ignore duplicate samples", func(t *testing.T) {
+		frames := frameGenFromLabels(t, data.FrameTypeNumericMulti, series)
+		require.NoError(t, writer.Write(ctx, "test", from, frames, 1, map[string]string{"extra": "label"}))
+	})
+
+	t.Run("should return a frame for each series", func(t *testing.T) {
+		frames, err := store.Query(ctx, recordedseries.Query{OrgID: 1, Metric: "test", From: from, To: from.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+		for i, frame := range frames {
+			require.Equal(t, 3, frame.Rows())
+			require.Equal(t, data.Labels{"__name__": "test", "extra": "label", "foo": series[i]["foo"]}, frame.Fields[1].Labels)
+			require.Equal(t, from, frame.Fields[0].At(0))
+		}
+	})
+
+	t.Run("should filter series by labels and time", func(t *testing.T) {
+		frames, err := store.Query(ctx, recordedseries.Query{OrgID: 1, Metric: "test", Matchers: map[string]string{"foo": "2"}, From: from.Add(time.Minute), To: from.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, 2, frames[0].Rows())
+		require.Equal(t, "2", frames[0].Fields[1].Labels["foo"])
+	})
+
+	t.Run("should not return series of other organizations", func(t *testing.T) {
+		frames, err := store.Query(ctx, recordedseries.Query{OrgID: 2, Metric: "test", From: from, To: from.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Empty(t, frames)
+	})
+
+	t.Run("should delete samples older than the given time", func(t *testing.T) {
+		deleted, err := store.DeleteBefore(ctx, from.Add(2*time.Minute))
+		require.NoError(t, err)
+		require.Equal(t, int64(4), deleted)
+
+		frames, err := store.Query(ctx, recordedseries.Query{OrgID: 1, Metric: "test", From: from, To: from.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, frames, 2)
+		require.Equal(t, 1, frames[0].Rows())
+	})
+
+	t.Run("should save the new samples of a write with duplicate samples", func(t *testing.T) {
+		frames := frameGenFromLabels(t, data.FrameTypeNumericMulti, []map[string]string{{"foo": "1"}, {"foo": "3"}})
+		require.NoError(t, writer.Write(ctx, "test", from.Add(2*time.Minute), frames, 1, map[string]string{"extra": "label"}))
+
+		frames, err := store.Query(ctx, recordedseries.Query{OrgID: 1, Metric: "test", From: from, To: from.Add(time.Hour)})
+		require.NoError(t, err)
+		require.Len(t, frames, 3)
+		for _, frame := range frames {
+			require.Equal(t, 1, frame.Rows())
+		}
+	})
+}
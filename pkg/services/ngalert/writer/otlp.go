@@ -0,0 +1,153 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const otlpBackendType = "otlp"
+
+const (
+	otlpServiceName = "grafana"
+	otlpScopeName   = "grafana-recording-rule"
+)
+
+// OTLPWriter writes recorded series as OTLP gauges to an OTLP/HTTP metrics endpoint.
+type OTLPWriter struct {
+	client  *http.Client
+	url     string
+	timeout time.Duration
+	clock   clock.Clock
+	logger  log.Logger
+	metrics *metrics.RemoteWriter
+}
+
+func NewOTLPWriter(
+	settings setting.RecordingRuleSettings,
+	httpClientProvider HttpClientProvider,
+	clock clock.Clock,
+	l log.Logger,
+	metrics *metrics.RemoteWriter,
+) (*OTLPWriter, error) {
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	if settings.URL == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+
+	headers := make(http.Header)
+	for k, v := range settings.CustomHeaders {
+		headers.Add(k, v)
+	}
+
+	cl, err := httpClientProvider.New(httpclient.Options{
+		BasicAuth: createAuthOpts(settings.BasicAuthUsername, settings.BasicAuthPassword),
+		Header:    headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTLPWriter{
+		client:  cl,
+		url:     settings.URL,
+		timeout: settings.Timeout,
+		clock:   clock,
+		logger:  l,
+		metrics: metrics,
+	}, nil
+}
+
+// Write writes the given frames to the OTLP metrics endpoint.
+func (w OTLPWriter) Write(ctx context.Context, name string, t time.Time, frames data.Frames, orgID int64, extraLabels map[string]string) error {
+	l := w.logger.FromContext(ctx)
+	lvs := []string{fmt.Sprint(orgID), otlpBackendType}
+
+	points, err := PointsFromFrames(name, t, frames, extraLabels)
+	if err != nil {
+		return errors.Join(ErrBadFrame, err)
+	}
+
+	body, err := pmetricotlp.NewExportRequestFromMetrics(otlpMetricsFromPoints(name, points)).MarshalProto()
+	if err != nil {
+		return errors.Join(ErrWriteFailure, err)
+	}
+
+	l.Debug("Writing metric", "name", name)
+	writeStart := w.clock.Now()
+	statusCode, writeErr := w.send(ctx, body)
+	w.metrics.WriteDuration.WithLabelValues(lvs...).Observe(w.clock.Now().Sub(writeStart).Seconds())
+
+	lvs = append(lvs, fmt.Sprint(statusCode))
+	w.metrics.WritesTotal.WithLabelValues(lvs...).Inc()
+
+	if writeErr != nil {
+		return errors.Join(ErrWriteFailure, writeErr)
+	}
+
+	return nil
+}
+
+func (w OTLPWriter) send(ctx context.Context, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", otlpScopeName)
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return res.StatusCode, fmt.Errorf("unexpected status code %d: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+	return res.StatusCode, nil
+}
+
+func otlpMetricsFromPoints(name string, points []Point) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", otlpServiceName)
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(otlpScopeName)
+
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(name)
+	gauge := m.SetEmptyGauge()
+	for _, p := range points {
+		dp := gauge.DataPoints().AppendEmpty()
+		dp.SetTimestamp(pcommon.NewTimestampFromTime(p.Metric.T))
+		dp.SetDoubleValue(p.Metric.V)
+		for k, v := range p.Labels {
+			dp.Attributes().PutStr(k, v)
+		}
+	}
+	return md
+}
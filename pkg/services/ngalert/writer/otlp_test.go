@@ -0,0 +1,109 @@
+package writer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type sdkClientProvider struct{}
+
+func (sdkClientProvider) New(options ...httpclient.Options) (*http.Client, error) {
+	return httpclient.New(options...)
+}
+
+func TestNewOTLPWriter(t *testing.T) {
+	t.Run("error when URL is empty", func(t *testing.T) {
+		_, err := NewOTLPWriter(setting.RecordingRuleSettings{Timeout: time.Second}, sdkClientProvider{}, clock.New(), log.NewNopLogger(), metrics.NewRemoteWriterMetrics(prometheus.NewRegistry()))
+		require.Error(t, err)
+	})
+}
+
+func TestOTLPWriter_Write(t *testing.T) {
+	var lastRequest pmetricotlp.ExportRequest
+	var lastHeader http.Header
+	statusCode := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		lastHeader = r.Header.Clone()
+		lastRequest = pmetricotlp.NewExportRequest()
+		require.NoError(t, lastRequest.UnmarshalProto(body))
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(srv.Close)
+
+	writer, err := NewOTLPWriter(setting.RecordingRuleSettings{
+		URL:               srv.URL + "/v1/metrics",
+		Timeout:           time.Second,
+		BasicAuthUsername: "user",
+		BasicAuthPassword: "password",
+		CustomHeaders:     map[string]string{"X-Scope-OrgID": "tenant"},
+	}, sdkClientProvider{}, clock.New(), log.NewNopLogger(), metrics.NewRemoteWriterMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Millisecond)
+	series := []map[string]string{{"foo": "1"}, {"foo": "2"}}
+	frames := frameGenFromLabels(t, data.FrameTypeNumericMulti, series)
+
+	t.Run("error when frames are empty", func(t *testing.T) {
+		err := writer.Write(context.Background(), "test", now, data.Frames{data.NewFrame("test")}, 1, nil)
+		require.ErrorIs(t, err, ErrBadFrame)
+	})
+
+	t.Run("writes expected gauge", func(t *testing.T) {
+		statusCode = http.StatusOK
+		err := writer.Write(context.Background(), "test", now, frames, 1, map[string]string{"extra": "label"})
+		require.NoError(t, err)
+
+		require.Equal(t, "application/x-protobuf", lastHeader.Get("Content-Type"))
+		require.Equal(t, "tenant", lastHeader.Get("X-Scope-OrgID"))
+		user, password, ok := (&http.Request{Header: lastHeader}).BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "password", password)
+
+		rms := lastRequest.Metrics().ResourceMetrics()
+		require.Equal(t, 1, rms.Len())
+		serviceName, ok := rms.At(0).Resource().Attributes().Get("service.name")
+		require.True(t, ok)
+		require.Equal(t, "grafana", serviceName.Str())
+
+		ms := rms.At(0).ScopeMetrics().At(0).Metrics()
+		require.Equal(t, 1, ms.Len())
+		require.Equal(t, "test", ms.At(0).Name())
+		require.Equal(t, pmetric.MetricTypeGauge, ms.At(0).Type())
+
+		dps := ms.At(0).Gauge().DataPoints()
+		require.Equal(t, len(series), dps.Len())
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			attrs := dp.Attributes().AsRaw()
+			require.Equal(t, "label", attrs["extra"])
+			require.Equal(t, series[i]["foo"], attrs["foo"])
+			require.Equal(t, now.UnixNano(), dp.Timestamp().AsTime().UnixNano())
+			require.Equal(t, extractValue(t, frames, series[i], data.FrameTypeNumericMulti), dp.DoubleValue())
+		}
+	})
+
+	t.Run("error when endpoint returns unexpected status code", func(t *testing.T) {
+		statusCode = http.StatusBadRequest
+		err := writer.Write(context.Background(), "test", now, frames, 1, nil)
+		require.ErrorIs(t, err, ErrWriteFailure)
+	})
+}
@@ -0,0 +1,419 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// ruleFieldsToIgnoreInPlan contains fields of alert rules that are not set by the provisioning files.
+var ruleFieldsToIgnoreInPlan = [...]string{"ID", "Version", "Updated", "RuleGroupIndex", "Metadata"}
+
+// Plan reads the alerting provisioning files and returns the changes that Provision would make, without applying them.
+// Changes to resources that exist with another provenance than file are marked as provenance conflicts.
+func Plan(ctx context.Context, cfg ProvisionerConfig) ([]plan.Change, error) {
+	logger := log.New("provisioning.alerting")
+	cfgReader := newRulesConfigReader(logger)
+	files, err := cfgReader.readConfig(ctx, cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &alertingPlanner{cfg: cfg}
+	steps := []struct {
+		name string
+		plan func(ctx context.Context, files []*AlertingFile) error
+	}{
+		{"contact points", p.planContactPoints},
+		{"mute times", p.planMuteTimes},
+		{"text templates", p.planTemplates},
+		{"notification policies", p.planPolicies},
+		{"alert rules", p.planRules},
+	}
+	for _, step := range steps {
+		if err := step.plan(ctx, files); err != nil {
+			return nil, fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return p.changes, nil
+}
+
+type alertingPlanner struct {
+	cfg     ProvisionerConfig
+	changes []plan.Change
+}
+
+func (p *alertingPlanner) add(file *AlertingFile, change plan.Change, provenance models.Provenance) {
+	change.Source = filepath.Join(p.cfg.Path, file.Filename)
+	if change.Action != plan.ActionCreate {
+		change.Provenance = string(provenance)
+		change.ProvenanceConflict = provenance != models.ProvenanceFile
+	}
+	p.changes = append(p.changes, change)
+}
+
+func (p *alertingPlanner) planContactPoints(ctx context.Context, files []*AlertingFile) error {
+	cache := map[int64]map[string]definitions.EmbeddedContactPoint{}
+	getContactPoints := func(orgID int64) (map[string]definitions.EmbeddedContactPoint, error) {
+		if cps, ok := cache[orgID]; ok {
+			return cps, nil
+		}
+		cps, err := p.cfg.ContactPointService.GetContactPoints(ctx, provisioning.ContactPointQuery{
+			OrgID:   orgID,
+			Decrypt: true,
+		}, provisionerUser(orgID))
+		if err != nil {
+			return nil, err
+		}
+		cache[orgID] = make(map[string]definitions.EmbeddedContactPoint, len(cps))
+		for _, cp := range cps {
+			cache[orgID][cp.UID] = cp
+		}
+		return cache[orgID], nil
+	}
+
+	for _, file := range files {
+		for _, contactPointsConfig := range file.ContactPoints {
+			existing, err := getContactPoints(contactPointsConfig.OrgID)
+			if err != nil {
+				return err
+			}
+			for _, contactPoint := range contactPointsConfig.ContactPoints {
+				change := plan.Change{
+					Resource: plan.ResourceContactPoint,
+					Action:   plan.ActionCreate,
+					OrgID:    contactPointsConfig.OrgID,
+					Name:     contactPoint.Name,
+					UID:      contactPoint.UID,
+				}
+				current, ok := existing[contactPoint.UID]
+				if ok {
+					fields, err := contactPointChangedFields(current, contactPoint)
+					if err != nil {
+						return err
+					}
+					if len(fields) == 0 && current.Provenance == string(models.ProvenanceFile) {
+						continue
+					}
+					change.Action = plan.ActionUpdate
+					change.Fields = fields
+				}
+				p.add(file, change, models.Provenance(current.Provenance))
+			}
+		}
+		for _, cp := range file.DeleteContactPoints {
+			existing, err := getContactPoints(cp.OrgID)
+			if err != nil {
+				return err
+			}
+			current, ok := existing[cp.UID]
+			if !ok {
+				continue
+			}
+			p.add(file, plan.Change{
+				Resource: plan.ResourceContactPoint,
+				Action:   plan.ActionDelete,
+				OrgID:    cp.OrgID,
+				Name:     current.Name,
+				UID:      cp.UID,
+			}, models.Provenance(current.Provenance))
+		}
+	}
+	return nil
+}
+
+func contactPointChangedFields(existing, desired definitions.EmbeddedContactPoint) ([]string, error) {
+	var fields []string
+	if existing.Name != desired.Name {
+		fields = append(fields, "name")
+	}
+	if existing.Type != desired.Type {
+		fields = append(fields, "type")
+	}
+	if existing.DisableResolveMessage != desired.DisableResolveMessage {
+		fields = append(fields, "disableResolveMessage")
+	}
+	equal, err := util.JSONEqual(existing.Settings, desired.Settings)
+	if err != nil {
+		return nil, err
+	}
+	if !equal {
+		fields = append(fields, "settings")
+	}
+	return fields, nil
+}
+
+func (p *alertingPlanner) planMuteTimes(ctx context.Context, files []*AlertingFile) error {
+	cache := map[int64]map[string]definitions.MuteTimeInterval{}
+	getMuteTimings := func(orgID int64) (map[string]definitions.MuteTimeInterval, error) {
+		if intervals, ok := cache[orgID]; ok {
+			return intervals, nil
+		}
+		intervals, err := p.cfg.MuteTimingService.GetMuteTimings(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		cache[orgID] = make(map[string]definitions.MuteTimeInterval, len(intervals))
+		for _, interval := range intervals {
+			cache[orgID][interval.Name] = interval
+		}
+		return cache[orgID], nil
+	}
+
+	for _, file := range files {
+		for _, muteTiming := range file.MuteTimes {
+			existing, err := getMuteTimings(muteTiming.OrgID)
+			if err != nil {
+				return err
+			}
+			change := plan.Change{
+				Resource: plan.ResourceMuteTiming,
+				Action:   plan.ActionCreate,
+				OrgID:    muteTiming.OrgID,
+				Name:     muteTiming.MuteTime.Name,
+			}
+			current, ok := existing[muteTiming.MuteTime.Name]
+			if ok {
+				equal, err := util.JSONEqual(current.TimeIntervals, muteTiming.MuteTime.TimeIntervals)
+				if err != nil {
+					return err
+				}
+				if equal && current.Provenance == definitions.Provenance(models.ProvenanceFile) {
+					continue
+				}
+				change.Action = plan.ActionUpdate
+				change.UID = current.UID
+				if !equal {
+					change.Fields = []string{"time_intervals"}
+				}
+			}
+			p.add(file, change, models.Provenance(current.Provenance))
+		}
+		for _, deleteMuteTime := range file.DeleteMuteTimes {
+			existing, err := getMuteTimings(deleteMuteTime.OrgID)
+			if err != nil {
+				return err
+			}
+			current, ok := existing[deleteMuteTime.Name]
+			if !ok {
+				continue
+			}
+			p.add(file, plan.Change{
+				Resource: plan.ResourceMuteTiming,
+				Action:   plan.ActionDelete,
+				OrgID:    deleteMuteTime.OrgID,
+				Name:     deleteMuteTime.Name,
+				UID:      current.UID,
+			}, models.Provenance(current.Provenance))
+		}
+	}
+	return nil
+}
+
+func (p *alertingPlanner) planTemplates(ctx context.Context, files []*AlertingFile) error {
+	cache := map[int64]map[string]definitions.NotificationTemplate{}
+	getTemplates := func(orgID int64) (map[string]definitions.NotificationTemplate, error) {
+		if templates, ok := cache[orgID]; ok {
+			return templates, nil
+		}
+		templates, err := p.cfg.TemplateService.GetTemplates(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		cache[orgID] = make(map[string]definitions.NotificationTemplate, len(templates))
+		for _, template := range templates {
+			cache[orgID][template.Name] = template
+		}
+		return cache[orgID], nil
+	}
+
+	for _, file := range files {
+		for _, template := range file.Templates {
+			existing, err := getTemplates(template.OrgID)
+			if err != nil {
+				return err
+			}
+			change := plan.Change{
+				Resource: plan.ResourceTemplate,
+				Action:   plan.ActionCreate,
+				OrgID:    template.OrgID,
+				Name:     template.Data.Name,
+			}
+			current, ok := existing[template.Data.Name]
+			if ok {
+				equal := current.Template == template.Data.Template
+				if equal && current.Provenance == definitions.Provenance(models.ProvenanceFile) {
+					continue
+				}
+				change.Action = plan.ActionUpdate
+				change.UID = current.UID
+				if !equal {
+					change.Fields = []string{"template"}
+				}
+			}
+			p.add(file, change, models.Provenance(current.Provenance))
+		}
+		for _, deleteTemplate := range file.DeleteTemplates {
+			existing, err := getTemplates(deleteTemplate.OrgID)
+			if err != nil {
+				return err
+			}
+			current, ok := existing[deleteTemplate.Name]
+			if !ok {
+				continue
+			}
+			p.add(file, plan.Change{
+				Resource: plan.ResourceTemplate,
+				Action:   plan.ActionDelete,
+				OrgID:    deleteTemplate.OrgID,
+				Name:     deleteTemplate.Name,
+				UID:      current.UID,
+			}, models.Provenance(current.Provenance))
+		}
+	}
+	return nil
+}
+
+func (p *alertingPlanner) planPolicies(ctx context.Context, files []*AlertingFile) error {
+	for _, file := range files {
+		for _, np := range file.Policies {
+			current, err := p.cfg.NotificiationPolicyService.GetPolicyTree(ctx, np.OrgID)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file.Filename, err)
+			}
+			provenance := models.Provenance(current.Provenance)
+			current.Provenance = ""
+			desired := np.Policy
+			desired.Provenance = ""
+			equal, err := util.JSONEqual(current, desired)
+			if err != nil {
+				return err
+			}
+			if equal && provenance == models.ProvenanceFile {
+				continue
+			}
+			p.add(file, plan.Change{
+				Resource: plan.ResourceNotificationPolicy,
+				Action:   plan.ActionUpdate,
+				OrgID:    np.OrgID,
+			}, provenance)
+		}
+		for _, orgID := range file.ResetPolicies {
+			current, err := p.cfg.NotificiationPolicyService.GetPolicyTree(ctx, int64(orgID))
+			if err != nil {
+				return fmt.Errorf("%s: %w", file.Filename, err)
+			}
+			// resetting the policy tree deletes all policies, only the default policy is kept
+			p.add(file, plan.Change{
+				Resource: plan.ResourceNotificationPolicy,
+				Action:   plan.ActionDelete,
+				OrgID:    int64(orgID),
+			}, models.Provenance(current.Provenance))
+		}
+	}
+	return nil
+}
+
+func (p *alertingPlanner) planRules(ctx context.Context, files []*AlertingFile) error {
+	for _, file := range files {
+		for _, group := range file.Groups {
+			u := provisionerUser(group.OrgID)
+			folderUID, missingFolders, err := p.findFolderFullpath(ctx, group.FolderFullpath, group.OrgID)
+			if err != nil {
+				return err
+			}
+			for _, title := range missingFolders {
+				p.add(file, plan.Change{
+					Resource: plan.ResourceFolder,
+					Action:   plan.ActionCreate,
+					OrgID:    group.OrgID,
+					Name:     title,
+				}, "")
+			}
+			for _, rule := range group.Rules {
+				rule.NamespaceUID = folderUID
+				rule.RuleGroup = group.Title
+				rule.IntervalSeconds = group.Interval
+				change := plan.Change{
+					Resource: plan.ResourceAlertRule,
+					Action:   plan.ActionCreate,
+					OrgID:    group.OrgID,
+					Name:     rule.Title,
+					UID:      rule.UID,
+				}
+				var provenance models.Provenance
+				if len(missingFolders) == 0 {
+					current, currentProvenance, err := p.cfg.RuleService.GetAlertRule(ctx, u, rule.UID)
+					if err != nil && !errors.Is(err, models.ErrAlertRuleNotFound) {
+						return err
+					}
+					if err == nil {
+						diff := current.Diff(&rule, ruleFieldsToIgnoreInPlan[:]...)
+						if len(diff) == 0 && currentProvenance == models.ProvenanceFile {
+							continue
+						}
+						change.Action = plan.ActionUpdate
+						change.Fields = plan.TopLevelFields(diff.Paths())
+						provenance = currentProvenance
+					}
+				}
+				p.add(file, change, provenance)
+			}
+		}
+		for _, deleteRule := range file.DeleteRules {
+			current, provenance, err := p.cfg.RuleService.GetAlertRule(ctx, provisionerUser(deleteRule.OrgID), deleteRule.UID)
+			if errors.Is(err, models.ErrAlertRuleNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			p.add(file, plan.Change{
+				Resource: plan.ResourceAlertRule,
+				Action:   plan.ActionDelete,
+				OrgID:    deleteRule.OrgID,
+				Name:     current.Title,
+				UID:      deleteRule.UID,
+			}, provenance)
+		}
+	}
+	return nil
+}
+
+// findFolderFullpath returns the UID of the folder with the full path and the titles of the folders of the path
+// that do not exist and would be created by provisioning.
+func (p *alertingPlanner) findFolderFullpath(ctx context.Context, folderFullpath string, orgID int64) (string, []string, error) {
+	folderTitles := folderimpl.SplitFullpath(folderFullpath)
+	if len(folderTitles) == 0 {
+		return "", nil, fmt.Errorf("invalid folder fullpath: %s", folderFullpath)
+	}
+
+	var parentUID *string
+	for i := range folderTitles {
+		f, err := p.cfg.FolderService.Get(ctx, &folder.GetFolderQuery{
+			Title:        &folderTitles[i],
+			ParentUID:    parentUID,
+			OrgID:        orgID,
+			SignedInUser: provisionerUser(orgID),
+		})
+		if errors.Is(err, dashboards.ErrFolderNotFound) {
+			return "", folderTitles[i:], nil
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		parentUID = &f.UID
+	}
+	return *parentUID, nil, nil
+}
@@ -0,0 +1,165 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/legacy_storage"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+)
+
+const testFilePlan = "./testdata/plan"
+
+func TestPlan(t *testing.T) {
+	source := filepath.Join(testFilePlan, "plan.yaml")
+
+	t.Run("resources that do not exist should be planned to be created", func(t *testing.T) {
+		cfg, _ := setupPlanConfig(t, "", "")
+
+		changes, err := Plan(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Equal(t, []plan.Change{
+			{Resource: plan.ResourceMuteTiming, Action: plan.ActionCreate, OrgID: 1, Name: "business-hours", Source: source},
+			{Resource: plan.ResourceTemplate, Action: plan.ActionCreate, OrgID: 1, Name: "my_template", Source: source},
+		}, changes)
+	})
+
+	t.Run("unchanged resources provisioned from files should not be in the plan", func(t *testing.T) {
+		cfg, prov := setupPlanConfig(t, "test", "09:00")
+		setPlanProvenance(t, prov, "business-hours", "my_template", models.ProvenanceFile)
+
+		changes, err := Plan(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	t.Run("changed resources provisioned from files should be updated", func(t *testing.T) {
+		cfg, prov := setupPlanConfig(t, "changed", "08:00")
+		setPlanProvenance(t, prov, "business-hours", "my_template", models.ProvenanceFile)
+
+		changes, err := Plan(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Equal(t, []plan.Change{
+			{
+				Resource:   plan.ResourceMuteTiming,
+				Action:     plan.ActionUpdate,
+				OrgID:      1,
+				Name:       "business-hours",
+				UID:        legacy_storage.NameToUid("business-hours"),
+				Source:     source,
+				Fields:     []string{"time_intervals"},
+				Provenance: string(models.ProvenanceFile),
+			},
+			{
+				Resource:   plan.ResourceTemplate,
+				Action:     plan.ActionUpdate,
+				OrgID:      1,
+				Name:       "my_template",
+				UID:        legacy_storage.NameToUid("my_template"),
+				Source:     source,
+				Fields:     []string{"template"},
+				Provenance: string(models.ProvenanceFile),
+			},
+		}, changes)
+	})
+
+	t.Run("resources that were not provisioned from files should be provenance conflicts", func(t *testing.T) {
+		cfg, prov := setupPlanConfig(t, "changed", "09:00")
+		setPlanProvenance(t, prov, "business-hours", "", models.ProvenanceAPI)
+
+		changes, err := Plan(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+
+		// the mute timing is unchanged, but provisioning takes it over from the API
+		require.Equal(t, plan.ActionUpdate, changes[0].Action)
+		require.Equal(t, plan.ResourceMuteTiming, changes[0].Resource)
+		require.Empty(t, changes[0].Fields)
+		require.Equal(t, string(models.ProvenanceAPI), changes[0].Provenance)
+		require.True(t, changes[0].ProvenanceConflict)
+
+		// the template was created in the UI
+		require.Equal(t, plan.ActionUpdate, changes[1].Action)
+		require.Equal(t, plan.ResourceTemplate, changes[1].Resource)
+		require.Equal(t, []string{"template"}, changes[1].Fields)
+		require.Equal(t, string(models.ProvenanceNone), changes[1].Provenance)
+		require.True(t, changes[1].ProvenanceConflict)
+	})
+
+	t.Run("deleted resources should be in the plan", func(t *testing.T) {
+		cfg, prov := setupPlanConfig(t, "test", "09:00", "old_template")
+		setPlanProvenance(t, prov, "business-hours", "my_template", models.ProvenanceFile)
+		require.NoError(t, prov.SetProvenance(context.Background(), &definitions.NotificationTemplate{Name: "old_template"}, 1, models.ProvenanceAPI))
+
+		changes, err := Plan(context.Background(), cfg)
+		require.NoError(t, err)
+		require.Equal(t, []plan.Change{{
+			Resource:           plan.ResourceTemplate,
+			Action:             plan.ActionDelete,
+			OrgID:              1,
+			Name:               "old_template",
+			UID:                legacy_storage.NameToUid("old_template"),
+			Source:             source,
+			Provenance:         string(models.ProvenanceAPI),
+			ProvenanceConflict: true,
+		}}, changes)
+	})
+}
+
+// setupPlanConfig returns the config of the plan testdata with an Alertmanager configuration that contains the template
+// my_template and the mute timing business-hours if their content and start time are set, and the extra templates.
+func setupPlanConfig(t *testing.T, template, startTime string, extraTemplates ...string) (ProvisionerConfig, *fakes.FakeProvisioningStore) {
+	t.Helper()
+	templates := map[string]string{}
+	if template != "" {
+		templates["my_template"] = template
+	}
+	for _, name := range extraTemplates {
+		templates[name] = "extra"
+	}
+	muteTimeIntervals := "[]"
+	if startTime != "" {
+		muteTimeIntervals = fmt.Sprintf(`[{"name": "business-hours", "time_intervals": [{"times": [{"start_time": %q, "end_time": "17:00"}]}]}]`, startTime)
+	}
+	templateFiles, err := json.Marshal(templates)
+	require.NoError(t, err)
+	amConfig := fmt.Sprintf(`{
+		"template_files": %s,
+		"alertmanager_config": {
+			"route": {"receiver": "grafana-default-email"},
+			"mute_time_intervals": %s,
+			"receivers": [{"name": "grafana-default-email", "grafana_managed_receiver_configs": [{"uid": "email", "name": "email receiver", "type": "email", "settings": {"addresses": "<example@email.com>"}}]}]
+		}
+	}`, templateFiles, muteTimeIntervals)
+
+	configStore := legacy_storage.NewAlertmanagerConfigStore(fakes.NewFakeAlertmanagerConfigStore(amConfig))
+	prov := fakes.NewFakeProvisioningStore()
+	xact := &provisioning.NopTransactionManager{}
+	return ProvisionerConfig{
+		Path:              testFilePlan,
+		MuteTimingService: *provisioning.NewMuteTimingService(configStore, prov, xact, log.NewNopLogger(), nil),
+		TemplateService:   *provisioning.NewTemplateService(configStore, prov, xact, log.NewNopLogger()),
+	}, prov
+}
+
+func setPlanProvenance(t *testing.T, prov *fakes.FakeProvisioningStore, muteTiming, template string, p models.Provenance) {
+	t.Helper()
+	if muteTiming != "" {
+		mt := &definitions.MuteTimeInterval{}
+		mt.Name = muteTiming
+		require.NoError(t, prov.SetProvenance(context.Background(), mt, 1, p))
+	}
+	if template != "" {
+		require.NoError(t, prov.SetProvenance(context.Background(), &definitions.NotificationTemplate{Name: template}, 1, p))
+	}
+}
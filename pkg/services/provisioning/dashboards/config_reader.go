@@ -16,7 +16,9 @@ import (
 )
 
 type configReader struct {
-	path       string
+	path string
+	// baseDir is the directory that relative paths of the providers are resolved against, if set.
+	baseDir    string
 	log        log.Logger
 	orgService org.Service
 }
@@ -107,6 +109,12 @@ func (cr *configReader) readConfig(ctx context.Context) ([]*config, error) {
 		if dashboard.UpdateIntervalSeconds == 0 {
 			dashboard.UpdateIntervalSeconds = 10
 		}
+
+		if cr.baseDir != "" {
+			if err := cr.resolvePaths(dashboard); err != nil {
+				return nil, err
+			}
+		}
 		if len(dashboard.FolderUID) > 0 {
 			uidUsage[dashboard.FolderUID]++
 		}
@@ -120,3 +128,19 @@ func (cr *configReader) readConfig(ctx context.Context) ([]*config, error) {
 
 	return dashboards, nil
 }
+
+// resolvePaths resolves the relative paths of the provider against the base directory. The paths
+// must stay inside the base directory.
+func (cr *configReader) resolvePaths(dashboard *config) error {
+	for _, key := range []string{"path", "folder"} {
+		path, ok := dashboard.Options[key].(string)
+		if !ok {
+			continue
+		}
+		if filepath.IsAbs(path) || !filepath.IsLocal(path) {
+			return fmt.Errorf("failed to provision dashboards with %q reader: path %q must be a relative path inside %s", dashboard.Name, path, cr.baseDir)
+		}
+		dashboard.Options[key] = filepath.Join(cr.baseDir, path)
+	}
+	return nil
+}
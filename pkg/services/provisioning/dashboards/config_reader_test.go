@@ -72,6 +72,20 @@ func TestDashboardsAsConfig(t *testing.T) {
 			require.Equal(t, 0, len(cfg))
 		})
 
+		t.Run("Should resolve relative paths against the base directory", func(t *testing.T) {
+			cfgProvider := configReader{path: "./testdata/test-configs/git-repository/dashboards", baseDir: "/var/lib/grafana/git/ops", log: logger, orgService: orgFake}
+			cfg, err := cfgProvider.readConfig(context.Background())
+			require.NoError(t, err)
+			require.Len(t, cfg, 1)
+			require.Equal(t, "/var/lib/grafana/git/ops/dashboards/json", cfg[0].Options["path"])
+		})
+
+		t.Run("Should fail if paths are outside of the base directory", func(t *testing.T) {
+			cfgProvider := configReader{path: "./testdata/test-configs/git-repository-outside", baseDir: "/var/lib/grafana/git/ops", log: logger, orgService: orgFake}
+			_, err := cfgProvider.readConfig(context.Background())
+			require.ErrorContains(t, err, "must be a relative path inside")
+		})
+
 		t.Run("Should skip broken config files", func(t *testing.T) {
 			cfgProvider := configReader{path: brokenConfigs, log: logger, orgService: orgFake}
 			cfg, err := cfgProvider.readConfig(context.Background())
@@ -9,6 +9,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
 )
 
@@ -21,10 +22,18 @@ type DashboardProvisioner interface {
 	GetProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 	CleanUpOrphanedDashboards(ctx context.Context)
+	Plan(ctx context.Context) ([]plan.Change, error)
 }
 
 // DashboardProvisionerFactory creates DashboardProvisioners based on input
-type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, org.Service, utils.DashboardStore, folder.Service) (DashboardProvisioner, error)
+type DashboardProvisionerFactory func(context.Context, string, dashboards.DashboardProvisioningService, org.Service, utils.DashboardStore, folder.Service, ...ConfigDirectory) (DashboardProvisioner, error)
+
+// ConfigDirectory is an additional directory of dashboard provider files, for example from a git repository.
+type ConfigDirectory struct {
+	Path string
+	// BaseDir is the directory that relative paths of the providers are resolved against.
+	BaseDir string
+}
 
 // Provisioner is responsible for syncing dashboard from disk to Grafana's database.
 type Provisioner struct {
@@ -40,7 +49,7 @@ func (provider *Provisioner) HasDashboardSources() bool {
 }
 
 // New returns a new DashboardProvisioner
-func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgService org.Service, dashboardStore utils.DashboardStore, folderService folder.Service) (DashboardProvisioner, error) {
+func New(ctx context.Context, configDirectory string, provisioner dashboards.DashboardProvisioningService, orgService org.Service, dashboardStore utils.DashboardStore, folderService folder.Service, configDirectories ...ConfigDirectory) (DashboardProvisioner, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger, orgService: orgService}
 	configs, err := cfgReader.readConfig(ctx)
@@ -48,6 +57,25 @@ func New(ctx context.Context, configDirectory string, provisioner dashboards.Das
 		return nil, fmt.Errorf("%v: %w", "Failed to read dashboards config", err)
 	}
 
+	names := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		names[cfg.Name] = true
+	}
+	for _, dir := range configDirectories {
+		cfgReader := &configReader{path: dir.Path, baseDir: dir.BaseDir, log: logger, orgService: orgService}
+		dirConfigs, err := cfgReader.readConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "Failed to read dashboards config", err)
+		}
+		for _, cfg := range dirConfigs {
+			if names[cfg.Name] {
+				return nil, fmt.Errorf("dashboard name %q is not unique", cfg.Name)
+			}
+			names[cfg.Name] = true
+		}
+		configs = append(configs, dirConfigs...)
+	}
+
 	fileReaders, err := getFileReaders(configs, logger, provisioner, dashboardStore, folderService)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", "Failed to initialize file readers", err)
@@ -1,6 +1,10 @@
 package dashboards
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+)
 
 // Calls is a mock implementation of the provisioner interface
 type calls struct {
@@ -17,6 +21,7 @@ type ProvisionerMock struct {
 	PollChangesFunc                 func(ctx context.Context)
 	GetProvisionerResolvedPathFunc  func(name string) string
 	GetAllowUIUpdatesFromConfigFunc func(name string) bool
+	PlanFunc                        func(ctx context.Context) ([]plan.Change, error)
 }
 
 // NewDashboardProvisionerMock returns a new dashboardprovisionermock
@@ -67,3 +72,11 @@ func (dpm *ProvisionerMock) GetAllowUIUpdatesFromConfig(name string) bool {
 
 // CleanUpOrphanedDashboards not implemented for mocks
 func (dpm *ProvisionerMock) CleanUpOrphanedDashboards(ctx context.Context) {}
+
+// Plan is a mock implementation of `Provisioner.Plan`
+func (dpm *ProvisionerMock) Plan(ctx context.Context) ([]plan.Change, error) {
+	if dpm.PlanFunc != nil {
+		return dpm.PlanFunc(ctx)
+	}
+	return nil, nil
+}
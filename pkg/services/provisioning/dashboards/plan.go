@@ -0,0 +1,199 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Plan returns the changes that Provision would make to the dashboards and their folders, without applying them.
+// Dashboards of providers that were removed from the config are deleted by CleanUpOrphanedDashboards and are not
+// part of the plan.
+func (provider *Provisioner) Plan(ctx context.Context) ([]plan.Change, error) {
+	var changes []plan.Change
+	for _, reader := range provider.fileReaders {
+		readerChanges, err := reader.plan(ctx)
+		if err != nil {
+			if os.IsNotExist(err) {
+				provider.log.Warn("Failed to plan config", "name", reader.Cfg.Name, "error", err)
+				continue
+			}
+			return nil, err
+		}
+		changes = append(changes, readerChanges...)
+	}
+	return changes, nil
+}
+
+// plan returns the changes that walkDisk would make to the database.
+func (fr *FileReader) plan(ctx context.Context) ([]plan.Change, error) {
+	resolvedPath := fr.resolvedPath()
+	if _, err := os.Stat(resolvedPath); err != nil {
+		return nil, err
+	}
+
+	provisionedDashboardRefs, err := getProvisionedDashboardsByPath(ctx, fr.dashboardProvisioningService, fr.Cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	filesFoundOnDisk := map[string]os.FileInfo{}
+	if err := filepath.Walk(resolvedPath, createWalkFn(filesFoundOnDisk)); err != nil {
+		return nil, err
+	}
+
+	// the maps are walked in the order of the paths so that the plan is the same every time
+	var changes []plan.Change
+	for _, path := range sortedPaths(provisionedDashboardRefs) {
+		if _, existsOnDisk := filesFoundOnDisk[path]; existsOnDisk {
+			continue
+		}
+		provisioningData := provisionedDashboardRefs[path]
+		change := plan.Change{
+			Resource: plan.ResourceDashboard,
+			Action:   plan.ActionDelete,
+			OrgID:    fr.Cfg.OrgID,
+			Source:   path,
+		}
+		if fr.Cfg.DisableDeletion {
+			change.Action = plan.ActionUnprovision
+		}
+		// nolint:staticcheck
+		dash, err := fr.dashboardStore.GetDashboard(ctx, &dashboards.GetDashboardQuery{ID: provisioningData.DashboardID, OrgID: fr.Cfg.OrgID})
+		if err != nil && !errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return nil, err
+		}
+		if dash != nil {
+			change.Name = dash.Title
+			change.UID = dash.UID
+		}
+		changes = append(changes, change)
+	}
+
+	plannedFolders := map[string]bool{}
+	for _, path := range sortedPaths(filesFoundOnDisk) {
+		fileInfo := filesFoundOnDisk[path]
+		folderName := fr.Cfg.Folder
+		if fr.FoldersFromFilesStructure {
+			folderName = ""
+			if dashboardsFolder := filepath.Dir(path); dashboardsFolder != resolvedPath {
+				folderName = filepath.Base(dashboardsFolder)
+			}
+		}
+		if folderName != "" && !plannedFolders[folderName] {
+			plannedFolders[folderName] = true
+			folderChange, err := fr.planFolder(ctx, folderName)
+			if err != nil {
+				return nil, err
+			}
+			if folderChange != nil {
+				changes = append(changes, *folderChange)
+			}
+		}
+
+		change, err := fr.planDashboard(ctx, path, fileInfo, provisionedDashboardRefs)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	return changes, nil
+}
+
+func sortedPaths[T any](m map[string]T) []string {
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// planDashboard returns the change that saveDashboard would make for the file at path, or nil if the dashboard is up to date.
+func (fr *FileReader) planDashboard(ctx context.Context, path string, fileInfo os.FileInfo,
+	provisionedDashboardRefs map[string]*dashboards.DashboardProvisioning) (*plan.Change, error) {
+	resolvedFileInfo, err := resolveSymlink(fileInfo, path)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonFile, err := fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), 0, "")
+	if err != nil {
+		// the file is skipped when provisioning
+		fr.log.Error("failed to load dashboard from ", "file", path, "error", err)
+		return nil, nil
+	}
+
+	dash := jsonFile.dashboard.Dashboard
+	change := &plan.Change{
+		Resource: plan.ResourceDashboard,
+		Action:   plan.ActionCreate,
+		OrgID:    fr.Cfg.OrgID,
+		Name:     dash.Title,
+		UID:      dash.UID,
+		Source:   path,
+	}
+
+	if provisionedData, alreadyProvisioned := provisionedDashboardRefs[path]; alreadyProvisioned {
+		if jsonFile.checkSum == provisionedData.CheckSum {
+			return nil, nil
+		}
+		change.Action = plan.ActionUpdate
+		return change, nil
+	}
+
+	if dash.UID == "" {
+		return change, nil
+	}
+	existing, err := fr.dashboardStore.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: fr.Cfg.OrgID, UID: dash.UID})
+	if errors.Is(err, dashboards.ErrDashboardNotFound) {
+		return change, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the file overwrites a dashboard that was not provisioned by this provider
+	change.Action = plan.ActionUpdate
+	change.ProvenanceConflict = true
+	provisionedData, err := fr.dashboardProvisioningService.GetProvisionedDashboardDataByDashboardUID(ctx, fr.Cfg.OrgID, existing.UID)
+	if err == nil && provisionedData != nil {
+		change.Provenance = provisionedData.Name
+	}
+	return change, nil
+}
+
+// planFolder returns the change that getOrCreateFolder would make, or nil if the folder exists.
+func (fr *FileReader) planFolder(ctx context.Context, folderName string) (*plan.Change, error) {
+	// nolint:staticcheck
+	query := &dashboards.GetDashboardQuery{FolderID: util.Pointer(int64(0)), OrgID: fr.Cfg.OrgID}
+	if fr.Cfg.FolderUID != "" {
+		query.UID = fr.Cfg.FolderUID
+	} else {
+		query.Title = &folderName
+	}
+
+	_, err := fr.dashboardStore.GetDashboard(ctx, query)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, dashboards.ErrDashboardNotFound) {
+		return nil, err
+	}
+	return &plan.Change{
+		Resource: plan.ResourceFolder,
+		Action:   plan.ActionCreate,
+		OrgID:    fr.Cfg.OrgID,
+		Name:     folderName,
+		UID:      fr.Cfg.FolderUID,
+		Source:   fr.Path,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestDashboardFileReaderPlan(t *testing.T) {
+	logger := log.New("test-logger")
+
+	t.Run("New dashboard and folder should be planned to be created", func(t *testing.T) {
+		cfg := &config{Name: configName, Type: "file", OrgID: 1, Folder: "Team A", Options: map[string]any{"path": oneDashboard}}
+		fakeService := &dashboards.FakeDashboardProvisioning{}
+		defer fakeService.AssertExpectations(t)
+		fakeService.On("GetProvisionedDashboardData", mock.Anything, configName).Return(nil, nil).Once()
+
+		reader, err := NewDashboardFileReader(cfg, logger, fakeService, &fakeDashboardStore{}, nil)
+		require.NoError(t, err)
+
+		changes, err := reader.plan(context.Background())
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, plan.ResourceFolder, changes[0].Resource)
+		require.Equal(t, plan.ActionCreate, changes[0].Action)
+		require.Equal(t, "Team A", changes[0].Name)
+		require.Equal(t, plan.ResourceDashboard, changes[1].Resource)
+		require.Equal(t, plan.ActionCreate, changes[1].Action)
+		require.Equal(t, "Grafana", changes[1].Name)
+	})
+
+	t.Run("Up to date dashboard should not be in the plan and missing file should be unprovisioned", func(t *testing.T) {
+		cfg := &config{Name: configName, Type: "file", OrgID: 1, DisableDeletion: true, Options: map[string]any{"path": oneDashboard}}
+		absPath, err := filepath.Abs(oneDashboard + "/dashboard1.json")
+		require.NoError(t, err)
+		file, err := os.Open(filepath.Clean(absPath))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = file.Close()
+		})
+		checksum, err := util.Md5Sum(file)
+		require.NoError(t, err)
+
+		fakeService := &dashboards.FakeDashboardProvisioning{}
+		defer fakeService.AssertExpectations(t)
+		fakeService.On("GetProvisionedDashboardData", mock.Anything, configName).Return([]*dashboards.DashboardProvisioning{
+			{Name: configName, DashboardID: 1, ExternalID: absPath, CheckSum: checksum},
+			{Name: configName, DashboardID: 2, ExternalID: "/tmp/removed.json", CheckSum: "removed"},
+		}, nil).Once()
+
+		reader, err := NewDashboardFileReader(cfg, logger, fakeService, &fakeDashboardStore{}, nil)
+		require.NoError(t, err)
+
+		changes, err := reader.plan(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []plan.Change{{
+			Resource: plan.ResourceDashboard,
+			Action:   plan.ActionUnprovision,
+			OrgID:    1,
+			Source:   "/tmp/removed.json",
+		}}, changes)
+	})
+
+	t.Run("Changes should be ordered by path", func(t *testing.T) {
+		cfg := &config{Name: configName, Type: "file", OrgID: 1, Options: map[string]any{"path": foldersFromFilesStructure, "foldersFromFilesStructure": true}}
+		fakeService := &dashboards.FakeDashboardProvisioning{}
+		defer fakeService.AssertExpectations(t)
+		fakeService.On("GetProvisionedDashboardData", mock.Anything, configName).Return([]*dashboards.DashboardProvisioning{
+			{Name: configName, DashboardID: 1, ExternalID: "/tmp/removed-b.json", CheckSum: "removed"},
+			{Name: configName, DashboardID: 2, ExternalID: "/tmp/removed-a.json", CheckSum: "removed"},
+		}, nil).Once()
+
+		reader, err := NewDashboardFileReader(cfg, logger, fakeService, &fakeDashboardStore{}, nil)
+		require.NoError(t, err)
+
+		changes, err := reader.plan(context.Background())
+		require.NoError(t, err)
+		names := make([]string, 0, len(changes))
+		for _, c := range changes {
+			names = append(names, fmt.Sprintf("%s %s %q %s", c.Action, c.Resource, c.Name, filepath.Base(c.Source)))
+		}
+		require.Equal(t, []string{
+			`delete dashboard "" removed-a.json`,
+			`delete dashboard "" removed-b.json`,
+			`create folder "folderOne" folders-from-files-structure`,
+			`create dashboard "Grafana1" dashboard1.json`,
+			`create folder "folderTwo" folders-from-files-structure`,
+			`create dashboard "Grafana2" dashboard2.json`,
+			`create dashboard "RootDashboard" root.json`,
+		}, names)
+	})
+}
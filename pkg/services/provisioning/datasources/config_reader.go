@@ -21,30 +21,33 @@ type configReader struct {
 	orgService org.Service
 }
 
-func (cr *configReader) readConfig(ctx context.Context, path string) ([]*configs, error) {
+// readConfig reads the provisioning files of the directories as one set of configs.
+func (cr *configReader) readConfig(ctx context.Context, paths ...string) ([]*configs, error) {
 	var datasources []*configs
 
-	files, err := os.ReadDir(path)
-	if err != nil {
-		cr.log.Error("can't read datasource provisioning files from directory", "path", path, "error", err)
-		return datasources, nil
-	}
+	for _, path := range paths {
+		files, err := os.ReadDir(path)
+		if err != nil {
+			cr.log.Error("can't read datasource provisioning files from directory", "path", path, "error", err)
+			continue
+		}
 
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
-			datasource, err := cr.parseDatasourceConfig(path, file)
-			if err != nil {
-				return nil, err
-			}
+		for _, file := range files {
+			if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+				datasource, err := cr.parseDatasourceConfig(path, file)
+				if err != nil {
+					return nil, err
+				}
 
-			if datasource != nil {
-				datasources = append(datasources, datasource)
+				if datasource != nil {
+					datasource.path = path
+					datasources = append(datasources, datasource)
+				}
 			}
 		}
 	}
 
-	err = cr.validateDefaultUniqueness(ctx, datasources)
-	if err != nil {
+	if err := cr.validateDefaultUniqueness(ctx, datasources); err != nil {
 		return nil, err
 	}
 
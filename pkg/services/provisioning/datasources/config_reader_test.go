@@ -204,6 +204,23 @@ func TestDatasourceAsConfig(t *testing.T) {
 		require.NotContains(t, remainingDataSourceNames, "test_prometheus_with_prune")
 	})
 
+	t.Run("Does not delete data sources provisioned by another directory", func(t *testing.T) {
+		store := &spyStore{}
+		orgFake := &orgtest.FakeOrgService{}
+		correlationsStore := &mockCorrelationsStore{}
+		dc := newDatasourceProvisioner(logger, store, correlationsStore, orgFake)
+		if err := dc.applyChanges(context.Background(), beforeAutoDeletion); err != nil {
+			t.Fatalf("applyChanges return an error %v", err)
+		}
+
+		if err := dc.applyChanges(context.Background(), afterAutoDeletion, beforeAutoDeletion); err != nil {
+			t.Fatalf("applyChanges return an error %v", err)
+		}
+
+		require.Equal(t, len(store.deleted), 0)
+		require.Equal(t, len(store.items), 5)
+	})
+
 	t.Run("broken yaml should return error", func(t *testing.T) {
 		reader := &configReader{}
 		_, err := reader.readConfig(context.Background(), brokenYaml)
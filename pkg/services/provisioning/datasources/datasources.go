@@ -33,11 +33,12 @@ var (
 	ErrInvalidConfigToManyDefault = errors.New("datasource.yaml config is invalid. Only one datasource per organization can be marked as default")
 )
 
-// Provision scans a directory for provisioning config files
-// and provisions the datasource in those files.
-func Provision(ctx context.Context, configDirectory string, dsService BaseDataSourceService, correlationsStore CorrelationsStore, orgService org.Service) error {
+// Provision scans the directories for provisioning config files
+// and provisions the datasource in those files. The files of all the directories
+// are provisioned as one set, a datasource is only pruned if none of them contains it.
+func Provision(ctx context.Context, configDirectories []string, dsService BaseDataSourceService, correlationsStore CorrelationsStore, orgService org.Service) error {
 	dc := newDatasourceProvisioner(log.New("provisioning.datasources"), dsService, correlationsStore, orgService)
-	return dc.applyChanges(ctx, configDirectory)
+	return dc.applyChanges(ctx, configDirectories...)
 }
 
 // DatasourceProvisioner is responsible for provisioning datasources based on
@@ -137,8 +138,8 @@ type DataSourceMapKey struct {
 	OrgId int64
 }
 
-func (dc *DatasourceProvisioner) applyChanges(ctx context.Context, configPath string) error {
-	configs, err := dc.cfgProvider.readConfig(ctx, configPath)
+func (dc *DatasourceProvisioner) applyChanges(ctx context.Context, configPaths ...string) error {
+	configs, err := dc.cfgProvider.readConfig(ctx, configPaths...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,157 @@
+package datasources
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Plan scans the directories for provisioning config files and returns the changes
+// that Provision would make to the datasources, without applying them.
+// Secure JSON data and correlations are not compared.
+func Plan(ctx context.Context, configDirectories []string, dsService BaseDataSourceService, orgService org.Service) ([]plan.Change, error) {
+	dc := newDatasourceProvisioner(log.New("provisioning.datasources"), dsService, nil, orgService)
+	return dc.planChanges(ctx, configDirectories...)
+}
+
+func (dc *DatasourceProvisioner) planChanges(ctx context.Context, configPaths ...string) ([]plan.Change, error) {
+	configs, err := dc.cfgProvider.readConfig(ctx, configPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	willExistAfterProvisioning := map[DataSourceMapKey]bool{}
+	for _, cfg := range configs {
+		for _, ds := range cfg.DeleteDatasources {
+			willExistAfterProvisioning[DataSourceMapKey{Name: ds.Name, OrgId: ds.OrgID}] = false
+		}
+		for _, ds := range cfg.Datasources {
+			willExistAfterProvisioning[DataSourceMapKey{Name: ds.Name, OrgId: ds.OrgID}] = true
+		}
+	}
+
+	prunableProvisionedDataSources, err := dc.dsService.GetPrunableProvisionedDataSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the pruned datasources are not in any of the directories, so their deletion has no source
+	type deletion struct {
+		ds     *deleteDatasourceConfig
+		source string
+	}
+	toDelete := []deletion{}
+	for _, prunableProvisionedDataSource := range prunableProvisionedDataSources {
+		key := DataSourceMapKey{OrgId: prunableProvisionedDataSource.OrgID, Name: prunableProvisionedDataSource.Name}
+		if _, ok := willExistAfterProvisioning[key]; !ok {
+			toDelete = append(toDelete, deletion{ds: &deleteDatasourceConfig{OrgID: prunableProvisionedDataSource.OrgID, Name: prunableProvisionedDataSource.Name}})
+		}
+	}
+	for _, cfg := range configs {
+		for _, ds := range cfg.DeleteDatasources {
+			toDelete = append(toDelete, deletion{ds: ds, source: cfg.path})
+		}
+	}
+
+	var changes []plan.Change
+	deleted := map[DataSourceMapKey]bool{}
+	for _, d := range toDelete {
+		ds := d.ds
+		key := DataSourceMapKey{Name: ds.Name, OrgId: ds.OrgID}
+		if deleted[key] {
+			continue
+		}
+		existing, err := dc.dsService.GetDataSource(ctx, &datasources.GetDataSourceQuery{Name: ds.Name, OrgID: ds.OrgID})
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deleted[key] = true
+		changes = append(changes, plan.Change{
+			Resource: plan.ResourceDatasource,
+			Action:   plan.ActionDelete,
+			OrgID:    ds.OrgID,
+			Name:     ds.Name,
+			UID:      existing.UID,
+			Source:   d.source,
+		})
+	}
+
+	for _, cfg := range configs {
+		for _, ds := range cfg.Datasources {
+			change := plan.Change{
+				Resource: plan.ResourceDatasource,
+				Action:   plan.ActionCreate,
+				OrgID:    ds.OrgID,
+				Name:     ds.Name,
+				UID:      ds.UID,
+				Source:   cfg.path,
+			}
+			existing, err := dc.dsService.GetDataSource(ctx, &datasources.GetDataSourceQuery{OrgID: ds.OrgID, Name: ds.Name})
+			if err != nil && !errors.Is(err, datasources.ErrDataSourceNotFound) {
+				return nil, err
+			}
+			if err == nil && !deleted[DataSourceMapKey{Name: ds.Name, OrgId: ds.OrgID}] {
+				// the update of an old version of the datasource is ignored
+				if ds.Version != 0 && existing.Version > ds.Version {
+					continue
+				}
+				fields, err := changedFields(existing, ds)
+				if err != nil {
+					return nil, err
+				}
+				if len(fields) == 0 {
+					continue
+				}
+				change.Action = plan.ActionUpdate
+				change.UID = existing.UID
+				change.Fields = fields
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// changedFields returns the JSON names of the fields that the update of the datasource changes.
+func changedFields(existing *datasources.DataSource, ds *upsertDataSourceFromConfig) ([]string, error) {
+	var fields []string
+	add := func(changed bool, field string) {
+		if changed {
+			fields = append(fields, field)
+		}
+	}
+	add(ds.UID != "" && ds.UID != existing.UID, "uid")
+	add(ds.Type != existing.Type, "type")
+	add(datasources.DsAccess(ds.Access) != existing.Access, "access")
+	add(ds.URL != existing.URL, "url")
+	add(ds.User != existing.User, "user")
+	add(ds.Database != existing.Database, "database")
+	add(ds.BasicAuth != existing.BasicAuth, "basicAuth")
+	add(ds.BasicAuthUser != existing.BasicAuthUser, "basicAuthUser")
+	add(ds.WithCredentials != existing.WithCredentials, "withCredentials")
+	add(ds.IsDefault != existing.IsDefault, "isDefault")
+	add(ds.Editable == existing.ReadOnly, "editable")
+
+	existingJSONData := map[string]any{}
+	if existing.JsonData != nil {
+		existingJSONData = existing.JsonData.MustMap()
+	}
+	desiredJSONData := ds.JSONData
+	if desiredJSONData == nil {
+		desiredJSONData = map[string]any{}
+	}
+	equal, err := util.JSONEqual(existingJSONData, desiredJSONData)
+	if err != nil {
+		return nil, err
+	}
+	add(!equal, "jsonData")
+	return fields, nil
+}
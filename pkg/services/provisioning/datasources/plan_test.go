@@ -0,0 +1,80 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+)
+
+func TestPlan(t *testing.T) {
+	t.Run("no datasource in database should plan to create all datasources", func(t *testing.T) {
+		store := &spyStore{}
+		changes, err := Plan(context.Background(), []string{twoDatasourcesConfig}, store, &orgtest.FakeOrgService{})
+		require.NoError(t, err)
+
+		require.Len(t, changes, 2)
+		for _, c := range changes {
+			require.Equal(t, plan.ActionCreate, c.Action)
+			require.Equal(t, int64(1), c.OrgID)
+		}
+		require.Empty(t, store.inserted)
+	})
+
+	t.Run("unchanged datasources should not be in the plan", func(t *testing.T) {
+		store := &spyStore{items: []*datasources.DataSource{
+			{Name: "Graphite", OrgID: 1, ID: 1, UID: "graphite", Type: "graphite", Access: "proxy", URL: "http://localhost:8080", ReadOnly: true},
+			{Name: "Prometheus", OrgID: 1, ID: 2, UID: "prometheus", Type: "prometheus", Access: "proxy", URL: "http://localhost:1234", ReadOnly: true},
+		}}
+		changes, err := Plan(context.Background(), []string{twoDatasourcesConfig}, store, &orgtest.FakeOrgService{})
+		require.NoError(t, err)
+
+		require.Equal(t, []plan.Change{{
+			Resource: plan.ResourceDatasource,
+			Action:   plan.ActionUpdate,
+			OrgID:    1,
+			Name:     "Prometheus",
+			UID:      "prometheus",
+			Source:   twoDatasourcesConfig,
+			Fields:   []string{"url"},
+		}}, changes)
+		require.Empty(t, store.updated)
+	})
+
+	t.Run("deleted and pruned datasources should be in the plan", func(t *testing.T) {
+		store := &spyStore{items: []*datasources.DataSource{
+			{Name: "old-graphite", OrgID: 1, ID: 1, UID: "old"},
+			{Name: "pruned", OrgID: 1, ID: 2, UID: "pruned", IsPrunable: true},
+		}}
+		changes, err := Plan(context.Background(), []string{twoDatasourcesConfigPurgeOthers}, store, &orgtest.FakeOrgService{})
+		require.NoError(t, err)
+
+		var deleted []string
+		for _, c := range changes {
+			if c.Action == plan.ActionDelete {
+				deleted = append(deleted, c.UID)
+			}
+		}
+		require.ElementsMatch(t, []string{"old", "pruned"}, deleted)
+		require.Equal(t, 2, len(changes)-len(deleted))
+		require.Empty(t, store.deleted)
+		require.Len(t, store.items, 2)
+	})
+
+	t.Run("datasources of another directory should not be pruned", func(t *testing.T) {
+		store := &spyStore{items: []*datasources.DataSource{
+			{Name: "testdata_with_prune", OrgID: 1, ID: 1, UID: "testdata", IsPrunable: true},
+			{Name: "test_prometheus_with_prune", OrgID: 1, ID: 2, UID: "prometheus", IsPrunable: true},
+		}}
+		changes, err := Plan(context.Background(), []string{afterAutoDeletion, beforeAutoDeletion}, store, &orgtest.FakeOrgService{})
+		require.NoError(t, err)
+
+		for _, c := range changes {
+			require.NotEqual(t, plan.ActionDelete, c.Action)
+		}
+	})
+}
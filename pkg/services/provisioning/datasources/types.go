@@ -19,6 +19,8 @@ type configVersion struct {
 type configs struct {
 	APIVersion int64
 	Prune      bool
+	// path is the directory the config was read from.
+	path string
 
 	Datasources       []*upsertDataSourceFromConfig
 	DeleteDatasources []*deleteDatasourceConfig
@@ -0,0 +1,116 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+var repositoryNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+type configReader struct {
+	path string
+	log  log.Logger
+}
+
+func (cr *configReader) readConfig() ([]*repositoryConfig, error) {
+	var repositories []*repositoryConfig
+
+	files, err := os.ReadDir(cr.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			cr.log.Error("can't read git provisioning files from directory", "path", cr.path, "error", err)
+		}
+		return repositories, nil
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".yaml") && !strings.HasSuffix(file.Name(), ".yml") {
+			continue
+		}
+
+		parsed, err := cr.parseConfig(filepath.Join(cr.path, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse provisioning config file: %s error: %w", file.Name(), err)
+		}
+		repositories = append(repositories, parsed...)
+	}
+
+	seen := make(map[string]bool, len(repositories))
+	for _, r := range repositories {
+		if err := validateRepositoryConfig(r); err != nil {
+			return nil, err
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("git repository name %q is not unique", r.Name)
+		}
+		seen[r.Name] = true
+	}
+
+	return repositories, nil
+}
+
+func (cr *configReader) parseConfig(filename string) ([]*repositoryConfig, error) {
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	apiVersion := &configVersion{}
+	if err := yaml.Unmarshal(yamlFile, apiVersion); err != nil {
+		return nil, err
+	}
+	if apiVersion.APIVersion != 1 {
+		return nil, fmt.Errorf("unsupported apiVersion %d, the only supported version is 1", apiVersion.APIVersion)
+	}
+
+	v1 := &configV1{}
+	if err := yaml.Unmarshal(yamlFile, v1); err != nil {
+		return nil, err
+	}
+
+	repositories := make([]*repositoryConfig, 0, len(v1.Repositories))
+	for _, r := range v1.Repositories {
+		if r == nil {
+			continue
+		}
+		repositories = append(repositories, r.mapToRepositoryConfig())
+	}
+	return repositories, nil
+}
+
+func validateRepositoryConfig(r *repositoryConfig) error {
+	if !repositoryNameRegexp.MatchString(r.Name) || r.Name == "." || r.Name == ".." {
+		return fmt.Errorf("git repository name %q is invalid, it can only contain letters, digits, '_', '-' and '.'", r.Name)
+	}
+	if r.URL == "" {
+		return fmt.Errorf("git repository %q: url is required", r.Name)
+	}
+	if strings.HasPrefix(r.URL, "-") {
+		return fmt.Errorf("git repository %q: url %q is invalid", r.Name, r.URL)
+	}
+	if strings.HasPrefix(r.Ref, "-") {
+		return fmt.Errorf("git repository %q: ref %q is invalid", r.Name, r.Ref)
+	}
+	if r.UpdateIntervalSeconds < 0 {
+		return fmt.Errorf("git repository %q: updateIntervalSeconds must be positive", r.Name)
+	}
+	for section, path := range r.Paths {
+		if filepath.IsAbs(path) || !filepath.IsLocal(path) {
+			return fmt.Errorf("git repository %q: %s path %q must be a relative path inside the repository", r.Name, section, path)
+		}
+	}
+	if r.VerifySignatures && r.TrustedKeys == "" && r.TrustedKeysFile == "" {
+		return fmt.Errorf("git repository %q: trustedKeys or trustedKeysFile is required to verify signatures", r.Name)
+	}
+	return nil
+}
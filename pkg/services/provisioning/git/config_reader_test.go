@@ -0,0 +1,59 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+func TestConfigReader(t *testing.T) {
+	logger := log.New("test-logger")
+
+	t.Run("Can read repositories and applies defaults", func(t *testing.T) {
+		t.Setenv("KEYS_FILE", "/etc/grafana/keys.asc")
+		cr := &configReader{path: "testdata/valid", log: logger}
+		cfgs, err := cr.readConfig()
+		require.NoError(t, err)
+		require.Len(t, cfgs, 2)
+
+		ops := cfgs[0]
+		require.Equal(t, "ops", ops.Name)
+		require.Equal(t, "main", ops.Ref)
+		require.Equal(t, int64(30), ops.UpdateIntervalSeconds)
+		require.Equal(t, "grafana/dashboards", ops.Paths[SectionDashboards])
+		require.Equal(t, "datasources", ops.Paths[SectionDatasources])
+		require.True(t, ops.VerifySignatures)
+		require.Equal(t, "/etc/grafana/keys.asc", ops.TrustedKeysFile)
+
+		defaults := cfgs[1]
+		require.Equal(t, "HEAD", defaults.Ref)
+		require.Equal(t, int64(60), defaults.UpdateIntervalSeconds)
+		require.Equal(t, "alerting", defaults.Paths[SectionAlerting])
+	})
+
+	t.Run("Missing directory is not an error", func(t *testing.T) {
+		cr := &configReader{path: "testdata/missing", log: logger}
+		cfgs, err := cr.readConfig()
+		require.NoError(t, err)
+		require.Empty(t, cfgs)
+	})
+
+	t.Run("Invalid repositories are rejected", func(t *testing.T) {
+		for dir, msg := range map[string]string{
+			"testdata/invalid-name": "is invalid",
+			"testdata/duplicate":    "is not unique",
+			"testdata/signatures":   "trustedKeys or trustedKeysFile is required",
+		} {
+			cr := &configReader{path: dir, log: logger}
+			_, err := cr.readConfig()
+			require.ErrorContains(t, err, msg, dir)
+		}
+	})
+
+	t.Run("Paths must stay inside the repository", func(t *testing.T) {
+		cfg := &repositoryConfig{Name: "ops", URL: "file:///srv/git/ops.git", Paths: map[string]string{SectionAlerting: "../alerting"}}
+		require.ErrorContains(t, validateRepositoryConfig(cfg), "must be a relative path inside the repository")
+	})
+}
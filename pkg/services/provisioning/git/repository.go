@@ -0,0 +1,98 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// repository is the local checkout of a git repository.
+type repository struct {
+	cfg *repositoryConfig
+	dir string
+}
+
+func newRepository(cfg *repositoryConfig, checkoutsPath string) *repository {
+	return &repository{
+		cfg: cfg,
+		dir: filepath.Join(checkoutsPath, cfg.Name),
+	}
+}
+
+// fetch fetches the configured ref and returns the commit it points to.
+func (r *repository) fetch(ctx context.Context) (string, error) {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(r.dir, 0o750); err != nil {
+			return "", fmt.Errorf("failed to create checkout directory: %w", err)
+		}
+		if _, err := r.git(ctx, "init", "--quiet"); err != nil {
+			return "", err
+		}
+	}
+	if _, err := r.git(ctx, "fetch", "--quiet", "--force", "--no-tags", "--depth=1", r.cfg.URL, r.cfg.Ref); err != nil {
+		return "", err
+	}
+	commit, err := r.git(ctx, "rev-parse", "--verify", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(commit)), nil
+}
+
+// rawCommit returns the commit object as it is stored by git.
+func (r *repository) rawCommit(ctx context.Context, commit string) ([]byte, error) {
+	return r.git(ctx, "cat-file", "commit", commit)
+}
+
+// checkout replaces the files of the checkout with the files of the commit.
+func (r *repository) checkout(ctx context.Context, commit string) error {
+	if _, err := r.git(ctx, "checkout", "--quiet", "--force", "--detach", commit); err != nil {
+		return err
+	}
+	_, err := r.git(ctx, "clean", "--quiet", "-ffdx")
+	return err
+}
+
+// path returns the absolute path of a directory of the repository.
+func (r *repository) path(rel string) string {
+	return filepath.Join(r.dir, rel)
+}
+
+func (r *repository) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	// Never wait for credentials on a terminal, they must be part of the URL or of the git configuration.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		// git prints the URL in its errors, which can contain credentials.
+		msg = strings.ReplaceAll(msg, r.cfg.URL, redactURL(r.cfg.URL))
+		return nil, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// redactURL hides the credentials of the URL of a repository. Tokens are often set as the user name.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if _, ok := u.User.Password(); ok {
+		return u.Redacted()
+	}
+	u.User = url.User("xxxxx")
+	return u.String()
+}
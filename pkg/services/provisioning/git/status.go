@@ -0,0 +1,138 @@
+package git
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileStatusApplied, FileStatusFailed and FileStatusSkipped are the statuses of the files of a repository.
+	FileStatusApplied = "applied"
+	FileStatusFailed  = "failed"
+	// FileStatusSkipped is the status of the files of a section that is not applied because another file has errors.
+	FileStatusSkipped = "skipped"
+)
+
+// RepositoryStatus is the status of the last sync of a repository.
+type RepositoryStatus struct {
+	Name string `json:"name"`
+	// URL of the repository, without credentials.
+	URL string `json:"url"`
+	Ref string `json:"ref"`
+	// Commit is the last commit that was checked out and provisioned.
+	Commit           string    `json:"commit,omitempty"`
+	VerifySignatures bool      `json:"verifySignatures"`
+	LastSync         time.Time `json:"lastSync"`
+	LastApplied      time.Time `json:"lastApplied"`
+	// Error is the error of the last sync, for example because the repository could not be fetched
+	// or the commit is not signed by a trusted key. The files of the last applied commit are still provisioned.
+	Error string       `json:"error,omitempty"`
+	Files []FileStatus `json:"files"`
+}
+
+// FileStatus is the status of a provisioning file of the last applied commit.
+type FileStatus struct {
+	// Path of the file relative to the root of the repository.
+	Path    string `json:"path"`
+	Section string `json:"section"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// listFiles returns the provisioning files of a section of the checkout and checks that they can be parsed.
+// Datasources and alerting files are read from the directory of the section. Dashboard provider files are read
+// from the directory of the section, and dashboards from any of its subdirectories.
+func listFiles(root, section, dir string) ([]FileStatus, error) {
+	var files []FileStatus
+	base := filepath.Join(root, dir)
+	err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != base && (section != SectionDashboards || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(d.Name())
+		isYAML := ext == ".yaml" || ext == ".yml"
+		switch {
+		case isYAML && filepath.Dir(path) == base:
+		case ext == ".json" && section != SectionDatasources:
+		default:
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		file := FileStatus{Path: filepath.ToSlash(rel), Section: section, Status: FileStatusApplied}
+		if err := checkFile(path, isYAML); err != nil {
+			file.Status = FileStatusFailed
+			file.Error = err.Error()
+		}
+		files = append(files, file)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return files, err
+}
+
+func checkFile(path string, isYAML bool) error {
+	// nolint:gosec
+	// The path is a file of the checkout of a configured repository.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var v any
+	if isYAML {
+		return yaml.Unmarshal(content, &v)
+	}
+	return json.Unmarshal(content, &v)
+}
+
+func hasFailedFiles(files []FileStatus) bool {
+	for _, f := range files {
+		if f.Status == FileStatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// setApplyError marks the files of a section as failed when the section could not be provisioned. The error is
+// attributed to the files it mentions, or to all the files of the section when it does not mention any.
+func setApplyError(files []FileStatus, err error) {
+	msg := err.Error()
+	matched := false
+	for i := range files {
+		if strings.Contains(msg, filepath.Base(files[i].Path)) {
+			files[i].Status = FileStatusFailed
+			files[i].Error = msg
+			matched = true
+		}
+	}
+	if matched {
+		return
+	}
+	for i := range files {
+		files[i].Status = FileStatusFailed
+		files[i].Error = msg
+	}
+}
+
+func setStatus(files []FileStatus, status string) {
+	for i := range files {
+		if files[i].Status != FileStatusFailed {
+			files[i].Status = status
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+)
+
+// checkInterval is how often the repositories are checked for an update interval that has elapsed.
+const checkInterval = 5 * time.Second
+
+// Appliers provision the files of the checkouts of the repositories.
+type Appliers struct {
+	// Datasources and Alerting provision the files of the directory, Datasources may provision
+	// the files of the other directories with it.
+	Datasources func(ctx context.Context, path string) error
+	Alerting    func(ctx context.Context, path string) error
+	// Dashboards reloads the dashboard providers, including the providers of the repositories.
+	Dashboards func(ctx context.Context) error
+}
+
+// Syncer fetches the git repositories configured in the provisioning directory and provisions
+// their dashboards, datasources and alerting files when the configured ref points to a new commit.
+type Syncer struct {
+	configPath    string
+	checkoutsPath string
+	appliers      Appliers
+	log           log.Logger
+
+	// syncMtx serializes the syncs so that a checkout is not changed by two syncs at the same time.
+	syncMtx      sync.Mutex
+	mtx          sync.RWMutex
+	loaded       bool
+	repositories []*repository
+	statuses     map[string]*RepositoryStatus
+}
+
+// NewSyncer returns a Syncer for the repositories configured in configPath. The repositories are checked out in checkoutsPath.
+func NewSyncer(configPath, checkoutsPath string, appliers Appliers) *Syncer {
+	return &Syncer{
+		configPath:    configPath,
+		checkoutsPath: checkoutsPath,
+		appliers:      appliers,
+		log:           log.New("provisioning.git"),
+		statuses:      map[string]*RepositoryStatus{},
+	}
+}
+
+// Load reads the repositories from the provisioning files.
+func (s *Syncer) Load() error {
+	cr := &configReader{path: s.configPath, log: s.log}
+	configs, err := cr.readConfig()
+	if err != nil {
+		return err
+	}
+
+	repositories := make([]*repository, 0, len(configs))
+	statuses := make(map[string]*RepositoryStatus, len(configs))
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, cfg := range configs {
+		repositories = append(repositories, newRepository(cfg, s.checkoutsPath))
+		status := &RepositoryStatus{Files: []FileStatus{}}
+		// Keep the status of the repositories that did not change.
+		if previous, ok := s.statuses[cfg.Name]; ok && previous.URL == redactURL(cfg.URL) && previous.Ref == cfg.Ref {
+			status = previous
+		}
+		status.Name = cfg.Name
+		status.URL = redactURL(cfg.URL)
+		status.Ref = cfg.Ref
+		status.VerifySignatures = cfg.VerifySignatures
+		statuses[cfg.Name] = status
+	}
+	s.repositories = repositories
+	s.statuses = statuses
+	s.loaded = true
+	return nil
+}
+
+// Loaded returns true if the repositories were read from the provisioning files.
+func (s *Syncer) Loaded() bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.loaded
+}
+
+// HasRepositories returns true if at least one repository is configured.
+func (s *Syncer) HasRepositories() bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return len(s.repositories) > 0
+}
+
+// Sync fetches all the repositories and provisions the files of the ones that point to a new commit. Dashboards
+// are reloaded only if reloadDashboards is true, which is not the case before the dashboard providers are started.
+func (s *Syncer) Sync(ctx context.Context, reloadDashboards bool) error {
+	var errs []error
+	for _, repo := range s.snapshot() {
+		if _, err := s.syncRepository(ctx, repo, reloadDashboards); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync git repository %s: %w", repo.cfg.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run syncs every repository at its update interval until the context is done.
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, repo := range s.snapshot() {
+				if ctx.Err() != nil {
+					return
+				}
+				interval := time.Duration(repo.cfg.UpdateIntervalSeconds) * time.Second
+				if now.Sub(s.lastSync(repo.cfg.Name)) < interval {
+					continue
+				}
+				if _, err := s.syncRepository(ctx, repo, true); err != nil {
+					s.log.Error("Failed to sync git repository", "repository", repo.cfg.Name, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// Status returns the status of the repositories sorted by name.
+func (s *Syncer) Status() []RepositoryStatus {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	result := make([]RepositoryStatus, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		st := *status
+		st.Files = append([]FileStatus{}, status.Files...)
+		result = append(result, st)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// DashboardConfigDirectories returns the directories of dashboard provider files of the checkouts. Relative paths
+// of the providers are resolved against the root of the repository. The checkouts of the previous runs are used
+// until the repositories are synced, so that their dashboards are not deleted if a repository can't be fetched.
+func (s *Syncer) DashboardConfigDirectories() []dashboards.ConfigDirectory {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var dirs []dashboards.ConfigDirectory
+	for _, repo := range s.repositories {
+		path := repo.path(repo.cfg.Paths[SectionDashboards])
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		dirs = append(dirs, dashboards.ConfigDirectory{
+			Path:    path,
+			BaseDir: repo.dir,
+		})
+	}
+	return dirs
+}
+
+// SectionDirectories returns the directories of a section in the current checkouts of the repositories.
+func (s *Syncer) SectionDirectories(section string) []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	var dirs []string
+	for _, repo := range s.repositories {
+		path := repo.path(repo.cfg.Paths[section])
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		dirs = append(dirs, path)
+	}
+	return dirs
+}
+
+func (s *Syncer) snapshot() []*repository {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return append([]*repository{}, s.repositories...)
+}
+
+func (s *Syncer) lastSync(name string) time.Time {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if status, ok := s.statuses[name]; ok {
+		return status.LastSync
+	}
+	return time.Time{}
+}
+
+// syncRepository fetches the repository and, if the ref points to a new commit, checks it out and provisions its files.
+// It returns true if a new commit was provisioned.
+func (s *Syncer) syncRepository(ctx context.Context, repo *repository, reloadDashboards bool) (bool, error) {
+	s.syncMtx.Lock()
+	defer s.syncMtx.Unlock()
+
+	logger := s.log.New("repository", repo.cfg.Name)
+	s.mtx.RLock()
+	status, ok := s.statuses[repo.cfg.Name]
+	var current string
+	if ok {
+		current = status.Commit
+	}
+	s.mtx.RUnlock()
+	if !ok {
+		// The repository was removed from the configuration in the meantime.
+		return false, nil
+	}
+
+	commit, err := s.checkout(ctx, repo, current)
+	if err != nil || commit == "" {
+		s.updateStatus(repo.cfg.Name, func(st *RepositoryStatus) {
+			st.LastSync = time.Now()
+			st.Error = ""
+			if err != nil {
+				st.Error = err.Error()
+			}
+		})
+		return false, err
+	}
+
+	logger.Info("Provisioning git repository", "commit", commit)
+	files := s.apply(ctx, repo, reloadDashboards)
+	now := time.Now()
+	s.updateStatus(repo.cfg.Name, func(st *RepositoryStatus) {
+		st.Commit = commit
+		st.LastSync = now
+		st.LastApplied = now
+		st.Error = ""
+		st.Files = files
+	})
+	for _, f := range files {
+		if f.Status == FileStatusFailed {
+			logger.Error("Failed to provision file", "commit", commit, "file", f.Path, "error", f.Error)
+		}
+	}
+	return true, nil
+}
+
+// checkout fetches the repository and checks out the commit the ref points to, unless it is the current commit.
+// It returns the new commit, or an empty string if the ref still points to the current commit.
+func (s *Syncer) checkout(ctx context.Context, repo *repository, current string) (string, error) {
+	commit, err := repo.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if commit == current {
+		return "", nil
+	}
+	if repo.cfg.VerifySignatures {
+		keyRing, err := readKeyRing(repo.cfg)
+		if err != nil {
+			return "", err
+		}
+		raw, err := repo.rawCommit(ctx, commit)
+		if err != nil {
+			return "", err
+		}
+		if err := verifyCommit(raw, keyRing); err != nil {
+			return "", fmt.Errorf("commit %s: %w", commit, err)
+		}
+	}
+	if err := repo.checkout(ctx, commit); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// apply provisions the sections of the checkout and returns the status of their files.
+func (s *Syncer) apply(ctx context.Context, repo *repository, reloadDashboards bool) []FileStatus {
+	result := []FileStatus{}
+	sections := []struct {
+		name  string
+		apply func(ctx context.Context, path string) error
+	}{
+		{SectionDatasources, s.appliers.Datasources},
+		{SectionAlerting, s.appliers.Alerting},
+		{SectionDashboards, func(ctx context.Context, _ string) error {
+			if !reloadDashboards {
+				return nil
+			}
+			return s.appliers.Dashboards(ctx)
+		}},
+	}
+	for _, section := range sections {
+		dir := repo.cfg.Paths[section.name]
+		files, err := listFiles(repo.dir, section.name, dir)
+		if err != nil {
+			files = append(files, FileStatus{Path: dir, Section: section.name, Status: FileStatusFailed, Error: err.Error()})
+		}
+		// Dashboards are provisioned even if some of them are invalid, the dashboard providers skip them.
+		if hasFailedFiles(files) && section.name != SectionDashboards {
+			setStatus(files, FileStatusSkipped)
+			result = append(result, files...)
+			continue
+		}
+		if _, err := os.Stat(repo.path(dir)); err == nil || section.name == SectionDashboards {
+			if err := section.apply(ctx, repo.path(dir)); err != nil {
+				setApplyError(files, err)
+			}
+		}
+		result = append(result, files...)
+	}
+	return result
+}
+
+func (s *Syncer) updateStatus(name string, update func(*RepositoryStatus)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if status, ok := s.statuses[name]; ok {
+		update(status)
+	}
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+const (
+	// SectionDashboards, SectionDatasources and SectionAlerting are the kinds of provisioning files of a repository.
+	SectionDashboards  = "dashboards"
+	SectionDatasources = "datasources"
+	SectionAlerting    = "alerting"
+
+	defaultRef                   = "HEAD"
+	defaultUpdateIntervalSeconds = 60
+)
+
+// repositoryConfig is a git repository to provision resources from. The repository mirrors the layout of
+// the provisioning directory: dashboards, datasources and alerting files are read from a directory each.
+type repositoryConfig struct {
+	Name                  string
+	URL                   string
+	Ref                   string
+	UpdateIntervalSeconds int64
+	// Paths are the directories of the provisioning files in the repository by section.
+	Paths map[string]string
+
+	VerifySignatures bool
+	TrustedKeys      string
+	TrustedKeysFile  string
+}
+
+type configVersion struct {
+	APIVersion int64 `json:"apiVersion" yaml:"apiVersion"`
+}
+
+type configV1 struct {
+	Repositories []*repositoryConfigV1 `json:"repositories" yaml:"repositories"`
+}
+
+type repositoryConfigV1 struct {
+	Name                  values.StringValue `json:"name" yaml:"name"`
+	URL                   values.StringValue `json:"url" yaml:"url"`
+	Ref                   values.StringValue `json:"ref" yaml:"ref"`
+	UpdateIntervalSeconds values.Int64Value  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
+	Paths                 pathsConfigV1      `json:"paths" yaml:"paths"`
+	VerifySignatures      values.BoolValue   `json:"verifySignatures" yaml:"verifySignatures"`
+	TrustedKeys           values.StringValue `json:"trustedKeys" yaml:"trustedKeys"`
+	TrustedKeysFile       values.StringValue `json:"trustedKeysFile" yaml:"trustedKeysFile"`
+}
+
+type pathsConfigV1 struct {
+	Dashboards  values.StringValue `json:"dashboards" yaml:"dashboards"`
+	Datasources values.StringValue `json:"datasources" yaml:"datasources"`
+	Alerting    values.StringValue `json:"alerting" yaml:"alerting"`
+}
+
+func (cfg *repositoryConfigV1) mapToRepositoryConfig() *repositoryConfig {
+	r := &repositoryConfig{
+		Name:                  cfg.Name.Value(),
+		URL:                   cfg.URL.Value(),
+		Ref:                   cfg.Ref.Value(),
+		UpdateIntervalSeconds: cfg.UpdateIntervalSeconds.Value(),
+		Paths: map[string]string{
+			SectionDashboards:  cfg.Paths.Dashboards.Value(),
+			SectionDatasources: cfg.Paths.Datasources.Value(),
+			SectionAlerting:    cfg.Paths.Alerting.Value(),
+		},
+		VerifySignatures: cfg.VerifySignatures.Value(),
+		TrustedKeys:      cfg.TrustedKeys.Value(),
+		TrustedKeysFile:  cfg.TrustedKeysFile.Value(),
+	}
+	if r.Ref == "" {
+		r.Ref = defaultRef
+	}
+	if r.UpdateIntervalSeconds == 0 {
+		r.UpdateIntervalSeconds = defaultUpdateIntervalSeconds
+	}
+	for section, path := range r.Paths {
+		if path == "" {
+			r.Paths[section] = section
+		}
+	}
+	return r
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+var (
+	// ErrCommitNotSigned is returned when signatures are verified and a commit is not signed.
+	ErrCommitNotSigned = errors.New("commit is not signed")
+	// ErrSignatureNotTrusted is returned when a commit is not signed by one of the trusted keys.
+	ErrSignatureNotTrusted = errors.New("commit is not signed by a trusted key")
+)
+
+// readKeyRing reads the armored public keys that commits can be signed with.
+func readKeyRing(cfg *repositoryConfig) (openpgp.EntityList, error) {
+	armored := []byte(cfg.TrustedKeys)
+	if cfg.TrustedKeysFile != "" {
+		// nolint:gosec
+		// The file is configured by the administrator in the provisioning files.
+		content, err := os.ReadFile(cfg.TrustedKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted keys file: %w", err)
+		}
+		armored = append(append(armored, '\n'), content...)
+	}
+
+	var keyRing openpgp.EntityList
+	for len(bytes.TrimSpace(armored)) > 0 {
+		// Several armored blocks can follow each other, ReadArmoredKeyRing reads one block at a time.
+		end := bytes.Index(armored, []byte("-----END PGP PUBLIC KEY BLOCK-----"))
+		if end < 0 {
+			return nil, errors.New("failed to read trusted keys: missing end of armored public key block")
+		}
+		end += len("-----END PGP PUBLIC KEY BLOCK-----")
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armored[:end]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted keys: %w", err)
+		}
+		keyRing = append(keyRing, entities...)
+		armored = armored[end:]
+	}
+	if len(keyRing) == 0 {
+		return nil, errors.New("no trusted keys")
+	}
+	return keyRing, nil
+}
+
+// verifyCommit verifies that the raw commit object, as printed by git cat-file, is signed by one of the keys.
+func verifyCommit(raw []byte, keyRing openpgp.KeyRing) error {
+	payload, signature, err := splitCommitSignature(raw)
+	if err != nil {
+		return err
+	}
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyRing, bytes.NewReader(payload), bytes.NewReader(signature), nil); err != nil {
+		return fmt.Errorf("%w: %s", ErrSignatureNotTrusted, err)
+	}
+	return nil
+}
+
+// splitCommitSignature splits a commit object into the signed payload and the signature. The signature
+// is in the gpgsig header, continued on the next lines that start with a space. The payload is the commit
+// object without the signature header.
+func splitCommitSignature(raw []byte) ([]byte, []byte, error) {
+	headers, message, found := bytes.Cut(raw, []byte("\n\n"))
+	if !found {
+		headers, message = raw, nil
+	}
+
+	var payload, signature bytes.Buffer
+	inSignature := false
+	for _, line := range strings.Split(string(headers), "\n") {
+		switch {
+		case inSignature && strings.HasPrefix(line, " "):
+			signature.WriteString(line[1:])
+			signature.WriteByte('\n')
+			continue
+		case strings.HasPrefix(line, "gpgsig ") || strings.HasPrefix(line, "gpgsig-sha256 "):
+			_, value, _ := strings.Cut(line, " ")
+			signature.WriteString(value)
+			signature.WriteByte('\n')
+			inSignature = true
+			continue
+		}
+		inSignature = false
+		payload.WriteString(line)
+		payload.WriteByte('\n')
+	}
+	if signature.Len() == 0 {
+		return nil, nil, ErrCommitNotSigned
+	}
+	if found {
+		payload.WriteByte('\n')
+		payload.Write(message)
+	}
+	return payload.Bytes(), signature.Bytes(), nil
+}
@@ -0,0 +1,102 @@
+// Package plan describes the changes that provisioning would make to the database, so that they can be reviewed
+// before the provisioning files are applied.
+package plan
+
+import (
+	"sort"
+	"strings"
+)
+
+// Action is the change that provisioning makes to a resource.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionUnprovision removes the provisioning metadata of a dashboard whose file is missing, the dashboard is kept.
+	ActionUnprovision Action = "unprovision"
+)
+
+const (
+	ResourceDatasource         = "datasource"
+	ResourcePlugin             = "plugin"
+	ResourceFolder             = "folder"
+	ResourceDashboard          = "dashboard"
+	ResourceAlertRule          = "alert_rule"
+	ResourceContactPoint       = "contact_point"
+	ResourceNotificationPolicy = "notification_policy"
+	ResourceMuteTiming         = "mute_timing"
+	ResourceTemplate           = "template"
+)
+
+// Change is the change of a single resource.
+type Change struct {
+	// Resource is the kind of the resource, e.g. datasource, dashboard or alert_rule.
+	Resource string `json:"resource"`
+	Action   Action `json:"action"`
+	OrgID    int64  `json:"orgId"`
+	Name     string `json:"name,omitempty"`
+	UID      string `json:"uid,omitempty"`
+	// Source is the provisioning directory or file the change comes from.
+	Source string `json:"source,omitempty"`
+	// Fields lists the fields that an update changes, if they are known.
+	Fields []string `json:"fields,omitempty"`
+	// Provenance is the current provenance of an alerting resource, or the provider of a dashboard that was
+	// provisioned by another provider.
+	Provenance string `json:"provenance,omitempty"`
+	// ProvenanceConflict is set when the resource exists but was not provisioned from a file, e.g. it was created
+	// in the UI or by the API, and the change overwrites or deletes it.
+	ProvenanceConflict bool `json:"provenanceConflict,omitempty"`
+}
+
+// Plan lists the changes of all provisioning sources.
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// Add adds changes to the plan.
+func (p *Plan) Add(changes ...Change) {
+	p.Changes = append(p.Changes, changes...)
+}
+
+// Count returns the number of changes with the action.
+func (p *Plan) Count(action Action) int {
+	count := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Conflicts returns the number of changes with a provenance conflict.
+func (p *Plan) Conflicts() int {
+	count := 0
+	for _, c := range p.Changes {
+		if c.ProvenanceConflict {
+			count++
+		}
+	}
+	return count
+}
+
+// TopLevelFields returns the sorted top level fields of the paths of a diff, e.g. "Data" for "Data[0].Model".
+func TopLevelFields(paths []string) []string {
+	seen := make(map[string]struct{}, len(paths))
+	fields := make([]string, 0, len(paths))
+	for _, path := range paths {
+		field := path
+		if i := strings.IndexAny(field, ".["); i > 0 {
+			field = field[:i]
+		}
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		seen[field] = struct{}{}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
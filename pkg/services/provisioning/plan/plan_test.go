@@ -0,0 +1,24 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopLevelFields(t *testing.T) {
+	require.Equal(t, []string{"Data", "For", "Labels"}, TopLevelFields([]string{"Labels.team", "Data[0].Model", "For", "Data[1].RefID"}))
+	require.Empty(t, TopLevelFields(nil))
+}
+
+func TestPlanCount(t *testing.T) {
+	p := &Plan{}
+	p.Add(
+		Change{Resource: ResourceDatasource, Action: ActionCreate},
+		Change{Resource: ResourceAlertRule, Action: ActionUpdate, ProvenanceConflict: true},
+		Change{Resource: ResourceDashboard, Action: ActionCreate},
+	)
+	require.Equal(t, 2, p.Count(ActionCreate))
+	require.Equal(t, 0, p.Count(ActionDelete))
+	require.Equal(t, 1, p.Conflicts())
+}
@@ -0,0 +1,87 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// Plan scans a directory for provisioning config files and returns the changes
+// that Provision would make to the app settings, without applying them.
+// Secure JSON data is not compared.
+func Plan(ctx context.Context, configDirectory string, pluginStore pluginstore.Store, pluginSettings pluginsettings.Service, orgService org.Service) ([]plan.Change, error) {
+	logger := log.New("provisioning.plugins")
+	ap := PluginProvisioner{
+		log:            logger,
+		cfgProvider:    newConfigReader(logger, pluginStore),
+		pluginSettings: pluginSettings,
+		orgService:     orgService,
+	}
+	return ap.planChanges(ctx, configDirectory)
+}
+
+func (ap *PluginProvisioner) planChanges(ctx context.Context, configPath string) ([]plan.Change, error) {
+	configs, err := ap.cfgProvider.readConfig(ctx, configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []plan.Change
+	for _, cfg := range configs {
+		for _, app := range cfg.Apps {
+			if err := ap.resolveOrgID(ctx, app); err != nil {
+				return nil, err
+			}
+			change := plan.Change{
+				Resource: plan.ResourcePlugin,
+				Action:   plan.ActionCreate,
+				OrgID:    app.OrgID,
+				Name:     app.PluginID,
+				Source:   configPath,
+			}
+
+			ps, err := ap.pluginSettings.GetPluginSettingByPluginID(ctx, &pluginsettings.GetByPluginIDArgs{
+				OrgID:    app.OrgID,
+				PluginID: app.PluginID,
+			})
+			if err != nil && !errors.Is(err, pluginsettings.ErrPluginSettingNotFound) {
+				return nil, err
+			}
+			if err == nil {
+				if ps.Enabled != app.Enabled {
+					change.Fields = append(change.Fields, "enabled")
+				}
+				if ps.Pinned != app.Pinned {
+					change.Fields = append(change.Fields, "pinned")
+				}
+				equal, err := util.JSONEqual(nonNilMap(ps.JSONData), nonNilMap(app.JSONData))
+				if err != nil {
+					return nil, err
+				}
+				if !equal {
+					change.Fields = append(change.Fields, "jsonData")
+				}
+				if len(change.Fields) == 0 {
+					continue
+				}
+				change.Action = plan.ActionUpdate
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func nonNilMap(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
@@ -34,15 +34,8 @@ type PluginProvisioner struct {
 
 func (ap *PluginProvisioner) apply(ctx context.Context, cfg *pluginsAsConfig) error {
 	for _, app := range cfg.Apps {
-		if app.OrgID == 0 && app.OrgName != "" {
-			getOrgQuery := &org.GetOrgByNameQuery{Name: app.OrgName}
-			res, err := ap.orgService.GetByName(ctx, getOrgQuery)
-			if err != nil {
-				return err
-			}
-			app.OrgID = res.ID
-		} else if app.OrgID < 0 {
-			app.OrgID = 1
+		if err := ap.resolveOrgID(ctx, app); err != nil {
+			return err
 		}
 
 		ps, err := ap.pluginSettings.GetPluginSettingByPluginID(ctx, &pluginsettings.GetByPluginIDArgs{
@@ -74,6 +67,20 @@ func (ap *PluginProvisioner) apply(ctx context.Context, cfg *pluginsAsConfig) er
 	return nil
 }
 
+func (ap *PluginProvisioner) resolveOrgID(ctx context.Context, app *appFromConfig) error {
+	if app.OrgID == 0 && app.OrgName != "" {
+		getOrgQuery := &org.GetOrgByNameQuery{Name: app.OrgName}
+		res, err := ap.orgService.GetByName(ctx, getOrgQuery)
+		if err != nil {
+			return err
+		}
+		app.OrgID = res.ID
+	} else if app.OrgID < 0 {
+		app.OrgID = 1
+	}
+	return nil
+}
+
 func (ap *PluginProvisioner) applyChanges(ctx context.Context, configPath string) error {
 	configs, err := ap.cfgProvider.readConfig(ctx, configPath)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/org/orgtest"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginsettings"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
 )
 
 func TestPluginProvisioner(t *testing.T) {
@@ -68,6 +69,28 @@ func TestPluginProvisioner(t *testing.T) {
 			require.Equal(t, tc.ExpectedSecureJSONData, cmd.SecureJSONData)
 		}
 	})
+
+	t.Run("Should plan configurations without applying them", func(t *testing.T) {
+		cfg := []*pluginsAsConfig{
+			{
+				Apps: []*appFromConfig{
+					{PluginID: "test-plugin", OrgID: 2, Enabled: true},
+					{PluginID: "test-plugin-2", OrgID: 3, Enabled: false},
+				},
+			},
+		}
+		reader := &testConfigReader{result: cfg}
+		store := &mockStore{}
+		ap := PluginProvisioner{log: log.New("test"), cfgProvider: reader, pluginSettings: store, orgService: orgtest.NewOrgServiceFake()}
+
+		changes, err := ap.planChanges(context.Background(), "")
+		require.NoError(t, err)
+		require.Empty(t, store.updateRequests)
+		require.Equal(t, []plan.Change{
+			{Resource: plan.ResourcePlugin, Action: plan.ActionUpdate, OrgID: 2, Name: "test-plugin", Fields: []string{"enabled"}},
+			{Resource: plan.ResourcePlugin, Action: plan.ActionCreate, OrgID: 3, Name: "test-plugin-2"},
+		}, changes)
+	})
 }
 
 type testConfigReader struct {
@@ -29,6 +29,8 @@ import (
 	prov_alerting "github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/searchV2"
@@ -79,6 +81,19 @@ func ProvideService(
 		folderService:                folderService,
 		resourcePermissions:          resourcePermissions,
 	}
+	s.gitSyncer = git.NewSyncer(
+		filepath.Join(cfg.ProvisioningPath, "git"),
+		filepath.Join(cfg.DataPath, "provisioning", "git"),
+		git.Appliers{
+			// The datasources of all the directories are provisioned together, otherwise the pruning of one
+			// directory would delete the datasources of the others.
+			Datasources: func(ctx context.Context, _ string) error {
+				return s.ProvisionDatasources(ctx)
+			},
+			Alerting:   s.provisionAlertingFromPath,
+			Dashboards: s.ProvisionDashboards,
+		},
+	)
 
 	if err := s.setDashboardProvisioner(); err != nil {
 		return nil, err
@@ -88,15 +103,27 @@ func ProvideService(
 }
 
 func (ps *ProvisioningServiceImpl) setDashboardProvisioner() error {
-	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
-	dashProvisioner, err := ps.newDashboardProvisioner(context.Background(), dashboardPath, ps.dashboardProvisioningService, ps.orgService, ps.dashboardService, ps.folderService)
+	dashProvisioner, err := ps.createDashboardProvisioner()
 	if err != nil {
-		return fmt.Errorf("%v: %w", "Failed to create provisioner", err)
+		return err
 	}
 	ps.dashboardProvisioner = dashProvisioner
 	return nil
 }
 
+func (ps *ProvisioningServiceImpl) createDashboardProvisioner() (dashboards.DashboardProvisioner, error) {
+	dashboardPath := filepath.Join(ps.Cfg.ProvisioningPath, "dashboards")
+	var gitDirectories []dashboards.ConfigDirectory
+	if ps.gitSyncer != nil {
+		gitDirectories = ps.gitSyncer.DashboardConfigDirectories()
+	}
+	dashProvisioner, err := ps.newDashboardProvisioner(context.Background(), dashboardPath, ps.dashboardProvisioningService, ps.orgService, ps.dashboardService, ps.folderService, gitDirectories...)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "Failed to create provisioner", err)
+	}
+	return dashProvisioner, nil
+}
+
 type ProvisioningService interface {
 	registry.BackgroundService
 	RunInitProvisioners(ctx context.Context) error
@@ -104,6 +131,9 @@ type ProvisioningService interface {
 	ProvisionPlugins(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
 	ProvisionAlerting(ctx context.Context) error
+	ProvisionGit(ctx context.Context) error
+	GetGitRepositoriesStatus() []git.RepositoryStatus
+	Plan(ctx context.Context) (*plan.Plan, error)
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 }
@@ -111,7 +141,7 @@ type ProvisioningService interface {
 // Used for testing purposes
 func newProvisioningServiceImpl(
 	newDashboardProvisioner dashboards.DashboardProvisionerFactory,
-	provisionDatasources func(context.Context, string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error,
+	provisionDatasources func(context.Context, []string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error,
 	provisionPlugins func(context.Context, string, pluginstore.Store, pluginsettings.Service, org.Service) error,
 	searchService searchV2.SearchService,
 ) (*ProvisioningServiceImpl, error) {
@@ -143,7 +173,7 @@ type ProvisioningServiceImpl struct {
 	pollingCtxCancel             context.CancelFunc
 	newDashboardProvisioner      dashboards.DashboardProvisionerFactory
 	dashboardProvisioner         dashboards.DashboardProvisioner
-	provisionDatasources         func(context.Context, string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error
+	provisionDatasources         func(context.Context, []string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error
 	provisionPlugins             func(context.Context, string, pluginstore.Store, pluginsettings.Service, org.Service) error
 	provisionAlerting            func(context.Context, prov_alerting.ProvisionerConfig) error
 	mutex                        sync.Mutex
@@ -157,6 +187,7 @@ type ProvisioningServiceImpl struct {
 	secretService                secrets.Service
 	folderService                folder.Service
 	resourcePermissions          accesscontrol.ReceiverPermissionsService
+	gitSyncer                    *git.Syncer
 }
 
 func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) error {
@@ -178,6 +209,19 @@ func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) erro
 		return err
 	}
 
+	if ps.gitSyncer == nil {
+		return nil
+	}
+	if err := ps.gitSyncer.Load(); err != nil {
+		err = fmt.Errorf("%v: %w", "Failed to read git repositories config", err)
+		ps.log.Error("Failed to provision git repositories", "error", err)
+		return err
+	}
+	if err := ps.gitSyncer.Sync(ctx, false); err != nil {
+		// The repositories are synced again at their update interval, the errors are reported in their status.
+		ps.log.Error("Failed to provision git repositories", "error", err)
+	}
+
 	return nil
 }
 
@@ -194,6 +238,9 @@ func (ps *ProvisioningServiceImpl) Run(ctx context.Context) error {
 	if ps.dashboardProvisioner.HasDashboardSources() {
 		ps.searchService.TriggerReIndex()
 	}
+	if ps.gitSyncer != nil {
+		go ps.gitSyncer.Run(ctx)
+	}
 
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
@@ -218,8 +265,8 @@ func (ps *ProvisioningServiceImpl) Run(ctx context.Context) error {
 }
 
 func (ps *ProvisioningServiceImpl) ProvisionDatasources(ctx context.Context) error {
-	datasourcePath := filepath.Join(ps.Cfg.ProvisioningPath, "datasources")
-	if err := ps.provisionDatasources(ctx, datasourcePath, ps.datasourceService, ps.correlationsService, ps.orgService); err != nil {
+	datasourcePaths := ps.provisioningDirectories("datasources", git.SectionDatasources)
+	if err := ps.provisionDatasources(ctx, datasourcePaths, ps.datasourceService, ps.correlationsService, ps.orgService); err != nil {
 		err = fmt.Errorf("%v: %w", "Datasource provisioning error", err)
 		ps.log.Error("Failed to provision data sources", "error", err)
 		return err
@@ -259,7 +306,88 @@ func (ps *ProvisioningServiceImpl) ProvisionDashboards(ctx context.Context) erro
 }
 
 func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error {
-	alertingPath := filepath.Join(ps.Cfg.ProvisioningPath, "alerting")
+	return ps.provisionAlertingFromPath(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "alerting"))
+}
+
+// ProvisionGit reads the git repositories config again, then fetches the repositories and provisions
+// the files of the ones that point to a new commit.
+func (ps *ProvisioningServiceImpl) ProvisionGit(ctx context.Context) error {
+	if ps.gitSyncer == nil {
+		return nil
+	}
+	if err := ps.gitSyncer.Load(); err != nil {
+		return fmt.Errorf("%v: %w", "Failed to read git repositories config", err)
+	}
+	return ps.gitSyncer.Sync(ctx, true)
+}
+
+// GetGitRepositoriesStatus returns the status of the last sync of the git repositories.
+func (ps *ProvisioningServiceImpl) GetGitRepositoriesStatus() []git.RepositoryStatus {
+	if ps.gitSyncer == nil {
+		return nil
+	}
+	return ps.gitSyncer.Status()
+}
+
+// Plan reads all provisioning sources and returns the changes that provisioning them would make to the database,
+// without applying them. The git repositories are not fetched, the plan uses their current checkouts.
+func (ps *ProvisioningServiceImpl) Plan(ctx context.Context) (*plan.Plan, error) {
+	// grafana-cli plans without running the provisioners, so the repositories are not read yet
+	if ps.gitSyncer != nil && !ps.gitSyncer.Loaded() {
+		if err := ps.gitSyncer.Load(); err != nil {
+			return nil, fmt.Errorf("%v: %w", "Failed to read git repositories config", err)
+		}
+	}
+
+	result := &plan.Plan{Changes: []plan.Change{}}
+
+	changes, err := datasources.Plan(ctx, ps.provisioningDirectories("datasources", git.SectionDatasources), ps.datasourceService, ps.orgService)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "Datasource provisioning error", err)
+	}
+	result.Add(changes...)
+
+	changes, err = plugins.Plan(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "plugins"), ps.pluginStore, ps.pluginsSettings, ps.orgService)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "app provisioning error", err)
+	}
+	result.Add(changes...)
+
+	dashProvisioner, err := ps.createDashboardProvisioner()
+	if err != nil {
+		return nil, err
+	}
+	changes, err = dashProvisioner.Plan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", "Failed to plan dashboards", err)
+	}
+	result.Add(changes...)
+
+	for _, path := range ps.provisioningDirectories("alerting", git.SectionAlerting) {
+		changes, err := prov_alerting.Plan(ctx, ps.alertingProvisionerConfig(path))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", "Failed to plan alerting", err)
+		}
+		result.Add(changes...)
+	}
+
+	return result, nil
+}
+
+// provisioningDirectories returns the directory of the provisioning path and the directories of the git checkouts for a section.
+func (ps *ProvisioningServiceImpl) provisioningDirectories(dir string, section string) []string {
+	dirs := []string{filepath.Join(ps.Cfg.ProvisioningPath, dir)}
+	if ps.gitSyncer != nil {
+		dirs = append(dirs, ps.gitSyncer.SectionDirectories(section)...)
+	}
+	return dirs
+}
+
+func (ps *ProvisioningServiceImpl) provisionAlertingFromPath(ctx context.Context, alertingPath string) error {
+	return ps.provisionAlerting(ctx, ps.alertingProvisionerConfig(alertingPath))
+}
+
+func (ps *ProvisioningServiceImpl) alertingProvisionerConfig(alertingPath string) prov_alerting.ProvisionerConfig {
 	st := store.DBstore{
 		Cfg:              ps.Cfg.UnifiedAlerting,
 		SQLStore:         ps.SQLStore,
@@ -298,7 +426,7 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 		st, ps.SQLStore, ps.Cfg.UnifiedAlerting, ps.log)
 	mutetimingsService := provisioning.NewMuteTimingService(configStore, st, &st, ps.log, &st)
 	templateService := provisioning.NewTemplateService(configStore, st, &st, ps.log)
-	cfg := prov_alerting.ProvisionerConfig{
+	return prov_alerting.ProvisionerConfig{
 		Path:                       alertingPath,
 		RuleService:                *ruleService,
 		FolderService:              ps.folderService,
@@ -308,7 +436,6 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 		MuteTimingService:          *mutetimingsService,
 		TemplateService:            *templateService,
 	}
-	return ps.provisionAlerting(ctx, cfg)
 }
 
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
@@ -1,6 +1,11 @@
 package provisioning
 
-import "context"
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/provisioning/git"
+	"github.com/grafana/grafana/pkg/services/provisioning/plan"
+)
 
 type Calls struct {
 	RunInitProvisioners                 []any
@@ -8,6 +13,9 @@ type Calls struct {
 	ProvisionPlugins                    []any
 	ProvisionDashboards                 []any
 	ProvisionAlerting                   []any
+	ProvisionGit                        []any
+	GetGitRepositoriesStatus            []any
+	Plan                                []any
 	GetDashboardProvisionerResolvedPath []any
 	GetAllowUIUpdatesFromConfig         []any
 	Run                                 []any
@@ -19,6 +27,9 @@ type ProvisioningServiceMock struct {
 	ProvisionDatasourcesFunc                func(ctx context.Context) error
 	ProvisionPluginsFunc                    func() error
 	ProvisionDashboardsFunc                 func() error
+	ProvisionGitFunc                        func(ctx context.Context) error
+	GetGitRepositoriesStatusFunc            func() []git.RepositoryStatus
+	PlanFunc                                func(ctx context.Context) (*plan.Plan, error)
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
 	RunFunc                                 func(ctx context.Context) error
@@ -67,6 +78,30 @@ func (mock *ProvisioningServiceMock) ProvisionAlerting(ctx context.Context) erro
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionGit(ctx context.Context) error {
+	mock.Calls.ProvisionGit = append(mock.Calls.ProvisionGit, nil)
+	if mock.ProvisionGitFunc != nil {
+		return mock.ProvisionGitFunc(ctx)
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) GetGitRepositoriesStatus() []git.RepositoryStatus {
+	mock.Calls.GetGitRepositoriesStatus = append(mock.Calls.GetGitRepositoriesStatus, nil)
+	if mock.GetGitRepositoriesStatusFunc != nil {
+		return mock.GetGitRepositoriesStatusFunc()
+	}
+	return nil
+}
+
+func (mock *ProvisioningServiceMock) Plan(ctx context.Context) (*plan.Plan, error) {
+	mock.Calls.Plan = append(mock.Calls.Plan, nil)
+	if mock.PlanFunc != nil {
+		return mock.PlanFunc(ctx)
+	}
+	return &plan.Plan{}, nil
+}
+
 func (mock *ProvisioningServiceMock) GetDashboardProvisionerResolvedPath(name string) string {
 	mock.Calls.GetDashboardProvisionerResolvedPath = append(mock.Calls.GetDashboardProvisionerResolvedPath, name)
 	if mock.GetDashboardProvisionerResolvedPathFunc != nil {
@@ -0,0 +1,252 @@
+// Package recordedseries stores the series written by recording rules in the Grafana database,
+// so they can be queried without an external time series database.
+package recordedseries
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+const (
+	// maxQuerySamples is the maximum number of samples a single query of the store can return.
+	maxQuerySamples = 100000
+	// maxSamplesPerStatement and maxSeriesPerStatement keep the number of parameters of a statement below the limits of the databases.
+	maxSamplesPerStatement = 100
+	maxSeriesPerStatement  = 500
+)
+
+var ErrTooManySamples = fmt.Errorf("query returned more than %d samples, reduce the time range or add label matchers", maxQuerySamples)
+
+// Sample is a single sample of a recorded series.
+type Sample struct {
+	Metric string
+	Labels map[string]string
+	Time   time.Time
+	Value  float64
+}
+
+// recordedSample is a single sample of a recorded series in the database.
+type recordedSample struct {
+	ID         int64   `xorm:"pk autoincr 'id'"`
+	OrgID      int64   `xorm:"org_id"`
+	Metric     string  `xorm:"metric"`
+	LabelsHash string  `xorm:"labels_hash"`
+	Labels     string  `xorm:"labels"`
+	Epoch      int64   `xorm:"epoch"`
+	Value      float64 `xorm:"value"`
+}
+
+func (recordedSample) TableName() string {
+	return "alert_recorded_sample"
+}
+
+// Query selects the samples of the series with the given metric name and labels in the time range [From, To].
+type Query struct {
+	OrgID    int64
+	Metric   string
+	Matchers map[string]string
+	From     time.Time
+	To       time.Time
+}
+
+// Store saves recorded series in the Grafana database.
+type Store struct {
+	db db.DB
+}
+
+func NewStore(db db.DB) *Store {
+	return &Store{db: db}
+}
+
+// Save saves the samples of an organization. Samples that already exist with the same timestamp are replaced,
+// so a write of the same series by another instance in HA mode does not fail the write of the other samples.
+func (s *Store) Save(ctx context.Context, orgID int64, samples []Sample) error {
+	rows := make([]*recordedSample, 0, len(samples))
+	seen := make(map[string]int, len(samples))
+	for _, p := range samples {
+		// NaN and Inf cannot be stored in all databases
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		labels, err := json.Marshal(p.Labels)
+		if err != nil {
+			return err
+		}
+		row := &recordedSample{
+			OrgID:      orgID,
+			Metric:     p.Metric,
+			LabelsHash: data.Labels(p.Labels).Fingerprint().String(),
+			Labels:     string(labels),
+			Epoch:      p.Time.UnixMilli(),
+			Value:      p.Value,
+		}
+		// a statement cannot update the same row twice, the last sample wins
+		key := fmt.Sprintf("%s/%s/%d", row.Metric, row.LabelsHash, row.Epoch)
+		if idx, ok := seen[key]; ok {
+			rows[idx] = row
+			continue
+		}
+		seen[key] = len(rows)
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		for batch := range slices.Chunk(rows, maxSamplesPerStatement) {
+			upsertSQL, err := s.db.GetDialect().UpsertMultipleSQL(
+				recordedSample{}.TableName(),
+				[]string{"org_id", "metric", "labels_hash", "epoch"},
+				[]string{"org_id", "metric", "labels_hash", "labels", "epoch", "value"},
+				len(batch))
+			if err != nil {
+				return err
+			}
+			args := make([]any, 0, 1+6*len(batch))
+			args = append(args, upsertSQL)
+			for _, sample := range batch {
+				args = append(args, sample.OrgID, sample.Metric, sample.LabelsHash, sample.Labels, sample.Epoch, sample.Value)
+			}
+			if _, err := sess.Exec(args...); err != nil {
+				return fmt.Errorf("failed to save recorded samples: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Query returns a frame for each series that matches the query.
+func (s *Store) Query(ctx context.Context, q Query) (data.Frames, error) {
+	var samples []recordedSample
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		cond := "org_id = ? AND metric = ? AND epoch >= ? AND epoch <= ?"
+		args := []any{q.OrgID, q.Metric, q.From.UnixMilli(), q.To.UnixMilli()}
+		if len(q.Matchers) == 0 {
+			return sess.Where(cond, args...).
+				OrderBy("labels_hash, epoch").
+				Limit(maxQuerySamples + 1).
+				Find(&samples)
+		}
+		// the labels are stored as JSON and cannot be matched in SQL. The matching series are selected first,
+		// so the limit applies only to their samples.
+		hashes, err := matchingSeries(sess, cond, args, q.Matchers)
+		if err != nil {
+			return err
+		}
+		for batch := range slices.Chunk(hashes, maxSeriesPerStatement) {
+			var batchSamples []recordedSample
+			err := sess.Where(cond, args...).
+				In("labels_hash", batch).
+				OrderBy("labels_hash, epoch").
+				Limit(maxQuerySamples + 1 - len(samples)).
+				Find(&batchSamples)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, batchSamples...)
+			if len(samples) > maxQuerySamples {
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) > maxQuerySamples {
+		return nil, ErrTooManySamples
+	}
+
+	type series struct {
+		labels data.Labels
+		times  []time.Time
+		values []float64
+	}
+	var result []*series
+	var current *series
+	currentHash := ""
+	for _, sample := range samples {
+		if current == nil || sample.LabelsHash != currentHash {
+			currentHash = sample.LabelsHash
+			labels := data.Labels{}
+			if err := json.Unmarshal([]byte(sample.Labels), &labels); err != nil {
+				return nil, fmt.Errorf("failed to read labels of series %s: %w", sample.Metric, err)
+			}
+			current = &series{labels: labels}
+			result = append(result, current)
+		}
+		current.times = append(current.times, time.UnixMilli(sample.Epoch).UTC())
+		current.values = append(current.values, sample.Value)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].labels.String() < result[j].labels.String()
+	})
+	frames := make(data.Frames, 0, len(result))
+	for _, ser := range result {
+		labels := ser.labels.Copy()
+		labels["__name__"] = q.Metric
+		frame := data.NewFrame(q.Metric,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, ser.times),
+			data.NewField(data.TimeSeriesValueFieldName, labels, ser.values),
+		)
+		frame.Meta = &data.FrameMeta{
+			Type:        data.FrameTypeTimeSeriesMulti,
+			TypeVersion: data.FrameTypeVersion{0, 1},
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// DeleteBefore deletes all samples older than t and returns the number of deleted samples.
+func (s *Store) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	var n int64
+	err := s.db.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		rows, err := sess.Where("epoch < ?", t.UnixMilli()).Delete(&recordedSample{})
+		if err != nil {
+			return fmt.Errorf("failed to delete recorded samples: %w", err)
+		}
+		n = rows
+		return nil
+	})
+	return n, err
+}
+
+// matchingSeries returns the hashes of the series that have samples matching the condition and labels matching the matchers.
+func matchingSeries(sess *db.Session, cond string, args []any, matchers map[string]string) ([]string, error) {
+	var series []recordedSample
+	if err := sess.Distinct("labels_hash", "labels").Where(cond, args...).Find(&series); err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(series))
+	for _, ser := range series {
+		labels := data.Labels{}
+		if err := json.Unmarshal([]byte(ser.Labels), &labels); err != nil {
+			return nil, fmt.Errorf("failed to read labels of series %s: %w", ser.LabelsHash, err)
+		}
+		if matches(labels, matchers) {
+			hashes = append(hashes, ser.LabelsHash)
+		}
+	}
+	return hashes, nil
+}
+
+func matches(labels data.Labels, matchers map[string]string) bool {
+	for k, v := range matchers {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+func addLiveChannelHistoryMigrations(mg *migrator.Migrator) {
+	liveChannelStream := migrator.Table{
+		Name: "live_channel_stream",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "channel", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "epoch", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "top_offset", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"channel"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create live_channel_stream table", migrator.NewAddTableMigration(liveChannelStream))
+	mg.AddMigration("add unique index live_channel_stream.channel", migrator.NewAddIndexMigration(liveChannelStream, liveChannelStream.Indices[0]))
+
+	liveChannelPublication := migrator.Table{
+		Name: "live_channel_publication",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "channel", Type: migrator.DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "pub_offset", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "data", Type: migrator.DB_MediumBlob, Nullable: false},
+			{Name: "created", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"channel", "pub_offset"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"created"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create live_channel_publication table", migrator.NewAddTableMigration(liveChannelPublication))
+	mg.AddMigration("add unique index live_channel_publication.channel-pub_offset", migrator.NewAddIndexMigration(liveChannelPublication, liveChannelPublication.Indices[0]))
+	mg.AddMigration("add index live_channel_publication.created", migrator.NewAddIndexMigration(liveChannelPublication, liveChannelPublication.Indices[1]))
+}
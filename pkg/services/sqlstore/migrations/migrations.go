@@ -131,6 +131,16 @@ func (oss *OSSMigrations) AddMigration(mg *Migrator) {
 	ualert.AddReceiverActionScopesMigration(mg)
 
 	ualert.AddRuleMetadata(mg)
+
+	ualert.AddRecordedSampleTable(mg)
+
+	ualert.AddStateHistoryTables(mg)
+
+	ualert.AddNotificationHistoryTables(mg)
+
+	ualert.AddNotificationResendQueueTable(mg)
+
+	addLiveChannelHistoryMigrations(mg)
 }
 
 func addStarMigrations(mg *Migrator) {
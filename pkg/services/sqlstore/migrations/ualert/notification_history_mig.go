@@ -0,0 +1,55 @@
+package ualert
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// AddNotificationHistoryTables creates the tables of the notification history of contact points.
+func AddNotificationHistoryTables(mg *migrator.Migrator) {
+	notificationHistory := migrator.Table{
+		Name: "notification_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "group_key", Type: migrator.DB_Text, Nullable: false},
+			{Name: "title", Type: migrator.DB_Text, Nullable: false},
+			{Name: "status", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "result", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "retry", Type: migrator.DB_Int, Nullable: false},
+			{Name: "duration", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "epoch", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "epoch"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "receiver", "epoch"}, Type: migrator.IndexType},
+			{Cols: []string{"epoch"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create notification_history table", migrator.NewAddTableMigration(notificationHistory))
+	mg.AddMigration("add index in notification_history on org_id and epoch columns", migrator.NewAddIndexMigration(notificationHistory, notificationHistory.Indices[0]))
+	mg.AddMigration("add index in notification_history on org_id, receiver and epoch columns", migrator.NewAddIndexMigration(notificationHistory, notificationHistory.Indices[1]))
+	mg.AddMigration("add index in notification_history on epoch column", migrator.NewAddIndexMigration(notificationHistory, notificationHistory.Indices[2]))
+
+	notificationHistoryAlert := migrator.Table{
+		Name: "notification_history_alert",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "notification_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "fingerprint", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"notification_id"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "rule_uid"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create notification_history_alert table", migrator.NewAddTableMigration(notificationHistoryAlert))
+	mg.AddMigration("add index in notification_history_alert on notification_id column", migrator.NewAddIndexMigration(notificationHistoryAlert, notificationHistoryAlert.Indices[0]))
+	mg.AddMigration("add index in notification_history_alert on org_id and rule_uid columns", migrator.NewAddIndexMigration(notificationHistoryAlert, notificationHistoryAlert.Indices[1]))
+}
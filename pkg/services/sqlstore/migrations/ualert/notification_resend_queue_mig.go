@@ -0,0 +1,35 @@
+package ualert
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// AddNotificationResendQueueTable creates the table of the notifications that the Alertmanager failed to deliver.
+func AddNotificationResendQueueTable(mg *migrator.Migrator) {
+	resendQueue := migrator.Table{
+		Name: "notification_resend_queue",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "group_key", Type: migrator.DB_Text, Nullable: false},
+			{Name: "group_labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "alerts", Type: migrator.DB_MediumText, Nullable: false},
+			{Name: "status", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "attempts", Type: migrator.DB_Int, Nullable: false},
+			{Name: "last_error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "next_attempt", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "created", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "updated", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"status", "next_attempt"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "status", "receiver"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create notification_resend_queue table", migrator.NewAddTableMigration(resendQueue))
+	mg.AddMigration("add index in notification_resend_queue on status and next_attempt columns", migrator.NewAddIndexMigration(resendQueue, resendQueue.Indices[0]))
+	mg.AddMigration("add index in notification_resend_queue on org_id, status and receiver columns", migrator.NewAddIndexMigration(resendQueue, resendQueue.Indices[1]))
+}
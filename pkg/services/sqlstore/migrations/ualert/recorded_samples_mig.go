@@ -0,0 +1,27 @@
+package ualert
+
+import "github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+
+// AddRecordedSampleTable creates the table that stores the series written by recording rules when the local backend is used.
+func AddRecordedSampleTable(mg *migrator.Migrator) {
+	recordedSample := migrator.Table{
+		Name: "alert_recorded_sample",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "metric", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "labels_hash", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "epoch", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "value", Type: migrator.DB_Double, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "metric", "labels_hash", "epoch"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"epoch"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_recorded_sample table", migrator.NewAddTableMigration(recordedSample))
+	mg.AddMigration("add unique index in alert_recorded_sample on org_id, metric, labels_hash and epoch columns", migrator.NewAddIndexMigration(recordedSample, recordedSample.Indices[0]))
+	mg.AddMigration("add index in alert_recorded_sample on epoch column", migrator.NewAddIndexMigration(recordedSample, recordedSample.Indices[1]))
+}
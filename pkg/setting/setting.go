@@ -413,6 +413,10 @@ type Cfg struct {
 
 	// ExpressionsEnabled specifies whether expressions are enabled.
 	ExpressionsEnabled bool
+	// SQLExpressionRowLimit is the maximum number of rows a SQL expression can read or produce. 0 disables the limit.
+	SQLExpressionRowLimit int64
+	// SQLExpressionMemoryLimitMB is the maximum memory in megabytes a SQL expression can use. 0 disables the limit.
+	SQLExpressionMemoryLimitMB int64
 
 	ImageUploadProvider string
 
@@ -429,6 +433,20 @@ type Cfg struct {
 	// LiveAllowedOrigins is a set of origins accepted by Live. If not provided
 	// then Live uses AppURL as the only allowed origin.
 	LiveAllowedOrigins []string
+	// LiveHistoryStorage is the storage of the history of Live channels, either
+	// "sql" or "file". Zero value disables the history.
+	LiveHistoryStorage string
+	// LiveHistoryPath is the directory of the history when LiveHistoryStorage is "file".
+	LiveHistoryPath string
+	// LiveHistoryChannels are the channels that keep a history.
+	LiveHistoryChannels []LiveHistoryChannel
+	// LivePipelineEnabled enables the Live pipeline, that processes the data pushed
+	// to channels according to the channel rules.
+	LivePipelineEnabled bool
+	// LiveMQTT is the MQTT input of the Live pipeline.
+	LiveMQTT LiveIngestSettings
+	// LiveNATS is the NATS input of the Live pipeline.
+	LiveNATS LiveIngestSettings
 
 	// Grafana.com URL, used for OAuth redirect.
 	GrafanaComURL string
@@ -454,6 +472,9 @@ type Cfg struct {
 
 	Search SearchSettings
 
+	// Query and resource caching
+	QueryCaching QueryCachingSettings
+
 	SecureSocksDSProxy SecureSocksDSProxySettings
 
 	// SAML Auth
@@ -750,6 +771,8 @@ func (cfg *Cfg) readAnnotationSettings() error {
 func (cfg *Cfg) readExpressionsSettings() {
 	expressions := cfg.Raw.Section("expressions")
 	cfg.ExpressionsEnabled = expressions.Key("enabled").MustBool(true)
+	cfg.SQLExpressionRowLimit = expressions.Key("sql_expression_row_limit").MustInt64(100000)
+	cfg.SQLExpressionMemoryLimitMB = expressions.Key("sql_expression_memory_limit_mb").MustInt64(100)
 }
 
 type AnnotationCleanupSettings struct {
@@ -1258,6 +1281,7 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 
 	cfg.Storage = readStorageSettings(iniFile)
 	cfg.Search = readSearchSettings(iniFile)
+	cfg.QueryCaching = readQueryCachingSettings(iniFile)
 
 	var err error
 	cfg.SecureSocksDSProxy, err = readSecureSocksDSProxySettings(iniFile)
@@ -2045,9 +2069,103 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 	}
 
 	cfg.LiveAllowedOrigins = originPatterns
+
+	cfg.LiveHistoryStorage = section.Key("history_storage").MustString("")
+	switch cfg.LiveHistoryStorage {
+	case "", "sql", "file":
+	default:
+		return fmt.Errorf("unsupported live history storage type: %s", cfg.LiveHistoryStorage)
+	}
+	cfg.LiveHistoryPath = section.Key("history_path").MustString(filepath.Join(cfg.DataPath, "live-history"))
+	defaultSize := section.Key("history_size").MustInt(100)
+	defaultTTL := section.Key("history_ttl").MustDuration(24 * time.Hour)
+	historyChannels, err := parseLiveHistoryChannels(util.SplitString(section.Key("history_channels").MustString("")), defaultSize, defaultTTL)
+	if err != nil {
+		return err
+	}
+	cfg.LiveHistoryChannels = historyChannels
+
+	cfg.LivePipelineEnabled = section.Key("pipeline_enabled").MustBool(false)
+	cfg.LiveMQTT = readLiveIngestSettings(iniFile.Section("live.mqtt"), "topics", "stream/mqtt")
+	cfg.LiveNATS = readLiveIngestSettings(iniFile.Section("live.nats"), "subjects", "stream/nats")
 	return nil
 }
 
+// LiveIngestSettings configures an input of the Live pipeline that subscribes to a message broker.
+type LiveIngestSettings struct {
+	// URL of the broker. The input is disabled when empty.
+	URL      string
+	Username string
+	Password string
+	// Token authenticates with NATS instead of Username and Password.
+	Token string
+	// ClientID is the MQTT client identifier.
+	ClientID string
+	// Topics are the MQTT topic filters or NATS subjects to subscribe to.
+	Topics []string
+	// ChannelPrefix is prepended to the topic of a message to build the Live channel.
+	ChannelPrefix string
+	// OrgID is the organization of the Live channels.
+	OrgID int64
+	// BufferSize is the number of messages waiting to be processed before reading from the broker pauses.
+	BufferSize int
+}
+
+func readLiveIngestSettings(section *ini.Section, topicsKey string, defaultChannelPrefix string) LiveIngestSettings {
+	return LiveIngestSettings{
+		URL:           section.Key("url").MustString(""),
+		Username:      section.Key("username").MustString(""),
+		Password:      section.Key("password").MustString(""),
+		Token:         section.Key("token").MustString(""),
+		ClientID:      section.Key("client_id").MustString("grafana"),
+		Topics:        util.SplitString(section.Key(topicsKey).MustString("")),
+		ChannelPrefix: strings.Trim(section.Key("channel_prefix").MustString(defaultChannelPrefix), "/"),
+		OrgID:         section.Key("org_id").MustInt64(1),
+		BufferSize:    section.Key("buffer_size").MustInt(1000),
+	}
+}
+
+// LiveHistoryChannel configures the history of the Live channels that match a pattern.
+type LiveHistoryChannel struct {
+	// Pattern matches the channel without the org prefix, e.g. "stream/factory/*".
+	Pattern string
+	// Size is the maximum number of publications to keep.
+	Size int
+	// TTL is the time window of the publications to keep.
+	TTL time.Duration
+}
+
+// parseLiveHistoryChannels parses entries in the "pattern[:size][:ttl]" format, e.g. "stream/factory/*:1000:1h".
+func parseLiveHistoryChannels(entries []string, defaultSize int, defaultTTL time.Duration) ([]LiveHistoryChannel, error) {
+	channels := make([]LiveHistoryChannel, 0, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid [live] history_channels entry %q: expected pattern[:size][:ttl]", entry)
+		}
+		channel := LiveHistoryChannel{Pattern: parts[0], Size: defaultSize, TTL: defaultTTL}
+		if _, err := glob.Compile(channel.Pattern, '/'); err != nil {
+			return nil, fmt.Errorf("invalid [live] history_channels pattern %q: %w", channel.Pattern, err)
+		}
+		for _, part := range parts[1:] {
+			if size, err := strconv.Atoi(part); err == nil {
+				channel.Size = size
+				continue
+			}
+			ttl, err := gtime.ParseDuration(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid [live] history_channels entry %q: %q is neither a size nor a duration", entry, part)
+			}
+			channel.TTL = ttl
+		}
+		if channel.Size <= 0 || channel.TTL <= 0 {
+			return nil, fmt.Errorf("invalid [live] history_channels entry %q: size and ttl must be positive", entry)
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
 func (cfg *Cfg) readPublicDashboardsSettings() {
 	publicDashboards := cfg.Raw.Section("public_dashboards")
 	cfg.PublicDashboardsEnabled = publicDashboards.Key("enabled").MustBool(true)
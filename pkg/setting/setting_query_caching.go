@@ -0,0 +1,25 @@
+package setting
+
+import (
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+type QueryCachingSettings struct {
+	// Enabled allows to turn on caching for data sources, it is off for every data source by default.
+	Enabled    bool
+	TTL        time.Duration
+	MaxTTL     time.Duration
+	MaxValueMB int
+}
+
+func readQueryCachingSettings(iniFile *ini.File) QueryCachingSettings {
+	section := iniFile.Section("caching")
+	return QueryCachingSettings{
+		Enabled:    section.Key("enabled").MustBool(true),
+		TTL:        section.Key("ttl").MustDuration(time.Minute),
+		MaxTTL:     section.Key("max_ttl").MustDuration(0),
+		MaxValueMB: section.Key("max_value_mb").MustInt(1),
+	}
+}
@@ -681,3 +681,19 @@ func TestDynamicSection(t *testing.T) {
 		assert.Equal(t, value, ds.section.Key(key).String())
 	})
 }
+
+func TestParseLiveHistoryChannels(t *testing.T) {
+	channels, err := parseLiveHistoryChannels([]string{"stream/factory/*:1000:1h", "stream/lab/temp:10m", "grafana/broadcast/**"}, 100, 24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []LiveHistoryChannel{
+		{Pattern: "stream/factory/*", Size: 1000, TTL: time.Hour},
+		{Pattern: "stream/lab/temp", Size: 100, TTL: 10 * time.Minute},
+		{Pattern: "grafana/broadcast/**", Size: 100, TTL: 24 * time.Hour},
+	}, channels)
+
+	_, err = parseLiveHistoryChannels([]string{"stream/factory/*:soon"}, 100, time.Hour)
+	require.Error(t, err)
+
+	_, err = parseLiveHistoryChannels([]string{"stream/factory/*:0"}, 100, time.Hour)
+	require.Error(t, err)
+}
@@ -60,11 +60,19 @@ const (
 	// with intervals that are not exactly divided by this number not to be evaluated
 	SchedulerBaseInterval = 10 * time.Second
 	// DefaultRuleEvaluationInterval indicates a default interval of for how long a rule should be evaluated to change state from Pending to Alerting
-	DefaultRuleEvaluationInterval  = SchedulerBaseInterval * 6 // == 60 seconds
-	stateHistoryDefaultEnabled     = true
-	lokiDefaultMaxQueryLength      = 721 * time.Hour // 30d1h, matches the default value in Loki
-	defaultRecordingRequestTimeout = 10 * time.Second
-	lokiDefaultMaxQuerySize        = 65536 // 64kb
+	DefaultRuleEvaluationInterval   = SchedulerBaseInterval * 6 // == 60 seconds
+	stateHistoryDefaultEnabled      = true
+	stateHistoryDefaultSQLRetention = 30 * 24 * time.Hour
+	notificationHistoryRetention    = 30 * 24 * time.Hour
+	resendQueuePollInterval         = 30 * time.Second
+	resendQueueMaxAttempts          = 10
+	resendQueueInitialBackoff       = time.Minute
+	resendQueueMaxBackoff           = time.Hour
+	resendQueueDeadLetterRetention  = 7 * 24 * time.Hour
+	lokiDefaultMaxQueryLength       = 721 * time.Hour // 30d1h, matches the default value in Loki
+	defaultRecordingRequestTimeout  = 10 * time.Second
+	defaultRecordingLocalRetention  = 15 * 24 * time.Hour
+	lokiDefaultMaxQuerySize         = 65536 // 64kb
 )
 
 type UnifiedAlertingSettings struct {
@@ -90,6 +98,7 @@ type UnifiedAlertingSettings struct {
 	HARedisMaxConns                 int
 	HARedisTLSEnabled               bool
 	HARedisTLSConfig                dstls.ClientConfig
+	HAEvaluationSharding            bool
 	MaxAttempts                     int64
 	MinInterval                     time.Duration
 	EvaluationTimeout               time.Duration
@@ -108,6 +117,8 @@ type UnifiedAlertingSettings struct {
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
 	SkipClustering                bool
 	StateHistory                  UnifiedAlertingStateHistorySettings
+	NotificationHistory           UnifiedAlertingNotificationHistorySettings
+	ResendQueue                   UnifiedAlertingResendQueueSettings
 	RemoteAlertmanager            RemoteAlertmanagerSettings
 	RecordingRules                RecordingRuleSettings
 
@@ -123,13 +134,25 @@ type UnifiedAlertingSettings struct {
 	ResolvedAlertRetention time.Duration
 }
 
+const (
+	// RecordingRuleBackendPrometheus writes recorded series to a Prometheus remote write endpoint.
+	RecordingRuleBackendPrometheus = "prometheus"
+	// RecordingRuleBackendOTLP exports recorded series to an OTLP/HTTP metrics endpoint.
+	RecordingRuleBackendOTLP = "otlp"
+	// RecordingRuleBackendLocal saves recorded series in the Grafana database.
+	RecordingRuleBackendLocal = "local"
+)
+
 type RecordingRuleSettings struct {
 	Enabled           bool
+	Backend           string
 	URL               string
 	BasicAuthUsername string
 	BasicAuthPassword string
 	CustomHeaders     map[string]string
 	Timeout           time.Duration
+	// LocalRetention is how long series saved by the local backend are kept.
+	LocalRetention time.Duration
 }
 
 // RemoteAlertmanagerSettings contains the configuration needed
@@ -169,6 +192,42 @@ type UnifiedAlertingStateHistorySettings struct {
 	MultiPrimary          string
 	MultiSecondaries      []string
 	ExternalLabels        map[string]string
+	// SQLRetention is how long state history written by the sql backend is kept. 0 keeps it forever.
+	SQLRetention time.Duration
+}
+
+// UnifiedAlertingNotificationHistorySettings configures the history of the notifications sent to contact points.
+type UnifiedAlertingNotificationHistorySettings struct {
+	Enabled bool
+	// Retention is how long the notification history is kept. 0 keeps it forever.
+	Retention time.Duration
+}
+
+// UnifiedAlertingResendQueueSettings configures the queue of the notifications that the Alertmanager failed to deliver.
+type UnifiedAlertingResendQueueSettings struct {
+	Enabled      bool
+	PollInterval time.Duration
+	// DeadLetterRetention is how long notifications that could not be delivered are kept. 0 keeps them forever.
+	DeadLetterRetention time.Duration
+	// DefaultBackoff is the backoff of integrations that do not have their own.
+	DefaultBackoff ResendBackoffSettings
+	// IntegrationBackoff is the backoff per integration type, such as email or slack.
+	IntegrationBackoff map[string]ResendBackoffSettings
+}
+
+// ResendBackoffSettings configures how often a notification is resent.
+type ResendBackoffSettings struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the backoff of the integration type.
+func (s UnifiedAlertingResendQueueSettings) Backoff(integration string) ResendBackoffSettings {
+	if b, ok := s.IntegrationBackoff[strings.ToLower(integration)]; ok {
+		return b
+	}
+	return s.DefaultBackoff
 }
 
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
@@ -277,6 +336,7 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	uaCfg.HARedisTLSConfig.InsecureSkipVerify = ua.Key("ha_redis_tls_insecure_skip_verify").MustBool(false)
 	uaCfg.HARedisTLSConfig.CipherSuites = ua.Key("ha_redis_tls_cipher_suites").MustString("")
 	uaCfg.HARedisTLSConfig.MinVersion = ua.Key("ha_redis_tls_min_version").MustString("")
+	uaCfg.HAEvaluationSharding = ua.Key("ha_evaluation_sharding").MustBool(false)
 
 	// TODO load from ini file
 	uaCfg.DefaultConfiguration = alertmanagerDefaultConfiguration
@@ -417,16 +477,52 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		MultiPrimary:          stateHistory.Key("primary").MustString(""),
 		MultiSecondaries:      splitTrim(stateHistory.Key("secondaries").MustString(""), ","),
 		ExternalLabels:        stateHistoryLabels.KeysHash(),
+		SQLRetention:          stateHistory.Key("sql_retention").MustDuration(stateHistoryDefaultSQLRetention),
 	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
+	notificationHistory := iniFile.Section("unified_alerting.notification_history")
+	uaCfg.NotificationHistory = UnifiedAlertingNotificationHistorySettings{
+		Enabled:   notificationHistory.Key("enabled").MustBool(false),
+		Retention: notificationHistory.Key("retention").MustDuration(notificationHistoryRetention),
+	}
+
+	const resendQueueSection = "unified_alerting.resend_queue"
+	resendQueue := iniFile.Section(resendQueueSection)
+	uaCfgResendQueue := UnifiedAlertingResendQueueSettings{
+		Enabled:             resendQueue.Key("enabled").MustBool(false),
+		PollInterval:        resendQueue.Key("poll_interval").MustDuration(resendQueuePollInterval),
+		DeadLetterRetention: resendQueue.Key("dead_letter_retention").MustDuration(resendQueueDeadLetterRetention),
+		DefaultBackoff:      readResendBackoff(resendQueue, ResendBackoffSettings{MaxAttempts: resendQueueMaxAttempts, InitialBackoff: resendQueueInitialBackoff, MaxBackoff: resendQueueMaxBackoff}),
+		IntegrationBackoff:  map[string]ResendBackoffSettings{},
+	}
+	for _, section := range iniFile.Sections() {
+		integration, ok := strings.CutPrefix(section.Name(), resendQueueSection+".")
+		if !ok || integration == "" {
+			continue
+		}
+		uaCfgResendQueue.IntegrationBackoff[strings.ToLower(integration)] = readResendBackoff(section, uaCfgResendQueue.DefaultBackoff)
+	}
+	if uaCfgResendQueue.PollInterval <= 0 {
+		return fmt.Errorf("setting 'poll_interval' in section '%s' must be positive", resendQueueSection)
+	}
+	uaCfg.ResendQueue = uaCfgResendQueue
+
 	rr := iniFile.Section("recording_rules")
 	uaCfgRecordingRules := RecordingRuleSettings{
 		Enabled:           rr.Key("enabled").MustBool(false),
+		Backend:           rr.Key("backend").MustString(RecordingRuleBackendPrometheus),
 		URL:               rr.Key("url").MustString(""),
 		BasicAuthUsername: rr.Key("basic_auth_username").MustString(""),
 		BasicAuthPassword: rr.Key("basic_auth_password").MustString(""),
 		Timeout:           rr.Key("timeout").MustDuration(defaultRecordingRequestTimeout),
+		LocalRetention:    rr.Key("local_retention").MustDuration(defaultRecordingLocalRetention),
+	}
+	switch uaCfgRecordingRules.Backend {
+	case RecordingRuleBackendPrometheus, RecordingRuleBackendOTLP, RecordingRuleBackendLocal:
+	default:
+		return fmt.Errorf("unsupported recording rules backend %q, must be one of [%s, %s, %s]", uaCfgRecordingRules.Backend,
+			RecordingRuleBackendPrometheus, RecordingRuleBackendOTLP, RecordingRuleBackendLocal)
 	}
 
 	rrHeaders := iniFile.Section("recording_rules.custom_headers")
@@ -463,6 +559,14 @@ func GetAlertmanagerDefaultConfiguration() string {
 	return alertmanagerDefaultConfiguration
 }
 
+func readResendBackoff(section *ini.Section, defaults ResendBackoffSettings) ResendBackoffSettings {
+	return ResendBackoffSettings{
+		MaxAttempts:    section.Key("max_attempts").MustInt(defaults.MaxAttempts),
+		InitialBackoff: section.Key("initial_backoff").MustDuration(defaults.InitialBackoff),
+		MaxBackoff:     section.Key("max_backoff").MustDuration(defaults.MaxBackoff),
+	}
+}
+
 func splitTrim(s string, sep string) []string {
 	spl := strings.Split(s, sep)
 	for i := range spl {
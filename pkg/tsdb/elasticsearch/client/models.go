@@ -67,6 +67,25 @@ type MultiSearchResponse struct {
 	Responses []*SearchResponse `json:"responses"`
 }
 
+// EsqlRequest represents an ES|QL query request
+type EsqlRequest struct {
+	Query string `json:"query"`
+}
+
+// EsqlColumn represents a column of an ES|QL query response
+type EsqlColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// EsqlResponse represents an ES|QL query response, the values are returned by row
+type EsqlResponse struct {
+	Status  int                    `json:"-"`
+	Error   map[string]interface{} `json:"error"`
+	Columns []EsqlColumn           `json:"columns"`
+	Values  [][]interface{}        `json:"values"`
+}
+
 // Query represents a query
 type Query struct {
 	Bool *BoolQuery `json:"bool"`
@@ -55,14 +55,27 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return errorsource.AddPluginErrorToResponse(e.dataQueries[0].RefID, response, err), nil
 	}
 
+	// the ES|QL queries are sent one by one, the other queries in one multisearch request
+	searchQueries := make([]*Query, 0, len(queries))
+	for _, q := range queries {
+		if isEsqlQuery(q) {
+			response.Responses[q.RefID] = e.processEsqlQuery(q)
+			continue
+		}
+		searchQueries = append(searchQueries, q)
+	}
+	if len(searchQueries) == 0 {
+		return response, nil
+	}
+
 	ms := e.client.MultiSearch()
 
-	for _, q := range queries {
+	for _, q := range searchQueries {
 		from := q.TimeRange.From.UnixNano() / int64(time.Millisecond)
 		to := q.TimeRange.To.UnixNano() / int64(time.Millisecond)
 		if err := e.processQuery(q, ms, from, to); err != nil {
 			mq, _ := json.Marshal(q)
-			e.logger.Error("Failed to process query to multisearch request builder", "error", err, "query", string(mq), "queriesLength", len(queries), "duration", time.Since(start), "stage", es.StagePrepareRequest)
+			e.logger.Error("Failed to process query to multisearch request builder", "error", err, "query", string(mq), "queriesLength", len(searchQueries), "duration", time.Since(start), "stage", es.StagePrepareRequest)
 			return errorsource.AddPluginErrorToResponse(q.RefID, response, err), nil
 		}
 	}
@@ -70,18 +83,32 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 	req, err := ms.Build()
 	if err != nil {
 		mqs, _ := json.Marshal(e.dataQueries)
-		e.logger.Error("Failed to build multisearch request", "error", err, "queriesLength", len(queries), "queries", string(mqs), "duration", time.Since(start), "stage", es.StagePrepareRequest)
-		return errorsource.AddPluginErrorToResponse(e.dataQueries[0].RefID, response, err), nil
+		e.logger.Error("Failed to build multisearch request", "error", err, "queriesLength", len(searchQueries), "queries", string(mqs), "duration", time.Since(start), "stage", es.StagePrepareRequest)
+		return errorsource.AddPluginErrorToResponse(searchQueries[0].RefID, response, err), nil
 	}
 
-	e.logger.Info("Prepared request", "queriesLength", len(queries), "duration", time.Since(start), "stage", es.StagePrepareRequest)
+	e.logger.Info("Prepared request", "queriesLength", len(searchQueries), "duration", time.Since(start), "stage", es.StagePrepareRequest)
 	res, err := e.client.ExecuteMultisearch(req)
 	if err != nil {
 		// We are returning error containing the source that was added through errorsource.Middleware
-		return errorsource.AddErrorToResponse(e.dataQueries[0].RefID, response, err), nil
+		return errorsource.AddErrorToResponse(searchQueries[0].RefID, response, err), nil
 	}
 
-	return parseResponse(e.ctx, res.Responses, queries, e.client.GetConfiguredFields(), e.keepLabelsInResponse, e.logger)
+	result, err := parseResponse(e.ctx, res.Responses, searchQueries, e.client.GetConfiguredFields(), e.keepLabelsInResponse, e.logger)
+	if err != nil {
+		if len(response.Responses) == 0 {
+			return result, err
+		}
+		// the responses of the ES|QL queries are kept, the error is returned for the other queries
+		for _, q := range searchQueries {
+			response.Responses[q.RefID] = errorsource.Response(errorsource.PluginError(err, false))
+		}
+		return response, nil
+	}
+	for refID, esqlResponse := range response.Responses {
+		result.Responses[refID] = esqlResponse
+	}
+	return result, nil
 }
 
 func (e *elasticsearchDataQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to int64) error {
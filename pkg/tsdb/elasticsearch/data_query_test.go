@@ -1805,6 +1805,9 @@ type fakeClient struct {
 	multiSearchError    error
 	builder             *es.MultiSearchRequestBuilder
 	multisearchRequests []*es.MultiSearchRequest
+	esqlResponse        *es.EsqlResponse
+	esqlError           error
+	esqlRequests        []*es.EsqlRequest
 }
 
 func newFakeClient() *fakeClient {
@@ -1818,6 +1821,7 @@ func newFakeClient() *fakeClient {
 		configuredFields:    configuredFields,
 		multisearchRequests: make([]*es.MultiSearchRequest, 0),
 		multiSearchResponse: &es.MultiSearchResponse{},
+		esqlResponse:        &es.EsqlResponse{Status: 200},
 	}
 }
 
@@ -1835,6 +1839,11 @@ func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	return c.builder
 }
 
+func (c *fakeClient) ExecuteEsql(r *es.EsqlRequest) (*es.EsqlResponse, error) {
+	c.esqlRequests = append(c.esqlRequests, r)
+	return c.esqlResponse, c.esqlError
+}
+
 func newDataQuery(body string) (backend.QueryDataRequest, error) {
 	return backend.QueryDataRequest{
 		Queries: []backend.DataQuery{
@@ -0,0 +1,309 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental/errorsource"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+const esqlQueryType = "esql"
+
+var (
+	timeFilterMacroRegex = regexp.MustCompile(`\$__timeFilter(?:\(([^)]*)\))?`)
+	esqlIdentifierRegex  = regexp.MustCompile(`^[A-Za-z0-9_.@]+$`)
+)
+
+// esqlNumericTypes are the ES|QL column types returned as numbers
+var esqlNumericTypes = map[string]bool{
+	"integer":         true,
+	"long":            true,
+	"unsigned_long":   true,
+	"double":          true,
+	"float":           true,
+	"half_float":      true,
+	"scaled_float":    true,
+	"counter_integer": true,
+	"counter_long":    true,
+	"counter_double":  true,
+}
+
+func isEsqlQuery(query *Query) bool {
+	return query.QueryType == esqlQueryType
+}
+
+// processEsqlQuery runs an ES|QL query, and returns its result as time series when it has a time
+// column and numeric columns, and as a table otherwise.
+func (e *elasticsearchDataQuery) processEsqlQuery(q *Query) backend.DataResponse {
+	if strings.TrimSpace(q.RawQuery) == "" {
+		return errorsource.Response(errorsource.DownstreamError(errors.New("received invalid query. ES|QL query is empty"), false))
+	}
+
+	query := interpolateEsqlMacros(q, e.client.GetConfiguredFields().TimeField)
+	res, err := e.client.ExecuteEsql(&es.EsqlRequest{Query: query})
+	if err != nil {
+		// We are returning error containing the source that was added through errorsource.Middleware
+		return errorsource.Response(err)
+	}
+
+	if res.Error != nil || res.Status/100 != 2 {
+		me, _ := json.Marshal(res.Error)
+		e.logger.Error("Processing error response from Elasticsearch", "error", string(me), "query", query, "statusCode", res.Status)
+		errResult := getErrorFromElasticResponse(&es.SearchResponse{Error: res.Error})
+		return errorsource.Response(errorsource.DownstreamError(errors.New(errResult), false))
+	}
+
+	start := time.Now()
+	frames := processEsqlResponse(res)
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.ExecutedQueryString = query
+	}
+	e.logger.Info("Finished processing of response", "duration", time.Since(start), "stage", es.StageParseResponse)
+
+	return backend.DataResponse{Frames: frames}
+}
+
+// interpolateEsqlMacros replaces the macros of an ES|QL query:
+//   - $__timeFilter and $__timeFilter(field) with the time range filter of the configured time
+//     field or of the given field
+//   - $__timeFrom and $__timeTo with the bounds of the time range
+//   - $__interval_ms and $__interval with the interval of the query, $__interval as a time span
+//     which can be used in BUCKET
+func interpolateEsqlMacros(q *Query, timeField string) string {
+	from := esqlDatetime(q.TimeRange.From)
+	to := esqlDatetime(q.TimeRange.To)
+
+	query := timeFilterMacroRegex.ReplaceAllStringFunc(q.RawQuery, func(macro string) string {
+		field := timeField
+		if args := timeFilterMacroRegex.FindStringSubmatch(macro); strings.TrimSpace(args[1]) != "" {
+			field = strings.TrimSpace(args[1])
+		}
+		field = esqlIdentifier(field)
+		return fmt.Sprintf("%s >= %s AND %s <= %s", field, from, field, to)
+	})
+
+	interval := q.Interval
+	if interval <= 0 {
+		interval = time.Duration(q.IntervalMs) * time.Millisecond
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	query = strings.ReplaceAll(query, "$__timeFrom", from)
+	query = strings.ReplaceAll(query, "$__timeTo", to)
+	query = strings.ReplaceAll(query, "$__interval_ms", strconv.FormatInt(interval.Milliseconds(), 10))
+	query = strings.ReplaceAll(query, "$__interval", esqlTimeSpan(interval))
+	return query
+}
+
+func esqlDatetime(t time.Time) string {
+	return fmt.Sprintf("TO_DATETIME(%q)", t.UTC().Format("2006-01-02T15:04:05.000Z"))
+}
+
+// esqlIdentifier quotes the field names which aren't valid unquoted ES|QL identifiers.
+func esqlIdentifier(field string) string {
+	if esqlIdentifierRegex.MatchString(field) || strings.HasPrefix(field, "`") {
+		return field
+	}
+	return "`" + strings.ReplaceAll(field, "`", "``") + "`"
+}
+
+// esqlTimeSpan returns a duration as an ES|QL time span, in the largest unit it is a multiple of.
+func esqlTimeSpan(d time.Duration) string {
+	ms := d.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+
+	units := []struct {
+		ms   int64
+		name string
+	}{
+		{ms: 24 * 60 * 60 * 1000, name: "day"},
+		{ms: 60 * 60 * 1000, name: "hour"},
+		{ms: 60 * 1000, name: "minute"},
+		{ms: 1000, name: "second"},
+		{ms: 1, name: "millisecond"},
+	}
+	for _, unit := range units {
+		if ms%unit.ms != 0 {
+			continue
+		}
+		if ms == unit.ms {
+			return "1 " + unit.name
+		}
+		return fmt.Sprintf("%d %ss", ms/unit.ms, unit.name)
+	}
+	return ""
+}
+
+func processEsqlResponse(res *es.EsqlResponse) data.Frames {
+	fields := make([]*data.Field, len(res.Columns))
+	for i, column := range res.Columns {
+		fields[i] = newEsqlField(column, res.Values, i)
+	}
+
+	if frames, ok := esqlTimeSeriesFrames(res.Columns, fields); ok {
+		return frames
+	}
+
+	frame := data.NewFrame("", fields...)
+	setPreferredVisType(frame, data.VisTypeTable)
+	return data.Frames{frame}
+}
+
+func newEsqlField(column es.EsqlColumn, rows [][]interface{}, index int) *data.Field {
+	value := func(row []interface{}) interface{} {
+		if index < len(row) {
+			return row[index]
+		}
+		return nil
+	}
+
+	switch {
+	case column.Type == "date" || column.Type == "date_nanos":
+		values := make([]*time.Time, len(rows))
+		for i, row := range rows {
+			values[i] = esqlTime(value(row))
+		}
+		return data.NewField(column.Name, nil, values)
+	case esqlNumericTypes[column.Type]:
+		values := make([]*float64, len(rows))
+		for i, row := range rows {
+			if number, ok := value(row).(float64); ok {
+				values[i] = &number
+			}
+		}
+		return data.NewField(column.Name, nil, values)
+	case column.Type == "boolean":
+		values := make([]*bool, len(rows))
+		for i, row := range rows {
+			if b, ok := value(row).(bool); ok {
+				values[i] = &b
+			}
+		}
+		return data.NewField(column.Name, nil, values)
+	default:
+		values := make([]*string, len(rows))
+		for i, row := range rows {
+			switch v := value(row).(type) {
+			case nil:
+			case string:
+				values[i] = &v
+			default:
+				// multi-valued fields and spatial values
+				if encoded, err := json.Marshal(v); err == nil {
+					str := string(encoded)
+					values[i] = &str
+				}
+			}
+		}
+		return data.NewField(column.Name, nil, values)
+	}
+}
+
+func esqlTime(value interface{}) *time.Time {
+	switch v := value.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil
+		}
+		t = t.UTC()
+		return &t
+	case float64:
+		t := time.UnixMilli(int64(v)).UTC()
+		return &t
+	}
+	return nil
+}
+
+// esqlTimeSeriesFrames returns a series for each numeric column and each combination of the
+// values of the string columns, which become the labels of the series. The result must have one
+// time column, and only numeric and string columns otherwise.
+func esqlTimeSeriesFrames(columns []es.EsqlColumn, fields []*data.Field) (data.Frames, bool) {
+	timeIndex := -1
+	var valueIndices, labelIndices []int
+	for i, field := range fields {
+		switch field.Type() {
+		case data.FieldTypeNullableTime:
+			if timeIndex != -1 {
+				return nil, false
+			}
+			timeIndex = i
+		case data.FieldTypeNullableFloat64:
+			valueIndices = append(valueIndices, i)
+		case data.FieldTypeNullableString:
+			labelIndices = append(labelIndices, i)
+		default:
+			return nil, false
+		}
+	}
+	if timeIndex == -1 || len(valueIndices) == 0 || fields[timeIndex].Len() == 0 {
+		return nil, false
+	}
+
+	timeField := fields[timeIndex]
+	rows := make([]int, 0, timeField.Len())
+	for i := 0; i < timeField.Len(); i++ {
+		if timeField.At(i).(*time.Time) != nil {
+			rows = append(rows, i)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return timeField.At(rows[i]).(*time.Time).Before(*timeField.At(rows[j]).(*time.Time))
+	})
+
+	type series struct {
+		labels data.Labels
+		times  []time.Time
+		values [][]*float64
+	}
+	var allSeries []*series
+	seriesByLabels := map[string]*series{}
+	for _, row := range rows {
+		var labels data.Labels
+		for _, i := range labelIndices {
+			if v := fields[i].At(row).(*string); v != nil {
+				if labels == nil {
+					labels = data.Labels{}
+				}
+				labels[columns[i].Name] = *v
+			}
+		}
+		s, ok := seriesByLabels[labels.String()]
+		if !ok {
+			s = &series{labels: labels, values: make([][]*float64, len(valueIndices))}
+			seriesByLabels[labels.String()] = s
+			allSeries = append(allSeries, s)
+		}
+		s.times = append(s.times, *timeField.At(row).(*time.Time))
+		for j, i := range valueIndices {
+			s.values[j] = append(s.values[j], fields[i].At(row).(*float64))
+		}
+	}
+
+	frames := data.Frames{}
+	for _, s := range allSeries {
+		for j, i := range valueIndices {
+			frame := newTimeSeriesFrame(s.times, s.labels, s.values[j])
+			frame.Fields[1].Name = columns[i].Name
+			frames = append(frames, frame)
+		}
+	}
+	return frames, true
+}
@@ -0,0 +1,149 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+func TestEsqlQuery(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC),
+		To:   time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC),
+	}
+
+	t.Run("interpolates the macros", func(t *testing.T) {
+		q := &Query{
+			RawQuery:  "FROM logs-* | WHERE $__timeFilter AND $__timeFilter(event.created) AND $__timeFilter(`event time`) | STATS c = COUNT(*) BY BUCKET(@timestamp, $__interval) | EVAL ms = $__interval_ms, from = $__timeFrom, to = $__timeTo",
+			TimeRange: timeRange,
+			Interval:  30 * time.Second,
+		}
+
+		require.Equal(t,
+			"FROM logs-* | WHERE @timestamp >= TO_DATETIME(\"2018-05-15T17:50:00.000Z\") AND @timestamp <= TO_DATETIME(\"2018-05-15T17:55:00.000Z\")"+
+				" AND event.created >= TO_DATETIME(\"2018-05-15T17:50:00.000Z\") AND event.created <= TO_DATETIME(\"2018-05-15T17:55:00.000Z\")"+
+				" AND `event time` >= TO_DATETIME(\"2018-05-15T17:50:00.000Z\") AND `event time` <= TO_DATETIME(\"2018-05-15T17:55:00.000Z\")"+
+				" | STATS c = COUNT(*) BY BUCKET(@timestamp, 30 seconds)"+
+				" | EVAL ms = 30000, from = TO_DATETIME(\"2018-05-15T17:50:00.000Z\"), to = TO_DATETIME(\"2018-05-15T17:55:00.000Z\")",
+			interpolateEsqlMacros(q, "@timestamp"))
+		require.Equal(t, "`time field`", esqlIdentifier("time field"))
+	})
+
+	t.Run("formats the interval as a time span", func(t *testing.T) {
+		require.Equal(t, "1500 milliseconds", esqlTimeSpan(1500*time.Millisecond))
+		require.Equal(t, "1 second", esqlTimeSpan(time.Second))
+		require.Equal(t, "2 minutes", esqlTimeSpan(2*time.Minute))
+		require.Equal(t, "90 minutes", esqlTimeSpan(90*time.Minute))
+		require.Equal(t, "1 day", esqlTimeSpan(24*time.Hour))
+	})
+
+	t.Run("sends the ES|QL queries separately from the other queries", func(t *testing.T) {
+		c := newFakeClient()
+		c.esqlResponse = &es.EsqlResponse{
+			Status:  200,
+			Columns: []es.EsqlColumn{{Name: "host", Type: "keyword"}, {Name: "c", Type: "long"}},
+			Values:  [][]interface{}{{"server-1", float64(3)}},
+		}
+		req := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: json.RawMessage(`{"queryType": "esql", "query": "FROM logs-* | STATS c = COUNT(*) BY host"}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: json.RawMessage(`{
+					"bucketAggs": [{ "type": "date_histogram", "field": "@timestamp", "id": "2" }],
+					"metrics": [{"type": "count", "id": "1" }]
+				}`)},
+			},
+		}
+
+		res, err := newElasticsearchDataQuery(context.Background(), c, req, log.New()).execute()
+		require.NoError(t, err)
+
+		require.Equal(t, []*es.EsqlRequest{{Query: "FROM logs-* | STATS c = COUNT(*) BY host"}}, c.esqlRequests)
+		require.Len(t, c.multisearchRequests, 1)
+		require.Len(t, c.multisearchRequests[0].Requests, 1)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, data.VisTypeTable, frames[0].Meta.PreferredVisualization)
+		require.Equal(t, "server-1", *frames[0].Fields[0].At(0).(*string))
+		require.Equal(t, float64(3), *frames[0].Fields[1].At(0).(*float64))
+	})
+
+	t.Run("keeps the ES|QL responses when the other responses can't be parsed", func(t *testing.T) {
+		c := newFakeClient()
+		c.esqlResponse = &es.EsqlResponse{
+			Status:  200,
+			Columns: []es.EsqlColumn{{Name: "host", Type: "keyword"}},
+			Values:  [][]interface{}{{"server-1"}},
+		}
+		c.multiSearchResponse = &es.MultiSearchResponse{
+			Responses: []*es.SearchResponse{{
+				Aggregations: map[string]interface{}{
+					"2": map[string]interface{}{
+						"buckets": []interface{}{map[string]interface{}{"key": "not a time", "doc_count": 1}},
+					},
+				},
+			}},
+		}
+		req := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: json.RawMessage(`{"queryType": "esql", "query": "FROM logs-* | KEEP host"}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: json.RawMessage(`{
+					"bucketAggs": [{ "type": "date_histogram", "field": "@timestamp", "id": "2" }],
+					"metrics": [{"type": "count", "id": "1" }]
+				}`)},
+			},
+		}
+
+		res, err := newElasticsearchDataQuery(context.Background(), c, req, log.New()).execute()
+		require.NoError(t, err)
+
+		require.NoError(t, res.Responses["A"].Error)
+		require.Len(t, res.Responses["A"].Frames, 1)
+		require.ErrorContains(t, res.Responses["B"].Error, "error processing count metric")
+		require.Equal(t, backend.ErrorSourcePlugin, res.Responses["B"].ErrorSource)
+	})
+
+	t.Run("returns the errors of Elasticsearch", func(t *testing.T) {
+		c := newFakeClient()
+		c.esqlResponse = &es.EsqlResponse{
+			Status: 400,
+			Error: map[string]interface{}{
+				"root_cause": []interface{}{map[string]interface{}{"type": "verification_exception", "reason": "Unknown column [hots]"}},
+				"type":       "verification_exception",
+				"reason":     "Found 1 problem",
+			},
+		}
+		res, err := executeElasticsearchDataQuery(c, `{"queryType": "esql", "query": "FROM logs-* | KEEP hots"}`, timeRange.From, timeRange.To)
+		require.NoError(t, err)
+		require.ErrorContains(t, res.Responses["A"].Error, "Unknown column [hots]")
+		require.Equal(t, backend.ErrorSourceDownstream, res.Responses["A"].ErrorSource)
+		require.Empty(t, c.multisearchRequests)
+	})
+
+	t.Run("returns an error for empty queries", func(t *testing.T) {
+		c := newFakeClient()
+		res, err := executeElasticsearchDataQuery(c, `{"queryType": "esql", "query": " "}`, timeRange.From, timeRange.To)
+		require.NoError(t, err)
+		require.Error(t, res.Responses["A"].Error)
+		require.Empty(t, c.esqlRequests)
+	})
+
+	t.Run("posts the query to _query", func(t *testing.T) {
+		queriesBytes, err := os.ReadFile(filepath.Join("testdata_response", "esql_timeseries.queries.json"))
+		require.NoError(t, err)
+
+		result, err := queryDataTest(queriesBytes, []byte(`{"columns": [], "values": []}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"query": "FROM testdb-* | WHERE testtime >= TO_DATETIME(\"2022-11-14T10:40:37.218Z\") AND testtime <= TO_DATETIME(\"2022-11-14T10:43:45.668Z\") | STATS avg_value = AVG(value), count = COUNT(*) BY time = BUCKET(testtime, 1 minute), host | SORT time"}`, string(result.requestBytes))
+	})
+}
@@ -18,6 +18,7 @@ func parseQuery(tsdbQuery []backend.DataQuery, logger log.Logger) ([]*Query, err
 		// we had a string-field named `timeField` in the past. we do not use it anymore.
 		// please do not create a new field with that name, to avoid potential problems with old, persisted queries.
 
+		queryType := model.Get("queryType").MustString(q.QueryType)
 		rawQuery := model.Get("query").MustString()
 		bucketAggs, err := parseBucketAggs(model)
 		if err != nil {
@@ -34,6 +35,7 @@ func parseQuery(tsdbQuery []backend.DataQuery, logger log.Logger) ([]*Query, err
 		interval := q.Interval
 
 		queries = append(queries, &Query{
+			QueryType:     queryType,
 			RawQuery:      rawQuery,
 			BucketAggs:    bucketAggs,
 			Metrics:       metrics,
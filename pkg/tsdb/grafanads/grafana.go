@@ -13,8 +13,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/recordedseries"
 	"github.com/grafana/grafana/pkg/services/searchV2"
 	"github.com/grafana/grafana/pkg/services/store"
 	testdatasource "github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource"
@@ -51,15 +53,20 @@ var (
 	)
 )
 
-func ProvideService(search searchV2.SearchService, store store.StorageService) *Service {
-	return newService(search, store)
+func ProvideService(search searchV2.SearchService, store store.StorageService, sqlStore db.DB) *Service {
+	var recorded *recordedseries.Store
+	if sqlStore != nil {
+		recorded = recordedseries.NewStore(sqlStore)
+	}
+	return newService(search, store, recorded)
 }
 
-func newService(search searchV2.SearchService, store store.StorageService) *Service {
+func newService(search searchV2.SearchService, store store.StorageService, recorded *recordedseries.Store) *Service {
 	s := &Service{
-		search: search,
-		store:  store,
-		log:    log.New("grafanads"),
+		search:   search,
+		store:    store,
+		recorded: recorded,
+		log:      log.New("grafanads"),
 	}
 
 	return s
@@ -67,9 +74,10 @@ func newService(search searchV2.SearchService, store store.StorageService) *Serv
 
 // Service exists regardless of user settings
 type Service struct {
-	search searchV2.SearchService
-	store  store.StorageService
-	log    log.Logger
+	search   searchV2.SearchService
+	store    store.StorageService
+	recorded *recordedseries.Store
+	log      log.Logger
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -97,6 +105,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeRecordedSeries:
+			response.Responses[q.RefID] = s.doRecordedSeriesQuery(ctx, req, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -163,6 +173,39 @@ func (s *Service) doReadQuery(ctx context.Context, query backend.DataQuery) back
 	return response
 }
 
+func (s *Service) doRecordedSeriesQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	if s.recorded == nil {
+		return backend.DataResponse{
+			Error: fmt.Errorf("recorded series are not available"),
+		}
+	}
+
+	q := &recordedSeriesQueryModel{}
+	err := json.Unmarshal(query.JSON, &q)
+	if err != nil {
+		return backend.DataResponse{
+			Error: err,
+		}
+	}
+	if q.Metric == "" {
+		return backend.DataResponse{
+			Error: fmt.Errorf("metric is required"),
+		}
+	}
+
+	frames, err := s.recorded.Query(ctx, recordedseries.Query{
+		OrgID:    req.PluginContext.OrgID,
+		Metric:   q.Metric,
+		Matchers: q.Matchers,
+		From:     query.TimeRange.From,
+		To:       query.TimeRange.To,
+	})
+	return backend.DataResponse{
+		Frames: frames,
+		Error:  err,
+	}
+}
+
 func (s *Service) doRandomWalk(query backend.DataQuery) backend.DataResponse {
 	response := backend.DataResponse{}
 
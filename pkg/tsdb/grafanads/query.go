@@ -14,6 +14,10 @@ const (
 	// currently only .csv files are supported,
 	// other file types will eventually be supported (parquet, etc)
 	queryTypeRead = "read"
+
+	// queryTypeRecordedSeries returns the series written by recording rules
+	// to the local store of Grafana
+	queryTypeRecordedSeries = "recordedSeries"
 )
 
 type listQueryModel struct {
@@ -22,3 +26,8 @@ type listQueryModel struct {
 type readQueryModel struct {
 	Path string `json:"path"`
 }
+
+type recordedSeriesQueryModel struct {
+	Metric   string            `json:"metric"`
+	Matchers map[string]string `json:"matchers,omitempty"`
+}
@@ -0,0 +1,154 @@
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// eventsQueryType is the query type of the annotation queries returning the Graphite events with
+// the given tags. The annotations with a target are rendered like the other queries.
+const eventsQueryType = "tags"
+
+type annotationQueryModel struct {
+	Target string   `json:"target"`
+	Tags   []string `json:"tags"`
+}
+
+// isEventsQuery returns true for the annotation queries reading the Graphite events.
+func isEventsQuery(query backend.DataQuery) bool {
+	if query.QueryType != eventsQueryType {
+		return false
+	}
+	var model annotationQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		// the error is returned by runAnnotationQuery
+		return true
+	}
+	return model.Target == ""
+}
+
+// GraphiteEvent is an event returned by /events/get_data.
+type GraphiteEvent struct {
+	When float64 `json:"when"`
+	What string  `json:"what"`
+	Data string  `json:"data"`
+	// Graphite returns the tags as a list, older versions return them as a string
+	Tags json.RawMessage `json:"tags"`
+}
+
+// runAnnotationQuery returns the events of the time range of the query with all its tags.
+func (s *Service) runAnnotationQuery(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, query backend.DataQuery) backend.DataResponse {
+	var model annotationQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse the annotation query: %v", err))
+	}
+
+	from, until := epochMStoGraphiteTime(query.TimeRange)
+	params := url.Values{
+		"from":  []string{from},
+		"until": []string{until},
+	}
+	if len(model.Tags) > 0 {
+		params.Set("tags", strings.Join(model.Tags, " "))
+	}
+
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("invalid data source URL: %v", err))
+	}
+	u.Path = path.Join(u.Path, "events/get_data")
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to create request: %v", err))
+	}
+
+	ctx, span := s.tracer.Start(ctx, "graphite annotation query")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("tags", params.Get("tags")),
+		attribute.String("from", from),
+		attribute.String("until", until),
+		attribute.Int64("datasource_id", dsInfo.Id),
+	)
+	s.tracer.Inject(ctx, req.Header, span)
+
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return downstreamErrorResponse(err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return downstreamErrorResponse(err)
+	}
+	if res.StatusCode/100 != 2 {
+		logger.Info("Events request failed", "status", res.Status, "body", string(body))
+		return downstreamErrorResponse(fmt.Errorf("request failed, status: %s", res.Status))
+	}
+
+	var events []GraphiteEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		logger.Info("Failed to unmarshal graphite events", "error", err, "body", string(body))
+		return downstreamErrorResponse(err)
+	}
+
+	return backend.DataResponse{Frames: data.Frames{eventsToFrame(query.RefID, events)}}
+}
+
+func downstreamErrorResponse(err error) backend.DataResponse {
+	return backend.DataResponse{Error: err, ErrorSource: backend.ErrorSourceDownstream}
+}
+
+func eventsToFrame(refID string, events []GraphiteEvent) *data.Frame {
+	frame := data.NewFrame(refID,
+		data.NewField("time", nil, []time.Time{}),
+		data.NewField("title", nil, []string{}),
+		data.NewField("tags", nil, []string{}),
+		data.NewField("text", nil, []string{}),
+	)
+	for _, event := range events {
+		frame.AppendRow(time.UnixMilli(int64(event.When*1000)).UTC(), event.What, strings.Join(parseEventTags(event.Tags), ","), event.Data)
+	}
+	return frame
+}
+
+// parseEventTags returns the tags of an event, the tags returned as a string are separated by
+// commas or spaces.
+func parseEventTags(raw json.RawMessage) []string {
+	var tags []string
+	if err := json.Unmarshal(raw, &tags); err == nil {
+		return tags
+	}
+
+	var tagString string
+	if err := json.Unmarshal(raw, &tagString); err != nil {
+		return nil
+	}
+	if strings.Contains(tagString, ",") {
+		return strings.Split(tagString, ",")
+	}
+	return strings.Fields(tagString)
+}
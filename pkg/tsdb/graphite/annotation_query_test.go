@@ -0,0 +1,55 @@
+package graphite
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotationQuery(t *testing.T) {
+	s, requests := newTestService(t, func(rw http.ResponseWriter, _ *http.Request) {
+		_, _ = rw.Write([]byte(`[
+			{"when": 1700000000, "what": "deploy", "tags": ["deploy", "api"], "data": "v1.2.3"},
+			{"when": 1700000060.5, "what": "restart", "tags": "restart,api", "data": ""}
+		]`))
+	})
+
+	from := time.Unix(1699999000, 0)
+	to := time.Unix(1700001000, 0)
+	res, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			RefID:     "Anno",
+			QueryType: eventsQueryType,
+			TimeRange: backend.TimeRange{From: from, To: to},
+			JSON:      []byte(`{"queryType":"tags","tags":["deploy","api"],"fromAnnotations":true}`),
+		}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 1)
+	require.Equal(t, "/graphite/events/get_data", (*requests)[0].URL.Path)
+	require.Equal(t, url.Values{"from": {"1699999000"}, "until": {"1700001000"}, "tags": {"deploy api"}}, (*requests)[0].URL.Query())
+
+	resp := res.Responses["Anno"]
+	require.NoError(t, resp.Error)
+	require.Len(t, resp.Frames, 1)
+	frame := resp.Frames[0]
+	require.Equal(t, 2, frame.Rows())
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), frame.Fields[0].At(0))
+	require.Equal(t, time.UnixMilli(1700000060500).UTC(), frame.Fields[0].At(1))
+	require.Equal(t, "deploy", frame.Fields[1].At(0))
+	require.Equal(t, "deploy,api", frame.Fields[2].At(0))
+	require.Equal(t, "restart,api", frame.Fields[2].At(1))
+	require.Equal(t, "v1.2.3", frame.Fields[3].At(0))
+}
+
+func TestIsEventsQuery(t *testing.T) {
+	require.True(t, isEventsQuery(backend.DataQuery{QueryType: eventsQueryType, JSON: []byte(`{"tags":["deploy"]}`)}))
+	require.False(t, isEventsQuery(backend.DataQuery{QueryType: eventsQueryType, JSON: []byte(`{"target":"events.deploys","tags":["deploy"]}`)}))
+	require.False(t, isEventsQuery(backend.DataQuery{JSON: []byte(`{"target":"a.b"}`)}))
+}
@@ -0,0 +1,152 @@
+package graphite
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// tagWritePaths are the Graphite tag API endpoints modifying the tag database, they can't be
+// used as tag names.
+var tagWritePaths = []string{"tagSeries", "tagMultiSeries", "delSeries"}
+
+// newResourceMux returns the resources used by the query editor and the template variables. They
+// forward the read-only requests of the Graphite API with the parameters Graphite accepts on
+// them, so that the frontend doesn't need the data proxy.
+func (s *Service) newResourceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics/find", s.handleResourceReq("query", "from", "until"))
+	mux.HandleFunc("/metrics/expand", s.handleResourceReq("query", "from", "until", "groupByExpr", "leavesOnly"))
+	mux.HandleFunc("/tags", s.handleResourceReq("filter", "from", "until"))
+	mux.HandleFunc("/tags/autoComplete/tags", s.handleResourceReq("expr", "tagPrefix", "limit", "from", "until"))
+	mux.HandleFunc("/tags/autoComplete/values", s.handleResourceReq("expr", "tag", "valuePrefix", "limit", "from", "until"))
+	mux.HandleFunc("/tags/", s.handleTagValues)
+	mux.HandleFunc("/functions", s.handleResourceReq())
+	mux.HandleFunc("/version", s.handleResourceReq())
+	mux.HandleFunc("/events/get_data", s.handleResourceReq("tags", "from", "until"))
+	return mux
+}
+
+func (s *Service) handleResourceReq(params ...string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodPost {
+			writeResponse(rw, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", req.Method))
+			return
+		}
+		if err := req.ParseForm(); err != nil {
+			writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("failed to parse the request: %v", err))
+			return
+		}
+
+		values := url.Values{}
+		for _, param := range params {
+			if value, ok := req.Form[param]; ok {
+				values[param] = value
+			}
+		}
+		s.forwardResourceReq(rw, req, strings.TrimPrefix(req.URL.Path, "/"), values)
+	}
+}
+
+// handleTagValues returns the values of the tag named by the last segment of the path.
+func (s *Service) handleTagValues(rw http.ResponseWriter, req *http.Request) {
+	tag := strings.TrimPrefix(req.URL.Path, "/tags/")
+	if tag == "" || strings.Contains(tag, "/") || slices.Contains(tagWritePaths, tag) {
+		writeResponse(rw, http.StatusNotFound, "not found")
+		return
+	}
+	if req.Method != http.MethodGet {
+		writeResponse(rw, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", req.Method))
+		return
+	}
+
+	values := url.Values{}
+	query := req.URL.Query()
+	for _, param := range []string{"filter", "from", "until"} {
+		if value, ok := query[param]; ok {
+			values[param] = value
+		}
+	}
+	s.forwardResourceReq(rw, req, "tags/"+tag, values)
+}
+
+// forwardResourceReq sends a request to the Graphite API with the HTTP client of the data source,
+// and writes its response.
+func (s *Service) forwardResourceReq(rw http.ResponseWriter, req *http.Request, graphitePath string, values url.Values) {
+	ctx := req.Context()
+	logger := logger.FromContext(ctx)
+
+	dsInfo, err := s.getDSInfo(ctx, backend.PluginConfigFromContext(ctx))
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to get the data source: %v", err))
+		return
+	}
+
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("invalid data source URL: %v", err))
+		return
+	}
+	u.Path = path.Join(u.Path, graphitePath)
+
+	var graphiteReq *http.Request
+	if req.Method == http.MethodPost {
+		graphiteReq, err = http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(values.Encode()))
+		if err == nil {
+			graphiteReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		u.RawQuery = values.Encode()
+		graphiteReq, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	}
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to create request: %v", err))
+		return
+	}
+
+	ctx, span := s.tracer.Start(ctx, "graphite resource")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("path", graphitePath),
+		attribute.Int64("datasource_id", dsInfo.Id),
+	)
+	s.tracer.Inject(ctx, graphiteReq.Header, span)
+
+	res, err := dsInfo.HTTPClient.Do(graphiteReq)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.Warn("Graphite resource request failed", "path", graphitePath, "error", err)
+		writeResponse(rw, http.StatusBadGateway, fmt.Sprintf("request to Graphite failed: %v", err))
+		return
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	span.SetAttributes(attribute.Int("graphite.response.code", res.StatusCode))
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		rw.Header().Set("Content-Type", contentType)
+	}
+	rw.WriteHeader(res.StatusCode)
+	if _, err := io.Copy(rw, res.Body); err != nil {
+		logger.Warn("Failed to write the Graphite response", "path", graphitePath, "error", err)
+	}
+}
+
+func writeResponse(rw http.ResponseWriter, code int, msg string) {
+	rw.WriteHeader(code)
+	if _, err := rw.Write([]byte(msg)); err != nil {
+		logger.Error("Unable to write HTTP response", "error", err)
+	}
+}
@@ -0,0 +1,122 @@
+package graphite
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+type graphiteInstanceManager struct {
+	info datasourceInfo
+}
+
+func (m graphiteInstanceManager) Get(_ context.Context, _ backend.PluginContext) (instancemgmt.Instance, error) {
+	return m.info, nil
+}
+
+func (m graphiteInstanceManager) Do(_ context.Context, _ backend.PluginContext, _ instancemgmt.InstanceCallbackFunc) error {
+	return nil
+}
+
+// newTestService returns a service sending its requests to a fake Graphite, which records them.
+func newTestService(t *testing.T, handler http.HandlerFunc) (*Service, *[]*http.Request) {
+	t.Helper()
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_ = req.ParseForm()
+		requests = append(requests, req)
+		handler(rw, req)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := &Service{
+		im:     graphiteInstanceManager{info: datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL + "/graphite"}},
+		tracer: tracing.InitializeTracerForTest(),
+	}
+	s.resourceHandler = httpadapter.New(s.newResourceMux())
+	return s, &requests
+}
+
+type fakeSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestCallResource(t *testing.T) {
+	call := func(t *testing.T, s *Service, method string, resourceURL string, body string) *backend.CallResourceResponse {
+		t.Helper()
+		u, err := url.Parse(resourceURL)
+		require.NoError(t, err)
+		req := &backend.CallResourceRequest{Method: method, Path: u.Path, URL: resourceURL, Body: []byte(body)}
+		if body != "" {
+			req.Headers = map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}}
+		}
+		sender := &fakeSender{}
+		require.NoError(t, s.CallResource(context.Background(), req, sender))
+		return sender.resp
+	}
+	respondJSON := func(body string) http.HandlerFunc {
+		return func(rw http.ResponseWriter, _ *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+			_, _ = rw.Write([]byte(body))
+		}
+	}
+
+	t.Run("forwards metric find requests with their parameters", func(t *testing.T) {
+		s, requests := newTestService(t, respondJSON(`[{"text":"servers","expandable":1}]`))
+		resp := call(t, s, http.MethodPost, "metrics/find?from=1000&until=2000&other=1", "query=prod.*")
+
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `[{"text":"servers","expandable":1}]`, string(resp.Body))
+		require.Len(t, *requests, 1)
+		req := (*requests)[0]
+		require.Equal(t, http.MethodPost, req.Method)
+		require.Equal(t, "/graphite/metrics/find", req.URL.Path)
+		require.Equal(t, url.Values{"query": {"prod.*"}, "from": {"1000"}, "until": {"2000"}}, req.PostForm)
+	})
+
+	t.Run("forwards tag requests", func(t *testing.T) {
+		s, requests := newTestService(t, respondJSON(`["dc","host"]`))
+		resp := call(t, s, http.MethodGet, "tags/autoComplete/tags?expr=name%3Dcpu&expr=dc%3Deu&tagPrefix=h&limit=10", "")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.Equal(t, "/graphite/tags/autoComplete/tags", (*requests)[0].URL.Path)
+		require.Equal(t, []string{"name=cpu", "dc=eu"}, (*requests)[0].URL.Query()["expr"])
+
+		resp = call(t, s, http.MethodGet, "tags/dc?from=1000", "")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.Equal(t, "/graphite/tags/dc", (*requests)[1].URL.Path)
+	})
+
+	t.Run("doesn't forward other requests", func(t *testing.T) {
+		s, requests := newTestService(t, respondJSON(`{}`))
+		require.Equal(t, http.StatusNotFound, call(t, s, http.MethodPost, "tags/delSeries", "path=a.b").Status)
+		require.Equal(t, http.StatusNotFound, call(t, s, http.MethodPost, "tags/tagSeries", "path=a.b;dc=eu").Status)
+		require.Equal(t, http.StatusMethodNotAllowed, call(t, s, http.MethodPost, "tags/dc", "").Status)
+		require.Equal(t, http.StatusMethodNotAllowed, call(t, s, http.MethodDelete, "metrics/find", "").Status)
+		require.Equal(t, http.StatusNotFound, call(t, s, http.MethodGet, "render?target=a.b", "").Status)
+		require.Empty(t, *requests)
+	})
+
+	t.Run("returns the errors of Graphite", func(t *testing.T) {
+		s, _ := newTestService(t, func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, _ = rw.Write([]byte("invalid query"))
+		})
+		resp := call(t, s, http.MethodGet, "metrics/expand?query=a.*", "")
+		require.Equal(t, http.StatusBadRequest, resp.Status)
+		require.Equal(t, "invalid query", string(resp.Body))
+	})
+}
@@ -328,6 +328,30 @@ func (api *LokiAPI) RawQuery(ctx context.Context, resourcePath string) (RawLokiR
 	return rawLokiResponse, nil
 }
 
+// LabelValues returns the values of a label in the streams matching the stream selector, between start and end.
+func (api *LokiAPI) LabelValues(ctx context.Context, label string, selector string, start time.Time, end time.Time) ([]string, error) {
+	qs := url.Values{}
+	qs.Set("query", selector)
+	qs.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	qs.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+
+	res, err := api.RawQuery(ctx, "/loki/api/v1/label/"+url.PathEscape(label)+"/values?"+qs.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if res.Status/100 != 2 {
+		return nil, makeLokiError(res.Body)
+	}
+
+	var values struct {
+		Data []string `json:"data"`
+	}
+	if err := json.Unmarshal(res.Body, &values); err != nil {
+		return nil, err
+	}
+	return values.Data, nil
+}
+
 func getSupportingQueryHeaderValue(supportingQueryType SupportingQueryType) string {
 	value := ""
 
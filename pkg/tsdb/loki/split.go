@@ -0,0 +1,445 @@
+package loki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// defaultSplitDuration is the duration of the chunks of split queries, the same as in the frontend.
+	defaultSplitDuration = 24 * time.Hour
+	// defaultSplitConcurrency is the number of chunks run in parallel when the concurrent query
+	// count of Grafana is not configured.
+	defaultSplitConcurrency = 4
+	// streamShardLabel is the label Loki adds to the streams it shards.
+	streamShardLabel = "__stream_shard__"
+)
+
+// splitQueryConcurrency returns the number of requests split queries run in parallel.
+func splitQueryConcurrency(req *backend.QueryDataRequest, plog log.Logger) int {
+	if req.PluginContext.GrafanaConfig == nil {
+		return defaultSplitConcurrency
+	}
+	count, err := req.PluginContext.GrafanaConfig.ConcurrentQueryCount()
+	if err != nil || count <= 0 {
+		plog.Debug("Failed to read the concurrent query count, using the default", "error", err, "default", defaultSplitConcurrency)
+		return defaultSplitConcurrency
+	}
+	return count
+}
+
+// timeChunk is a part of the time range of a split query.
+type timeChunk struct {
+	start time.Time
+	end   time.Time
+}
+
+// isLogsQuery returns true for the queries returning log lines, they start with a stream selector.
+func isLogsQuery(expr string) bool {
+	return strings.HasPrefix(strings.TrimSpace(expr), "{")
+}
+
+// splitTimeRange splits the time range of a query into chunks of at most duration, from the
+// oldest to the newest chunk.
+//
+// Loki includes the start and excludes the end of the range of log queries, so the chunks of
+// log queries share their boundaries. The chunks of metric queries are aligned to the step and
+// both of their ends are evaluated, like the chunks of the frontend and of the Loki query
+// frontend. The range variables of the query were already interpolated with the whole range.
+func splitTimeRange(query *lokiQuery, duration time.Duration) []timeChunk {
+	if isLogsQuery(query.Expr) {
+		if query.End.Sub(query.Start) <= duration {
+			return []timeChunk{{start: query.Start, end: query.End}}
+		}
+		// we walk backward, so that the potentially smaller chunk is the oldest one
+		var chunks []timeChunk
+		for end := query.End; end.After(query.Start); end = end.Add(-duration) {
+			start := end.Add(-duration)
+			if start.Before(query.Start) {
+				start = query.Start
+			}
+			chunks = append(chunks, timeChunk{start: start, end: end})
+		}
+		slices.Reverse(chunks)
+		return chunks
+	}
+
+	step := query.Step
+	if step <= 0 || duration < step || query.End.Sub(query.Start) <= duration {
+		return []timeChunk{{start: query.Start, end: query.End}}
+	}
+	aligned := duration / step * step
+	start := time.Unix(0, query.Start.UnixNano()-query.Start.UnixNano()%int64(step))
+	var chunks []timeChunk
+	for chunkStart := start; !chunkStart.After(query.End); chunkStart = chunkStart.Add(aligned) {
+		chunkEnd := chunkStart.Add(aligned - step)
+		if chunkEnd.After(query.End) {
+			chunkEnd = query.End
+		}
+		chunks = append(chunks, timeChunk{start: chunkStart, end: chunkEnd})
+	}
+	return chunks
+}
+
+// streamSelectorEnd returns the index of the closing brace of the stream selector a log query
+// starts with, or -1 when it can't be found.
+func streamSelectorEnd(expr string) int {
+	start := strings.Index(expr, "{")
+	if start < 0 {
+		return -1
+	}
+	var quote byte
+	for i := start + 1; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// withStreamSelectorMatcher adds a label matcher to the stream selector of a log query.
+func withStreamSelectorMatcher(expr string, matcher string) string {
+	start := strings.Index(expr, "{")
+	end := streamSelectorEnd(expr)
+	if strings.TrimSpace(expr[start+1:end]) == "" {
+		return expr[:start+1] + matcher + expr[end:]
+	}
+	return expr[:start+1] + matcher + ", " + expr[start+1:]
+}
+
+// streamShardMatchers returns the label matchers sharding a log query into at most groups
+// queries, and one more query for the streams Loki didn't shard. It returns nil when the
+// streams of the query are not sharded.
+func streamShardMatchers(ctx context.Context, api *LokiAPI, query *lokiQuery, groups int, plog log.Logger) []string {
+	end := streamSelectorEnd(query.Expr)
+	if end < 0 {
+		return nil
+	}
+	selector := strings.TrimSpace(query.Expr[:end+1])
+	shards, err := api.LabelValues(ctx, streamShardLabel, selector, query.Start, query.End)
+	if err != nil {
+		plog.Warn("Failed to get the stream shards of the query, it is not sharded", "error", err)
+		return nil
+	}
+	shards = slices.DeleteFunc(shards, func(shard string) bool { return shard == "" })
+	if len(shards) == 0 {
+		return nil
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		a, errA := strconv.Atoi(shards[i])
+		b, errB := strconv.Atoi(shards[j])
+		if errA != nil || errB != nil {
+			return shards[i] < shards[j]
+		}
+		return a < b
+	})
+	groups = min(groups, len(shards))
+	matchers := make([]string, 0, groups+1)
+	for i := 0; i < groups; i++ {
+		group := shards[i*len(shards)/groups : (i+1)*len(shards)/groups]
+		values := make([]string, 0, len(group))
+		for _, shard := range group {
+			values = append(values, regexp.QuoteMeta(shard))
+		}
+		matchers = append(matchers, fmt.Sprintf("%s=~%q", streamShardLabel, strings.Join(values, "|")))
+	}
+	return append(matchers, fmt.Sprintf("%s=%q", streamShardLabel, ""))
+}
+
+// runSplitQuery runs a range query in time chunks, and log queries also in stream shards, with
+// at most concurrency requests at a time. The frames of the chunks are merged in order.
+//
+// Log queries with a line limit run the chunks in the direction of the query, in batches, until
+// the limit is reached. When a chunk fails, the frames of the other chunks are returned with the
+// error.
+func runSplitQuery(ctx context.Context, api *LokiAPI, query *lokiQuery, responseOpts ResponseOpts, concurrent int, plog log.Logger) (*backend.DataResponse, error) {
+	duration := query.SplitDuration
+	if duration <= 0 {
+		duration = defaultSplitDuration
+	}
+	logs := isLogsQuery(query.Expr)
+	chunks := splitTimeRange(query, duration)
+	var shardMatchers []string
+	if logs {
+		shardMatchers = streamShardMatchers(ctx, api, query, concurrent, plog)
+	}
+	if len(chunks) == 1 && len(shardMatchers) == 0 {
+		return runQuery(ctx, api, query, responseOpts, plog)
+	}
+
+	// backward log queries return the newest lines first
+	if logs && query.Direction != DirectionForward {
+		slices.Reverse(chunks)
+	}
+	queriesPerChunk := max(1, len(shardMatchers))
+	chunksPerBatch := len(chunks)
+	if logs && query.MaxLines > 0 {
+		chunksPerBatch = max(1, concurrent/queriesPerChunk)
+	}
+	plog.Debug("Running split query", "chunks", len(chunks), "shards", len(shardMatchers), "chunksPerBatch", chunksPerBatch)
+
+	var (
+		frames      data.Frames
+		errs        []error
+		errorSource backend.ErrorSource
+		queried     int
+		lines       int
+	)
+	for first := 0; first < len(chunks) && len(errs) == 0; first += chunksPerBatch {
+		var queries []lokiQuery
+		for _, chunk := range chunks[first:min(first+chunksPerBatch, len(chunks))] {
+			chunkQuery := *query
+			chunkQuery.Start = chunk.start
+			chunkQuery.End = chunk.end
+			if logs && query.MaxLines > 0 {
+				chunkQuery.MaxLines = query.MaxLines - lines
+			}
+			if len(shardMatchers) == 0 {
+				queries = append(queries, chunkQuery)
+				continue
+			}
+			for _, matcher := range shardMatchers {
+				shardQuery := chunkQuery
+				shardQuery.Expr = withStreamSelectorMatcher(chunkQuery.Expr, matcher)
+				queries = append(queries, shardQuery)
+			}
+		}
+
+		queried += len(queries)
+		results := make([]*backend.DataResponse, len(queries))
+		_ = concurrency.ForEachJob(ctx, len(queries), concurrent, func(ctx context.Context, idx int) error {
+			res, err := api.DataQuery(ctx, queries[idx], responseOpts)
+			if err != nil {
+				res = &backend.DataResponse{Error: err}
+			}
+			results[idx] = res
+			return nil // errors are saved per-chunk, always return nil
+		})
+
+		for _, res := range results {
+			if res == nil {
+				res = &backend.DataResponse{Error: ctx.Err()}
+			}
+			if res.Error != nil {
+				errs = append(errs, res.Error)
+				errorSource = res.ErrorSource
+				continue
+			}
+			frames = append(frames, res.Frames...)
+		}
+
+		if logs && query.MaxLines > 0 {
+			lines = countLogLines(frames)
+			if lines >= query.MaxLines {
+				break
+			}
+		}
+	}
+
+	res := &backend.DataResponse{Frames: mergeSplitFrames(frames, query, logs)}
+	for _, frame := range res.Frames {
+		if err := adjustFrame(frame, query, !responseOpts.metricDataplane, responseOpts.logsDataplane); err != nil {
+			plog.Error("Error adjusting frame", "error", err)
+			return res, err
+		}
+	}
+
+	if len(errs) > 0 {
+		res.Error = fmt.Errorf("%d of %d split queries failed, the results are partial: %w", len(errs), queried, errors.Join(errs...))
+		res.ErrorSource = errorSource
+		if len(res.Frames) > 0 {
+			if res.Frames[0].Meta == nil {
+				res.Frames[0].Meta = &data.FrameMeta{}
+			}
+			res.Frames[0].AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     "Some parts of the query failed, the results are partial.",
+			})
+		}
+	}
+	return res, nil
+}
+
+// mergeSplitFrames merges the frames of the same series of split queries in the order of the
+// frames, and sums their stats. The log lines are sorted in the direction of the query and
+// limited to its line limit. The samples before the start of a metric query, returned by its
+// first chunk aligned to the step, are removed.
+func mergeSplitFrames(frames data.Frames, query *lokiQuery, logs bool) data.Frames {
+	var merged data.Frames
+	var stats map[string]any
+	series := map[string]*data.Frame{}
+	for _, frame := range frames {
+		if frame.Meta != nil {
+			if custom, ok := frame.Meta.Custom.(map[string]any); ok {
+				if frameStats, ok := custom["stats"].(map[string]any); ok {
+					stats = sumStats(stats, frameStats)
+					delete(custom, "stats")
+				}
+			}
+		}
+
+		key := frameSeriesKey(frame)
+		existing, ok := series[key]
+		if !ok {
+			series[key] = frame
+			merged = append(merged, frame)
+			continue
+		}
+		for row := 0; row < frame.Rows(); row++ {
+			for i, field := range frame.Fields {
+				existing.Fields[i].Append(field.CopyAt(row))
+			}
+		}
+	}
+
+	if logs {
+		for i, frame := range merged {
+			if len(frame.Fields) >= 4 && frame.Fields[3].Type() == data.FieldTypeString {
+				merged[i] = sortLogLines(frame, query.Direction == DirectionForward, query.MaxLines)
+			}
+		}
+	} else {
+		for i, frame := range merged {
+			merged[i] = trimFrameBefore(frame, query.Start)
+		}
+		// the series of the first chunk may only have samples before the start
+		merged = slices.DeleteFunc(merged, func(frame *data.Frame) bool {
+			return len(frame.Fields) > 0 && frame.Rows() == 0
+		})
+	}
+
+	if stats != nil && len(merged) > 0 {
+		if merged[0].Meta == nil {
+			merged[0].Meta = &data.FrameMeta{}
+		}
+		merged[0].Meta.Custom = map[string]any{"stats": stats}
+	}
+	return merged
+}
+
+// trimFrameBefore returns the frame without the rows before start, or the frame itself when it
+// has no such rows.
+func trimFrameBefore(frame *data.Frame, start time.Time) *data.Frame {
+	timeIdx := slices.IndexFunc(frame.Fields, func(field *data.Field) bool {
+		return field.Type() == data.FieldTypeTime
+	})
+	if timeIdx < 0 {
+		return frame
+	}
+	trimmed := frame.EmptyCopy()
+	for row := 0; row < frame.Rows(); row++ {
+		if t, ok := frame.Fields[timeIdx].ConcreteAt(row); ok && t.(time.Time).Before(start) {
+			continue
+		}
+		for i, field := range frame.Fields {
+			trimmed.Fields[i].Append(field.CopyAt(row))
+		}
+	}
+	if trimmed.Rows() == frame.Rows() {
+		return frame
+	}
+	return trimmed
+}
+
+// frameSeriesKey identifies the series of a frame, the frames of a series have the same fields.
+func frameSeriesKey(frame *data.Frame) string {
+	var b strings.Builder
+	b.WriteString(frame.Name)
+	for _, field := range frame.Fields {
+		b.WriteString("\x00" + field.Name + "\x00" + field.Type().String() + "\x00" + field.Labels.String())
+	}
+	return b.String()
+}
+
+// sortLogLines sorts the lines of a logs frame by their nanosecond timestamp, and keeps at most
+// maxLines of them when it's positive.
+func sortLogLines(frame *data.Frame, ascending bool, maxLines int) *data.Frame {
+	tsField := frame.Fields[3]
+	timestamps := make([]int64, tsField.Len())
+	for i := range timestamps {
+		timestamps[i], _ = strconv.ParseInt(tsField.At(i).(string), 10, 64)
+	}
+	rows := make([]int, len(timestamps))
+	for i := range rows {
+		rows[i] = i
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if ascending {
+			return timestamps[rows[i]] < timestamps[rows[j]]
+		}
+		return timestamps[rows[i]] > timestamps[rows[j]]
+	})
+	if maxLines > 0 && len(rows) > maxLines {
+		rows = rows[:maxLines]
+	}
+
+	sorted := data.NewFrame(frame.Name)
+	sorted.RefID = frame.RefID
+	sorted.Meta = frame.Meta
+	for _, field := range frame.Fields {
+		sortedField := data.NewFieldFromFieldType(field.Type(), 0)
+		sortedField.Name = field.Name
+		sortedField.Labels = field.Labels
+		sortedField.Config = field.Config
+		for _, row := range rows {
+			sortedField.Append(field.CopyAt(row))
+		}
+		sorted.Fields = append(sorted.Fields, sortedField)
+	}
+	return sorted
+}
+
+func countLogLines(frames data.Frames) int {
+	lines := 0
+	for _, frame := range frames {
+		if len(frame.Fields) >= 4 && frame.Fields[3].Type() == data.FieldTypeString {
+			lines += frame.Rows()
+		}
+	}
+	return lines
+}
+
+// sumStats adds the numbers of the Loki stats of src to dst.
+func sumStats(dst map[string]any, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = map[string]any{}
+	}
+	for key, value := range src {
+		switch v := value.(type) {
+		case map[string]any:
+			existing, _ := dst[key].(map[string]any)
+			dst[key] = sumStats(existing, v)
+		case float64:
+			existing, _ := dst[key].(float64)
+			dst[key] = existing + v
+		default:
+			if _, ok := dst[key]; !ok {
+				dst[key] = value
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,255 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestSplitTimeRange(t *testing.T) {
+	end := time.Date(2024, 5, 4, 12, 0, 30, 0, time.UTC)
+
+	t.Run("splits log queries backward from the end", func(t *testing.T) {
+		chunks := splitTimeRange(&lokiQuery{Expr: `{app="a"}`, Start: end.Add(-60 * time.Hour), End: end}, 24*time.Hour)
+		require.Equal(t, []timeChunk{
+			{start: end.Add(-60 * time.Hour), end: end.Add(-48 * time.Hour)},
+			{start: end.Add(-48 * time.Hour), end: end.Add(-24 * time.Hour)},
+			{start: end.Add(-24 * time.Hour), end: end},
+		}, chunks)
+	})
+
+	t.Run("splits metric queries in chunks aligned to the step", func(t *testing.T) {
+		query := &lokiQuery{Expr: `rate({app="a"}[1m])`, Step: time.Minute, Start: end.Add(-48 * time.Hour), End: end}
+		chunks := splitTimeRange(query, 24*time.Hour)
+		require.Equal(t, []timeChunk{
+			{start: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC), end: time.Date(2024, 5, 3, 11, 59, 0, 0, time.UTC)},
+			{start: time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC), end: time.Date(2024, 5, 4, 11, 59, 0, 0, time.UTC)},
+			{start: time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC), end: end},
+		}, chunks)
+	})
+
+	t.Run("doesn't split short ranges", func(t *testing.T) {
+		query := &lokiQuery{Expr: `rate({app="a"}[1m])`, Step: time.Minute, Start: end.Add(-time.Hour), End: end}
+		require.Equal(t, []timeChunk{{start: query.Start, end: end}}, splitTimeRange(query, 24*time.Hour))
+	})
+}
+
+func TestWithStreamSelectorMatcher(t *testing.T) {
+	require.Equal(t, `{__stream_shard__="1", app="a"} |= "}"`, withStreamSelectorMatcher(`{app="a"} |= "}"`, `__stream_shard__="1"`))
+	require.Equal(t, `{__stream_shard__="1", app="}"} | json`, withStreamSelectorMatcher(`{app="}"} | json`, `__stream_shard__="1"`))
+	require.Equal(t, `{__stream_shard__="1"}`, withStreamSelectorMatcher(`{}`, `__stream_shard__="1"`))
+	require.Equal(t, -1, streamSelectorEnd(`{app="a`))
+}
+
+// fakeLoki answers range queries with one log line per hour or one sample per step, and the
+// label values requests with its stream shards.
+type fakeLoki struct {
+	shards []string
+	// the requests starting at this time fail
+	failAt time.Time
+
+	mu       sync.Mutex
+	requests []fakeLokiRequest
+}
+
+type fakeLokiRequest struct {
+	path  string
+	query string
+	start time.Time
+	end   time.Time
+}
+
+func (l *fakeLoki) RoundTrip(req *http.Request) (*http.Response, error) {
+	params := req.URL.Query()
+	start, _ := strconv.ParseInt(params.Get("start"), 10, 64)
+	end, _ := strconv.ParseInt(params.Get("end"), 10, 64)
+	l.mu.Lock()
+	l.requests = append(l.requests, fakeLokiRequest{path: req.URL.Path, query: params.Get("query"), start: time.Unix(0, start).UTC(), end: time.Unix(0, end).UTC()})
+	l.mu.Unlock()
+
+	respond := func(status int, body string) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader([]byte(body)))}, nil
+	}
+	if strings.HasSuffix(req.URL.Path, "/values") {
+		shards, _ := json.Marshal(l.shards)
+		return respond(http.StatusOK, `{"status":"success","data":`+string(shards)+`}`)
+	}
+	if time.Unix(0, start).Equal(l.failAt) {
+		return respond(http.StatusInternalServerError, `{"message":"too many outstanding requests"}`)
+	}
+
+	if !isLogsQuery(params.Get("query")) {
+		step, _ := time.ParseDuration(params.Get("step"))
+		var values []string
+		for ts := time.Unix(0, start); !ts.After(time.Unix(0, end)); ts = ts.Add(step) {
+			values = append(values, fmt.Sprintf(`[%d,"1"]`, ts.Unix()))
+		}
+		return respond(http.StatusOK, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"app":"a"},"values":[`+
+			strings.Join(values, ",")+`]}],"stats":{"summary":{"totalLinesProcessed":10}}}}`)
+	}
+
+	var lines []int64
+	for ts := time.Unix(0, start).Truncate(time.Hour); ts.Before(time.Unix(0, end)); ts = ts.Add(time.Hour) {
+		if !ts.Before(time.Unix(0, start)) {
+			lines = append(lines, ts.UnixNano())
+		}
+	}
+	if params.Get("direction") != "forward" {
+		sort.Slice(lines, func(i, j int) bool { return lines[i] > lines[j] })
+	}
+	if limit, _ := strconv.Atoi(params.Get("limit")); limit > 0 && len(lines) > limit {
+		lines = lines[:limit]
+	}
+	values := make([]string, 0, len(lines))
+	for _, ts := range lines {
+		values = append(values, fmt.Sprintf(`["%d","line %d"]`, ts, ts))
+	}
+	return respond(http.StatusOK, `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"a"},"values":[`+
+		strings.Join(values, ",")+`]}],"stats":{"summary":{"totalLinesProcessed":10}}}}`)
+}
+
+func (l *fakeLoki) queryRequests() []fakeLokiRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var requests []fakeLokiRequest
+	for _, r := range l.requests {
+		if strings.HasSuffix(r.path, "/query_range") {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func TestRunSplitQuery(t *testing.T) {
+	end := time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)
+	newAPI := func(loki *fakeLoki) *LokiAPI {
+		return newLokiAPI(&http.Client{Transport: loki}, "http://localhost:3100", backend.NewLoggerWith("logger", "test"), tracing.InitializeTracerForTest(), false)
+	}
+	logsQuery := func() *lokiQuery {
+		return &lokiQuery{
+			Expr:      `{app="a"}`,
+			QueryType: QueryTypeRange,
+			Direction: DirectionBackward,
+			Start:     end.Add(-72 * time.Hour),
+			End:       end,
+			RefID:     "A",
+		}
+	}
+
+	t.Run("merges the log lines of the chunks in order", func(t *testing.T) {
+		loki := &fakeLoki{}
+		res, err := runSplitQuery(context.Background(), newAPI(loki), logsQuery(), ResponseOpts{}, 4, backend.NewLoggerWith("logger", "test"))
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Len(t, loki.queryRequests(), 3)
+
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, 72, frame.Rows())
+		require.Equal(t, end.Add(-time.Hour).UnixNano(), frame.Fields[1].At(0).(time.Time).UnixNano())
+		require.Equal(t, end.Add(-72*time.Hour).UnixNano(), frame.Fields[1].At(71).(time.Time).UnixNano())
+		require.Equal(t, "id", frame.Fields[len(frame.Fields)-1].Name)
+		require.Contains(t, frame.Meta.Stats, data.QueryStat{
+			FieldConfig: data.FieldConfig{DisplayName: "Summary: total lines processed"},
+			Value:       30,
+		})
+	})
+
+	t.Run("stops querying the chunks when the line limit is reached", func(t *testing.T) {
+		loki := &fakeLoki{}
+		query := logsQuery()
+		query.MaxLines = 30
+		res, err := runSplitQuery(context.Background(), newAPI(loki), query, ResponseOpts{}, 1, backend.NewLoggerWith("logger", "test"))
+		require.NoError(t, err)
+
+		requests := loki.queryRequests()
+		require.Len(t, requests, 2)
+		require.Equal(t, end.Add(-24*time.Hour), requests[0].start)
+		require.Equal(t, end.Add(-48*time.Hour), requests[1].start)
+		require.Equal(t, 30, res.Frames[0].Rows())
+		require.Equal(t, end.Add(-30*time.Hour).UnixNano(), res.Frames[0].Fields[1].At(29).(time.Time).UnixNano())
+	})
+
+	t.Run("shards log queries by stream", func(t *testing.T) {
+		loki := &fakeLoki{shards: []string{"10", "2", "1", "0"}}
+		query := logsQuery()
+		query.Start = end.Add(-6 * time.Hour)
+		_, err := runSplitQuery(context.Background(), newAPI(loki), query, ResponseOpts{}, 2, backend.NewLoggerWith("logger", "test"))
+		require.NoError(t, err)
+
+		var exprs []string
+		for _, r := range loki.queryRequests() {
+			exprs = append(exprs, r.query)
+		}
+		require.ElementsMatch(t, []string{
+			`{__stream_shard__=~"0|1", app="a"}`,
+			`{__stream_shard__=~"2|10", app="a"}`,
+			`{__stream_shard__="", app="a"}`,
+		}, exprs)
+	})
+
+	t.Run("removes the samples of the first chunk before the start of a metric query", func(t *testing.T) {
+		loki := &fakeLoki{}
+		query := &lokiQuery{
+			Expr:      `sum(rate({app="a"}[1m]))`,
+			QueryType: QueryTypeRange,
+			Step:      time.Hour,
+			Start:     end.Add(-48*time.Hour + 30*time.Minute),
+			End:       end,
+			RefID:     "A",
+		}
+		res, err := runSplitQuery(context.Background(), newAPI(loki), query, ResponseOpts{}, 4, backend.NewLoggerWith("logger", "test"))
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+
+		var starts []time.Time
+		for _, r := range loki.queryRequests() {
+			starts = append(starts, r.start)
+		}
+		// the first chunk is aligned to the step
+		require.ElementsMatch(t, []time.Time{end.Add(-48 * time.Hour), end.Add(-24 * time.Hour), end}, starts)
+
+		require.Len(t, res.Frames, 1)
+		require.Equal(t, 48, res.Frames[0].Rows())
+		require.Equal(t, end.Add(-47*time.Hour).Unix(), res.Frames[0].Fields[0].At(0).(time.Time).Unix())
+		require.Equal(t, end.Unix(), res.Frames[0].Fields[0].At(47).(time.Time).Unix())
+	})
+
+	t.Run("returns the frames of the other chunks when a chunk fails", func(t *testing.T) {
+		loki := &fakeLoki{failAt: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)}
+		query := &lokiQuery{
+			Expr:      `sum(rate({app="a"}[1m]))`,
+			QueryType: QueryTypeRange,
+			Step:      time.Hour,
+			Start:     end.Add(-72 * time.Hour),
+			End:       end,
+			RefID:     "A",
+		}
+		res, err := runSplitQuery(context.Background(), newAPI(loki), query, ResponseOpts{}, 4, backend.NewLoggerWith("logger", "test"))
+		require.NoError(t, err)
+		require.Len(t, loki.queryRequests(), 4)
+		require.ErrorContains(t, res.Error, "1 of 4 split queries failed")
+		require.ErrorContains(t, res.Error, "too many outstanding requests")
+
+		require.Len(t, res.Frames, 1)
+		require.Equal(t, 49, res.Frames[0].Rows())
+		require.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC).Unix(), res.Frames[0].Fields[0].At(0).(time.Time).Unix())
+		require.Equal(t, time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC).Unix(), res.Frames[0].Fields[0].At(24).(time.Time).Unix())
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+	})
+}
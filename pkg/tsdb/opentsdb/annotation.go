@@ -0,0 +1,58 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type annotationQuery struct {
+	target   string
+	isGlobal bool
+}
+
+type annotationQueryModel struct {
+	FromAnnotations bool   `json:"fromAnnotations"`
+	Target          string `json:"target"`
+	IsGlobal        bool   `json:"isGlobal"`
+}
+
+// parseAnnotationQuery returns the annotation query of the annotation editor, nil for the other
+// queries. The annotations of the series of the target metric are returned, or the global
+// annotations when isGlobal is set.
+func parseAnnotationQuery(query backend.DataQuery) *annotationQuery {
+	var model annotationQueryModel
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return nil
+	}
+	if !model.FromAnnotations || model.Target == "" {
+		return nil
+	}
+	return &annotationQuery{target: model.Target, isGlobal: model.IsGlobal}
+}
+
+func annotationsToFrame(refID string, res OpenTsdbResponse, isGlobal bool) *data.Frame {
+	annotations := res.Annotations
+	if isGlobal {
+		annotations = res.GlobalAnnotations
+	}
+
+	frame := data.NewFrame(refID,
+		data.NewField("time", nil, []time.Time{}),
+		data.NewField("timeEnd", nil, []*time.Time{}),
+		data.NewField("text", nil, []string{}),
+	)
+	frame.RefID = refID
+	for _, annotation := range annotations {
+		// OpenTSDB stores the time of the annotations in seconds
+		var timeEnd *time.Time
+		if annotation.EndTime > 0 {
+			end := time.Unix(int64(annotation.EndTime), 0).UTC()
+			timeEnd = &end
+		}
+		frame.AppendRow(time.Unix(int64(annotation.StartTime), 0).UTC(), timeEnd, annotation.Description)
+	}
+	return frame
+}
@@ -0,0 +1,118 @@
+package opentsdb
+
+import (
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// queryBatch is the queries sent in one /api/query request. OpenTSDB returns the series of all the
+// queries in one list, they are mapped back to their query by queryIndex.
+type queryBatch struct {
+	tsdbQuery OpenTsdbQuery
+	queries   []batchedQuery
+}
+
+type batchedQuery struct {
+	refID  string
+	metric map[string]any
+	// annotation is only set for the annotation queries
+	annotation *annotationQuery
+}
+
+type timeRangeKey struct {
+	from, to int64
+}
+
+// batchQueries returns one batch per time range, usually a single one as all the queries of a
+// panel share the time range of the dashboard. Before OpenTSDB 2.3, the series can only be mapped
+// back to their query by metric, so the queries of the same metric are sent in separate batches.
+// The queries which aren't sent get their response in result.
+func (s *Service) batchQueries(dsInfo *datasourceInfo, queries []backend.DataQuery, result *backend.QueryDataResponse) []*queryBatch {
+	var batches []*queryBatch
+	batchesByTimeRange := map[timeRangeKey][]*queryBatch{}
+
+	for _, query := range queries {
+		q := batchedQuery{refID: query.RefID}
+		if annotation := parseAnnotationQuery(query); annotation != nil {
+			q.annotation = annotation
+			q.metric = map[string]any{
+				"metric":     annotation.target,
+				"aggregator": "sum",
+			}
+		} else {
+			q.metric = s.buildMetric(query)
+			if q.metric == nil {
+				result.Responses[query.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, "failed to parse the query")
+				continue
+			}
+			if q.metric["metric"] == "" {
+				// like in the frontend, the queries without a metric don't return anything
+				result.Responses[query.RefID] = backend.DataResponse{}
+				continue
+			}
+		}
+
+		from := query.TimeRange.From.UnixNano() / int64(time.Millisecond)
+		to := query.TimeRange.To.UnixNano() / int64(time.Millisecond)
+		key := timeRangeKey{from, to}
+		var batch *queryBatch
+		for _, b := range batchesByTimeRange[key] {
+			if b.tsdbQuery.ShowQuery || !b.hasMetric(q.metric["metric"]) {
+				batch = b
+				break
+			}
+		}
+		if batch == nil {
+			batch = &queryBatch{
+				tsdbQuery: OpenTsdbQuery{
+					Start:        from,
+					End:          to,
+					MsResolution: dsInfo.TSDBResolution == 2,
+					// the index of the queries is returned since OpenTSDB 2.3
+					ShowQuery: dsInfo.TSDBVersion >= 3,
+				},
+			}
+			batchesByTimeRange[key] = append(batchesByTimeRange[key], batch)
+			batches = append(batches, batch)
+		}
+
+		if q.annotation != nil && q.annotation.isGlobal {
+			batch.tsdbQuery.GlobalAnnotations = true
+		}
+		batch.tsdbQuery.Queries = append(batch.tsdbQuery.Queries, q.metric)
+		batch.queries = append(batch.queries, q)
+	}
+
+	return batches
+}
+
+// hasMetric returns whether a query of the batch has the metric.
+func (b *queryBatch) hasMetric(metric any) bool {
+	return slices.ContainsFunc(b.queries, func(q batchedQuery) bool {
+		return q.metric["metric"] == metric
+	})
+}
+
+// queryIndex returns the index of the query of a series. Without the query in the response, the
+// series belongs to the query with its metric, the only one in the batch.
+func (b *queryBatch) queryIndex(res OpenTsdbResponse) int {
+	if res.Query != nil && res.Query.Index >= 0 && res.Query.Index < len(b.queries) {
+		return res.Query.Index
+	}
+
+	for i, q := range b.queries {
+		if q.metric["metric"] == res.Metric {
+			return i
+		}
+	}
+	return 0
+}
+
+func (b *queryBatch) pointTime(timestamp float64) time.Time {
+	if b.tsdbQuery.MsResolution {
+		return time.UnixMilli(int64(timestamp)).UTC()
+	}
+	return time.Unix(int64(timestamp), 0).UTC()
+}
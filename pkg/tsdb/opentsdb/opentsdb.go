@@ -8,12 +8,13 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -25,22 +26,34 @@ import (
 var logger = log.New("tsdb.opentsdb")
 
 type Service struct {
-	im instancemgmt.InstanceManager
+	im              instancemgmt.InstanceManager
+	resourceHandler backend.CallResourceHandler
 }
 
 func ProvideService(httpClientProvider httpclient.Provider) *Service {
-	return &Service{
+	s := &Service{
 		im: datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
 	}
+	s.resourceHandler = httpadapter.New(s.newResourceMux())
+	return s
 }
 
 type datasourceInfo struct {
-	HTTPClient *http.Client
-	URL        string
+	HTTPClient     *http.Client
+	URL            string
+	TSDBVersion    int
+	TSDBResolution int
+	LookupLimit    int
 }
 
 type DsAccess string
 
+type jsonData struct {
+	TSDBVersion    int `json:"tsdbVersion"`
+	TSDBResolution int `json:"tsdbResolution"`
+	LookupLimit    int `json:"lookupLimit"`
+}
+
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
 	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		opts, err := settings.HTTPClientOptions(ctx)
@@ -53,50 +66,83 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jsonSettings := jsonData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonSettings); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
 		model := &datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
+			HTTPClient:     client,
+			URL:            settings.URL,
+			TSDBVersion:    jsonSettings.TSDBVersion,
+			TSDBResolution: jsonSettings.TSDBResolution,
+			LookupLimit:    jsonSettings.LookupLimit,
+		}
+		// same defaults as the config editor
+		if model.TSDBVersion == 0 {
+			model.TSDBVersion = 1
+		}
+		if model.TSDBResolution == 0 {
+			model.TSDBResolution = 1
+		}
+		if model.LookupLimit == 0 {
+			model.LookupLimit = 1000
 		}
 
 		return model, nil
 	}
 }
 
-func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
-	var tsdbQuery OpenTsdbQuery
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	return s.resourceHandler.CallResource(ctx, req, sender)
+}
 
+// QueryData sends the queries with the same time range in one request, and maps the results back
+// to the queries.
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	logger := logger.FromContext(ctx)
 
-	q := req.Queries[0]
-
-	myRefID := q.RefID
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
 
-	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
-	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	result := backend.NewQueryDataResponse()
+	for _, batch := range s.batchQueries(dsInfo, req.Queries, result) {
+		// TODO: Don't use global variable
+		if setting.Env == setting.Dev {
+			logger.Debug("OpenTsdb request", "params", batch.tsdbQuery)
+		}
 
-	for _, query := range req.Queries {
-		metric := s.buildMetric(query)
-		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+		for refID, res := range s.runBatch(ctx, logger, dsInfo, batch) {
+			result.Responses[refID] = res
+		}
 	}
 
-	// TODO: Don't use global variable
-	if setting.Env == setting.Dev {
-		logger.Debug("OpenTsdb request", "params", tsdbQuery)
-	}
+	return result, nil
+}
 
-	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
-	if err != nil {
-		return nil, err
+// runBatch returns the responses of the queries of a batch, the queries all get the error of a
+// failed request.
+func (s *Service) runBatch(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, batch *queryBatch) backend.Responses {
+	errorResponses := func(err error, source backend.ErrorSource) backend.Responses {
+		responses := backend.Responses{}
+		for _, q := range batch.queries {
+			responses[q.refID] = backend.DataResponse{Error: err, ErrorSource: source}
+		}
+		return responses
 	}
 
-	request, err := s.createRequest(ctx, logger, dsInfo, tsdbQuery)
+	request, err := s.createRequest(ctx, logger, dsInfo, batch.tsdbQuery)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return errorResponses(err, backend.ErrorSourcePlugin)
 	}
 
 	res, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return errorResponses(err, backend.ErrorSourceDownstream)
 	}
 
 	defer func() {
@@ -106,12 +152,12 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		}
 	}()
 
-	result, err := s.parseResponse(logger, res, myRefID)
+	result, err := s.parseResponse(logger, res, batch)
 	if err != nil {
-		return &backend.QueryDataResponse{}, err
+		return errorResponses(err, backend.ErrorSourceDownstream)
 	}
 
-	return result, nil
+	return result.Responses
 }
 
 func (s *Service) createRequest(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, data OpenTsdbQuery) (*http.Request, error) {
@@ -140,7 +186,7 @@ func (s *Service) createRequest(ctx context.Context, logger log.Logger, dsInfo *
 	return req, nil
 }
 
-func (s *Service) parseResponse(logger log.Logger, res *http.Response, myRefID string) (*backend.QueryDataResponse, error) {
+func (s *Service) parseResponse(logger log.Logger, res *http.Response, batch *queryBatch) (*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
 
 	body, err := io.ReadAll(res.Body)
@@ -165,8 +211,26 @@ func (s *Service) parseResponse(logger log.Logger, res *http.Response, myRefID s
 		return nil, err
 	}
 
-	frames := data.Frames{}
+	for _, q := range batch.queries {
+		resp.Responses[q.refID] = backend.DataResponse{Frames: data.Frames{}}
+	}
+
+	annotated := map[int]bool{}
 	for _, val := range responseData {
+		index := batch.queryIndex(val)
+		q := batch.queries[index]
+		result := resp.Responses[q.refID]
+
+		if q.annotation != nil {
+			// like the frontend, the annotations are read from the first series of the query
+			if !annotated[index] {
+				annotated[index] = true
+				result.Frames = append(result.Frames, annotationsToFrame(q.refID, val, q.annotation.isGlobal))
+			}
+			resp.Responses[q.refID] = result
+			continue
+		}
+
 		labels := data.Labels{}
 		for label, value := range val.Tags {
 			labels[label] = value
@@ -174,7 +238,7 @@ func (s *Service) parseResponse(logger log.Logger, res *http.Response, myRefID s
 
 		frame := data.NewFrameOfFieldTypes(val.Metric, len(val.DataPoints), data.FieldTypeTime, data.FieldTypeFloat64)
 		frame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti, TypeVersion: data.FrameTypeVersion{0, 1}}
-		frame.RefID = myRefID
+		frame.RefID = q.refID
 		timeField := frame.Fields[0]
 		timeField.Name = data.TimeSeriesTimeFieldName
 		dataField := frame.Fields[1]
@@ -183,13 +247,11 @@ func (s *Service) parseResponse(logger log.Logger, res *http.Response, myRefID s
 
 		points := val.DataPoints
 		for i, point := range points {
-			frame.SetRow(i, time.Unix(int64(point[0]), 0).UTC(), point[1])
+			frame.SetRow(i, batch.pointTime(point[0]), point[1])
 		}
-		frames = append(frames, frame)
+		result.Frames = append(result.Frames, frame)
+		resp.Responses[q.refID] = result
 	}
-	result := resp.Responses[myRefID]
-	result.Frames = frames
-	resp.Responses[myRefID] = result
 	return resp, nil
 }
 
@@ -226,17 +288,17 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
 		rateOptions := make(map[string]any)
 		rateOptions["counter"] = model.Get("isCounter").MustBool()
 
-		counterMax, counterMaxCheck := model.CheckGet("counterMax")
+		counterMax, counterMaxCheck := numberOption(model, "counterMax")
 		if counterMaxCheck {
-			rateOptions["counterMax"] = counterMax.MustFloat64()
+			rateOptions["counterMax"] = counterMax
 		}
 
-		resetValue, resetValueCheck := model.CheckGet("counterResetValue")
+		resetValue, resetValueCheck := numberOption(model, "counterResetValue")
 		if resetValueCheck {
-			rateOptions["resetValue"] = resetValue.MustFloat64()
+			rateOptions["resetValue"] = resetValue
 		}
 
-		if !counterMaxCheck && (!resetValueCheck || resetValue.MustFloat64() == 0) {
+		if !counterMaxCheck && (!resetValueCheck || resetValue == 0) {
 			rateOptions["dropResets"] = true
 		}
 
@@ -255,9 +317,35 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
 		metric["filters"] = filters.MustArray()
 	}
 
+	if model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
+	}
+
 	return metric
 }
 
+// numberOption returns a numeric option of the query. The query editor stores them as strings,
+// empty when the option is not set.
+func numberOption(model *simplejson.Json, key string) (float64, bool) {
+	value, ok := model.CheckGet(key)
+	if !ok {
+		return 0, false
+	}
+	if number, err := value.Float64(); err == nil {
+		return number, true
+	}
+
+	str := strings.TrimSpace(value.MustString())
+	if str == "" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
 func (s *Service) getDSInfo(ctx context.Context, pluginCtx backend.PluginContext) (*datasourceInfo, error) {
 	i, err := s.im.Get(ctx, pluginCtx)
 	if err != nil {
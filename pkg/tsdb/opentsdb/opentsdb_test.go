@@ -4,12 +4,15 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/resource/httpadapter"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,7 +36,7 @@ func TestOpenTsdbExecutor(t *testing.T) {
 	t.Run("Parse response should handle invalid JSON", func(t *testing.T) {
 		response := `{ invalid }`
 
-		result, err := service.parseResponse(logger, &http.Response{Body: io.NopCloser(strings.NewReader(response))}, "A")
+		result, err := service.parseResponse(logger, &http.Response{Body: io.NopCloser(strings.NewReader(response))}, testBatch("A"))
 		require.Nil(t, result)
 		require.Error(t, err)
 	})
@@ -68,7 +71,7 @@ func TestOpenTsdbExecutor(t *testing.T) {
 
 		resp := http.Response{Body: io.NopCloser(strings.NewReader(response))}
 		resp.StatusCode = 200
-		result, err := service.parseResponse(logger, &resp, "A")
+		result, err := service.parseResponse(logger, &resp, testBatch("A"))
 		require.NoError(t, err)
 
 		frame := result.Responses["A"]
@@ -110,7 +113,7 @@ func TestOpenTsdbExecutor(t *testing.T) {
 
 		resp := http.Response{Body: io.NopCloser(strings.NewReader(response))}
 		resp.StatusCode = 200
-		result, err := service.parseResponse(logger, &resp, myRefid)
+		result, err := service.parseResponse(logger, &resp, testBatch(myRefid))
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(testFrame, result.Responses[myRefid].Frames[0], data.FrameTestCompareOptions()...); diff != "" {
@@ -286,4 +289,223 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(45), metricRateOptions["counterMax"])
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
+
+	t.Run("Build metric with the counter options of the query editor", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"aggregator": "avg",
+						"disableDownsampling": true,
+						"shouldComputeRate": true,
+						"isCounter": true,
+						"counterMax": "45",
+						"counterResetValue": "",
+						"explicitTags": true
+					}`,
+			),
+		}
+
+		metric := service.buildMetric(query)
+
+		require.Len(t, metric, 5)
+		require.True(t, metric["explicitTags"].(bool))
+		require.Equal(t, map[string]any{"counter": true, "counterMax": float64(45)}, metric["rateOptions"])
+	})
+}
+
+func testBatch(refIDs ...string) *queryBatch {
+	batch := &queryBatch{}
+	for _, refID := range refIDs {
+		batch.queries = append(batch.queries, batchedQuery{refID: refID, metric: map[string]any{}})
+	}
+	return batch
+}
+
+type opentsdbInstanceManager struct {
+	info *datasourceInfo
+}
+
+func (m opentsdbInstanceManager) Get(_ context.Context, _ backend.PluginContext) (instancemgmt.Instance, error) {
+	return m.info, nil
+}
+
+func (m opentsdbInstanceManager) Do(_ context.Context, _ backend.PluginContext, _ instancemgmt.InstanceCallbackFunc) error {
+	return nil
+}
+
+// newTestService returns a service sending its requests to a fake OpenTSDB, which records their
+// URL and body.
+func newTestService(t *testing.T, info datasourceInfo, handler http.HandlerFunc) (*Service, *[]string, *[]string) {
+	t.Helper()
+	var urls, bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		urls = append(urls, req.URL.String())
+		bodies = append(bodies, string(body))
+		handler(rw, req)
+	}))
+	t.Cleanup(srv.Close)
+
+	info.HTTPClient = srv.Client()
+	info.URL = srv.URL
+	s := &Service{im: opentsdbInstanceManager{info: &info}}
+	s.resourceHandler = httpadapter.New(s.newResourceMux())
+	return s, &urls, &bodies
+}
+
+func TestQueryData(t *testing.T) {
+	timeRange := backend.TimeRange{
+		From: time.Unix(1700000000, 0),
+		To:   time.Unix(1700003600, 0),
+	}
+	respond := func(body string) http.HandlerFunc {
+		return func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write([]byte(body))
+		}
+	}
+
+	t.Run("sends the queries in one request and maps the series to their query", func(t *testing.T) {
+		s, urls, bodies := newTestService(t, datasourceInfo{TSDBVersion: 3, TSDBResolution: 1}, respond(`[
+			{"metric": "cpu", "tags": {"host": "a"}, "dps": [[1700000000, 1]], "query": {"index": 1}},
+			{"metric": "cpu", "tags": {"host": "b"}, "dps": [[1700000000, 2]], "query": {"index": 0}}
+		]`))
+
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "aggregator": "sum", "disableDownsampling": true, "tags": {"host": "b"}}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "aggregator": "sum", "disableDownsampling": true, "tags": {"host": "a"}}`)},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, *urls, 1)
+		require.Equal(t, "/api/query?arrays=true", (*urls)[0])
+		require.JSONEq(t, `{
+			"start": 1700000000000,
+			"end": 1700003600000,
+			"showQuery": true,
+			"queries": [
+				{"metric": "cpu", "aggregator": "sum", "tags": {"host": "b"}},
+				{"metric": "cpu", "aggregator": "sum", "tags": {"host": "a"}}
+			]
+		}`, (*bodies)[0])
+
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		require.Equal(t, "A", resp.Responses["A"].Frames[0].RefID)
+		require.Equal(t, data.Labels{"host": "b"}, resp.Responses["A"].Frames[0].Fields[1].Labels)
+		require.Len(t, resp.Responses["B"].Frames, 1)
+		require.Equal(t, data.Labels{"host": "a"}, resp.Responses["B"].Frames[0].Fields[1].Labels)
+	})
+
+	t.Run("maps the series by metric and tags without the query in the response", func(t *testing.T) {
+		s, _, _ := newTestService(t, datasourceInfo{TSDBVersion: 1, TSDBResolution: 2}, respond(`[
+			{"metric": "mem", "tags": {"host": "a"}, "dps": [[1700000000000, 1]]},
+			{"metric": "cpu", "tags": {"host": "b"}, "dps": [[1700000000000, 2]]}
+		]`))
+
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "aggregator": "sum", "tags": {"host": "a|b"}}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "mem", "aggregator": "sum", "tags": {"host": "*"}}`)},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		require.Equal(t, "cpu", resp.Responses["A"].Frames[0].Name)
+		require.Equal(t, time.UnixMilli(1700000000000).UTC(), resp.Responses["A"].Frames[0].Fields[0].At(0))
+		require.Len(t, resp.Responses["B"].Frames, 1)
+		require.Equal(t, "mem", resp.Responses["B"].Frames[0].Name)
+	})
+
+	t.Run("sends the queries of the same metric in separate requests without the query in the response", func(t *testing.T) {
+		s, _, bodies := newTestService(t, datasourceInfo{TSDBVersion: 1}, func(rw http.ResponseWriter, req *http.Request) {
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			host := "a"
+			if strings.Contains(string(body), `"host":"b"`) {
+				host = "b"
+			}
+			_, _ = rw.Write([]byte(`[{"metric": "cpu", "tags": {"host": "` + host + `"}, "dps": [[1700000000, 1]]}]`))
+		})
+
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "aggregator": "sum", "tags": {"host": "a"}}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu", "aggregator": "sum", "tags": {"host": "b"}}`)},
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, *bodies, 2)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		require.Equal(t, "A", resp.Responses["A"].Frames[0].RefID)
+		require.Equal(t, data.Labels{"host": "a"}, resp.Responses["A"].Frames[0].Fields[1].Labels)
+		require.Len(t, resp.Responses["B"].Frames, 1)
+		require.Equal(t, "B", resp.Responses["B"].Frames[0].RefID)
+		require.Equal(t, data.Labels{"host": "b"}, resp.Responses["B"].Frames[0].Fields[1].Labels)
+	})
+
+	t.Run("returns the annotations of the metric or the global annotations", func(t *testing.T) {
+		s, _, bodies := newTestService(t, datasourceInfo{TSDBVersion: 3}, respond(`[
+			{
+				"metric": "deploys",
+				"tags": {},
+				"dps": [],
+				"annotations": [{"description": "deploy", "startTime": 1700000000}],
+				"globalAnnotations": [{"description": "outage", "startTime": 1700000100, "endTime": 1700000200}],
+				"query": {"index": 0}
+			},
+			{
+				"metric": "deploys",
+				"tags": {},
+				"dps": [],
+				"annotations": [{"description": "deploy", "startTime": 1700000000}],
+				"globalAnnotations": [{"description": "outage", "startTime": 1700000100, "endTime": 1700000200}],
+				"query": {"index": 1}
+			}
+		]`))
+
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "Anno", TimeRange: timeRange, JSON: []byte(`{"fromAnnotations": true, "target": "deploys"}`)},
+				{RefID: "Global", TimeRange: timeRange, JSON: []byte(`{"fromAnnotations": true, "target": "deploys", "isGlobal": true}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.Contains(t, (*bodies)[0], `"globalAnnotations":true`)
+
+		frame := resp.Responses["Anno"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, time.Unix(1700000000, 0).UTC(), frame.Fields[0].At(0))
+		require.Nil(t, frame.Fields[1].At(0))
+		require.Equal(t, "deploy", frame.Fields[2].At(0))
+
+		frame = resp.Responses["Global"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		end := time.Unix(1700000200, 0).UTC()
+		require.Equal(t, &end, frame.Fields[1].At(0))
+		require.Equal(t, "outage", frame.Fields[2].At(0))
+	})
+
+	t.Run("returns the error of the request on all its queries", func(t *testing.T) {
+		s, _, _ := newTestService(t, datasourceInfo{}, func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(http.StatusBadRequest)
+		})
+
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", TimeRange: timeRange, JSON: []byte(`{"metric": "cpu"}`)},
+				{RefID: "B", TimeRange: timeRange, JSON: []byte(`{"metric": "mem"}`)},
+				{RefID: "C", TimeRange: timeRange, JSON: []byte(`{"metric": ""}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, resp.Responses["A"].Error, "400 Bad Request")
+		require.Equal(t, backend.ErrorSourceDownstream, resp.Responses["A"].ErrorSource)
+		require.ErrorContains(t, resp.Responses["B"].Error, "400 Bad Request")
+		require.NoError(t, resp.Responses["C"].Error)
+	})
 }
@@ -0,0 +1,241 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+var suggestTypes = []string{"metrics", "tagk", "tagv"}
+
+// newResourceMux returns the resources used by the query editor and the template variables.
+func (s *Service) newResourceMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/suggest", s.handleSuggest)
+	mux.HandleFunc("/api/aggregators", s.handleForward("api/aggregators"))
+	mux.HandleFunc("/api/config/filters", s.handleForward("api/config/filters"))
+	mux.HandleFunc("/tag-keys", s.handleTagKeys)
+	mux.HandleFunc("/tag-values", s.handleTagValues)
+	return mux
+}
+
+// handleSuggest returns the metrics, tag keys or tag values starting with q.
+func (s *Service) handleSuggest(rw http.ResponseWriter, req *http.Request) {
+	dsInfo, ok := s.resourceDSInfo(rw, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	suggestType := query.Get("type")
+	if !slices.Contains(suggestTypes, suggestType) {
+		writeResponse(rw, http.StatusBadRequest, fmt.Sprintf("invalid suggest type %q", suggestType))
+		return
+	}
+	params := url.Values{
+		"type": []string{suggestType},
+		"q":    []string{query.Get("q")},
+		"max":  []string{strconv.Itoa(lookupLimit(dsInfo, query.Get("max")))},
+	}
+
+	body, status, err := s.get(req, dsInfo, "api/suggest", params)
+	if err != nil {
+		writeResponse(rw, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(rw, status, body)
+}
+
+func (s *Service) handleForward(apiPath string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		dsInfo, ok := s.resourceDSInfo(rw, req)
+		if !ok {
+			return
+		}
+
+		body, status, err := s.get(req, dsInfo, apiPath, url.Values{})
+		if err != nil {
+			writeResponse(rw, http.StatusBadGateway, err.Error())
+			return
+		}
+		writeJSON(rw, status, body)
+	}
+}
+
+// handleTagKeys returns the tag keys of the series of a metric.
+func (s *Service) handleTagKeys(rw http.ResponseWriter, req *http.Request) {
+	dsInfo, ok := s.resourceDSInfo(rw, req)
+	if !ok {
+		return
+	}
+
+	metric := req.URL.Query().Get("metric")
+	if metric == "" {
+		writeResponse(rw, http.StatusBadRequest, "metric is required")
+		return
+	}
+
+	results, ok := s.lookup(rw, req, dsInfo, metric)
+	if !ok {
+		return
+	}
+	keys := []string{}
+	for _, result := range results {
+		for key := range result.Tags {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	writeValues(rw, keys)
+}
+
+// handleTagValues returns the values of a tag of the series of a metric. Like the tag_values
+// template variable query, keys is the tag followed by the tags the series must have, e.g.
+// "host,env=prod".
+func (s *Service) handleTagValues(rw http.ResponseWriter, req *http.Request) {
+	dsInfo, ok := s.resourceDSInfo(rw, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	metric := query.Get("metric")
+	keys := strings.Split(query.Get("keys"), ",")
+	for i := range keys {
+		keys[i] = strings.TrimSpace(keys[i])
+	}
+	if metric == "" || keys[0] == "" {
+		writeResponse(rw, http.StatusBadRequest, "metric and keys are required")
+		return
+	}
+	key := keys[0]
+	tags := append([]string{key + "=*"}, keys[1:]...)
+
+	results, ok := s.lookup(rw, req, dsInfo, metric+"{"+strings.Join(tags, ",")+"}")
+	if !ok {
+		return
+	}
+	values := []string{}
+	for _, result := range results {
+		if value, ok := result.Tags[key]; ok && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	writeValues(rw, values)
+}
+
+func (s *Service) resourceDSInfo(rw http.ResponseWriter, req *http.Request) (*datasourceInfo, bool) {
+	if req.Method != http.MethodGet {
+		writeResponse(rw, http.StatusMethodNotAllowed, fmt.Sprintf("method %s is not allowed", req.Method))
+		return nil, false
+	}
+
+	dsInfo, err := s.getDSInfo(req.Context(), backend.PluginConfigFromContext(req.Context()))
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to get the data source: %v", err))
+		return nil, false
+	}
+	return dsInfo, true
+}
+
+// lookup returns the series matching a /api/search/lookup query, the errors are written to rw.
+func (s *Service) lookup(rw http.ResponseWriter, req *http.Request, dsInfo *datasourceInfo, m string) ([]OpenTsdbLookupResult, bool) {
+	params := url.Values{
+		"m":     []string{m},
+		"limit": []string{strconv.Itoa(lookupLimit(dsInfo, req.URL.Query().Get("limit")))},
+	}
+	body, status, err := s.get(req, dsInfo, "api/search/lookup", params)
+	if err != nil {
+		writeResponse(rw, http.StatusBadGateway, err.Error())
+		return nil, false
+	}
+	if status/100 != 2 {
+		writeJSON(rw, status, body)
+		return nil, false
+	}
+
+	var res OpenTsdbLookupResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		writeResponse(rw, http.StatusBadGateway, fmt.Sprintf("failed to unmarshal the lookup response: %v", err))
+		return nil, false
+	}
+	return res.Results, true
+}
+
+// get sends a GET request to the OpenTSDB API, and returns the body and the status of its response.
+func (s *Service) get(req *http.Request, dsInfo *datasourceInfo, apiPath string, params url.Values) ([]byte, int, error) {
+	ctx := req.Context()
+	logger := logger.FromContext(ctx)
+
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid data source URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, apiPath)
+	u.RawQuery = params.Encode()
+
+	apiReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	res, err := dsInfo.HTTPClient.Do(apiReq)
+	if err != nil {
+		logger.Warn("OpenTSDB resource request failed", "path", apiPath, "error", err)
+		return nil, 0, fmt.Errorf("request to OpenTSDB failed: %w", err)
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read the OpenTSDB response: %w", err)
+	}
+	return body, res.StatusCode, nil
+}
+
+// lookupLimit returns the limit of a request, the lookup limit of the data source by default.
+func lookupLimit(dsInfo *datasourceInfo, limit string) int {
+	if value, err := strconv.Atoi(limit); err == nil && value > 0 {
+		return value
+	}
+	return dsInfo.LookupLimit
+}
+
+func writeValues(rw http.ResponseWriter, values []string) {
+	body, err := json.Marshal(values)
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, fmt.Sprintf("failed to marshal the response: %v", err))
+		return
+	}
+	writeJSON(rw, http.StatusOK, body)
+}
+
+func writeJSON(rw http.ResponseWriter, code int, body []byte) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	if _, err := rw.Write(body); err != nil {
+		logger.Error("Unable to write HTTP response", "error", err)
+	}
+}
+
+func writeResponse(rw http.ResponseWriter, code int, msg string) {
+	rw.WriteHeader(code)
+	if _, err := rw.Write([]byte(msg)); err != nil {
+		logger.Error("Unable to write HTTP response", "error", err)
+	}
+}
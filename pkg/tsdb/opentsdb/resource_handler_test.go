@@ -0,0 +1,95 @@
+package opentsdb
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSender struct {
+	resp *backend.CallResourceResponse
+}
+
+func (s *fakeSender) Send(resp *backend.CallResourceResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestCallResource(t *testing.T) {
+	call := func(t *testing.T, s *Service, method string, resourceURL string) *backend.CallResourceResponse {
+		t.Helper()
+		u, err := url.Parse(resourceURL)
+		require.NoError(t, err)
+		sender := &fakeSender{}
+		req := &backend.CallResourceRequest{Method: method, Path: u.Path, URL: resourceURL}
+		require.NoError(t, s.CallResource(context.Background(), req, sender))
+		return sender.resp
+	}
+	respond := func(body string) http.HandlerFunc {
+		return func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write([]byte(body))
+		}
+	}
+	lookupResponse := `{
+		"results": [
+			{"metric": "cpu", "tags": {"host": "a", "env": "prod"}},
+			{"metric": "cpu", "tags": {"host": "b", "env": "prod"}},
+			{"metric": "cpu", "tags": {"host": "a", "env": "dev", "dc": "eu"}}
+		]
+	}`
+
+	t.Run("suggest uses the lookup limit of the data source", func(t *testing.T) {
+		s, urls, _ := newTestService(t, datasourceInfo{LookupLimit: 50}, respond(`["cpu.user","cpu.system"]`))
+
+		resp := call(t, s, http.MethodGet, "api/suggest?type=metrics&q=cpu")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["cpu.user","cpu.system"]`, string(resp.Body))
+		require.Equal(t, []string{"/api/suggest?max=50&q=cpu&type=metrics"}, *urls)
+
+		resp = call(t, s, http.MethodGet, "api/suggest?type=tagk&q=h&max=10")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.Equal(t, "/api/suggest?max=10&q=h&type=tagk", (*urls)[1])
+	})
+
+	t.Run("suggest rejects unknown types", func(t *testing.T) {
+		s, urls, _ := newTestService(t, datasourceInfo{LookupLimit: 50}, respond(`[]`))
+		require.Equal(t, http.StatusBadRequest, call(t, s, http.MethodGet, "api/suggest?type=uids&q=a").Status)
+		require.Equal(t, http.StatusMethodNotAllowed, call(t, s, http.MethodPost, "api/suggest?type=metrics&q=a").Status)
+		require.Empty(t, *urls)
+	})
+
+	t.Run("returns the tag keys of a metric", func(t *testing.T) {
+		s, urls, _ := newTestService(t, datasourceInfo{LookupLimit: 50}, respond(lookupResponse))
+
+		resp := call(t, s, http.MethodGet, "tag-keys?metric=cpu")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["dc","env","host"]`, string(resp.Body))
+		require.Equal(t, []string{"/api/search/lookup?limit=50&m=cpu"}, *urls)
+	})
+
+	t.Run("returns the tag values of a metric", func(t *testing.T) {
+		s, urls, _ := newTestService(t, datasourceInfo{LookupLimit: 50}, respond(lookupResponse))
+
+		resp := call(t, s, http.MethodGet, "tag-values?metric=cpu&keys=host,%20env%3Dprod")
+		require.Equal(t, http.StatusOK, resp.Status)
+		require.JSONEq(t, `["a","b"]`, string(resp.Body))
+		require.Equal(t, []string{"/api/search/lookup?limit=50&m=cpu%7Bhost%3D%2A%2Cenv%3Dprod%7D"}, *urls)
+
+		require.Equal(t, http.StatusBadRequest, call(t, s, http.MethodGet, "tag-values?metric=cpu").Status)
+	})
+
+	t.Run("returns the errors of OpenTSDB", func(t *testing.T) {
+		s, _, _ := newTestService(t, datasourceInfo{LookupLimit: 50}, func(rw http.ResponseWriter, _ *http.Request) {
+			rw.WriteHeader(http.StatusNotFound)
+			_, _ = rw.Write([]byte(`{"error":{"code":404,"message":"No such name for 'metrics': 'mem'"}}`))
+		})
+
+		resp := call(t, s, http.MethodGet, "tag-keys?metric=mem")
+		require.Equal(t, http.StatusNotFound, resp.Status)
+		require.Contains(t, string(resp.Body), "No such name")
+	})
+}
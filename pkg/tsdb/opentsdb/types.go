@@ -1,13 +1,41 @@
 package opentsdb
 
 type OpenTsdbQuery struct {
-	Start   int64            `json:"start"`
-	End     int64            `json:"end"`
-	Queries []map[string]any `json:"queries"`
+	Start             int64            `json:"start"`
+	End               int64            `json:"end"`
+	Queries           []map[string]any `json:"queries"`
+	MsResolution      bool             `json:"msResolution,omitempty"`
+	GlobalAnnotations bool             `json:"globalAnnotations,omitempty"`
+	ShowQuery         bool             `json:"showQuery,omitempty"`
 }
 
 type OpenTsdbResponse struct {
-	Metric     string            `json:"metric"`
-	Tags       map[string]string `json:"tags"`
-	DataPoints [][]float64       `json:"dps"`
+	Metric            string               `json:"metric"`
+	Tags              map[string]string    `json:"tags"`
+	DataPoints        [][]float64          `json:"dps"`
+	Annotations       []OpenTsdbAnnotation `json:"annotations"`
+	GlobalAnnotations []OpenTsdbAnnotation `json:"globalAnnotations"`
+	// Query is only returned when the request has showQuery set
+	Query *OpenTsdbResponseQuery `json:"query"`
+}
+
+type OpenTsdbResponseQuery struct {
+	Index int `json:"index"`
+}
+
+type OpenTsdbAnnotation struct {
+	TSUID       string  `json:"tsuid"`
+	Description string  `json:"description"`
+	Notes       string  `json:"notes"`
+	StartTime   float64 `json:"startTime"`
+	EndTime     float64 `json:"endTime"`
+}
+
+type OpenTsdbLookupResponse struct {
+	Results []OpenTsdbLookupResult `json:"results"`
+}
+
+type OpenTsdbLookupResult struct {
+	Metric string            `json:"metric"`
+	Tags   map[string]string `json:"tags"`
 }
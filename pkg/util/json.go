@@ -2,6 +2,7 @@ package util
 
 import (
 	"encoding/json"
+	"reflect"
 
 	"github.com/jmespath/go-jmespath"
 
@@ -110,3 +111,27 @@ func searchJSONForAttr(attributePath string, data any) (any, error) {
 	// Return the value and nil error
 	return value, nil
 }
+
+// JSONEqual compares two values by their JSON representation, e.g. a map[string]any is equal to a struct
+// with the same fields.
+func JSONEqual(a, b any) (bool, error) {
+	na, err := normalizeJSON(a)
+	if err != nil {
+		return false, err
+	}
+	nb, err := normalizeJSON(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(na, nb), nil
+}
+
+func normalizeJSON(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result any
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
@@ -153,3 +153,14 @@ func TestSearchJSONForEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONEqual(t *testing.T) {
+	t.Parallel()
+	equal, err := util.JSONEqual(map[string]any{"a": 1, "b": []string{"x"}}, map[string]any{"a": 1.0, "b": []any{"x"}})
+	require.NoError(t, err)
+	require.True(t, equal)
+
+	equal, err = util.JSONEqual(map[string]any{"a": 1}, map[string]any{"a": 2})
+	require.NoError(t, err)
+	require.False(t, equal)
+}
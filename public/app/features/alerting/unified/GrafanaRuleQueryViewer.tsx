@@ -12,6 +12,7 @@ import { CombinedRule } from 'app/types/unified-alerting';
 import { AlertDataQuery, AlertQuery } from '../../../types/unified-alerting-dto';
 import { isExpressionQuery } from '../../expressions/guards';
 import {
+  anomalyMethods,
   downsamplingTypes,
   ExpressionQuery,
   ExpressionQueryType,
@@ -225,6 +226,9 @@ function ExpressionPreview({ refId, model, evalData, isAlertCondition }: Express
       case ExpressionQueryType.threshold:
         return <ThresholdExpressionViewer model={model} />;
 
+      case ExpressionQueryType.anomaly:
+        return <AnomalyExpressionViewer model={model} />;
+
       case ExpressionQueryType.sql:
         return <Preview rawSql={model.expression || ''} datasourceType={model.datasource?.type} />;
 
@@ -426,6 +430,29 @@ const getResampleExpressionViewerStyles = (theme: GrafanaTheme2) => ({
   ...getCommonQueryStyles(theme),
 });
 
+function AnomalyExpressionViewer({ model }: { model: ExpressionQuery }) {
+  const styles = useStyles2(getResampleExpressionViewerStyles);
+
+  const { expression, method, sensitivity, window } = model;
+  const anomalyMethod = anomalyMethods.find((m) => m.value === method);
+
+  return (
+    <div className={styles.container}>
+      <div className={styles.label}>Input</div>
+      <div className={styles.value}>{expression}</div>
+
+      <div className={styles.label}>Method</div>
+      <div className={styles.value}>{anomalyMethod?.label}</div>
+
+      <div className={styles.label}>Sensitivity</div>
+      <div className={styles.value}>{sensitivity ?? 3}</div>
+
+      <div className={styles.label}>Window</div>
+      <div className={styles.value}>{window || 'Whole series'}</div>
+    </div>
+  );
+}
+
 function ThresholdExpressionViewer({ model }: { model: ExpressionQuery }) {
   const styles = useStyles2(getExpressionViewerStyles);
 
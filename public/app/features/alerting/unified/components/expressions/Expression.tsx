@@ -5,6 +5,7 @@ import { useFormContext } from 'react-hook-form';
 
 import { DataFrame, dateTimeFormat, GrafanaTheme2, isTimeSeriesFrames, LoadingState, PanelData } from '@grafana/data';
 import { Alert, AutoSizeInput, Button, clearButtonStyles, IconButton, Stack, useStyles2 } from '@grafana/ui';
+import { Anomaly } from 'app/features/expressions/components/Anomaly';
 import { ClassicConditions } from 'app/features/expressions/components/ClassicConditions';
 import { Math } from 'app/features/expressions/components/Math';
 import { Reduce } from 'app/features/expressions/components/Reduce';
@@ -114,6 +115,9 @@ export const Expression: FC<ExpressionProps> = ({
         case ExpressionQueryType.sql:
           return <SqlExpr onChange={onChangeQuery} query={query} refIds={availableRefIds} />;
 
+        case ExpressionQueryType.anomaly:
+          return <Anomaly onChange={onChangeQuery} query={query} labelWidth={'auto'} refIds={availableRefIds} />;
+
         default:
           return <>Expression not supported: {query.type}</>;
       }
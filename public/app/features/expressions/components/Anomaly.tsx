@@ -0,0 +1,62 @@
+import { ChangeEvent } from 'react';
+
+import { SelectableValue } from '@grafana/data';
+import { InlineField, InlineFieldRow, Input, Select } from '@grafana/ui';
+
+import { anomalyMethods, ExpressionQuery } from '../types';
+
+interface Props {
+  refIds: Array<SelectableValue<string>>;
+  query: ExpressionQuery;
+  labelWidth?: number | 'auto';
+  onChange: (query: ExpressionQuery) => void;
+}
+
+export const Anomaly = ({ labelWidth = 'auto', onChange, refIds, query }: Props) => {
+  const method = anomalyMethods.find((o) => o.value === query.method);
+
+  const onRefIdChange = (value: SelectableValue<string>) => {
+    onChange({ ...query, expression: value.value });
+  };
+
+  const onSelectMethod = (value: SelectableValue<string>) => {
+    onChange({ ...query, method: value.value });
+  };
+
+  const onSensitivityChange = (event: ChangeEvent<HTMLInputElement>) => {
+    const value = parseFloat(event.target.value);
+    onChange({ ...query, sensitivity: isNaN(value) ? undefined : value });
+  };
+
+  const onWindowChange = (event: ChangeEvent<HTMLInputElement>) => {
+    onChange({ ...query, window: event.target.value || undefined });
+  };
+
+  return (
+    <>
+      <InlineFieldRow>
+        <InlineField label="Input" labelWidth={labelWidth}>
+          <Select onChange={onRefIdChange} options={refIds} value={query.expression} width={20} />
+        </InlineField>
+        <InlineField label="Method">
+          <Select options={anomalyMethods} value={method} onChange={onSelectMethod} width={30} />
+        </InlineField>
+      </InlineFieldRow>
+      <InlineFieldRow>
+        <InlineField
+          label="Sensitivity"
+          labelWidth={labelWidth}
+          tooltip="Number of standard deviations between the center and the bounds of the band"
+        >
+          <Input type="number" onChange={onSensitivityChange} value={query.sensitivity} placeholder="3" width={15} />
+        </InlineField>
+        <InlineField
+          label="Window"
+          tooltip="Only use the values in the preceding window, e.g. 1d or 4w. Defaults to the whole series"
+        >
+          <Input onChange={onWindowChange} value={query.window} placeholder="whole series" width={15} />
+        </InlineField>
+      </InlineFieldRow>
+    </>
+  );
+};
@@ -2,8 +2,9 @@ import { useMemo } from 'react';
 
 import { SelectableValue } from '@grafana/data';
 import { SQLEditor } from '@grafana/experimental';
+import { InlineField, InlineFieldRow, Select } from '@grafana/ui';
 
-import { ExpressionQuery } from '../types';
+import { ExpressionQuery, sqlFormatTypes } from '../types';
 
 interface Props {
   refIds: Array<SelectableValue<string>>;
@@ -15,6 +16,7 @@ export const SqlExpr = ({ onChange, refIds, query }: Props) => {
   const vars = useMemo(() => refIds.map((v) => v.value!), [refIds]);
 
   const initialQuery = `select * from ${vars[0]} limit 1`;
+  const format = sqlFormatTypes.find((o) => o.value === query.format) ?? sqlFormatTypes[0];
 
   const onEditorChange = (expression: string) => {
     onChange({
@@ -23,5 +25,18 @@ export const SqlExpr = ({ onChange, refIds, query }: Props) => {
     });
   };
 
-  return <SQLEditor query={query.expression || initialQuery} onChange={onEditorChange}></SQLEditor>;
+  const onSelectFormat = (value: SelectableValue<string>) => {
+    onChange({ ...query, format: value.value });
+  };
+
+  return (
+    <>
+      <SQLEditor query={query.expression || initialQuery} onChange={onEditorChange}></SQLEditor>
+      <InlineFieldRow>
+        <InlineField label="Format" tooltip="Numbers returns a number for each row, with the string columns as labels">
+          <Select options={sqlFormatTypes} value={format} onChange={onSelectFormat} width={20} />
+        </InlineField>
+      </InlineFieldRow>
+    </>
+  );
 };
@@ -15,6 +15,7 @@ export enum ExpressionQueryType {
   classic = 'classic_conditions',
   threshold = 'threshold',
   sql = 'sql',
+  anomaly = 'anomaly',
 }
 
 export const getExpressionLabel = (type: ExpressionQueryType) => {
@@ -31,6 +32,8 @@ export const getExpressionLabel = (type: ExpressionQueryType) => {
       return 'Threshold';
     case ExpressionQueryType.sql:
       return 'SQL';
+    case ExpressionQueryType.anomaly:
+      return 'Anomaly detection';
   }
 };
 
@@ -63,6 +66,12 @@ export const expressionTypes: Array<SelectableValue<ExpressionQueryType>> = [
     description:
       'Takes one or more time series returned from a query or an expression and checks if any of the series match the threshold condition.',
   },
+  {
+    value: ExpressionQueryType.anomaly,
+    label: 'Anomaly detection',
+    description:
+      'Computes an expected band for each time series from its own history and checks if the last value of the series is outside of the band.',
+  },
   {
     value: ExpressionQueryType.sql,
     label: 'SQL',
@@ -83,6 +92,16 @@ export const reducerTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.sum, label: 'Sum', description: 'Get the sum of all values' },
   { value: ReducerID.count, label: 'Count', description: 'Get the number of values' },
   { value: ReducerID.last, label: 'Last', description: 'Get the last value' },
+  { value: ReducerID.first, label: 'First', description: 'Get the first value' },
+  { value: 'count_non_null', label: 'Count non-null', description: 'Get the number of non-null values' },
+  { value: 'diff', label: 'Difference', description: 'Get the difference between the last and first values' },
+  { value: ReducerID.range, label: 'Range', description: 'Get the difference between the maximum and minimum values' },
+  { value: 'stddev', label: 'StdDev', description: 'Get the standard deviation of all values' },
+  { value: ReducerID.variance, label: 'Variance', description: 'Get the variance of all values' },
+  { value: 'p50', label: 'P50', description: 'Get the 50th percentile' },
+  { value: 'p90', label: 'P90', description: 'Get the 90th percentile' },
+  { value: 'p95', label: 'P95', description: 'Get the 95th percentile' },
+  { value: 'p99', label: 'P99', description: 'Get the 99th percentile' },
 ];
 
 export enum ReducerMode {
@@ -115,6 +134,11 @@ export const downsamplingTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.max, label: 'Max', description: 'Fill with the maximum value' },
   { value: ReducerID.mean, label: 'Mean', description: 'Fill with the average value' },
   { value: ReducerID.sum, label: 'Sum', description: 'Fill with the sum of all values' },
+  { value: ReducerID.count, label: 'Count', description: 'Fill with the number of values' },
+  { value: ReducerID.median, label: 'Median', description: 'Fill with the median value' },
+  { value: 'p90', label: 'P90', description: 'Fill with the 90th percentile' },
+  { value: 'p95', label: 'P95', description: 'Fill with the 95th percentile' },
+  { value: 'p99', label: 'P99', description: 'Fill with the 99th percentile' },
 ];
 
 export const upsamplingTypes: Array<SelectableValue<string>> = [
@@ -123,6 +147,29 @@ export const upsamplingTypes: Array<SelectableValue<string>> = [
   { value: 'fillna', label: 'fillna', description: 'Fill with NaNs' },
 ];
 
+export const sqlFormatTypes: Array<SelectableValue<string>> = [
+  { value: 'table', label: 'Table', description: 'Return the result as a single table' },
+  {
+    value: 'numbers',
+    label: 'Numbers',
+    description: 'Return a number for each row, the string columns become the labels. Use this format for alert conditions',
+  },
+];
+
+export const anomalyMethods: Array<SelectableValue<string>> = [
+  {
+    value: 'mad',
+    label: 'Median absolute deviation',
+    description: 'Median and median absolute deviation of the series, robust to outliers',
+  },
+  { value: 'zscore', label: 'Z-score', description: 'Mean and standard deviation of the series' },
+  {
+    value: 'seasonal',
+    label: 'Seasonal',
+    description: 'Median and median absolute deviation of the values in the same hour of the week',
+  },
+];
+
 export const thresholdFunctions: Array<SelectableValue<EvalFunction>> = [
   { value: EvalFunction.IsAbove, label: 'Is above' },
   { value: EvalFunction.IsBelow, label: 'Is below' },
@@ -141,6 +188,9 @@ export interface ExpressionQuery extends DataQuery {
   window?: string;
   downsampler?: string;
   upsampler?: string;
+  format?: string;
+  method?: string;
+  sensitivity?: number;
   conditions?: ClassicCondition[];
   settings?: ExpressionQuerySettings;
 }
@@ -187,4 +237,12 @@ export type ReducerType =
   | 'diff_abs'
   | 'percent_diff'
   | 'percent_diff_abs'
-  | 'count_non_null';
+  | 'count_non_null'
+  | 'first'
+  | 'range'
+  | 'stddev'
+  | 'variance'
+  | 'p50'
+  | 'p90'
+  | 'p95'
+  | 'p99';
@@ -24,6 +24,14 @@ export const getDefaults = (query: ExpressionQuery) => {
       query.reducer = undefined;
       break;
 
+    case ExpressionQueryType.anomaly:
+      if (!query.method) {
+        query.method = 'mad';
+      }
+
+      query.reducer = undefined;
+      break;
+
     case ExpressionQueryType.math:
       query.expression = undefined;
       break;
@@ -19,6 +19,7 @@ export enum GrafanaQueryType {
   List = 'list',
   Read = 'read',
   Search = 'search',
+  RecordedSeries = 'recordedSeries',
 }
 
 export interface GrafanaQuery extends DataQuery {
@@ -31,6 +32,8 @@ export interface GrafanaQuery extends DataQuery {
   snapshot?: DataFrameJSON[];
   timeRegion?: TimeRegionConfig;
   file?: GrafanaQueryFile;
+  metric?: string; // for recorded series
+  matchers?: Record<string, string>;
 }
 
 export interface GrafanaQueryFile {
@@ -10,7 +10,7 @@ import {
   DataQueryRequest,
   MetricFindValue,
 } from '@grafana/data';
-import { BackendSrvRequest } from '@grafana/runtime';
+import { BackendSrvRequest, config } from '@grafana/runtime';
 import { backendSrv } from 'app/core/services/backend_srv'; // will use the version in __mocks__
 import { TemplateSrv } from 'app/features/templating/template_srv';
 
@@ -542,6 +542,23 @@ describe('graphiteDatasource', () => {
       expect(results).not.toBe(null);
     });
 
+    it('should use the resource API in backend mode', () => {
+      config.featureToggles.graphiteBackendMode = true;
+      const ds = new GraphiteDatasource(
+        { url: '/api/datasources/proxy/1', uid: 'graphite-uid', basicAuth: 'Basic token', jsonData: {} },
+        ctx.templateSrv
+      );
+      ds.metricFindQuery('app.*').then((data) => {
+        results = data;
+      });
+      config.featureToggles.graphiteBackendMode = false;
+
+      expect(requestOptions.url).toBe('/api/datasources/uid/graphite-uid/resources/metrics/find');
+      expect(requestOptions.data).toEqual('query=app.*');
+      expect(requestOptions.headers?.Authorization).toBeUndefined();
+      expect(results).not.toBe(null);
+    });
+
     it('should request expanded metrics', () => {
       ctx.ds.metricFindQuery('expand(*.servers.*)').then((data) => {
         results = data;
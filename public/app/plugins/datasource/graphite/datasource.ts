@@ -21,7 +21,7 @@ import {
   toDataFrame,
   getSearchFilterScopedVar,
 } from '@grafana/data';
-import { BackendSrvRequest, getBackendSrv } from '@grafana/runtime';
+import { BackendSrvRequest, config, getBackendSrv } from '@grafana/runtime';
 import { isVersionGtOrEq, SemVersion } from 'app/core/utils/version';
 import { getTemplateSrv, TemplateSrv } from 'app/features/templating/template_srv';
 import { getRollupNotice, getRuntimeConsolidationNotice } from 'app/plugins/datasource/graphite/meta';
@@ -927,17 +927,23 @@ export class GraphiteDatasource
       inspect?: any;
     }
   ) {
-    if (this.basicAuth || this.withCredentials) {
-      options.withCredentials = true;
-    }
-    if (this.basicAuth) {
-      options.headers = options.headers || {};
-      options.headers.Authorization = this.basicAuth;
-    }
-
-    options.url = this.url + options.url;
     options.inspect = { type: 'graphite' };
 
+    // Only the render requests go through the data proxy in backend mode, the other requests are
+    // resources of the backend plugin, which uses the authentication of the data source.
+    if (config.featureToggles.graphiteBackendMode && !options.url.startsWith('/render')) {
+      options.url = `/api/datasources/uid/${this.uid}/resources${options.url}`;
+    } else {
+      if (this.basicAuth || this.withCredentials) {
+        options.withCredentials = true;
+      }
+      if (this.basicAuth) {
+        options.headers = options.headers || {};
+        options.headers.Authorization = this.basicAuth;
+      }
+      options.url = this.url + options.url;
+    }
+
     return getBackendSrv()
       .fetch(options)
       .pipe(